@@ -55,6 +55,7 @@ func loadConfigWithAliases(configFile string, optional bool) (*config.Config, er
 	if err != nil {
 		return nil, err
 	}
+	cfg.ApplyCredentialEncryption()
 	return cfg, nil
 }
 
@@ -62,6 +63,27 @@ func loadConfigWithAliases(configFile string, optional bool) (*config.Config, er
 // It parses command-line flags, loads configuration, and starts the appropriate
 // service based on the provided flags (login, codex-login, or server mode).
 func main() {
+	// Subcommands are dispatched before flag parsing so they can define their own flags
+	// and keep stdout clean for machine-readable output.
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "usage":
+			os.Exit(cmd.DoUsageReport(os.Args[2:], DefaultConfigPath))
+		case "cache":
+			os.Exit(cmd.DoCacheCommand(os.Args[2:], DefaultConfigPath))
+		case "accounts":
+			os.Exit(cmd.DoAccountsCommand(os.Args[2:], DefaultConfigPath))
+		case "credentials":
+			os.Exit(cmd.DoCredentialsCommand(os.Args[2:], DefaultConfigPath))
+		case "bench":
+			os.Exit(cmd.DoBenchCommand(os.Args[2:], DefaultConfigPath))
+		case "doctor":
+			os.Exit(cmd.DoDoctorCommand(os.Args[2:], DefaultConfigPath))
+		case "service":
+			os.Exit(cmd.DoServiceCommand(os.Args[2:], DefaultConfigPath))
+		}
+	}
+
 	fmt.Printf("CLIProxyAPI Version: %s, Commit: %s, BuiltAt: %s\n", buildinfo.Version, buildinfo.Commit, buildinfo.BuildDate)
 
 	// Command-line flags to control the application's behavior.
@@ -428,11 +450,14 @@ func main() {
 		log.Errorf("failed to configure log output: %v", err)
 		return
 	}
+	if err = logging.ConfigurePayloadLog(cfg); err != nil {
+		log.Errorf("failed to configure payload log: %v", err)
+	}
 
 	log.Infof("CLIProxyAPI Version: %s, Commit: %s, BuiltAt: %s", buildinfo.Version, buildinfo.Commit, buildinfo.BuildDate)
 
 	// Set the log level based on the configuration.
-	util.SetLogLevel(cfg)
+	logging.SetLogLevel(cfg)
 
 	if resolvedAuthDir, errResolveAuthDir := util.ResolveAuthDir(cfg.AuthDir); errResolveAuthDir != nil {
 		log.Errorf("failed to resolve auth directory: %v", errResolveAuthDir)
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	clipexec "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkprovider "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/provider"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktr "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// echoProviderKey identifies the echo sample provider.
+const echoProviderKey = "echo"
+
+func init() {
+	sdkprovider.MustRegister(sdkprovider.Registration{
+		Key:           echoProviderKey,
+		Authenticator: func() sdkAuth.Authenticator { return echoAuthenticator{} },
+		Executor:      func(*config.Config) coreauth.ProviderExecutor { return echoExecutor{} },
+		Models: func() []*cliproxy.ModelInfo {
+			return []*cliproxy.ModelInfo{{ID: "echo-1", Object: "model", OwnedBy: echoProviderKey, Type: echoProviderKey, DisplayName: "Echo 1"}}
+		},
+	})
+}
+
+// echoAuthenticator asks for an API key and stores it in the auth file metadata.
+type echoAuthenticator struct{}
+
+func (echoAuthenticator) Provider() string { return echoProviderKey }
+
+func (echoAuthenticator) RefreshLead() *time.Duration { return nil }
+
+func (echoAuthenticator) Login(_ context.Context, _ *config.Config, opts *sdkAuth.LoginOptions) (*coreauth.Auth, error) {
+	if opts == nil || opts.Prompt == nil {
+		return nil, fmt.Errorf("echo: interactive prompt required")
+	}
+	key, err := opts.Prompt("Echo API key: ")
+	if err != nil {
+		return nil, err
+	}
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, fmt.Errorf("echo: API key is empty")
+	}
+	fileName := fmt.Sprintf("echo-%d.json", time.Now().Unix())
+	return &coreauth.Auth{
+		ID:       fileName,
+		Provider: echoProviderKey,
+		FileName: fileName,
+		Metadata: map[string]any{"type": echoProviderKey, "api_key": key},
+	}, nil
+}
+
+// echoExecutor answers every request with the last user message, in OpenAI chat format.
+type echoExecutor struct{}
+
+func (echoExecutor) Identifier() string { return echoProviderKey }
+
+func (e echoExecutor) Execute(ctx context.Context, _ *coreauth.Auth, req clipexec.Request, opts clipexec.Options) (clipexec.Response, error) {
+	to := sdktr.FormatOpenAI
+	body := sdktr.TranslateRequest(opts.SourceFormat, to, req.Model, req.Payload, false)
+	out := echoCompletion(req.Model, body)
+	var param any
+	translated := sdktr.TranslateNonStream(ctx, to, opts.SourceFormat, req.Model, opts.OriginalRequest, body, out, &param)
+	return clipexec.Response{Payload: []byte(translated)}, nil
+}
+
+func (e echoExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, req clipexec.Request, opts clipexec.Options) (*clipexec.StreamResult, error) {
+	to := sdktr.FormatOpenAI
+	body := sdktr.TranslateRequest(opts.SourceFormat, to, req.Model, req.Payload, true)
+	text := lastUserText(body)
+	chunk, _ := sjson.SetBytes([]byte(`{"id":"echo","object":"chat.completion.chunk","choices":[{"index":0,"delta":{"role":"assistant"},"finish_reason":"stop"}]}`), "choices.0.delta.content", text)
+	chunk, _ = sjson.SetBytes(chunk, "model", req.Model)
+
+	ch := make(chan clipexec.StreamChunk, 4)
+	go func() {
+		defer close(ch)
+		var param any
+		for _, line := range [][]byte{append([]byte("data: "), chunk...), []byte("data: [DONE]")} {
+			for _, segment := range sdktr.TranslateStream(ctx, to, opts.SourceFormat, req.Model, opts.OriginalRequest, body, line, &param) {
+				ch <- clipexec.StreamChunk{Payload: []byte(segment)}
+			}
+		}
+	}()
+	return &clipexec.StreamResult{Chunks: ch}, nil
+}
+
+func (echoExecutor) CountTokens(context.Context, *coreauth.Auth, clipexec.Request, clipexec.Options) (clipexec.Response, error) {
+	return clipexec.Response{}, fmt.Errorf("echo: count tokens not supported")
+}
+
+func (echoExecutor) Refresh(_ context.Context, a *coreauth.Auth) (*coreauth.Auth, error) {
+	return a, nil
+}
+
+func (echoExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("echo: raw HTTP requests not supported")
+}
+
+func echoCompletion(model string, body []byte) []byte {
+	out, _ := sjson.SetBytes([]byte(`{"id":"echo","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant"},"finish_reason":"stop"}],"usage":{"prompt_tokens":0,"completion_tokens":0,"total_tokens":0}}`), "choices.0.message.content", lastUserText(body))
+	out, _ = sjson.SetBytes(out, "model", model)
+	out, _ = sjson.SetBytes(out, "created", time.Now().Unix())
+	return out
+}
+
+func lastUserText(body []byte) string {
+	messages := gjson.GetBytes(body, "messages").Array()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Get("role").String() != "user" {
+			continue
+		}
+		content := messages[i].Get("content")
+		if content.Type == gjson.String {
+			return content.String()
+		}
+		var parts []string
+		content.ForEach(func(_, part gjson.Result) bool {
+			if part.Get("type").String() == "text" {
+				parts = append(parts, part.Get("text").String())
+			}
+			return true
+		})
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+// Package main demonstrates the provider SDK. Two sample providers register themselves
+// from init via sdk/cliproxy/provider:
+//   - echo: an API-key provider whose executor answers in OpenAI chat format locally
+//   - ollama: a base-URL provider with its own wire format and translators
+//
+// Auth files with "type": "echo" or "type": "ollama" in the auth directory are picked
+// up by the service, which binds the registered executors and models automatically.
+// The same registrations also expose the login flows:
+//
+//	go run ./examples/provider-sdk login echo
+//	go run ./examples/provider-sdk login ollama
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy"
+	sdkprovider "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/provider"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func main() {
+	cfg, err := config.LoadConfig("config.yaml")
+	if err != nil {
+		panic(err)
+	}
+
+	if len(os.Args) == 3 && os.Args[1] == "login" {
+		login(cfg, os.Args[2])
+		return
+	}
+
+	svc, err := cliproxy.NewBuilder().
+		WithConfig(cfg).
+		WithConfigPath("config.yaml").
+		Build()
+	if err != nil {
+		panic(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if errRun := svc.Run(ctx); errRun != nil && !errors.Is(errRun, context.Canceled) {
+		panic(errRun)
+	}
+}
+
+// login runs the registered authenticator of provider and stores the resulting auth file.
+func login(cfg *config.Config, provider string) {
+	reg, ok := sdkprovider.Lookup(provider)
+	if !ok || reg.Authenticator == nil {
+		fmt.Fprintf(os.Stderr, "no login flow registered for %s\n", provider)
+		os.Exit(1)
+	}
+	reader := bufio.NewReader(os.Stdin)
+	mgr := sdkAuth.NewManager(sdkAuth.GetTokenStore(), reg.Authenticator())
+	_, savedPath, err := mgr.Login(context.Background(), reg.Key, cfg, &sdkAuth.LoginOptions{
+		Prompt: func(prompt string) (string, error) {
+			fmt.Print(prompt)
+			line, errRead := reader.ReadString('\n')
+			return strings.TrimSpace(line), errRead
+		},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "login failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("saved %s credentials to %s\n", reg.Key, savedPath)
+}
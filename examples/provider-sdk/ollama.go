@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	clipexec "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkprovider "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/provider"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktr "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// ollamaProviderKey identifies the ollama sample provider.
+	ollamaProviderKey = "ollama"
+
+	// fOllama is the native /api/chat wire format of Ollama.
+	fOllama = sdktr.Format("ollama.chat")
+)
+
+func init() {
+	sdkprovider.MustRegister(sdkprovider.Registration{
+		Key:           ollamaProviderKey,
+		Authenticator: func() sdkAuth.Authenticator { return ollamaAuthenticator{} },
+		Executor:      func(*config.Config) coreauth.ProviderExecutor { return ollamaExecutor{} },
+		Models: func() []*cliproxy.ModelInfo {
+			return []*cliproxy.ModelInfo{{ID: "llama3.2", Object: "model", OwnedBy: ollamaProviderKey, Type: ollamaProviderKey, DisplayName: "Llama 3.2"}}
+		},
+		Translators: []sdkprovider.Translator{{
+			From:    sdktr.FormatOpenAI,
+			To:      fOllama,
+			Request: openAIToOllamaRequest,
+			Response: sdktr.ResponseTransform{
+				Stream:    ollamaToOpenAIStream,
+				NonStream: ollamaToOpenAINonStream,
+			},
+		}},
+	})
+}
+
+// ollamaAuthenticator stores the base URL of an Ollama server; no secret is required.
+type ollamaAuthenticator struct{}
+
+func (ollamaAuthenticator) Provider() string { return ollamaProviderKey }
+
+func (ollamaAuthenticator) RefreshLead() *time.Duration { return nil }
+
+func (ollamaAuthenticator) Login(_ context.Context, _ *config.Config, opts *sdkAuth.LoginOptions) (*coreauth.Auth, error) {
+	baseURL := "http://127.0.0.1:11434"
+	if opts != nil && opts.Prompt != nil {
+		input, err := opts.Prompt(fmt.Sprintf("Ollama base URL [%s]: ", baseURL))
+		if err != nil {
+			return nil, err
+		}
+		if input = strings.TrimSpace(input); input != "" {
+			baseURL = strings.TrimSuffix(input, "/")
+		}
+	}
+	fileName := fmt.Sprintf("ollama-%d.json", time.Now().Unix())
+	return &coreauth.Auth{
+		ID:       fileName,
+		Provider: ollamaProviderKey,
+		FileName: fileName,
+		Metadata: map[string]any{"type": ollamaProviderKey, "base_url": baseURL},
+	}, nil
+}
+
+// ollamaExecutor forwards requests to the /api/chat endpoint of an Ollama server.
+type ollamaExecutor struct{}
+
+func (ollamaExecutor) Identifier() string { return ollamaProviderKey }
+
+func (e ollamaExecutor) Execute(ctx context.Context, a *coreauth.Auth, req clipexec.Request, opts clipexec.Options) (clipexec.Response, error) {
+	body := sdktr.TranslateRequest(opts.SourceFormat, fOllama, req.Model, req.Payload, false)
+	resp, err := e.post(ctx, a, body)
+	if err != nil {
+		return clipexec.Response{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return clipexec.Response{}, err
+	}
+	var param any
+	out := sdktr.TranslateNonStream(ctx, fOllama, opts.SourceFormat, req.Model, opts.OriginalRequest, body, data, &param)
+	return clipexec.Response{Payload: []byte(out), Headers: resp.Header.Clone()}, nil
+}
+
+func (e ollamaExecutor) ExecuteStream(ctx context.Context, a *coreauth.Auth, req clipexec.Request, opts clipexec.Options) (*clipexec.StreamResult, error) {
+	body := sdktr.TranslateRequest(opts.SourceFormat, fOllama, req.Model, req.Payload, true)
+	resp, err := e.post(ctx, a, body)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan clipexec.StreamChunk)
+	go func() {
+		defer close(ch)
+		defer func() { _ = resp.Body.Close() }()
+		scanner := bufio.NewScanner(resp.Body)
+		var param any
+		for scanner.Scan() {
+			line := bytes.TrimSpace(scanner.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			for _, segment := range sdktr.TranslateStream(ctx, fOllama, opts.SourceFormat, req.Model, opts.OriginalRequest, body, bytes.Clone(line), &param) {
+				ch <- clipexec.StreamChunk{Payload: []byte(segment)}
+			}
+		}
+		if errScan := scanner.Err(); errScan != nil {
+			ch <- clipexec.StreamChunk{Err: errScan}
+		}
+	}()
+	return &clipexec.StreamResult{Headers: resp.Header.Clone(), Chunks: ch}, nil
+}
+
+func (ollamaExecutor) CountTokens(context.Context, *coreauth.Auth, clipexec.Request, clipexec.Options) (clipexec.Response, error) {
+	return clipexec.Response{}, fmt.Errorf("ollama: count tokens not supported")
+}
+
+func (ollamaExecutor) Refresh(_ context.Context, a *coreauth.Auth) (*coreauth.Auth, error) {
+	return a, nil
+}
+
+func (ollamaExecutor) HttpRequest(ctx context.Context, _ *coreauth.Auth, req *http.Request) (*http.Response, error) {
+	if req == nil {
+		return nil, fmt.Errorf("ollama executor: request is nil")
+	}
+	return http.DefaultClient.Do(req.WithContext(ctx))
+}
+
+func (ollamaExecutor) post(ctx context.Context, a *coreauth.Auth, body []byte) (*http.Response, error) {
+	baseURL := "http://127.0.0.1:11434"
+	if a != nil {
+		if v, ok := a.Metadata["base_url"].(string); ok && strings.TrimSpace(v) != "" {
+			baseURL = strings.TrimSuffix(strings.TrimSpace(v), "/")
+		}
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/api/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("ollama: status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return resp, nil
+}
+
+// openAIToOllamaRequest converts an OpenAI chat request into an Ollama /api/chat request.
+func openAIToOllamaRequest(model string, raw []byte, stream bool) []byte {
+	out := []byte(`{"messages":[]}`)
+	out, _ = sjson.SetBytes(out, "model", model)
+	out, _ = sjson.SetBytes(out, "stream", stream)
+	gjson.GetBytes(raw, "messages").ForEach(func(_, msg gjson.Result) bool {
+		content := msg.Get("content")
+		text := content.String()
+		if content.IsArray() {
+			var parts []string
+			content.ForEach(func(_, part gjson.Result) bool {
+				if part.Get("type").String() == "text" {
+					parts = append(parts, part.Get("text").String())
+				}
+				return true
+			})
+			text = strings.Join(parts, "\n")
+		}
+		entry, _ := sjson.Set(`{}`, "role", msg.Get("role").String())
+		entry, _ = sjson.Set(entry, "content", text)
+		out, _ = sjson.SetRawBytes(out, "messages.-1", []byte(entry))
+		return true
+	})
+	if v := gjson.GetBytes(raw, "temperature"); v.Exists() {
+		out, _ = sjson.SetBytes(out, "options.temperature", v.Float())
+	}
+	return out
+}
+
+// ollamaToOpenAINonStream converts an Ollama /api/chat response into an OpenAI chat completion.
+func ollamaToOpenAINonStream(_ context.Context, model string, _, _, raw []byte, _ *any) string {
+	out := `{"id":"ollama","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant"},"finish_reason":"stop"}]}`
+	out, _ = sjson.Set(out, "model", model)
+	out, _ = sjson.Set(out, "created", time.Now().Unix())
+	out, _ = sjson.Set(out, "choices.0.message.content", gjson.GetBytes(raw, "message.content").String())
+	return setOllamaUsage(out, raw)
+}
+
+// ollamaToOpenAIStream converts one NDJSON line of an Ollama stream into an OpenAI chunk.
+func ollamaToOpenAIStream(_ context.Context, model string, _, _, raw []byte, _ *any) []string {
+	if !gjson.ValidBytes(raw) {
+		return nil
+	}
+	out := `{"id":"ollama","object":"chat.completion.chunk","choices":[{"index":0,"delta":{}}]}`
+	out, _ = sjson.Set(out, "model", model)
+	out, _ = sjson.Set(out, "created", time.Now().Unix())
+	if content := gjson.GetBytes(raw, "message.content").String(); content != "" {
+		out, _ = sjson.Set(out, "choices.0.delta.content", content)
+	}
+	if gjson.GetBytes(raw, "done").Bool() {
+		out, _ = sjson.Set(out, "choices.0.finish_reason", "stop")
+		out = setOllamaUsage(out, raw)
+	}
+	return []string{out}
+}
+
+func setOllamaUsage(out string, raw []byte) string {
+	prompt := gjson.GetBytes(raw, "prompt_eval_count").Int()
+	completion := gjson.GetBytes(raw, "eval_count").Int()
+	if prompt == 0 && completion == 0 {
+		return out
+	}
+	out, _ = sjson.Set(out, "usage.prompt_tokens", prompt)
+	out, _ = sjson.Set(out, "usage.completion_tokens", completion)
+	out, _ = sjson.Set(out, "usage.total_tokens", prompt+completion)
+	return out
+}
@@ -0,0 +1,223 @@
+// Package accountbundle packages OAuth token files and provider credential config
+// fragments into a single encrypted archive so a deployment can be moved between
+// hosts without re-running every login flow.
+package accountbundle
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/secrets"
+)
+
+// CurrentVersion is the bundle format version written by Collect.
+const CurrentVersion = 1
+
+// ConfigFragment holds the provider credential sections of the configuration.
+type ConfigFragment struct {
+	GeminiKey           []config.GeminiKey                  `json:"gemini-api-key,omitempty"`
+	CodexKey            []config.CodexKey                   `json:"codex-api-key,omitempty"`
+	ClaudeKey           []config.ClaudeKey                  `json:"claude-api-key,omitempty"`
+	OpenAICompatibility []config.OpenAICompatibility        `json:"openai-compatibility,omitempty"`
+	VertexCompatAPIKey  []config.VertexCompatKey            `json:"vertex-api-key,omitempty"`
+	OAuthExcludedModels map[string][]string                 `json:"oauth-excluded-models,omitempty"`
+	OAuthModelAlias     map[string][]config.OAuthModelAlias `json:"oauth-model-alias,omitempty"`
+}
+
+// Bundle is the decrypted content of an account archive.
+type Bundle struct {
+	Version   int                        `json:"version"`
+	CreatedAt time.Time                  `json:"created_at"`
+	AuthFiles map[string]json.RawMessage `json:"auth_files"`
+	Config    ConfigFragment             `json:"config"`
+}
+
+// ImportResult reports what Restore changed.
+type ImportResult struct {
+	AuthFilesWritten []string `json:"auth_files_written"`
+	AuthFilesSkipped []string `json:"auth_files_skipped"`
+	ConfigEntries    int      `json:"config_entries_added"`
+}
+
+// Collect reads every JSON token file in authDir and the credential sections of cfg.
+func Collect(cfg *config.Config, authDir string) (*Bundle, error) {
+	bundle := &Bundle{
+		Version:   CurrentVersion,
+		CreatedAt: time.Now().UTC(),
+		AuthFiles: make(map[string]json.RawMessage),
+	}
+	if authDir != "" {
+		entries, err := os.ReadDir(authDir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read auth dir: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".json") {
+				continue
+			}
+			data, errRead := secrets.ReadFile(filepath.Join(authDir, entry.Name()))
+			if errRead != nil {
+				return nil, fmt.Errorf("failed to read auth file %s: %w", entry.Name(), errRead)
+			}
+			if !json.Valid(data) {
+				continue
+			}
+			bundle.AuthFiles[entry.Name()] = json.RawMessage(data)
+		}
+	}
+	if cfg != nil {
+		bundle.Config = ConfigFragment{
+			GeminiKey:           cfg.GeminiKey,
+			CodexKey:            cfg.CodexKey,
+			ClaudeKey:           cfg.ClaudeKey,
+			OpenAICompatibility: cfg.OpenAICompatibility,
+			VertexCompatAPIKey:  cfg.VertexCompatAPIKey,
+			OAuthExcludedModels: cfg.OAuthExcludedModels,
+			OAuthModelAlias:     cfg.OAuthModelAlias,
+		}
+	}
+	return bundle, nil
+}
+
+// Seal serialises and encrypts the bundle with passphrase.
+func Seal(bundle *Bundle, passphrase string) ([]byte, error) {
+	data, err := json.Marshal(bundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal bundle: %w", err)
+	}
+	return secrets.Encrypt(data, passphrase)
+}
+
+// Open decrypts and parses an archive produced by Seal.
+func Open(data []byte, passphrase string) (*Bundle, error) {
+	plaintext, err := secrets.Decrypt(data, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	var bundle Bundle
+	if err = json.Unmarshal(plaintext, &bundle); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+	if bundle.Version != CurrentVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d", bundle.Version)
+	}
+	return &bundle, nil
+}
+
+// Restore writes the bundle's token files into authDir and merges its config
+// fragments into cfg. Existing token files are kept unless overwrite is set; config
+// entries are appended only when no entry with the same identity already exists.
+// The caller is responsible for persisting cfg.
+func Restore(bundle *Bundle, cfg *config.Config, authDir string, overwrite bool) (ImportResult, error) {
+	var result ImportResult
+	if bundle == nil {
+		return result, nil
+	}
+	if len(bundle.AuthFiles) > 0 {
+		if authDir == "" {
+			return result, fmt.Errorf("auth dir is not configured")
+		}
+		if err := os.MkdirAll(authDir, 0o700); err != nil {
+			return result, fmt.Errorf("failed to create auth dir: %w", err)
+		}
+	}
+
+	names := make([]string, 0, len(bundle.AuthFiles))
+	for name := range bundle.AuthFiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		base := filepath.Base(name)
+		if base != name || !strings.HasSuffix(strings.ToLower(base), ".json") {
+			result.AuthFilesSkipped = append(result.AuthFilesSkipped, name)
+			continue
+		}
+		dst := filepath.Join(authDir, base)
+		if _, err := os.Stat(dst); err == nil && !overwrite {
+			result.AuthFilesSkipped = append(result.AuthFilesSkipped, base)
+			continue
+		}
+		if err := secrets.WriteFile(dst, bundle.AuthFiles[name], 0o600); err != nil {
+			return result, fmt.Errorf("failed to write auth file %s: %w", base, err)
+		}
+		result.AuthFilesWritten = append(result.AuthFilesWritten, base)
+	}
+
+	if cfg != nil {
+		result.ConfigEntries = mergeConfig(cfg, bundle.Config)
+	}
+	return result, nil
+}
+
+// mergeConfig appends fragment entries missing from cfg and returns how many were added.
+func mergeConfig(cfg *config.Config, fragment ConfigFragment) int {
+	added := 0
+	for _, key := range fragment.GeminiKey {
+		if !containsKey(cfg.GeminiKey, key.APIKey, key.BaseURL, func(k config.GeminiKey) (string, string) { return k.APIKey, k.BaseURL }) {
+			cfg.GeminiKey = append(cfg.GeminiKey, key)
+			added++
+		}
+	}
+	for _, key := range fragment.CodexKey {
+		if !containsKey(cfg.CodexKey, key.APIKey, key.BaseURL, func(k config.CodexKey) (string, string) { return k.APIKey, k.BaseURL }) {
+			cfg.CodexKey = append(cfg.CodexKey, key)
+			added++
+		}
+	}
+	for _, key := range fragment.ClaudeKey {
+		if !containsKey(cfg.ClaudeKey, key.APIKey, key.BaseURL, func(k config.ClaudeKey) (string, string) { return k.APIKey, k.BaseURL }) {
+			cfg.ClaudeKey = append(cfg.ClaudeKey, key)
+			added++
+		}
+	}
+	for _, key := range fragment.VertexCompatAPIKey {
+		if !containsKey(cfg.VertexCompatAPIKey, key.APIKey, key.BaseURL, func(k config.VertexCompatKey) (string, string) { return k.APIKey, k.BaseURL }) {
+			cfg.VertexCompatAPIKey = append(cfg.VertexCompatAPIKey, key)
+			added++
+		}
+	}
+	for _, compat := range fragment.OpenAICompatibility {
+		if !containsKey(cfg.OpenAICompatibility, compat.Name, "", func(k config.OpenAICompatibility) (string, string) { return k.Name, "" }) {
+			cfg.OpenAICompatibility = append(cfg.OpenAICompatibility, compat)
+			added++
+		}
+	}
+	for channel, models := range fragment.OAuthExcludedModels {
+		if _, ok := cfg.OAuthExcludedModels[channel]; ok {
+			continue
+		}
+		if cfg.OAuthExcludedModels == nil {
+			cfg.OAuthExcludedModels = make(map[string][]string)
+		}
+		cfg.OAuthExcludedModels[channel] = models
+		added++
+	}
+	for channel, aliases := range fragment.OAuthModelAlias {
+		if _, ok := cfg.OAuthModelAlias[channel]; ok {
+			continue
+		}
+		if cfg.OAuthModelAlias == nil {
+			cfg.OAuthModelAlias = make(map[string][]config.OAuthModelAlias)
+		}
+		cfg.OAuthModelAlias[channel] = aliases
+		added++
+	}
+	return added
+}
+
+func containsKey[T any](items []T, id, baseURL string, identity func(T) (string, string)) bool {
+	for _, item := range items {
+		itemID, itemBaseURL := identity(item)
+		if itemID == id && itemBaseURL == baseURL {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package accountbundle
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/secrets"
+)
+
+func TestSealOpenRestore(t *testing.T) {
+	srcDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(srcDir, "claude-a.json"), []byte(`{"type":"claude","email":"a@example.com"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	srcCfg := &config.Config{ClaudeKey: []config.ClaudeKey{{APIKey: "sk-1"}, {APIKey: "sk-2"}}}
+
+	bundle, err := Collect(srcCfg, srcDir)
+	if err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	sealed, err := Seal(bundle, "correct horse")
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if _, err = Open(sealed, "wrong"); err != secrets.ErrInvalidPassphrase {
+		t.Fatalf("expected ErrInvalidPassphrase, got %v", err)
+	}
+	opened, err := Open(sealed, "correct horse")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	dstDir := t.TempDir()
+	dstCfg := &config.Config{ClaudeKey: []config.ClaudeKey{{APIKey: "sk-1"}}}
+	result, err := Restore(opened, dstCfg, dstDir, false)
+	if err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+	if len(result.AuthFilesWritten) != 1 || result.ConfigEntries != 1 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if len(dstCfg.ClaudeKey) != 2 {
+		t.Errorf("expected 2 claude keys after merge, got %d", len(dstCfg.ClaudeKey))
+	}
+	if _, err = os.Stat(filepath.Join(dstDir, "claude-a.json")); err != nil {
+		t.Errorf("auth file not restored: %v", err)
+	}
+
+	result, err = Restore(opened, dstCfg, dstDir, false)
+	if err != nil {
+		t.Fatalf("second Restore: %v", err)
+	}
+	if len(result.AuthFilesSkipped) != 1 || result.ConfigEntries != 0 {
+		t.Errorf("expected existing entries to be skipped, got %+v", result)
+	}
+}
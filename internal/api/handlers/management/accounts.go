@@ -0,0 +1,99 @@
+package management
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/accountbundle"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/secrets"
+)
+
+// bundlePassphraseHeader carries the archive passphrase for account imports.
+const bundlePassphraseHeader = "X-Bundle-Passphrase"
+
+// ExportAccounts returns an encrypted archive of all auth files and provider credential config.
+//
+// POST /v0/management/accounts/export  {"passphrase": "..."}
+func (h *Handler) ExportAccounts(c *gin.Context) {
+	var body struct {
+		Passphrase string `json:"passphrase"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Passphrase) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "passphrase is required"})
+		return
+	}
+
+	h.mu.Lock()
+	bundle, err := accountbundle.Collect(h.cfg, h.cfg.AuthDir)
+	h.mu.Unlock()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	data, err := accountbundle.Seal(bundle, body.Passphrase)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Header("Content-Disposition", "attachment; filename=\"cliproxy-accounts.bundle\"")
+	c.Data(http.StatusOK, "application/octet-stream", data)
+}
+
+// ImportAccounts restores an encrypted account archive.
+// The passphrase is read from the X-Bundle-Passphrase header; ?overwrite=true replaces
+// existing auth files with the same name.
+//
+// POST /v0/management/accounts/import
+func (h *Handler) ImportAccounts(c *gin.Context) {
+	passphrase := strings.TrimSpace(c.GetHeader(bundlePassphraseHeader))
+	if passphrase == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "passphrase is required"})
+		return
+	}
+	data, err := c.GetRawData()
+	if err != nil || len(data) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		return
+	}
+	bundle, err := accountbundle.Open(data, passphrase)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, secrets.ErrInvalidPassphrase) {
+			status = http.StatusUnauthorized
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	overwrite := c.Query("overwrite") == "true" || c.Query("overwrite") == "1"
+
+	h.mu.Lock()
+	result, err := accountbundle.Restore(bundle, h.cfg, h.cfg.AuthDir, overwrite)
+	if err == nil && result.ConfigEntries > 0 {
+		err = config.SaveConfigPreserveComments(h.configFilePath, h.cfg)
+	}
+	h.mu.Unlock()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	for _, name := range result.AuthFilesWritten {
+		full := filepath.Join(h.cfg.AuthDir, name)
+		if !filepath.IsAbs(full) {
+			if abs, errAbs := filepath.Abs(full); errAbs == nil {
+				full = abs
+			}
+		}
+		if errReg := h.registerAuthFromFile(ctx, full, bundle.AuthFiles[name]); errReg != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to register %s: %v", name, errReg)})
+			return
+		}
+	}
+	c.JSON(http.StatusOK, result)
+}
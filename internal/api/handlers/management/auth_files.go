@@ -13,7 +13,6 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -30,6 +29,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/secrets"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
@@ -263,12 +263,49 @@ func (h *Handler) ListAuthFiles(c *gin.Context) {
 			files = append(files, entry)
 		}
 	}
-	sort.Slice(files, func(i, j int) bool {
-		nameI, _ := files[i]["name"].(string)
-		nameJ, _ := files[j]["name"].(string)
-		return strings.ToLower(nameI) < strings.ToLower(nameJ)
+	respondAuthFileList(c, files)
+}
+
+// respondAuthFileList filters, sorts and pages auth file entries. Besides the shared list
+// parameters (limit, cursor, since, until, fields) it accepts type and status filters;
+// the time range applies to modtime.
+func respondAuthFileList(c *gin.Context, files []gin.H) {
+	params, err := parseListParams(c)
+	if err != nil {
+		c.JSON(400, gin.H{"error": err.Error()})
+		return
+	}
+	typeFilter := strings.TrimSpace(c.Query("type"))
+	statusFilter := strings.TrimSpace(c.Query("status"))
+	filtered := make([]gin.H, 0, len(files))
+	for _, entry := range files {
+		if typeFilter != "" && !strings.EqualFold(fmt.Sprint(entry["type"]), typeFilter) {
+			continue
+		}
+		if statusFilter != "" && !strings.EqualFold(fmt.Sprint(entry["status"]), statusFilter) {
+			continue
+		}
+		if modTime, ok := entry["modtime"].(time.Time); ok && !params.InRange(modTime) {
+			continue
+		} else if !ok && (!params.Since.IsZero() || !params.Until.IsZero()) {
+			continue
+		}
+		filtered = append(filtered, entry)
+	}
+	page := paginate(filtered, params, func(entry gin.H) string {
+		name, _ := entry["name"].(string)
+		id, _ := entry["id"].(string)
+		return strings.ToLower(name) + "\x00" + id
 	})
-	c.JSON(200, gin.H{"files": files})
+	out := make([]gin.H, 0, len(page.Items))
+	for _, entry := range page.Items {
+		out = append(out, selectFields(entry, params.Fields))
+	}
+	body := gin.H{"files": out}
+	if params.Paginated() {
+		body = writeListPage(body, page)
+	}
+	c.JSON(200, body)
 }
 
 // GetAuthFileModels returns the models supported by a specific auth file
@@ -340,7 +377,7 @@ func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
 
 			// Read file to get type field
 			full := filepath.Join(h.cfg.AuthDir, name)
-			if data, errRead := os.ReadFile(full); errRead == nil {
+			if data, errRead := secrets.ReadFile(full); errRead == nil {
 				typeValue := gjson.GetBytes(data, "type").String()
 				emailValue := gjson.GetBytes(data, "email").String()
 				fileData["type"] = typeValue
@@ -350,7 +387,7 @@ func (h *Handler) listAuthFilesFromDisk(c *gin.Context) {
 			files = append(files, fileData)
 		}
 	}
-	c.JSON(200, gin.H{"files": files})
+	respondAuthFileList(c, files)
 }
 
 func (h *Handler) buildAuthFileEntry(auth *coreauth.Auth) gin.H {
@@ -502,6 +539,23 @@ func isRuntimeOnlyAuth(auth *coreauth.Auth) bool {
 	return strings.EqualFold(strings.TrimSpace(auth.Attributes["runtime_only"]), "true")
 }
 
+// RescanAuthFiles re-reads the auth directory and applies added, changed and removed
+// token files to the credential pool. Sending SIGHUP to the process does the same.
+//
+// POST /v0/management/auth-files/rescan
+func (h *Handler) RescanAuthFiles(c *gin.Context) {
+	if h.authRescan == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "auth rescan unavailable"})
+		return
+	}
+	result, err := h.authRescan()
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
 // Download single auth file by name
 func (h *Handler) DownloadAuthFile(c *gin.Context) {
 	name := c.Query("name")
@@ -514,7 +568,7 @@ func (h *Handler) DownloadAuthFile(c *gin.Context) {
 		return
 	}
 	full := filepath.Join(h.cfg.AuthDir, name)
-	data, err := os.ReadFile(full)
+	data, err := secrets.ReadFile(full)
 	if err != nil {
 		if os.IsNotExist(err) {
 			c.JSON(404, gin.H{"error": "file not found"})
@@ -550,7 +604,11 @@ func (h *Handler) UploadAuthFile(c *gin.Context) {
 			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to save file: %v", errSave)})
 			return
 		}
-		data, errRead := os.ReadFile(dst)
+		if errSeal := secrets.SealFileInPlace(dst); errSeal != nil {
+			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to encrypt file: %v", errSeal)})
+			return
+		}
+		data, errRead := secrets.ReadFile(dst)
 		if errRead != nil {
 			c.JSON(500, gin.H{"error": fmt.Sprintf("failed to read saved file: %v", errRead)})
 			return
@@ -582,7 +640,7 @@ func (h *Handler) UploadAuthFile(c *gin.Context) {
 			dst = abs
 		}
 	}
-	if errWrite := os.WriteFile(dst, data, 0o600); errWrite != nil {
+	if errWrite := secrets.WriteFile(dst, data, 0o600); errWrite != nil {
 		c.JSON(500, gin.H{"error": fmt.Sprintf("failed to write file: %v", errWrite)})
 		return
 	}
@@ -687,10 +745,14 @@ func (h *Handler) registerAuthFromFile(ctx context.Context, path string, data []
 	}
 	if data == nil {
 		var err error
-		data, err = os.ReadFile(path)
+		data, err = secrets.ReadFile(path)
 		if err != nil {
 			return fmt.Errorf("failed to read auth file: %w", err)
 		}
+	} else if plain, err := secrets.OpenAtRest(data); err != nil {
+		return fmt.Errorf("failed to decrypt auth file: %w", err)
+	} else {
+		data = plain
 	}
 	metadata := make(map[string]any)
 	if err := json.Unmarshal(data, &metadata); err != nil {
@@ -1281,7 +1343,7 @@ func (h *Handler) RequestGeminiCLIToken(c *gin.Context) {
 				SetOAuthSessionError(state, "Google One auto-discovery returned empty project ID")
 				return
 			}
-			isChecked, errCheck := checkCloudAPIIsEnabled(ctx, gemClient, ts.ProjectID)
+			isChecked, errCheck := geminiCloudAPIEnabled(ctx, gemClient, &ts)
 			if errCheck != nil {
 				log.Errorf("Failed to verify Cloud AI API status: %v", errCheck)
 				SetOAuthSessionError(state, "Failed to verify Cloud AI API status")
@@ -1306,7 +1368,7 @@ func (h *Handler) RequestGeminiCLIToken(c *gin.Context) {
 				return
 			}
 
-			isChecked, errCheck := checkCloudAPIIsEnabled(ctx, gemClient, ts.ProjectID)
+			isChecked, errCheck := geminiCloudAPIEnabled(ctx, gemClient, &ts)
 			if errCheck != nil {
 				log.Errorf("Failed to verify Cloud AI API status: %v", errCheck)
 				SetOAuthSessionError(state, "Failed to verify Cloud AI API status")
@@ -1326,6 +1388,9 @@ func (h *Handler) RequestGeminiCLIToken(c *gin.Context) {
 			"auto":       ts.Auto,
 			"checked":    ts.Checked,
 		}
+		if ts.Tier != "" {
+			recordMetadata["tier"] = ts.Tier
+		}
 
 		fileName := geminiAuth.CredentialFileName(ts.Email, ts.ProjectID, true)
 		record := &coreauth.Auth{
@@ -2011,6 +2076,11 @@ func ensureGeminiProjectAndOnboard(ctx context.Context, httpClient *http.Client,
 
 	trimmedRequest := strings.TrimSpace(requestedProject)
 	if trimmedRequest == "" {
+		// An account already onboarded to Code Assist keeps its project.
+		if detectOnboardedProject(ctx, httpClient, storage) {
+			storage.Auto = true
+			return nil
+		}
 		projects, errProjects := fetchGCPProjects(ctx, httpClient)
 		if errProjects != nil {
 			return fmt.Errorf("fetch project list: %w", errProjects)
@@ -2072,6 +2142,15 @@ func onboardAllGeminiProjects(ctx context.Context, httpClient *http.Client, stor
 	return activated, nil
 }
 
+// geminiCloudAPIEnabled verifies the Cloud AI API of the credential's project. Projects of
+// a managed tier are provisioned by Google with the API enabled and are not checked.
+func geminiCloudAPIEnabled(ctx context.Context, httpClient *http.Client, storage *geminiAuth.GeminiTokenStorage) (bool, error) {
+	if geminiAuth.IsManagedProjectTier(storage.Tier) {
+		return true, nil
+	}
+	return checkCloudAPIIsEnabled(ctx, httpClient, storage.ProjectID)
+}
+
 func ensureGeminiProjectsEnabled(ctx context.Context, httpClient *http.Client, projectIDs []string) error {
 	for _, pid := range projectIDs {
 		trimmed := strings.TrimSpace(pid)
@@ -2111,33 +2190,17 @@ func performGeminiCLISetup(ctx context.Context, httpClient *http.Client, storage
 		return fmt.Errorf("load code assist: %w", errLoad)
 	}
 
-	tierID := "legacy-tier"
-	if tiers, okTiers := loadResp["allowedTiers"].([]any); okTiers {
-		for _, rawTier := range tiers {
-			tier, okTier := rawTier.(map[string]any)
-			if !okTier {
-				continue
-			}
-			if isDefault, okDefault := tier["isDefault"].(bool); okDefault && isDefault {
-				if id, okID := tier["id"].(string); okID && strings.TrimSpace(id) != "" {
-					tierID = strings.TrimSpace(id)
-					break
-				}
-			}
-		}
-	}
+	tierID, onboarded := geminiAuth.CodeAssistTier(loadResp)
+	storage.Tier = tierID
 
 	projectID := trimmedRequest
 	if projectID == "" {
-		if id, okProject := loadResp["cloudaicompanionProject"].(string); okProject {
-			projectID = strings.TrimSpace(id)
-		}
-		if projectID == "" {
-			if projectMap, okProject := loadResp["cloudaicompanionProject"].(map[string]any); okProject {
-				if id, okID := projectMap["id"].(string); okID {
-					projectID = strings.TrimSpace(id)
-				}
-			}
+		projectID = geminiAuth.CodeAssistProject(loadResp)
+		if onboarded && projectID != "" {
+			// The account is already onboarded to this project; nothing to set up.
+			storage.ProjectID = projectID
+			log.Infof("Gemini Code Assist: using project %s (%s)", projectID, tierID)
+			return nil
 		}
 	}
 	if projectID == "" {
@@ -2159,14 +2222,7 @@ func performGeminiCLISetup(ctx context.Context, httpClient *http.Client, storage
 
 			if done, okDone := onboardResp["done"].(bool); okDone && done {
 				if resp, okResp := onboardResp["response"].(map[string]any); okResp {
-					switch v := resp["cloudaicompanionProject"].(type) {
-					case string:
-						projectID = strings.TrimSpace(v)
-					case map[string]any:
-						if id, okID := v["id"].(string); okID {
-							projectID = strings.TrimSpace(id)
-						}
-					}
+					projectID = geminiAuth.CodeAssistProject(resp)
 				}
 				break
 			}
@@ -2183,6 +2239,11 @@ func performGeminiCLISetup(ctx context.Context, httpClient *http.Client, storage
 			return &projectSelectionRequiredError{}
 		}
 		log.Infof("Auto-discovered project ID via onboarding: %s", projectID)
+		if geminiAuth.IsManagedProjectTier(tierID) {
+			// Google manages the project of this tier; onboarding is already complete.
+			storage.ProjectID = projectID
+			return nil
+		}
 	}
 
 	onboardReqBody := map[string]any{
@@ -2202,14 +2263,7 @@ func performGeminiCLISetup(ctx context.Context, httpClient *http.Client, storage
 		if done, okDone := onboardResp["done"].(bool); okDone && done {
 			responseProjectID := ""
 			if resp, okResp := onboardResp["response"].(map[string]any); okResp {
-				switch projectValue := resp["cloudaicompanionProject"].(type) {
-				case map[string]any:
-					if id, okID := projectValue["id"].(string); okID {
-						responseProjectID = strings.TrimSpace(id)
-					}
-				case string:
-					responseProjectID = strings.TrimSpace(projectValue)
-				}
+				responseProjectID = geminiAuth.CodeAssistProject(resp)
 			}
 
 			finalProjectID := projectID
@@ -2250,6 +2304,33 @@ func performGeminiCLISetup(ctx context.Context, httpClient *http.Client, storage
 	}
 }
 
+// detectOnboardedProject asks loadCodeAssist whether the account is already onboarded
+// to Gemini Code Assist and, if so, stores its project and tier. Accounts set up through
+// the Gemini CLI or a Google One subscription are detected this way without prompting
+// for a project.
+func detectOnboardedProject(ctx context.Context, httpClient *http.Client, storage *geminiAuth.GeminiTokenStorage) bool {
+	loadReqBody := map[string]any{
+		"metadata": map[string]string{
+			"ideType":    "IDE_UNSPECIFIED",
+			"platform":   "PLATFORM_UNSPECIFIED",
+			"pluginType": "GEMINI",
+		},
+	}
+	var loadResp map[string]any
+	if errLoad := callGeminiCLI(ctx, httpClient, "loadCodeAssist", loadReqBody, &loadResp); errLoad != nil {
+		log.Debugf("Gemini Code Assist detection failed: %v", errLoad)
+		return false
+	}
+	tierID, onboarded := geminiAuth.CodeAssistTier(loadResp)
+	projectID := geminiAuth.CodeAssistProject(loadResp)
+	if !onboarded || projectID == "" {
+		return false
+	}
+	storage.ProjectID = projectID
+	storage.Tier = tierID
+	return true
+}
+
 func callGeminiCLI(ctx context.Context, httpClient *http.Client, endpoint string, body any, result any) error {
 	endPointURL := fmt.Sprintf("%s/%s:%s", geminiCLIEndpoint, geminiCLIVersion, endpoint)
 	if strings.HasPrefix(endpoint, "operations/") {
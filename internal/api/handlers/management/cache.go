@@ -0,0 +1,84 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+)
+
+// GetCacheStats returns entry counts for the signature and thinking caches.
+//
+// GET /v0/management/cache/stats
+func (h *Handler) GetCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, cache.Stats())
+}
+
+// DeleteCache clears cache entries.
+// Query "type" selects "signature", "thinking", "responses" or "all" (default); "model"
+// limits signature clearing to one model group and "id" limits thinking clearing to one
+// entry. "responses" purges the stores kept per downstream API key (stored responses,
+// chat completions and async operations), filtered by "user" and "before".
+//
+// DELETE /v0/management/cache
+func (h *Handler) DeleteCache(c *gin.Context) {
+	cacheType := strings.ToLower(strings.TrimSpace(c.DefaultQuery("type", "all")))
+	switch cacheType {
+	case "responses":
+		p, err := parsePurgeParams(c)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "removed": cache.PurgeUserData(p.User, p.Before)})
+		return
+	case "all":
+		cache.ClearSignatureCache(c.Query("model"))
+		cache.ClearThinkingCache(c.Query("id"))
+	case "signature":
+		cache.ClearSignatureCache(c.Query("model"))
+	case "thinking":
+		cache.ClearThinkingCache(c.Query("id"))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid cache type"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "stats": cache.Stats()})
+}
+
+// ExportCache returns a snapshot of all non-expired cache entries.
+//
+// GET /v0/management/cache/export
+func (h *Handler) ExportCache(c *gin.Context) {
+	c.JSON(http.StatusOK, cache.ExportSnapshot())
+}
+
+// ImportCache merges a previously exported cache snapshot into memory.
+//
+// POST /v0/management/cache/import
+func (h *Handler) ImportCache(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		return
+	}
+
+	var snapshot cache.Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+	if snapshot.Version != 0 && snapshot.Version != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported version"})
+		return
+	}
+
+	result := cache.ImportSnapshot(snapshot)
+	c.JSON(http.StatusOK, gin.H{
+		"imported": result.Imported,
+		"skipped":  result.Skipped,
+		"stats":    cache.Stats(),
+	})
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	"golang.org/x/crypto/bcrypt"
@@ -47,6 +48,9 @@ type Handler struct {
 	allowRemoteOverride bool
 	envSecret           string
 	logDir              string
+	sessions            *sessionStore
+	authRescan          func() (watcher.AuthRescanResult, error)
+	routeLister         func() gin.RoutesInfo
 }
 
 // NewHandler creates a new management handler instance.
@@ -63,19 +67,22 @@ func NewHandler(cfg *config.Config, configFilePath string, manager *coreauth.Man
 		tokenStore:          sdkAuth.GetTokenStore(),
 		allowRemoteOverride: envSecret != "",
 		envSecret:           envSecret,
+		sessions:            newSessionStore(),
 	}
 	h.startAttemptCleanup()
 	return h
 }
 
 // startAttemptCleanup launches a background goroutine that periodically
-// removes stale IP entries from failedAttempts to prevent memory leaks.
+// removes stale IP entries from failedAttempts and expired login sessions
+// to prevent memory leaks.
 func (h *Handler) startAttemptCleanup() {
 	go func() {
 		ticker := time.NewTicker(attemptCleanupInterval)
 		defer ticker.Stop()
 		for range ticker.C {
 			h.purgeStaleAttempts()
+			h.sessions.purgeExpired(time.Now())
 		}
 	}()
 }
@@ -109,6 +116,9 @@ func (h *Handler) SetConfig(cfg *config.Config) { h.cfg = cfg }
 // SetAuthManager updates the auth manager reference used by management endpoints.
 func (h *Handler) SetAuthManager(manager *coreauth.Manager) { h.authManager = manager }
 
+// SetAuthRescanner sets the function used to rescan the auth directory.
+func (h *Handler) SetAuthRescanner(fn func() (watcher.AuthRescanResult, error)) { h.authRescan = fn }
+
 // SetUsageStatistics allows replacing the usage statistics reference.
 func (h *Handler) SetUsageStatistics(stats *usage.RequestStatistics) { h.usageStats = stats }
 
@@ -129,74 +139,26 @@ func (h *Handler) SetLogDirectory(dir string) {
 }
 
 // Middleware enforces access control for management endpoints.
-// All requests (local and remote) require a valid management key.
+// All requests (local and remote) require a valid management key or login session.
 // Additionally, remote access requires allow-remote-management=true.
 func (h *Handler) Middleware() gin.HandlerFunc {
-	const maxFailures = 5
-	const banDuration = 30 * time.Minute
-
 	return func(c *gin.Context) {
 		c.Header("X-CPA-VERSION", buildinfo.Version)
 		c.Header("X-CPA-COMMIT", buildinfo.Commit)
 		c.Header("X-CPA-BUILD-DATE", buildinfo.BuildDate)
 
-		clientIP := c.ClientIP()
-		localClient := clientIP == "127.0.0.1" || clientIP == "::1"
+		localClient, fail, ok := h.guardClient(c)
+		if !ok {
+			return
+		}
 		cfg := h.cfg
-		var (
-			allowRemote bool
-			secretHash  string
-		)
+		var remote config.RemoteManagement
 		if cfg != nil {
-			allowRemote = cfg.RemoteManagement.AllowRemote
-			secretHash = cfg.RemoteManagement.SecretKey
-		}
-		if h.allowRemoteOverride {
-			allowRemote = true
+			remote = cfg.RemoteManagement
 		}
+		secretHash := remote.SecretKey
 		envSecret := h.envSecret
-
-		fail := func() {}
-		if !localClient {
-			h.attemptsMu.Lock()
-			ai := h.failedAttempts[clientIP]
-			if ai != nil {
-				if !ai.blockedUntil.IsZero() {
-					if time.Now().Before(ai.blockedUntil) {
-						remaining := time.Until(ai.blockedUntil).Round(time.Second)
-						h.attemptsMu.Unlock()
-						c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("IP banned due to too many failed attempts. Try again in %s", remaining)})
-						return
-					}
-					// Ban expired, reset state
-					ai.blockedUntil = time.Time{}
-					ai.count = 0
-				}
-			}
-			h.attemptsMu.Unlock()
-
-			if !allowRemote {
-				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "remote management disabled"})
-				return
-			}
-
-			fail = func() {
-				h.attemptsMu.Lock()
-				aip := h.failedAttempts[clientIP]
-				if aip == nil {
-					aip = &attemptInfo{}
-					h.failedAttempts[clientIP] = aip
-				}
-				aip.count++
-				aip.lastActivity = time.Now()
-				if aip.count >= maxFailures {
-					aip.blockedUntil = time.Now().Add(banDuration)
-					aip.count = 0
-				}
-				h.attemptsMu.Unlock()
-			}
-		}
-		if secretHash == "" && envSecret == "" {
+		if !remote.HasCredentials() && envSecret == "" {
 			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "remote management key not set"})
 			return
 		}
@@ -215,6 +177,19 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 			provided = c.GetHeader("X-Management-Key")
 		}
 
+		// Login sessions are presented as a bearer token or, for the control panel, a cookie.
+		fromCookie := false
+		if provided == "" {
+			if cookie, errCookie := c.Cookie(sessionCookieName); errCookie == nil && cookie != "" {
+				provided = cookie
+				fromCookie = true
+			}
+		}
+		if strings.HasPrefix(provided, sessionTokenPrefix) {
+			h.authenticateSession(c, provided, fromCookie, localClient, fail)
+			return
+		}
+
 		if provided == "" {
 			if !localClient {
 				fail()
@@ -226,6 +201,7 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 		if localClient {
 			if lp := h.localPassword; lp != "" {
 				if subtle.ConstantTimeCompare([]byte(provided), []byte(lp)) == 1 {
+					c.Set(managementRoleContextKey, config.ManagementRoleAdmin)
 					c.Next()
 					return
 				}
@@ -234,13 +210,23 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 
 		if envSecret != "" && subtle.ConstantTimeCompare([]byte(provided), []byte(envSecret)) == 1 {
 			if !localClient {
-				h.attemptsMu.Lock()
-				if ai := h.failedAttempts[clientIP]; ai != nil {
-					ai.count = 0
-					ai.blockedUntil = time.Time{}
-				}
-				h.attemptsMu.Unlock()
+				h.resetFailedAttempts(c.ClientIP())
+			}
+			c.Set(managementRoleContextKey, config.ManagementRoleAdmin)
+			c.Next()
+			return
+		}
+
+		// Read-only keys authenticate as viewers, for monitoring systems.
+		if matchReadOnlyKey(remote.ReadOnlyKeys, provided) {
+			if !localClient {
+				h.resetFailedAttempts(c.ClientIP())
+			}
+			if !viewerAllowed(c.Request.Method, c.FullPath()) {
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin role required"})
+				return
 			}
+			c.Set(managementRoleContextKey, config.ManagementRoleViewer)
 			c.Next()
 			return
 		}
@@ -254,18 +240,85 @@ func (h *Handler) Middleware() gin.HandlerFunc {
 		}
 
 		if !localClient {
-			h.attemptsMu.Lock()
-			if ai := h.failedAttempts[clientIP]; ai != nil {
-				ai.count = 0
-				ai.blockedUntil = time.Time{}
-			}
-			h.attemptsMu.Unlock()
+			h.resetFailedAttempts(c.ClientIP())
 		}
 
+		c.Set(managementRoleContextKey, config.ManagementRoleAdmin)
 		c.Next()
 	}
 }
 
+// guardClient rejects banned clients and remote clients when remote management is
+// disabled. It returns whether the client is local and a callback that records a failed
+// authentication attempt (a no-op for local clients).
+func (h *Handler) guardClient(c *gin.Context) (localClient bool, fail func(), ok bool) {
+	const maxFailures = 5
+	const banDuration = 30 * time.Minute
+
+	clientIP := c.ClientIP()
+	localClient = clientIP == "127.0.0.1" || clientIP == "::1"
+	fail = func() {}
+	if localClient {
+		return true, fail, true
+	}
+
+	allowRemote := false
+	if h.cfg != nil {
+		allowRemote = h.cfg.RemoteManagement.AllowRemote
+	}
+	if h.allowRemoteOverride {
+		allowRemote = true
+	}
+
+	h.attemptsMu.Lock()
+	ai := h.failedAttempts[clientIP]
+	if ai != nil {
+		if !ai.blockedUntil.IsZero() {
+			if time.Now().Before(ai.blockedUntil) {
+				remaining := time.Until(ai.blockedUntil).Round(time.Second)
+				h.attemptsMu.Unlock()
+				c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("IP banned due to too many failed attempts. Try again in %s", remaining)})
+				return false, fail, false
+			}
+			// Ban expired, reset state
+			ai.blockedUntil = time.Time{}
+			ai.count = 0
+		}
+	}
+	h.attemptsMu.Unlock()
+
+	if !allowRemote {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "remote management disabled"})
+		return false, fail, false
+	}
+
+	fail = func() {
+		h.attemptsMu.Lock()
+		aip := h.failedAttempts[clientIP]
+		if aip == nil {
+			aip = &attemptInfo{}
+			h.failedAttempts[clientIP] = aip
+		}
+		aip.count++
+		aip.lastActivity = time.Now()
+		if aip.count >= maxFailures {
+			aip.blockedUntil = time.Now().Add(banDuration)
+			aip.count = 0
+		}
+		h.attemptsMu.Unlock()
+	}
+	return false, fail, true
+}
+
+func (h *Handler) resetFailedAttempts(clientIP string) {
+	h.attemptsMu.Lock()
+	if ai := h.failedAttempts[clientIP]; ai != nil {
+		ai.count = 0
+		ai.blockedUntil = time.Time{}
+	}
+	h.attemptsMu.Unlock()
+}
+
 // persist saves the current in-memory config to disk.
 func (h *Handler) persist(c *gin.Context) bool {
 	h.mu.Lock()
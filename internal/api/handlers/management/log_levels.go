@@ -0,0 +1,89 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+)
+
+// maxPayloadDumpCount bounds a single payload dump request.
+const maxPayloadDumpCount = 100
+
+// GetLogLevels returns the base log level, per-module overrides and pending payload dumps.
+//
+// GET /v0/management/log-levels
+func (h *Handler) GetLogLevels(c *gin.Context) {
+	c.JSON(http.StatusOK, logging.ModuleLevels())
+}
+
+// PutLogLevel overrides the log level of one module until restart. An empty level or
+// "default" restores the base level.
+//
+// PUT /v0/management/log-levels
+// Body: {"module": "executor", "level": "debug"}
+func (h *Handler) PutLogLevel(c *gin.Context) {
+	var body struct {
+		Module string `json:"module"`
+		Level  string `json:"level"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if err := logging.SetModuleLevel(body.Module, body.Level); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, logging.ModuleLevels())
+}
+
+// PostPayloadDump logs the next count translated upstream payloads of a client API key
+// at debug level. A count of 0 cancels a pending dump.
+//
+// POST /v0/management/log-levels/payload-dump
+// Body: {"api-key": "sk-...", "count": 5}
+func (h *Handler) PostPayloadDump(c *gin.Context) {
+	var body struct {
+		APIKey string `json:"api-key"`
+		Count  *int   `json:"count"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.APIKey) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "api-key is required"})
+		return
+	}
+	count := 1
+	if body.Count != nil {
+		count = *body.Count
+	}
+	if count < 0 || count > maxPayloadDumpCount {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "count must be between 0 and 100"})
+		return
+	}
+	logging.ArmPayloadDump(body.APIKey, count)
+	c.JSON(http.StatusOK, logging.ModuleLevels())
+}
+
+// GetDebugErrors returns the most recent upstream 4xx responses, newest first, each with
+// the redacted translated request that caused it. Samples are kept regardless of
+// request-log so rejected payloads can be inspected after the fact.
+//
+// GET /v0/management/debug/errors
+func (h *Handler) GetDebugErrors(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"errors": logging.ErrorSamples()})
+}
+
+// DeleteDebugErrors clears the buffered upstream 4xx samples. Query "user" limits it to
+// the samples of one downstream API key and "before" to samples recorded before a time.
+//
+// DELETE /v0/management/debug/errors
+func (h *Handler) DeleteDebugErrors(c *gin.Context) {
+	p, err := parsePurgeParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	removed := logging.PurgeErrorSamples(p.User, p.Before)
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "removed": removed})
+}
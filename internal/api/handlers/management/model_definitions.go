@@ -31,3 +31,9 @@ func (h *Handler) GetStaticModelDefinitions(c *gin.Context) {
 		"models":  models,
 	})
 }
+
+// GetModelPins returns the concrete versions "-latest" model targets are pinned to,
+// including the previous version when a pin drifted.
+func (h *Handler) GetModelPins(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"model-pins": registry.ModelPins()})
+}
@@ -0,0 +1,376 @@
+package management
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
+)
+
+// paramDoc documents one query parameter.
+type paramDoc struct {
+	Name        string
+	Type        string // OpenAPI scalar type; defaults to string
+	Description string
+}
+
+// operationDoc annotates a management handler. Routes are discovered from the gin engine
+// and matched to annotations by handler method name, so a handler without an entry is
+// still listed with a summary derived from its name.
+type operationDoc struct {
+	Summary     string
+	Description string
+	Query       []paramDoc
+	// Body describes the JSON request body; empty means no body is documented.
+	Body string
+	// Produces overrides the response media type (application/json by default).
+	Produces string
+}
+
+// listQueryDocs are the shared pagination parameters accepted by list endpoints.
+var listQueryDocs = []paramDoc{
+	{Name: "limit", Type: "integer", Description: "Page size (1-1000). Omit with cursor to return every item."},
+	{Name: "cursor", Description: "Opaque next_cursor value from the previous page."},
+	{Name: "since", Description: "Inclusive lower time bound: RFC3339, Unix seconds or a duration such as 24h."},
+	{Name: "until", Description: "Exclusive upper time bound, same formats as since."},
+	{Name: "fields", Description: "Comma-separated list of fields to keep in every item."},
+}
+
+// purgeQueryDocs are the filters accepted by the purge endpoints.
+var purgeQueryDocs = []paramDoc{
+	{Name: "user", Description: "Downstream client API key whose data is removed."},
+	{Name: "before", Description: "Only data recorded before this time: RFC3339, Unix seconds or a duration such as 720h."},
+}
+
+// deleteCacheQueryDocs are the parameters of DELETE /cache.
+var deleteCacheQueryDocs = append([]paramDoc{
+	{Name: "type", Description: "signature, thinking, responses or all (default)."},
+	{Name: "model", Description: "Signature cache model group."},
+	{Name: "id", Description: "Thinking cache entry."},
+}, purgeQueryDocs...)
+
+// valueBodyDoc is the body accepted by the single-value PUT/PATCH configuration endpoints.
+const valueBodyDoc = `{"value": <new value>}`
+
+var operationDocs = map[string]operationDoc{
+	"GetSession": {Summary: "Describe the current management session and role"},
+	"Logout":     {Summary: "Revoke the current login session"},
+	"GetUsageStatistics": {
+		Summary:     "Usage statistics snapshot",
+		Description: "since/until trim per-request details; fields selects top-level members of the usage object.",
+		Query:       []paramDoc{listQueryDocs[2], listQueryDocs[3], listQueryDocs[4]},
+	},
+	"GetUsageRequests": {
+		Summary: "List individual request records, oldest first",
+		Query: append(append([]paramDoc(nil), listQueryDocs...),
+			paramDoc{Name: "api", Description: "Client API key filter."},
+			paramDoc{Name: "model", Description: "Model filter."},
+			paramDoc{Name: "source", Description: "Credential source filter."},
+			paramDoc{Name: "failed", Type: "boolean", Description: "Only failed or only successful requests."}),
+	},
+	"DeleteUsage": {
+		Summary:     "Purge per-request usage details",
+		Description: "Requires user and/or before. Global totals and daily and hourly aggregates are kept.",
+		Query:       purgeQueryDocs,
+	},
+	"DeleteUserData": {
+		Summary:     "Purge the data kept per downstream API key",
+		Description: "Removes usage details, upstream error samples, stored responses and payload log records of the key, and reports the count per store. Request log files and stream statistics hold only masked keys and are not purged.",
+		Query:       purgeQueryDocs,
+	},
+	"ExportUsageStatistics": {Summary: "Export the complete usage snapshot for backup"},
+	"ImportUsageStatistics": {Summary: "Merge a previously exported usage snapshot", Body: `{"version": 1, "usage": {...}}`},
+	"GetUsageLimits": {
+		Summary:     "Latest rate-limit state per credential",
+		Description: "A single snapshot, so only fields applies; it selects top-level members.",
+		Query:       []paramDoc{listQueryDocs[4]},
+	},
+	"PostUsageLimitRecords": {
+		Summary:     "Inject or correct rate-limit records",
+		Description: "Records follow the rate-limit record schema; unknown fields are rejected and a missing timestamp means now. A record with the same source, type and timestamp replaces the stored one. Nothing is stored unless every record is valid.",
+		Body:        `{"records": [{"source": "...", "type": "unified", "timestamp": "...", "utilization_5h": 0.4, "status_5h": "allowed"}]}`,
+	},
+	"GetUsageRateLimitWindow": {Summary: "Rate-limit records aggregated over a window", Query: []paramDoc{{Name: "window", Description: "Window duration, e.g. 5h or 7d."}}},
+	"GetUsageLatency": {
+		Summary: "TTFB and total latency percentiles per model and source",
+		Query:   []paramDoc{{Name: "model", Description: "Model filter."}, {Name: "source", Description: "Source filter."}},
+	},
+	"GetUsageErrors": {
+		Summary:     "Per-credential error classes and decayed error rates",
+		Description: "The time range applies to last_error; pages are ordered by auth_id.",
+		Query:       listQueryDocs,
+	},
+	"GetUsageStreams": {
+		Summary:     "Active streams and per-model output rate summaries",
+		Description: "The list parameters apply to active; the time range applies to started_at.",
+		Query:       listQueryDocs,
+	},
+	"GetUsagePersistence": {Summary: "Last save time, pending changes and errors for statistics persistence"},
+	"GetMetrics":          {Summary: "Prometheus metrics", Produces: "text/plain"},
+	"GetConfigYAML":       {Summary: "Raw config.yaml", Produces: "application/yaml"},
+	"PutConfigYAML":       {Summary: "Replace config.yaml", Body: "YAML document"},
+	"GetConfig":           {Summary: "Effective configuration as JSON"},
+	"GetLogs":             {Summary: "Tail server logs", Query: []paramDoc{{Name: "after", Type: "integer", Description: "Only lines after this Unix timestamp."}}},
+	"APICall":             {Summary: "Issue an upstream HTTP call with a stored credential", Body: `{"auth_index": "...", "method": "GET", "url": "...", "header": {}, "data": "..."}`},
+	"ListAuthFiles": {
+		Summary:     "List credentials",
+		Description: "The time range applies to modtime.",
+		Query: append(append([]paramDoc(nil), listQueryDocs...),
+			paramDoc{Name: "type", Description: "Provider filter, e.g. claude."},
+			paramDoc{Name: "status", Description: "Status filter, e.g. active."}),
+	},
+	"GetAuthFileModels":   {Summary: "Models served by a credential", Query: []paramDoc{{Name: "name", Description: "Auth file name or ID."}}},
+	"DownloadAuthFile":    {Summary: "Download an auth file", Query: []paramDoc{{Name: "name", Description: "Auth file name."}}},
+	"UploadAuthFile":      {Summary: "Upload an auth file", Body: "Auth file JSON or multipart form with field file"},
+	"DeleteAuthFile":      {Summary: "Delete auth files", Query: []paramDoc{{Name: "name", Description: "Auth file name."}, {Name: "all", Type: "boolean", Description: "Delete every auth file."}}},
+	"PatchAuthFileStatus": {Summary: "Enable or disable a credential", Body: `{"name": "...", "disabled": true}`},
+	"RescanAuthFiles":     {Summary: "Rescan the auth directory"},
+	"ExportAccounts":      {Summary: "Export credentials as an encrypted bundle", Body: `{"passphrase": "..."}`},
+	"ImportAccounts":      {Summary: "Import an encrypted credential bundle", Body: `{"passphrase": "...", "bundle": "..."}`},
+	"GetAuthStatus":       {Summary: "Poll the state of an OAuth login", Query: []paramDoc{{Name: "state", Description: "State returned by the *-auth-url endpoint."}}},
+	"PostOAuthCallback":   {Summary: "Complete an OAuth login with a pasted callback URL", Body: `{"provider": "...", "redirect_url": "..."}`},
+	"GetDebugErrors":      {Summary: "Recent upstream 4xx responses with the redacted translated request"},
+	"DeleteDebugErrors":   {Summary: "Purge upstream error samples", Query: purgeQueryDocs},
+	"PostSelftest":        {Summary: "Run canned requests through every model and client format", Body: `{"models": ["..."], "formats": ["openai"], "stream": true, "mock": false}`},
+	"GetVersion":          {Summary: "Build version, enabled features and supported provider API versions"},
+	"GetLogLevels":        {Summary: "Base log level, per-module overrides and pending payload dumps"},
+	"PutLogLevel":         {Summary: "Override the log level of a module", Body: `{"module": "executor", "level": "debug"}`},
+	"PostPayloadDump":     {Summary: "Log the next translated payloads of a client API key", Body: `{"api-key": "...", "count": 5}`},
+	"GetModelPins":        {Summary: "Pinned -latest model targets and detected drift"},
+	"GetCacheStats":       {Summary: "Signature cache statistics"},
+	"DeleteCache":         {Summary: "Clear the signature, thinking or stored-response caches", Query: deleteCacheQueryDocs},
+	"ExportCache":         {Summary: "Export the signature cache"},
+	"ImportCache":         {Summary: "Import a signature cache export"},
+	"ExportState":         {Summary: "Export rate limits, cooldowns and caches for an upgrade handoff"},
+	"ImportState":         {Summary: "Import a state export from another instance"},
+	"GetOpenAPISpec":      {Summary: "This OpenAPI document"},
+}
+
+// compatDeviation documents a /v1 or /v1beta route whose behavior differs from the
+// upstream vendor specification.
+type compatDeviation struct {
+	Method      string
+	Path        string
+	Summary     string
+	Description string
+}
+
+var compatDeviations = []compatDeviation{
+	{http.MethodGet, "/v1/models", "Unified model list",
+		"Lists models of every configured provider. Clients identified as Claude Code receive the Anthropic model list format instead of the OpenAI one."},
+	{http.MethodPost, "/v1/chat/completions", "OpenAI Chat Completions served by any provider",
+		"The model may carry a thinking suffix such as claude-sonnet-4-5(high) or gemini-2.5-pro(8192). usage.prompt_tokens_details.cache_creation_tokens reports prompt cache writes. With unknown-stream-events: passthrough, unrecognised upstream events are forwarded as extension chunks."},
+	{http.MethodPost, "/v1/messages", "Anthropic Messages served by any provider",
+		"Accepts the same thinking model suffix as /v1/chat/completions."},
+	{http.MethodPost, "/v1/messages/count_tokens", "Anthropic token counting served by any provider", ""},
+	{http.MethodPost, "/v1/responses", "OpenAI Responses served by any provider", ""},
+	{http.MethodGet, "/v1/responses", "OpenAI Responses over WebSocket",
+		"Upgrades to a WebSocket carrying Responses events, as used by Codex clients."},
+	{http.MethodPost, "/v1/responses/compact", "Compact a Responses conversation", "Only supported by Codex-backed models."},
+	{http.MethodPost, "/v1/responses/{response_id}/cancel", "Cancel an in-flight response",
+		"Cancels a response still streaming through the proxy; the cancellation is propagated upstream."},
+	{http.MethodGet, "/v1/operations/{operation_id}", "Poll an asynchronous request",
+		"Non-streaming chat completion and response requests sent with Prefer: respond-async that outlast async-threshold-seconds are answered with 202 and an operation. The operation object reports status in_progress, completed with the result, or failed with the error. wait=N long-polls up to 60 seconds."},
+}
+
+var (
+	ginParamPattern     = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+	openAPIParamPattern = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+)
+
+// SetRouteLister sets the function used to enumerate the server routes for the OpenAPI document.
+func (h *Handler) SetRouteLister(fn func() gin.RoutesInfo) { h.routeLister = fn }
+
+// GetOpenAPISpec serves an OpenAPI 3 document of the management API and the /v1
+// compatibility routes that deviate from their upstream specifications.
+//
+// GET /v0/management/openapi.json
+func (h *Handler) GetOpenAPISpec(c *gin.Context) {
+	var routes gin.RoutesInfo
+	if h != nil && h.routeLister != nil {
+		routes = h.routeLister()
+	}
+	c.JSON(http.StatusOK, buildOpenAPISpec(routes))
+}
+
+// buildOpenAPISpec assembles the document from the registered management routes.
+func buildOpenAPISpec(routes gin.RoutesInfo) gin.H {
+	paths := gin.H{}
+	addOperation := func(path, method string, op gin.H) {
+		item, _ := paths[path].(gin.H)
+		if item == nil {
+			item = gin.H{}
+			paths[path] = item
+		}
+		item[strings.ToLower(method)] = op
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	for _, route := range routes {
+		if !strings.HasPrefix(route.Path, "/v0/management/") {
+			continue
+		}
+		name := handlerMethodName(route.Handler)
+		doc, ok := operationDocs[name]
+		if !ok {
+			doc = operationDoc{Summary: summaryFromHandlerName(name)}
+			if route.Method == http.MethodPut || route.Method == http.MethodPatch {
+				doc.Body = valueBodyDoc
+			}
+		}
+		path := openAPIPath(route.Path)
+		op := gin.H{
+			"operationId": operationID(name, route.Method),
+			"summary":     doc.Summary,
+			"tags":        []string{managementTag(route.Path)},
+			"security":    []gin.H{{"managementKey": []string{}}, {"sessionToken": []string{}}},
+			"responses":   openAPIResponses(doc.Produces),
+		}
+		if doc.Description != "" {
+			op["description"] = doc.Description
+		}
+		if params := openAPIParameters(route.Path, doc.Query); len(params) > 0 {
+			op["parameters"] = params
+		}
+		if doc.Body != "" {
+			op["requestBody"] = gin.H{
+				"description": doc.Body,
+				"content":     gin.H{"application/json": gin.H{"schema": gin.H{"type": "object"}}},
+			}
+		}
+		addOperation(path, route.Method, op)
+	}
+
+	for _, dev := range compatDeviations {
+		op := gin.H{
+			"operationId": operationID(strings.Trim(strings.NewReplacer("/", "_", "{", "", "}", "").Replace(dev.Path), "_"), dev.Method),
+			"summary":     dev.Summary,
+			"tags":        []string{"compatibility"},
+			"security":    []gin.H{{"apiKey": []string{}}},
+			"responses":   openAPIResponses(""),
+		}
+		if dev.Description != "" {
+			op["description"] = dev.Description
+		}
+		if params := openAPIParameters(dev.Path, nil); len(params) > 0 {
+			op["parameters"] = params
+		}
+		addOperation(dev.Path, dev.Method, op)
+	}
+
+	return gin.H{
+		"openapi": "3.0.3",
+		"info": gin.H{
+			"title":   "CLIProxyAPI Management API",
+			"version": buildinfo.Version,
+		},
+		"paths": paths,
+		"components": gin.H{
+			"securitySchemes": gin.H{
+				"managementKey": gin.H{"type": "http", "scheme": "bearer", "description": "Management secret key, also accepted as X-Management-Key."},
+				"sessionToken":  gin.H{"type": "apiKey", "in": "cookie", "name": sessionCookieName},
+				"apiKey":        gin.H{"type": "http", "scheme": "bearer", "description": "Client API key from api-keys."},
+			},
+		},
+	}
+}
+
+func openAPIResponses(produces string) gin.H {
+	if produces == "" {
+		produces = "application/json"
+	}
+	return gin.H{
+		"200": gin.H{"description": "OK", "content": gin.H{produces: gin.H{}}},
+		"400": gin.H{"description": "Invalid request"},
+		"401": gin.H{"description": "Missing or invalid credentials"},
+		"403": gin.H{"description": "Insufficient role"},
+	}
+}
+
+func openAPIParameters(path string, query []paramDoc) []gin.H {
+	var params []gin.H
+	for _, match := range openAPIParamPattern.FindAllStringSubmatch(openAPIPath(path), -1) {
+		params = append(params, gin.H{"name": match[1], "in": "path", "required": true, "schema": gin.H{"type": "string"}})
+	}
+	for _, q := range query {
+		typ := q.Type
+		if typ == "" {
+			typ = "string"
+		}
+		params = append(params, gin.H{"name": q.Name, "in": "query", "description": q.Description, "schema": gin.H{"type": typ}})
+	}
+	return params
+}
+
+// openAPIPath converts gin path parameters (:name, *name) into OpenAPI templates.
+func openAPIPath(path string) string {
+	return ginParamPattern.ReplaceAllString(path, "{$1}")
+}
+
+// handlerMethodName extracts GetUsageStatistics from a gin handler name such as
+// ".../management.(*Handler).GetUsageStatistics-fm".
+func handlerMethodName(handler string) string {
+	name := strings.TrimSuffix(handler, "-fm")
+	if idx := strings.LastIndex(name, "."); idx >= 0 {
+		name = name[idx+1:]
+	}
+	return name
+}
+
+// managementTag groups operations by the first path segment after /v0/management.
+func managementTag(path string) string {
+	rest := strings.TrimPrefix(path, "/v0/management/")
+	if idx := strings.Index(rest, "/"); idx >= 0 {
+		rest = rest[:idx]
+	}
+	return strings.TrimSuffix(rest, ".yaml")
+}
+
+func operationID(name, method string) string {
+	if name == "" {
+		return strings.ToLower(method)
+	}
+	if method == http.MethodPatch && strings.HasPrefix(name, "Put") {
+		return "Patch" + strings.TrimPrefix(name, "Put")
+	}
+	return name
+}
+
+// summaryFromHandlerName turns PutLogsMaxTotalSizeMB into "Put logs max total size MB".
+func summaryFromHandlerName(name string) string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		boundary := i == len(runes) ||
+			(unicode.IsUpper(runes[i]) && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))))
+		if !boundary {
+			continue
+		}
+		word := string(runes[start:i])
+		if len(words) > 0 && !isAcronym(word) {
+			word = strings.ToLower(word)
+		}
+		words = append(words, word)
+		start = i
+	}
+	return strings.Join(words, " ")
+}
+
+func isAcronym(word string) bool {
+	for _, r := range word {
+		if !unicode.IsUpper(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return len(word) > 1
+}
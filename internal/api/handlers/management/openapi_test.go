@@ -0,0 +1,68 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestGetOpenAPISpecDocumentsRegisteredRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{}
+	engine := gin.New()
+	mgmt := engine.Group("/v0/management")
+	mgmt.GET("/usage/requests", h.GetUsageRequests)
+	mgmt.PUT("/logs-max-total-size-mb", h.GetOpenAPISpec)
+	mgmt.GET("/auth-files/:name", h.GetOpenAPISpec)
+	mgmt.GET("/openapi.json", h.GetOpenAPISpec)
+	engine.GET("/healthz", func(c *gin.Context) {})
+	h.SetRouteLister(engine.Routes)
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v0/management/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	var spec struct {
+		OpenAPI string                               `json:"openapi"`
+		Paths   map[string]map[string]map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if spec.OpenAPI != "3.0.3" {
+		t.Fatalf("openapi = %q", spec.OpenAPI)
+	}
+	if _, ok := spec.Paths["/healthz"]; ok {
+		t.Fatal("non-management route documented")
+	}
+	op := spec.Paths["/v0/management/usage/requests"]["get"]
+	if op["summary"] != "List individual request records, oldest first" {
+		t.Fatalf("summary = %v", op["summary"])
+	}
+	if params, _ := op["parameters"].([]any); len(params) != 9 {
+		t.Fatalf("parameters = %d, want 9", len(params))
+	}
+	if _, ok := spec.Paths["/v0/management/auth-files/{name}"]["get"]; !ok {
+		t.Fatal("path parameter not converted")
+	}
+	if _, ok := spec.Paths["/v1/chat/completions"]["post"]; !ok {
+		t.Fatal("compatibility deviations missing")
+	}
+}
+
+func TestSummaryFromHandlerName(t *testing.T) {
+	cases := map[string]string{
+		"PutLogsMaxTotalSizeMB": "Put logs max total size MB",
+		"GetAPIKeys":            "Get API keys",
+		"DeleteCache":           "Delete cache",
+	}
+	for in, want := range cases {
+		if got := summaryFromHandlerName(in); got != want {
+			t.Errorf("summaryFromHandlerName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package management
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultListLimit is the page size used when cursor is given without limit.
+	defaultListLimit = 100
+	// maxListLimit caps the page size a client can request.
+	maxListLimit = 1000
+)
+
+// listParams holds the pagination, time-range and field selection query parameters shared
+// by list endpoints:
+//
+//	limit   page size (1-1000); omitted together with cursor returns every item
+//	cursor  opaque next_cursor value from the previous page
+//	since   inclusive lower bound (RFC3339, Unix seconds or a duration such as 24h)
+//	until   exclusive upper bound, same formats as since
+//	fields  comma-separated list of fields to keep in every item
+type listParams struct {
+	Limit  int
+	Cursor string
+	Since  time.Time
+	Until  time.Time
+	Fields []string
+}
+
+// parseListParams reads the shared list parameters from the query string.
+func parseListParams(c *gin.Context) (listParams, error) {
+	var p listParams
+	if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			return p, fmt.Errorf("invalid limit %q", raw)
+		}
+		p.Limit = min(n, maxListLimit)
+	}
+	if raw := strings.TrimSpace(c.Query("cursor")); raw != "" {
+		decoded, err := base64.RawURLEncoding.DecodeString(raw)
+		if err != nil {
+			return p, fmt.Errorf("invalid cursor")
+		}
+		p.Cursor = string(decoded)
+		if p.Limit == 0 {
+			p.Limit = defaultListLimit
+		}
+	}
+	var err error
+	if p.Since, err = parseTimeParam(c.Query("since")); err != nil {
+		return p, fmt.Errorf("invalid since: %w", err)
+	}
+	if p.Until, err = parseTimeParam(c.Query("until")); err != nil {
+		return p, fmt.Errorf("invalid until: %w", err)
+	}
+	for _, field := range strings.Split(c.Query("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			p.Fields = append(p.Fields, field)
+		}
+	}
+	return p, nil
+}
+
+// parseTimeParam accepts RFC3339 timestamps, Unix seconds or a duration relative to now.
+func parseTimeParam(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.RFC3339, raw); err == nil {
+		return t, nil
+	}
+	if secs, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+		return time.Now().Add(-d), nil
+	}
+	return time.Time{}, fmt.Errorf("%q is not a timestamp or duration", raw)
+}
+
+// InRange reports whether t lies within [Since, Until). Zero bounds are open.
+func (p listParams) InRange(t time.Time) bool {
+	if !p.Since.IsZero() && t.Before(p.Since) {
+		return false
+	}
+	if !p.Until.IsZero() && !t.Before(p.Until) {
+		return false
+	}
+	return true
+}
+
+// Paginated reports whether the client asked for a page rather than the full list.
+func (p listParams) Paginated() bool { return p.Limit > 0 }
+
+// listPage is one page of items keyed by a stable, unique sort key.
+type listPage[T any] struct {
+	Items      []T
+	NextCursor string
+	Total      int
+}
+
+// paginate sorts items by key and returns the page after p.Cursor. When p.Limit is zero
+// every item is returned.
+func paginate[T any](items []T, p listParams, key func(T) string) listPage[T] {
+	sort.SliceStable(items, func(i, j int) bool { return key(items[i]) < key(items[j]) })
+	page := listPage[T]{Total: len(items)}
+	start := 0
+	if p.Cursor != "" {
+		start = sort.Search(len(items), func(i int) bool { return key(items[i]) > p.Cursor })
+	}
+	end := len(items)
+	if p.Limit > 0 && start+p.Limit < end {
+		end = start + p.Limit
+		page.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(key(items[end-1])))
+	}
+	page.Items = items[start:end]
+	return page
+}
+
+// selectFields returns entry reduced to the requested fields, or entry itself when no
+// selection was requested.
+func selectFields(entry gin.H, fields []string) gin.H {
+	if len(fields) == 0 || entry == nil {
+		return entry
+	}
+	out := make(gin.H, len(fields))
+	for _, field := range fields {
+		if v, ok := entry[field]; ok {
+			out[field] = v
+		}
+	}
+	return out
+}
+
+// listEntry converts an item to its JSON object form so fields can be selected from it.
+func listEntry(item any) gin.H {
+	data, err := json.Marshal(item)
+	if err != nil {
+		return gin.H{}
+	}
+	var entry gin.H
+	if err = json.Unmarshal(data, &entry); err != nil {
+		return gin.H{}
+	}
+	return entry
+}
+
+// respondList builds a list response under name. Unpaginated lists keep their order;
+// pages are ordered by key.
+func respondList(name string, entries []gin.H, params listParams, key func(gin.H) string) gin.H {
+	if !params.Paginated() {
+		out := make([]gin.H, 0, len(entries))
+		for _, entry := range entries {
+			out = append(out, selectFields(entry, params.Fields))
+		}
+		return gin.H{name: out}
+	}
+	page := paginate(entries, params, key)
+	out := make([]gin.H, 0, len(page.Items))
+	for _, entry := range page.Items {
+		out = append(out, selectFields(entry, params.Fields))
+	}
+	return writeListPage(gin.H{name: out}, page)
+}
+
+// writeListPage sets the pagination members of a list response body.
+func writeListPage[T any](body gin.H, page listPage[T]) gin.H {
+	body["total"] = page.Total
+	if page.NextCursor != "" {
+		body["next_cursor"] = page.NextCursor
+	}
+	return body
+}
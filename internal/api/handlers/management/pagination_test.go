@@ -0,0 +1,154 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestGetUsageRequestsPaginatesWithCursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	stats := usage.NewRequestStatistics()
+	details := make([]usage.RequestDetail, 0, 5)
+	for i := 0; i < 5; i++ {
+		details = append(details, usage.RequestDetail{Timestamp: base.Add(time.Duration(i) * time.Hour), Source: "a@example.com", Failed: i == 4})
+	}
+	stats.MergeSnapshot(usage.StatisticsSnapshot{APIs: map[string]usage.APISnapshot{
+		"key-1": {Models: map[string]usage.ModelSnapshot{"claude-sonnet-4-5": {Details: details}}},
+	}})
+	h := &Handler{usageStats: stats}
+	r := gin.New()
+	r.GET("/usage/requests", h.GetUsageRequests)
+
+	type page struct {
+		Requests   []map[string]any `json:"requests"`
+		NextCursor string           `json:"next_cursor"`
+		Total      int              `json:"total"`
+	}
+	get := func(query string) page {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/usage/requests?"+query, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status %d: %s", rec.Code, rec.Body.String())
+		}
+		var p page
+		if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return p
+	}
+
+	first := get("limit=2&since=" + base.Add(time.Hour).Format(time.RFC3339) + "&fields=timestamp,failed")
+	if first.Total != 4 || len(first.Requests) != 2 || first.NextCursor == "" {
+		t.Fatalf("first page = %+v", first)
+	}
+	if _, ok := first.Requests[0]["source"]; ok {
+		t.Fatal("expected field selection to drop source")
+	}
+	second := get("limit=2&since=" + base.Add(time.Hour).Format(time.RFC3339) + "&cursor=" + first.NextCursor)
+	if len(second.Requests) != 2 || second.NextCursor != "" {
+		t.Fatalf("second page = %+v", second)
+	}
+	if failed, _ := second.Requests[1]["failed"].(bool); !failed {
+		t.Fatalf("expected last record to be the failed one, got %+v", second.Requests[1])
+	}
+	if _, ok := second.Requests[0]["key"]; ok {
+		t.Fatal("internal sort key must not be exposed")
+	}
+}
+
+func TestParseListParamsRejectsInvalidValues(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	for _, query := range []string{"limit=0", "limit=abc", "cursor=***", "since=yesterday"} {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+		if _, err := parseListParams(c); err == nil {
+			t.Fatalf("expected error for %q", query)
+		}
+	}
+}
+
+func TestUsageErrorsStreamsAndLimitsAcceptListParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	errorStore := usage.GetErrorRateStore()
+	errorStore.Reset()
+	defer errorStore.Reset()
+	for _, id := range []string{"auth-c", "auth-a", "auth-b"} {
+		errorStore.Record(coreusage.LatencyRecord{AuthID: id, Source: id + "@example.com", Provider: "claude", Failed: true})
+	}
+	errorStore.Record(coreusage.LatencyRecord{AuthID: "auth-ok", Provider: "claude"})
+
+	streamStore := usage.GetStreamStatsStore()
+	for i := 0; i < 3; i++ {
+		tracker := streamStore.Start("claude-sonnet-4-5", "")
+		defer tracker.Finish()
+	}
+
+	h := &Handler{}
+	r := gin.New()
+	r.GET("/usage/errors", h.GetUsageErrors)
+	r.GET("/usage/streams", h.GetUsageStreams)
+	r.GET("/usage/limits", h.GetUsageLimits)
+	get := func(path string) map[string]any {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", path, rec.Code, rec.Body.String())
+		}
+		var body map[string]any
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+			t.Fatalf("%s: decode: %v", path, err)
+		}
+		return body
+	}
+
+	since := time.Now().Add(-time.Hour).Format(time.RFC3339)
+	first := get("/usage/errors?limit=2&fields=auth_id&since=" + since)
+	items, _ := first["errors"].([]any)
+	if first["total"] != float64(3) || len(items) != 2 || first["next_cursor"] == nil {
+		t.Fatalf("first errors page = %+v", first)
+	}
+	entry, _ := items[0].(map[string]any)
+	if entry["auth_id"] != "auth-a" || len(entry) != 1 {
+		t.Fatalf("first error entry = %+v", entry)
+	}
+	second := get("/usage/errors?limit=2&since=" + since + "&cursor=" + first["next_cursor"].(string))
+	if items, _ = second["errors"].([]any); len(items) != 1 || second["next_cursor"] != nil {
+		t.Fatalf("second errors page = %+v", second)
+	}
+	if entry, _ = items[0].(map[string]any); entry["auth_id"] != "auth-c" {
+		t.Fatalf("second error entry = %+v", entry)
+	}
+	if all, _ := get("/usage/errors")["errors"].([]any); len(all) != 4 {
+		t.Fatalf("expected every credential without list params, got %d", len(all))
+	}
+
+	streams := get("/usage/streams?limit=2&fields=id,model")
+	active, _ := streams["active"].([]any)
+	if streams["total"] != float64(3) || len(active) != 2 || streams["next_cursor"] == nil {
+		t.Fatalf("streams page = %+v", streams)
+	}
+	if entry, _ = active[0].(map[string]any); len(entry) != 2 || entry["model"] != "claude-sonnet-4-5" {
+		t.Fatalf("stream entry = %+v", entry)
+	}
+	if _, ok := streams["models"]; !ok {
+		t.Fatal("expected model summaries next to the paginated streams")
+	}
+	if recent := get("/usage/streams?since=1h"); len(recent["active"].([]any)) != 3 {
+		t.Fatalf("expected streams started within the last hour, got %+v", recent)
+	}
+
+	limits := get("/usage/limits?fields=5h_usage,7d_usage")
+	if len(limits) != 2 || limits["5h_usage"] == nil || limits["7d_usage"] == nil {
+		t.Fatalf("limits = %+v", limits)
+	}
+}
@@ -0,0 +1,85 @@
+package management
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+)
+
+// purgeParams selects the data removed by the purge endpoints: the downstream API key
+// ("user") it belongs to and the time it was recorded before. Empty values match all.
+type purgeParams struct {
+	User   string
+	Before time.Time
+}
+
+// parsePurgeParams reads the user and before query parameters. before accepts the same
+// formats as the list endpoints' since and until; a duration such as 720h means older
+// than that.
+func parsePurgeParams(c *gin.Context) (purgeParams, error) {
+	p := purgeParams{User: strings.TrimSpace(c.Query("user"))}
+	var err error
+	if p.Before, err = parseTimeParam(c.Query("before")); err != nil {
+		return p, fmt.Errorf("invalid before: %w", err)
+	}
+	return p, nil
+}
+
+// DeleteUsage purges per-request usage details, including their spill-over and model
+// downgrade markers, of one downstream API key and/or recorded before a time. At least
+// one filter is required. Global totals and per-day and per-hour aggregates are kept.
+//
+// DELETE /v0/management/usage?user=...&before=...
+func (h *Handler) DeleteUsage(c *gin.Context) {
+	if h == nil || h.usageStats == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "usage statistics unavailable"})
+		return
+	}
+	p, err := parsePurgeParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if p.User == "" && p.Before.IsZero() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user or before is required"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"removed": h.usageStats.Purge(p.User, p.Before)})
+}
+
+// DeleteUserData purges the data kept per downstream API key: usage details, upstream
+// error samples, stored responses and the key's records in payloads.log, optionally only
+// data recorded before a time. The response counts the entries removed from each of
+// these stores. Other stores hold no data attributable to the key and are left alone:
+// request log files carry only masked credentials, stream statistics keep a masked key
+// only while a stream is in progress, and caches such as the signature cache hold no
+// client identity. Request logs can be removed with DELETE /v0/management/logs.
+//
+// DELETE /v0/management/user-data?user=...&before=...
+func (h *Handler) DeleteUserData(c *gin.Context) {
+	p, err := parsePurgeParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if p.User == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user is required"})
+		return
+	}
+	removed := cache.PurgeUserData(p.User, p.Before)
+	if h != nil && h.usageStats != nil {
+		removed["usage"] = h.usageStats.Purge(p.User, p.Before)
+	}
+	removed["error_samples"] = logging.PurgeErrorSamples(p.User, p.Before)
+	removed["payload_log"], err = logging.PurgePayloadLog(h.logDirectory(), p.User, p.Before)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to purge payload log: %v", err), "removed": removed})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"removed": removed})
+}
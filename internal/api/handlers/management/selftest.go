@@ -0,0 +1,281 @@
+package management
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	// selftestPrompt is the canned user message sent through every translation path.
+	selftestPrompt = "Reply with the single word OK."
+	// selftestTimeout bounds one canned request.
+	selftestTimeout = 60 * time.Second
+	// selftestConcurrency bounds the canned requests in flight.
+	selftestConcurrency = 4
+)
+
+// selftestCase is the canned request of one client format and the check applied to
+// the translated response.
+type selftestCase struct {
+	payload func(model string, stream bool) string
+	// valid reports whether a non-streaming response has the shape of the client format.
+	valid func(body gjson.Result) bool
+}
+
+var selftestCases = map[string]selftestCase{
+	"openai": {
+		payload: func(model string, stream bool) string {
+			return fmt.Sprintf(`{"model":%q,"messages":[{"role":"user","content":%q}],"max_tokens":32,"stream":%t}`, model, selftestPrompt, stream)
+		},
+		valid: func(body gjson.Result) bool { return body.Get("choices.0").Exists() },
+	},
+	"claude": {
+		payload: func(model string, stream bool) string {
+			return fmt.Sprintf(`{"model":%q,"max_tokens":32,"messages":[{"role":"user","content":%q}],"stream":%t}`, model, selftestPrompt, stream)
+		},
+		valid: func(body gjson.Result) bool { return body.Get("content").IsArray() },
+	},
+	"gemini": {
+		payload: func(_ string, _ bool) string {
+			return fmt.Sprintf(`{"contents":[{"role":"user","parts":[{"text":%q}]}],"generationConfig":{"maxOutputTokens":32}}`, selftestPrompt)
+		},
+		valid: func(body gjson.Result) bool { return body.Get("candidates.0").Exists() },
+	},
+	"openai-response": {
+		payload: func(model string, stream bool) string {
+			return fmt.Sprintf(`{"model":%q,"input":%q,"max_output_tokens":32,"stream":%t}`, model, selftestPrompt, stream)
+		},
+		valid: func(body gjson.Result) bool { return body.Get("output").IsArray() },
+	},
+}
+
+// selftestResult reports one canned request.
+type selftestResult struct {
+	Model     string   `json:"model"`
+	Format    string   `json:"format"`
+	Stream    bool     `json:"stream"`
+	Providers []string `json:"providers,omitempty"`
+	OK        bool     `json:"ok"`
+	Status    int      `json:"status,omitempty"`
+	LatencyMS int64    `json:"latency_ms"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// PostSelftest runs a canned request in every client format through each configured
+// model and reports which translation paths succeed. With mock set, the requests go to
+// the built-in mock provider instead of real upstreams, so upgrades can be validated
+// without credentials or cost.
+//
+// POST /v0/management/selftest
+// Body: {"models": ["gpt-5"], "formats": ["openai", "claude"], "stream": true, "mock": false}
+func (h *Handler) PostSelftest(c *gin.Context) {
+	var body struct {
+		Models  []string `json:"models"`
+		Formats []string `json:"formats"`
+		Stream  bool     `json:"stream"`
+		Mock    bool     `json:"mock"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&body); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+			return
+		}
+	}
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+
+	formats, err := selftestFormats(body.Formats)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	models := body.Models
+	if body.Mock {
+		if h.cfg == nil || !h.cfg.MockBackend.Enabled {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "mock-backend is not enabled"})
+			return
+		}
+		if len(models) == 0 {
+			models = h.cfg.MockBackend.Models
+		}
+		if len(models) == 0 {
+			models = []string{"mock-model"}
+		}
+	} else if len(models) == 0 {
+		models = selftestModels()
+	}
+	if len(models) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no models available"})
+		return
+	}
+
+	type job struct {
+		model, format string
+		stream        bool
+	}
+	var jobs []job
+	for _, model := range models {
+		for _, format := range formats {
+			jobs = append(jobs, job{model: model, format: format})
+			if body.Stream {
+				jobs = append(jobs, job{model: model, format: format, stream: true})
+			}
+		}
+	}
+
+	results := make([]selftestResult, len(jobs))
+	sem := make(chan struct{}, selftestConcurrency)
+	var wg sync.WaitGroup
+	for i, j := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, j job) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			providers := util.GetProviderName(j.model)
+			if body.Mock {
+				providers = []string{"mock"}
+			}
+			results[i] = h.runSelftest(c.Request.Context(), providers, j.model, j.format, j.stream)
+		}(i, j)
+	}
+	wg.Wait()
+
+	passed := 0
+	for _, result := range results {
+		if result.OK {
+			passed++
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"mock":    body.Mock,
+		"total":   len(results),
+		"passed":  passed,
+		"failed":  len(results) - passed,
+		"results": results,
+	})
+}
+
+// runSelftest sends one canned request through the auth manager and checks the response.
+func (h *Handler) runSelftest(parent context.Context, providers []string, model, format string, stream bool) selftestResult {
+	result := selftestResult{Model: model, Format: format, Stream: stream, Providers: providers}
+	if len(providers) == 0 {
+		result.Error = "no provider serves this model"
+		return result
+	}
+	tc := selftestCases[format]
+	payload := []byte(tc.payload(model, stream))
+	req := cliproxyexecutor.Request{Model: model, Payload: payload}
+	opts := cliproxyexecutor.Options{
+		Stream:          stream,
+		OriginalRequest: payload,
+		SourceFormat:    sdktranslator.FromString(format),
+		Metadata:        map[string]any{cliproxyexecutor.RequestedModelMetadataKey: model},
+	}
+	if stream && format == "gemini" {
+		opts.Alt = "sse"
+	}
+
+	ctx, cancel := context.WithTimeout(parent, selftestTimeout)
+	defer cancel()
+	start := time.Now()
+	defer func() { result.LatencyMS = time.Since(start).Milliseconds() }()
+
+	if !stream {
+		resp, err := h.authManager.Execute(ctx, providers, req, opts)
+		if err != nil {
+			result.Status, result.Error = selftestError(err)
+			return result
+		}
+		parsed := gjson.ParseBytes(resp.Payload)
+		if !gjson.ValidBytes(resp.Payload) || !tc.valid(parsed) {
+			result.Error = fmt.Sprintf("unexpected %s response: %s", format, truncateSelftestBody(resp.Payload))
+			return result
+		}
+		result.OK = true
+		return result
+	}
+
+	streamResult, err := h.authManager.ExecuteStream(ctx, providers, req, opts)
+	if err != nil {
+		result.Status, result.Error = selftestError(err)
+		return result
+	}
+	received := 0
+	for chunk := range streamResult.Chunks {
+		if chunk.Err != nil {
+			result.Status, result.Error = selftestError(chunk.Err)
+			return result
+		}
+		received += len(chunk.Payload)
+	}
+	if received == 0 {
+		result.Error = "stream ended without data"
+		return result
+	}
+	result.OK = true
+	return result
+}
+
+// selftestFormats validates the requested client formats, defaulting to all of them.
+func selftestFormats(requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		formats := make([]string, 0, len(selftestCases))
+		for format := range selftestCases {
+			formats = append(formats, format)
+		}
+		sort.Strings(formats)
+		return formats, nil
+	}
+	formats := make([]string, 0, len(requested))
+	for _, format := range requested {
+		format = strings.ToLower(strings.TrimSpace(format))
+		if _, ok := selftestCases[format]; !ok {
+			return nil, fmt.Errorf("unsupported format %q", format)
+		}
+		formats = append(formats, format)
+	}
+	return formats, nil
+}
+
+// selftestModels lists every model currently served, including configured aliases.
+func selftestModels() []string {
+	var models []string
+	for _, model := range registry.GetGlobalRegistry().GetAvailableModels("openai") {
+		if id, ok := model["id"].(string); ok && id != "" {
+			models = append(models, id)
+		}
+	}
+	sort.Strings(models)
+	return models
+}
+
+func selftestError(err error) (int, string) {
+	status := 0
+	if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
+		status = se.StatusCode()
+	}
+	return status, err.Error()
+}
+
+func truncateSelftestBody(body []byte) string {
+	const limit = 256
+	if len(body) > limit {
+		return string(body[:limit]) + "..."
+	}
+	return string(body)
+}
@@ -0,0 +1,88 @@
+package management
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestPostSelftestMockCoversEveryFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{MockBackend: config.MockBackendConfig{
+		Enabled: true,
+		Models:  []string{"mock-ok", "mock-down"},
+		Rules: []config.MockRule{
+			{Model: "mock-down", Status: http.StatusServiceUnavailable},
+			{Model: "mock-*", Text: "OK"},
+		},
+	}}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor.NewMockExecutor(cfg))
+	auth := &coreauth.Auth{ID: "selftest-mock", Provider: "mock", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("register auth: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "mock-ok"}, {ID: "mock-down"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	h := &Handler{cfg: cfg, authManager: manager}
+	r := gin.New()
+	r.POST("/v0/management/selftest", h.PostSelftest)
+
+	req := httptest.NewRequest(http.MethodPost, "/v0/management/selftest", strings.NewReader(`{"mock":true,"stream":true}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	var out struct {
+		Total   int              `json:"total"`
+		Passed  int              `json:"passed"`
+		Results []selftestResult `json:"results"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// 2 models x 4 formats x (non-stream + stream)
+	if out.Total != 16 {
+		t.Fatalf("total = %d, want 16", out.Total)
+	}
+	for _, result := range out.Results {
+		wantOK := result.Model == "mock-ok"
+		if result.OK != wantOK {
+			t.Errorf("%s/%s stream=%t ok = %t, error = %q", result.Model, result.Format, result.Stream, result.OK, result.Error)
+		}
+	}
+	if out.Passed != 8 {
+		t.Errorf("passed = %d, want 8", out.Passed)
+	}
+}
+
+func TestPostSelftestRejectsUnknownFormatAndDisabledMock(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{cfg: &config.Config{}, authManager: coreauth.NewManager(nil, nil, nil)}
+	r := gin.New()
+	r.POST("/v0/management/selftest", h.PostSelftest)
+
+	for _, body := range []string{`{"formats":["soap"]}`, `{"mock":true}`} {
+		req := httptest.NewRequest(http.MethodPost, "/v0/management/selftest", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", body, w.Code)
+		}
+	}
+}
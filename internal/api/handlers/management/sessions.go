@@ -0,0 +1,353 @@
+package management
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// sessionTokenPrefix distinguishes login session tokens from static management keys.
+	sessionTokenPrefix = "cpas_"
+	sessionCookieName  = "cpa_session"
+	sessionCookiePath  = "/v0/management"
+	csrfHeaderName     = "X-CSRF-Token"
+
+	managementRoleContextKey = "managementRole"
+	managementUserContextKey = "managementUser"
+)
+
+// dummyPasswordHash is compared against when the username is unknown so that failed
+// logins take the same time whether or not the user exists.
+var dummyPasswordHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("cliproxy-unknown-user"), bcrypt.DefaultCost)
+	return hash
+})
+
+type managementSession struct {
+	username  string
+	role      string
+	csrfToken string
+	expiresAt time.Time
+}
+
+// sessionStore keeps login sessions in memory, keyed by the SHA-256 of the token so
+// the tokens themselves are never retained.
+type sessionStore struct {
+	mu       sync.Mutex
+	sessions map[string]*managementSession
+}
+
+func newSessionStore() *sessionStore {
+	return &sessionStore{sessions: make(map[string]*managementSession)}
+}
+
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomToken(prefix string) (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return prefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func (s *sessionStore) create(username, role string, ttl time.Duration) (string, *managementSession, error) {
+	token, err := randomToken(sessionTokenPrefix)
+	if err != nil {
+		return "", nil, err
+	}
+	csrfToken, err := randomToken("")
+	if err != nil {
+		return "", nil, err
+	}
+	session := &managementSession{
+		username:  username,
+		role:      role,
+		csrfToken: csrfToken,
+		expiresAt: time.Now().Add(ttl),
+	}
+	s.mu.Lock()
+	s.sessions[hashSessionToken(token)] = session
+	s.mu.Unlock()
+	return token, session, nil
+}
+
+// lookup returns the live session for token. Sessions whose user was removed or whose
+// role changed in the configuration are revoked.
+func (s *sessionStore) lookup(token string, remote config.RemoteManagement, now time.Time) *managementSession {
+	key := hashSessionToken(token)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session, ok := s.sessions[key]
+	if !ok {
+		return nil
+	}
+	user := remote.FindUser(session.username)
+	if now.After(session.expiresAt) || user == nil || user.Role != session.role {
+		delete(s.sessions, key)
+		return nil
+	}
+	return session
+}
+
+func (s *sessionStore) revoke(token string) {
+	s.mu.Lock()
+	delete(s.sessions, hashSessionToken(token))
+	s.mu.Unlock()
+}
+
+func (s *sessionStore) purgeExpired(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, session := range s.sessions {
+		if now.After(session.expiresAt) {
+			delete(s.sessions, key)
+		}
+	}
+}
+
+// authenticateSession validates a login session token, enforces CSRF protection for
+// cookie-authenticated writes and applies role separation.
+func (h *Handler) authenticateSession(c *gin.Context, token string, fromCookie, localClient bool, fail func()) {
+	var remote config.RemoteManagement
+	if h.cfg != nil {
+		remote = h.cfg.RemoteManagement
+	}
+	session := h.sessions.lookup(token, remote, time.Now())
+	if session == nil {
+		if !localClient {
+			fail()
+		}
+		if fromCookie {
+			clearSessionCookie(c)
+		}
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "invalid or expired session"})
+		return
+	}
+	if fromCookie && !isSafeMethod(c.Request.Method) {
+		provided := c.GetHeader(csrfHeaderName)
+		if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(session.csrfToken)) != 1 {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "missing or invalid CSRF token"})
+			return
+		}
+	}
+	if session.role != config.ManagementRoleAdmin && !viewerAllowed(c.Request.Method, c.FullPath()) {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "admin role required"})
+		return
+	}
+	if !localClient {
+		h.resetFailedAttempts(c.ClientIP())
+	}
+	c.Set(managementRoleContextKey, session.role)
+	c.Set(managementUserContextKey, session.username)
+	c.Next()
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// viewerReadablePaths are the read endpoints open to viewers and read-only keys: usage
+// and health monitoring, and settings that hold no credentials. Routes missing here,
+// including new ones, are reserved for admins.
+var viewerReadablePaths = map[string]struct{}{
+	"/v0/management/session":                                  {},
+	"/v0/management/openapi.json":                             {},
+	"/v0/management/usage":                                    {},
+	"/v0/management/usage/requests":                           {},
+	"/v0/management/usage/limits":                             {},
+	"/v0/management/usage/ratelimit":                          {},
+	"/v0/management/usage/persistence":                        {},
+	"/v0/management/usage/latency":                            {},
+	"/v0/management/usage/errors":                             {},
+	"/v0/management/usage/streams":                            {},
+	"/v0/management/metrics":                                  {},
+	"/v0/management/cache/stats":                              {},
+	"/v0/management/version":                                  {},
+	"/v0/management/latest-version":                           {},
+	"/v0/management/debug":                                    {},
+	"/v0/management/log-levels":                               {},
+	"/v0/management/logging-to-file":                          {},
+	"/v0/management/logs-max-total-size-mb":                   {},
+	"/v0/management/error-logs-max-files":                     {},
+	"/v0/management/usage-statistics-enabled":                 {},
+	"/v0/management/quota-exceeded/switch-project":            {},
+	"/v0/management/quota-exceeded/switch-preview-model":      {},
+	"/v0/management/request-error-logs":                       {},
+	"/v0/management/ws-auth":                                  {},
+	"/v0/management/ampcode/upstream-url":                     {},
+	"/v0/management/ampcode/restrict-management-to-localhost": {},
+	"/v0/management/ampcode/model-mappings":                   {},
+	"/v0/management/ampcode/force-model-mappings":             {},
+	"/v0/management/request-retry":                            {},
+	"/v0/management/max-retry-interval":                       {},
+	"/v0/management/force-model-prefix":                       {},
+	"/v0/management/routing/strategy":                         {},
+	"/v0/management/oauth-excluded-models":                    {},
+	"/v0/management/oauth-model-alias":                        {},
+	"/v0/management/auth-files":                               {},
+	"/v0/management/auth-files/models":                        {},
+	"/v0/management/model-definitions/:channel":               {},
+	"/v0/management/model-pins":                               {},
+}
+
+// viewerAllowed reports whether the viewer role may call the route. Viewers are limited
+// to reads of viewerReadablePaths; logging out is always allowed.
+func viewerAllowed(method, route string) bool {
+	if route == "/v0/management/logout" {
+		return true
+	}
+	if !isSafeMethod(method) {
+		return false
+	}
+	_, allowed := viewerReadablePaths[route]
+	return allowed
+}
+
+// matchReadOnlyKey reports whether provided is one of the configured read-only keys.
+// Keys may be stored in plaintext or as bcrypt hashes.
+func matchReadOnlyKey(keys []string, provided string) bool {
+	matched := false
+	for _, key := range keys {
+		if strings.HasPrefix(key, "$2a$") || strings.HasPrefix(key, "$2b$") || strings.HasPrefix(key, "$2y$") {
+			if bcrypt.CompareHashAndPassword([]byte(key), []byte(provided)) == nil {
+				matched = true
+			}
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(key), []byte(provided)) == 1 {
+			matched = true
+		}
+	}
+	return matched
+}
+
+func setSessionCookie(c *gin.Context, token string, ttl time.Duration) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    token,
+		Path:     sessionCookiePath,
+		MaxAge:   int(ttl / time.Second),
+		HttpOnly: true,
+		Secure:   c.Request.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func clearSessionCookie(c *gin.Context) {
+	http.SetCookie(c.Writer, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     sessionCookiePath,
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   c.Request.TLS != nil,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// Login exchanges a username and password for a short-lived session token. The token is
+// returned in the body for API clients and set as an HttpOnly cookie for the control
+// panel; cookie-authenticated writes must echo csrf_token in the X-CSRF-Token header.
+//
+// POST /v0/management/login
+func (h *Handler) Login(c *gin.Context) {
+	c.Header("Cache-Control", "no-store")
+	localClient, fail, ok := h.guardClient(c)
+	if !ok {
+		return
+	}
+	var remote config.RemoteManagement
+	if h.cfg != nil {
+		remote = h.cfg.RemoteManagement
+	}
+	if len(remote.Users) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "login is not configured"})
+		return
+	}
+	var body struct {
+		Username string `json:"username"`
+		Password string `json:"password"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil || strings.TrimSpace(body.Username) == "" || body.Password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "username and password are required"})
+		return
+	}
+	user := remote.FindUser(strings.TrimSpace(body.Username))
+	hash := dummyPasswordHash()
+	if user != nil {
+		hash = []byte(user.Password)
+	}
+	if bcrypt.CompareHashAndPassword(hash, []byte(body.Password)) != nil || user == nil {
+		if !localClient {
+			fail()
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid username or password"})
+		return
+	}
+	if !localClient {
+		h.resetFailedAttempts(c.ClientIP())
+	}
+
+	ttl := remote.SessionLifetime()
+	token, session, err := h.sessions.create(user.Username, user.Role, ttl)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
+		return
+	}
+	setSessionCookie(c, token, ttl)
+	c.JSON(http.StatusOK, gin.H{
+		"token":      token,
+		"csrf_token": session.csrfToken,
+		"username":   session.username,
+		"role":       session.role,
+		"expires_at": session.expiresAt.UTC(),
+	})
+}
+
+// Logout revokes the current login session.
+//
+// POST /v0/management/logout
+func (h *Handler) Logout(c *gin.Context) {
+	token := ""
+	if ah := c.GetHeader("Authorization"); ah != "" {
+		token = strings.TrimSpace(strings.TrimPrefix(ah, "Bearer "))
+	}
+	if !strings.HasPrefix(token, sessionTokenPrefix) {
+		token, _ = c.Cookie(sessionCookieName)
+	}
+	if strings.HasPrefix(token, sessionTokenPrefix) {
+		h.sessions.revoke(token)
+	}
+	clearSessionCookie(c)
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GetSession describes the caller's identity and role.
+//
+// GET /v0/management/session
+func (h *Handler) GetSession(c *gin.Context) {
+	role := c.GetString(managementRoleContextKey)
+	if role == "" {
+		role = config.ManagementRoleAdmin
+	}
+	out := gin.H{"role": role}
+	if username := c.GetString(managementUserContextKey); username != "" {
+		out["username"] = username
+	}
+	c.JSON(http.StatusOK, out)
+}
@@ -0,0 +1,229 @@
+package management
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func newSessionTestRouter(t *testing.T) (*gin.Engine, *config.Config, *Handler) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	hash := func(pw string) string {
+		out, err := bcrypt.GenerateFromPassword([]byte(pw), bcrypt.MinCost)
+		if err != nil {
+			t.Fatalf("hash password: %v", err)
+		}
+		return string(out)
+	}
+	cfg := &config.Config{}
+	cfg.RemoteManagement.Users = []config.ManagementUser{
+		{Username: "root", Password: hash("admin-pw"), Role: config.ManagementRoleAdmin},
+		{Username: "watcher", Password: hash("viewer-pw"), Role: config.ManagementRoleViewer},
+	}
+	h := &Handler{cfg: cfg, failedAttempts: make(map[string]*attemptInfo), sessions: newSessionStore(), usageStats: usage.NewRequestStatistics()}
+
+	r := gin.New()
+	r.POST("/v0/management/login", h.Login)
+	mgmt := r.Group("/v0/management", h.Middleware())
+	mgmt.GET("/session", h.GetSession)
+	mgmt.POST("/logout", h.Logout)
+	mgmt.GET("/usage", h.GetUsageStatistics)
+	mgmt.GET("/usage/requests", h.GetUsageRequests)
+	mgmt.GET("/config.yaml", func(c *gin.Context) { c.String(http.StatusOK, "secret") })
+	mgmt.PUT("/debug", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{}) })
+	return r, cfg, h
+}
+
+type loginResult struct {
+	Token     string `json:"token"`
+	CSRFToken string `json:"csrf_token"`
+	Role      string `json:"role"`
+	cookie    *http.Cookie
+}
+
+func login(t *testing.T, r *gin.Engine, username, password string) (int, loginResult) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/v0/management/login", strings.NewReader(`{"username":"`+username+`","password":"`+password+`"}`))
+	req.RemoteAddr = "127.0.0.1:1234"
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	var out loginResult
+	_ = json.Unmarshal(w.Body.Bytes(), &out)
+	for _, c := range w.Result().Cookies() {
+		if c.Name == sessionCookieName {
+			out.cookie = c
+		}
+	}
+	return w.Code, out
+}
+
+func doRequest(r *gin.Engine, method, path string, setup func(*http.Request)) int {
+	req := httptest.NewRequest(method, path, nil)
+	req.RemoteAddr = "127.0.0.1:1234"
+	if setup != nil {
+		setup(req)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestManagementLoginSessions(t *testing.T) {
+	r, cfg, _ := newSessionTestRouter(t)
+
+	if code, _ := login(t, r, "root", "wrong"); code != http.StatusUnauthorized {
+		t.Fatalf("login with wrong password = %d, want 401", code)
+	}
+
+	code, admin := login(t, r, "root", "admin-pw")
+	if code != http.StatusOK || !strings.HasPrefix(admin.Token, sessionTokenPrefix) || admin.Role != config.ManagementRoleAdmin || admin.cookie == nil {
+		t.Fatalf("admin login = %d %+v", code, admin)
+	}
+	bearer := func(token string) func(*http.Request) {
+		return func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+	}
+	if code := doRequest(r, http.MethodPut, "/v0/management/debug", bearer(admin.Token)); code != http.StatusOK {
+		t.Fatalf("admin bearer write = %d, want 200", code)
+	}
+
+	// Cookie-authenticated writes need the CSRF token.
+	withCookie := func(csrf string) func(*http.Request) {
+		return func(req *http.Request) {
+			req.AddCookie(admin.cookie)
+			if csrf != "" {
+				req.Header.Set(csrfHeaderName, csrf)
+			}
+		}
+	}
+	if code := doRequest(r, http.MethodGet, "/v0/management/usage", withCookie("")); code != http.StatusOK {
+		t.Fatalf("cookie read = %d, want 200", code)
+	}
+	if code := doRequest(r, http.MethodPut, "/v0/management/debug", withCookie("")); code != http.StatusForbidden {
+		t.Fatalf("cookie write without CSRF = %d, want 403", code)
+	}
+	if code := doRequest(r, http.MethodPut, "/v0/management/debug", withCookie(admin.CSRFToken)); code != http.StatusOK {
+		t.Fatalf("cookie write with CSRF = %d, want 200", code)
+	}
+
+	_, viewer := login(t, r, "watcher", "viewer-pw")
+	if code := doRequest(r, http.MethodGet, "/v0/management/usage", bearer(viewer.Token)); code != http.StatusOK {
+		t.Fatalf("viewer read = %d, want 200", code)
+	}
+	if code := doRequest(r, http.MethodGet, "/v0/management/config.yaml", bearer(viewer.Token)); code != http.StatusForbidden {
+		t.Fatalf("viewer sensitive read = %d, want 403", code)
+	}
+	if code := doRequest(r, http.MethodPut, "/v0/management/debug", bearer(viewer.Token)); code != http.StatusForbidden {
+		t.Fatalf("viewer write = %d, want 403", code)
+	}
+
+	if code := doRequest(r, http.MethodPost, "/v0/management/logout", bearer(admin.Token)); code != http.StatusOK {
+		t.Fatalf("logout = %d, want 200", code)
+	}
+	if code := doRequest(r, http.MethodGet, "/v0/management/session", bearer(admin.Token)); code != http.StatusUnauthorized {
+		t.Fatalf("revoked session = %d, want 401", code)
+	}
+
+	// Removing a user from the configuration ends their sessions.
+	cfg.RemoteManagement.Users = cfg.RemoteManagement.Users[:1]
+	if code := doRequest(r, http.MethodGet, "/v0/management/usage", bearer(viewer.Token)); code != http.StatusUnauthorized {
+		t.Fatalf("session of removed user = %d, want 401", code)
+	}
+}
+
+func TestManagementReadOnlyKeys(t *testing.T) {
+	r, cfg, _ := newSessionTestRouter(t)
+	cfg.RemoteManagement.ReadOnlyKeys = []string{"monitor-key"}
+	bearer := func(token string) func(*http.Request) {
+		return func(req *http.Request) { req.Header.Set("Authorization", "Bearer "+token) }
+	}
+
+	if code := doRequest(r, http.MethodGet, "/v0/management/usage", bearer("monitor-key")); code != http.StatusOK {
+		t.Fatalf("read-only key read = %d, want 200", code)
+	}
+	if code := doRequest(r, http.MethodGet, "/v0/management/config.yaml", bearer("monitor-key")); code != http.StatusForbidden {
+		t.Fatalf("read-only key sensitive read = %d, want 403", code)
+	}
+	if code := doRequest(r, http.MethodPut, "/v0/management/debug", bearer("monitor-key")); code != http.StatusForbidden {
+		t.Fatalf("read-only key write = %d, want 403", code)
+	}
+	if code := doRequest(r, http.MethodGet, "/v0/management/usage", bearer("other-key")); code != http.StatusUnauthorized {
+		t.Fatalf("unknown key = %d, want 401", code)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("hashed-key"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash key: %v", err)
+	}
+	cfg.RemoteManagement.ReadOnlyKeys = []string{string(hash)}
+	if code := doRequest(r, http.MethodGet, "/v0/management/usage", bearer("hashed-key")); code != http.StatusOK {
+		t.Fatalf("hashed read-only key read = %d, want 200", code)
+	}
+}
+
+func TestViewerAllowed(t *testing.T) {
+	for _, route := range []string{"/v0/management/usage", "/v0/management/session"} {
+		if !viewerAllowed(http.MethodGet, route) {
+			t.Errorf("viewer denied GET %s", route)
+		}
+	}
+	for _, route := range []string{"/v0/management/cache/export", "/v0/management/state/export", "/v0/management/debug/errors", "/v0/management/not-yet-reviewed"} {
+		if viewerAllowed(http.MethodGet, route) {
+			t.Errorf("viewer allowed GET %s", route)
+		}
+	}
+}
+
+func TestViewersSeeMaskedClientKeys(t *testing.T) {
+	r, cfg, h := newSessionTestRouter(t)
+	cfg.RemoteManagement.ReadOnlyKeys = []string{"monitor-key"}
+	const clientKey = "sk-client-secret-0123456789"
+	now := time.Now()
+	h.usageStats.MergeSnapshot(usage.StatisticsSnapshot{APIs: map[string]usage.APISnapshot{
+		clientKey: {Models: map[string]usage.ModelSnapshot{"m": {Details: []usage.RequestDetail{{Timestamp: now}, {Timestamp: now.Add(time.Second)}}}}},
+	}})
+	get := func(path, token string) string {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "127.0.0.1:1234"
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s = %d: %s", path, w.Code, w.Body.String())
+		}
+		return w.Body.String()
+	}
+	// exposed returns the response with its cursor decoded, so a key inside it is found.
+	exposed := func(body string) string {
+		var page struct {
+			NextCursor string `json:"next_cursor"`
+		}
+		_ = json.Unmarshal([]byte(body), &page)
+		cursor, _ := base64.RawURLEncoding.DecodeString(page.NextCursor)
+		return body + string(cursor)
+	}
+
+	_, viewer := login(t, r, "watcher", "viewer-pw")
+	for _, token := range []string{viewer.Token, "monitor-key"} {
+		for _, path := range []string{"/v0/management/usage", "/v0/management/usage/requests?limit=1", "/v0/management/usage/requests?limit=1&fields=key,api"} {
+			if body := exposed(get(path, token)); strings.Contains(body, clientKey) || !strings.Contains(body, maskUsageAPIName(clientKey)) {
+				t.Errorf("GET %s as viewer: %s", path, body)
+			}
+		}
+	}
+
+	_, admin := login(t, r, "root", "admin-pw")
+	if body := get("/v0/management/usage/requests", admin.Token); !strings.Contains(body, clientKey) {
+		t.Errorf("admin does not see the client key: %s", body)
+	}
+}
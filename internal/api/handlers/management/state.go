@@ -0,0 +1,77 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// stateHandoffPayload bundles the in-memory routing state handed from an old proxy
+// instance to its replacement during a blue/green upgrade.
+type stateHandoffPayload struct {
+	Version    int                     `json:"version"`
+	ExportedAt time.Time               `json:"exported_at"`
+	RateLimits []usage.RateLimitRecord `json:"rate_limits"`
+	Cooldowns  []coreauth.AuthCooldown `json:"cooldowns"`
+	Cache      *cache.Snapshot         `json:"cache,omitempty"`
+}
+
+// ExportState returns the rate-limit records, pending credential cooldowns and the
+// signature and thinking caches, so a replacement instance can resume routing without
+// relearning limits or breaking ongoing thinking conversations.
+//
+// GET /v0/management/state/export
+func (h *Handler) ExportState(c *gin.Context) {
+	now := time.Now()
+	snapshot := cache.ExportSnapshot()
+	payload := stateHandoffPayload{
+		Version:    1,
+		ExportedAt: now.UTC(),
+		RateLimits: usage.GetRateLimitStore().Records(),
+		Cache:      &snapshot,
+	}
+	if h.authManager != nil {
+		payload.Cooldowns = h.authManager.ExportCooldowns(now)
+	}
+	c.JSON(http.StatusOK, payload)
+}
+
+// ImportState merges a state export from another instance. Expired cooldowns and cache
+// entries, duplicate rate-limit records and unknown credentials are skipped.
+//
+// POST /v0/management/state/import
+func (h *Handler) ImportState(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		return
+	}
+	var payload stateHandoffPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid json"})
+		return
+	}
+	if payload.Version != 0 && payload.Version != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported version"})
+		return
+	}
+
+	cooldowns := 0
+	if h.authManager != nil {
+		cooldowns = h.authManager.ImportCooldowns(c.Request.Context(), payload.Cooldowns, time.Now())
+	}
+	var cacheResult cache.ImportResult
+	if payload.Cache != nil {
+		cacheResult = cache.ImportSnapshot(*payload.Cache)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"rate_limits": usage.GetRateLimitStore().MergeRecords(payload.RateLimits),
+		"cooldowns":   cooldowns,
+		"cache":       cacheResult,
+	})
+}
@@ -1,12 +1,19 @@
 package management
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 )
 
 type usageExportPayload struct {
@@ -20,18 +27,156 @@ type usageImportPayload struct {
 	Usage   usage.StatisticsSnapshot `json:"usage"`
 }
 
-// GetUsageStatistics returns the in-memory request statistics snapshot.
+// GetUsageStatistics returns the in-memory request statistics snapshot. The optional
+// since/until parameters trim the per-request details to a time range, and fields selects
+// top-level members of the usage object (e.g. fields=total_requests,requests_by_day).
+// Viewers see client API keys masked.
 func (h *Handler) GetUsageStatistics(c *gin.Context) {
-	var snapshot usage.StatisticsSnapshot
-	if h != nil && h.usageStats != nil {
-		snapshot = h.usageStats.Snapshot()
+	params, err := parseListParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	snapshot := h.usageSnapshotFor(c)
+	if !params.Since.IsZero() || !params.Until.IsZero() {
+		snapshot = filterUsageDetails(snapshot, params)
+	}
+	var body any = snapshot
+	if len(params.Fields) > 0 {
+		body = selectFields(usageSnapshotFields(snapshot), params.Fields)
 	}
 	c.JSON(http.StatusOK, gin.H{
-		"usage":           snapshot,
+		"usage":           body,
 		"failed_requests": snapshot.FailureCount,
 	})
 }
 
+// GetUsageRequests lists individual request records flattened across APIs and models,
+// oldest first. It supports the shared list parameters plus api, model, source and
+// failed filters. For viewers, the api field, the api filter and the cursor use the
+// masked client API key.
+//
+// GET /v0/management/usage/requests?since=24h&limit=500
+func (h *Handler) GetUsageRequests(c *gin.Context) {
+	params, err := parseListParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	snapshot := h.usageSnapshotFor(c)
+	apiFilter := strings.TrimSpace(c.Query("api"))
+	modelFilter := strings.TrimSpace(c.Query("model"))
+	sourceFilter := strings.TrimSpace(c.Query("source"))
+	failedFilter := strings.TrimSpace(c.Query("failed"))
+
+	records := make([]gin.H, 0)
+	for apiName, api := range snapshot.APIs {
+		if apiFilter != "" && apiName != apiFilter {
+			continue
+		}
+		for modelName, model := range api.Models {
+			if modelFilter != "" && !strings.EqualFold(modelName, modelFilter) {
+				continue
+			}
+			for i, detail := range model.Details {
+				if !params.InRange(detail.Timestamp) {
+					continue
+				}
+				if sourceFilter != "" && detail.Source != sourceFilter {
+					continue
+				}
+				if failedFilter != "" && strconv.FormatBool(detail.Failed) != strings.ToLower(failedFilter) {
+					continue
+				}
+				records = append(records, gin.H{
+					"key":        fmt.Sprintf("%s|%s|%s|%06d", detail.Timestamp.UTC().Format("2006-01-02T15:04:05.000000000Z"), apiName, modelName, i),
+					"timestamp":  detail.Timestamp,
+					"api":        apiName,
+					"model":      modelName,
+					"source":     detail.Source,
+					"auth_index": detail.AuthIndex,
+					"tokens":     detail.Tokens,
+					"failed":     detail.Failed,
+					"cancelled":  detail.Cancelled,
+					"spill_over": detail.SpillOver,
+				})
+			}
+		}
+	}
+	page := paginate(records, params, func(record gin.H) string { return record["key"].(string) })
+	out := make([]gin.H, 0, len(page.Items))
+	for _, record := range page.Items {
+		entry := selectFields(record, params.Fields)
+		if len(params.Fields) == 0 {
+			delete(entry, "key")
+		}
+		out = append(out, entry)
+	}
+	c.JSON(http.StatusOK, writeListPage(gin.H{"requests": out}, page))
+}
+
+// usageSnapshotFor returns the usage snapshot as the caller may see it: viewers get the
+// client API keys the statistics are grouped by masked.
+func (h *Handler) usageSnapshotFor(c *gin.Context) usage.StatisticsSnapshot {
+	var snapshot usage.StatisticsSnapshot
+	if h != nil && h.usageStats != nil {
+		snapshot = h.usageStats.Snapshot()
+	}
+	if c.GetString(managementRoleContextKey) != config.ManagementRoleViewer {
+		return snapshot
+	}
+	apis := make(map[string]usage.APISnapshot, len(snapshot.APIs))
+	for apiName, api := range snapshot.APIs {
+		apis[maskUsageAPIName(apiName)] = api
+	}
+	snapshot.APIs = apis
+	return snapshot
+}
+
+// maskUsageAPIName hides a client API key. A short hash of the full key keeps keys with
+// the same masked form apart.
+func maskUsageAPIName(apiName string) string {
+	sum := sha256.Sum256([]byte(apiName))
+	return util.HideAPIKey(apiName) + "#" + hex.EncodeToString(sum[:4])
+}
+
+// filterUsageDetails returns a copy of snapshot keeping only details within the range.
+func filterUsageDetails(snapshot usage.StatisticsSnapshot, params listParams) usage.StatisticsSnapshot {
+	apis := make(map[string]usage.APISnapshot, len(snapshot.APIs))
+	for apiName, api := range snapshot.APIs {
+		models := make(map[string]usage.ModelSnapshot, len(api.Models))
+		for modelName, model := range api.Models {
+			details := make([]usage.RequestDetail, 0, len(model.Details))
+			for _, detail := range model.Details {
+				if params.InRange(detail.Timestamp) {
+					details = append(details, detail)
+				}
+			}
+			model.Details = details
+			models[modelName] = model
+		}
+		api.Models = models
+		apis[apiName] = api
+	}
+	snapshot.APIs = apis
+	return snapshot
+}
+
+// usageSnapshotFields exposes the snapshot's top-level members for field selection.
+func usageSnapshotFields(snapshot usage.StatisticsSnapshot) gin.H {
+	return gin.H{
+		"total_requests":   snapshot.TotalRequests,
+		"success_count":    snapshot.SuccessCount,
+		"failure_count":    snapshot.FailureCount,
+		"total_tokens":     snapshot.TotalTokens,
+		"apis":             snapshot.APIs,
+		"requests_by_day":  snapshot.RequestsByDay,
+		"requests_by_hour": snapshot.RequestsByHour,
+		"tokens_by_day":    snapshot.TokensByDay,
+		"tokens_by_hour":   snapshot.TokensByHour,
+	}
+}
+
 // ExportUsageStatistics returns a complete usage snapshot for backup/migration.
 func (h *Handler) ExportUsageStatistics(c *gin.Context) {
 	var snapshot usage.StatisticsSnapshot
@@ -77,3 +222,11 @@ func (h *Handler) ImportUsageStatistics(c *gin.Context) {
 		"failed_requests": snapshot.FailureCount,
 	})
 }
+
+// GetUsagePersistence reports when usage and rate-limit statistics were last written to
+// disk, so operators can confirm saves are landing on a mounted volume.
+//
+// GET /v0/management/usage/persistence
+func (h *Handler) GetUsagePersistence(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"persistence": usage.PersistenceStatuses()})
+}
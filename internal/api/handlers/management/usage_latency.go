@@ -0,0 +1,120 @@
+package management
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+)
+
+// GetUsageLatency returns TTFB and total latency percentiles (p50/p95/p99) per model
+// and source. Optional query parameters model and source filter the result.
+//
+// GET /v0/management/usage/latency
+func (h *Handler) GetUsageLatency(c *gin.Context) {
+	model := strings.TrimSpace(c.Query("model"))
+	source := strings.TrimSpace(c.Query("source"))
+	c.JSON(http.StatusOK, gin.H{"latency": usage.GetLatencyStore().Summaries(model, source)})
+}
+
+// DeleteUsageLatency clears the recorded latency histograms.
+//
+// DELETE /v0/management/usage/latency
+func (h *Handler) DeleteUsageLatency(c *gin.Context) {
+	usage.GetLatencyStore().Reset()
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GetMetrics exposes the latency histograms, stream statistics and translator unknown
+// event counters in the Prometheus text format.
+//
+// GET /v0/management/metrics
+func (h *Handler) GetMetrics(c *gin.Context) {
+	var buf bytes.Buffer
+	if err := usage.GetLatencyStore().WritePrometheus(&buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := usage.GetStreamStatsStore().WritePrometheus(&buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := translator.WriteUnknownEventsPrometheus(&buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "text/plain; version=0.0.4; charset=utf-8", buf.Bytes())
+}
+
+// GetUsageErrors returns per-credential error classes and the decayed error rate used
+// to deprioritize failing credentials, highest rate first. It accepts the shared list
+// parameters: since and until apply to last_error, and pages are ordered by auth_id.
+//
+// GET /v0/management/usage/errors
+func (h *Handler) GetUsageErrors(c *gin.Context) {
+	params, err := parseListParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	entries := make([]gin.H, 0)
+	for _, summary := range usage.GetErrorRateStore().Summaries() {
+		if !params.Since.IsZero() || !params.Until.IsZero() {
+			if summary.LastError == nil || !params.InRange(*summary.LastError) {
+				continue
+			}
+		}
+		entries = append(entries, listEntry(summary))
+	}
+	c.JSON(http.StatusOK, respondList("errors", entries, params, func(entry gin.H) string {
+		id, _ := entry["auth_id"].(string)
+		return id
+	}))
+}
+
+// DeleteUsageErrors clears the recorded error rates.
+//
+// DELETE /v0/management/usage/errors
+func (h *Handler) DeleteUsageErrors(c *gin.Context) {
+	usage.GetErrorRateStore().Reset()
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GetUsageStreams returns the active streams with their estimated output rate and, per
+// model, the output rate and stall count of finished streams. The shared list parameters
+// apply to the active streams: since and until to started_at, and pages are ordered by
+// id. The per-model summaries are always returned in full.
+//
+// GET /v0/management/usage/streams
+func (h *Handler) GetUsageStreams(c *gin.Context) {
+	params, err := parseListParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	store := usage.GetStreamStatsStore()
+	entries := make([]gin.H, 0)
+	for _, stream := range store.Active() {
+		if params.InRange(stream.StartedAt) {
+			entries = append(entries, listEntry(stream))
+		}
+	}
+	body := respondList("active", entries, params, func(entry gin.H) string {
+		id, _ := entry["id"].(float64)
+		return fmt.Sprintf("%020d", uint64(id))
+	})
+	body["models"] = store.Summaries()
+	c.JSON(http.StatusOK, body)
+}
+
+// DeleteUsageStreams clears the per-model stream statistics.
+//
+// DELETE /v0/management/usage/streams
+func (h *Handler) DeleteUsageStreams(c *gin.Context) {
+	usage.GetStreamStatsStore().Reset()
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
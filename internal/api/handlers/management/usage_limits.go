@@ -1,6 +1,9 @@
 package management
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -10,21 +13,32 @@ import (
 
 // GetUsageLimits trả về rate limit usage ở format đơn giản nhất.
 // Usage tính theo % (0-100), status là "allowed"/"rejected".
+// estimated_exhaustion_at chứa dự báo tuyến tính thời điểm từng source chạm 100%.
+// Query param fields chọn các field top-level (ví dụ fields=5h_usage,7d_usage). Response là
+// một snapshot duy nhất chứ không phải danh sách, nên limit/cursor/since/until không áp dụng.
 //
 // GET /v0/management/usage/limits
 func (h *Handler) GetUsageLimits(c *gin.Context) {
+	params, err := parseListParams(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	store := usage.GetRateLimitStore()
 	latest := store.Latest()
+	forecasts := store.ForecastExhaustion(time.Now())
 
 	if latest == nil {
-		c.JSON(http.StatusOK, gin.H{
+		c.JSON(http.StatusOK, selectFields(gin.H{
 			"5h_usage":  0,
 			"5h_status": "unknown",
 			"5h_reset":  "",
 			"7d_usage":  0,
 			"7d_status": "unknown",
 			"7d_reset":  "",
-		})
+
+			"estimated_exhaustion_at": forecasts,
+		}, params.Fields))
 		return
 	}
 
@@ -37,17 +51,85 @@ func (h *Handler) GetUsageLimits(c *gin.Context) {
 		reset7d = latest.Reset7d.Format(time.RFC3339)
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	c.JSON(http.StatusOK, selectFields(gin.H{
 		"5h_usage":  round2(latest.Utilization5h * 100),
 		"5h_status": latest.Status5h,
 		"5h_reset":  reset5h,
 		"7d_usage":  round2(latest.Utilization7d * 100),
 		"7d_status": latest.Status7d,
 		"7d_reset":  reset7d,
-	})
+
+		"estimated_exhaustion_at": forecasts,
+	}, params.Fields))
 }
 
 // round2 làm tròn float đến 2 chữ số thập phân.
 func round2(f float64) float64 {
 	return float64(int(f*100+0.5)) / 100
 }
+
+// GetUsageRateLimitWindow trả về aggregated rate limit summary (theo từng source)
+// cho time window được chỉ định qua query param "window" (mặc định 5h).
+//
+// GET /v0/management/usage/ratelimit?window=5h
+func (h *Handler) GetUsageRateLimitWindow(c *gin.Context) {
+	window := 5 * time.Hour
+	if raw := c.Query("window"); raw != "" {
+		parsed, err := usage.ParseWindow(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid window"})
+			return
+		}
+		window = parsed
+	}
+	c.JSON(http.StatusOK, usage.GetRateLimitStore().QueryByWindow(window))
+}
+
+// rateLimitRecordsPayload is the body of PostUsageLimitRecords.
+type rateLimitRecordsPayload struct {
+	Records []usage.RateLimitRecord `json:"records"`
+}
+
+// PostUsageLimitRecords injects or corrects rate-limit snapshots, e.g. to import state
+// from another proxy instance or to seed limits after a migration. Records are decoded
+// strictly against the RateLimitRecord schema and validated; a record without a
+// timestamp is stamped with the current time. A record with the same source, type and
+// timestamp as a stored one replaces it. Nothing is stored unless every record is valid.
+//
+// POST /v0/management/usage/limits/records
+func (h *Handler) PostUsageLimitRecords(c *gin.Context) {
+	data, err := c.GetRawData()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read request body"})
+		return
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var payload rateLimitRecordsPayload
+	if err := decoder.Decode(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid json: %v", err)})
+		return
+	}
+	if len(payload.Records) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "records is required"})
+		return
+	}
+
+	now := time.Now()
+	var invalid []gin.H
+	for i := range payload.Records {
+		if payload.Records[i].Timestamp.IsZero() {
+			payload.Records[i].Timestamp = now
+		}
+		if errValidate := usage.ValidateRateLimitRecord(payload.Records[i], now); errValidate != nil {
+			invalid = append(invalid, gin.H{"index": i, "error": errValidate.Error()})
+		}
+	}
+	if len(invalid) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid records", "records": invalid})
+		return
+	}
+
+	added, replaced := usage.GetRateLimitStore().InjectRecords(payload.Records)
+	c.JSON(http.StatusOK, gin.H{"added": added, "replaced": replaced})
+}
@@ -0,0 +1,60 @@
+package management
+
+import (
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/buildinfo"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
+)
+
+// GetVersion reports the build version, git commit, Go toolchain, the features enabled
+// in the running config and the upstream API versions and beta flags this build targets.
+//
+// GET /v0/management/version
+func (h *Handler) GetVersion(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"version":    buildinfo.Version,
+		"commit":     buildinfo.Commit,
+		"build_date": buildinfo.BuildDate,
+		"go_version": runtime.Version(),
+		"platform":   runtime.GOOS + "/" + runtime.GOARCH,
+		"features":   enabledFeatures(h.cfg),
+		"providers":  executor.ProviderCompatibilities(),
+	})
+}
+
+// enabledFeatures lists the optional subsystems switched on in cfg, using their config keys.
+func enabledFeatures(cfg *config.Config) []string {
+	if cfg == nil {
+		return []string{}
+	}
+	toggles := []struct {
+		name string
+		on   bool
+	}{
+		{"tls", cfg.TLS.Enable},
+		{"debug", cfg.Debug},
+		{"pprof", cfg.Pprof.Enable},
+		{"commercial-mode", cfg.CommercialMode},
+		{"request-log", cfg.RequestLog},
+		{"logging-to-file", cfg.LoggingToFile},
+		{"usage-statistics", cfg.UsageStatisticsEnabled},
+		{"payload-log", cfg.PayloadLog.Enabled},
+		{"chaos", cfg.Chaos.Enabled},
+		{"mock-backend", cfg.MockBackend.Enabled},
+		{"ws-auth", cfg.WebsocketAuth},
+		{"credential-encryption", cfg.CredentialEncryption.Enable},
+		{"ampcode", strings.TrimSpace(cfg.AmpCode.UpstreamURL) != ""},
+	}
+	features := make([]string, 0, len(toggles))
+	for _, toggle := range toggles {
+		if toggle.on {
+			features = append(features, toggle.name)
+		}
+	}
+	return features
+}
@@ -0,0 +1,55 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestGetVersionReportsFeaturesAndProviders(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{}
+	cfg.RequestLog = true
+	cfg.MockBackend.Enabled = true
+	h := &Handler{cfg: cfg}
+	r := gin.New()
+	r.GET("/v0/management/version", h.GetVersion)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v0/management/version", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	var out struct {
+		Version   string   `json:"version"`
+		GoVersion string   `json:"go_version"`
+		Features  []string `json:"features"`
+		Providers []struct {
+			Provider   string   `json:"provider"`
+			APIVersion string   `json:"api_version"`
+			BetaFlags  []string `json:"beta_flags"`
+		} `json:"providers"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if out.Version == "" || out.GoVersion == "" {
+		t.Errorf("missing build info: %s", w.Body.String())
+	}
+	if len(out.Features) != 2 || out.Features[0] != "request-log" || out.Features[1] != "mock-backend" {
+		t.Errorf("features = %v", out.Features)
+	}
+	var claudeFound bool
+	for _, p := range out.Providers {
+		if p.Provider == "claude" {
+			claudeFound = p.APIVersion != "" && len(p.BetaFlags) > 0
+		}
+	}
+	if !claudeFound {
+		t.Errorf("claude compatibility missing: %s", w.Body.String())
+	}
+}
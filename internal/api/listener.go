@@ -0,0 +1,145 @@
+package api
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// listen opens a TCP listener on addr. Sockets are not shared with SO_REUSEPORT, so
+// another process cannot bind the same address and take a share of the connections.
+func listen(addr string) (net.Listener, error) {
+	return net.Listen("tcp", addr)
+}
+
+// serveListener starts accepting connections on ln in the background. Errors from the
+// current listener end Start; a listener retired by rebindListener stops silently.
+func (s *Server) serveListener(ln net.Listener) {
+	go func() {
+		err := s.serve(ln)
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		s.listenerMu.Lock()
+		current := s.listener == ln
+		s.listenerMu.Unlock()
+		if !current {
+			return
+		}
+		select {
+		case s.serveErr <- err:
+		default:
+		}
+	}()
+}
+
+// startListener binds the configured address and blocks until the server is shut down
+// or the active listener fails.
+func (s *Server) startListener(serve func(net.Listener) error) error {
+	ln, err := listen(s.server.Addr)
+	if err != nil {
+		return err
+	}
+	s.listenerMu.Lock()
+	s.listener = ln
+	s.serve = serve
+	s.serveErr = make(chan error, 1)
+	s.listenerMu.Unlock()
+	s.serveListener(ln)
+	return <-s.serveErr
+}
+
+// rebindListener moves the server to addr without a restart. New connections are
+// accepted on addr; connections already accepted on the old address, such as
+// long-lived SSE streams, keep running until they finish because closing a listener
+// does not close the connections it produced. When addr overlaps the old address, for
+// example the same port on all interfaces, the old listener is closed before binding,
+// so new connections are refused for that moment. If addr cannot be bound, the server
+// keeps listening on the old address.
+func (s *Server) rebindListener(addr string) {
+	s.listenerMu.Lock()
+	old := s.listener
+	oldAddr := s.server.Addr
+	started := old != nil && s.serve != nil
+	s.listenerMu.Unlock()
+	if !started || addr == oldAddr {
+		return
+	}
+
+	ln, err := listen(addr)
+	if boundAddr := old.Addr().String(); errors.Is(err, syscall.EADDRINUSE) && samePort(boundAddr, addr) {
+		if ln = s.rebindOverlapping(old, boundAddr, addr); ln == nil {
+			return
+		}
+		old, err = nil, nil
+	}
+	if err != nil {
+		log.Errorf("failed to listen on %s, still serving on %s: %v", addr, old.Addr(), err)
+		return
+	}
+	s.listenerMu.Lock()
+	s.listener = ln
+	s.server.Addr = addr
+	s.listenerMu.Unlock()
+	s.serveListener(ln)
+
+	if old == nil {
+		log.Infof("API server now listening on %s", ln.Addr())
+		return
+	}
+	if errClose := old.Close(); errClose != nil {
+		log.Warnf("failed to close previous listener %s: %v", old.Addr(), errClose)
+	}
+	log.Infof("API server now listening on %s; open connections on %s finish normally", ln.Addr(), old.Addr())
+}
+
+// rebindOverlapping closes old so addr, which overlaps it, can be bound, and returns
+// the new listener. If addr still cannot be bound, oldAddr is bound and served again
+// and nil is returned. Connections accepted on old keep running either way.
+func (s *Server) rebindOverlapping(old net.Listener, oldAddr, addr string) net.Listener {
+	s.listenerMu.Lock()
+	s.listener = nil
+	s.listenerMu.Unlock()
+	if errClose := old.Close(); errClose != nil {
+		log.Warnf("failed to close previous listener %s: %v", old.Addr(), errClose)
+	}
+	ln, err := listen(addr)
+	if err == nil {
+		return ln
+	}
+	log.Errorf("failed to listen on %s, returning to %s: %v", addr, oldAddr, err)
+	restored, errRestore := listen(oldAddr)
+	if errRestore != nil {
+		log.Errorf("failed to listen on %s again: %v", oldAddr, errRestore)
+		select {
+		case s.serveErr <- errRestore:
+		default:
+		}
+		return nil
+	}
+	s.listenerMu.Lock()
+	s.listener = restored
+	s.listenerMu.Unlock()
+	s.serveListener(restored)
+	return nil
+}
+
+// samePort reports whether two listen addresses use the same port.
+func samePort(a, b string) bool {
+	_, portA, errA := net.SplitHostPort(a)
+	_, portB, errB := net.SplitHostPort(b)
+	return errA == nil && errB == nil && portA == portB
+}
+
+// listenAddr returns the address the server currently accepts connections on.
+func (s *Server) listenAddr() string {
+	s.listenerMu.Lock()
+	defer s.listenerMu.Unlock()
+	if s.listener == nil {
+		return ""
+	}
+	return s.listener.Addr().String()
+}
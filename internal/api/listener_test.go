@@ -0,0 +1,145 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRebindListenerKeepsOpenStreams(t *testing.T) {
+	release := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/stream" {
+			_, _ = w.Write([]byte("data: first\n"))
+			w.(http.Flusher).Flush()
+			<-release
+			_, _ = w.Write([]byte("data: last\n"))
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+	s := &Server{server: &http.Server{Addr: "127.0.0.1:0", Handler: handler}}
+	startErr := make(chan error, 1)
+	go func() { startErr <- s.startHTTP() }()
+
+	oldAddr := waitForListener(t, s, "")
+	resp, err := http.Get("http://" + oldAddr + "/stream")
+	if err != nil {
+		t.Fatalf("stream request: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	reader := bufio.NewReader(resp.Body)
+	if line, _ := reader.ReadString('\n'); line != "data: first\n" {
+		t.Fatalf("first line = %q", line)
+	}
+
+	s.rebindListener(freeAddr(t))
+	newAddr := waitForListener(t, s, oldAddr)
+
+	if _, err = net.DialTimeout("tcp", oldAddr, time.Second); err == nil {
+		t.Fatalf("old address %s still accepts connections", oldAddr)
+	}
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+	newResp, err := client.Get("http://" + newAddr + "/")
+	if err != nil {
+		t.Fatalf("request on new address: %v", err)
+	}
+	_ = newResp.Body.Close()
+
+	close(release)
+	if line, _ := reader.ReadString('\n'); line != "data: last\n" {
+		t.Fatalf("stream on the old listener was cut: %q", line)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err = s.server.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	select {
+	case err = <-startErr:
+		if err != nil {
+			t.Fatalf("Start returned %v after shutdown", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Start did not return after shutdown")
+	}
+}
+
+// waitForListener waits until the server listens on an address other than previous.
+func waitForListener(t *testing.T, s *Server, previous string) string {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if addr := s.listenAddr(); addr != "" && addr != previous {
+			return addr
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("server did not start listening")
+	return ""
+}
+
+// freeAddr returns a loopback address with a port that is free at the time of the call.
+func freeAddr(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	_ = ln.Close()
+	return addr
+}
+
+func TestRebindListenerKeepsOldAddressOnFailure(t *testing.T) {
+	s := &Server{server: &http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()}}
+	go func() { _ = s.startHTTP() }()
+	addr := waitForListener(t, s, "")
+	defer func() { _ = s.server.Close() }()
+
+	s.rebindListener("256.0.0.1:1")
+	if got := s.listenAddr(); got != addr || !strings.HasPrefix(got, "127.0.0.1:") {
+		t.Fatalf("listener changed to %q after a failed rebind, want %q", got, addr)
+	}
+}
+
+func TestListenerPortIsNotShared(t *testing.T) {
+	s := &Server{server: &http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()}}
+	go func() { _ = s.startHTTP() }()
+	addr := waitForListener(t, s, "")
+	defer func() { _ = s.server.Close() }()
+
+	if ln, err := net.Listen("tcp", addr); err == nil {
+		_ = ln.Close()
+		t.Fatalf("another socket could bind %s while the server listens on it", addr)
+	}
+
+	s.rebindListener(s.server.Addr)
+	if got := s.listenAddr(); got != addr {
+		t.Fatalf("listener changed to %q on a rebind to the same address, want %q", got, addr)
+	}
+}
+
+func TestRebindListenerToOverlappingAddress(t *testing.T) {
+	s := &Server{server: &http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()}}
+	go func() { _ = s.startHTTP() }()
+	oldAddr := waitForListener(t, s, "")
+	defer func() { _ = s.server.Close() }()
+
+	_, port, _ := net.SplitHostPort(oldAddr)
+	s.rebindListener(net.JoinHostPort("0.0.0.0", port))
+	newAddr := waitForListener(t, s, oldAddr)
+	if _, gotPort, _ := net.SplitHostPort(newAddr); gotPort != port {
+		t.Fatalf("listener moved to %q, want port %s", newAddr, port)
+	}
+	conn, err := net.DialTimeout("tcp", oldAddr, time.Second)
+	if err != nil {
+		t.Fatalf("dial %s after rebind: %v", oldAddr, err)
+	}
+	_ = conn.Close()
+}
@@ -8,8 +8,8 @@ import (
 	"context"
 	"crypto/subtle"
 	"crypto/tls"
-	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -30,6 +30,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/managementasset"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/claude"
@@ -37,6 +38,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers/openai"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/extension"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/net/http2"
 	"golang.org/x/net/http2/h2c"
@@ -123,6 +125,12 @@ type Server struct {
 	// server is the underlying HTTP server.
 	server *http.Server
 
+	// listenerMu guards listener, which rebindListener swaps on host/port changes.
+	listenerMu sync.Mutex
+	listener   net.Listener
+	serve      func(net.Listener) error
+	serveErr   chan error
+
 	// handlers contains the API handlers for processing requests.
 	handlers *handlers.BaseAPIHandler
 
@@ -209,6 +217,9 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	for _, mw := range optionState.extraMiddleware {
 		engine.Use(mw)
 	}
+	for _, mw := range extension.Middleware() {
+		engine.Use(mw)
+	}
 
 	// Add request logging middleware (positioned after recovery, before auth)
 	// Resolve logs directory relative to the configuration file directory.
@@ -265,6 +276,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	}
 	logDir := logging.ResolveLogDirectory(cfg)
 	s.mgmt.SetLogDirectory(logDir)
+	s.mgmt.SetRouteLister(engine.Routes)
 	s.localPassword = optionState.localPassword
 
 	// Setup routes
@@ -289,7 +301,7 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 
 	// Register management routes when configuration or environment secrets are available,
 	// or when a local management password is provided (e.g. TUI mode).
-	hasManagementSecret := cfg.RemoteManagement.SecretKey != "" || envManagementSecret || s.localPassword != ""
+	hasManagementSecret := cfg.RemoteManagement.HasCredentials() || envManagementSecret || s.localPassword != ""
 	s.managementRoutesEnabled.Store(hasManagementSecret)
 	if hasManagementSecret {
 		s.registerManagementRoutes()
@@ -346,12 +358,16 @@ func (s *Server) setupRoutes() {
 	{
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
+		v1.GET("/chat/completions/:completion_id", openaiHandlers.GetChatCompletion)
+		v1.GET("/operations/:operation_id", openaiHandlers.GetOperation)
 		v1.POST("/completions", openaiHandlers.Completions)
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
 		v1.GET("/responses", openaiResponsesHandlers.ResponsesWebsocket)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
 		v1.POST("/responses/compact", openaiResponsesHandlers.Compact)
+		v1.GET("/responses/:response_id", openaiResponsesHandlers.GetResponse)
+		v1.POST("/responses/:response_id/cancel", openaiResponsesHandlers.CancelResponse)
 	}
 
 	// Gemini compatible API routes
@@ -499,21 +515,53 @@ func (s *Server) registerManagementRoutes() {
 
 	log.Info("management routes registered after secret key configuration")
 
+	// Login is reachable without a management key; it applies its own remote and ban checks.
+	s.engine.POST("/v0/management/login", s.managementAvailabilityMiddleware(), s.mgmt.Login)
+
 	mgmt := s.engine.Group("/v0/management")
 	mgmt.Use(s.managementAvailabilityMiddleware(), s.mgmt.Middleware())
 	{
+		mgmt.GET("/session", s.mgmt.GetSession)
+		mgmt.GET("/openapi.json", s.mgmt.GetOpenAPISpec)
+		mgmt.POST("/logout", s.mgmt.Logout)
 		mgmt.GET("/usage", s.mgmt.GetUsageStatistics)
+		mgmt.DELETE("/usage", s.mgmt.DeleteUsage)
+		mgmt.DELETE("/user-data", s.mgmt.DeleteUserData)
+		mgmt.GET("/usage/requests", s.mgmt.GetUsageRequests)
 		mgmt.GET("/usage/export", s.mgmt.ExportUsageStatistics)
 		mgmt.POST("/usage/import", s.mgmt.ImportUsageStatistics)
 		mgmt.GET("/usage/limits", s.mgmt.GetUsageLimits)
+		mgmt.POST("/usage/limits/records", s.mgmt.PostUsageLimitRecords)
+		mgmt.GET("/usage/ratelimit", s.mgmt.GetUsageRateLimitWindow)
+		mgmt.GET("/usage/persistence", s.mgmt.GetUsagePersistence)
+		mgmt.GET("/usage/latency", s.mgmt.GetUsageLatency)
+		mgmt.DELETE("/usage/latency", s.mgmt.DeleteUsageLatency)
+		mgmt.GET("/usage/errors", s.mgmt.GetUsageErrors)
+		mgmt.DELETE("/usage/errors", s.mgmt.DeleteUsageErrors)
+		mgmt.GET("/usage/streams", s.mgmt.GetUsageStreams)
+		mgmt.DELETE("/usage/streams", s.mgmt.DeleteUsageStreams)
+		mgmt.GET("/metrics", s.mgmt.GetMetrics)
+		mgmt.GET("/cache/stats", s.mgmt.GetCacheStats)
+		mgmt.DELETE("/cache", s.mgmt.DeleteCache)
+		mgmt.GET("/cache/export", s.mgmt.ExportCache)
+		mgmt.POST("/cache/import", s.mgmt.ImportCache)
+		mgmt.GET("/state/export", s.mgmt.ExportState)
+		mgmt.POST("/state/import", s.mgmt.ImportState)
 		mgmt.GET("/config", s.mgmt.GetConfig)
 		mgmt.GET("/config.yaml", s.mgmt.GetConfigYAML)
 		mgmt.PUT("/config.yaml", s.mgmt.PutConfigYAML)
+		mgmt.GET("/version", s.mgmt.GetVersion)
 		mgmt.GET("/latest-version", s.mgmt.GetLatestVersion)
 
 		mgmt.GET("/debug", s.mgmt.GetDebug)
 		mgmt.PUT("/debug", s.mgmt.PutDebug)
 		mgmt.PATCH("/debug", s.mgmt.PutDebug)
+		mgmt.GET("/debug/errors", s.mgmt.GetDebugErrors)
+		mgmt.DELETE("/debug/errors", s.mgmt.DeleteDebugErrors)
+		mgmt.POST("/selftest", s.mgmt.PostSelftest)
+		mgmt.GET("/log-levels", s.mgmt.GetLogLevels)
+		mgmt.PUT("/log-levels", s.mgmt.PutLogLevel)
+		mgmt.POST("/log-levels/payload-dump", s.mgmt.PostPayloadDump)
 
 		mgmt.GET("/logging-to-file", s.mgmt.GetLoggingToFile)
 		mgmt.PUT("/logging-to-file", s.mgmt.PutLoggingToFile)
@@ -640,11 +688,15 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/auth-files", s.mgmt.ListAuthFiles)
 		mgmt.GET("/auth-files/models", s.mgmt.GetAuthFileModels)
 		mgmt.GET("/model-definitions/:channel", s.mgmt.GetStaticModelDefinitions)
+		mgmt.GET("/model-pins", s.mgmt.GetModelPins)
 		mgmt.GET("/auth-files/download", s.mgmt.DownloadAuthFile)
+		mgmt.POST("/auth-files/rescan", s.mgmt.RescanAuthFiles)
 		mgmt.POST("/auth-files", s.mgmt.UploadAuthFile)
 		mgmt.DELETE("/auth-files", s.mgmt.DeleteAuthFile)
 		mgmt.PATCH("/auth-files/status", s.mgmt.PatchAuthFileStatus)
 		mgmt.PATCH("/auth-files/fields", s.mgmt.PatchAuthFileFields)
+		mgmt.POST("/accounts/export", s.mgmt.ExportAccounts)
+		mgmt.POST("/accounts/import", s.mgmt.ImportAccounts)
 		mgmt.POST("/vertex/import", s.mgmt.ImportVertexCredential)
 
 		mgmt.GET("/anthropic-auth-url", s.mgmt.RequestAnthropicToken)
@@ -867,7 +919,7 @@ func (s *Server) startWithManualTLS() error {
 	}
 
 	log.Debugf("Starting API server on %s with manual TLS (HTTP/2 enabled)", s.server.Addr)
-	if err := s.server.ListenAndServeTLS(cert, key); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	if err := s.startListener(func(ln net.Listener) error { return s.server.ServeTLS(ln, cert, key) }); err != nil {
 		return fmt.Errorf("failed to start HTTPS server: %v", err)
 	}
 	return nil
@@ -877,7 +929,7 @@ func (s *Server) startWithManualTLS() error {
 // This is useful when running behind a reverse proxy that terminates TLS.
 func (s *Server) startWithH2C() error {
 	log.Debugf("Starting API server on %s with HTTP/2 cleartext (h2c)", s.server.Addr)
-	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	if err := s.startListener(s.server.Serve); err != nil {
 		return fmt.Errorf("failed to start h2c server: %v", err)
 	}
 	return nil
@@ -886,7 +938,7 @@ func (s *Server) startWithH2C() error {
 // startHTTP starts the server in plain HTTP/1.1 mode.
 func (s *Server) startHTTP() error {
 	log.Debugf("Starting API server on %s (HTTP/1.1)", s.server.Addr)
-	if err := s.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	if err := s.startListener(s.server.Serve); err != nil {
 		return fmt.Errorf("failed to start HTTP server: %v", err)
 	}
 	return nil
@@ -980,6 +1032,12 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		}
 	}
 
+	if oldCfg == nil || !reflect.DeepEqual(oldCfg.PayloadLog, cfg.PayloadLog) {
+		if err := logging.ConfigurePayloadLog(cfg); err != nil {
+			log.Errorf("failed to reconfigure payload log: %v", err)
+		}
+	}
+
 	if oldCfg == nil || oldCfg.UsageStatisticsEnabled != cfg.UsageStatisticsEnabled {
 		usage.SetStatisticsEnabled(cfg.UsageStatisticsEnabled)
 	}
@@ -1000,14 +1058,14 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 
 	// Update log level dynamically when debug flag changes
 	if oldCfg == nil || oldCfg.Debug != cfg.Debug {
-		util.SetLogLevel(cfg)
+		logging.SetLogLevel(cfg)
 	}
 
 	prevSecretEmpty := true
 	if oldCfg != nil {
-		prevSecretEmpty = oldCfg.RemoteManagement.SecretKey == ""
+		prevSecretEmpty = !oldCfg.RemoteManagement.HasCredentials()
 	}
-	newSecretEmpty := cfg.RemoteManagement.SecretKey == ""
+	newSecretEmpty := !cfg.RemoteManagement.HasCredentials()
 	if s.envManagementSecret {
 		s.registerManagementRoutes()
 		if s.managementRoutesEnabled.CompareAndSwap(false, true) {
@@ -1036,6 +1094,9 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	}
 
 	s.applyAccessConfig(oldCfg, cfg)
+	if oldCfg != nil && (oldCfg.Host != cfg.Host || oldCfg.Port != cfg.Port) {
+		s.rebindListener(fmt.Sprintf("%s:%d", cfg.Host, cfg.Port))
+	}
 	s.cfg = cfg
 	s.wsAuthEnabled.Store(cfg.WebsocketAuth)
 	if oldCfg != nil && s.wsAuthChanged != nil && oldCfg.WebsocketAuth != cfg.WebsocketAuth {
@@ -1093,6 +1154,15 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	)
 }
 
+// SetAuthRescanHandler installs the function the management API calls to rescan the auth
+// directory.
+func (s *Server) SetAuthRescanHandler(fn func() (watcher.AuthRescanResult, error)) {
+	if s == nil || s.mgmt == nil {
+		return
+	}
+	s.mgmt.SetAuthRescanner(fn)
+}
+
 func (s *Server) SetWebsocketAuthChangeHandler(fn func(bool, bool)) {
 	if s == nil {
 		return
@@ -0,0 +1,60 @@
+package gemini
+
+import "strings"
+
+// Gemini Code Assist tiers reported by loadCodeAssist.
+const (
+	// TierFree is the free tier for personal accounts. Its requests run in a project
+	// that Google provisions and manages for the user.
+	TierFree = "free-tier"
+	// TierLegacy is the tier of accounts onboarded before tiers existed.
+	TierLegacy = "legacy-tier"
+	// TierStandard is the paid tier bound to a user-selected Google Cloud project.
+	TierStandard = "standard-tier"
+)
+
+// CodeAssistTier picks the tier from a loadCodeAssist response: the account's current
+// tier when it is already onboarded, otherwise the default allowed tier, otherwise the
+// legacy tier. onboarded reports whether the account already has a current tier.
+func CodeAssistTier(loadResp map[string]any) (tierID string, onboarded bool) {
+	if current, ok := loadResp["currentTier"].(map[string]any); ok {
+		if id, okID := current["id"].(string); okID && strings.TrimSpace(id) != "" {
+			return strings.TrimSpace(id), true
+		}
+	}
+	if tiers, ok := loadResp["allowedTiers"].([]any); ok {
+		for _, rawTier := range tiers {
+			tier, okTier := rawTier.(map[string]any)
+			if !okTier {
+				continue
+			}
+			if isDefault, okDefault := tier["isDefault"].(bool); okDefault && isDefault {
+				if id, okID := tier["id"].(string); okID && strings.TrimSpace(id) != "" {
+					return strings.TrimSpace(id), false
+				}
+			}
+		}
+	}
+	return TierLegacy, false
+}
+
+// CodeAssistProject extracts cloudaicompanionProject from a loadCodeAssist or onboardUser
+// response, which carries it either as an ID string or as an object with an id field.
+func CodeAssistProject(resp map[string]any) string {
+	switch project := resp["cloudaicompanionProject"].(type) {
+	case string:
+		return strings.TrimSpace(project)
+	case map[string]any:
+		if id, ok := project["id"].(string); ok {
+			return strings.TrimSpace(id)
+		}
+	}
+	return ""
+}
+
+// IsManagedProjectTier reports whether requests of the tier run in a Google-managed
+// project. Such projects are discovered through onboardUser without naming a project and
+// cannot be checked for the Cloud AI Companion API, which Google enables for them.
+func IsManagedProjectTier(tierID string) bool {
+	return strings.EqualFold(strings.TrimSpace(tierID), TierFree)
+}
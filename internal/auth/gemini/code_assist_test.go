@@ -0,0 +1,49 @@
+package gemini
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCodeAssistTier(t *testing.T) {
+	cases := []struct {
+		name          string
+		resp          string
+		wantTier      string
+		wantOnboarded bool
+	}{
+		{"current tier", `{"currentTier":{"id":"standard-tier"},"allowedTiers":[{"id":"free-tier","isDefault":true}]}`, TierStandard, true},
+		{"default allowed tier", `{"allowedTiers":[{"id":"legacy-tier"},{"id":"free-tier","isDefault":true}]}`, TierFree, false},
+		{"no tiers", `{}`, TierLegacy, false},
+	}
+	for _, tc := range cases {
+		var resp map[string]any
+		if err := json.Unmarshal([]byte(tc.resp), &resp); err != nil {
+			t.Fatal(err)
+		}
+		tier, onboarded := CodeAssistTier(resp)
+		if tier != tc.wantTier || onboarded != tc.wantOnboarded {
+			t.Errorf("%s: got (%q, %v), want (%q, %v)", tc.name, tier, onboarded, tc.wantTier, tc.wantOnboarded)
+		}
+	}
+}
+
+func TestCodeAssistProject(t *testing.T) {
+	for raw, want := range map[string]string{
+		`{"cloudaicompanionProject":" proj-a "}`:         "proj-a",
+		`{"cloudaicompanionProject":{"id":"proj-b"}}`:    "proj-b",
+		`{"cloudaicompanionProject":{"name":"ignored"}}`: "",
+		`{}`: "",
+	} {
+		var resp map[string]any
+		if err := json.Unmarshal([]byte(raw), &resp); err != nil {
+			t.Fatal(err)
+		}
+		if got := CodeAssistProject(resp); got != want {
+			t.Errorf("CodeAssistProject(%s) = %q, want %q", raw, got, want)
+		}
+	}
+	if !IsManagedProjectTier("FREE-TIER") || IsManagedProjectTier(TierStandard) {
+		t.Error("only the free tier uses a managed project")
+	}
+}
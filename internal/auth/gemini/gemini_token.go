@@ -33,6 +33,10 @@ type GeminiTokenStorage struct {
 	// Checked indicates if the associated Cloud AI API has been verified as enabled.
 	Checked bool `json:"checked"`
 
+	// Tier is the Gemini Code Assist tier detected during onboarding, e.g. "free-tier"
+	// or "standard-tier".
+	Tier string `json:"tier,omitempty"`
+
 	// Type indicates the authentication provider type, always "gemini" for this storage.
 	Type string `json:"type"`
 }
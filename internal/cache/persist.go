@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Persister keeps cache snapshots outside the process so caches survive restarts.
+type Persister interface {
+	Load() (Snapshot, error)
+	Save(Snapshot) error
+}
+
+var (
+	persisterMu sync.Mutex
+	persister   Persister
+)
+
+// SetPersister installs p, restoring its last snapshot into the caches. The caches are
+// saved to p on every cleanup tick and by FlushPersister. A nil p disables persistence.
+func SetPersister(p Persister) error {
+	persisterMu.Lock()
+	persister = p
+	persisterMu.Unlock()
+	if p == nil {
+		return nil
+	}
+	cacheCleanupOnce.Do(startCacheCleanup)
+	snapshot, err := p.Load()
+	if err != nil {
+		return err
+	}
+	result := ImportSnapshot(snapshot)
+	if result.Imported > 0 {
+		log.Infof("cache: restored %d entries from snapshot (%d skipped)", result.Imported, result.Skipped)
+	}
+	return nil
+}
+
+// FlushPersister saves the current caches to the installed persister, if any.
+func FlushPersister() error {
+	persisterMu.Lock()
+	p := persister
+	persisterMu.Unlock()
+	if p == nil {
+		return nil
+	}
+	return p.Save(ExportSnapshot())
+}
+
+// FilePersister stores snapshots as JSON in a local file.
+type FilePersister struct {
+	Path string
+}
+
+// Load reads the snapshot file; a missing file yields an empty snapshot.
+func (f FilePersister) Load() (Snapshot, error) {
+	var snapshot Snapshot
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, os.ErrNotExist) {
+		return snapshot, nil
+	}
+	if err != nil {
+		return snapshot, fmt.Errorf("cache: read snapshot: %w", err)
+	}
+	if err = json.Unmarshal(data, &snapshot); err != nil {
+		return snapshot, fmt.Errorf("cache: decode snapshot %s: %w", f.Path, err)
+	}
+	return snapshot, nil
+}
+
+// Save writes the snapshot atomically through a temporary file in the same directory.
+func (f FilePersister) Save(snapshot Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("cache: encode snapshot: %w", err)
+	}
+	dir := filepath.Dir(f.Path)
+	if err = os.MkdirAll(dir, 0o700); err != nil {
+		return fmt.Errorf("cache: create snapshot directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, ".cache-snapshot-*")
+	if err != nil {
+		return fmt.Errorf("cache: create snapshot file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("cache: write snapshot: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("cache: write snapshot: %w", err)
+	}
+	if err = os.Rename(tmp.Name(), f.Path); err != nil {
+		return fmt.Errorf("cache: replace snapshot: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package cache
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// UserDataPurger removes the entries a store holds for a downstream API key, or for every
+// key when apiKey is empty, that were stored before the given time, or at any time when
+// before is zero. It returns how many entries were removed.
+type UserDataPurger func(apiKey string, before time.Time) int
+
+var userDataPurgers = struct {
+	sync.RWMutex
+	byName map[string]UserDataPurger
+}{byName: make(map[string]UserDataPurger)}
+
+// RegisterUserDataPurger registers the purger of a store that keeps data per downstream
+// API key, such as the stored responses. Registering a name again replaces its purger.
+func RegisterUserDataPurger(name string, purger UserDataPurger) {
+	if name == "" || purger == nil {
+		return
+	}
+	userDataPurgers.Lock()
+	userDataPurgers.byName[name] = purger
+	userDataPurgers.Unlock()
+}
+
+// UserDataStores lists the names of the registered per-key stores.
+func UserDataStores() []string {
+	userDataPurgers.RLock()
+	names := make([]string, 0, len(userDataPurgers.byName))
+	for name := range userDataPurgers.byName {
+		names = append(names, name)
+	}
+	userDataPurgers.RUnlock()
+	sort.Strings(names)
+	return names
+}
+
+// PurgeUserData runs every registered purger and returns the entries removed per store.
+func PurgeUserData(apiKey string, before time.Time) map[string]int {
+	userDataPurgers.RLock()
+	defer userDataPurgers.RUnlock()
+	removed := make(map[string]int, len(userDataPurgers.byName))
+	for name, purger := range userDataPurgers.byName {
+		removed[name] = purger(apiKey, before)
+	}
+	return removed
+}
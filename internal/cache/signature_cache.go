@@ -4,16 +4,9 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"strings"
-	"sync"
 	"time"
 )
 
-// SignatureEntry holds a cached thinking signature with timestamp
-type SignatureEntry struct {
-	Signature string
-	Timestamp time.Time
-}
-
 const (
 	// SignatureCacheTTL is how long signatures are valid
 	SignatureCacheTTL = 2 * time.Hour
@@ -31,17 +24,8 @@ const (
 	CacheCleanupInterval = 10 * time.Minute
 )
 
-// signatureCache stores signatures by model group -> textHash -> SignatureEntry
-var signatureCache sync.Map
-
-// cacheCleanupOnce ensures the background cleanup goroutine starts only once
-var cacheCleanupOnce sync.Once
-
-// groupCache is the inner map type
-type groupCache struct {
-	mu      sync.RWMutex
-	entries map[string]SignatureEntry
-}
+// signatureCache stores signatures keyed by "model group:textHash"
+var signatureCache = newTTLCache[string]("signature", SignatureCacheTTL)
 
 // hashText creates a stable, Unicode-safe key from text content
 func hashText(text string) string {
@@ -49,51 +33,18 @@ func hashText(text string) string {
 	return hex.EncodeToString(h[:])[:SignatureTextHashLen]
 }
 
-// getOrCreateGroupCache gets or creates a cache bucket for a model group
-func getOrCreateGroupCache(groupKey string) *groupCache {
-	// Start background cleanup on first access
-	cacheCleanupOnce.Do(startCacheCleanup)
-
-	if val, ok := signatureCache.Load(groupKey); ok {
-		return val.(*groupCache)
-	}
-	sc := &groupCache{entries: make(map[string]SignatureEntry)}
-	actual, _ := signatureCache.LoadOrStore(groupKey, sc)
-	return actual.(*groupCache)
+// signatureKey joins a model group and text hash into a cache key
+func signatureKey(groupKey, textHash string) string {
+	return groupKey + ":" + textHash
 }
 
-// startCacheCleanup launches a background goroutine that periodically
-// removes caches where all entries have expired.
-func startCacheCleanup() {
-	go func() {
-		ticker := time.NewTicker(CacheCleanupInterval)
-		defer ticker.Stop()
-		for range ticker.C {
-			purgeExpiredCaches()
-		}
-	}()
-}
-
-// purgeExpiredCaches removes caches with no valid (non-expired) entries.
-func purgeExpiredCaches() {
-	now := time.Now()
-	signatureCache.Range(func(key, value any) bool {
-		sc := value.(*groupCache)
-		sc.mu.Lock()
-		// Remove expired entries
-		for k, entry := range sc.entries {
-			if now.Sub(entry.Timestamp) > SignatureCacheTTL {
-				delete(sc.entries, k)
-			}
-		}
-		isEmpty := len(sc.entries) == 0
-		sc.mu.Unlock()
-		// Remove cache bucket if empty
-		if isEmpty {
-			signatureCache.Delete(key)
-		}
-		return true
-	})
+// splitSignatureKey reverses signatureKey; the hash is the fixed-length suffix
+func splitSignatureKey(key string) (groupKey, textHash string) {
+	i := strings.LastIndex(key, ":")
+	if i < 0 {
+		return "", key
+	}
+	return key[:i], key[i+1:]
 }
 
 // CacheSignature stores a thinking signature for a given model group and text.
@@ -106,79 +57,28 @@ func CacheSignature(modelName, text, signature string) {
 		return
 	}
 
-	groupKey := GetModelGroup(modelName)
-	textHash := hashText(text)
-	sc := getOrCreateGroupCache(groupKey)
-	sc.mu.Lock()
-	defer sc.mu.Unlock()
-
-	sc.entries[textHash] = SignatureEntry{
-		Signature: signature,
-		Timestamp: time.Now(),
-	}
+	key := signatureKey(GetModelGroup(modelName), hashText(text))
+	signatureCache.store(key, signature, time.Now(), len(signature))
 }
 
 // GetCachedSignature retrieves a cached signature for a given model group and text.
-// Returns empty string if not found or expired.
+// Returns empty string if not found or expired. Access refreshes the TTL (sliding expiration).
+// Translators resolving many blocks of one request should use a SignatureLookup.
 func GetCachedSignature(modelName, text string) string {
-	groupKey := GetModelGroup(modelName)
-
-	if text == "" {
-		if groupKey == "gemini" {
-			return "skip_thought_signature_validator"
-		}
-		return ""
-	}
-	val, ok := signatureCache.Load(groupKey)
-	if !ok {
-		if groupKey == "gemini" {
-			return "skip_thought_signature_validator"
-		}
-		return ""
-	}
-	sc := val.(*groupCache)
-
-	textHash := hashText(text)
-
-	now := time.Now()
-
-	sc.mu.Lock()
-	entry, exists := sc.entries[textHash]
-	if !exists {
-		sc.mu.Unlock()
-		if groupKey == "gemini" {
-			return "skip_thought_signature_validator"
-		}
-		return ""
-	}
-	if now.Sub(entry.Timestamp) > SignatureCacheTTL {
-		delete(sc.entries, textHash)
-		sc.mu.Unlock()
-		if groupKey == "gemini" {
-			return "skip_thought_signature_validator"
-		}
-		return ""
-	}
-
-	// Refresh TTL on access (sliding expiration).
-	entry.Timestamp = now
-	sc.entries[textHash] = entry
-	sc.mu.Unlock()
-
-	return entry.Signature
+	return NewSignatureLookup(modelName).CachedSignature(text)
 }
 
 // ClearSignatureCache clears signature cache for a specific model group or all groups.
 func ClearSignatureCache(modelName string) {
 	if modelName == "" {
-		signatureCache.Range(func(key, _ any) bool {
-			signatureCache.Delete(key)
-			return true
-		})
+		signatureCache.clear()
 		return
 	}
 	groupKey := GetModelGroup(modelName)
-	signatureCache.Delete(groupKey)
+	signatureCache.deleteFunc(func(key string) bool {
+		group, _ := splitSignatureKey(key)
+		return group == groupKey
+	})
 }
 
 // HasValidSignature checks if a signature is valid (non-empty and long enough)
@@ -209,7 +109,7 @@ type ThinkingEntry struct {
 }
 
 const (
-	// ThinkingCacheTTL là thời gian thinking cache còn hiệu lực (dài hơn signature cache)
+	// ThinkingCacheTTL là thời gian thinking cache còn hiệu lực kể từ lần dùng cuối (dài hơn signature cache)
 	ThinkingCacheTTL = 2 * time.Hour
 
 	// MaxThinkingEntriesPerSession giới hạn số thinking entries mỗi session
@@ -219,14 +119,7 @@ const (
 	ThinkingIDLen = 32
 )
 
-// thinkingCache stores thinking by sessionId -> thinkingId -> ThinkingEntry
-var thinkingCache sync.Map
-
-// thinkingSessionCache là inner map type cho thinking cache
-type thinkingSessionCache struct {
-	mu      sync.RWMutex
-	entries map[string]ThinkingEntry
-}
+// thinkingCache (thinking_store.go) là LRU store giới hạn theo dung lượng, dùng chung cho mọi session
 
 // GenerateThinkingID tạo hash-based ID từ thinking text
 func GenerateThinkingID(thinkingText string) string {
@@ -234,6 +127,49 @@ func GenerateThinkingID(thinkingText string) string {
 	return hex.EncodeToString(h[:])[:ThinkingIDLen]
 }
 
+// ChainBranchKey nối thêm một turn vào hash chain của conversation branch.
+// Key của turn thứ n phụ thuộc vào toàn bộ các turn trước, nên khi client sửa
+// một message cũ thì các turn phía sau sẽ có key khác.
+func ChainBranchKey(prevKey, turn string) string {
+	h := sha256.New()
+	h.Write([]byte(prevKey))
+	h.Write([]byte{0})
+	h.Write([]byte(turn))
+	return hex.EncodeToString(h.Sum(nil))[:ThinkingIDLen]
+}
+
+// branchThinkingKey là key lưu thinking của một branch cụ thể
+func branchThinkingKey(branchKey, thinkingID string) string {
+	if branchKey == "" {
+		return thinkingID
+	}
+	return branchKey + ":" + thinkingID
+}
+
+// CacheBranchThinking lưu thinking theo thinkingID trong phạm vi một conversation branch.
+// branchKey là hash chain của các turn đứng trước assistant message chứa thinking.
+func CacheBranchThinking(branchKey, thinkingID, thinkingText, signature string) {
+	if thinkingID == "" {
+		return
+	}
+	CacheThinking(branchThinkingKey(branchKey, thinkingID), thinkingText, signature)
+}
+
+// GetBranchThinking lấy thinking đã cache cho branch hiện tại.
+// Entry cache trước khi có branch key (chỉ theo thinkingID) vẫn được dùng làm fallback;
+// entry thuộc branch khác thì không được trả về để tránh signature mismatch.
+func GetBranchThinking(branchKey, thinkingID string) *ThinkingEntry {
+	if thinkingID == "" {
+		return nil
+	}
+	if branchKey != "" {
+		if entry := GetCachedThinking(branchThinkingKey(branchKey, thinkingID)); entry != nil {
+			return entry
+		}
+	}
+	return GetCachedThinking(thinkingID)
+}
+
 // CacheThinking lưu thinking content với signature theo thinkingID
 // Note: Đã loại bỏ sessionID vì không cần thiết - chỉ cần thinkingID là đủ
 func CacheThinking(thinkingID, thinkingText, signature string) {
@@ -241,13 +177,11 @@ func CacheThinking(thinkingID, thinkingText, signature string) {
 		return
 	}
 
-	entry := ThinkingEntry{
+	thinkingCache.put(thinkingID, ThinkingEntry{
 		ThinkingText: thinkingText,
 		Signature:    signature,
 		Timestamp:    time.Now(),
-	}
-	
-	thinkingCache.Store(thinkingID, entry)
+	})
 }
 
 // GetCachedThinking lấy cached thinking entry theo thinkingID
@@ -257,30 +191,14 @@ func GetCachedThinking(thinkingID string) *ThinkingEntry {
 		return nil
 	}
 
-	val, ok := thinkingCache.Load(thinkingID)
-	if !ok {
-		return nil
-	}
-	
-	entry := val.(ThinkingEntry)
-
-	// Check if expired
-	if time.Since(entry.Timestamp) > ThinkingCacheTTL {
-		thinkingCache.Delete(thinkingID)
-		return nil
-	}
-
-	return &entry
+	return thinkingCache.get(thinkingID)
 }
 
 // ClearThinkingCache xóa thinking cache cho một thinkingID cụ thể hoặc tất cả
 func ClearThinkingCache(thinkingID string) {
 	if thinkingID != "" {
-		thinkingCache.Delete(thinkingID)
+		thinkingCache.delete(thinkingID)
 	} else {
-		thinkingCache.Range(func(key, _ any) bool {
-			thinkingCache.Delete(key)
-			return true
-		})
+		thinkingCache.clear()
 	}
 }
@@ -0,0 +1,83 @@
+package cache
+
+// SignatureLookup resolves cached signatures for one request. It computes the model
+// group once and hashes each distinct thinking text once, and Prefetch resolves all
+// thinking blocks of a history with one signature cache lock instead of one per block,
+// so concurrent requests replaying the same session contend far less. It is not safe
+// for concurrent use.
+type SignatureLookup struct {
+	group    string
+	hashes   map[string]string
+	resolved map[string]string // signatures found by Prefetch, keyed by thinking text
+}
+
+// NewSignatureLookup creates a lookup for the given model.
+func NewSignatureLookup(modelName string) *SignatureLookup {
+	return &SignatureLookup{group: GetModelGroup(modelName)}
+}
+
+// ModelGroup returns the model group of the request's model.
+func (l *SignatureLookup) ModelGroup() string {
+	return l.group
+}
+
+// Prefetch loads the cached signatures of texts under a single cache lock. Later
+// CachedSignature calls for these texts do not touch the cache.
+func (l *SignatureLookup) Prefetch(texts []string) {
+	if l.resolved == nil {
+		l.resolved = make(map[string]string, len(texts))
+	}
+	pending := make([]string, 0, len(texts))
+	keys := make([]string, 0, len(texts))
+	for _, text := range texts {
+		if _, done := l.resolved[text]; done || text == "" {
+			continue
+		}
+		l.resolved[text] = ""
+		pending = append(pending, text)
+		keys = append(keys, signatureKey(l.group, hashText(text)))
+	}
+	if len(keys) == 0 {
+		return
+	}
+	signatures, found := signatureCache.loadAll(keys)
+	for i, text := range pending {
+		if found[i] {
+			l.resolved[text] = signatures[i]
+		}
+	}
+}
+
+// CachedSignature behaves like GetCachedSignature for the request's model.
+func (l *SignatureLookup) CachedSignature(text string) string {
+	if text != "" {
+		if signature, prefetched := l.resolved[text]; prefetched {
+			if signature != "" {
+				return signature
+			}
+		} else if signature, ok := signatureCache.load(signatureKey(l.group, l.hash(text))); ok {
+			return signature
+		}
+	}
+	if l.group == "gemini" {
+		return "skip_thought_signature_validator"
+	}
+	return ""
+}
+
+// HasValidSignature behaves like HasValidSignature for the request's model.
+func (l *SignatureLookup) HasValidSignature(signature string) bool {
+	return (signature != "" && len(signature) >= MinValidSignatureLen) || (signature == "skip_thought_signature_validator" && l.group == "gemini")
+}
+
+func (l *SignatureLookup) hash(text string) string {
+	if textHash, ok := l.hashes[text]; ok {
+		return textHash
+	}
+	if l.hashes == nil {
+		l.hashes = make(map[string]string)
+	}
+	textHash := hashText(text)
+	l.hashes[text] = textHash
+	return textHash
+}
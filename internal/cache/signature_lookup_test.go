@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestSignatureLookup_MatchesGetCachedSignature(t *testing.T) {
+	ClearSignatureCache("")
+	defer ClearSignatureCache("")
+
+	signature := strings.Repeat("s", MinValidSignatureLen)
+	CacheSignature(testModelName, "cached thinking", signature)
+
+	lookup := NewSignatureLookup(testModelName)
+	for i := 0; i < 2; i++ {
+		if got := lookup.CachedSignature("cached thinking"); got != signature {
+			t.Fatalf("CachedSignature = %q, want %q", got, signature)
+		}
+	}
+	if len(lookup.hashes) != 1 {
+		t.Fatalf("hashed %d texts, want 1", len(lookup.hashes))
+	}
+	if got := lookup.CachedSignature("unknown thinking"); got != "" {
+		t.Fatalf("CachedSignature of unknown text = %q", got)
+	}
+	if lookup.ModelGroup() != "claude" || !lookup.HasValidSignature(signature) || lookup.HasValidSignature("short") {
+		t.Fatal("lookup disagrees with GetModelGroup or HasValidSignature")
+	}
+
+	prefetched := NewSignatureLookup(testModelName)
+	reads := signatureCache.readLocks()
+	prefetched.Prefetch([]string{"cached thinking", "unknown thinking", "cached thinking"})
+	if got := prefetched.CachedSignature("cached thinking"); got != signature {
+		t.Fatalf("prefetched CachedSignature = %q, want %q", got, signature)
+	}
+	if got := prefetched.CachedSignature("unknown thinking"); got != "" {
+		t.Fatalf("prefetched CachedSignature of unknown text = %q", got)
+	}
+	if locks := signatureCache.readLocks() - reads; locks != 1 {
+		t.Fatalf("prefetch and lookups took the cache lock %d times, want 1", locks)
+	}
+
+	gemini := NewSignatureLookup("gemini-2.5-pro")
+	if got := gemini.CachedSignature("unknown thinking"); got != GetCachedSignature("gemini-2.5-pro", "unknown thinking") {
+		t.Fatalf("gemini fallback = %q", got)
+	}
+	if !gemini.HasValidSignature("skip_thought_signature_validator") {
+		t.Fatal("gemini skip sentinel should be valid")
+	}
+}
+
+// benchmarkSessionHistory caches the thinking blocks of one session and returns them, so
+// every parallel request replays the same history.
+func benchmarkSessionHistory(b *testing.B) []string {
+	b.Helper()
+	ClearSignatureCache("")
+	b.Cleanup(func() { ClearSignatureCache("") })
+	history := make([]string, 20)
+	for i := range history {
+		history[i] = fmt.Sprintf("%s turn %d", strings.Repeat("reasoning about the task ", 200), i)
+		CacheSignature(testModelName, history[i], strings.Repeat("s", MinValidSignatureLen))
+	}
+	return history
+}
+
+// cacheLockContentions returns how many contended acquisitions of a cache lock the
+// mutex profile has recorded.
+func cacheLockContentions() int64 {
+	records := make([]runtime.BlockProfileRecord, 64)
+	n, ok := runtime.MutexProfile(records)
+	for !ok {
+		records = make([]runtime.BlockProfileRecord, n+64)
+		n, ok = runtime.MutexProfile(records)
+	}
+	var total int64
+	for _, record := range records[:n] {
+		frames := runtime.CallersFrames(record.Stack())
+		for {
+			frame, more := frames.Next()
+			if strings.Contains(frame.Function, "cache.(*ttlCache[") {
+				total += record.Count
+				break
+			}
+			if !more {
+				break
+			}
+		}
+	}
+	return total
+}
+
+// benchmarkHistoryLookups resolves the replayed history from parallel requests with
+// resolve and reports, per thinking block, how often the signature cache lock was taken
+// and how often a request had to wait for it.
+func benchmarkHistoryLookups(b *testing.B, resolve func(history []string)) {
+	history := benchmarkSessionHistory(b)
+	previous := runtime.SetMutexProfileFraction(1)
+	defer runtime.SetMutexProfileFraction(previous)
+	locksBefore := signatureCache.readLocks()
+	contentionsBefore := cacheLockContentions()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			resolve(history)
+		}
+	})
+	b.StopTimer()
+	blocks := float64(b.N * len(history))
+	b.ReportMetric(float64(signatureCache.readLocks()-locksBefore)/blocks, "locks/block")
+	b.ReportMetric(float64(cacheLockContentions()-contentionsBefore)/blocks, "contentions/block")
+}
+
+// BenchmarkSignatureLookup_PerBlock resolves every block of a replayed history the way
+// translators did before SignatureLookup: group, hash and one cache lock per block.
+func BenchmarkSignatureLookup_PerBlock(b *testing.B) {
+	benchmarkHistoryLookups(b, func(history []string) {
+		for _, text := range history {
+			if GetCachedSignature(testModelName, text) == "" {
+				b.Fatal("signature not found")
+			}
+		}
+	})
+}
+
+// BenchmarkSignatureLookup_Request resolves the same history through a SignatureLookup
+// that prefetches every block with one cache lock.
+func BenchmarkSignatureLookup_Request(b *testing.B) {
+	benchmarkHistoryLookups(b, func(history []string) {
+		lookup := NewSignatureLookup(testModelName)
+		lookup.Prefetch(history)
+		for _, text := range history {
+			if lookup.CachedSignature(text) == "" {
+				b.Fatal("signature not found")
+			}
+		}
+	})
+}
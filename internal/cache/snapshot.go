@@ -0,0 +1,152 @@
+package cache
+
+import "time"
+
+// CacheStats summarises the current contents of the signature and thinking caches.
+type CacheStats struct {
+	SignatureEntries int            `json:"signature_entries"`
+	SignatureGroups  map[string]int `json:"signature_groups"`
+	ThinkingEntries  int            `json:"thinking_entries"`
+	ThinkingBytes    int            `json:"thinking_bytes"`
+	OldestEntry      *time.Time     `json:"oldest_entry,omitempty"`
+	NewestEntry      *time.Time     `json:"newest_entry,omitempty"`
+	// Metrics reports hits, misses, evictions and expirations per cache.
+	Metrics map[string]CacheMetrics `json:"metrics"`
+}
+
+// SignatureSnapshotEntry is the serialisable form of a cached signature.
+type SignatureSnapshotEntry struct {
+	Signature string    `json:"signature"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ThinkingSnapshotEntry is the serialisable form of a cached thinking block.
+type ThinkingSnapshotEntry struct {
+	ThinkingText string    `json:"thinking_text"`
+	Signature    string    `json:"signature"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// Snapshot is a point-in-time copy of both caches, used to migrate or warm up instances.
+// Signatures are keyed by model group then text hash; thinking entries by thinkingID.
+type Snapshot struct {
+	Version    int                                          `json:"version"`
+	ExportedAt time.Time                                    `json:"exported_at"`
+	Signatures map[string]map[string]SignatureSnapshotEntry `json:"signatures"`
+	Thinking   map[string]ThinkingSnapshotEntry             `json:"thinking"`
+}
+
+// ImportResult reports how many snapshot entries were restored or skipped.
+type ImportResult struct {
+	Imported int `json:"imported"`
+	Skipped  int `json:"skipped"`
+}
+
+// Stats returns counts and age bounds for the non-expired cache entries.
+func Stats() CacheStats {
+	stats := CacheStats{SignatureGroups: make(map[string]int), Metrics: Metrics()}
+	now := time.Now()
+	var oldest, newest time.Time
+	track := func(ts time.Time) {
+		if oldest.IsZero() || ts.Before(oldest) {
+			oldest = ts
+		}
+		if ts.After(newest) {
+			newest = ts
+		}
+	}
+
+	for _, entry := range signatureCache.live(now) {
+		group, _ := splitSignatureKey(entry.key)
+		stats.SignatureEntries++
+		stats.SignatureGroups[group]++
+		track(entry.timestamp)
+	}
+	for _, entry := range thinkingCache.live(now) {
+		stats.ThinkingEntries++
+		stats.ThinkingBytes += entry.size
+		track(entry.timestamp)
+	}
+
+	if !oldest.IsZero() {
+		stats.OldestEntry = &oldest
+		stats.NewestEntry = &newest
+	}
+	return stats
+}
+
+// ExportSnapshot copies all non-expired cache entries into a Snapshot.
+func ExportSnapshot() Snapshot {
+	now := time.Now()
+	snapshot := Snapshot{
+		Version:    1,
+		ExportedAt: now.UTC(),
+		Signatures: make(map[string]map[string]SignatureSnapshotEntry),
+		Thinking:   make(map[string]ThinkingSnapshotEntry),
+	}
+
+	for _, entry := range signatureCache.live(now) {
+		group, hash := splitSignatureKey(entry.key)
+		if snapshot.Signatures[group] == nil {
+			snapshot.Signatures[group] = make(map[string]SignatureSnapshotEntry)
+		}
+		snapshot.Signatures[group][hash] = SignatureSnapshotEntry{Signature: entry.value, Timestamp: entry.timestamp}
+	}
+	for _, entry := range thinkingCache.live(now) {
+		decoded, ok := entry.value.decode(entry.key)
+		if !ok {
+			continue
+		}
+		snapshot.Thinking[entry.key] = ThinkingSnapshotEntry{
+			ThinkingText: decoded.ThinkingText,
+			Signature:    decoded.Signature,
+			Timestamp:    entry.timestamp,
+		}
+	}
+	return snapshot
+}
+
+// ImportSnapshot merges a Snapshot into the caches.
+// Expired or invalid entries are skipped, and existing entries are only replaced
+// when the snapshot entry is newer.
+func ImportSnapshot(snapshot Snapshot) ImportResult {
+	var result ImportResult
+	now := time.Now()
+
+	for groupKey, entries := range snapshot.Signatures {
+		for hash, entry := range entries {
+			if len(hash) != SignatureTextHashLen || len(entry.Signature) < MinValidSignatureLen || now.Sub(entry.Timestamp) > SignatureCacheTTL {
+				result.Skipped++
+				continue
+			}
+			key := signatureKey(groupKey, hash)
+			if existing, ok := signatureCache.lastUsed(key); ok && !entry.Timestamp.After(existing) {
+				result.Skipped++
+				continue
+			}
+			signatureCache.store(key, entry.Signature, entry.Timestamp, len(entry.Signature))
+			result.Imported++
+		}
+	}
+
+	for thinkingID, entry := range snapshot.Thinking {
+		if thinkingID == "" || entry.ThinkingText == "" || now.Sub(entry.Timestamp) > ThinkingCacheTTL {
+			result.Skipped++
+			continue
+		}
+		if existing, ok := thinkingCache.lastUsed(thinkingID); ok && !entry.Timestamp.After(existing) {
+			result.Skipped++
+			continue
+		}
+		if !thinkingCache.put(thinkingID, ThinkingEntry{
+			ThinkingText: entry.ThinkingText,
+			Signature:    entry.Signature,
+			Timestamp:    entry.Timestamp,
+		}) {
+			result.Skipped++
+			continue
+		}
+		result.Imported++
+	}
+	return result
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExportImportSnapshot_RoundTrip(t *testing.T) {
+	ClearSignatureCache("")
+	ClearThinkingCache("")
+
+	text := "thinking text for snapshot"
+	signature := "snapshotSignature_123456789012345678901234567890123456789"
+	CacheSignature(testModelName, text, signature)
+	thinkingID := GenerateThinkingID(text)
+	CacheThinking(thinkingID, text, signature)
+
+	snapshot := ExportSnapshot()
+	if got := len(snapshot.Signatures["claude"]); got != 1 {
+		t.Fatalf("expected 1 claude signature in snapshot, got %d", got)
+	}
+	if _, ok := snapshot.Thinking[thinkingID]; !ok {
+		t.Fatalf("expected thinking entry %s in snapshot", thinkingID)
+	}
+
+	ClearSignatureCache("")
+	ClearThinkingCache("")
+
+	result := ImportSnapshot(snapshot)
+	if result.Imported != 2 || result.Skipped != 0 {
+		t.Fatalf("unexpected import result: %+v", result)
+	}
+	if got := GetCachedSignature(testModelName, text); got != signature {
+		t.Errorf("signature not restored, got %q", got)
+	}
+	if entry := GetCachedThinking(thinkingID); entry == nil || entry.ThinkingText != text {
+		t.Errorf("thinking entry not restored: %+v", entry)
+	}
+
+	stats := Stats()
+	if stats.SignatureEntries != 1 || stats.ThinkingEntries != 1 || stats.SignatureGroups["claude"] != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestImportSnapshot_SkipsExpiredEntries(t *testing.T) {
+	ClearSignatureCache("")
+	ClearThinkingCache("")
+
+	old := time.Now().Add(-SignatureCacheTTL - time.Minute)
+	snapshot := Snapshot{
+		Signatures: map[string]map[string]SignatureSnapshotEntry{
+			"claude": {hashText("x"): {Signature: "expiredSignature_12345678901234567890123456789012345678", Timestamp: old}},
+		},
+		Thinking: map[string]ThinkingSnapshotEntry{
+			"abc": {ThinkingText: "x", Timestamp: old},
+		},
+	}
+
+	result := ImportSnapshot(snapshot)
+	if result.Imported != 0 || result.Skipped != 2 {
+		t.Fatalf("unexpected import result: %+v", result)
+	}
+}
@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultThinkingCacheMaxBytes is the global thinking cache budget used when none is configured.
+const DefaultThinkingCacheMaxBytes = 64 << 20
+
+// ThinkingCacheLimits bounds the memory used by the thinking cache.
+type ThinkingCacheLimits struct {
+	// MaxEntryBytes skips thinking blocks whose text is larger than this. 0 means no limit.
+	MaxEntryBytes int
+	// MaxTotalBytes is the budget across all conversations; least recently used entries
+	// are evicted beyond it. 0 selects DefaultThinkingCacheMaxBytes.
+	MaxTotalBytes int
+	// Compress stores thinking text zstd-compressed when that makes it smaller.
+	Compress bool
+}
+
+// storedThinking is a thinking cache value as held in memory.
+type storedThinking struct {
+	text       []byte
+	compressed bool
+	signature  string
+}
+
+// thinkingCache stores thinking by thinkingID (optionally prefixed with a branch key).
+var thinkingCache = newThinkingStore()
+
+// thinkingStore adds the per-entry cap and compression to the generic cache.
+type thinkingStore struct {
+	*ttlCache[storedThinking]
+	limitsMu sync.Mutex
+	limits   ThinkingCacheLimits
+}
+
+func newThinkingStore() *thinkingStore {
+	s := &thinkingStore{
+		ttlCache: newTTLCache[storedThinking]("thinking", ThinkingCacheTTL),
+		limits:   ThinkingCacheLimits{MaxTotalBytes: DefaultThinkingCacheMaxBytes},
+	}
+	s.setMaxBytes(DefaultThinkingCacheMaxBytes)
+	return s
+}
+
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+)
+
+func initZstd() {
+	zstdOnce.Do(func() {
+		var err error
+		if zstdEncoder, err = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest)); err != nil {
+			log.Warnf("thinking cache: zstd encoder unavailable: %v", err)
+		}
+		if zstdDecoder, err = zstd.NewReader(nil); err != nil {
+			log.Warnf("thinking cache: zstd decoder unavailable: %v", err)
+		}
+	})
+}
+
+// SetThinkingCacheLimits applies new limits, evicting entries that no longer fit.
+// The compression setting only affects entries stored afterwards.
+func SetThinkingCacheLimits(limits ThinkingCacheLimits) {
+	if limits.MaxEntryBytes < 0 {
+		limits.MaxEntryBytes = 0
+	}
+	if limits.MaxTotalBytes <= 0 {
+		limits.MaxTotalBytes = DefaultThinkingCacheMaxBytes
+	}
+	thinkingCache.limitsMu.Lock()
+	thinkingCache.limits = limits
+	thinkingCache.limitsMu.Unlock()
+	thinkingCache.setMaxBytes(limits.MaxTotalBytes)
+}
+
+// put stores entry under key, replacing any previous value. Entries above the per-entry
+// cap are not cached.
+func (s *thinkingStore) put(key string, entry ThinkingEntry) bool {
+	s.limitsMu.Lock()
+	limits := s.limits
+	s.limitsMu.Unlock()
+
+	if limits.MaxEntryBytes > 0 && len(entry.ThinkingText) > limits.MaxEntryBytes {
+		s.delete(key)
+		return false
+	}
+	stored := storedThinking{text: []byte(entry.ThinkingText), signature: entry.Signature}
+	if limits.Compress {
+		initZstd()
+		if zstdEncoder != nil {
+			if packed := zstdEncoder.EncodeAll(stored.text, nil); len(packed) < len(stored.text) {
+				stored.text = packed
+				stored.compressed = true
+			}
+		}
+	}
+	return s.store(key, stored, entry.Timestamp, len(stored.text)+len(stored.signature))
+}
+
+// get returns the entry for key, refreshing its TTL so thinking of conversations still
+// in use stays cached while abandoned conversations age out through the periodic purge.
+func (s *thinkingStore) get(key string) *ThinkingEntry {
+	stored, ok := s.load(key)
+	if !ok {
+		return nil
+	}
+	entry, ok := stored.decode(key)
+	if !ok {
+		s.delete(key)
+		return nil
+	}
+	entry.Timestamp = time.Now()
+	return &entry
+}
+
+// decode returns the entry with its thinking text decompressed.
+func (st storedThinking) decode(key string) (ThinkingEntry, bool) {
+	text := st.text
+	if st.compressed {
+		initZstd()
+		if zstdDecoder == nil {
+			return ThinkingEntry{}, false
+		}
+		raw, err := zstdDecoder.DecodeAll(st.text, nil)
+		if err != nil {
+			log.Warnf("thinking cache: failed to decompress entry %s: %v", key, err)
+			return ThinkingEntry{}, false
+		}
+		text = raw
+	}
+	return ThinkingEntry{ThinkingText: string(text), Signature: st.signature}, true
+}
@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestThinkingCacheLimits(t *testing.T) {
+	defer SetThinkingCacheLimits(ThinkingCacheLimits{})
+	ClearThinkingCache("")
+	defer ClearThinkingCache("")
+	signature := strings.Repeat("s", MinValidSignatureLen)
+
+	SetThinkingCacheLimits(ThinkingCacheLimits{MaxEntryBytes: 1024, MaxTotalBytes: 3000, Compress: true})
+
+	CacheThinking("too-large", strings.Repeat("x", 2048), signature)
+	if GetCachedThinking("too-large") != nil {
+		t.Fatal("entry above the per-entry cap should not be cached")
+	}
+
+	text := strings.Repeat("compressible reasoning ", 40)
+	CacheThinking("compressed", text, signature)
+	if entry := GetCachedThinking("compressed"); entry == nil || entry.ThinkingText != text {
+		t.Fatalf("compressed entry did not round trip: %+v", entry)
+	}
+	if stats := Stats(); stats.ThinkingBytes >= len(text) {
+		t.Fatalf("thinking bytes = %d, want compressed below %d", stats.ThinkingBytes, len(text))
+	}
+
+	SetThinkingCacheLimits(ThinkingCacheLimits{MaxEntryBytes: 1024, MaxTotalBytes: 3000})
+	for _, id := range []string{"a", "b", "c"} {
+		CacheThinking(id, strings.Repeat(id, 900), signature)
+	}
+	if GetCachedThinking("compressed") != nil || GetCachedThinking("a") != nil {
+		t.Fatal("least recently used entries should be evicted beyond the budget")
+	}
+	if GetCachedThinking("b") == nil || GetCachedThinking("c") == nil {
+		t.Fatal("recent entries should stay within the budget")
+	}
+}
+
+func TestPurgeExpiredCachesDropsIdleThinking(t *testing.T) {
+	ClearThinkingCache("")
+	defer ClearThinkingCache("")
+	signature := strings.Repeat("s", MinValidSignatureLen)
+
+	CacheThinking("idle", "abandoned conversation", signature)
+	CacheThinking("active", "ongoing conversation", signature)
+	for _, key := range []string{"idle", "active"} {
+		thinkingCache.setTimestamp(key, time.Now().Add(-ThinkingCacheTTL+time.Minute))
+	}
+
+	// Reading refreshes the entry, so only the untouched conversation expires.
+	if GetCachedThinking("active") == nil {
+		t.Fatal("active entry missing before purge")
+	}
+	if n := thinkingCache.purgeExpired(time.Now().Add(2 * time.Minute)); n != 1 {
+		t.Fatalf("purged %d entries, want 1", n)
+	}
+	if GetCachedThinking("idle") != nil {
+		t.Fatal("idle entry should be purged")
+	}
+	if GetCachedThinking("active") == nil {
+		t.Fatal("recently used entry should survive the purge")
+	}
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"time"
+)
+
+// ToolIDMapTTL is how long a tool call ID mapping is kept after it was last used.
+const ToolIDMapTTL = 6 * time.Hour
+
+// toolIDCache maps tool call IDs between clients and Claude in both directions, keyed by
+// "session\x00c\x00clientID" (to the Claude ID) and "session\x00u\x00claudeID" (back to
+// the client ID).
+var toolIDCache = newTTLCache[string]("tool_id", ToolIDMapTTL)
+
+// claudeToolIDPattern is the format Claude accepts for tool_use IDs.
+var claudeToolIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+func clientToolIDKey(session, clientID string) string {
+	return session + "\x00c\x00" + clientID
+}
+
+func claudeToolIDKey(session, claudeID string) string {
+	return session + "\x00u\x00" + claudeID
+}
+
+// GenerateToolID derives a toolu_ ID from a session and a seed. The same inputs always
+// give the same ID, so a replayed history keeps its tool IDs and its prompt cache.
+func GenerateToolID(session, seed string) string {
+	h := sha256.New()
+	h.Write([]byte(session))
+	h.Write([]byte{0})
+	h.Write([]byte(seed))
+	return "toolu_" + hex.EncodeToString(h.Sum(nil))[:24]
+}
+
+// ClaudeToolID returns the tool_use ID sent to Claude for a client tool call ID within a
+// session. IDs Claude accepts are kept as they are; any other ID is replaced by a stable
+// toolu_ ID and the pair is recorded so ClientToolID can restore the client's ID.
+func ClaudeToolID(session, clientID string) string {
+	if clientID == "" || claudeToolIDPattern.MatchString(clientID) {
+		return clientID
+	}
+	if claudeID, ok := toolIDCache.load(clientToolIDKey(session, clientID)); ok {
+		toolIDCache.load(claudeToolIDKey(session, claudeID))
+		return claudeID
+	}
+	claudeID := GenerateToolID(session, clientID)
+	now := time.Now()
+	toolIDCache.store(clientToolIDKey(session, clientID), claudeID, now, len(claudeID))
+	toolIDCache.store(claudeToolIDKey(session, claudeID), clientID, now, len(clientID))
+	return claudeID
+}
+
+// ClientToolID returns the client's tool call ID for a Claude tool_use ID, or claudeID
+// itself when the proxy did not replace a client ID with it.
+func ClientToolID(session, claudeID string) string {
+	if claudeID == "" {
+		return claudeID
+	}
+	if clientID, ok := toolIDCache.load(claudeToolIDKey(session, claudeID)); ok {
+		return clientID
+	}
+	return claudeID
+}
+
+// ClearToolIDMap removes all tool call ID mappings.
+func ClearToolIDMap() {
+	toolIDCache.clear()
+}
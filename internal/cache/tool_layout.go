@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"strings"
+	"time"
+)
+
+// ToolLayoutCacheTTL is how long the layout of an assistant turn is kept after last use.
+const ToolLayoutCacheTTL = 2 * time.Hour
+
+// toolLayoutCache stores, per first tool call ID of an assistant turn, the text the turn
+// produced before, between and after its tool calls.
+var toolLayoutCache = newTTLCache[[]string]("tool_layout", ToolLayoutCacheTTL)
+
+// CacheToolCallLayout records the layout of an assistant turn. segments[k] is the text
+// emitted before tool call k and the final segment the text after the last tool call.
+// OpenAI messages keep text and tool calls apart, so the layout lets a replayed turn be
+// rebuilt in its original order. Turns whose text all precedes the tool calls are not
+// recorded, since that is the default order.
+func CacheToolCallLayout(firstToolID string, segments []string) {
+	if firstToolID == "" || len(segments) < 2 {
+		return
+	}
+	interleaved := false
+	for _, segment := range segments[1:] {
+		if strings.TrimSpace(segment) != "" {
+			interleaved = true
+			break
+		}
+	}
+	if !interleaved {
+		return
+	}
+	size := 0
+	for _, segment := range segments {
+		size += len(segment)
+	}
+	toolLayoutCache.store(firstToolID, append([]string(nil), segments...), time.Now(), size)
+}
+
+// GetToolCallLayout returns the layout recorded for the assistant turn whose first tool
+// call has the given ID, or nil.
+func GetToolCallLayout(firstToolID string) []string {
+	if firstToolID == "" {
+		return nil
+	}
+	segments, ok := toolLayoutCache.load(firstToolID)
+	if !ok {
+		return nil
+	}
+	return segments
+}
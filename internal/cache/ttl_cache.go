@@ -0,0 +1,297 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// entryOverhead approximates the per-entry bookkeeping cost (map slot, list element,
+// timestamp) so byte budgets stay meaningful for many small entries.
+const entryOverhead = 128
+
+// CacheMetrics reports the activity of one cache since process start.
+type CacheMetrics struct {
+	Entries     int    `json:"entries"`
+	Bytes       int    `json:"bytes"`
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`
+	Expirations uint64 `json:"expirations"`
+}
+
+// ttlEntry is one value held by a ttlCache.
+type ttlEntry[V any] struct {
+	key       string
+	value     V
+	timestamp time.Time
+	size      int
+}
+
+// ttlCache is a string-keyed cache with sliding expiration and an optional byte budget
+// enforced by least-recently-used eviction. The signature and thinking caches are typed
+// wrappers around it; all instances share the periodic cleanup and metrics.
+type ttlCache[V any] struct {
+	name string
+	ttl  time.Duration
+
+	mu       sync.Mutex
+	maxBytes int // 0 means unbounded
+	items    map[string]*list.Element
+	order    *list.List // front is most recently used
+	size     int
+	metrics  CacheMetrics
+	reads    uint64 // lock acquisitions by load and loadAll
+}
+
+// purgeable is the part of a ttlCache the shared cleanup and metrics need.
+type purgeable interface {
+	cacheName() string
+	purgeExpired(now time.Time) int
+	snapshotMetrics() CacheMetrics
+}
+
+var (
+	registryMu sync.Mutex
+	registry   []purgeable
+)
+
+// newTTLCache creates a cache and registers it for the shared cleanup.
+func newTTLCache[V any](name string, ttl time.Duration) *ttlCache[V] {
+	c := &ttlCache[V]{name: name, ttl: ttl, items: make(map[string]*list.Element), order: list.New()}
+	registryMu.Lock()
+	registry = append(registry, c)
+	registryMu.Unlock()
+	return c
+}
+
+func (c *ttlCache[V]) cacheName() string { return c.name }
+
+// setMaxBytes changes the byte budget, evicting entries that no longer fit.
+func (c *ttlCache[V]) setMaxBytes(maxBytes int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.maxBytes = maxBytes
+	c.evictLocked()
+}
+
+// store saves value under key with the given timestamp. size is the caller's estimate of
+// the value's memory use. It reports false when the value alone exceeds the budget.
+func (c *ttlCache[V]) store(key string, value V, timestamp time.Time, size int) bool {
+	cacheCleanupOnce.Do(startCacheCleanup)
+
+	size += len(key) + entryOverhead
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.removeLocked(key)
+	if c.maxBytes > 0 && size > c.maxBytes {
+		return false
+	}
+	c.items[key] = c.order.PushFront(&ttlEntry[V]{key: key, value: value, timestamp: timestamp, size: size})
+	c.size += size
+	c.evictLocked()
+	return true
+}
+
+// load returns the value for key and refreshes its timestamp. Expired entries are dropped.
+func (c *ttlCache[V]) load(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reads++
+	return c.loadLocked(key, time.Now())
+}
+
+// loadAll is load for many keys under a single lock acquisition. found[i] reports
+// whether values[i] holds the value of keys[i].
+func (c *ttlCache[V]) loadAll(keys []string) (values []V, found []bool) {
+	values = make([]V, len(keys))
+	found = make([]bool, len(keys))
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.reads++
+	for i, key := range keys {
+		values[i], found[i] = c.loadLocked(key, now)
+	}
+	return values, found
+}
+
+func (c *ttlCache[V]) loadLocked(key string, now time.Time) (V, bool) {
+	var zero V
+	elem, ok := c.items[key]
+	if !ok {
+		c.metrics.Misses++
+		return zero, false
+	}
+	entry := elem.Value.(*ttlEntry[V])
+	if now.Sub(entry.timestamp) > c.ttl {
+		c.removeLocked(key)
+		c.metrics.Expirations++
+		c.metrics.Misses++
+		return zero, false
+	}
+	entry.timestamp = now
+	c.order.MoveToFront(elem)
+	c.metrics.Hits++
+	return entry.value, true
+}
+
+// lastUsed reports when key was last stored or read, without refreshing it.
+func (c *ttlCache[V]) lastUsed(key string) (time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[key]
+	if !ok {
+		return time.Time{}, false
+	}
+	return elem.Value.(*ttlEntry[V]).timestamp, true
+}
+
+// setTimestamp overrides the timestamp of key; used by tests to age entries.
+func (c *ttlCache[V]) setTimestamp(key string, timestamp time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*ttlEntry[V]).timestamp = timestamp
+	}
+}
+
+func (c *ttlCache[V]) delete(key string) {
+	c.mu.Lock()
+	c.removeLocked(key)
+	c.mu.Unlock()
+}
+
+// deleteFunc removes every entry whose key matches.
+func (c *ttlCache[V]) deleteFunc(match func(key string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.items {
+		if match(key) {
+			c.removeLocked(key)
+		}
+	}
+}
+
+func (c *ttlCache[V]) clear() {
+	c.mu.Lock()
+	c.items = make(map[string]*list.Element)
+	c.order.Init()
+	c.size = 0
+	c.mu.Unlock()
+}
+
+// live returns copies of the non-expired entries from most to least recently used.
+func (c *ttlCache[V]) live(now time.Time) []ttlEntry[V] {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]ttlEntry[V], 0, len(c.items))
+	for elem := c.order.Front(); elem != nil; elem = elem.Next() {
+		entry := elem.Value.(*ttlEntry[V])
+		if now.Sub(entry.timestamp) <= c.ttl {
+			out = append(out, *entry)
+		}
+	}
+	return out
+}
+
+// purgeExpired drops entries not used within the TTL and returns how many were removed.
+func (c *ttlCache[V]) purgeExpired(now time.Time) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	removed := 0
+	for elem := c.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		if entry := elem.Value.(*ttlEntry[V]); now.Sub(entry.timestamp) > c.ttl {
+			c.removeLocked(entry.key)
+			removed++
+		}
+		elem = prev
+	}
+	c.metrics.Expirations += uint64(removed)
+	return removed
+}
+
+func (c *ttlCache[V]) snapshotMetrics() CacheMetrics {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	metrics := c.metrics
+	metrics.Entries = len(c.items)
+	metrics.Bytes = c.size
+	return metrics
+}
+
+// readLocks reports how many times lookups have taken the cache lock.
+func (c *ttlCache[V]) readLocks() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reads
+}
+
+func (c *ttlCache[V]) removeLocked(key string) {
+	elem, ok := c.items[key]
+	if !ok {
+		return
+	}
+	c.size -= elem.Value.(*ttlEntry[V]).size
+	c.order.Remove(elem)
+	delete(c.items, key)
+}
+
+func (c *ttlCache[V]) evictLocked() {
+	if c.maxBytes <= 0 {
+		return
+	}
+	for c.size > c.maxBytes {
+		oldest := c.order.Back()
+		if oldest == nil {
+			return
+		}
+		c.removeLocked(oldest.Value.(*ttlEntry[V]).key)
+		c.metrics.Evictions++
+	}
+}
+
+// cacheCleanupOnce ensures the background cleanup goroutine starts only once
+var cacheCleanupOnce sync.Once
+
+// startCacheCleanup launches a background goroutine that periodically
+// removes expired entries from every registered cache and saves the result
+// to the installed persister.
+func startCacheCleanup() {
+	go func() {
+		ticker := time.NewTicker(CacheCleanupInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			purgeExpiredCaches()
+			if err := FlushPersister(); err != nil {
+				log.Warnf("cache: failed to persist snapshot: %v", err)
+			}
+		}
+	}()
+}
+
+// purgeExpiredCaches drops expired entries from every registered cache.
+func purgeExpiredCaches() {
+	now := time.Now()
+	for _, c := range registeredCaches() {
+		c.purgeExpired(now)
+	}
+}
+
+// Metrics returns the metrics of every registered cache keyed by cache name.
+func Metrics() map[string]CacheMetrics {
+	out := make(map[string]CacheMetrics)
+	for _, c := range registeredCaches() {
+		out[c.cacheName()] = c.snapshotMetrics()
+	}
+	return out
+}
+
+func registeredCaches() []purgeable {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	return append([]purgeable(nil), registry...)
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTTLCacheEvictionAndMetrics(t *testing.T) {
+	c := newTTLCache[string]("test-lru", time.Hour)
+	c.setMaxBytes(3 * (entryOverhead + 2))
+
+	for _, key := range []string{"a", "b", "c"} {
+		c.store(key, key, time.Now(), 1)
+	}
+	if _, ok := c.load("a"); !ok {
+		t.Fatal("a should be cached")
+	}
+	c.store("d", "d", time.Now(), 1)
+	if _, ok := c.load("b"); ok {
+		t.Fatal("b was least recently used and should be evicted")
+	}
+
+	c.store("e", "e", time.Now().Add(-2*time.Hour), 1)
+	if n := c.purgeExpired(time.Now()); n != 1 {
+		t.Fatalf("purged %d entries, want 1", n)
+	}
+
+	metrics := Metrics()["test-lru"]
+	if metrics.Entries != 2 || metrics.Hits != 1 || metrics.Misses != 1 || metrics.Evictions != 2 || metrics.Expirations != 1 {
+		t.Fatalf("unexpected metrics: %+v", metrics)
+	}
+}
+
+func TestFilePersisterRoundTrip(t *testing.T) {
+	ClearSignatureCache("")
+	ClearThinkingCache("")
+	defer func() { _ = SetPersister(nil) }()
+
+	path := filepath.Join(t.TempDir(), "cache.json")
+	if err := SetPersister(FilePersister{Path: path}); err != nil {
+		t.Fatalf("SetPersister on missing file: %v", err)
+	}
+	signature := strings.Repeat("p", MinValidSignatureLen)
+	CacheSignature(testModelName, "persisted text", signature)
+	CacheThinking("persisted", "persisted thinking", signature)
+	if err := FlushPersister(); err != nil {
+		t.Fatalf("FlushPersister: %v", err)
+	}
+
+	ClearSignatureCache("")
+	ClearThinkingCache("")
+	if err := SetPersister(FilePersister{Path: path}); err != nil {
+		t.Fatalf("SetPersister: %v", err)
+	}
+	if got := GetCachedSignature(testModelName, "persisted text"); got != signature {
+		t.Errorf("signature not restored, got %q", got)
+	}
+	if entry := GetCachedThinking("persisted"); entry == nil || entry.ThinkingText != "persisted thinking" {
+		t.Errorf("thinking not restored: %+v", entry)
+	}
+}
@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/accountbundle"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+// bundlePassphraseEnv names the environment variable used when --passphrase is omitted.
+const bundlePassphraseEnv = "CLIPROXY_BUNDLE_PASSPHRASE"
+
+// DoAccountsCommand implements the `accounts export|import` subcommands.
+// By default the archive is built from, or restored into, the local config file and
+// auth directory; with --remote the running instance's management API is used instead.
+//
+// Parameters:
+//   - args: The command-line arguments following the subcommand name
+//   - defaultConfigPath: The default configuration file path
+//
+// Returns:
+//   - int: The process exit code
+func DoAccountsCommand(args []string, defaultConfigPath string) int {
+	if len(args) == 0 || (args[0] != "export" && args[0] != "import") {
+		fmt.Fprintln(os.Stderr, "usage: accounts <export|import> [flags]")
+		return 2
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("accounts "+action, flag.ContinueOnError)
+	var configPath, file, passphrase, remoteURL, password string
+	var remote, overwrite bool
+	fs.StringVar(&configPath, "config", defaultConfigPath, "Configure File Path")
+	fs.StringVar(&passphrase, "passphrase", "", "Archive passphrase (defaults to "+bundlePassphraseEnv+")")
+	fs.BoolVar(&remote, "remote", false, "Use a running instance via the management API")
+	fs.StringVar(&remoteURL, "url", "", "Base URL of the running instance (defaults to http://127.0.0.1:<port>)")
+	fs.StringVar(&password, "password", "", "Management key (defaults to MANAGEMENT_PASSWORD)")
+	if action == "export" {
+		fs.StringVar(&file, "output", "cliproxy-accounts.bundle", "Archive file to write")
+	} else {
+		fs.StringVar(&file, "input", "cliproxy-accounts.bundle", "Archive file to read")
+		fs.BoolVar(&overwrite, "overwrite", false, "Replace existing auth files with the same name")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if passphrase == "" {
+		passphrase = strings.TrimSpace(os.Getenv(bundlePassphraseEnv))
+	}
+	if passphrase == "" {
+		fmt.Fprintf(os.Stderr, "accounts: a passphrase is required (--passphrase or %s)\n", bundlePassphraseEnv)
+		return 2
+	}
+
+	cfg, resolvedPath, err := resolveSubcommandConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "accounts: %v\n", err)
+		return 1
+	}
+	authDir, err := util.ResolveAuthDir(cfg.AuthDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "accounts: failed to resolve auth directory: %v\n", err)
+		return 1
+	}
+
+	if action == "export" {
+		var data []byte
+		if remote || remoteURL != "" {
+			body, _ := json.Marshal(map[string]string{"passphrase": passphrase})
+			data, err = newManagementClient(cfg, remoteURL, password).do(http.MethodPost, "/accounts/export", body)
+		} else {
+			var bundle *accountbundle.Bundle
+			if bundle, err = accountbundle.Collect(cfg, authDir); err == nil {
+				data, err = accountbundle.Seal(bundle, passphrase)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "accounts: %v\n", err)
+			return 1
+		}
+		if err = os.WriteFile(file, data, 0o600); err != nil {
+			fmt.Fprintf(os.Stderr, "accounts: failed to write archive: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Account archive written to %s\n", file)
+		return 0
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "accounts: failed to read archive: %v\n", err)
+		return 1
+	}
+	var result accountbundle.ImportResult
+	if remote || remoteURL != "" {
+		client := newManagementClient(cfg, remoteURL, password)
+		path := "/accounts/import"
+		if overwrite {
+			path += "?overwrite=true"
+		}
+		var respData []byte
+		respData, err = client.doWithHeaders(http.MethodPost, path, data, map[string]string{"X-Bundle-Passphrase": passphrase})
+		if err == nil {
+			err = json.Unmarshal(respData, &result)
+		}
+	} else {
+		var bundle *accountbundle.Bundle
+		if bundle, err = accountbundle.Open(data, passphrase); err == nil {
+			if result, err = accountbundle.Restore(bundle, cfg, authDir, overwrite); err == nil && result.ConfigEntries > 0 {
+				err = config.SaveConfigPreserveComments(resolvedPath, cfg)
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "accounts: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Imported %d auth files (%d skipped), added %d config entries.\n",
+		len(result.AuthFilesWritten), len(result.AuthFilesSkipped), result.ConfigEntries)
+	return 0
+}
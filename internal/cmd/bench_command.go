@@ -0,0 +1,408 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// benchLatency summarises a latency distribution in milliseconds.
+type benchLatency struct {
+	Min float64 `json:"min_ms"`
+	P50 float64 `json:"p50_ms"`
+	P95 float64 `json:"p95_ms"`
+	P99 float64 `json:"p99_ms"`
+	Max float64 `json:"max_ms"`
+}
+
+// benchAllocs is the server-side allocation delta observed over the run.
+type benchAllocs struct {
+	TotalBytes       uint64  `json:"total_alloc_bytes"`
+	Mallocs          uint64  `json:"mallocs"`
+	NumGC            uint64  `json:"num_gc"`
+	BytesPerRequest  float64 `json:"bytes_per_request"`
+	AllocsPerRequest float64 `json:"allocs_per_request"`
+	BytesPerChunk    float64 `json:"bytes_per_chunk"`
+	AllocsPerChunk   float64 `json:"allocs_per_chunk"`
+}
+
+// benchReport is the machine-readable output of the bench subcommand.
+type benchReport struct {
+	Format        string         `json:"format"`
+	Model         string         `json:"model"`
+	Concurrency   int            `json:"concurrency"`
+	Requests      int            `json:"requests"`
+	Failed        int            `json:"failed"`
+	Duration      float64        `json:"duration_seconds"`
+	RequestsPerS  float64        `json:"requests_per_second"`
+	ChunksPerS    float64        `json:"chunks_per_second"`
+	BytesPerS     float64        `json:"bytes_per_second"`
+	TTFB          benchLatency   `json:"ttfb"`
+	Latency       benchLatency   `json:"latency"`
+	Errors        map[string]int `json:"errors,omitempty"`
+	ServerAllocs  *benchAllocs   `json:"server_allocs,omitempty"`
+	totalChunks   int64
+	totalDuration time.Duration
+}
+
+// benchResult records a single streaming request.
+type benchResult struct {
+	ttfb    time.Duration
+	latency time.Duration
+	chunks  int64
+	bytes   int64
+	err     error
+}
+
+// DoBenchCommand implements the `bench` subcommand.
+// It drives concurrent streaming requests against a running instance, normally backed by
+// the mock provider, and reports throughput, time to first byte and, when the pprof
+// server is reachable, the server's allocation delta over the run.
+//
+// Parameters:
+//   - args: The command-line arguments following the subcommand name
+//   - defaultConfigPath: The default configuration file path
+//
+// Returns:
+//   - int: The process exit code
+func DoBenchCommand(args []string, defaultConfigPath string) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	var configPath, remoteURL, apiKey, model, format, prompt, pprofURL string
+	var concurrency, requests, warmup int
+	var timeout time.Duration
+	var jsonOutput bool
+	fs.StringVar(&configPath, "config", defaultConfigPath, "Configure File Path")
+	fs.StringVar(&remoteURL, "url", "", "Base URL of the running instance (defaults to http://127.0.0.1:<port>)")
+	fs.StringVar(&apiKey, "api-key", "", "Client API key (defaults to the first api-keys entry)")
+	fs.StringVar(&model, "model", "mock-model", "Model to request")
+	fs.StringVar(&format, "format", "openai", "Client API format: openai, responses, claude or gemini")
+	fs.StringVar(&prompt, "prompt", "Benchmark the proxy with a short streamed reply.", "User message sent with every request")
+	fs.StringVar(&pprofURL, "pprof", "", "Base URL of the pprof server for allocation stats (defaults to the configured pprof address when enabled)")
+	fs.IntVar(&concurrency, "concurrency", 8, "Number of concurrent streams")
+	fs.IntVar(&requests, "requests", 200, "Total number of measured requests")
+	fs.IntVar(&warmup, "warmup", 10, "Requests sent before measuring")
+	fs.DurationVar(&timeout, "timeout", 60*time.Second, "Per-request timeout")
+	fs.BoolVar(&jsonOutput, "json", false, "Print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if concurrency <= 0 || requests <= 0 {
+		fmt.Fprintln(os.Stderr, "bench: -concurrency and -requests must be positive")
+		return 2
+	}
+
+	cfg, _, err := resolveSubcommandConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bench: %v\n", err)
+		return 1
+	}
+	baseURL := newManagementClient(cfg, remoteURL, "").baseURL
+	if strings.TrimSpace(apiKey) == "" && len(cfg.APIKeys) > 0 {
+		apiKey = cfg.APIKeys[0]
+	}
+	if strings.TrimSpace(pprofURL) == "" && cfg.Pprof.Enable {
+		addr := strings.TrimSpace(cfg.Pprof.Addr)
+		if addr == "" {
+			addr = config.DefaultPprofAddr
+		}
+		pprofURL = "http://" + addr
+	}
+
+	target, err := newBenchTarget(baseURL, apiKey, format, model, prompt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "bench: %v\n", err)
+		return 2
+	}
+	client := &http.Client{Timeout: timeout, Transport: &http.Transport{MaxIdleConnsPerHost: concurrency}}
+
+	if warmup > 0 {
+		runBench(client, target, concurrency, warmup)
+	}
+	before, errAllocs := fetchServerMemStats(pprofURL)
+	start := time.Now()
+	results := runBench(client, target, concurrency, requests)
+	elapsed := time.Since(start)
+
+	report := summarizeBench(results, elapsed)
+	report.Format = format
+	report.Model = model
+	report.Concurrency = concurrency
+	if errAllocs == nil {
+		if after, errAfter := fetchServerMemStats(pprofURL); errAfter == nil {
+			report.ServerAllocs = diffServerMemStats(before, after, len(results), report.totalChunks)
+		}
+	}
+
+	if jsonOutput {
+		return printJSON(report)
+	}
+	printBenchReport(os.Stdout, report)
+	if report.ServerAllocs == nil {
+		fmt.Fprintln(os.Stderr, "Server allocation stats unavailable; enable pprof or pass -pprof.")
+	}
+	if report.Failed == report.Requests {
+		return 1
+	}
+	return 0
+}
+
+// benchTarget builds streaming requests for one client API format.
+type benchTarget struct {
+	url    string
+	apiKey string
+	body   []byte
+}
+
+func newBenchTarget(baseURL, apiKey, format, model, prompt string) (*benchTarget, error) {
+	modelJSON, _ := json.Marshal(model)
+	promptJSON, _ := json.Marshal(prompt)
+	t := &benchTarget{apiKey: strings.TrimSpace(apiKey)}
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "openai":
+		t.url = baseURL + "/v1/chat/completions"
+		t.body = []byte(fmt.Sprintf(`{"model":%s,"stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":%s}]}`, modelJSON, promptJSON))
+	case "responses":
+		t.url = baseURL + "/v1/responses"
+		t.body = []byte(fmt.Sprintf(`{"model":%s,"stream":true,"input":%s}`, modelJSON, promptJSON))
+	case "claude":
+		t.url = baseURL + "/v1/messages"
+		t.body = []byte(fmt.Sprintf(`{"model":%s,"stream":true,"max_tokens":1024,"messages":[{"role":"user","content":%s}]}`, modelJSON, promptJSON))
+	case "gemini":
+		t.url = baseURL + "/v1beta/models/" + url.PathEscape(model) + ":streamGenerateContent?alt=sse"
+		t.body = []byte(fmt.Sprintf(`{"contents":[{"role":"user","parts":[{"text":%s}]}]}`, promptJSON))
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+	return t, nil
+}
+
+// runBench sends total requests over concurrency workers and returns their results.
+func runBench(client *http.Client, target *benchTarget, concurrency, total int) []benchResult {
+	results := make([]benchResult, total)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = target.do(client)
+			}
+		}()
+	}
+	for i := 0; i < total; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func (t *benchTarget) do(client *http.Client) (res benchResult) {
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, t.url, bytes.NewReader(t.body))
+	if err != nil {
+		res.err = err
+		return res
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	if t.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+	start := time.Now()
+	defer func() { res.latency = time.Since(start) }()
+	resp, err := client.Do(req)
+	if err != nil {
+		res.err = err
+		return res
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 400 {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		res.err = fmt.Errorf("HTTP %d", resp.StatusCode)
+		return res
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, 52_428_800) // 50MB
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		res.bytes += int64(len(line)) + 1
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		if res.chunks == 0 {
+			res.ttfb = time.Since(start)
+		}
+		res.chunks++
+	}
+	if errScan := scanner.Err(); errScan != nil {
+		res.err = errScan
+	} else if res.chunks == 0 {
+		res.err = fmt.Errorf("empty stream")
+	}
+	return res
+}
+
+// summarizeBench aggregates request results into a report.
+func summarizeBench(results []benchResult, elapsed time.Duration) benchReport {
+	report := benchReport{Requests: len(results), Duration: elapsed.Seconds(), totalDuration: elapsed}
+	var ttfbs, latencies []time.Duration
+	var totalBytes int64
+	for _, res := range results {
+		if res.err != nil {
+			report.Failed++
+			if report.Errors == nil {
+				report.Errors = make(map[string]int)
+			}
+			report.Errors[res.err.Error()]++
+			continue
+		}
+		ttfbs = append(ttfbs, res.ttfb)
+		latencies = append(latencies, res.latency)
+		report.totalChunks += res.chunks
+		totalBytes += res.bytes
+	}
+	if seconds := elapsed.Seconds(); seconds > 0 {
+		report.RequestsPerS = float64(len(latencies)) / seconds
+		report.ChunksPerS = float64(report.totalChunks) / seconds
+		report.BytesPerS = float64(totalBytes) / seconds
+	}
+	report.TTFB = summarizeLatency(ttfbs)
+	report.Latency = summarizeLatency(latencies)
+	return report
+}
+
+func summarizeLatency(values []time.Duration) benchLatency {
+	if len(values) == 0 {
+		return benchLatency{}
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	ms := func(d time.Duration) float64 { return float64(d.Microseconds()) / 1000 }
+	percentile := func(p float64) float64 {
+		idx := int(p*float64(len(values)-1) + 0.5)
+		return ms(values[idx])
+	}
+	return benchLatency{
+		Min: ms(values[0]),
+		P50: percentile(0.50),
+		P95: percentile(0.95),
+		P99: percentile(0.99),
+		Max: ms(values[len(values)-1]),
+	}
+}
+
+// fetchServerMemStats reads the runtime.MemStats counters from the text form of the
+// server's allocs profile.
+func fetchServerMemStats(pprofURL string) (map[string]uint64, error) {
+	if strings.TrimSpace(pprofURL) == "" {
+		return nil, fmt.Errorf("pprof disabled")
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(strings.TrimRight(pprofURL, "/") + "/debug/pprof/allocs?debug=1")
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("pprof: HTTP %d", resp.StatusCode)
+	}
+	stats := make(map[string]uint64)
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(nil, 52_428_800) // 50MB
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "# ") {
+			continue
+		}
+		name, value, ok := strings.Cut(strings.TrimPrefix(line, "# "), " = ")
+		if !ok {
+			continue
+		}
+		if n, errParse := strconv.ParseUint(strings.TrimSpace(value), 10, 64); errParse == nil {
+			stats[name] = n
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	if _, ok := stats["TotalAlloc"]; !ok {
+		return nil, fmt.Errorf("pprof: memstats not found")
+	}
+	return stats, nil
+}
+
+func diffServerMemStats(before, after map[string]uint64, requests int, chunks int64) *benchAllocs {
+	allocs := &benchAllocs{
+		TotalBytes: after["TotalAlloc"] - before["TotalAlloc"],
+		Mallocs:    after["Mallocs"] - before["Mallocs"],
+		NumGC:      after["NumGC"] - before["NumGC"],
+	}
+	if requests > 0 {
+		allocs.BytesPerRequest = float64(allocs.TotalBytes) / float64(requests)
+		allocs.AllocsPerRequest = float64(allocs.Mallocs) / float64(requests)
+	}
+	if chunks > 0 {
+		allocs.BytesPerChunk = float64(allocs.TotalBytes) / float64(chunks)
+		allocs.AllocsPerChunk = float64(allocs.Mallocs) / float64(chunks)
+	}
+	return allocs
+}
+
+// printBenchReport renders a benchmark report as aligned text.
+func printBenchReport(out io.Writer, report benchReport) {
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Format:\t%s\n", report.Format)
+	_, _ = fmt.Fprintf(tw, "Model:\t%s\n", report.Model)
+	_, _ = fmt.Fprintf(tw, "Concurrency:\t%d\n", report.Concurrency)
+	_, _ = fmt.Fprintf(tw, "Requests:\t%d (%d failed)\n", report.Requests, report.Failed)
+	_, _ = fmt.Fprintf(tw, "Duration:\t%s\n", report.totalDuration.Round(time.Millisecond))
+	_, _ = fmt.Fprintf(tw, "Throughput:\t%.1f req/s, %.1f chunks/s, %.1f KiB/s\n", report.RequestsPerS, report.ChunksPerS, report.BytesPerS/1024)
+	_ = tw.Flush()
+
+	_, _ = fmt.Fprintln(out)
+	tw = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "METRIC\tMIN\tP50\tP95\tP99\tMAX")
+	for _, row := range []struct {
+		name string
+		l    benchLatency
+	}{{"ttfb (ms)", report.TTFB}, {"latency (ms)", report.Latency}} {
+		_, _ = fmt.Fprintf(tw, "%s\t%.2f\t%.2f\t%.2f\t%.2f\t%.2f\n", row.name, row.l.Min, row.l.P50, row.l.P95, row.l.P99, row.l.Max)
+	}
+	_ = tw.Flush()
+
+	if allocs := report.ServerAllocs; allocs != nil {
+		_, _ = fmt.Fprintln(out)
+		tw = tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintf(tw, "Server allocated:\t%.1f MiB in %d allocations (%d GCs)\n", float64(allocs.TotalBytes)/(1<<20), allocs.Mallocs, allocs.NumGC)
+		_, _ = fmt.Fprintf(tw, "Per request:\t%.0f B, %.0f allocs\n", allocs.BytesPerRequest, allocs.AllocsPerRequest)
+		_, _ = fmt.Fprintf(tw, "Per chunk:\t%.0f B, %.1f allocs\n", allocs.BytesPerChunk, allocs.AllocsPerChunk)
+		_ = tw.Flush()
+	}
+
+	if len(report.Errors) > 0 {
+		_, _ = fmt.Fprintln(out)
+		messages := make([]string, 0, len(report.Errors))
+		for msg := range report.Errors {
+			messages = append(messages, msg)
+		}
+		sort.Strings(messages)
+		for _, msg := range messages {
+			_, _ = fmt.Fprintf(out, "error x%d: %s\n", report.Errors[msg], msg)
+		}
+	}
+}
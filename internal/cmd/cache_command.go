@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+)
+
+// DoCacheCommand implements the `cache stats|clear|export|import` subcommands.
+// All actions operate on a running instance through the management API.
+//
+// Parameters:
+//   - args: The command-line arguments following the subcommand name
+//   - defaultConfigPath: The default configuration file path
+//
+// Returns:
+//   - int: The process exit code
+func DoCacheCommand(args []string, defaultConfigPath string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: cache <stats|clear|export|import> [flags]")
+		return 2
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("cache "+action, flag.ContinueOnError)
+	var configPath, remoteURL, password, file, cacheType, model, thinkingID string
+	var jsonOutput bool
+	fs.StringVar(&configPath, "config", defaultConfigPath, "Configure File Path")
+	fs.StringVar(&remoteURL, "url", "", "Base URL of the running instance (defaults to http://127.0.0.1:<port>)")
+	fs.StringVar(&password, "password", "", "Management key (defaults to MANAGEMENT_PASSWORD)")
+	fs.BoolVar(&jsonOutput, "json", false, "Print results as JSON")
+	switch action {
+	case "export":
+		fs.StringVar(&file, "output", "", "Write the snapshot to this file instead of stdout")
+	case "import":
+		fs.StringVar(&file, "input", "", "Read the snapshot from this file instead of stdin")
+	case "clear":
+		fs.StringVar(&cacheType, "type", "all", "Cache to clear: signature, thinking or all")
+		fs.StringVar(&model, "model", "", "Only clear signatures for this model group")
+		fs.StringVar(&thinkingID, "id", "", "Only clear this thinking entry")
+	case "stats":
+	default:
+		fmt.Fprintf(os.Stderr, "cache: unknown action %q\n", action)
+		return 2
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	cfg, _, err := resolveSubcommandConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cache: %v\n", err)
+		return 1
+	}
+	client := newManagementClient(cfg, remoteURL, password)
+
+	switch action {
+	case "stats":
+		var stats cache.CacheStats
+		if err = client.getJSON("/cache/stats", &stats); err != nil {
+			fmt.Fprintf(os.Stderr, "cache: %v\n", err)
+			return 1
+		}
+		if jsonOutput {
+			return printJSON(stats)
+		}
+		printCacheStats(os.Stdout, stats)
+
+	case "clear":
+		query := url.Values{}
+		query.Set("type", cacheType)
+		if model != "" {
+			query.Set("model", model)
+		}
+		if thinkingID != "" {
+			query.Set("id", thinkingID)
+		}
+		var resp struct {
+			Stats cache.CacheStats `json:"stats"`
+		}
+		data, errDo := client.do(http.MethodDelete, "/cache?"+query.Encode(), nil)
+		if errDo != nil {
+			fmt.Fprintf(os.Stderr, "cache: %v\n", errDo)
+			return 1
+		}
+		if err = json.Unmarshal(data, &resp); err != nil {
+			fmt.Fprintf(os.Stderr, "cache: %v\n", err)
+			return 1
+		}
+		if jsonOutput {
+			return printJSON(resp.Stats)
+		}
+		fmt.Println("Cache cleared.")
+		printCacheStats(os.Stdout, resp.Stats)
+
+	case "export":
+		data, errDo := client.do(http.MethodGet, "/cache/export", nil)
+		if errDo != nil {
+			fmt.Fprintf(os.Stderr, "cache: %v\n", errDo)
+			return 1
+		}
+		if file == "" {
+			_, _ = os.Stdout.Write(data)
+			fmt.Println()
+			return 0
+		}
+		if err = os.WriteFile(file, data, 0o600); err != nil {
+			fmt.Fprintf(os.Stderr, "cache: failed to write snapshot: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "Cache snapshot written to %s\n", file)
+
+	case "import":
+		var data []byte
+		if file == "" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(file)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cache: failed to read snapshot: %v\n", err)
+			return 1
+		}
+		var result cache.ImportResult
+		respData, errDo := client.do(http.MethodPost, "/cache/import", data)
+		if errDo != nil {
+			fmt.Fprintf(os.Stderr, "cache: %v\n", errDo)
+			return 1
+		}
+		if err = json.Unmarshal(respData, &result); err != nil {
+			fmt.Fprintf(os.Stderr, "cache: %v\n", err)
+			return 1
+		}
+		if jsonOutput {
+			return printJSON(result)
+		}
+		fmt.Printf("Imported %d entries, skipped %d.\n", result.Imported, result.Skipped)
+	}
+	return 0
+}
+
+// printCacheStats renders cache statistics as an aligned table.
+func printCacheStats(out io.Writer, stats cache.CacheStats) {
+	_, _ = fmt.Fprintf(out, "Signature entries: %d\nThinking entries:  %d\n", stats.SignatureEntries, stats.ThinkingEntries)
+	if stats.OldestEntry != nil && stats.NewestEntry != nil {
+		_, _ = fmt.Fprintf(out, "Oldest entry:      %s\nNewest entry:      %s\n", stats.OldestEntry.Format("2006-01-02 15:04:05"), stats.NewestEntry.Format("2006-01-02 15:04:05"))
+	}
+	if len(stats.SignatureGroups) == 0 {
+		return
+	}
+	groups := make([]string, 0, len(stats.SignatureGroups))
+	for group := range stats.SignatureGroups {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+	_, _ = fmt.Fprintln(out)
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "GROUP\tSIGNATURES")
+	for _, group := range groups {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\n", group, stats.SignatureGroups[group])
+	}
+	_ = tw.Flush()
+}
+
+// printJSON writes v to stdout as indented JSON and returns the exit code.
+func printJSON(v any) int {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode output: %v\n", err)
+		return 1
+	}
+	return 0
+}
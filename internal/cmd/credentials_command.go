@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/secrets"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+// DoCredentialsCommand implements the `credentials encrypt|decrypt|seal-value` subcommands.
+// encrypt migrates plaintext auth files and API keys to sealed storage, decrypt reverses
+// the migration, and seal-value prints the sealed form of a single value for config.yaml.
+// The key is resolved from the credential-encryption section or CLIPROXY_CREDENTIAL_KEY.
+//
+// Parameters:
+//   - args: The command-line arguments following the subcommand name
+//   - defaultConfigPath: The default configuration file path
+//
+// Returns:
+//   - int: The process exit code
+func DoCredentialsCommand(args []string, defaultConfigPath string) int {
+	if len(args) == 0 || (args[0] != "encrypt" && args[0] != "decrypt" && args[0] != "seal-value") {
+		fmt.Fprintln(os.Stderr, "usage: credentials <encrypt|decrypt|seal-value> [flags]")
+		return 2
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("credentials "+action, flag.ContinueOnError)
+	var configPath string
+	var skipConfig bool
+	fs.StringVar(&configPath, "config", defaultConfigPath, "Configure File Path")
+	if action != "seal-value" {
+		fs.BoolVar(&skipConfig, "auth-only", false, "Only migrate auth files and leave config.yaml untouched")
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+
+	cfg, resolvedPath, err := resolveSubcommandConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "credentials: %v\n", err)
+		return 1
+	}
+	sealer := secrets.AtRestSealer()
+	if sealer == nil {
+		fmt.Fprintf(os.Stderr, "credentials: no credential key configured (set %s or credential-encryption.key-*)\n", secrets.DefaultKeyEnv)
+		return 1
+	}
+
+	if action == "seal-value" {
+		value := strings.TrimSpace(strings.Join(fs.Args(), " "))
+		if value == "" {
+			line, errRead := bufio.NewReader(os.Stdin).ReadString('\n')
+			if errRead != nil && line == "" {
+				fmt.Fprintln(os.Stderr, "credentials: no value provided")
+				return 2
+			}
+			value = strings.TrimSpace(line)
+		}
+		secrets.SetAtRest(sealer, true)
+		sealed, errSeal := secrets.SealValue(value)
+		if errSeal != nil {
+			fmt.Fprintf(os.Stderr, "credentials: %v\n", errSeal)
+			return 1
+		}
+		fmt.Println(sealed)
+		return 0
+	}
+
+	encrypt := action == "encrypt"
+	verb := "decrypted"
+	if encrypt {
+		verb = "encrypted"
+	}
+	secrets.SetAtRest(sealer, encrypt)
+
+	authDir, err := util.ResolveAuthDir(cfg.AuthDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "credentials: failed to resolve auth directory: %v\n", err)
+		return 1
+	}
+	changed, err := migrateAuthFiles(authDir, encrypt)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "credentials: %v\n", err)
+		return 1
+	}
+	fmt.Printf("%d auth files %s.\n", changed, verb)
+
+	if !skipConfig {
+		if _, errStat := os.Stat(resolvedPath); errStat == nil {
+			if !encrypt {
+				cfg.ClearSealedValues()
+			}
+			if err = config.SaveConfigPreserveComments(resolvedPath, cfg); err != nil {
+				fmt.Fprintf(os.Stderr, "credentials: failed to update config: %v\n", err)
+				return 1
+			}
+			fmt.Printf("API keys in %s %s.\n", resolvedPath, verb)
+		}
+	}
+	if encrypt && !cfg.CredentialEncryption.Enable {
+		fmt.Println("Set credential-encryption.enable: true so newly saved credentials are encrypted too.")
+	}
+	if !encrypt && cfg.CredentialEncryption.Enable {
+		fmt.Println("credential-encryption.enable is still true; credentials will be re-encrypted when next saved.")
+	}
+	return 0
+}
+
+// migrateAuthFiles seals or unseals every JSON auth file in authDir and returns how many changed.
+func migrateAuthFiles(authDir string, encrypt bool) (int, error) {
+	entries, err := os.ReadDir(authDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read auth dir: %w", err)
+	}
+	changed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(strings.ToLower(entry.Name()), ".json") {
+			continue
+		}
+		path := filepath.Join(authDir, entry.Name())
+		data, errRead := os.ReadFile(path)
+		if errRead != nil {
+			return changed, fmt.Errorf("failed to read %s: %w", entry.Name(), errRead)
+		}
+		if len(data) == 0 || secrets.IsEncrypted(data) == encrypt {
+			continue
+		}
+		if encrypt {
+			err = secrets.SealFileInPlace(path)
+		} else {
+			var plain []byte
+			if plain, err = secrets.OpenAtRest(data); err == nil {
+				err = os.WriteFile(path, plain, 0o600)
+			}
+		}
+		if err != nil {
+			return changed, fmt.Errorf("failed to migrate %s: %w", entry.Name(), err)
+		}
+		changed++
+	}
+	return changed, nil
+}
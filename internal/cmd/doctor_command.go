@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/secrets"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// doctorSeverity orders findings from informational to blocking.
+type doctorSeverity int
+
+const (
+	doctorOK doctorSeverity = iota
+	doctorWarn
+	doctorFail
+)
+
+func (s doctorSeverity) String() string {
+	switch s {
+	case doctorWarn:
+		return "WARN"
+	case doctorFail:
+		return "FAIL"
+	default:
+		return " OK "
+	}
+}
+
+// doctorFinding is one diagnostic result with an optional hint on how to fix it.
+type doctorFinding struct {
+	severity doctorSeverity
+	check    string
+	message  string
+	hint     string
+}
+
+type doctorReport struct {
+	findings []doctorFinding
+}
+
+func (r *doctorReport) add(severity doctorSeverity, check, message, hint string) {
+	r.findings = append(r.findings, doctorFinding{severity: severity, check: check, message: message, hint: hint})
+}
+
+// providerEndpoints are probed when credentials or API keys for the provider exist.
+var providerEndpoints = map[string]string{
+	"claude":     "https://api.anthropic.com",
+	"codex":      "https://chatgpt.com",
+	"gemini":     "https://generativelanguage.googleapis.com",
+	"gemini-cli": "https://cloudcode-pa.googleapis.com",
+	"vertex":     "https://aiplatform.googleapis.com",
+	"qwen":       "https://portal.qwen.ai",
+	"iflow":      "https://apis.iflow.cn",
+}
+
+// DoDoctorCommand implements the `doctor` subcommand. It validates the configuration,
+// checks credential token expiry, probes upstream connectivity and verifies write access
+// to persistence paths, printing one line per finding with a hint for each problem.
+//
+// Parameters:
+//   - args: The command-line arguments following the subcommand name
+//   - defaultConfigPath: The default configuration file path
+//
+// Returns:
+//   - int: 1 when any check failed, 0 otherwise
+func DoDoctorCommand(args []string, defaultConfigPath string) int {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	var configPath string
+	var offline bool
+	var timeout time.Duration
+	flags.StringVar(&configPath, "config", defaultConfigPath, "Configure File Path")
+	flags.BoolVar(&offline, "offline", false, "Skip upstream connectivity probes")
+	flags.DurationVar(&timeout, "timeout", 10*time.Second, "Timeout for each connectivity probe")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if strings.TrimSpace(configPath) == "" {
+		if wd, err := os.Getwd(); err == nil {
+			configPath = filepath.Join(wd, "config.yaml")
+		}
+	}
+
+	report := &doctorReport{}
+	cfg := doctorCheckConfig(report, configPath)
+	if cfg != nil {
+		providers := doctorCheckCredentials(report, cfg)
+		doctorCheckPersistence(report, cfg, configPath)
+		if !offline {
+			doctorCheckConnectivity(report, cfg, providers, timeout)
+		}
+	}
+
+	failed := 0
+	for _, f := range report.findings {
+		fmt.Printf("[%s] %-13s %s\n", f.severity, f.check, f.message)
+		if f.hint != "" && f.severity != doctorOK {
+			fmt.Printf("       %-13s -> %s\n", "", f.hint)
+		}
+		if f.severity == doctorFail {
+			failed++
+		}
+	}
+	if failed > 0 {
+		fmt.Printf("\n%d check(s) failed.\n", failed)
+		return 1
+	}
+	fmt.Println("\nNo blocking problems found.")
+	return 0
+}
+
+// doctorCheckConfig loads the config file and reports settings that commonly break or
+// expose a deployment. It returns nil when the file cannot be loaded.
+func doctorCheckConfig(report *doctorReport, configPath string) *config.Config {
+	if _, err := os.Stat(configPath); err != nil {
+		report.add(doctorFail, "config", fmt.Sprintf("cannot read %s: %v", configPath, err), "pass --config or copy config.example.yaml to config.yaml")
+		return nil
+	}
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		report.add(doctorFail, "config", err.Error(), "fix the YAML error; config.example.yaml documents every option")
+		return nil
+	}
+	report.add(doctorOK, "config", "loaded "+configPath, "")
+
+	if cfg.Port <= 0 || cfg.Port > 65535 {
+		report.add(doctorFail, "config", fmt.Sprintf("port %d is out of range", cfg.Port), "set port to a value between 1 and 65535")
+	}
+	if len(cfg.APIKeys) == 0 {
+		hint := "add at least one entry under api-keys"
+		if cfg.Host == "" || cfg.Host == "0.0.0.0" || cfg.Host == "::" {
+			report.add(doctorFail, "config", "no api-keys configured and the server listens on all interfaces", hint+", or bind host to 127.0.0.1")
+		} else {
+			report.add(doctorWarn, "config", "no api-keys configured; any local client can use the proxy", hint)
+		}
+	}
+	if cfg.RemoteManagement.AllowRemote && !cfg.RemoteManagement.HasCredentials() {
+		report.add(doctorWarn, "config", "remote-management.allow-remote is on but no management key is set", "set remote-management.secret-key or disable allow-remote")
+	}
+	if raw := strings.TrimSpace(cfg.ProxyURL); raw != "" {
+		if u, errParse := url.Parse(raw); errParse != nil || u.Host == "" {
+			report.add(doctorFail, "config", fmt.Sprintf("proxy-url %q is not a valid URL", raw), "use a URL such as socks5://host:1080 or http://host:8080")
+		}
+	}
+	return cfg
+}
+
+// doctorCheckCredentials reads every auth file and reports unreadable, disabled and
+// expired credentials. It returns the providers that have usable credentials.
+func doctorCheckCredentials(report *doctorReport, cfg *config.Config) map[string]bool {
+	providers := make(map[string]bool)
+	if len(cfg.ClaudeKey) > 0 {
+		providers["claude"] = true
+	}
+	if len(cfg.CodexKey) > 0 {
+		providers["codex"] = true
+	}
+	if len(cfg.GeminiKey) > 0 {
+		providers["gemini"] = true
+	}
+
+	authDir, err := util.ResolveAuthDir(cfg.AuthDir)
+	if err != nil {
+		report.add(doctorFail, "credentials", fmt.Sprintf("cannot resolve auth-dir: %v", err), "set auth-dir to an existing directory")
+		return providers
+	}
+	if _, err = os.Stat(authDir); err != nil {
+		report.add(doctorWarn, "credentials", fmt.Sprintf("auth-dir %s does not exist", authDir), "log in with one of the -*-login flags to create credentials")
+		return providers
+	}
+
+	now := time.Now()
+	total := 0
+	errWalk := filepath.WalkDir(authDir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil || d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), ".json") {
+			return nil
+		}
+		name, _ := filepath.Rel(authDir, path)
+		total++
+		data, errRead := os.ReadFile(path)
+		if errRead == nil && secrets.IsEncrypted(data) {
+			data, errRead = secrets.OpenAtRest(data)
+		}
+		if errRead != nil {
+			report.add(doctorFail, "credentials", fmt.Sprintf("%s: %v", name, errRead), "check file permissions and the credential-encryption key")
+			return nil
+		}
+		var metadata map[string]any
+		if errJSON := json.Unmarshal(data, &metadata); errJSON != nil {
+			report.add(doctorFail, "credentials", fmt.Sprintf("%s: invalid JSON: %v", name, errJSON), "restore the file from a backup or log in again")
+			return nil
+		}
+		provider, _ := metadata["type"].(string)
+		if disabled, _ := metadata["disabled"].(bool); disabled {
+			report.add(doctorWarn, "credentials", fmt.Sprintf("%s (%s) is disabled", name, provider), "re-enable it in the management UI if it should serve traffic")
+			return nil
+		}
+		auth := &cliproxyauth.Auth{Provider: provider, Metadata: metadata}
+		if expiry, ok := auth.ExpirationTime(); ok && expiry.Before(now) && !hasRefreshToken(metadata) {
+			report.add(doctorFail, "credentials", fmt.Sprintf("%s (%s) expired at %s and has no refresh token", name, provider, expiry.Format(time.RFC3339)), "log in again for this account")
+			return nil
+		}
+		if provider != "" {
+			providers[provider] = true
+		}
+		return nil
+	})
+	if errWalk != nil {
+		report.add(doctorFail, "credentials", fmt.Sprintf("cannot read auth-dir: %v", errWalk), "check permissions on "+authDir)
+		return providers
+	}
+	if total == 0 && len(providers) == 0 {
+		report.add(doctorWarn, "credentials", "no credentials or provider API keys configured", "log in with one of the -*-login flags or add provider API keys to config.yaml")
+		return providers
+	}
+	report.add(doctorOK, "credentials", fmt.Sprintf("%d auth files checked in %s", total, authDir), "")
+	return providers
+}
+
+// hasRefreshToken reports whether the token can be renewed without a new login, in
+// which case an expired access token is refreshed on first use.
+func hasRefreshToken(metadata map[string]any) bool {
+	if token, _ := metadata["refresh_token"].(string); strings.TrimSpace(token) != "" {
+		return true
+	}
+	if nested, ok := metadata["token"].(map[string]any); ok {
+		token, _ := nested["refresh_token"].(string)
+		return strings.TrimSpace(token) != ""
+	}
+	return false
+}
+
+// doctorCheckPersistence verifies that every directory the server writes to accepts new files.
+func doctorCheckPersistence(report *doctorReport, cfg *config.Config, configPath string) {
+	dirs := map[string]string{
+		"statistics": filepath.Join(filepath.Dir(configPath), "logs"),
+	}
+	if authDir, err := util.ResolveAuthDir(cfg.AuthDir); err == nil {
+		dirs["auth-dir"] = authDir
+	}
+	if cfg.LoggingToFile || cfg.RequestLog {
+		dirs["logs"] = logging.ResolveLogDirectory(cfg)
+	}
+	names := make([]string, 0, len(dirs))
+	for name := range dirs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		dir := dirs[name]
+		if err := checkWritableDir(dir); err != nil {
+			report.add(doctorFail, "persistence", fmt.Sprintf("%s directory %s is not writable: %v", name, dir, err), "create the directory or fix its ownership (Docker: check the volume mount)")
+			continue
+		}
+		report.add(doctorOK, "persistence", fmt.Sprintf("%s directory %s is writable", name, dir), "")
+	}
+}
+
+// checkWritableDir creates the directory if needed and writes and removes a probe file.
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	_ = f.Close()
+	return os.Remove(name)
+}
+
+// doctorCheckConnectivity probes the endpoints of providers in use, plus configured
+// base URLs, through the configured proxy. Any HTTP response counts as reachable.
+func doctorCheckConnectivity(report *doctorReport, cfg *config.Config, providers map[string]bool, timeout time.Duration) {
+	targets := make(map[string]string)
+	for provider := range providers {
+		if endpoint, ok := providerEndpoints[provider]; ok {
+			targets[endpoint] = provider
+		}
+	}
+	for _, key := range cfg.ClaudeKey {
+		if key.BaseURL != "" {
+			targets[key.BaseURL] = "claude-api-key"
+		}
+	}
+	for _, key := range cfg.CodexKey {
+		if key.BaseURL != "" {
+			targets[key.BaseURL] = "codex-api-key"
+		}
+	}
+	for _, key := range cfg.GeminiKey {
+		if key.BaseURL != "" {
+			targets[key.BaseURL] = "gemini-api-key"
+		}
+	}
+	for _, compat := range cfg.OpenAICompatibility {
+		if compat.BaseURL != "" {
+			targets[compat.BaseURL] = compat.Name
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+
+	client := util.SetProxy(&cfg.SDKConfig, &http.Client{Timeout: timeout})
+	endpoints := make([]string, 0, len(targets))
+	for endpoint := range targets {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+
+	results := make([]doctorFinding, len(endpoints))
+	var wg sync.WaitGroup
+	for i, endpoint := range endpoints {
+		wg.Add(1)
+		go func(i int, endpoint string) {
+			defer wg.Done()
+			label := fmt.Sprintf("%s (%s)", endpoint, targets[endpoint])
+			start := time.Now()
+			resp, err := client.Get(endpoint)
+			if err != nil {
+				hint := "check DNS and firewall rules"
+				if cfg.ProxyURL != "" {
+					hint = "check that the server behind proxy-url is reachable"
+				}
+				results[i] = doctorFinding{severity: doctorFail, check: "connectivity", message: fmt.Sprintf("%s unreachable: %v", label, err), hint: hint}
+				return
+			}
+			_ = resp.Body.Close()
+			results[i] = doctorFinding{severity: doctorOK, check: "connectivity", message: fmt.Sprintf("%s reachable (HTTP %d, %s)", label, resp.StatusCode, time.Since(start).Round(time.Millisecond))}
+		}(i, endpoint)
+	}
+	wg.Wait()
+	report.findings = append(report.findings, results...)
+}
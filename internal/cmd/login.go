@@ -102,8 +102,11 @@ func DoLogin(cfg *config.Config, projectID string, options *LoginOptions) {
 
 	var activatedProjects []string
 
+	// An account already onboarded to Code Assist needs no project selection.
+	detected := trimmedProjectID == "" && detectOnboardedProject(ctx, httpClient, storage)
+
 	useGoogleOne := false
-	if trimmedProjectID == "" && promptFn != nil {
+	if !detected && trimmedProjectID == "" && promptFn != nil {
 		fmt.Println("\nSelect login mode:")
 		fmt.Println("  1. Code Assist  (GCP project, manual selection)")
 		fmt.Println("  2. Google One   (personal account, auto-discover project)")
@@ -113,7 +116,11 @@ func DoLogin(cfg *config.Config, projectID string, options *LoginOptions) {
 		}
 	}
 
-	if useGoogleOne {
+	switch {
+	case detected:
+		log.Infof("Detected Gemini Code Assist project %s (%s)", storage.ProjectID, storage.Tier)
+		activatedProjects = []string{strings.TrimSpace(storage.ProjectID)}
+	case useGoogleOne:
 		log.Info("Google One mode: auto-discovering project...")
 		if errSetup := performGeminiCLISetup(ctx, httpClient, storage, ""); errSetup != nil {
 			log.Errorf("Google One auto-discovery failed: %v", errSetup)
@@ -126,7 +133,7 @@ func DoLogin(cfg *config.Config, projectID string, options *LoginOptions) {
 		}
 		log.Infof("Auto-discovered project: %s", autoProject)
 		activatedProjects = []string{autoProject}
-	} else {
+	default:
 		projects, errProjects := fetchGCPProjects(ctx, httpClient)
 		if errProjects != nil {
 			log.Errorf("Failed to get project list: %v", errProjects)
@@ -173,6 +180,10 @@ func DoLogin(cfg *config.Config, projectID string, options *LoginOptions) {
 	storage.Auto = false
 	storage.ProjectID = strings.Join(activatedProjects, ",")
 
+	if gemini.IsManagedProjectTier(storage.Tier) {
+		// Google enables the Cloud AI API in the projects it manages.
+		storage.Checked = true
+	}
 	if !storage.Auto && !storage.Checked {
 		for _, pid := range activatedProjects {
 			isChecked, errCheck := checkCloudAPIIsEnabled(ctx, httpClient, pid)
@@ -230,33 +241,17 @@ func performGeminiCLISetup(ctx context.Context, httpClient *http.Client, storage
 		return fmt.Errorf("load code assist: %w", errLoad)
 	}
 
-	tierID := "legacy-tier"
-	if tiers, okTiers := loadResp["allowedTiers"].([]any); okTiers {
-		for _, rawTier := range tiers {
-			tier, okTier := rawTier.(map[string]any)
-			if !okTier {
-				continue
-			}
-			if isDefault, okDefault := tier["isDefault"].(bool); okDefault && isDefault {
-				if id, okID := tier["id"].(string); okID && strings.TrimSpace(id) != "" {
-					tierID = strings.TrimSpace(id)
-					break
-				}
-			}
-		}
-	}
+	tierID, onboarded := gemini.CodeAssistTier(loadResp)
+	storage.Tier = tierID
 
 	projectID := trimmedRequest
 	if projectID == "" {
-		if id, okProject := loadResp["cloudaicompanionProject"].(string); okProject {
-			projectID = strings.TrimSpace(id)
-		}
-		if projectID == "" {
-			if projectMap, okProject := loadResp["cloudaicompanionProject"].(map[string]any); okProject {
-				if id, okID := projectMap["id"].(string); okID {
-					projectID = strings.TrimSpace(id)
-				}
-			}
+		projectID = gemini.CodeAssistProject(loadResp)
+		if onboarded && projectID != "" {
+			// The account is already onboarded to this project; nothing to set up.
+			storage.ProjectID = projectID
+			log.Infof("Gemini Code Assist: using project %s (%s)", projectID, tierID)
+			return nil
 		}
 	}
 	if projectID == "" {
@@ -278,14 +273,7 @@ func performGeminiCLISetup(ctx context.Context, httpClient *http.Client, storage
 
 			if done, okDone := onboardResp["done"].(bool); okDone && done {
 				if resp, okResp := onboardResp["response"].(map[string]any); okResp {
-					switch v := resp["cloudaicompanionProject"].(type) {
-					case string:
-						projectID = strings.TrimSpace(v)
-					case map[string]any:
-						if id, okID := v["id"].(string); okID {
-							projectID = strings.TrimSpace(id)
-						}
-					}
+					projectID = gemini.CodeAssistProject(resp)
 				}
 				break
 			}
@@ -302,6 +290,11 @@ func performGeminiCLISetup(ctx context.Context, httpClient *http.Client, storage
 			return &projectSelectionRequiredError{}
 		}
 		log.Infof("Auto-discovered project ID via onboarding: %s", projectID)
+		if gemini.IsManagedProjectTier(tierID) {
+			// Google manages the project of this tier; onboarding is already complete.
+			storage.ProjectID = projectID
+			return nil
+		}
 	}
 
 	onboardReqBody := map[string]any{
@@ -322,14 +315,7 @@ func performGeminiCLISetup(ctx context.Context, httpClient *http.Client, storage
 		if done, okDone := onboardResp["done"].(bool); okDone && done {
 			responseProjectID := ""
 			if resp, okResp := onboardResp["response"].(map[string]any); okResp {
-				switch projectValue := resp["cloudaicompanionProject"].(type) {
-				case map[string]any:
-					if id, okID := projectValue["id"].(string); okID {
-						responseProjectID = strings.TrimSpace(id)
-					}
-				case string:
-					responseProjectID = strings.TrimSpace(projectValue)
-				}
+				responseProjectID = gemini.CodeAssistProject(resp)
 			}
 
 			finalProjectID := projectID
@@ -389,6 +375,33 @@ func performGeminiCLISetup(ctx context.Context, httpClient *http.Client, storage
 	}
 }
 
+// detectOnboardedProject asks loadCodeAssist whether the account is already onboarded
+// to Gemini Code Assist and, if so, stores its project and tier. Accounts set up through
+// the Gemini CLI or a Google One subscription are detected this way without prompting
+// for a project.
+func detectOnboardedProject(ctx context.Context, httpClient *http.Client, storage *gemini.GeminiTokenStorage) bool {
+	loadReqBody := map[string]any{
+		"metadata": map[string]string{
+			"ideType":    "IDE_UNSPECIFIED",
+			"platform":   "PLATFORM_UNSPECIFIED",
+			"pluginType": "GEMINI",
+		},
+	}
+	var loadResp map[string]any
+	if errLoad := callGeminiCLI(ctx, httpClient, "loadCodeAssist", loadReqBody, &loadResp); errLoad != nil {
+		log.Debugf("Gemini Code Assist detection failed: %v", errLoad)
+		return false
+	}
+	tierID, onboarded := gemini.CodeAssistTier(loadResp)
+	projectID := gemini.CodeAssistProject(loadResp)
+	if !onboarded || projectID == "" {
+		return false
+	}
+	storage.ProjectID = projectID
+	storage.Tier = tierID
+	return true
+}
+
 func callGeminiCLI(ctx context.Context, httpClient *http.Client, endpoint string, body any, result any) error {
 	url := fmt.Sprintf("%s/%s:%s", geminiCLIEndpoint, geminiCLIVersion, endpoint)
 	if strings.HasPrefix(endpoint, "operations/") {
@@ -692,6 +705,9 @@ func updateAuthRecord(record *cliproxyauth.Auth, storage *gemini.GeminiTokenStor
 	record.Metadata["project_id"] = storage.ProjectID
 	record.Metadata["auto"] = storage.Auto
 	record.Metadata["checked"] = storage.Checked
+	if storage.Tier != "" {
+		record.Metadata["tier"] = storage.Tier
+	}
 
 	record.ID = finalName
 	record.FileName = finalName
@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// managementClient is a minimal client for the management API of a running instance.
+// It is used by CLI subcommands that operate on live server state.
+type managementClient struct {
+	baseURL   string
+	secretKey string
+	http      *http.Client
+}
+
+// newManagementClient creates a client for the management API.
+// An empty baseURL targets the local instance on the configured port.
+func newManagementClient(cfg *config.Config, baseURL, secretKey string) *managementClient {
+	if strings.TrimSpace(baseURL) == "" {
+		port := 8317
+		if cfg != nil && cfg.Port > 0 {
+			port = cfg.Port
+		}
+		baseURL = fmt.Sprintf("http://127.0.0.1:%d", port)
+	}
+	if strings.TrimSpace(secretKey) == "" {
+		secretKey = os.Getenv("MANAGEMENT_PASSWORD")
+	}
+	return &managementClient{
+		baseURL:   strings.TrimRight(strings.TrimSpace(baseURL), "/"),
+		secretKey: strings.TrimSpace(secretKey),
+		http:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// do executes a management request and returns the response body.
+// Non-2xx responses are returned as errors that include the server message.
+func (c *managementClient) do(method, path string, body []byte) ([]byte, error) {
+	return c.doWithHeaders(method, path, body, nil)
+}
+
+// doWithHeaders is like do but sets additional request headers.
+func (c *managementClient) doWithHeaders(method, path string, body []byte, headers map[string]string) ([]byte, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, c.baseURL+"/v0/management"+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	if c.secretKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.secretKey)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("management API %s %s: HTTP %d: %s", method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+// getJSON decodes the JSON response of a GET request into out.
+func (c *managementClient) getJSON(path string, out any) error {
+	data, err := c.do(http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// resolveSubcommandConfig loads the configuration for a CLI subcommand.
+// It falls back to config.yaml in the working directory and tolerates a missing file.
+func resolveSubcommandConfig(configPath string) (*config.Config, string, error) {
+	if strings.TrimSpace(configPath) == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get working directory: %w", err)
+		}
+		configPath = filepath.Join(wd, "config.yaml")
+	}
+	cfg, err := config.LoadConfigOptional(configPath, true)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load config: %w", err)
+	}
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	cfg.ApplyCredentialEncryption()
+	return cfg, configPath, nil
+}
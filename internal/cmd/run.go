@@ -6,6 +6,7 @@ package cmd
 import (
 	"context"
 	"errors"
+	"os"
 	"os/signal"
 	"path/filepath"
 	"syscall"
@@ -13,6 +14,8 @@ import (
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/store"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy"
 	log "github.com/sirupsen/logrus"
@@ -31,6 +34,15 @@ func StartService(cfg *config.Config, configPath string, localPassword string) {
 	// File sẽ được lưu trong thư mục logs (để persist qua Docker volume)
 	statsPath := filepath.Join(filepath.Dir(configPath), "logs", "usage_statistics.json")
 	usage.SetStatsFilePath(statsPath)
+	rateLimitPath := filepath.Join(filepath.Dir(configPath), "logs", "ratelimit_statistics.json")
+
+	// Restore snapshots from object storage before loading them, for deployments
+	// without a persistent volume.
+	stateSync, errStateSync := store.NewStateSync(cfg.PersistenceStorage, []string{statsPath, rateLimitPath}, logging.ResolveLogDirectory(cfg))
+	if errStateSync != nil {
+		log.Warnf("persistence storage disabled: %v", errStateSync)
+	}
+	stateSync.Restore(context.Background())
 
 	// Load statistics từ file (nếu có)
 	if err := usage.GetRequestStatistics().Load(); err != nil {
@@ -38,16 +50,14 @@ func StartService(cfg *config.Config, configPath string, localPassword string) {
 	}
 
 	// Setup rate limit statistics persistence
-	rateLimitPath := filepath.Join(filepath.Dir(configPath), "logs", "ratelimit_statistics.json")
 	usage.SetRateLimitFilePath(rateLimitPath)
 	if err := usage.GetRateLimitStore().Load(); err != nil {
 		log.Warnf("failed to load ratelimit statistics: %v", err)
 	}
 
-	// Start auto-save mỗi 1 phút
-	autoSaveCtx, autoSaveCancel := context.WithCancel(context.Background())
-	usage.StartAutoSave(autoSaveCtx, 1*time.Minute)
-	usage.StartRateLimitAutoSave(autoSaveCtx, 1*time.Minute)
+	// Auto-save của usage stores chạy trong lifecycle của service
+	stateSyncCtx, stateSyncCancel := context.WithCancel(context.Background())
+	go stateSync.Run(stateSyncCtx)
 
 	builder := cliproxy.NewBuilder().
 		WithConfig(cfg).
@@ -56,6 +66,8 @@ func StartService(cfg *config.Config, configPath string, localPassword string) {
 
 	ctxSignal, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
+	ctxSignal, serviceStopped := runUnderServiceManager(ctxSignal)
+	defer serviceStopped()
 
 	runCtx := ctxSignal
 	if localPassword != "" {
@@ -67,24 +79,28 @@ func StartService(cfg *config.Config, configPath string, localPassword string) {
 		}))
 	}
 
+	builder = builder.WithHooks(cliproxy.Hooks{OnAfterStart: func(service *cliproxy.Service) {
+		startSupervision(runCtx, service)
+	}})
+
 	service, err := builder.Build()
 	if err != nil {
 		log.Errorf("failed to build proxy service: %v", err)
-		autoSaveCancel()
-		usage.StopAutoSave()
-		usage.StopRateLimitAutoSave()
+		stateSyncCancel()
+		stateSync.Flush(context.Background())
 		return
 	}
 
+	rescanAuthsOnSignal(runCtx, service)
 	err = service.Run(runCtx)
+	notifyStopping()
 	if err != nil && !errors.Is(err, context.Canceled) {
 		log.Errorf("proxy service exited with error: %v", err)
 	}
 
-	// Cleanup: dừng auto-save và save lần cuối
-	autoSaveCancel()
-	usage.StopAutoSave()
-	usage.StopRateLimitAutoSave()
+	// Cleanup: service đã save usage stores lần cuối khi shutdown
+	stateSyncCancel()
+	stateSync.Flush(context.Background())
 }
 
 // StartServiceBackground starts the proxy service in a background goroutine
@@ -105,6 +121,7 @@ func StartServiceBackground(cfg *config.Config, configPath string, localPassword
 		return cancelFn, doneCh
 	}
 
+	rescanAuthsOnSignal(ctx, service)
 	go func() {
 		defer close(doneCh)
 		if err := service.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
@@ -115,6 +132,27 @@ func StartServiceBackground(cfg *config.Config, configPath string, localPassword
 	return cancelFn, doneCh
 }
 
+// rescanAuthsOnSignal rescans the auth directory whenever the process receives SIGHUP,
+// until ctx is done.
+func rescanAuthsOnSignal(ctx context.Context, service *cliproxy.Service) {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(sighup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sighup:
+				log.Info("SIGHUP received, rescanning auth directory")
+				if _, err := service.RescanAuths(); err != nil {
+					log.Warnf("auth directory rescan failed: %v", err)
+				}
+			}
+		}
+	}()
+}
+
 // WaitForCloudDeploy waits indefinitely for shutdown signals in cloud deploy mode
 // when no configuration file is available.
 func WaitForCloudDeploy() {
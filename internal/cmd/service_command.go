@@ -0,0 +1,183 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// defaultServiceName names the systemd unit or Windows service created by `service install`.
+const defaultServiceName = "cliproxyapi"
+
+// serviceOptions describes the service that `service install` registers.
+type serviceOptions struct {
+	name        string
+	executable  string
+	configPath  string
+	user        string
+	unitDir     string
+	watchdogSec int
+	start       bool
+}
+
+// DoServiceCommand implements the `service install|uninstall` subcommands. On Linux it
+// writes a systemd unit of Type=notify with a watchdog; on Windows it registers the
+// binary with the service control manager and restarts it on failure.
+//
+// Parameters:
+//   - args: The command-line arguments following the subcommand name
+//   - defaultConfigPath: The default configuration file path
+//
+// Returns:
+//   - int: The process exit code
+func DoServiceCommand(args []string, defaultConfigPath string) int {
+	if len(args) == 0 || (args[0] != "install" && args[0] != "uninstall") {
+		fmt.Fprintln(os.Stderr, "usage: service <install|uninstall> [flags]")
+		return 2
+	}
+	action := args[0]
+
+	fs := flag.NewFlagSet("service "+action, flag.ContinueOnError)
+	opts := serviceOptions{}
+	var noStart bool
+	fs.StringVar(&opts.name, "name", defaultServiceName, "Service name")
+	if action == "install" {
+		fs.StringVar(&opts.configPath, "config", defaultConfigPath, "Configure File Path")
+		fs.StringVar(&opts.user, "user", "", "User the service runs as (systemd only)")
+		fs.IntVar(&opts.watchdogSec, "watchdog-sec", 60, "Restart the service when it stops responding for this many seconds (0 disables)")
+		fs.BoolVar(&noStart, "no-start", false, "Register the service without enabling and starting it")
+	}
+	fs.StringVar(&opts.unitDir, "unit-dir", "/etc/systemd/system", "Directory for the systemd unit file (systemd only)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	opts.start = !noStart
+
+	if action == "install" {
+		exe, err := os.Executable()
+		if err == nil {
+			exe, err = filepath.EvalSymlinks(exe)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "service: cannot resolve executable path: %v\n", err)
+			return 1
+		}
+		opts.executable = exe
+		if strings.TrimSpace(opts.configPath) == "" {
+			wd, errWd := os.Getwd()
+			if errWd != nil {
+				fmt.Fprintf(os.Stderr, "service: %v\n", errWd)
+				return 1
+			}
+			opts.configPath = filepath.Join(wd, "config.yaml")
+		}
+		if opts.configPath, err = filepath.Abs(opts.configPath); err != nil {
+			fmt.Fprintf(os.Stderr, "service: %v\n", err)
+			return 1
+		}
+	}
+
+	var err error
+	switch {
+	case runtime.GOOS == "windows" && action == "install":
+		err = installWindowsService(opts)
+	case runtime.GOOS == "windows":
+		err = uninstallWindowsService(opts.name)
+	case runtime.GOOS == "linux" && action == "install":
+		err = installSystemdUnit(opts)
+	case runtime.GOOS == "linux":
+		err = uninstallSystemdUnit(opts)
+	default:
+		err = fmt.Errorf("service management is not supported on %s", runtime.GOOS)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "service: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// systemdUnit renders the unit file for opts.
+func systemdUnit(opts serviceOptions) string {
+	var b strings.Builder
+	b.WriteString("[Unit]\n")
+	b.WriteString("Description=CLI Proxy API\n")
+	b.WriteString("Wants=network-online.target\n")
+	b.WriteString("After=network-online.target\n\n")
+	b.WriteString("[Service]\n")
+	b.WriteString("Type=notify\n")
+	b.WriteString("NotifyAccess=main\n")
+	fmt.Fprintf(&b, "ExecStart=%s -config %s\n", systemdQuote(opts.executable), systemdQuote(opts.configPath))
+	fmt.Fprintf(&b, "WorkingDirectory=%s\n", systemdQuote(filepath.Dir(opts.configPath)))
+	if opts.user != "" {
+		fmt.Fprintf(&b, "User=%s\n", opts.user)
+	}
+	if opts.watchdogSec > 0 {
+		fmt.Fprintf(&b, "WatchdogSec=%d\n", opts.watchdogSec)
+	}
+	b.WriteString("Restart=on-failure\n")
+	b.WriteString("RestartSec=5\n")
+	b.WriteString("TimeoutStopSec=30\n\n")
+	b.WriteString("[Install]\n")
+	b.WriteString("WantedBy=multi-user.target\n")
+	return b.String()
+}
+
+// systemdQuote quotes a path for ExecStart= when it contains whitespace or quotes.
+func systemdQuote(value string) string {
+	if !strings.ContainsAny(value, " \t\"'\\") {
+		return value
+	}
+	return strconv.Quote(value)
+}
+
+func installSystemdUnit(opts serviceOptions) error {
+	path := filepath.Join(opts.unitDir, opts.name+".service")
+	if err := os.WriteFile(path, []byte(systemdUnit(opts)), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s (run as root or pass --unit-dir): %w", path, err)
+	}
+	fmt.Printf("Wrote %s\n", path)
+	if err := runSystemctl("daemon-reload"); err != nil {
+		return err
+	}
+	if !opts.start {
+		fmt.Printf("Start it with: systemctl enable --now %s\n", opts.name)
+		return nil
+	}
+	if err := runSystemctl("enable", "--now", opts.name); err != nil {
+		return err
+	}
+	fmt.Printf("Service %s enabled and started.\n", opts.name)
+	return nil
+}
+
+func uninstallSystemdUnit(opts serviceOptions) error {
+	path := filepath.Join(opts.unitDir, opts.name+".service")
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("unit %s not found: %w", path, err)
+	}
+	if err := runSystemctl("disable", "--now", opts.name); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+	if err := runSystemctl("daemon-reload"); err != nil {
+		return err
+	}
+	fmt.Printf("Service %s removed.\n", opts.name)
+	return nil
+}
+
+func runSystemctl(args ...string) error {
+	out, err := exec.Command("systemctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("systemctl %s: %v: %s", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
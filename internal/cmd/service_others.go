@@ -0,0 +1,28 @@
+//go:build !windows
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+func installWindowsService(serviceOptions) error {
+	return fmt.Errorf("windows services are only available on windows")
+}
+
+func uninstallWindowsService(string) error {
+	return fmt.Errorf("windows services are only available on windows")
+}
+
+// runUnderServiceManager is a no-op outside Windows; systemd talks to the process
+// through signals and sd_notify instead.
+func runUnderServiceManager(ctx context.Context) (context.Context, func()) {
+	return ctx, func() {}
+}
+
+// serviceManagerWatchdogInterval reports no watchdog outside Windows.
+func serviceManagerWatchdogInterval() (time.Duration, bool) {
+	return 0, false
+}
@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// windowsServiceWatchdog is how long a stalled service may stay unresponsive before it
+// exits so the service recovery actions restart it.
+const windowsServiceWatchdog = 60 * time.Second
+
+func installWindowsService(opts serviceOptions) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager (run as administrator): %w", err)
+	}
+	defer func() { _ = m.Disconnect() }()
+
+	if existing, errOpen := m.OpenService(opts.name); errOpen == nil {
+		_ = existing.Close()
+		return fmt.Errorf("service %s already exists", opts.name)
+	}
+	s, err := m.CreateService(opts.name, opts.executable, mgr.Config{
+		DisplayName: "CLI Proxy API",
+		StartType:   mgr.StartAutomatic,
+	}, "-config", opts.configPath)
+	if err != nil {
+		return fmt.Errorf("failed to create service %s: %w", opts.name, err)
+	}
+	defer func() { _ = s.Close() }()
+
+	recovery := []mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: 5 * time.Second},
+		{Type: mgr.ServiceRestart, Delay: 30 * time.Second},
+		{Type: mgr.ServiceRestart, Delay: time.Minute},
+	}
+	if err = s.SetRecoveryActions(recovery, uint32((24 * time.Hour).Seconds())); err != nil {
+		return fmt.Errorf("failed to set recovery actions: %w", err)
+	}
+	fmt.Printf("Service %s installed.\n", opts.name)
+	if !opts.start {
+		fmt.Printf("Start it with: sc.exe start %s\n", opts.name)
+		return nil
+	}
+	if err = s.Start(); err != nil {
+		return fmt.Errorf("failed to start service %s: %w", opts.name, err)
+	}
+	fmt.Printf("Service %s started.\n", opts.name)
+	return nil
+}
+
+func uninstallWindowsService(name string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect to the service manager (run as administrator): %w", err)
+	}
+	defer func() { _ = m.Disconnect() }()
+
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("service %s not found: %w", name, err)
+	}
+	defer func() { _ = s.Close() }()
+	if status, errQuery := s.Query(); errQuery == nil && status.State != svc.Stopped {
+		if _, errStop := s.Control(svc.Stop); errStop != nil {
+			log.Warnf("failed to stop service %s: %v", name, errStop)
+		}
+	}
+	if err = s.Delete(); err != nil {
+		return fmt.Errorf("failed to delete service %s: %w", name, err)
+	}
+	fmt.Printf("Service %s removed.\n", name)
+	return nil
+}
+
+// windowsService bridges service control requests to the proxy's run context.
+type windowsService struct {
+	cancel  context.CancelFunc
+	stopped chan struct{}
+}
+
+// Execute implements svc.Handler.
+func (w *windowsService) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case <-w.stopped:
+			status <- svc.Status{State: svc.StopPending}
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				w.cancel()
+			}
+		}
+	}
+}
+
+// runUnderServiceManager connects to the Windows service control manager when the
+// process was started as a service. The returned context is cancelled when the service
+// is stopped; the returned function must be called once the proxy has shut down.
+func runUnderServiceManager(ctx context.Context) (context.Context, func()) {
+	isService, err := svc.IsWindowsService()
+	if err != nil || !isService {
+		return ctx, func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	handler := &windowsService{cancel: cancel, stopped: make(chan struct{})}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if errRun := svc.Run(defaultServiceName, handler); errRun != nil {
+			log.Errorf("windows service: %v", errRun)
+		}
+		cancel()
+	}()
+	return ctx, func() {
+		close(handler.stopped)
+		<-done
+	}
+}
+
+// serviceManagerWatchdogInterval enables the exit-on-stall watchdog for Windows services.
+func serviceManagerWatchdogInterval() (time.Duration, bool) {
+	if isService, err := svc.IsWindowsService(); err == nil && isService {
+		return windowsServiceWatchdog, true
+	}
+	return 0, false
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/sdnotify"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy"
+	log "github.com/sirupsen/logrus"
+)
+
+// startSupervision reports readiness to the service manager and starts the liveness
+// watchdog. Under systemd the watchdog withholds WATCHDOG=1 pings while the service is
+// stalled, so systemd restarts it; under the Windows service manager, which has no
+// watchdog protocol, a stalled process exits and the service recovery actions restart it.
+func startSupervision(ctx context.Context, service *cliproxy.Service) {
+	if notified, err := sdnotify.Notify(sdnotify.Ready); err != nil {
+		log.Warnf("failed to notify systemd of readiness: %v", err)
+	} else if notified {
+		log.Info("notified systemd that the service is ready")
+	}
+
+	interval := sdnotify.WatchdogInterval()
+	exitOnStall := false
+	if interval == 0 {
+		interval, exitOnStall = serviceManagerWatchdogInterval()
+	}
+	if interval <= 0 {
+		return
+	}
+	ping := func() {
+		if _, err := sdnotify.Notify(sdnotify.Watchdog); err != nil {
+			log.Debugf("watchdog ping failed: %v", err)
+		}
+	}
+	onStall := func() {
+		log.Errorf("watchdog: service did not respond within %s, it looks deadlocked", interval/2)
+		if exitOnStall {
+			os.Exit(1)
+		}
+	}
+	log.Infof("watchdog enabled with a %s timeout", interval)
+	go sdnotify.RunWatchdog(ctx, interval, service.CheckResponsive, ping, onStall)
+}
+
+// notifyStopping tells systemd that a graceful shutdown started.
+func notifyStopping() {
+	_, _ = sdnotify.Notify(sdnotify.Stopping)
+}
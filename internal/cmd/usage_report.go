@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+)
+
+// usageReport is the machine-readable output of the usage subcommand.
+type usageReport struct {
+	Window        string               `json:"window"`
+	TotalRequests int64                `json:"total_requests"`
+	FailedCount   int64                `json:"failed_requests"`
+	TotalTokens   int64                `json:"total_tokens"`
+	EstimatedCost float64              `json:"estimated_cost_usd"`
+	Accounts      []usage.AccountUsage `json:"accounts"`
+	SpillOver     usage.SpillOverUsage `json:"spill_over"`
+}
+
+// DoUsageReport implements the `usage` subcommand.
+// It prints per-account utilization, token and estimated cost tables either from the
+// persisted usage/rate-limit stores next to the config file, or from a running instance
+// via the management API when --remote is set.
+//
+// Parameters:
+//   - args: The command-line arguments following the subcommand name
+//   - defaultConfigPath: The default configuration file path
+//
+// Returns:
+//   - int: The process exit code
+func DoUsageReport(args []string, defaultConfigPath string) int {
+	fs := flag.NewFlagSet("usage", flag.ContinueOnError)
+	var configPath, window, remoteURL, password string
+	var remote, jsonOutput bool
+	fs.StringVar(&configPath, "config", defaultConfigPath, "Configure File Path")
+	fs.StringVar(&window, "window", "5h", "Rate limit window to summarise (e.g. 5h, 7d)")
+	fs.BoolVar(&remote, "remote", false, "Query a running instance via the management API instead of reading persisted stores")
+	fs.StringVar(&remoteURL, "url", "", "Base URL of the running instance (defaults to http://127.0.0.1:<port>)")
+	fs.StringVar(&password, "password", "", "Management key (defaults to MANAGEMENT_PASSWORD)")
+	fs.BoolVar(&jsonOutput, "json", false, "Print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	windowDuration, err := usage.ParseWindow(window)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "usage: %v\n", err)
+		return 2
+	}
+
+	cfg, resolvedPath, err := resolveSubcommandConfig(configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "usage: %v\n", err)
+		return 1
+	}
+
+	var snapshot usage.StatisticsSnapshot
+	var limits usage.WindowSummary
+	if remote || remoteURL != "" {
+		client := newManagementClient(cfg, remoteURL, password)
+		var payload struct {
+			Usage usage.StatisticsSnapshot `json:"usage"`
+		}
+		if err = client.getJSON("/usage", &payload); err != nil {
+			fmt.Fprintf(os.Stderr, "usage: %v\n", err)
+			return 1
+		}
+		snapshot = payload.Usage
+		if err = client.getJSON("/usage/ratelimit?window="+url.QueryEscape(window), &limits); err != nil {
+			fmt.Fprintf(os.Stderr, "usage: %v\n", err)
+			return 1
+		}
+	} else {
+		logsDir := filepath.Join(filepath.Dir(resolvedPath), "logs")
+		usage.SetStatsFilePath(filepath.Join(logsDir, "usage_statistics.json"))
+		usage.SetRateLimitFilePath(filepath.Join(logsDir, "ratelimit_statistics.json"))
+		stats := usage.NewRequestStatistics()
+		if err = stats.Load(); err != nil {
+			fmt.Fprintf(os.Stderr, "usage: %v\n", err)
+			return 1
+		}
+		store := usage.NewRateLimitStore()
+		if err = store.Load(); err != nil {
+			fmt.Fprintf(os.Stderr, "usage: %v\n", err)
+			return 1
+		}
+		snapshot = stats.Snapshot()
+		limits = store.QueryByWindow(windowDuration)
+	}
+
+	report := usageReport{
+		Window:        window,
+		TotalRequests: snapshot.TotalRequests,
+		FailedCount:   snapshot.FailureCount,
+		TotalTokens:   snapshot.TotalTokens,
+		Accounts:      usage.BuildAccountReport(snapshot, limits),
+		SpillOver:     usage.BuildSpillOverReport(snapshot),
+	}
+	for _, acc := range report.Accounts {
+		report.EstimatedCost += acc.EstimatedCost
+	}
+
+	if jsonOutput {
+		return printJSON(report)
+	}
+	printUsageReport(os.Stdout, report)
+	return 0
+}
+
+// printUsageReport renders the usage report as aligned text tables.
+func printUsageReport(out io.Writer, report usageReport) {
+	_, _ = fmt.Fprintf(out, "Requests: %d (failed %d)  Tokens: %d  Estimated cost: $%.4f\n\n",
+		report.TotalRequests, report.FailedCount, report.TotalTokens, report.EstimatedCost)
+	if report.SpillOver.Requests > 0 {
+		_, _ = fmt.Fprintf(out, "Spilled to API keys: %d requests  Tokens: %d  Estimated cost: $%.4f\n\n",
+			report.SpillOver.Requests, report.SpillOver.Tokens.TotalTokens, report.SpillOver.EstimatedCost)
+	}
+
+	tw := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ACCOUNT\tREQUESTS\tFAILED\tINPUT\tOUTPUT\tCACHED\tCOST (USD)\t5H\t7D")
+	for _, acc := range report.Accounts {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%d\t%.4f\t%s\t%s\n",
+			acc.Source, acc.Requests, acc.Failed,
+			acc.Tokens.InputTokens, acc.Tokens.OutputTokens, acc.Tokens.CachedTokens,
+			acc.EstimatedCost,
+			formatUtilization(acc.Utilization5h, acc.Status5h),
+			formatUtilization(acc.Utilization7d, acc.Status7d))
+	}
+	_ = tw.Flush()
+}
+
+// formatUtilization formats a 0.0-1.0 utilization with its status for table output.
+func formatUtilization(value float64, status string) string {
+	if status == "" && value == 0 {
+		return "-"
+	}
+	if status == "" {
+		return fmt.Sprintf("%.1f%%", value*100)
+	}
+	return fmt.Sprintf("%.1f%% (%s)", value*100, status)
+}
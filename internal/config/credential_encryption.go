@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/secrets"
+)
+
+// CredentialEncryption configures encryption of credential material at rest.
+// The key is taken from the first configured source: passphrase, key-env
+// (default CLIPROXY_CREDENTIAL_KEY), then key-file.
+type CredentialEncryption struct {
+	// Enable seals newly written auth files and API keys. Existing plaintext
+	// credentials keep working and are sealed the next time they are saved.
+	Enable bool `yaml:"enable,omitempty"`
+	// Passphrase is an inline key; prefer key-env or key-file in production.
+	Passphrase string `yaml:"passphrase,omitempty"`
+	// KeyEnv names the environment variable holding the key.
+	KeyEnv string `yaml:"key-env,omitempty"`
+	// KeyFile is a file whose content is the key, such as a mounted secret.
+	KeyFile string `yaml:"key-file,omitempty"`
+}
+
+// KeySource converts the configuration into a secrets.KeySource.
+func (c CredentialEncryption) KeySource() secrets.KeySource {
+	return secrets.KeySource{
+		Passphrase: c.Passphrase,
+		Env:        c.KeyEnv,
+		File:       c.KeyFile,
+	}
+}
+
+// ClearSealedValues forgets the sealed form of API keys so the next save writes
+// them according to the current sealing mode only.
+func (cfg *Config) ClearSealedValues() {
+	cfg.sealedValues = nil
+}
+
+// ApplyCredentialEncryption installs the credential key resolved when cfg was loaded
+// as the process-wide sealer. Loading only resolves the key, so a config that is
+// validated but never applied leaves the running sealer untouched.
+func (cfg *Config) ApplyCredentialEncryption() {
+	secrets.SetAtRest(cfg.atRestSealer, cfg.CredentialEncryption.Enable)
+}
+
+// openSealedValues resolves the credential key and replaces sealed API keys with
+// their plaintext, remembering the sealed form for later saves.
+func (cfg *Config) openSealedValues() error {
+	sealer, err := secrets.ResolveSealer(cfg.CredentialEncryption.KeySource(), cfg.CredentialEncryption.Enable)
+	if err != nil {
+		return fmt.Errorf("failed to configure credential encryption: %w", err)
+	}
+	cfg.atRestSealer = sealer
+	var errOpen error
+	cfg.forEachAPIKey(func(value *string) {
+		if errOpen != nil || !secrets.IsSealedValue(*value) {
+			return
+		}
+		plaintext, err := sealer.OpenValue(*value)
+		if err != nil {
+			errOpen = fmt.Errorf("failed to decrypt api key: %w", err)
+			return
+		}
+		if cfg.sealedValues == nil {
+			cfg.sealedValues = make(map[string]string)
+		}
+		cfg.sealedValues[plaintext] = *value
+		*value = plaintext
+	})
+	return errOpen
+}
+
+// sealedForPersist returns the config to write to disk. API keys that were sealed
+// on load keep their original ciphertext; when sealing is enabled the remaining
+// plaintext keys are sealed too. cfg itself keeps plaintext values.
+func (cfg *Config) sealedForPersist() (*Config, error) {
+	if cfg == nil || (len(cfg.sealedValues) == 0 && !secrets.SealsWrites()) {
+		return cfg, nil
+	}
+	out := *cfg
+	out.GeminiKey = append([]GeminiKey(nil), cfg.GeminiKey...)
+	out.CodexKey = append([]CodexKey(nil), cfg.CodexKey...)
+	out.ClaudeKey = append([]ClaudeKey(nil), cfg.ClaudeKey...)
+	out.VertexCompatAPIKey = append([]VertexCompatKey(nil), cfg.VertexCompatAPIKey...)
+	out.OpenAICompatibility = append([]OpenAICompatibility(nil), cfg.OpenAICompatibility...)
+	for i := range out.OpenAICompatibility {
+		out.OpenAICompatibility[i].APIKeyEntries = append([]OpenAICompatibilityAPIKey(nil), out.OpenAICompatibility[i].APIKeyEntries...)
+	}
+
+	var errSeal error
+	out.forEachAPIKey(func(value *string) {
+		if errSeal != nil || *value == "" || secrets.IsSealedValue(*value) {
+			return
+		}
+		if sealed, ok := cfg.sealedValues[*value]; ok {
+			*value = sealed
+			return
+		}
+		if !secrets.SealsWrites() {
+			return
+		}
+		sealed, err := secrets.SealValue(*value)
+		if err != nil {
+			errSeal = fmt.Errorf("failed to encrypt api key: %w", err)
+			return
+		}
+		if cfg.sealedValues == nil {
+			cfg.sealedValues = make(map[string]string)
+		}
+		cfg.sealedValues[*value] = sealed
+		*value = sealed
+	})
+	if errSeal != nil {
+		return nil, errSeal
+	}
+	return &out, nil
+}
+
+// forEachAPIKey calls fn with a pointer to every provider API key in cfg.
+func (cfg *Config) forEachAPIKey(fn func(value *string)) {
+	for i := range cfg.GeminiKey {
+		fn(&cfg.GeminiKey[i].APIKey)
+	}
+	for i := range cfg.CodexKey {
+		fn(&cfg.CodexKey[i].APIKey)
+	}
+	for i := range cfg.ClaudeKey {
+		fn(&cfg.ClaudeKey[i].APIKey)
+	}
+	for i := range cfg.VertexCompatAPIKey {
+		fn(&cfg.VertexCompatAPIKey[i].APIKey)
+	}
+	for i := range cfg.OpenAICompatibility {
+		for j := range cfg.OpenAICompatibility[i].APIKeyEntries {
+			fn(&cfg.OpenAICompatibility[i].APIKeyEntries[j].APIKey)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/secrets"
+)
+
+func TestSealedAPIKeysRoundTrip(t *testing.T) {
+	t.Cleanup(func() { secrets.SetAtRest(nil, false) })
+	if err := secrets.ConfigureAtRest(secrets.KeySource{Passphrase: "k"}, false); err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := secrets.SealValue("sk-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "credential-encryption:\n  passphrase: k\nclaude-api-key:\n  - api-key: \"" + sealed + "\"\n"
+	if err = os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if len(cfg.ClaudeKey) != 1 || cfg.ClaudeKey[0].APIKey != "sk-secret" {
+		t.Fatalf("expected decrypted api key, got %+v", cfg.ClaudeKey)
+	}
+
+	cfg.Debug = true
+	if err = SaveConfigPreserveComments(path, cfg); err != nil {
+		t.Fatalf("SaveConfigPreserveComments: %v", err)
+	}
+	saved, _ := os.ReadFile(path)
+	if strings.Contains(string(saved), "sk-secret") || !strings.Contains(string(saved), sealed) {
+		t.Errorf("expected sealed api key to be preserved on save, got:\n%s", saved)
+	}
+	if cfg.ClaudeKey[0].APIKey != "sk-secret" {
+		t.Errorf("in-memory key should stay decrypted, got %q", cfg.ClaudeKey[0].APIKey)
+	}
+}
+
+func TestLoadConfigDoesNotInstallCredentialKey(t *testing.T) {
+	secrets.SetAtRest(nil, false)
+	t.Cleanup(func() { secrets.SetAtRest(nil, false) })
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte("credential-encryption:\n  enable: true\n  passphrase: k\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if secrets.AtRestSealer() != nil {
+		t.Fatal("loading a config installed its credential key")
+	}
+	cfg.ApplyCredentialEncryption()
+	if secrets.AtRestSealer() == nil || !secrets.SealsWrites() {
+		t.Fatal("ApplyCredentialEncryption did not install the credential key")
+	}
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Credential source types supported under 'credential-sources'.
+const (
+	CredentialSourceVault             = "vault"
+	CredentialSourceAWSSecretsManager = "aws-secrets-manager"
+)
+
+// CredentialSource configures an external secret store that supplies OAuth
+// credential documents (the same JSON shape as auth files). Credentials fetched
+// from a source are kept in memory only and are re-fetched periodically so that
+// rotated secrets are picked up without a restart.
+type CredentialSource struct {
+	// Type selects the backend: "vault" or "aws-secrets-manager".
+	Type string `yaml:"type"`
+	// Name identifies the source in auth IDs and logs; defaults to the type and index.
+	Name string `yaml:"name,omitempty"`
+	// RefreshInterval controls how often secrets are re-fetched (Go duration, default 5m).
+	RefreshInterval string `yaml:"refresh-interval,omitempty"`
+
+	// Address is the Vault server URL, e.g. https://vault.example.com:8200.
+	Address string `yaml:"address,omitempty"`
+	// Namespace is the optional Vault Enterprise namespace.
+	Namespace string `yaml:"namespace,omitempty"`
+	// Mount is the KV secrets engine mount (default "secret").
+	Mount string `yaml:"mount,omitempty"`
+	// Path is the folder under the mount whose secrets are loaded.
+	Path string `yaml:"path,omitempty"`
+	// KVVersion selects the KV engine version, 1 or 2 (default 2).
+	KVVersion int `yaml:"kv-version,omitempty"`
+	// Token is an inline Vault token; prefer TokenEnv or TokenFile.
+	Token string `yaml:"token,omitempty"`
+	// TokenEnv names the environment variable holding the Vault token (default VAULT_TOKEN).
+	TokenEnv string `yaml:"token-env,omitempty"`
+	// TokenFile is re-read on every fetch, which suits Vault Agent token sinks.
+	TokenFile string `yaml:"token-file,omitempty"`
+
+	// Region is the AWS region (defaults to AWS_REGION / AWS_DEFAULT_REGION).
+	Region string `yaml:"region,omitempty"`
+	// Endpoint overrides the Secrets Manager endpoint, e.g. for VPC endpoints.
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// Prefix limits loading to secrets whose name starts with this value.
+	Prefix string `yaml:"prefix,omitempty"`
+	// AccessKeyID, SecretAccessKey and SessionToken default to the standard AWS_* variables.
+	AccessKeyID     string `yaml:"access-key-id,omitempty"`
+	SecretAccessKey string `yaml:"secret-access-key,omitempty"`
+	SessionToken    string `yaml:"session-token,omitempty"`
+}
+
+// SanitizeCredentialSources normalizes source types, assigns default names and
+// drops entries with an unknown type.
+func (cfg *Config) SanitizeCredentialSources() {
+	if cfg == nil || len(cfg.CredentialSources) == 0 {
+		return
+	}
+	out := make([]CredentialSource, 0, len(cfg.CredentialSources))
+	seen := make(map[string]struct{}, len(cfg.CredentialSources))
+	for i := range cfg.CredentialSources {
+		src := cfg.CredentialSources[i]
+		src.Type = strings.ToLower(strings.TrimSpace(src.Type))
+		switch src.Type {
+		case CredentialSourceVault, CredentialSourceAWSSecretsManager:
+		case "aws", "secretsmanager", "aws-secretsmanager":
+			src.Type = CredentialSourceAWSSecretsManager
+		default:
+			continue
+		}
+		src.Name = strings.TrimSpace(src.Name)
+		if src.Name == "" {
+			src.Name = fmt.Sprintf("%s-%d", src.Type, i)
+		}
+		if _, ok := seen[src.Name]; ok {
+			continue
+		}
+		seen[src.Name] = struct{}{}
+		out = append(out, src)
+	}
+	cfg.CredentialSources = out
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"net/http"
+	"strings"
+	"text/template"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// HeaderTemplate decorates every upstream request sent for one backend type.
+// Values are Go text/template strings rendered per request; see the executor package for
+// the available fields. A value that renders empty removes the header.
+type HeaderTemplate struct {
+	// Forward copies these downstream client request headers (e.g. X-Request-Id,
+	// Traceparent) onto the upstream request before Set is applied. A trailing '*'
+	// matches a prefix. Client credentials and hop-by-hop headers are never forwarded.
+	Forward []string `yaml:"forward,omitempty" json:"forward,omitempty"`
+	// Set assigns headers, replacing any value the executor produced.
+	Set map[string]string `yaml:"set,omitempty" json:"set,omitempty"`
+	// Remove deletes headers after Set has been applied.
+	Remove []string `yaml:"remove,omitempty" json:"remove,omitempty"`
+}
+
+// SanitizeHeaderTemplates lower-cases backend keys, canonicalizes header names and drops
+// headers whose template does not parse.
+func (cfg *Config) SanitizeHeaderTemplates() {
+	if cfg == nil || len(cfg.HeaderTemplates) == 0 {
+		return
+	}
+	out := make(map[string]HeaderTemplate, len(cfg.HeaderTemplates))
+	for provider, tmpl := range cfg.HeaderTemplates {
+		key := strings.ToLower(strings.TrimSpace(provider))
+		if key == "" {
+			continue
+		}
+		clean := HeaderTemplate{}
+		for name, value := range tmpl.Set {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				continue
+			}
+			if _, err := template.New(name).Option("missingkey=zero").Parse(value); err != nil {
+				log.Warnf("header-templates.%s: header %s ignored: %v", key, name, err)
+				continue
+			}
+			if clean.Set == nil {
+				clean.Set = make(map[string]string)
+			}
+			clean.Set[http.CanonicalHeaderKey(name)] = value
+		}
+		for _, name := range tmpl.Forward {
+			if name = strings.TrimSpace(name); name != "" {
+				clean.Forward = append(clean.Forward, strings.ToLower(name))
+			}
+		}
+		for _, name := range tmpl.Remove {
+			if name = strings.TrimSpace(name); name != "" {
+				clean.Remove = append(clean.Remove, http.CanonicalHeaderKey(name))
+			}
+		}
+		if len(clean.Forward) == 0 && len(clean.Set) == 0 && len(clean.Remove) == 0 {
+			continue
+		}
+		out[key] = clean
+	}
+	cfg.HeaderTemplates = out
+}
@@ -0,0 +1,337 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/secrets"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// includeKey is the top-level config key listing additional config files.
+const includeKey = "include"
+
+// includedContent remembers which parts of the loaded configuration came from included
+// files, so saving config.yaml does not copy them into the main file.
+type includedContent struct {
+	// watch lists the included files and directories, for reload watching.
+	watch []string
+	// values holds top-level keys that only came from includes.
+	values map[string]*yaml.Node
+	// items holds the included items of top-level sequences also set in the main file.
+	items map[string][]*yaml.Node
+}
+
+// IncludeWatchPaths returns the files and directories pulled in through include, so
+// changes to them can trigger a reload.
+func (cfg *Config) IncludeWatchPaths() []string {
+	if cfg == nil || cfg.included == nil {
+		return nil
+	}
+	return append([]string(nil), cfg.included.watch...)
+}
+
+// ResolveIncludes expands the include entries of the config file at configFile into the
+// files to merge, in merge order. Relative entries are resolved against the directory of
+// configFile. A directory contributes its *.yaml and *.yml files sorted by name, and a
+// glob contributes its matches sorted by name. The second result lists the files and
+// directories to watch for changes.
+func ResolveIncludes(configFile string, include []string) (files, watch []string, err error) {
+	baseDir := filepath.Dir(configFile)
+	seen := make(map[string]struct{})
+	addFile := func(path string) {
+		if _, ok := seen[path]; ok {
+			return
+		}
+		seen[path] = struct{}{}
+		files = append(files, path)
+	}
+	for _, entry := range include {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		path := entry
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		if strings.ContainsAny(entry, "*?[") {
+			matches, errGlob := filepath.Glob(path)
+			if errGlob != nil {
+				return nil, nil, fmt.Errorf("invalid include pattern %q: %w", entry, errGlob)
+			}
+			sort.Strings(matches)
+			for _, match := range matches {
+				if info, errStat := os.Stat(match); errStat == nil && !info.IsDir() {
+					addFile(match)
+				}
+			}
+			watch = append(watch, filepath.Dir(path))
+			continue
+		}
+		info, errStat := os.Stat(path)
+		if errStat != nil {
+			return nil, nil, fmt.Errorf("failed to read include %q: %w", entry, errStat)
+		}
+		watch = append(watch, path)
+		if !info.IsDir() {
+			addFile(path)
+			continue
+		}
+		dirEntries, errRead := os.ReadDir(path)
+		if errRead != nil {
+			return nil, nil, fmt.Errorf("failed to read include directory %q: %w", entry, errRead)
+		}
+		names := make([]string, 0, len(dirEntries))
+		for _, dirEntry := range dirEntries {
+			name := dirEntry.Name()
+			ext := strings.ToLower(filepath.Ext(name))
+			if dirEntry.IsDir() || strings.HasPrefix(name, ".") || (ext != ".yaml" && ext != ".yml") {
+				continue
+			}
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			addFile(filepath.Join(path, name))
+		}
+	}
+	return files, watch, nil
+}
+
+// mergeIncludes merges the files listed under include into the main config document.
+// Files are merged in order: sequences are appended, mappings are merged key by key, and
+// for scalars the value seen first wins (the main file before any include). Includes
+// cannot include further files. It returns the merged document and what was included.
+func mergeIncludes(configFile string, data []byte) ([]byte, *includedContent, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return data, nil, nil
+	}
+	root := doc.Content[0]
+	idx := findMapKeyIndex(root, includeKey)
+	if idx < 0 {
+		return data, nil, nil
+	}
+	var include []string
+	if err := root.Content[idx+1].Decode(&include); err != nil {
+		return nil, nil, fmt.Errorf("invalid include list: %w", err)
+	}
+	files, watch, err := ResolveIncludes(configFile, include)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	included := &includedContent{watch: watch, values: make(map[string]*yaml.Node), items: make(map[string][]*yaml.Node)}
+	mainKeys := make(map[string]struct{}, len(root.Content)/2)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		mainKeys[root.Content[i].Value] = struct{}{}
+	}
+	for _, file := range files {
+		raw, errRead := os.ReadFile(file)
+		if errRead != nil {
+			return nil, nil, fmt.Errorf("failed to read included config %s: %w", file, errRead)
+		}
+		var part yaml.Node
+		if errParse := yaml.Unmarshal(raw, &part); errParse != nil {
+			return nil, nil, fmt.Errorf("failed to parse included config %s: %w", file, errParse)
+		}
+		if part.Kind != yaml.DocumentNode || len(part.Content) == 0 {
+			continue
+		}
+		partRoot := part.Content[0]
+		if partRoot.Kind != yaml.MappingNode {
+			return nil, nil, fmt.Errorf("included config %s: expected a mapping", file)
+		}
+		for i := 0; i+1 < len(partRoot.Content); i += 2 {
+			key, value := partRoot.Content[i].Value, partRoot.Content[i+1]
+			if key == includeKey {
+				log.Warnf("included config %s: nested include is ignored", file)
+				continue
+			}
+			dstIdx := findMapKeyIndex(root, key)
+			if dstIdx < 0 {
+				root.Content = append(root.Content, partRoot.Content[i], value)
+				continue
+			}
+			dst := root.Content[dstIdx+1]
+			if dst.Kind == yaml.ScalarNode && dst.Tag == "!!null" {
+				root.Content[dstIdx+1] = value
+				if value.Kind == yaml.SequenceNode {
+					included.items[key] = append(included.items[key], value.Content...)
+				} else {
+					included.values[key] = value
+				}
+				continue
+			}
+			if dst.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode {
+				dst.Content = append(dst.Content, value.Content...)
+				included.items[key] = append(included.items[key], value.Content...)
+				continue
+			}
+			mergeIncludedNode(dst, value, key, file)
+		}
+	}
+	// Keys introduced by includes are recorded whole rather than item by item.
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key := root.Content[i].Value
+		if _, ok := mainKeys[key]; ok {
+			continue
+		}
+		included.values[key] = root.Content[i+1]
+	}
+
+	merged, err := yaml.Marshal(&doc)
+	if err != nil {
+		return nil, nil, err
+	}
+	return merged, included, nil
+}
+
+// mergeIncludedNode merges an included value into an existing one. Mappings are merged
+// recursively; on any other conflict the existing value is kept.
+func mergeIncludedNode(dst, src *yaml.Node, path, file string) {
+	if dst.Kind == yaml.MappingNode && src.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			key := src.Content[i].Value
+			idx := findMapKeyIndex(dst, key)
+			if idx < 0 {
+				dst.Content = append(dst.Content, src.Content[i], src.Content[i+1])
+				continue
+			}
+			mergeIncludedNode(dst.Content[idx+1], src.Content[i+1], path+"."+key, file)
+		}
+		return
+	}
+	if dst.Kind == yaml.SequenceNode && src.Kind == yaml.SequenceNode {
+		dst.Content = append(dst.Content, src.Content...)
+		return
+	}
+	log.Warnf("included config %s: %s is already set, keeping the earlier value", file, path)
+}
+
+// canonicalIncludedValue renders the value of a top-level key the way SaveConfig renders
+// it, so included content can be recognised in the generated document. With items set,
+// value is a sequence holding one item and the item itself is rendered.
+func canonicalIncludedValue(key string, value *yaml.Node, items bool) string {
+	if items {
+		value = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{value}}
+	}
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value,
+	}}
+	var cfg Config
+	if err := root.Decode(&cfg); err != nil {
+		return ""
+	}
+	rendered, err := yaml.Marshal(&cfg)
+	if err != nil {
+		return ""
+	}
+	var generated yaml.Node
+	if err = yaml.Unmarshal(rendered, &generated); err != nil || len(generated.Content) == 0 {
+		return ""
+	}
+	idx := findMapKeyIndex(generated.Content[0], key)
+	if idx < 0 {
+		return ""
+	}
+	node := generated.Content[0].Content[idx+1]
+	if items {
+		if node.Kind != yaml.SequenceNode || len(node.Content) != 1 {
+			return ""
+		}
+		node = node.Content[0]
+	}
+	return canonicalNode(node)
+}
+
+// canonicalNode returns a stable JSON form of node with sealed secrets opened, so a
+// value reads the same whether or not it was sealed when written. Secrets can only be
+// opened once the credential key is installed, hence canonical forms are computed when
+// saving rather than while loading.
+func canonicalNode(node *yaml.Node) string {
+	var value any
+	if err := node.Decode(&value); err != nil {
+		return ""
+	}
+	value = openSealedStrings(value)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value); err != nil {
+		return ""
+	}
+	return buf.String()
+}
+
+func openSealedStrings(value any) any {
+	switch v := value.(type) {
+	case string:
+		if secrets.IsSealedValue(v) {
+			if plaintext, err := secrets.OpenValue(v); err == nil {
+				return plaintext
+			}
+		}
+		return v
+	case map[string]any:
+		for key, item := range v {
+			v[key] = openSealedStrings(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = openSealedStrings(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+// pruneIncluded removes included content from a generated config document before it is
+// merged into config.yaml: top-level keys that only came from includes, while unchanged,
+// and included sequence items.
+func (c *includedContent) pruneIncluded(root *yaml.Node) {
+	if c == nil || root == nil || root.Kind != yaml.MappingNode {
+		return
+	}
+	for key, value := range c.values {
+		idx := findMapKeyIndex(root, key)
+		if idx >= 0 && canonicalNode(root.Content[idx+1]) == canonicalIncludedValue(key, value, false) {
+			removeMapKey(root, key)
+		}
+	}
+	for key, items := range c.items {
+		idx := findMapKeyIndex(root, key)
+		if idx < 0 || root.Content[idx+1].Kind != yaml.SequenceNode {
+			continue
+		}
+		remaining := make(map[string]int, len(items))
+		for _, item := range items {
+			if canonical := canonicalIncludedValue(key, item, true); canonical != "" {
+				remaining[canonical]++
+			}
+		}
+		seq := root.Content[idx+1]
+		kept := seq.Content[:0]
+		for _, item := range seq.Content {
+			canonical := canonicalNode(item)
+			if remaining[canonical] > 0 {
+				remaining[canonical]--
+				continue
+			}
+			kept = append(kept, item)
+		}
+		seq.Content = kept
+	}
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadConfigIncludes(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("config.yaml", "port: 8317\ninclude:\n  - accounts.d/\n  - tenants.d/*.yaml\nclaude-api-key:\n  - api-key: main-key\n")
+	write("accounts.d/20-b.yaml", "claude-api-key:\n  - api-key: b-key\n")
+	write("accounts.d/10-a.yml", "port: 9000\nclaude-api-key:\n  - api-key: a-key\n")
+	write("accounts.d/notes.txt", "ignored")
+	write("tenants.d/acme.yaml", "api-keys:\n  - acme-client\n")
+
+	path := filepath.Join(dir, "config.yaml")
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Port != 8317 {
+		t.Fatalf("port = %d, want the main file value", cfg.Port)
+	}
+	var keys []string
+	for _, entry := range cfg.ClaudeKey {
+		keys = append(keys, entry.APIKey)
+	}
+	if got := strings.Join(keys, ","); got != "main-key,a-key,b-key" {
+		t.Fatalf("claude keys = %s", got)
+	}
+	if len(cfg.APIKeys) != 1 || cfg.APIKeys[0] != "acme-client" {
+		t.Fatalf("api keys = %v", cfg.APIKeys)
+	}
+	if len(cfg.IncludeWatchPaths()) != 2 {
+		t.Fatalf("watch paths = %v", cfg.IncludeWatchPaths())
+	}
+
+	// Saving keeps included entries out of the main file.
+	cfg.Debug = true
+	cfg.ClaudeKey = append(cfg.ClaudeKey, ClaudeKey{APIKey: "new-key"})
+	if err = SaveConfigPreserveComments(path, cfg); err != nil {
+		t.Fatalf("SaveConfigPreserveComments: %v", err)
+	}
+	saved, _ := os.ReadFile(path)
+	for _, included := range []string{"a-key", "b-key", "acme-client"} {
+		if strings.Contains(string(saved), included) {
+			t.Fatalf("saved config contains included value %s:\n%s", included, saved)
+		}
+	}
+	if !strings.Contains(string(saved), "main-key") || !strings.Contains(string(saved), "new-key") {
+		t.Fatalf("saved config lost main file entries:\n%s", saved)
+	}
+	reloaded, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if !reloaded.Debug || len(reloaded.ClaudeKey) != 4 {
+		t.Fatalf("reloaded config = debug %t, %d claude keys", reloaded.Debug, len(reloaded.ClaudeKey))
+	}
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ModelParameterRule pins or limits sampling parameters for matching models or aliases.
+// Values are resolved with the precedence default < client < override < clamp: defaults
+// fill parameters the client left out, the override replaces whatever the client sent,
+// and the clamp bounds the final value.
+type ModelParameterRule struct {
+	// Models lists model entries with name pattern and protocol constraint. Names are
+	// matched against both the client-requested alias and the resolved upstream model.
+	Models []PayloadModelRule `yaml:"models" json:"models"`
+	// Default sets parameters only when the client sent none, e.g. enabling thinking for
+	// a "-thinking" alias while still honouring an explicit client choice.
+	Default ModelParameters `yaml:"default,omitempty" json:"default,omitempty"`
+	// Override sets parameters regardless of what the client sent.
+	Override ModelParameters `yaml:"override,omitempty" json:"override,omitempty"`
+	// Clamp bounds parameters after the override has been applied.
+	Clamp ModelParameterClamp `yaml:"clamp,omitempty" json:"clamp,omitempty"`
+}
+
+// ModelParameters holds provider-neutral request parameters. Nil fields are left untouched.
+type ModelParameters struct {
+	Temperature *float64 `yaml:"temperature,omitempty" json:"temperature,omitempty"`
+	TopP        *float64 `yaml:"top-p,omitempty" json:"top-p,omitempty"`
+	MaxTokens   *int     `yaml:"max-tokens,omitempty" json:"max-tokens,omitempty"`
+	// ThinkingBudget is a token budget; 0 disables thinking and -1 requests dynamic thinking.
+	// Level-based providers receive the nearest level (see thinking.ConvertBudgetToLevel).
+	ThinkingBudget *int `yaml:"thinking-budget,omitempty" json:"thinking-budget,omitempty"`
+	// Stop replaces the stop sequences. An empty list removes them.
+	Stop []string `yaml:"stop,omitempty" json:"stop,omitempty"`
+}
+
+// ModelParameterClamp bounds numeric parameters. Nil bounds are not enforced.
+type ModelParameterClamp struct {
+	Temperature    ParameterRange `yaml:"temperature,omitempty" json:"temperature,omitempty"`
+	TopP           ParameterRange `yaml:"top-p,omitempty" json:"top-p,omitempty"`
+	MaxTokens      ParameterRange `yaml:"max-tokens,omitempty" json:"max-tokens,omitempty"`
+	ThinkingBudget ParameterRange `yaml:"thinking-budget,omitempty" json:"thinking-budget,omitempty"`
+}
+
+// ParameterRange is an inclusive numeric range.
+type ParameterRange struct {
+	Min *float64 `yaml:"min,omitempty" json:"min,omitempty"`
+	Max *float64 `yaml:"max,omitempty" json:"max,omitempty"`
+}
+
+// IsZero reports whether the range has no bounds.
+func (r ParameterRange) IsZero() bool {
+	return r.Min == nil && r.Max == nil
+}
+
+// Apply bounds v to the range.
+func (r ParameterRange) Apply(v float64) float64 {
+	if r.Min != nil && v < *r.Min {
+		v = *r.Min
+	}
+	if r.Max != nil && v > *r.Max {
+		v = *r.Max
+	}
+	return v
+}
+
+// SanitizeModelParameters drops rules without model patterns and ranges whose
+// minimum exceeds their maximum.
+func (cfg *Config) SanitizeModelParameters() {
+	if cfg == nil || len(cfg.ModelParameters) == 0 {
+		return
+	}
+	out := make([]ModelParameterRule, 0, len(cfg.ModelParameters))
+	for i, rule := range cfg.ModelParameters {
+		hasModel := false
+		for _, m := range rule.Models {
+			if strings.TrimSpace(m.Name) != "" {
+				hasModel = true
+				break
+			}
+		}
+		if !hasModel {
+			log.Warnf("model-parameters[%d]: no model names, rule ignored", i)
+			continue
+		}
+		rule.Clamp.Temperature = sanitizeParameterRange(rule.Clamp.Temperature, i, "temperature")
+		rule.Clamp.TopP = sanitizeParameterRange(rule.Clamp.TopP, i, "top-p")
+		rule.Clamp.MaxTokens = sanitizeParameterRange(rule.Clamp.MaxTokens, i, "max-tokens")
+		rule.Clamp.ThinkingBudget = sanitizeParameterRange(rule.Clamp.ThinkingBudget, i, "thinking-budget")
+		out = append(out, rule)
+	}
+	cfg.ModelParameters = out
+}
+
+func sanitizeParameterRange(r ParameterRange, index int, field string) ParameterRange {
+	if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+		log.Warnf("model-parameters[%d]: clamp.%s min exceeds max, clamp ignored", index, field)
+		return ParameterRange{}
+	}
+	return r
+}
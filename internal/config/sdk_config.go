@@ -4,6 +4,11 @@
 // debug settings, proxy configuration, and API keys.
 package config
 
+import (
+	"strings"
+	"time"
+)
+
 // SDKConfig represents the application's configuration, loaded from a YAML file.
 type SDKConfig struct {
 	// ProxyURL is the URL of an optional proxy server to use for outbound requests.
@@ -24,12 +29,251 @@ type SDKConfig struct {
 	// Default is false (disabled).
 	PassthroughHeaders bool `yaml:"passthrough-headers" json:"passthrough-headers"`
 
+	// PassthroughHeaderAllowlist forwards only these upstream response headers when
+	// PassthroughHeaders is false. Names are case-insensitive; a trailing '*' matches a
+	// prefix (e.g. "anthropic-*").
+	PassthroughHeaderAllowlist []string `yaml:"passthrough-header-allowlist,omitempty" json:"passthrough-header-allowlist,omitempty"`
+
+	// ParameterHeaders lets clients set sampling parameters with the X-Temperature, X-Top-P,
+	// X-Max-Tokens and X-Thinking request headers. The values replace what the request body
+	// sent and remain subject to model-parameters and API key overrides and clamps.
+	ParameterHeaders bool `yaml:"parameter-headers,omitempty" json:"parameter-headers,omitempty"`
+
 	// Streaming configures server-side streaming behavior (keep-alives and safe bootstrap retries).
 	Streaming StreamingConfig `yaml:"streaming" json:"streaming"`
 
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// ResponseStoreTTLSeconds controls how long completed responses are kept in memory for
+	// previous_response_id chaining and GET /v1/responses/{id} or /v1/chat/completions/{id}.
+	// 0 uses the default of one hour; < 0 disables the store.
+	ResponseStoreTTLSeconds int `yaml:"response-store-ttl-seconds,omitempty" json:"response-store-ttl-seconds,omitempty"`
+
+	// AsyncThresholdSeconds lets non-streaming OpenAI requests sent with "Prefer: respond-async"
+	// answer 202 with an operation ID once they run longer than this; the client then polls
+	// GET /v1/operations/{id}. Results are kept for response-store-ttl-seconds. <= 0 disables it.
+	AsyncThresholdSeconds int `yaml:"async-threshold-seconds,omitempty" json:"async-threshold-seconds,omitempty"`
+
+	// MaxRequestBodyMB rejects API request bodies larger than this many megabytes with 413
+	// before they are buffered. 0 uses the default of 64; < 0 means no limit.
+	MaxRequestBodyMB int `yaml:"max-request-body-mb,omitempty" json:"max-request-body-mb,omitempty"`
+
+	// RequestLimits bounds how much content a single request may carry into translation.
+	RequestLimits RequestLimitsConfig `yaml:"request-limits,omitempty" json:"request-limits,omitempty"`
+
+	// SessionLimits bounds the traffic of a single conversation so a runaway agent loop
+	// cannot drain an account's usage window.
+	SessionLimits SessionLimitsConfig `yaml:"session-limits,omitempty" json:"session-limits,omitempty"`
+
+	// LoopDetection stops agents that keep repeating the same tool call.
+	LoopDetection LoopDetectionConfig `yaml:"loop-detection,omitempty" json:"loop-detection,omitempty"`
+
+	// ModelFallbacks answers requests with another model when every credential of the
+	// requested model is out of quota.
+	ModelFallbacks ModelFallbackConfig `yaml:"model-fallbacks,omitempty" json:"model-fallbacks,omitempty"`
+
+	// ModelRouting rewrites requested model names that no provider serves.
+	ModelRouting ModelRoutingConfig `yaml:"model-routing,omitempty" json:"model-routing,omitempty"`
+
+	// RequestSampling records a fraction of translated traffic for offline translator regression tests.
+	RequestSampling RequestSamplingConfig `yaml:"request-sampling,omitempty" json:"request-sampling,omitempty"`
+
+	// APIKeyPolicies restricts what individual client API keys may do.
+	APIKeyPolicies []APIKeyPolicy `yaml:"api-key-policies,omitempty" json:"api-key-policies,omitempty"`
+}
+
+// APIKeyPolicy holds the restrictions for one client API key.
+type APIKeyPolicy struct {
+	APIKey string `yaml:"api-key" json:"api-key"`
+	// AllowedModels lists the model names or aliases the key may request. Names are
+	// case-insensitive and '*' matches any characters (e.g. "claude-haiku-*"). Empty
+	// allows every model.
+	AllowedModels []string `yaml:"allowed-models,omitempty" json:"allowed-models,omitempty"`
+	// Parameters gives the key's requests their own defaults and limits, so tools sharing
+	// the proxy get suitable settings without client changes.
+	Parameters ParameterProfile `yaml:"parameters,omitempty" json:"parameters,omitempty"`
+}
+
+// ParameterProfile holds per-key request parameters. It uses the precedence of
+// model-parameters, wrapped around the matching model rules: the key's defaults are
+// tried before the model defaults, and its override and clamp run after the model
+// override and clamp, so a key-level cap always holds.
+type ParameterProfile struct {
+	// Default sets parameters only when the client and the request left them out.
+	Default ModelParameters `yaml:"default,omitempty" json:"default,omitempty"`
+	// Override sets parameters regardless of what the client sent, e.g. a fixed
+	// thinking budget.
+	Override ModelParameters `yaml:"override,omitempty" json:"override,omitempty"`
+	// Clamp bounds parameters last, e.g. a max-tokens cap.
+	Clamp ModelParameterClamp `yaml:"clamp,omitempty" json:"clamp,omitempty"`
+	// SystemPrompt is added to the request's system instructions, after any the client sent.
+	SystemPrompt string `yaml:"system-prompt,omitempty" json:"system-prompt,omitempty"`
+}
+
+// APIKeyPolicy returns the policy configured for a client API key.
+func (s *SDKConfig) APIKeyPolicy(apiKey string) (APIKeyPolicy, bool) {
+	if s == nil || apiKey == "" {
+		return APIKeyPolicy{}, false
+	}
+	for _, policy := range s.APIKeyPolicies {
+		if policy.APIKey == apiKey {
+			return policy, true
+		}
+	}
+	return APIKeyPolicy{}, false
+}
+
+// RequestLimitsConfig bounds the size of request histories before they are translated, so
+// adversarial payloads are rejected instead of being expanded by the translators.
+// Zero uses the default; a negative value removes the limit.
+type RequestLimitsConfig struct {
+	// MaxContentBlocks caps the content blocks (content parts, nested tool result parts and
+	// Gemini parts) across all messages. Defaults to 20000.
+	MaxContentBlocks int `yaml:"max-content-blocks,omitempty" json:"max-content-blocks,omitempty"`
+	// MaxImages caps the images across all messages. Defaults to 500.
+	MaxImages int `yaml:"max-images,omitempty" json:"max-images,omitempty"`
+}
+
+// SessionLimitsConfig caps conversations, identified by the client's session header or
+// conversation metadata and otherwise by a hash of the conversation's opening turn.
+type SessionLimitsConfig struct {
+	// MaxTurnsPerHour caps the requests of one conversation within a sliding hour. Zero disables it.
+	MaxTurnsPerHour int `yaml:"max-turns-per-hour,omitempty" json:"max-turns-per-hour,omitempty"`
+	// MaxTokensPerSession caps the total tokens, summed over every turn, of one conversation.
+	// Zero disables it.
+	MaxTokensPerSession int64 `yaml:"max-tokens-per-session,omitempty" json:"max-tokens-per-session,omitempty"`
+}
+
+// LoopDetectionConfig detects agents stuck calling the same tool with identical arguments.
+// A loop is found in the request history: the trailing run of identical tool calls
+// answered by the tool results the request carries.
+type LoopDetectionConfig struct {
+	// Threshold is how many identical tool calls in a row count as a loop. Zero disables detection.
+	Threshold int `yaml:"threshold,omitempty" json:"threshold,omitempty"`
+	// Action is "warn" (default), which appends a warning to the latest tool result so the
+	// model can change course, or "terminate", which rejects the request.
+	Action string `yaml:"action,omitempty" json:"action,omitempty"`
+	// Overrides set a different threshold or action for specific client API keys.
+	Overrides []LoopDetectionOverride `yaml:"overrides,omitempty" json:"overrides,omitempty"`
+}
+
+// LoopDetectionOverride sets loop detection for one client API key. A zero threshold
+// keeps the global one; a negative threshold disables detection for the key.
+type LoopDetectionOverride struct {
+	APIKey    string `yaml:"api-key" json:"api-key"`
+	Threshold int    `yaml:"threshold,omitempty" json:"threshold,omitempty"`
+	Action    string `yaml:"action,omitempty" json:"action,omitempty"`
+}
+
+// Loop detection actions for LoopDetectionConfig.Action.
+const (
+	LoopDetectionWarn      = "warn"
+	LoopDetectionTerminate = "terminate"
+)
+
+// Policy returns the threshold and action for a client API key, applying a matching
+// override. A threshold of zero means detection is disabled; unrecognized actions fall
+// back to "warn".
+func (c LoopDetectionConfig) Policy(apiKey string) (int, string) {
+	threshold, action := c.Threshold, c.Action
+	if apiKey != "" {
+		for _, override := range c.Overrides {
+			if override.APIKey == apiKey {
+				if override.Threshold != 0 {
+					threshold = override.Threshold
+				}
+				if override.Action != "" {
+					action = override.Action
+				}
+				break
+			}
+		}
+	}
+	if threshold < 0 {
+		threshold = 0
+	}
+	if strings.EqualFold(strings.TrimSpace(action), LoopDetectionTerminate) {
+		return threshold, LoopDetectionTerminate
+	}
+	return threshold, LoopDetectionWarn
+}
+
+// ModelFallbackConfig downgrades requests whose model has no credential with quota left.
+// Each downgrade is reported in the X-CLIProxy-Model-Downgrade response header and the
+// usage record, and posted to WebhookURL when set.
+type ModelFallbackConfig struct {
+	// Models maps a requested model to the models tried, in order, once every credential
+	// of the requested model is quota-exhausted.
+	Models map[string][]string `yaml:"models,omitempty" json:"models,omitempty"`
+	// WebhookURL receives a JSON event for every downgraded request. Empty disables it.
+	WebhookURL string `yaml:"webhook-url,omitempty" json:"webhook-url,omitempty"`
+}
+
+// RequestSamplingConfig controls the request sampling recorder. Each sampled request is
+// written as one replayable JSON file holding the incoming request, its translation,
+// the upstream response and the translated response, with credentials redacted.
+type RequestSamplingConfig struct {
+	// Rate is the fraction of requests to record, between 0 and 1. Zero disables sampling.
+	Rate float64 `yaml:"rate,omitempty" json:"rate,omitempty"`
+	// Dir is the output directory. Defaults to "samples" in the working directory.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// MaxSamples stops recording once the directory holds this many samples. Defaults to 1000.
+	MaxSamples int `yaml:"max-samples,omitempty" json:"max-samples,omitempty"`
+	// RedactContent additionally replaces prompt and completion text with placeholders.
+	RedactContent bool `yaml:"redact-content,omitempty" json:"redact-content,omitempty"`
+}
+
+// ModelRoutingConfig routes unknown model names to served models so new client model
+// names keep working before the configuration is updated.
+type ModelRoutingConfig struct {
+	// Rules are evaluated in order; the first rule whose target is served wins.
+	Rules []ModelRouteRule `yaml:"rules,omitempty" json:"rules,omitempty"`
+	// Default is the catch-all target used when no rule applies. Empty disables it.
+	Default string `yaml:"default,omitempty" json:"default,omitempty"`
+	// UnknownModel selects what happens to models no rule routes: "reject" answers with
+	// an OpenAI-style model_not_found error, "default" routes to Default, and "passthrough"
+	// forwards the model name verbatim to PassthroughProvider. Empty means "default" when
+	// Default is set and "reject" otherwise.
+	UnknownModel string `yaml:"unknown-model,omitempty" json:"unknown-model,omitempty"`
+	// PassthroughProvider is the provider key (e.g. "claude" or an openai-compatibility
+	// provider name) that receives unknown models under the "passthrough" policy.
+	PassthroughProvider string `yaml:"passthrough-provider,omitempty" json:"passthrough-provider,omitempty"`
+}
+
+// Unknown-model policies for ModelRoutingConfig.UnknownModel.
+const (
+	UnknownModelReject      = "reject"
+	UnknownModelDefault     = "default"
+	UnknownModelPassthrough = "passthrough"
+)
+
+// UnknownModelPolicy returns the effective unknown-model policy, applying the default
+// and treating unrecognized or incomplete settings as "reject".
+func (r ModelRoutingConfig) UnknownModelPolicy() string {
+	switch strings.ToLower(strings.TrimSpace(r.UnknownModel)) {
+	case UnknownModelPassthrough:
+		if strings.TrimSpace(r.PassthroughProvider) != "" {
+			return UnknownModelPassthrough
+		}
+	case UnknownModelDefault, "":
+		if strings.TrimSpace(r.Default) != "" {
+			return UnknownModelDefault
+		}
+	}
+	return UnknownModelReject
+}
+
+// ModelRouteRule maps requested model names to a target model.
+type ModelRouteRule struct {
+	// Match is a case-insensitive pattern where '*' matches any characters (e.g. "gpt-4*"),
+	// or a regular expression when Regex is true.
+	Match string `yaml:"match" json:"match"`
+	// Regex treats Match as a case-insensitive regular expression.
+	Regex bool `yaml:"regex,omitempty" json:"regex,omitempty"`
+	// Target is the model the request is routed to; a thinking suffix is allowed.
+	Target string `yaml:"target" json:"target"`
 }
 
 // StreamingConfig holds server streaming behavior configuration.
@@ -42,4 +286,68 @@ type StreamingConfig struct {
 	// to allow auth rotation / transient recovery.
 	// <= 0 disables bootstrap retries. Default is 0.
 	BootstrapRetries int `yaml:"bootstrap-retries,omitempty" json:"bootstrap-retries,omitempty"`
+
+	// FlushStrategy controls when streamed output is flushed to the client: "immediate"
+	// (default) flushes every chunk, "line" waits for a complete line, and "coalesce"
+	// flushes at most once per FlushIntervalMs.
+	FlushStrategy string `yaml:"flush-strategy,omitempty" json:"flush-strategy,omitempty"`
+
+	// FlushIntervalMs is the coalescing window for the "coalesce" strategy. Default is 50.
+	FlushIntervalMs int `yaml:"flush-interval-ms,omitempty" json:"flush-interval-ms,omitempty"`
+
+	// FlushOverrides selects a different flush strategy for specific client API keys, for
+	// clients that need every token immediately or tolerate more coalescing.
+	FlushOverrides []StreamFlushOverride `yaml:"flush-overrides,omitempty" json:"flush-overrides,omitempty"`
+
+	// StallTimeoutSeconds ends a stream that receives nothing from upstream for this long,
+	// with a 504 error instead of leaving the client hanging. <= 0 disables stall detection.
+	StallTimeoutSeconds int `yaml:"stall-timeout-seconds,omitempty" json:"stall-timeout-seconds,omitempty"`
+
+	// StallRetries is how many times a stream that stalls before any bytes were sent is
+	// restarted on the next credential. Default is 0.
+	StallRetries int `yaml:"stall-retries,omitempty" json:"stall-retries,omitempty"`
+}
+
+// StreamFlushOverride sets the flush strategy for one client API key.
+type StreamFlushOverride struct {
+	APIKey     string `yaml:"api-key" json:"api-key"`
+	Strategy   string `yaml:"strategy" json:"strategy"`
+	IntervalMs int    `yaml:"interval-ms,omitempty" json:"interval-ms,omitempty"`
+}
+
+// Stream flush strategies for StreamingConfig.FlushStrategy.
+const (
+	StreamFlushImmediate = "immediate"
+	StreamFlushLine      = "line"
+	StreamFlushCoalesce  = "coalesce"
+)
+
+const defaultStreamFlushIntervalMs = 50
+
+// FlushPolicy returns the flush strategy and coalescing interval for a client API key,
+// applying a matching override. Unrecognized strategies fall back to "immediate".
+func (s StreamingConfig) FlushPolicy(apiKey string) (string, time.Duration) {
+	strategy, intervalMs := s.FlushStrategy, s.FlushIntervalMs
+	if apiKey != "" {
+		for _, override := range s.FlushOverrides {
+			if override.APIKey == apiKey {
+				strategy = override.Strategy
+				if override.IntervalMs > 0 {
+					intervalMs = override.IntervalMs
+				}
+				break
+			}
+		}
+	}
+	switch strings.ToLower(strings.TrimSpace(strategy)) {
+	case StreamFlushLine:
+		return StreamFlushLine, 0
+	case StreamFlushCoalesce:
+		if intervalMs <= 0 {
+			intervalMs = defaultStreamFlushIntervalMs
+		}
+		return StreamFlushCoalesce, time.Duration(intervalMs) * time.Millisecond
+	default:
+		return StreamFlushImmediate, 0
+	}
 }
@@ -0,0 +1,233 @@
+package credsource
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+const awsSecretsService = "secretsmanager"
+
+// awsSecretsProvider reads credentials from AWS Secrets Manager using the JSON API
+// signed with Signature Version 4.
+type awsSecretsProvider struct {
+	name            string
+	region          string
+	endpoint        string
+	prefix          string
+	accessKeyID     string
+	secretAccessKey string
+	sessionToken    string
+	client          *http.Client
+	now             func() time.Time
+}
+
+func newAWSSecretsProvider(src config.CredentialSource, client *http.Client) (*awsSecretsProvider, error) {
+	region := strings.TrimSpace(src.Region)
+	if region == "" {
+		region = firstEnv("AWS_REGION", "AWS_DEFAULT_REGION")
+	}
+	if region == "" {
+		return nil, fmt.Errorf("credsource: aws-secrets-manager source %s has no region", src.Name)
+	}
+	endpoint := strings.TrimRight(strings.TrimSpace(src.Endpoint), "/")
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://secretsmanager.%s.amazonaws.com", region)
+	}
+	return &awsSecretsProvider{
+		name:            src.Name,
+		region:          region,
+		endpoint:        endpoint,
+		prefix:          strings.TrimSpace(src.Prefix),
+		accessKeyID:     strings.TrimSpace(src.AccessKeyID),
+		secretAccessKey: strings.TrimSpace(src.SecretAccessKey),
+		sessionToken:    strings.TrimSpace(src.SessionToken),
+		client:          client,
+		now:             time.Now,
+	}, nil
+}
+
+func (p *awsSecretsProvider) Name() string { return p.name }
+
+// Fetch lists secrets matching the prefix and returns their SecretString values.
+// Binary secrets are skipped.
+func (p *awsSecretsProvider) Fetch(ctx context.Context) ([]Secret, error) {
+	var out []Secret
+	nextToken := ""
+	for {
+		listReq := map[string]any{"MaxResults": 100}
+		if p.prefix != "" {
+			listReq["Filters"] = []map[string]any{{"Key": "name", "Values": []string{p.prefix}}}
+		}
+		if nextToken != "" {
+			listReq["NextToken"] = nextToken
+		}
+		var listResp struct {
+			SecretList []struct {
+				ARN  string `json:"ARN"`
+				Name string `json:"Name"`
+			} `json:"SecretList"`
+			NextToken string `json:"NextToken"`
+		}
+		if err := p.call(ctx, "ListSecrets", listReq, &listResp); err != nil {
+			return nil, err
+		}
+		for _, entry := range listResp.SecretList {
+			// The name filter matches prefixes of any word; enforce a true prefix match.
+			if p.prefix != "" && !strings.HasPrefix(entry.Name, p.prefix) {
+				continue
+			}
+			var valueResp struct {
+				SecretString string `json:"SecretString"`
+			}
+			secretID := entry.ARN
+			if secretID == "" {
+				secretID = entry.Name
+			}
+			if err := p.call(ctx, "GetSecretValue", map[string]any{"SecretId": secretID}, &valueResp); err != nil {
+				return nil, err
+			}
+			if strings.TrimSpace(valueResp.SecretString) == "" {
+				continue
+			}
+			id := strings.TrimPrefix(strings.TrimPrefix(entry.Name, p.prefix), "/")
+			if id == "" {
+				id = entry.Name
+			}
+			out = append(out, Secret{ID: id, Data: []byte(valueResp.SecretString)})
+		}
+		if listResp.NextToken == "" {
+			break
+		}
+		nextToken = listResp.NextToken
+	}
+	return out, nil
+}
+
+// call invokes a Secrets Manager action and decodes the JSON response into out.
+func (p *awsSecretsProvider) call(ctx context.Context, action string, payload any, out any) error {
+	accessKeyID, secretAccessKey, sessionToken := p.credentials()
+	if accessKeyID == "" || secretAccessKey == "" {
+		return fmt.Errorf("credsource: aws-secrets-manager source %s has no credentials (set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)", p.name)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager."+action)
+	signAWSRequestV4(req, body, accessKeyID, secretAccessKey, sessionToken, p.region, awsSecretsService, p.now().UTC())
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("credsource: secrets manager %s failed: %w", action, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("credsource: failed to read secrets manager response: %w", err)
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("credsource: secrets manager %s returned HTTP %d: %s", action, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if err = json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("credsource: invalid secrets manager response: %w", err)
+	}
+	return nil
+}
+
+// credentials returns the configured keys, falling back to the standard environment
+// variables on every call so rotated session credentials are picked up.
+func (p *awsSecretsProvider) credentials() (string, string, string) {
+	if p.accessKeyID != "" && p.secretAccessKey != "" {
+		return p.accessKeyID, p.secretAccessKey, p.sessionToken
+	}
+	return firstEnv("AWS_ACCESS_KEY_ID"), firstEnv("AWS_SECRET_ACCESS_KEY"), firstEnv("AWS_SESSION_TOKEN")
+}
+
+// signAWSRequestV4 adds Signature Version 4 headers to req.
+func signAWSRequestV4(req *http.Request, body []byte, accessKeyID, secretAccessKey, sessionToken, region, service string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	signedHeaders := []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+	if sessionToken != "" {
+		signedHeaders = append(signedHeaders, "x-amz-security-token")
+	}
+	signedHeaders = append(signedHeaders, "x-amz-target")
+
+	var canonicalHeaders strings.Builder
+	for _, name := range signedHeaders {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		strings.Join(signedHeaders, ";"),
+		payloadHash,
+	}, "\n")
+
+	scope := dateStamp + "/" + region + "/" + service + "/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretAccessKey), dateStamp)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKeyID, scope, strings.Join(signedHeaders, ";"), signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func firstEnv(keys ...string) string {
+	for _, key := range keys {
+		if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,58 @@
+package credsource
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestAWSSecretsProviderFetch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		switch r.Header.Get("X-Amz-Target") {
+		case "secretsmanager.ListSecrets":
+			if body["NextToken"] == nil {
+				_, _ = w.Write([]byte(`{"SecretList":[{"ARN":"arn:1","Name":"cliproxy/claude-a"},{"ARN":"arn:x","Name":"other/cliproxy/x"}],"NextToken":"p2"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"SecretList":[{"ARN":"arn:2","Name":"cliproxy/gemini-b"}]}`))
+		case "secretsmanager.GetSecretValue":
+			switch body["SecretId"] {
+			case "arn:1":
+				_, _ = w.Write([]byte(`{"SecretString":"{\"type\":\"claude\"}"}`))
+			case "arn:2":
+				_, _ = w.Write([]byte(`{"SecretString":"{\"type\":\"gemini\"}"}`))
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+			}
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer srv.Close()
+
+	provider, err := NewProvider(config.CredentialSource{
+		Type: config.CredentialSourceAWSSecretsManager, Name: "aws", Region: "us-east-1",
+		Endpoint: srv.URL, Prefix: "cliproxy/", AccessKeyID: "AKID", SecretAccessKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	secrets, err := provider.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(secrets) != 2 || secrets[0].ID != "claude-a" || secrets[1].ID != "gemini-b" {
+		t.Fatalf("unexpected secrets: %+v", secrets)
+	}
+}
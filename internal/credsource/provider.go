@@ -0,0 +1,63 @@
+// Package credsource loads OAuth credential documents from external secret stores
+// such as HashiCorp Vault and AWS Secrets Manager. Fetched credentials are turned
+// into runtime-only auths so they are never written to the local auth directory.
+package credsource
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// defaultRefreshInterval is used when a source does not set refresh-interval.
+const defaultRefreshInterval = 5 * time.Minute
+
+// minRefreshInterval protects secret stores from overly aggressive polling.
+const minRefreshInterval = 30 * time.Second
+
+// Secret is a single credential document fetched from a provider.
+type Secret struct {
+	// ID identifies the secret within its provider (e.g. the Vault key or secret name).
+	ID string
+	// Data is the credential JSON, in the same format as an auth file.
+	Data []byte
+}
+
+// Provider fetches the full set of credential documents from a secret store.
+type Provider interface {
+	// Name returns the configured source name.
+	Name() string
+	// Fetch returns every credential currently stored in the source.
+	Fetch(ctx context.Context) ([]Secret, error)
+}
+
+// NewProvider builds the provider for a configured credential source.
+func NewProvider(src config.CredentialSource) (Provider, error) {
+	client := &http.Client{Timeout: 30 * time.Second}
+	switch src.Type {
+	case config.CredentialSourceVault:
+		return newVaultProvider(src, client)
+	case config.CredentialSourceAWSSecretsManager:
+		return newAWSSecretsProvider(src, client)
+	default:
+		return nil, fmt.Errorf("credsource: unsupported source type %q", src.Type)
+	}
+}
+
+// refreshInterval parses the source's refresh interval, applying defaults and bounds.
+func refreshInterval(src config.CredentialSource) time.Duration {
+	if src.RefreshInterval == "" {
+		return defaultRefreshInterval
+	}
+	d, err := time.ParseDuration(src.RefreshInterval)
+	if err != nil || d <= 0 {
+		return defaultRefreshInterval
+	}
+	if d < minRefreshInterval {
+		return minRefreshInterval
+	}
+	return d
+}
@@ -0,0 +1,234 @@
+package credsource
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher/synthesizer"
+	log "github.com/sirupsen/logrus"
+)
+
+// EmitFunc publishes an auth update, typically Service.emitAuthUpdate.
+type EmitFunc func(ctx context.Context, update watcher.AuthUpdate)
+
+// secretState remembers what was published for one secret so unchanged secrets
+// are not re-published and removed secrets can be withdrawn.
+type secretState struct {
+	hash    string
+	authIDs []string
+}
+
+// Syncer periodically fetches credentials from every configured source and
+// publishes them as runtime-only auths. A secret whose content changes is
+// re-published as a modification, which is how rotated credentials take effect.
+type Syncer struct {
+	emit EmitFunc
+
+	mu      sync.Mutex
+	cfg     *config.Config
+	sources []config.CredentialSource
+	state   map[string]map[string]secretState
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// NewSyncer creates a syncer that publishes updates through emit.
+func NewSyncer(emit EmitFunc) *Syncer {
+	return &Syncer{emit: emit, state: make(map[string]map[string]secretState)}
+}
+
+// Apply starts polling the sources in cfg. When the source list is unchanged only
+// the config used for synthesis is updated; otherwise running pollers are stopped,
+// their auths withdrawn, and pollers for the new list are started.
+func (s *Syncer) Apply(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	s.mu.Lock()
+	s.cfg = cfg
+	running := s.cancel != nil
+	unchanged := reflect.DeepEqual(s.sources, cfg.CredentialSources)
+	s.mu.Unlock()
+	if unchanged && (running || len(cfg.CredentialSources) == 0) {
+		return
+	}
+
+	// Withdraw everything published by the previous source list before restarting.
+	for _, secrets := range s.stopPollers() {
+		for _, st := range secrets {
+			s.withdraw(context.Background(), st.authIDs)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sources = append([]config.CredentialSource(nil), cfg.CredentialSources...)
+	if len(s.sources) == 0 {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	for _, src := range s.sources {
+		provider, err := NewProvider(src)
+		if err != nil {
+			log.Errorf("credential source %s disabled: %v", src.Name, err)
+			continue
+		}
+		interval := refreshInterval(src)
+		log.Infof("credential source %s (%s) enabled, refresh every %s", src.Name, src.Type, interval)
+		s.wg.Add(1)
+		go s.run(ctx, provider, interval)
+	}
+}
+
+// Stop halts all pollers. Published auths are left in place; they are runtime-only
+// and disappear with the process.
+func (s *Syncer) Stop() {
+	if s == nil {
+		return
+	}
+	s.stopPollers()
+}
+
+// stopPollers cancels running pollers, waits for them, and returns the state they
+// had published.
+func (s *Syncer) stopPollers() map[string]map[string]secretState {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.cancel = nil
+	s.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	state := s.state
+	s.state = make(map[string]map[string]secretState)
+	s.sources = nil
+	return state
+}
+
+func (s *Syncer) run(ctx context.Context, provider Provider, interval time.Duration) {
+	defer s.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.syncOnce(ctx, provider); err != nil && ctx.Err() == nil {
+			log.Warnf("credential source %s: fetch failed, keeping previous credentials: %v", provider.Name(), err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncOnce fetches the provider's secrets and publishes the differences.
+func (s *Syncer) syncOnce(ctx context.Context, provider Provider) error {
+	secrets, err := provider.Fetch(ctx)
+	if err != nil {
+		return err
+	}
+	name := provider.Name()
+
+	s.mu.Lock()
+	cfg := s.cfg
+	previous := s.state[name]
+	s.mu.Unlock()
+
+	now := time.Now()
+	next := make(map[string]secretState, len(secrets))
+	var updates []watcher.AuthUpdate
+	var stale []string
+	for _, secret := range secrets {
+		sum := sha256.Sum256(secret.Data)
+		hash := hex.EncodeToString(sum[:])
+		prev, known := previous[secret.ID]
+		if known && prev.hash == hash {
+			next[secret.ID] = prev
+			continue
+		}
+
+		var metadata map[string]any
+		if errUnmarshal := json.Unmarshal(secret.Data, &metadata); errUnmarshal != nil {
+			log.Warnf("credential source %s: secret %s is not valid JSON, skipping", name, secret.ID)
+			continue
+		}
+		id := name + ":" + secret.ID
+		attrs := map[string]string{
+			"source":            "credsource:" + name + "/" + secret.ID,
+			"runtime_only":      "true",
+			"credential_source": name,
+		}
+		auths := synthesizer.SynthesizeMetadataAuths(id, attrs, metadata, cfg, now)
+		if len(auths) == 0 {
+			log.Warnf("credential source %s: secret %s has no credential type, skipping", name, secret.ID)
+			continue
+		}
+
+		state := secretState{hash: hash, authIDs: make([]string, 0, len(auths))}
+		published := make(map[string]struct{}, len(auths))
+		for _, a := range auths {
+			action := watcher.AuthUpdateActionAdd
+			if containsString(prev.authIDs, a.ID) {
+				action = watcher.AuthUpdateActionModify
+			}
+			updates = append(updates, watcher.AuthUpdate{Action: action, ID: a.ID, Auth: a})
+			state.authIDs = append(state.authIDs, a.ID)
+			published[a.ID] = struct{}{}
+		}
+		for _, oldID := range prev.authIDs {
+			if _, ok := published[oldID]; !ok {
+				stale = append(stale, oldID)
+			}
+		}
+		if known {
+			log.Infof("credential source %s: secret %s changed, credentials rotated", name, secret.ID)
+		}
+		next[secret.ID] = state
+	}
+	for secretID, prev := range previous {
+		if _, ok := next[secretID]; !ok {
+			stale = append(stale, prev.authIDs...)
+		}
+	}
+
+	s.mu.Lock()
+	if ctx.Err() != nil {
+		s.mu.Unlock()
+		return ctx.Err()
+	}
+	s.state[name] = next
+	s.mu.Unlock()
+
+	for _, update := range updates {
+		s.emit(ctx, update)
+	}
+	s.withdraw(ctx, stale)
+	return nil
+}
+
+func (s *Syncer) withdraw(ctx context.Context, ids []string) {
+	for _, id := range ids {
+		s.emit(ctx, watcher.AuthUpdate{Action: watcher.AuthUpdateActionDelete, ID: id})
+	}
+}
+
+func containsString(items []string, target string) bool {
+	for _, item := range items {
+		if item == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package credsource
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
+)
+
+type fakeProvider struct {
+	secrets []Secret
+}
+
+func (p *fakeProvider) Name() string { return "fake" }
+
+func (p *fakeProvider) Fetch(context.Context) ([]Secret, error) { return p.secrets, nil }
+
+func TestSyncerPublishesRotationsAndRemovals(t *testing.T) {
+	var updates []watcher.AuthUpdate
+	syncer := NewSyncer(func(_ context.Context, update watcher.AuthUpdate) {
+		updates = append(updates, update)
+	})
+	syncer.cfg = &config.Config{}
+	provider := &fakeProvider{secrets: []Secret{
+		{ID: "a", Data: []byte(`{"type":"claude","access_token":"t1"}`)},
+		{ID: "b", Data: []byte(`{"type":"codex"}`)},
+	}}
+	ctx := context.Background()
+
+	if err := syncer.syncOnce(ctx, provider); err != nil {
+		t.Fatalf("syncOnce: %v", err)
+	}
+	if len(updates) != 2 || updates[0].Action != watcher.AuthUpdateActionAdd || updates[0].ID != "fake:a" {
+		t.Fatalf("unexpected initial updates: %+v", updates)
+	}
+	if updates[0].Auth.Attributes["runtime_only"] != "true" {
+		t.Errorf("expected runtime_only auth, got %+v", updates[0].Auth.Attributes)
+	}
+
+	updates = nil
+	if err := syncer.syncOnce(ctx, provider); err != nil {
+		t.Fatalf("syncOnce: %v", err)
+	}
+	if len(updates) != 0 {
+		t.Fatalf("expected no updates for unchanged secrets, got %+v", updates)
+	}
+
+	provider.secrets = []Secret{{ID: "a", Data: []byte(`{"type":"claude","access_token":"t2"}`)}}
+	if err := syncer.syncOnce(ctx, provider); err != nil {
+		t.Fatalf("syncOnce: %v", err)
+	}
+	if len(updates) != 2 {
+		t.Fatalf("expected modify and delete, got %+v", updates)
+	}
+	if updates[0].Action != watcher.AuthUpdateActionModify || updates[0].Auth.Metadata["access_token"] != "t2" {
+		t.Errorf("expected rotated credential to be modified, got %+v", updates[0])
+	}
+	if updates[1].Action != watcher.AuthUpdateActionDelete || updates[1].ID != "fake:b" {
+		t.Errorf("expected removed secret to be withdrawn, got %+v", updates[1])
+	}
+}
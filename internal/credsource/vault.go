@@ -0,0 +1,207 @@
+package credsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+// vaultAuthJSONField lets a secret carry the credential document as a single string
+// field instead of storing its keys at the top level of the secret.
+const vaultAuthJSONField = "auth_json"
+
+// vaultProvider reads credentials from a Vault KV secrets engine.
+type vaultProvider struct {
+	name      string
+	address   string
+	namespace string
+	mount     string
+	path      string
+	kvVersion int
+	token     string
+	tokenEnv  string
+	tokenFile string
+	client    *http.Client
+}
+
+func newVaultProvider(src config.CredentialSource, client *http.Client) (*vaultProvider, error) {
+	address := strings.TrimRight(strings.TrimSpace(src.Address), "/")
+	if address == "" {
+		address = strings.TrimRight(strings.TrimSpace(os.Getenv("VAULT_ADDR")), "/")
+	}
+	if address == "" {
+		return nil, fmt.Errorf("credsource: vault source %s has no address", src.Name)
+	}
+	mount := strings.Trim(strings.TrimSpace(src.Mount), "/")
+	if mount == "" {
+		mount = "secret"
+	}
+	kvVersion := src.KVVersion
+	if kvVersion != 1 {
+		kvVersion = 2
+	}
+	tokenEnv := strings.TrimSpace(src.TokenEnv)
+	if tokenEnv == "" {
+		tokenEnv = "VAULT_TOKEN"
+	}
+	return &vaultProvider{
+		name:      src.Name,
+		address:   address,
+		namespace: strings.TrimSpace(src.Namespace),
+		mount:     mount,
+		path:      strings.Trim(strings.TrimSpace(src.Path), "/"),
+		kvVersion: kvVersion,
+		token:     strings.TrimSpace(src.Token),
+		tokenEnv:  tokenEnv,
+		tokenFile: strings.TrimSpace(src.TokenFile),
+		client:    client,
+	}, nil
+}
+
+func (p *vaultProvider) Name() string { return p.name }
+
+// Fetch lists the configured folder and reads every secret in it. Sub-folders are ignored.
+func (p *vaultProvider) Fetch(ctx context.Context) ([]Secret, error) {
+	token, err := p.resolveToken()
+	if err != nil {
+		return nil, err
+	}
+	var listing struct {
+		Data struct {
+			Keys []string `json:"keys"`
+		} `json:"data"`
+	}
+	found, err := p.get(ctx, token, p.listURL(), &listing)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+
+	out := make([]Secret, 0, len(listing.Data.Keys))
+	for _, key := range listing.Data.Keys {
+		if key == "" || strings.HasSuffix(key, "/") {
+			continue
+		}
+		data, errRead := p.read(ctx, token, key)
+		if errRead != nil {
+			return nil, errRead
+		}
+		if data != nil {
+			out = append(out, Secret{ID: key, Data: data})
+		}
+	}
+	return out, nil
+}
+
+// resolveToken re-reads the token on every fetch so rotated tokens are used immediately.
+func (p *vaultProvider) resolveToken() (string, error) {
+	if p.token != "" {
+		return p.token, nil
+	}
+	if token := strings.TrimSpace(os.Getenv(p.tokenEnv)); token != "" {
+		return token, nil
+	}
+	if p.tokenFile != "" {
+		data, err := os.ReadFile(p.tokenFile)
+		if err != nil {
+			return "", fmt.Errorf("credsource: failed to read vault token file: %w", err)
+		}
+		if token := strings.TrimSpace(string(data)); token != "" {
+			return token, nil
+		}
+	}
+	return "", fmt.Errorf("credsource: vault source %s has no token (set %s or token-file)", p.name, p.tokenEnv)
+}
+
+func (p *vaultProvider) listURL() string {
+	if p.kvVersion == 1 {
+		return p.secretURL("", p.path) + "?list=true"
+	}
+	return p.secretURL("metadata", p.path) + "?list=true"
+}
+
+func (p *vaultProvider) secretURL(kind, path string) string {
+	parts := []string{p.address, "v1", p.mount}
+	if kind != "" {
+		parts = append(parts, kind)
+	}
+	if path != "" {
+		for _, segment := range strings.Split(path, "/") {
+			parts = append(parts, url.PathEscape(segment))
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// read returns the credential JSON stored under key, or nil when the secret is empty.
+func (p *vaultProvider) read(ctx context.Context, token, key string) ([]byte, error) {
+	path := strings.TrimPrefix(p.path+"/"+key, "/")
+	var fields map[string]any
+	if p.kvVersion == 1 {
+		var resp struct {
+			Data map[string]any `json:"data"`
+		}
+		if _, err := p.get(ctx, token, p.secretURL("", path), &resp); err != nil {
+			return nil, err
+		}
+		fields = resp.Data
+	} else {
+		var resp struct {
+			Data struct {
+				Data map[string]any `json:"data"`
+			} `json:"data"`
+		}
+		if _, err := p.get(ctx, token, p.secretURL("data", path), &resp); err != nil {
+			return nil, err
+		}
+		fields = resp.Data.Data
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	if raw, ok := fields[vaultAuthJSONField].(string); ok && strings.TrimSpace(raw) != "" {
+		return []byte(raw), nil
+	}
+	return json.Marshal(fields)
+}
+
+// get performs an authenticated GET and decodes the response into out.
+// It reports false without error when Vault answers 404.
+func (p *vaultProvider) get(ctx context.Context, token, rawURL string, out any) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if p.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("credsource: vault request failed: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("credsource: failed to read vault response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode >= 400 {
+		return false, fmt.Errorf("credsource: vault returned HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	if err = json.Unmarshal(body, out); err != nil {
+		return false, fmt.Errorf("credsource: invalid vault response: %w", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,64 @@
+package credsource
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+)
+
+func TestVaultProviderFetchKV2(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "tok" || r.Header.Get("X-Vault-Namespace") != "team" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/kv/metadata/cliproxy":
+			_, _ = w.Write([]byte(`{"data":{"keys":["claude-a","codex-b","nested/"]}}`))
+		case "/v1/kv/data/cliproxy/claude-a":
+			_, _ = w.Write([]byte(`{"data":{"data":{"type":"claude","email":"a@example.com"},"metadata":{"version":2}}}`))
+		case "/v1/kv/data/cliproxy/codex-b":
+			_, _ = w.Write([]byte(`{"data":{"data":{"auth_json":"{\"type\":\"codex\"}"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	provider, err := NewProvider(config.CredentialSource{
+		Type: config.CredentialSourceVault, Name: "vault", Address: srv.URL,
+		Namespace: "team", Mount: "kv", Path: "cliproxy", Token: "tok",
+	})
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	secrets, err := provider.Fetch(context.Background())
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(secrets) != 2 {
+		t.Fatalf("expected 2 secrets, got %d", len(secrets))
+	}
+	if secrets[0].ID != "claude-a" || string(secrets[0].Data) != `{"email":"a@example.com","type":"claude"}` {
+		t.Errorf("unexpected first secret: %s %s", secrets[0].ID, secrets[0].Data)
+	}
+	if string(secrets[1].Data) != `{"type":"codex"}` {
+		t.Errorf("expected auth_json field to be used verbatim, got %s", secrets[1].Data)
+	}
+}
+
+func TestVaultProviderMissingFolderIsEmpty(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	provider, err := NewProvider(config.CredentialSource{Type: config.CredentialSourceVault, Address: srv.URL, Token: "tok"})
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	secrets, err := provider.Fetch(context.Background())
+	if err != nil || len(secrets) != 0 {
+		t.Fatalf("expected no secrets and no error, got %d, %v", len(secrets), err)
+	}
+}
@@ -0,0 +1,166 @@
+package logging
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+)
+
+const (
+	// errorSampleCapacity bounds the number of upstream 4xx samples kept in memory.
+	errorSampleCapacity = 100
+	// maxErrorSampleBody bounds the stored upstream error body; request bodies are kept in full.
+	maxErrorSampleBody = 64 << 10
+)
+
+// ErrorSample is one upstream request that was answered with a 4xx status. Bodies,
+// headers and URL are redacted the same way as the payload log.
+type ErrorSample struct {
+	ID              uint64            `json:"id"`
+	Timestamp       time.Time         `json:"timestamp"`
+	RequestID       string            `json:"request_id,omitempty"`
+	APIKey          string            `json:"api_key,omitempty"`
+	Provider        string            `json:"provider,omitempty"`
+	AuthID          string            `json:"auth_id,omitempty"`
+	Method          string            `json:"method,omitempty"`
+	URL             string            `json:"url,omitempty"`
+	Status          int               `json:"status"`
+	RequestHeaders  map[string]string `json:"request_headers,omitempty"`
+	RequestBody     string            `json:"request_body,omitempty"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	ErrorBody       string            `json:"error_body,omitempty"`
+
+	// apiKeyHash identifies the full API key, which APIKey only shows masked.
+	apiKeyHash [sha256.Size]byte
+}
+
+// ErrorSampleRequest carries the translated upstream request an error sample is built from.
+type ErrorSampleRequest struct {
+	RequestID string
+	APIKey    string
+	Provider  string
+	AuthID    string
+	Method    string
+	URL       string
+	Headers   http.Header
+	Body      []byte
+}
+
+var errorSamples = struct {
+	sync.Mutex
+	entries []ErrorSample
+	next    int
+	seq     uint64
+}{}
+
+// RecordErrorSample stores an upstream 4xx response together with its redacted request
+// and returns the sample ID used to attach the error body later. Other statuses are
+// ignored and return 0.
+func RecordErrorSample(req ErrorSampleRequest, status int, headers http.Header) uint64 {
+	if status < http.StatusBadRequest || status >= http.StatusInternalServerError {
+		return 0
+	}
+	sample := ErrorSample{
+		Timestamp:       time.Now(),
+		RequestID:       req.RequestID,
+		Provider:        req.Provider,
+		AuthID:          req.AuthID,
+		Method:          req.Method,
+		URL:             redactURL(req.URL),
+		Status:          status,
+		RequestHeaders:  redactHeaders(req.Headers),
+		RequestBody:     RedactPayload(req.Body),
+		ResponseHeaders: redactHeaders(headers),
+	}
+	if req.APIKey != "" {
+		sample.APIKey = util.HideAPIKey(req.APIKey)
+		sample.apiKeyHash = sha256.Sum256([]byte(req.APIKey))
+	}
+
+	errorSamples.Lock()
+	defer errorSamples.Unlock()
+	errorSamples.seq++
+	sample.ID = errorSamples.seq
+	if len(errorSamples.entries) < errorSampleCapacity {
+		errorSamples.entries = append(errorSamples.entries, sample)
+	} else {
+		errorSamples.entries[errorSamples.next] = sample
+		errorSamples.next = (errorSamples.next + 1) % errorSampleCapacity
+	}
+	return sample.ID
+}
+
+// AppendErrorSampleBody appends an upstream error body chunk to the sample with the given
+// ID. Samples already evicted from the buffer are ignored.
+func AppendErrorSampleBody(id uint64, chunk []byte) {
+	if id == 0 || len(chunk) == 0 {
+		return
+	}
+	errorSamples.Lock()
+	defer errorSamples.Unlock()
+	for i := range errorSamples.entries {
+		sample := &errorSamples.entries[i]
+		if sample.ID != id {
+			continue
+		}
+		if remaining := maxErrorSampleBody - len(sample.ErrorBody); remaining > 0 {
+			redacted := RedactPayload(chunk)
+			if len(redacted) > remaining {
+				redacted = redacted[:remaining]
+			}
+			if sample.ErrorBody != "" && redacted != "" {
+				sample.ErrorBody += "\n"
+			}
+			sample.ErrorBody += redacted
+		}
+		return
+	}
+}
+
+// ErrorSamples returns the buffered upstream 4xx samples, newest first.
+func ErrorSamples() []ErrorSample {
+	errorSamples.Lock()
+	defer errorSamples.Unlock()
+	out := make([]ErrorSample, 0, len(errorSamples.entries))
+	for i := 0; i < len(errorSamples.entries); i++ {
+		idx := (errorSamples.next - 1 - i + 2*len(errorSamples.entries)) % len(errorSamples.entries)
+		out = append(out, errorSamples.entries[idx])
+	}
+	return out
+}
+
+// ResetErrorSamples clears the buffered upstream 4xx samples.
+func ResetErrorSamples() {
+	errorSamples.Lock()
+	defer errorSamples.Unlock()
+	errorSamples.entries = nil
+	errorSamples.next = 0
+}
+
+// PurgeErrorSamples removes the samples of a downstream API key, or of every key when
+// apiKey is empty, recorded before the given time, or at any time when before is zero.
+// It returns how many samples were removed.
+func PurgeErrorSamples(apiKey string, before time.Time) int {
+	keyHash := sha256.Sum256([]byte(apiKey))
+	errorSamples.Lock()
+	defer errorSamples.Unlock()
+	n := len(errorSamples.entries)
+	kept := make([]ErrorSample, 0, n)
+	// Walk oldest first so the kept samples stay in insertion order.
+	for i := 0; i < n; i++ {
+		sample := errorSamples.entries[(errorSamples.next+i)%n]
+		if (apiKey == "" || sample.apiKeyHash == keyHash) && (before.IsZero() || sample.Timestamp.Before(before)) {
+			continue
+		}
+		kept = append(kept, sample)
+	}
+	removed := n - len(kept)
+	if removed > 0 {
+		errorSamples.entries = kept
+		errorSamples.next = 0
+	}
+	return removed
+}
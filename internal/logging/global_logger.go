@@ -34,6 +34,10 @@ var logFieldOrder = []string{"provider", "model", "mode", "budget", "level", "or
 
 // Format renders a single log entry with custom formatting.
 func (m *LogFormatter) Format(entry *log.Entry) ([]byte, error) {
+	if !entryAllowed(entry) {
+		return nil, nil
+	}
+
 	var buffer *bytes.Buffer
 	if entry.Buffer != nil {
 		buffer = entry.Buffer
@@ -0,0 +1,223 @@
+package logging
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// Subsystems whose log level can be changed independently at runtime.
+const (
+	ModuleTranslator = "translator"
+	ModuleExecutor   = "executor"
+	ModuleCache      = "cache"
+	ModuleUsage      = "usage"
+)
+
+// payloadDumpField marks entries written by a payload dump so they bypass module levels.
+const payloadDumpField = "payload_dump"
+
+// modulePathMarkers maps source paths to modules; entries are attributed to a module by
+// the file of their caller, so existing log calls need no changes.
+var modulePathMarkers = []struct {
+	module string
+	marker string
+}{
+	{ModuleTranslator, "/internal/translator/"},
+	{ModuleTranslator, "/sdk/translator/"},
+	{ModuleExecutor, "/internal/runtime/executor/"},
+	{ModuleCache, "/internal/cache/"},
+	{ModuleUsage, "/internal/usage/"},
+}
+
+var levels = struct {
+	sync.RWMutex
+	base    log.Level
+	modules map[string]log.Level
+	dumps   map[string]int
+}{base: log.InfoLevel, modules: make(map[string]log.Level), dumps: make(map[string]int)}
+
+// ModuleLevelState describes the configured log levels.
+type ModuleLevelState struct {
+	Base         string            `json:"base"`
+	Modules      map[string]string `json:"modules"`
+	Available    []string          `json:"available"`
+	PayloadDumps []PayloadDump     `json:"payload-dumps"`
+}
+
+// PayloadDump is a pending payload dump for a client API key.
+type PayloadDump struct {
+	Key       string `json:"key"`
+	Remaining int    `json:"remaining"`
+}
+
+// SetLogLevel applies the base log level from the configuration (debug or info).
+// Module overrides set through SetModuleLevel stay in effect.
+func SetLogLevel(cfg *config.Config) {
+	newLevel := log.InfoLevel
+	if cfg.Debug {
+		newLevel = log.DebugLevel
+	}
+	levels.Lock()
+	currentLevel := levels.base
+	levels.base = newLevel
+	applyLevelsLocked()
+	levels.Unlock()
+
+	if currentLevel != newLevel {
+		log.Infof("log level changed from %s to %s (debug=%t)", currentLevel, newLevel, cfg.Debug)
+	}
+}
+
+// Modules returns the names of the modules that accept a level override.
+func Modules() []string {
+	return []string{ModuleCache, ModuleExecutor, ModuleTranslator, ModuleUsage}
+}
+
+// SetModuleLevel overrides the log level of a module. An empty level or "default"
+// removes the override so the module follows the base level again.
+func SetModuleLevel(module, level string) error {
+	module = strings.ToLower(strings.TrimSpace(module))
+	if !isModule(module) {
+		return fmt.Errorf("unknown log module %q", module)
+	}
+	level = strings.ToLower(strings.TrimSpace(level))
+	levels.Lock()
+	defer levels.Unlock()
+	if level == "" || level == "default" {
+		delete(levels.modules, module)
+		applyLevelsLocked()
+		return nil
+	}
+	parsed, err := log.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	levels.modules[module] = parsed
+	applyLevelsLocked()
+	return nil
+}
+
+// ArmPayloadDump logs the next count translated upstream payloads sent for the client
+// API key at debug level, regardless of module levels. A count of zero or less disarms it.
+func ArmPayloadDump(apiKey string, count int) {
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return
+	}
+	levels.Lock()
+	defer levels.Unlock()
+	if count <= 0 {
+		delete(levels.dumps, apiKey)
+	} else {
+		levels.dumps[apiKey] = count
+	}
+	applyLevelsLocked()
+}
+
+// ConsumePayloadDump reports whether a payload for apiKey should be dumped and counts it.
+func ConsumePayloadDump(apiKey string) bool {
+	levels.RLock()
+	armed := len(levels.dumps) > 0
+	levels.RUnlock()
+	if !armed || apiKey == "" {
+		return false
+	}
+	levels.Lock()
+	defer levels.Unlock()
+	remaining, ok := levels.dumps[apiKey]
+	if !ok {
+		return false
+	}
+	if remaining <= 1 {
+		delete(levels.dumps, apiKey)
+		applyLevelsLocked()
+	} else {
+		levels.dumps[apiKey] = remaining - 1
+	}
+	return true
+}
+
+// PayloadDumpEntry returns an entry whose debug output is kept by the module filter.
+func PayloadDumpEntry(entry *log.Entry) *log.Entry {
+	return entry.WithField(payloadDumpField, true)
+}
+
+// ModuleLevels returns the current base level, module overrides and pending payload
+// dumps; API keys are masked.
+func ModuleLevels() ModuleLevelState {
+	levels.RLock()
+	defer levels.RUnlock()
+	state := ModuleLevelState{
+		Base:         levels.base.String(),
+		Modules:      make(map[string]string, len(levels.modules)),
+		Available:    Modules(),
+		PayloadDumps: make([]PayloadDump, 0, len(levels.dumps)),
+	}
+	for module, level := range levels.modules {
+		state.Modules[module] = level.String()
+	}
+	for key, remaining := range levels.dumps {
+		state.PayloadDumps = append(state.PayloadDumps, PayloadDump{Key: util.HideAPIKey(key), Remaining: remaining})
+	}
+	sort.Slice(state.PayloadDumps, func(i, j int) bool { return state.PayloadDumps[i].Key < state.PayloadDumps[j].Key })
+	return state
+}
+
+// applyLevelsLocked sets the logrus level to the most verbose level any module or
+// payload dump needs; entryAllowed filters the rest. Callers hold levels.
+func applyLevelsLocked() {
+	effective := levels.base
+	for _, level := range levels.modules {
+		if level > effective {
+			effective = level
+		}
+	}
+	if len(levels.dumps) > 0 && effective < log.DebugLevel {
+		effective = log.DebugLevel
+	}
+	if log.GetLevel() != effective {
+		log.SetLevel(effective)
+	}
+}
+
+// entryAllowed reports whether an entry passes its module level, or the base level
+// when it belongs to no module.
+func entryAllowed(entry *log.Entry) bool {
+	if dump, _ := entry.Data[payloadDumpField].(bool); dump {
+		return true
+	}
+	levels.RLock()
+	defer levels.RUnlock()
+	threshold := levels.base
+	if len(levels.modules) > 0 && entry.Caller != nil {
+		if level, ok := levels.modules[moduleForFile(entry.Caller.File)]; ok {
+			threshold = level
+		}
+	}
+	return entry.Level <= threshold
+}
+
+func moduleForFile(file string) string {
+	file = strings.ReplaceAll(file, "\\", "/")
+	for _, m := range modulePathMarkers {
+		if strings.Contains(file, m.marker) {
+			return m.module
+		}
+	}
+	return ""
+}
+
+func isModule(module string) bool {
+	for _, m := range Modules() {
+		if m == module {
+			return true
+		}
+	}
+	return false
+}
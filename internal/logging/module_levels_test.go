@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+func TestModuleLevelFiltersByCallerPath(t *testing.T) {
+	SetLogLevel(&config.Config{})
+	if err := SetModuleLevel(ModuleExecutor, "debug"); err != nil {
+		t.Fatalf("SetModuleLevel: %v", err)
+	}
+	defer func() { _ = SetModuleLevel(ModuleExecutor, "") }()
+
+	if log.GetLevel() != log.DebugLevel {
+		t.Fatalf("logrus level = %s, want debug", log.GetLevel())
+	}
+	entry := func(file string, level log.Level) *log.Entry {
+		e := log.NewEntry(log.StandardLogger())
+		e.Level = level
+		e.Caller = &runtime.Frame{File: file}
+		return e
+	}
+	if !entryAllowed(entry("/src/internal/runtime/executor/claude_executor.go", log.DebugLevel)) {
+		t.Error("executor debug entry filtered")
+	}
+	if entryAllowed(entry("/src/internal/translator/claude/x.go", log.DebugLevel)) {
+		t.Error("translator debug entry kept at info base level")
+	}
+	if !entryAllowed(entry("/src/internal/translator/claude/x.go", log.InfoLevel)) {
+		t.Error("translator info entry filtered")
+	}
+	if err := SetModuleLevel("unknown", "debug"); err == nil {
+		t.Error("expected error for unknown module")
+	}
+}
+
+func TestPayloadDumpCountsDown(t *testing.T) {
+	SetLogLevel(&config.Config{})
+	ArmPayloadDump("sk-test", 2)
+	if log.GetLevel() != log.DebugLevel {
+		t.Fatalf("logrus level = %s, want debug while a dump is armed", log.GetLevel())
+	}
+	if ConsumePayloadDump("sk-other") {
+		t.Error("dump consumed for another key")
+	}
+	if !ConsumePayloadDump("sk-test") || !ConsumePayloadDump("sk-test") {
+		t.Fatal("expected two dumps")
+	}
+	if ConsumePayloadDump("sk-test") {
+		t.Error("dump not exhausted after two payloads")
+	}
+	if log.GetLevel() != log.InfoLevel {
+		t.Errorf("logrus level = %s, want info after the dump finished", log.GetLevel())
+	}
+	dump := PayloadDumpEntry(log.NewEntry(log.StandardLogger()))
+	dump.Level = log.DebugLevel
+	if !entryAllowed(dump) {
+		t.Error("payload dump entry filtered at info base level")
+	}
+}
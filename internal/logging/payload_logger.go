@@ -0,0 +1,392 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	payloadLogFileName      = "payloads.log"
+	defaultPayloadLogSizeMB = 10
+	// minRedactedBase64 is the length from which base64-looking data fields are masked.
+	minRedactedBase64 = 256
+)
+
+var payloadLog = struct {
+	sync.Mutex
+	cfg    config.PayloadLogConfig
+	keys   map[string]struct{}
+	writer *lumberjack.Logger
+}{}
+
+// payloadLogActive lets the per-chunk check skip the mutex while the logger is off.
+var payloadLogActive atomic.Bool
+
+// payloadSecretKeys are JSON keys whose scalar values are masked in logged payloads.
+var payloadSecretKeys = map[string]struct{}{
+	"api_key":       {},
+	"apikey":        {},
+	"key":           {},
+	"authorization": {},
+	"access_token":  {},
+	"refresh_token": {},
+	"id_token":      {},
+	"client_secret": {},
+	"password":      {},
+	"secret":        {},
+}
+
+// payloadBase64Keys hold inline binary data (Claude image sources, Gemini inline data,
+// OpenAI image results).
+var payloadBase64Keys = map[string]struct{}{
+	"data":     {},
+	"b64_json": {},
+	"bytes":    {},
+}
+
+var (
+	dataURLPattern   = regexp.MustCompile(`data:([\w.+-]+/[\w.+-]+);base64,[A-Za-z0-9+/=]+`)
+	inlineKeyPattern = regexp.MustCompile(`\b(sk-[A-Za-z0-9_-]{16,}|sk-ant-[A-Za-z0-9_-]{16,}|AIza[0-9A-Za-z_-]{30,})`)
+	base64Pattern    = regexp.MustCompile(`^[A-Za-z0-9+/=\r\n]+$`)
+)
+
+// PayloadRecord is one line of payloads.log.
+type PayloadRecord struct {
+	Time      time.Time         `json:"time"`
+	RequestID string            `json:"request_id,omitempty"`
+	APIKey    string            `json:"api_key,omitempty"`
+	APIKeyID  string            `json:"api_key_id,omitempty"` // fingerprint of the full key, used to purge its records
+	Direction string            `json:"direction"`
+	Method    string            `json:"method,omitempty"`
+	URL       string            `json:"url,omitempty"`
+	Status    int               `json:"status,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Body      string            `json:"body,omitempty"`
+}
+
+// ConfigurePayloadLog opens or closes payloads.log in the log directory according to
+// cfg.PayloadLog. It is called at startup and on every configuration reload.
+func ConfigurePayloadLog(cfg *config.Config) error {
+	payloadLog.Lock()
+	defer payloadLog.Unlock()
+
+	if payloadLog.writer != nil {
+		_ = payloadLog.writer.Close()
+		payloadLog.writer = nil
+	}
+	payloadLog.keys = nil
+	payloadLog.cfg = config.PayloadLogConfig{}
+	payloadLogActive.Store(false)
+	if cfg == nil || !cfg.PayloadLog.Enabled {
+		return nil
+	}
+
+	logDir := ResolveLogDirectory(cfg)
+	if err := os.MkdirAll(logDir, 0o755); err != nil {
+		return fmt.Errorf("logging: failed to create payload log directory: %w", err)
+	}
+	maxSize := cfg.PayloadLog.MaxSizeMB
+	if maxSize <= 0 {
+		maxSize = defaultPayloadLogSizeMB
+	}
+	payloadLog.cfg = cfg.PayloadLog
+	payloadLog.writer = &lumberjack.Logger{
+		Filename:   filepath.Join(logDir, payloadLogFileName),
+		MaxSize:    maxSize,
+		MaxBackups: 1,
+	}
+	if len(cfg.PayloadLog.APIKeys) > 0 {
+		payloadLog.keys = make(map[string]struct{}, len(cfg.PayloadLog.APIKeys))
+		for _, key := range cfg.PayloadLog.APIKeys {
+			if key = strings.TrimSpace(key); key != "" {
+				payloadLog.keys[key] = struct{}{}
+			}
+		}
+	}
+	payloadLogActive.Store(true)
+	return nil
+}
+
+// PayloadLogEnabled reports whether payloads of requests made with apiKey are logged,
+// and whether response payloads are included.
+func PayloadLogEnabled(apiKey string) (requests, responses bool) {
+	if !payloadLogActive.Load() {
+		return false, false
+	}
+	payloadLog.Lock()
+	defer payloadLog.Unlock()
+	if payloadLog.writer == nil {
+		return false, false
+	}
+	if payloadLog.keys != nil {
+		if _, ok := payloadLog.keys[apiKey]; !ok {
+			return false, false
+		}
+	}
+	return true, payloadLog.cfg.IncludeResponses
+}
+
+// WritePayload redacts record and appends it to payloads.log.
+func WritePayload(record PayloadRecord, apiKey string, headers http.Header, body []byte) {
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+	if apiKey != "" {
+		record.APIKey = util.HideAPIKey(apiKey)
+		record.APIKeyID = payloadKeyID(apiKey)
+	}
+	record.URL = redactURL(record.URL)
+	record.Headers = redactHeaders(headers)
+	record.Body = RedactPayload(body)
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+	payloadLog.Lock()
+	defer payloadLog.Unlock()
+	if payloadLog.writer == nil {
+		return
+	}
+	_, _ = payloadLog.writer.Write(append(line, '\n'))
+}
+
+// payloadKeyID fingerprints an API key without revealing it.
+func payloadKeyID(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+// PurgePayloadLog removes the records of apiKey logged before the given time, or at any
+// time when before is zero, from payloads.log and its rotated backup in logDir, and
+// returns how many were removed. Records written before key fingerprints were logged
+// are matched by their masked key.
+func PurgePayloadLog(logDir, apiKey string, before time.Time) (int, error) {
+	if apiKey == "" || logDir == "" {
+		return 0, nil
+	}
+	payloadLog.Lock()
+	defer payloadLog.Unlock()
+	if payloadLog.writer != nil {
+		// lumberjack reopens the file on the next write.
+		_ = payloadLog.writer.Close()
+	}
+	paths, err := filepath.Glob(filepath.Join(logDir, strings.TrimSuffix(payloadLogFileName, ".log")+"*.log"))
+	if err != nil {
+		return 0, err
+	}
+	keyID, masked := payloadKeyID(apiKey), util.HideAPIKey(apiKey)
+	matches := func(record PayloadRecord) bool {
+		if record.APIKeyID != "" {
+			if record.APIKeyID != keyID {
+				return false
+			}
+		} else if record.APIKey != masked {
+			return false
+		}
+		return before.IsZero() || record.Time.Before(before)
+	}
+	removed := 0
+	for _, path := range paths {
+		n, errPurge := purgePayloadFile(path, matches)
+		removed += n
+		if errPurge != nil {
+			return removed, errPurge
+		}
+	}
+	return removed, nil
+}
+
+// purgePayloadFile rewrites path without the records that match.
+func purgePayloadFile(path string, match func(PayloadRecord) bool) (int, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	defer func() { _ = in.Close() }()
+
+	var kept bytes.Buffer
+	removed := 0
+	reader := bufio.NewReader(in)
+	for {
+		line, errRead := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			var record PayloadRecord
+			if json.Unmarshal(line, &record) == nil && match(record) {
+				removed++
+			} else {
+				kept.Write(line)
+			}
+		}
+		if errRead == io.EOF {
+			break
+		}
+		if errRead != nil {
+			return 0, errRead
+		}
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, kept.Bytes(), 0o644); err != nil {
+		return 0, err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return 0, err
+	}
+	return removed, nil
+}
+
+// RedactPayload masks credentials, inline API keys and base64 data in a JSON body or
+// SSE chunk. Non-JSON text only has inline keys and data URLs masked.
+func RedactPayload(body []byte) string {
+	text := strings.TrimSpace(string(body))
+	if text == "" {
+		return ""
+	}
+	if gjson.Valid(text) {
+		return redactPayloadJSON(text)
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if rest, ok := strings.CutPrefix(line, "data:"); ok {
+			payload := strings.TrimSpace(rest)
+			if payload != "" && gjson.Valid(payload) {
+				lines[i] = "data: " + redactPayloadJSON(payload)
+				continue
+			}
+		}
+		lines[i] = redactPayloadText(line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func redactPayloadJSON(doc string) string {
+	type replacement struct {
+		path  string
+		value string
+	}
+	var replacements []replacement
+	var walk func(node gjson.Result, path string)
+	walk = func(node gjson.Result, path string) {
+		switch {
+		case node.IsObject():
+			node.ForEach(func(key, value gjson.Result) bool {
+				child := joinPayloadPath(path, escapePayloadKey(key.String()))
+				lower := strings.ToLower(key.String())
+				if value.Type == gjson.String {
+					if _, ok := payloadSecretKeys[lower]; ok {
+						replacements = append(replacements, replacement{child, util.HideAPIKey(value.String())})
+						return true
+					}
+					if _, ok := payloadBase64Keys[lower]; ok && isBase64Blob(value.String()) {
+						replacements = append(replacements, replacement{child, fmt.Sprintf("[base64 %d bytes redacted]", len(value.String()))})
+						return true
+					}
+				}
+				walk(value, child)
+				return true
+			})
+		case node.IsArray():
+			index := 0
+			node.ForEach(func(_, value gjson.Result) bool {
+				walk(value, joinPayloadPath(path, strconv.Itoa(index)))
+				index++
+				return true
+			})
+		case node.Type == gjson.String:
+			if redacted := redactPayloadText(node.String()); redacted != node.String() {
+				replacements = append(replacements, replacement{path, redacted})
+			}
+		}
+	}
+	walk(gjson.Parse(doc), "")
+	for _, r := range replacements {
+		if updated, err := sjson.Set(doc, r.path, r.value); err == nil {
+			doc = updated
+		}
+	}
+	return doc
+}
+
+func redactPayloadText(text string) string {
+	text = dataURLPattern.ReplaceAllStringFunc(text, func(match string) string {
+		mime := dataURLPattern.FindStringSubmatch(match)[1]
+		return fmt.Sprintf("data:%s;base64,[%d bytes redacted]", mime, len(match))
+	})
+	return inlineKeyPattern.ReplaceAllStringFunc(text, util.HideAPIKey)
+}
+
+func isBase64Blob(value string) bool {
+	return len(value) >= minRedactedBase64 && base64Pattern.MatchString(value)
+}
+
+func redactHeaders(headers http.Header) map[string]string {
+	if len(headers) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(headers))
+	for key, values := range headers {
+		value := strings.Join(values, ", ")
+		if strings.EqualFold(key, "Cookie") || strings.EqualFold(key, "Set-Cookie") {
+			value = util.HideAPIKey(value)
+		}
+		out[key] = util.MaskSensitiveHeaderValue(key, value)
+	}
+	return out
+}
+
+func redactURL(raw string) string {
+	parsed, err := url.Parse(raw)
+	if err != nil || parsed.RawQuery == "" {
+		return raw
+	}
+	parsed.RawQuery = util.MaskSensitiveQuery(parsed.RawQuery)
+	return parsed.String()
+}
+
+func joinPayloadPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+func escapePayloadKey(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		switch r {
+		case '.', '*', '?', '|', '#', '@', '\\', ':', '!', '=', '<', '>', '%':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestRedactPayloadMasksSecretsAndImages(t *testing.T) {
+	image := strings.Repeat("QUJD", 100)
+	body := `{"api_key":"sk-abcdefghijklmnopqrstuvwxyz","messages":[` +
+		`{"role":"user","content":[{"type":"image","source":{"type":"base64","media_type":"image/png","data":"` + image + `"}},` +
+		`{"type":"image_url","image_url":{"url":"data:image/jpeg;base64,` + image + `"}},` +
+		`{"type":"text","text":"my key is sk-ant-REDACTED, thanks"}]}]}`
+
+	out := RedactPayload([]byte(body))
+	if strings.Contains(out, image) {
+		t.Fatalf("base64 image data not redacted: %s", out)
+	}
+	if strings.Contains(out, "abcdefghijklmnopqrstuvwxyz") {
+		t.Fatalf("API key not redacted: %s", out)
+	}
+	if got := gjson.Get(out, "messages.0.content.0.source.media_type").String(); got != "image/png" {
+		t.Errorf("media_type = %q, want it kept", got)
+	}
+	if got := gjson.Get(out, "messages.0.content.2.text").String(); !strings.HasSuffix(got, ", thanks") {
+		t.Errorf("text = %q, want surrounding text kept", got)
+	}
+}
+
+func TestWritePayloadHonorsKeyFilter(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("WRITABLE_PATH", dir)
+	cfg := &config.Config{PayloadLog: config.PayloadLogConfig{Enabled: true, APIKeys: []string{"client-a"}}}
+	if err := ConfigurePayloadLog(cfg); err != nil {
+		t.Fatalf("ConfigurePayloadLog: %v", err)
+	}
+	defer func() { _ = ConfigurePayloadLog(nil) }()
+
+	if ok, _ := PayloadLogEnabled("client-b"); ok {
+		t.Fatal("payload log enabled for unlisted key")
+	}
+	if ok, responses := PayloadLogEnabled("client-a"); !ok || responses {
+		t.Fatalf("PayloadLogEnabled(client-a) = %t, %t; want true, false", ok, responses)
+	}
+	WritePayload(PayloadRecord{Direction: "request", URL: "https://example.com/v1?key=secretsecret"}, "client-a",
+		http.Header{"Authorization": {"Bearer tokentokentoken"}}, []byte(`{"model":"m"}`))
+	_ = ConfigurePayloadLog(nil)
+
+	data, err := os.ReadFile(filepath.Join(ResolveLogDirectory(cfg), payloadLogFileName))
+	if err != nil {
+		t.Fatalf("read payload log: %v", err)
+	}
+	line := string(data)
+	if strings.Contains(line, "tokentokentoken") || strings.Contains(line, "secretsecret") {
+		t.Fatalf("credentials written to payload log: %s", line)
+	}
+	if gjson.Get(line, "body").String() != `{"model":"m"}` {
+		t.Errorf("body = %s", gjson.Get(line, "body").Raw)
+	}
+}
+
+func TestPurgePayloadLogByAPIKey(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("WRITABLE_PATH", dir)
+	cfg := &config.Config{PayloadLog: config.PayloadLogConfig{Enabled: true}}
+	if err := ConfigurePayloadLog(cfg); err != nil {
+		t.Fatalf("ConfigurePayloadLog: %v", err)
+	}
+	defer func() { _ = ConfigurePayloadLog(nil) }()
+	logDir := ResolveLogDirectory(cfg)
+
+	// sk-andy-0000006789 masks like sk-alice-0123456789.
+	old := time.Now().Add(-2 * time.Hour)
+	for _, key := range []string{"sk-alice-0123456789", "sk-andy-0000006789"} {
+		WritePayload(PayloadRecord{Time: old, Direction: "request"}, key, nil, []byte(`{"model":"old"}`))
+		WritePayload(PayloadRecord{Direction: "request"}, key, nil, []byte(`{"model":"new"}`))
+	}
+
+	if removed, err := PurgePayloadLog(logDir, "sk-alice-0123456789", time.Now().Add(-time.Hour)); err != nil || removed != 1 {
+		t.Fatalf("purge before an hour ago = %d, %v; want 1", removed, err)
+	}
+	if removed, err := PurgePayloadLog(logDir, "sk-alice-0123456789", time.Time{}); err != nil || removed != 1 {
+		t.Fatalf("purge all = %d, %v; want 1", removed, err)
+	}
+	WritePayload(PayloadRecord{Direction: "request"}, "sk-andy-0000006789", nil, []byte(`{"model":"after purge"}`))
+
+	data, err := os.ReadFile(filepath.Join(logDir, payloadLogFileName))
+	if err != nil {
+		t.Fatalf("read payload log: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("payload log has %d records, want andy's 3:\n%s", len(lines), data)
+	}
+	for _, line := range lines {
+		if gjson.Get(line, "api_key_id").String() != payloadKeyID("sk-andy-0000006789") {
+			t.Errorf("unexpected record kept: %s", line)
+		}
+	}
+}
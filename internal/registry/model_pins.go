@@ -0,0 +1,157 @@
+package registry
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// latestModelSuffix marks a floating model target that is pinned to a concrete version.
+const latestModelSuffix = "-latest"
+
+// ModelPin records the concrete version a floating alias target such as
+// "claude-sonnet-latest" currently resolves to.
+type ModelPin struct {
+	Channel    string     `json:"channel"`
+	Target     string     `json:"target"`
+	Resolved   string     `json:"resolved,omitempty"`
+	Previous   string     `json:"previous,omitempty"`
+	ResolvedAt time.Time  `json:"resolved_at"`
+	ChangedAt  *time.Time `json:"changed_at,omitempty"`
+}
+
+type modelPinKey struct {
+	channel string
+	target  string
+}
+
+var modelPins = struct {
+	sync.RWMutex
+	entries map[modelPinKey]*ModelPin
+}{entries: make(map[modelPinKey]*ModelPin)}
+
+// IsFloatingModel reports whether model is a "-latest" target that should be pinned.
+func IsFloatingModel(model string) bool {
+	model = strings.ToLower(strings.TrimSpace(model))
+	return len(model) > len(latestModelSuffix) && strings.HasSuffix(model, latestModelSuffix)
+}
+
+// ResolveLatestModel picks the newest model in models belonging to the family of target,
+// e.g. "claude-sonnet-latest" resolves to the most recently created "claude-sonnet-*" model.
+// It returns an empty string when target is not floating or no model matches.
+func ResolveLatestModel(target string, models []*ModelInfo) string {
+	if !IsFloatingModel(target) {
+		return ""
+	}
+	target = strings.ToLower(strings.TrimSpace(target))
+	family := strings.TrimSuffix(target, latestModelSuffix) + "-"
+	var best *ModelInfo
+	for _, model := range models {
+		if model == nil {
+			continue
+		}
+		id := strings.ToLower(strings.TrimSpace(model.ID))
+		if !strings.HasPrefix(id, family) || IsFloatingModel(id) {
+			continue
+		}
+		if best == nil || model.Created > best.Created || (model.Created == best.Created && id > strings.ToLower(best.ID)) {
+			best = model
+		}
+	}
+	if best == nil {
+		return ""
+	}
+	return strings.TrimSpace(best.ID)
+}
+
+// PinModels resolves the floating targets configured per channel against the channel's
+// provider model list: the static catalog plus any models registered at runtime. Changes
+// of an existing pin are logged so version drift is visible, and pins that are no longer
+// configured are dropped.
+func PinModels(targets map[string][]string) {
+	now := time.Now()
+	wanted := make(map[modelPinKey]struct{})
+	modelPins.Lock()
+	defer modelPins.Unlock()
+	for channel, channelTargets := range targets {
+		channel = strings.ToLower(strings.TrimSpace(channel))
+		if channel == "" {
+			continue
+		}
+		var models []*ModelInfo
+		for _, target := range channelTargets {
+			if !IsFloatingModel(target) {
+				continue
+			}
+			if models == nil {
+				models = append(models, GetStaticModelDefinitionsByChannel(channel)...)
+				models = append(models, GetGlobalRegistry().GetAvailableModelsByProvider(channel)...)
+			}
+			key := modelPinKey{channel: channel, target: strings.ToLower(strings.TrimSpace(target))}
+			wanted[key] = struct{}{}
+			resolved := ResolveLatestModel(target, models)
+			pin := modelPins.entries[key]
+			switch {
+			case pin == nil:
+				pin = &ModelPin{Channel: channel, Target: key.target, Resolved: resolved}
+				modelPins.entries[key] = pin
+				if resolved == "" {
+					log.Warnf("model pin %s/%s: no matching model in provider list, forwarding as-is", channel, key.target)
+				} else {
+					log.Infof("model pin %s/%s resolved to %s", channel, key.target, resolved)
+				}
+			case pin.Resolved != resolved:
+				log.Warnf("model pin %s/%s changed from %q to %q", channel, key.target, pin.Resolved, resolved)
+				pin.Previous = pin.Resolved
+				pin.Resolved = resolved
+				changedAt := now
+				pin.ChangedAt = &changedAt
+			}
+			pin.ResolvedAt = now
+		}
+	}
+	for key := range modelPins.entries {
+		if _, ok := wanted[key]; !ok {
+			delete(modelPins.entries, key)
+		}
+	}
+}
+
+// PinnedModel returns the concrete version model is pinned to on channel, or model itself
+// when it is not a pinned floating target.
+func PinnedModel(channel, model string) string {
+	if !IsFloatingModel(model) {
+		return model
+	}
+	key := modelPinKey{
+		channel: strings.ToLower(strings.TrimSpace(channel)),
+		target:  strings.ToLower(strings.TrimSpace(model)),
+	}
+	modelPins.RLock()
+	pin := modelPins.entries[key]
+	modelPins.RUnlock()
+	if pin == nil || pin.Resolved == "" {
+		return model
+	}
+	return pin.Resolved
+}
+
+// ModelPins returns the current pins sorted by channel and target.
+func ModelPins() []ModelPin {
+	modelPins.RLock()
+	out := make([]ModelPin, 0, len(modelPins.entries))
+	for _, pin := range modelPins.entries {
+		out = append(out, *pin)
+	}
+	modelPins.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Channel != out[j].Channel {
+			return out[i].Channel < out[j].Channel
+		}
+		return out[i].Target < out[j].Target
+	})
+	return out
+}
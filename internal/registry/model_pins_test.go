@@ -0,0 +1,41 @@
+package registry
+
+import "testing"
+
+func TestPinModelsDetectsDrift(t *testing.T) {
+	t.Cleanup(func() { PinModels(nil) })
+
+	PinModels(map[string][]string{"claude": {"claude-sonnet-latest", "claude-sonnet-4-6"}})
+	if got := PinnedModel("claude", "claude-sonnet-latest"); got != "claude-sonnet-4-6" {
+		t.Fatalf("pinned = %q, want claude-sonnet-4-6", got)
+	}
+	if got := PinnedModel("claude", "claude-sonnet-4-6"); got != "claude-sonnet-4-6" {
+		t.Fatalf("concrete model changed to %q", got)
+	}
+	if got := PinnedModel("codex", "claude-sonnet-latest"); got != "claude-sonnet-latest" {
+		t.Fatalf("other channel pinned to %q", got)
+	}
+
+	// A newer model appearing in the provider list moves the pin on the next resolution.
+	reg := GetGlobalRegistry()
+	reg.RegisterClient("pin-test", "claude", []*ModelInfo{{ID: "claude-sonnet-5-20270101", Created: 1798761600}})
+	t.Cleanup(func() { reg.UnregisterClient("pin-test") })
+	PinModels(map[string][]string{"claude": {"claude-sonnet-latest"}})
+
+	pins := ModelPins()
+	if len(pins) != 1 {
+		t.Fatalf("pins = %+v, want one", pins)
+	}
+	pin := pins[0]
+	if pin.Resolved != "claude-sonnet-5-20270101" || pin.Previous != "claude-sonnet-4-6" || pin.ChangedAt == nil {
+		t.Fatalf("pin after drift = %+v", pin)
+	}
+
+	PinModels(map[string][]string{"claude": {"claude-unknown-latest"}})
+	if got := PinnedModel("claude", "claude-sonnet-latest"); got != "claude-sonnet-latest" {
+		t.Fatalf("removed pin still applied: %q", got)
+	}
+	if got := PinnedModel("claude", "claude-unknown-latest"); got != "claude-unknown-latest" {
+		t.Fatalf("unresolved pin = %q", got)
+	}
+}
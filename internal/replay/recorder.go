@@ -0,0 +1,197 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultSampleDir  = "samples"
+	defaultMaxSamples = 1000
+	// maxExchanges bounds the memory held for one sampled stream; longer streams are dropped.
+	maxExchanges = 4096
+)
+
+// sampleCounter tracks how many samples each output directory holds so MaxSamples
+// is enforced without listing the directory on every request.
+var sampleCounter = struct {
+	sync.Mutex
+	counts map[string]int
+}{counts: make(map[string]int)}
+
+// Capture collects the translations of one sampled request. It implements
+// sdktranslator.ResponseObserver. A nil *Capture is valid and records nothing.
+type Capture struct {
+	mu              sync.Mutex
+	cfg             config.RequestSamplingConfig
+	from            string
+	model           string
+	incoming        []byte
+	to              string
+	responseModel   string
+	stream          bool
+	upstreamRequest []byte
+	exchanges       []Exchange
+	overflow        bool
+}
+
+// Begin decides whether a request is sampled. When it is, the returned context reports
+// the request's response translations to the returned Capture, which must be finished
+// once the response is complete. Otherwise ctx is returned with a nil Capture.
+func Begin(ctx context.Context, cfg *config.SDKConfig, handlerType, model string, rawJSON []byte) (context.Context, *Capture) {
+	if cfg == nil || cfg.RequestSampling.Rate <= 0 || len(rawJSON) == 0 {
+		return ctx, nil
+	}
+	if cfg.RequestSampling.Rate < 1 && rand.Float64() >= cfg.RequestSampling.Rate {
+		return ctx, nil
+	}
+	capture := &Capture{
+		cfg:      cfg.RequestSampling,
+		from:     handlerType,
+		model:    model,
+		incoming: bytes.Clone(rawJSON),
+	}
+	return sdktranslator.WithResponseObserver(ctx, capture), capture
+}
+
+// ObserveResponse implements sdktranslator.ResponseObserver.
+func (c *Capture) ObserveResponse(clientFormat, upstreamFormat sdktranslator.Format, model string, _, requestRawJSON, rawJSON []byte, translated []string, stream bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// A new upstream request means the previous attempt failed and was retried; only
+	// the attempt that produced the response is kept.
+	if c.to != upstreamFormat.String() || c.stream != stream || !bytes.Equal(c.upstreamRequest, requestRawJSON) {
+		c.to = upstreamFormat.String()
+		c.stream = stream
+		c.upstreamRequest = bytes.Clone(requestRawJSON)
+		c.exchanges = nil
+		c.overflow = false
+	}
+	if clientFormat.String() != "" {
+		c.from = clientFormat.String()
+	}
+	c.responseModel = model
+	if len(c.exchanges) >= maxExchanges {
+		c.overflow = true
+		return
+	}
+	c.exchanges = append(c.exchanges, Exchange{
+		Upstream:   string(rawJSON),
+		Translated: append([]string(nil), translated...),
+	})
+}
+
+// Finish writes the sample when the request succeeded and at least one response
+// translation was observed.
+func (c *Capture) Finish(success bool) {
+	if c == nil || !success {
+		return
+	}
+	c.mu.Lock()
+	sample, ok := c.buildLocked()
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+	if err := writeSample(c.cfg, sample); err != nil {
+		log.Warnf("replay: failed to write request sample: %v", err)
+	}
+}
+
+func (c *Capture) buildLocked() (Sample, bool) {
+	if len(c.exchanges) == 0 || c.overflow || c.to == "" {
+		return Sample{}, false
+	}
+	content := c.cfg.RedactContent
+	model := c.responseModel
+	if model == "" {
+		model = c.model
+	}
+	incoming := redactChunk(string(c.incoming), content)
+	translated := sdktranslator.TranslateRequest(sdktranslator.FromString(c.from), sdktranslator.FromString(c.to), model, []byte(incoming), c.stream)
+	sample := Sample{
+		Version:           SampleVersion,
+		ID:                uuid.NewString(),
+		CapturedAt:        time.Now().UTC(),
+		From:              c.from,
+		To:                c.to,
+		Model:             model,
+		Stream:            c.stream,
+		IncomingRequest:   incoming,
+		TranslatedRequest: redactChunk(string(translated), content),
+		UpstreamRequest:   redactChunk(string(c.upstreamRequest), content),
+		Exchanges:         make([]Exchange, 0, len(c.exchanges)),
+	}
+	for _, exchange := range c.exchanges {
+		redacted := Exchange{
+			Upstream:   redactChunk(exchange.Upstream, content),
+			Translated: make([]string, len(exchange.Translated)),
+		}
+		for i, chunk := range exchange.Translated {
+			redacted.Translated[i] = redactChunk(chunk, content)
+		}
+		sample.Exchanges = append(sample.Exchanges, redacted)
+	}
+	return sample, true
+}
+
+func writeSample(cfg config.RequestSamplingConfig, sample Sample) error {
+	dir := strings.TrimSpace(cfg.Dir)
+	if dir == "" {
+		dir = defaultSampleDir
+	}
+	maxSamples := cfg.MaxSamples
+	if maxSamples <= 0 {
+		maxSamples = defaultMaxSamples
+	}
+
+	sampleCounter.Lock()
+	defer sampleCounter.Unlock()
+	count, known := sampleCounter.counts[dir]
+	if !known {
+		existing, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+		count = len(existing)
+	}
+	if count >= maxSamples {
+		sampleCounter.counts[dir] = count
+		return nil
+	}
+	data, err := json.MarshalIndent(sample, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s-%s-%s.json", sample.CapturedAt.Format("20060102T150405.000"), sanitizeName(sample.From), sanitizeName(sample.To), sample.ID[:8])
+	if err = os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+		return err
+	}
+	sampleCounter.counts[dir] = count + 1
+	return nil
+}
+
+func sanitizeName(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,85 @@
+package replay
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+func TestCaptureRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.SDKConfig{RequestSampling: config.RequestSamplingConfig{Rate: 1, Dir: dir, RedactContent: true}}
+	incoming := []byte(`{"model":"claude-sonnet-4-5","user":"alice@example.com","stream":true,"messages":[{"role":"user","content":"Hello there"}]}`)
+
+	ctx, capture := Begin(context.Background(), cfg, "openai", "claude-sonnet-4-5", incoming)
+	if capture == nil {
+		t.Fatal("expected the request to be sampled at rate 1")
+	}
+	from, to := sdktranslator.FromString("openai"), sdktranslator.FromString("claude")
+	upstreamRequest := sdktranslator.TranslateRequest(from, to, "claude-sonnet-4-5", incoming, true)
+	var param any
+	for _, line := range []string{
+		`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":10,"output_tokens":1}}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi Alice"}}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":3}}`,
+		`data: {"type":"message_stop"}`,
+	} {
+		sdktranslator.TranslateStream(ctx, to, from, "claude-sonnet-4-5", incoming, upstreamRequest, []byte(line), &param)
+	}
+	capture.Finish(true)
+
+	samples, err := LoadSamples(dir)
+	if err != nil {
+		t.Fatalf("LoadSamples() error = %v", err)
+	}
+	if len(samples) != 1 {
+		t.Fatalf("expected one sample, got %d", len(samples))
+	}
+	sample := samples[0]
+	if sample.From != "openai" || sample.To != "claude" || !sample.Stream || len(sample.Exchanges) != 5 {
+		t.Fatalf("unexpected sample header: %+v", sample)
+	}
+	for _, leaked := range []string{"alice@example.com", "Hello there", "Hi Alice"} {
+		if strings.Contains(sample.IncomingRequest+sample.UpstreamRequest+sample.TranslatedRequest, leaked) {
+			t.Fatalf("sample request leaked %q", leaked)
+		}
+		for _, exchange := range sample.Exchanges {
+			if strings.Contains(exchange.Upstream+strings.Join(exchange.Translated, ""), leaked) {
+				t.Fatalf("sample response leaked %q", leaked)
+			}
+		}
+	}
+	if diffs := Replay(context.Background(), sample); len(diffs) != 0 {
+		t.Fatalf("replay of a fresh sample differs:\n%s", strings.Join(diffs, "\n"))
+	}
+
+	sample.Exchanges[2].Translated[0] = strings.Replace(sample.Exchanges[2].Translated[0], "Xx Xxxxx", "Xx", 1)
+	if diffs := Replay(context.Background(), sample); len(diffs) != 1 {
+		t.Fatalf("expected one diff after tampering, got %d: %v", len(diffs), diffs)
+	}
+}
+
+func TestCaptureSkipsFailedRequests(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.SDKConfig{RequestSampling: config.RequestSamplingConfig{Rate: 1, Dir: dir}}
+	ctx, capture := Begin(context.Background(), cfg, "openai", "m", []byte(`{"model":"m"}`))
+	var param any
+	sdktranslator.TranslateNonStream(ctx, sdktranslator.FromString("claude"), sdktranslator.FromString("openai"), "m", []byte(`{"model":"m"}`), []byte(`{}`), []byte(`{"type":"message"}`), &param)
+	capture.Finish(false)
+
+	samples, err := LoadSamples(dir)
+	if err != nil {
+		t.Fatalf("LoadSamples() error = %v", err)
+	}
+	if len(samples) != 0 {
+		t.Fatalf("expected no samples for a failed request, got %d", len(samples))
+	}
+	if _, capture = Begin(context.Background(), &config.SDKConfig{}, "openai", "m", []byte(`{}`)); capture != nil {
+		t.Fatal("expected sampling to be disabled at rate 0")
+	}
+}
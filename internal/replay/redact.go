@@ -0,0 +1,210 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// secretKeys are JSON keys whose values are always replaced before a sample is written.
+var secretKeys = map[string]struct{}{
+	"api_key":           {},
+	"apikey":            {},
+	"authorization":     {},
+	"access_token":      {},
+	"refresh_token":     {},
+	"id_token":          {},
+	"client_secret":     {},
+	"password":          {},
+	"secret":            {},
+	"user":              {},
+	"user_id":           {},
+	"email":             {},
+	"safety_identifier": {},
+}
+
+// contentKeys hold prompt or completion text masked when content redaction is enabled.
+// Tool arguments are kept because masking would break their JSON.
+var contentKeys = map[string]struct{}{
+	"text":              {},
+	"content":           {},
+	"thinking":          {},
+	"reasoning_content": {},
+	"instructions":      {},
+	"system":            {},
+	"refusal":           {},
+	"input":             {},
+	"delta":             {},
+}
+
+// volatileKeys carry generated IDs and timestamps that differ between runs; replay
+// ignores their values, as well as those of secretKeys, which translators may generate.
+var volatileKeys = map[string]struct{}{
+	"id":                 {},
+	"created":            {},
+	"created_at":         {},
+	"createtime":         {},
+	"call_id":            {},
+	"item_id":            {},
+	"response_id":        {},
+	"responseid":         {},
+	"system_fingerprint": {},
+}
+
+const redactedValue = "[redacted]"
+
+// redactChunk removes credentials (and optionally text) from a JSON document or SSE chunk
+// without reordering keys, so translators see the same structure on replay.
+func redactChunk(chunk string, content bool) string {
+	return mapChunk(chunk, func(doc string) string {
+		return redactJSON(doc, content)
+	})
+}
+
+func redactJSON(doc string, content bool) string {
+	type redaction struct {
+		path  string
+		value string
+	}
+	var redactions []redaction
+	var walk func(node gjson.Result, path string)
+	walk = func(node gjson.Result, path string) {
+		switch {
+		case node.IsObject():
+			node.ForEach(func(key, value gjson.Result) bool {
+				name := key.String()
+				child := joinPath(path, escapePathKey(name))
+				lower := strings.ToLower(name)
+				if _, ok := secretKeys[lower]; ok && value.Type != gjson.Null && !value.IsObject() && !value.IsArray() {
+					redactions = append(redactions, redaction{path: child, value: redactedValue})
+					return true
+				}
+				if _, ok := contentKeys[lower]; ok && content && value.Type == gjson.String {
+					redactions = append(redactions, redaction{path: child, value: maskText(value.String())})
+					return true
+				}
+				walk(value, child)
+				return true
+			})
+		case node.IsArray():
+			index := 0
+			node.ForEach(func(_, value gjson.Result) bool {
+				walk(value, joinPath(path, strconv.Itoa(index)))
+				index++
+				return true
+			})
+		}
+	}
+	walk(gjson.Parse(doc), "")
+	for _, r := range redactions {
+		if updated, err := sjson.Set(doc, r.path, r.value); err == nil {
+			doc = updated
+		}
+	}
+	return doc
+}
+
+// maskText replaces letters and digits one for one, so masked stream deltas still
+// concatenate to the masked full text.
+func maskText(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsUpper(r):
+			return 'X'
+		case unicode.IsLetter(r):
+			return 'x'
+		case unicode.IsDigit(r):
+			return '0'
+		}
+		return r
+	}, s)
+}
+
+// normalizeChunk rewrites a chunk into a canonical form with volatile values blanked,
+// for comparing recorded and replayed output.
+func normalizeChunk(chunk string) string {
+	return strings.TrimSpace(mapChunk(chunk, func(doc string) string {
+		decoder := json.NewDecoder(strings.NewReader(doc))
+		decoder.UseNumber()
+		var value any
+		if err := decoder.Decode(&value); err != nil {
+			return doc
+		}
+		var buf bytes.Buffer
+		encoder := json.NewEncoder(&buf)
+		encoder.SetEscapeHTML(false)
+		if err := encoder.Encode(blankVolatile(value)); err != nil {
+			return doc
+		}
+		return strings.TrimSpace(buf.String())
+	}))
+}
+
+func blankVolatile(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			lower := strings.ToLower(key)
+			_, volatile := volatileKeys[lower]
+			_, secret := secretKeys[lower]
+			if volatile || secret {
+				v[key] = "<volatile>"
+				continue
+			}
+			v[key] = blankVolatile(child)
+		}
+	case []any:
+		for i := range v {
+			v[i] = blankVolatile(v[i])
+		}
+	}
+	return value
+}
+
+// mapChunk applies fn to every JSON document in a chunk. A chunk is either a bare JSON
+// document or SSE text whose data lines carry JSON; other lines are kept verbatim.
+func mapChunk(chunk string, fn func(doc string) string) string {
+	trimmed := strings.TrimSpace(chunk)
+	if trimmed == "" {
+		return chunk
+	}
+	if gjson.Valid(trimmed) {
+		return fn(trimmed)
+	}
+	lines := strings.Split(chunk, "\n")
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+		payload := strings.TrimLeft(line[len("data:"):], " ")
+		if payload == "" || !gjson.Valid(payload) {
+			continue
+		}
+		lines[i] = line[:len(line)-len(payload)] + fn(payload)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func joinPath(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+func escapePathKey(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		switch r {
+		case '.', '*', '?', '|', '#', '@', '\\', ':', '!', '=', '<', '>', '%':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,115 @@
+// Package replay records sampled proxy traffic to disk and replays it through the
+// translators, so translator changes can be regression-tested offline against real
+// request and response shapes.
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// SampleVersion is the version of the on-disk sample format.
+const SampleVersion = 1
+
+// Sample is one recorded request/response translation tuple.
+type Sample struct {
+	Version    int       `json:"version"`
+	ID         string    `json:"id"`
+	CapturedAt time.Time `json:"captured_at"`
+	// From is the client schema and To the upstream schema.
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Model  string `json:"model"`
+	Stream bool   `json:"stream"`
+	// Bodies are stored as strings rather than embedded JSON so that replay feeds the
+	// translators byte-identical input.
+	//
+	// IncomingRequest is the client request body.
+	IncomingRequest string `json:"incoming_request"`
+	// TranslatedRequest is the translator output for IncomingRequest.
+	TranslatedRequest string `json:"translated_request"`
+	// UpstreamRequest is the body sent upstream after payload rules and thinking
+	// adjustments; response translators receive it alongside the incoming request.
+	UpstreamRequest string `json:"upstream_request"`
+	// Exchanges pairs each upstream response chunk with the translated chunks it produced.
+	// Non-streaming samples hold exactly one exchange.
+	Exchanges []Exchange `json:"exchanges"`
+}
+
+// Exchange is one upstream response chunk and its translation.
+type Exchange struct {
+	Upstream   string   `json:"upstream"`
+	Translated []string `json:"translated"`
+}
+
+// LoadSamples reads every *.json sample in dir, ordered by file name.
+func LoadSamples(dir string) ([]Sample, error) {
+	matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+	samples := make([]Sample, 0, len(matches))
+	for _, path := range matches {
+		data, errRead := os.ReadFile(path)
+		if errRead != nil {
+			return nil, errRead
+		}
+		var sample Sample
+		if errUnmarshal := json.Unmarshal(data, &sample); errUnmarshal != nil {
+			return nil, fmt.Errorf("replay: parse %s: %w", path, errUnmarshal)
+		}
+		if sample.Version != SampleVersion {
+			return nil, fmt.Errorf("replay: %s: unsupported sample version %d", path, sample.Version)
+		}
+		if sample.ID == "" {
+			sample.ID = strings.TrimSuffix(filepath.Base(path), ".json")
+		}
+		samples = append(samples, sample)
+	}
+	return samples, nil
+}
+
+// Replay runs a sample through the currently registered translators and returns a
+// description of every difference from the recorded output. Volatile fields such as
+// generated IDs and timestamps are ignored; an empty result means the sample matches.
+func Replay(ctx context.Context, sample Sample) []string {
+	from := sdktranslator.FromString(sample.From)
+	to := sdktranslator.FromString(sample.To)
+	incoming := []byte(sample.IncomingRequest)
+	upstreamRequest := []byte(sample.UpstreamRequest)
+
+	var diffs []string
+	translated := sdktranslator.TranslateRequest(from, to, sample.Model, incoming, sample.Stream)
+	if want, got := normalizeChunk(sample.TranslatedRequest), normalizeChunk(string(translated)); want != got {
+		diffs = append(diffs, fmt.Sprintf("translated_request:\n  want %s\n  got  %s", want, got))
+	}
+
+	var param any
+	for i, exchange := range sample.Exchanges {
+		var out []string
+		if sample.Stream {
+			out = sdktranslator.TranslateStream(ctx, to, from, sample.Model, incoming, upstreamRequest, []byte(exchange.Upstream), &param)
+		} else {
+			out = []string{sdktranslator.TranslateNonStream(ctx, to, from, sample.Model, incoming, upstreamRequest, []byte(exchange.Upstream), &param)}
+		}
+		if len(out) != len(exchange.Translated) {
+			diffs = append(diffs, fmt.Sprintf("exchanges[%d]: want %d chunks, got %d\n  want %q\n  got  %q", i, len(exchange.Translated), len(out), exchange.Translated, out))
+			continue
+		}
+		for j := range out {
+			if want, got := normalizeChunk(exchange.Translated[j]), normalizeChunk(out[j]); want != got {
+				diffs = append(diffs, fmt.Sprintf("exchanges[%d].translated[%d]:\n  want %s\n  got  %s", i, j, want, got))
+			}
+		}
+	}
+	return diffs
+}
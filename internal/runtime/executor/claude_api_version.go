@@ -0,0 +1,126 @@
+package executor
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// defaultAnthropicVersion is sent when neither the credential config nor the client
+// request specifies an anthropic-version.
+const defaultAnthropicVersion = "2023-06-01"
+
+// anthropicVersionPattern matches the date-formatted versions listed in error messages.
+var anthropicVersionPattern = regexp.MustCompile(`\b\d{4}-\d{2}-\d{2}\b`)
+
+// anthropicVersionOverrides remembers negotiated versions so later requests skip the
+// failed attempt. Keys are "host|rejected version", values the accepted version.
+var anthropicVersionOverrides sync.Map
+
+// resolveAnthropicVersion picks the anthropic-version for r: the credential's configured
+// api-version, else the client's header, else the default, replaced by a previously
+// negotiated version when the upstream rejected it.
+func resolveAnthropicVersion(r *http.Request, auth *cliproxyauth.Auth, ginHeaders http.Header) string {
+	version := ""
+	if auth != nil && auth.Attributes != nil {
+		version = strings.TrimSpace(auth.Attributes["api_version"])
+	}
+	if version == "" && ginHeaders != nil {
+		version = strings.TrimSpace(ginHeaders.Get("Anthropic-Version"))
+	}
+	if version == "" {
+		version = defaultAnthropicVersion
+	}
+	if r != nil && r.URL != nil {
+		if accepted, ok := anthropicVersionOverrides.Load(r.URL.Host + "|" + version); ok {
+			return accepted.(string)
+		}
+	}
+	return version
+}
+
+// anthropicVersionTransport retries a request once with the version advertised by an
+// "unsupported anthropic-version" error.
+type anthropicVersionTransport struct {
+	base http.RoundTripper
+}
+
+// withAnthropicVersionNegotiation wraps the transport of client with version negotiation.
+func withAnthropicVersionNegotiation(client *http.Client) *http.Client {
+	base := client.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	client.Transport = &anthropicVersionTransport{base: base}
+	return client
+}
+
+func (t *anthropicVersionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusBadRequest || req.GetBody == nil {
+		return resp, err
+	}
+	sent := req.Header.Get("Anthropic-Version")
+	if sent == "" {
+		return resp, nil
+	}
+
+	decoded, errDecode := decodeResponseBody(resp.Body, resp.Header.Get("Content-Encoding"))
+	if errDecode != nil {
+		return resp, nil
+	}
+	data, errRead := io.ReadAll(decoded)
+	_ = decoded.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = int64(len(data))
+	if errRead != nil {
+		return resp, nil
+	}
+
+	accepted, ok := advertisedAnthropicVersion(data, sent)
+	if !ok {
+		return resp, nil
+	}
+	body, errBody := req.GetBody()
+	if errBody != nil {
+		return resp, nil
+	}
+	log.Infof("claude executor: upstream %s rejected anthropic-version %s, retrying with %s", req.URL.Host, sent, accepted)
+	anthropicVersionOverrides.Store(req.URL.Host+"|"+sent, accepted)
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	retry.Header.Set("Anthropic-Version", accepted)
+	return t.base.RoundTrip(retry)
+}
+
+// advertisedAnthropicVersion reports the newest version listed in an error body that
+// rejects the sent anthropic-version.
+func advertisedAnthropicVersion(body []byte, sent string) (string, bool) {
+	message := gjson.GetBytes(body, "error.message").String()
+	if message == "" {
+		message = string(body)
+	}
+	lower := strings.ToLower(message)
+	if !strings.Contains(lower, "anthropic-version") {
+		return "", false
+	}
+	if !strings.Contains(lower, "not a valid version") && !strings.Contains(lower, "unsupported") && !strings.Contains(lower, "not supported") {
+		return "", false
+	}
+	best := ""
+	for _, candidate := range anthropicVersionPattern.FindAllString(message, -1) {
+		if candidate != sent && candidate > best {
+			best = candidate
+		}
+	}
+	return best, best != ""
+}
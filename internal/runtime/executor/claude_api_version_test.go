@@ -0,0 +1,57 @@
+package executor
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestAnthropicVersionNegotiation(t *testing.T) {
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		version := r.Header.Get("Anthropic-Version")
+		seen = append(seen, version)
+		body, _ := io.ReadAll(r.Body)
+		if version != "2023-06-01" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"type":"error","error":{"type":"invalid_request_error","message":"anthropic-version: \"` + version + `\" is not a valid version. Supported versions: 2023-01-01, 2023-06-01"}}`))
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	defer server.Close()
+
+	auth := &cliproxyauth.Auth{ID: "claude-version-test", Provider: "claude", Attributes: map[string]string{"api_version": "2021-01-01"}}
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/v1/messages", strings.NewReader(`{"ping":true}`))
+	req.Header.Set("Anthropic-Version", resolveAnthropicVersion(req, auth, nil))
+	resp, err := withAnthropicVersionNegotiation(&http.Client{}).Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != `{"ping":true}` {
+		t.Fatalf("status = %d, body = %s", resp.StatusCode, body)
+	}
+	if len(seen) != 2 || seen[0] != "2021-01-01" || seen[1] != "2023-06-01" {
+		t.Fatalf("versions sent = %v, want rejected then advertised", seen)
+	}
+
+	next, _ := http.NewRequest(http.MethodPost, server.URL+"/v1/messages", nil)
+	if got := resolveAnthropicVersion(next, auth, nil); got != "2023-06-01" {
+		t.Fatalf("negotiated version not remembered, got %q", got)
+	}
+}
+
+func TestAdvertisedAnthropicVersionIgnoresOtherErrors(t *testing.T) {
+	if _, ok := advertisedAnthropicVersion([]byte(`{"error":{"message":"max_tokens: 2023-06-01 is too large"}}`), "2023-06-01"); ok {
+		t.Fatal("expected unrelated 400 to be left alone")
+	}
+	if got, ok := advertisedAnthropicVersion([]byte(`{"error":{"message":"anthropic-version 2030-01-01 is not supported; use 2023-06-01"}}`), "2030-01-01"); !ok || got != "2023-06-01" {
+		t.Fatalf("advertised = %q, %v", got, ok)
+	}
+}
@@ -119,7 +119,7 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 	body = applyCloaking(ctx, e.cfg, auth, body, baseModel)
 
 	requestedModel := payloadRequestedModel(opts, req.Model)
-	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel, payloadClientAPIKey(opts), payloadParameterHeaders(opts))
 
 	// Disable thinking if tool_choice forces tool use (Anthropic API constraint)
 	body = disableThinkingIfToolChoiceForced(body)
@@ -172,7 +172,7 @@ func (e *ClaudeExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 		AuthValue: authValue,
 	})
 
-	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpClient := withAnthropicVersionNegotiation(newProxyAwareHTTPClient(ctx, e.cfg, auth, 0))
 	httpResp, err := httpClient.Do(httpReq)
 	if err != nil {
 		recordAPIResponseError(ctx, e.cfg, err)
@@ -271,7 +271,7 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 	body = applyCloaking(ctx, e.cfg, auth, body, baseModel)
 
 	requestedModel := payloadRequestedModel(opts, req.Model)
-	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel, payloadClientAPIKey(opts), payloadParameterHeaders(opts))
 
 	// Disable thinking if tool_choice forces tool use (Anthropic API constraint)
 	body = disableThinkingIfToolChoiceForced(body)
@@ -306,6 +306,9 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 		return nil, err
 	}
 	applyClaudeHeaders(httpReq, auth, apiKey, true, extraBetas)
+	if enabled := claudeFineGrainedToolStreaming(e.cfg, auth, requestedModel, baseModel); enabled != nil {
+		setClaudeBeta(httpReq.Header, fineGrainedToolStreamingBeta, *enabled)
+	}
 	var authID, authLabel, authType, authValue string
 	if auth != nil {
 		authID = auth.ID
@@ -324,7 +327,7 @@ func (e *ClaudeExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.A
 		AuthValue: authValue,
 	})
 
-	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpClient := withAnthropicVersionNegotiation(newProxyAwareHTTPClient(ctx, e.cfg, auth, 0))
 	httpResp, err := httpClient.Do(httpReq)
 	if err != nil {
 		recordAPIResponseError(ctx, e.cfg, err)
@@ -475,7 +478,7 @@ func (e *ClaudeExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Aut
 		AuthValue: authValue,
 	})
 
-	httpClient := newProxyAwareHTTPClient(ctx, e.cfg, auth, 0)
+	httpClient := withAnthropicVersionNegotiation(newProxyAwareHTTPClient(ctx, e.cfg, auth, 0))
 	resp, err := httpClient.Do(httpReq)
 	if err != nil {
 		recordAPIResponseError(ctx, e.cfg, err)
@@ -797,6 +800,13 @@ func filterExcludedBetas(betas string) string {
 	return strings.Join(filtered, ",")
 }
 
+const (
+	// claudeDefaultBetas is the Anthropic-Beta value sent when the client supplies none.
+	claudeDefaultBetas = "claude-code-20250219,oauth-2025-04-20,interleaved-thinking-2025-05-14,fine-grained-tool-streaming-2025-05-14"
+	// claudePromptCachingBeta is always enabled so cache_control blocks are honoured.
+	claudePromptCachingBeta = "prompt-caching-2024-07-31"
+)
+
 func applyClaudeHeaders(r *http.Request, auth *cliproxyauth.Auth, apiKey string, stream bool, extraBetas []string) {
 	useAPIKey := auth != nil && auth.Attributes != nil && strings.TrimSpace(auth.Attributes["api_key"]) != ""
 	isAnthropicBase := r.URL != nil && strings.EqualFold(r.URL.Scheme, "https") && strings.EqualFold(r.URL.Host, "api.anthropic.com")
@@ -813,8 +823,7 @@ func applyClaudeHeaders(r *http.Request, auth *cliproxyauth.Auth, apiKey string,
 		ginHeaders = ginCtx.Request.Header
 	}
 
-	promptCachingBeta := "prompt-caching-2024-07-31"
-	baseBetas := "claude-code-20250219,oauth-2025-04-20,interleaved-thinking-2025-05-14,fine-grained-tool-streaming-2025-05-14," + promptCachingBeta
+	baseBetas := claudeDefaultBetas + "," + claudePromptCachingBeta
 	if val := strings.TrimSpace(ginHeaders.Get("Anthropic-Beta")); val != "" {
 		// Filter loại bỏ các beta không mong muốn
 		val = filterExcludedBetas(val)
@@ -825,8 +834,8 @@ func applyClaudeHeaders(r *http.Request, auth *cliproxyauth.Auth, apiKey string,
 			baseBetas += ",oauth-2025-04-20"
 		}
 	}
-	if !strings.Contains(baseBetas, promptCachingBeta) {
-		baseBetas += "," + promptCachingBeta
+	if !strings.Contains(baseBetas, claudePromptCachingBeta) {
+		baseBetas += "," + claudePromptCachingBeta
 	}
 
 	// Merge extra betas from request body
@@ -857,7 +866,7 @@ func applyClaudeHeaders(r *http.Request, auth *cliproxyauth.Auth, apiKey string,
 	}
 	r.Header.Set("Anthropic-Beta", baseBetas)
 
-	misc.EnsureHeader(r.Header, ginHeaders, "Anthropic-Version", "2023-06-01")
+	r.Header.Set("Anthropic-Version", resolveAnthropicVersion(r, auth, ginHeaders))
 	misc.EnsureHeader(r.Header, ginHeaders, "Anthropic-Dangerous-Direct-Browser-Access", "true")
 	misc.EnsureHeader(r.Header, ginHeaders, "X-App", "cli")
 	misc.EnsureHeader(r.Header, ginHeaders, "X-Stainless-Helper-Method", "stream")
@@ -1063,6 +1072,14 @@ func getCloakConfigFromAuth(auth *cliproxyauth.Auth) (string, bool, []string) {
 
 // resolveClaudeKeyCloakConfig finds the matching ClaudeKey config and returns its CloakConfig.
 func resolveClaudeKeyCloakConfig(cfg *config.Config, auth *cliproxyauth.Auth) *config.CloakConfig {
+	if entry := resolveClaudeKeyConfig(cfg, auth); entry != nil {
+		return entry.Cloak
+	}
+	return nil
+}
+
+// resolveClaudeKeyConfig finds the ClaudeKey config entry matching the auth's credentials.
+func resolveClaudeKeyConfig(cfg *config.Config, auth *cliproxyauth.Auth) *config.ClaudeKey {
 	if cfg == nil || auth == nil {
 		return nil
 	}
@@ -1083,13 +1100,54 @@ func resolveClaudeKeyCloakConfig(cfg *config.Config, auth *cliproxyauth.Auth) *c
 			if baseURL != "" && cfgBase != "" && !strings.EqualFold(cfgBase, baseURL) {
 				continue
 			}
-			return entry.Cloak
+			return entry
 		}
 	}
 
 	return nil
 }
 
+const fineGrainedToolStreamingBeta = "fine-grained-tool-streaming-2025-05-14"
+
+// claudeFineGrainedToolStreaming returns the fine-grained tool streaming setting of the
+// model alias a request targets, or nil when the alias does not configure it.
+func claudeFineGrainedToolStreaming(cfg *config.Config, auth *cliproxyauth.Auth, requestedModel, upstreamModel string) *bool {
+	entry := resolveClaudeKeyConfig(cfg, auth)
+	if entry == nil {
+		return nil
+	}
+	requestedModel = thinking.ParseSuffix(requestedModel).ModelName
+	if prefix := strings.Trim(strings.TrimSpace(entry.Prefix), "/"); prefix != "" {
+		requestedModel = strings.TrimPrefix(requestedModel, prefix+"/")
+	}
+	for i := range entry.Models {
+		model := &entry.Models[i]
+		if model.FineGrainedToolStreaming == nil {
+			continue
+		}
+		alias := strings.TrimSpace(model.Alias)
+		if strings.EqualFold(alias, requestedModel) || (alias == "" && strings.EqualFold(strings.TrimSpace(model.Name), upstreamModel)) {
+			return model.FineGrainedToolStreaming
+		}
+	}
+	return nil
+}
+
+// setClaudeBeta adds beta to or removes it from the Anthropic-Beta header.
+func setClaudeBeta(header http.Header, beta string, enabled bool) {
+	var betas []string
+	for _, existing := range strings.Split(header.Get("Anthropic-Beta"), ",") {
+		existing = strings.TrimSpace(existing)
+		if existing != "" && existing != beta {
+			betas = append(betas, existing)
+		}
+	}
+	if enabled {
+		betas = append(betas, beta)
+	}
+	header.Set("Anthropic-Beta", strings.Join(betas, ","))
+}
+
 // injectFakeUserID generates and injects a fake user ID into the request metadata.
 func injectFakeUserID(payload []byte) []byte {
 	metadata := gjson.GetBytes(payload, "metadata")
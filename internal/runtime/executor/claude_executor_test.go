@@ -348,3 +348,38 @@ func TestApplyClaudeToolPrefix_SkipsBuiltinToolReference(t *testing.T) {
 		t.Fatalf("built-in tool_reference should not be prefixed, got %q", got)
 	}
 }
+
+func TestClaudeFineGrainedToolStreamingPerAlias(t *testing.T) {
+	on, off := true, false
+	cfg := &config.Config{ClaudeKey: []config.ClaudeKey{{
+		APIKey: "sk-test",
+		Prefix: "team",
+		Models: []config.ClaudeModel{
+			{Name: "claude-sonnet-4-5", Alias: "sonnet-fast", FineGrainedToolStreaming: &on},
+			{Name: "claude-sonnet-4-5", Alias: "sonnet-safe", FineGrainedToolStreaming: &off},
+			{Name: "claude-opus-4-1", Alias: "opus"},
+		},
+	}}}
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"api_key": "sk-test"}}
+
+	if got := claudeFineGrainedToolStreaming(cfg, auth, "team/sonnet-fast(8192)", "claude-sonnet-4-5"); got == nil || !*got {
+		t.Fatalf("sonnet-fast = %v, want enabled", got)
+	}
+	if got := claudeFineGrainedToolStreaming(cfg, auth, "sonnet-safe", "claude-sonnet-4-5"); got == nil || *got {
+		t.Fatalf("sonnet-safe = %v, want disabled", got)
+	}
+	if got := claudeFineGrainedToolStreaming(cfg, auth, "opus", "claude-opus-4-1"); got != nil {
+		t.Fatalf("opus = %v, want unset", *got)
+	}
+
+	header := http.Header{}
+	header.Set("Anthropic-Beta", "claude-code-20250219,"+fineGrainedToolStreamingBeta+",prompt-caching-2024-07-31")
+	setClaudeBeta(header, fineGrainedToolStreamingBeta, false)
+	if got := header.Get("Anthropic-Beta"); got != "claude-code-20250219,prompt-caching-2024-07-31" {
+		t.Fatalf("Anthropic-Beta after disabling = %q", got)
+	}
+	setClaudeBeta(header, fineGrainedToolStreamingBeta, true)
+	if got := header.Get("Anthropic-Beta"); got != "claude-code-20250219,prompt-caching-2024-07-31,"+fineGrainedToolStreamingBeta {
+		t.Fatalf("Anthropic-Beta after enabling = %q", got)
+	}
+}
@@ -0,0 +1,42 @@
+package executor
+
+import "strings"
+
+// ProviderCompatibility describes the upstream API surface a provider executor targets
+// in this build.
+type ProviderCompatibility struct {
+	Provider string `json:"provider"`
+	// APIVersion is the upstream API version or path version sent by default.
+	APIVersion string `json:"api_version,omitempty"`
+	// ClientVersion is the official client version the executor identifies as.
+	ClientVersion string `json:"client_version,omitempty"`
+	// BetaFlags lists beta headers enabled by default.
+	BetaFlags []string `json:"beta_flags,omitempty"`
+}
+
+// ProviderCompatibilities reports the provider API versions and beta flags this build
+// supports, for bug reports and rollout gating.
+func ProviderCompatibilities() []ProviderCompatibility {
+	return []ProviderCompatibility{
+		{
+			Provider:   "claude",
+			APIVersion: defaultAnthropicVersion,
+			BetaFlags:  append(strings.Split(claudeDefaultBetas, ","), claudePromptCachingBeta),
+		},
+		{
+			Provider:      "codex",
+			ClientVersion: codexClientVersion,
+			BetaFlags:     []string{codexResponsesWebsocketBetaHeaderValue},
+		},
+		{Provider: "gemini", APIVersion: glAPIVersion},
+		{Provider: "gemini-cli", APIVersion: codeAssistVersion},
+		{Provider: "vertex", APIVersion: vertexAPIVersion},
+		{
+			Provider:      "antigravity",
+			APIVersion:    strings.TrimPrefix(strings.SplitN(antigravityGeneratePath, ":", 2)[0], "/"),
+			ClientVersion: defaultAntigravityAgent,
+		},
+		{Provider: "qwen", APIVersion: "v1", ClientVersion: qwenUserAgent},
+		{Provider: "iflow", APIVersion: "v1", ClientVersion: iflowUserAgent},
+	}
+}
@@ -0,0 +1,135 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// geminiCLIQuotaInterval throttles retrieveUserQuota lookups per account and project.
+const geminiCLIQuotaInterval = 5 * time.Minute
+
+var geminiCLIQuotaChecks = struct {
+	sync.Mutex
+	last map[string]time.Time
+}{last: make(map[string]time.Time)}
+
+// captureGeminiCLIQuotaExceeded records a 429 from the code assist endpoint as an exhausted
+// quota for the project, resetting after the delay Google reports.
+func captureGeminiCLIQuotaExceeded(source, model, projectID string, body []byte) {
+	record := usage.RateLimitRecord{
+		Source:      source,
+		Model:       model,
+		Type:        usage.RateLimitTypeQuota,
+		Project:     projectID,
+		QuotaStatus: "rejected",
+	}
+	if delay, err := parseRetryDelay(body); err == nil && delay != nil {
+		record.QuotaReset = time.Now().Add(*delay)
+	}
+	log.Infof("ratelimit: [quota] model=%s source=%s project=%s exhausted", model, source, projectID)
+	usage.GetRateLimitStore().Record(record)
+}
+
+// refreshGeminiCLIQuota looks up the remaining code assist quota of the project in the
+// background, at most once per geminiCLIQuotaInterval, and records the bucket of model.
+func refreshGeminiCLIQuota(cfg *config.Config, auth *cliproxyauth.Auth, accessToken, source, model, projectID string) {
+	if auth == nil || accessToken == "" || projectID == "" {
+		return
+	}
+	key := auth.ID + "|" + projectID
+	now := time.Now()
+	geminiCLIQuotaChecks.Lock()
+	if last, ok := geminiCLIQuotaChecks.last[key]; ok && now.Sub(last) < geminiCLIQuotaInterval {
+		geminiCLIQuotaChecks.Unlock()
+		return
+	}
+	geminiCLIQuotaChecks.last[key] = now
+	geminiCLIQuotaChecks.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+		records, err := fetchGeminiCLIQuota(ctx, newHTTPClient(ctx, cfg, auth, 0), accessToken, projectID)
+		if err != nil {
+			log.Debugf("gemini cli executor: quota lookup for project %s failed: %v", projectID, err)
+			return
+		}
+		for _, record := range records {
+			if !strings.EqualFold(record.Model, model) {
+				continue
+			}
+			record.Source = source
+			usage.GetRateLimitStore().Record(record)
+		}
+	}()
+}
+
+// fetchGeminiCLIQuota calls retrieveUserQuota and converts its buckets into records.
+func fetchGeminiCLIQuota(ctx context.Context, client *http.Client, accessToken, projectID string) ([]usage.RateLimitRecord, error) {
+	body := []byte(fmt.Sprintf(`{"project":%q}`, projectID))
+	url := fmt.Sprintf("%s/%s:retrieveUserQuota", codeAssistEndpoint, codeAssistVersion)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	applyGeminiCLIHeaders(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return parseGeminiCLIQuotaBuckets(data, projectID), nil
+}
+
+// parseGeminiCLIQuotaBuckets converts a retrieveUserQuota response into one record per
+// model bucket.
+func parseGeminiCLIQuotaBuckets(data []byte, projectID string) []usage.RateLimitRecord {
+	var records []usage.RateLimitRecord
+	now := time.Now()
+	gjson.GetBytes(data, "buckets").ForEach(func(_, bucket gjson.Result) bool {
+		model := bucket.Get("modelId").String()
+		if model == "" {
+			return true
+		}
+		remaining := bucket.Get("remainingFraction").Float()
+		status := "allowed"
+		if remaining <= 0 {
+			status = "rejected"
+		}
+		record := usage.RateLimitRecord{
+			Timestamp:         now,
+			Model:             model,
+			Type:              usage.RateLimitTypeQuota,
+			Project:           projectID,
+			RemainingFraction: remaining,
+			QuotaStatus:       status,
+		}
+		if reset, err := time.Parse(time.RFC3339, bucket.Get("resetTime").String()); err == nil {
+			record.QuotaReset = reset
+		}
+		records = append(records, record)
+		return true
+	})
+	return records
+}
@@ -0,0 +1,35 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+)
+
+func TestParseGeminiCLIQuotaBuckets(t *testing.T) {
+	data := []byte(`{"buckets":[
+		{"modelId":"gemini-2.5-pro","remainingFraction":0.25,"resetTime":"2026-01-02T03:04:05Z"},
+		{"modelId":"gemini-2.5-flash","remainingFraction":0},
+		{"remainingFraction":1}
+	]}`)
+	records := parseGeminiCLIQuotaBuckets(data, "proj-1")
+	if len(records) != 2 {
+		t.Fatalf("records = %d, want %d", len(records), 2)
+	}
+	pro := records[0]
+	if pro.Model != "gemini-2.5-pro" || pro.Project != "proj-1" || pro.Type != usage.RateLimitTypeQuota {
+		t.Fatalf("unexpected record: %+v", pro)
+	}
+	if pro.RemainingFraction != 0.25 || pro.QuotaStatus != "allowed" {
+		t.Fatalf("remaining = %v status = %q, want 0.25 allowed", pro.RemainingFraction, pro.QuotaStatus)
+	}
+	if pro.QuotaReset.IsZero() {
+		t.Fatal("expected quota reset to be parsed")
+	}
+	if records[1].QuotaStatus != "rejected" {
+		t.Fatalf("status = %q, want %q", records[1].QuotaStatus, "rejected")
+	}
+	if records[1].IsEmpty() {
+		t.Fatal("quota record should not be empty")
+	}
+}
@@ -0,0 +1,202 @@
+package executor
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// headerTemplateData is the value header templates are rendered against, e.g.
+//
+//	User-Agent: "my-cli/1.0 ({{ .Provider }})"
+//	Anthropic-Beta: "{{ .Header \"Anthropic-Beta\" }},my-beta-2025-01-01"
+//	X-Client-Version: "{{ .ClientHeader \"X-Client-Version\" }}"
+//	X-Team: "{{ .Env \"TEAM_ID\" }}"
+type headerTemplateData struct {
+	Provider  string
+	AuthID    string
+	AuthLabel string
+	Account   string
+	Method    string
+	Host      string
+	Path      string
+
+	outgoing http.Header
+	incoming http.Header
+}
+
+// Header returns the value the executor set for name on the outgoing request.
+func (d headerTemplateData) Header(name string) string { return d.outgoing.Get(name) }
+
+// ClientHeader returns the value of name on the downstream client request, if any.
+func (d headerTemplateData) ClientHeader(name string) string {
+	if d.incoming == nil {
+		return ""
+	}
+	return d.incoming.Get(name)
+}
+
+// Env returns the value of the environment variable name.
+func (d headerTemplateData) Env(name string) string { return os.Getenv(name) }
+
+var headerTemplateCache sync.Map // template source -> *template.Template
+
+// resolveHeaderTemplate returns the header template configured for the backend of auth.
+func resolveHeaderTemplate(cfg *config.Config, auth *cliproxyauth.Auth) (config.HeaderTemplate, bool) {
+	if cfg == nil || auth == nil || len(cfg.HeaderTemplates) == 0 {
+		return config.HeaderTemplate{}, false
+	}
+	candidates := []string{auth.Provider}
+	if auth.Attributes != nil {
+		candidates = append(candidates, auth.Attributes["compat_name"], auth.Attributes["provider_key"])
+	}
+	for _, candidate := range candidates {
+		key := strings.ToLower(strings.TrimSpace(candidate))
+		if key == "" {
+			continue
+		}
+		if tmpl, ok := cfg.HeaderTemplates[key]; ok {
+			return tmpl, true
+		}
+	}
+	return config.HeaderTemplate{}, false
+}
+
+// headerTemplateTransport applies a header template to every request it forwards.
+type headerTemplateTransport struct {
+	base     http.RoundTripper
+	tmpl     config.HeaderTemplate
+	provider string
+	auth     *cliproxyauth.Auth
+}
+
+// wrapHeaderTemplateTransport decorates base with the header template of auth's backend,
+// or returns base unchanged when none is configured.
+func wrapHeaderTemplateTransport(base http.RoundTripper, cfg *config.Config, auth *cliproxyauth.Auth) http.RoundTripper {
+	tmpl, ok := resolveHeaderTemplate(cfg, auth)
+	if !ok {
+		return base
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &headerTemplateTransport{base: base, tmpl: tmpl, provider: strings.ToLower(strings.TrimSpace(auth.Provider)), auth: auth}
+}
+
+func (t *headerTemplateTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	out := req.Clone(req.Context())
+	applyHeaderTemplate(out, t.tmpl, t.templateData(out))
+	return t.base.RoundTrip(out)
+}
+
+func (t *headerTemplateTransport) templateData(req *http.Request) headerTemplateData {
+	data := headerTemplateData{
+		Provider: t.provider,
+		Method:   req.Method,
+		outgoing: req.Header.Clone(),
+	}
+	if req.URL != nil {
+		data.Host = req.URL.Host
+		data.Path = req.URL.Path
+	}
+	if t.auth != nil {
+		data.AuthID = t.auth.ID
+		data.AuthLabel = t.auth.Label
+		_, data.Account = t.auth.AccountInfo()
+	}
+	if ginCtx, ok := req.Context().Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+		data.incoming = ginCtx.Request.Header
+	}
+	return data
+}
+
+// unforwardableHeaders are client request headers Forward never copies upstream: client
+// credentials for this proxy and headers owned by the HTTP transport.
+var unforwardableHeaders = map[string]struct{}{
+	"authorization":       {},
+	"proxy-authorization": {},
+	"x-api-key":           {},
+	"x-goog-api-key":      {},
+	"cookie":              {},
+	"host":                {},
+	"connection":          {},
+	"keep-alive":          {},
+	"te":                  {},
+	"trailer":             {},
+	"transfer-encoding":   {},
+	"upgrade":             {},
+	"content-length":      {},
+	"content-encoding":    {},
+	"accept-encoding":     {},
+}
+
+// forwardClientHeaders copies the downstream headers matching patterns onto req.
+func forwardClientHeaders(req *http.Request, patterns []string, incoming http.Header) {
+	if len(patterns) == 0 || len(incoming) == 0 {
+		return
+	}
+	for name, values := range incoming {
+		lower := strings.ToLower(name)
+		if _, blocked := unforwardableHeaders[lower]; blocked {
+			continue
+		}
+		for _, pattern := range patterns {
+			matched := lower == pattern
+			if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+				matched = strings.HasPrefix(lower, prefix)
+			}
+			if matched {
+				req.Header[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+				break
+			}
+		}
+	}
+}
+
+// applyHeaderTemplate copies the Forward client headers, renders the Set values onto req
+// and then removes the Remove headers.
+func applyHeaderTemplate(req *http.Request, tmpl config.HeaderTemplate, data headerTemplateData) {
+	forwardClientHeaders(req, tmpl.Forward, data.incoming)
+	for name, source := range tmpl.Set {
+		value, err := renderHeaderTemplate(source, data)
+		if err != nil {
+			log.Warnf("header template %s for %s: %v", name, data.Provider, err)
+			continue
+		}
+		value = strings.Trim(strings.TrimSpace(value), ",")
+		if value == "" {
+			req.Header.Del(name)
+			continue
+		}
+		req.Header.Set(name, value)
+	}
+	for _, name := range tmpl.Remove {
+		req.Header.Del(name)
+	}
+}
+
+func renderHeaderTemplate(source string, data headerTemplateData) (string, error) {
+	var parsed *template.Template
+	if cached, ok := headerTemplateCache.Load(source); ok {
+		parsed = cached.(*template.Template)
+	} else {
+		var err error
+		parsed, err = template.New("header").Option("missingkey=zero").Parse(source)
+		if err != nil {
+			return "", err
+		}
+		headerTemplateCache.Store(source, parsed)
+	}
+	var b strings.Builder
+	if err := parsed.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
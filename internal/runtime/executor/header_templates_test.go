@@ -0,0 +1,117 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestHeaderTemplateTransportAppliesTemplate(t *testing.T) {
+	t.Setenv("HEADER_TEMPLATE_TEAM", "team-7")
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{HeaderTemplates: map[string]config.HeaderTemplate{
+		"claude": {
+			Set: map[string]string{
+				"User-Agent":     "proxy/{{ .Provider }} ({{ .AuthLabel }})",
+				"Anthropic-Beta": `{{ .Header "Anthropic-Beta" }},extra-beta`,
+				"X-Team":         `{{ .Env "HEADER_TEMPLATE_TEAM" }}`,
+				"X-Empty":        `{{ .ClientHeader "X-Missing" }}`,
+			},
+			Remove: []string{"X-Drop"},
+		},
+	}}
+	cfg.SanitizeHeaderTemplates()
+	auth := &cliproxyauth.Auth{ID: "a1", Provider: "claude", Label: "work"}
+
+	req, _ := http.NewRequest(http.MethodPost, server.URL, nil)
+	req.Header.Set("Anthropic-Beta", "base-beta")
+	req.Header.Set("X-Drop", "1")
+	req.Header.Set("X-Empty", "set-by-executor")
+	resp, err := newProxyAwareHTTPClient(req.Context(), cfg, auth, 0).Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if v := got.Get("User-Agent"); v != "proxy/claude (work)" {
+		t.Fatalf("User-Agent = %q", v)
+	}
+	if v := got.Get("Anthropic-Beta"); v != "base-beta,extra-beta" {
+		t.Fatalf("Anthropic-Beta = %q", v)
+	}
+	if v := got.Get("X-Team"); v != "team-7" {
+		t.Fatalf("X-Team = %q", v)
+	}
+	if got.Get("X-Empty") != "" || got.Get("X-Drop") != "" {
+		t.Fatalf("expected X-Empty and X-Drop to be removed, got %v", got)
+	}
+	if req.Header.Get("X-Drop") != "1" {
+		t.Fatal("original request headers must not be modified")
+	}
+}
+
+func TestSanitizeHeaderTemplatesDropsInvalid(t *testing.T) {
+	cfg := &config.Config{HeaderTemplates: map[string]config.HeaderTemplate{
+		" Codex ": {Set: map[string]string{"version": "{{ .Env ", "x-ok": "1"}},
+		"qwen":    {Set: map[string]string{"x-bad": "{{"}},
+	}}
+	cfg.SanitizeHeaderTemplates()
+	codex, ok := cfg.HeaderTemplates["codex"]
+	if !ok || len(codex.Set) != 1 || codex.Set["X-Ok"] != "1" {
+		t.Fatalf("codex template = %+v", codex)
+	}
+	if _, ok := cfg.HeaderTemplates["qwen"]; ok {
+		t.Fatal("expected empty qwen template to be dropped")
+	}
+}
+
+func TestHeaderTemplateTransportForwardsClientHeaders(t *testing.T) {
+	var got http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{HeaderTemplates: map[string]config.HeaderTemplate{
+		"codex": {Forward: []string{"X-Request-Id", "traceparent", "x-metadata-*", "Authorization"}},
+	}}
+	cfg.SanitizeHeaderTemplates()
+	auth := &cliproxyauth.Auth{ID: "a1", Provider: "codex"}
+
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/responses", nil)
+	ginCtx.Request.Header.Set("X-Request-Id", "req-42")
+	ginCtx.Request.Header.Set("Traceparent", "00-abc-def-01")
+	ginCtx.Request.Header.Set("X-Metadata-Team", "infra")
+	ginCtx.Request.Header.Set("X-Other", "ignored")
+	ginCtx.Request.Header.Set("Authorization", "Bearer client-key")
+
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodPost, server.URL, nil)
+	req.Header.Set("Authorization", "Bearer upstream-key")
+	resp, err := newProxyAwareHTTPClient(ctx, cfg, auth, 0).Do(req)
+	if err != nil {
+		t.Fatalf("Do: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if got.Get("X-Request-Id") != "req-42" || got.Get("Traceparent") != "00-abc-def-01" || got.Get("X-Metadata-Team") != "infra" {
+		t.Fatalf("expected correlation headers to be forwarded, got %v", got)
+	}
+	if got.Get("X-Other") != "" {
+		t.Fatalf("expected unlisted header to stay local, got %v", got)
+	}
+	if v := got.Get("Authorization"); v != "Bearer upstream-key" {
+		t.Fatalf("Authorization = %q, client credentials must not be forwarded", v)
+	}
+}
@@ -22,6 +22,10 @@ const (
 	apiAttemptsKey = "API_UPSTREAM_ATTEMPTS"
 	apiRequestKey  = "API_REQUEST"
 	apiResponseKey = "API_RESPONSE"
+	// errorSampleRequestKey holds the latest upstream request so a 4xx answer can be sampled.
+	errorSampleRequestKey = "API_ERROR_SAMPLE_REQUEST"
+	// errorSampleIDKey holds the error sample opened for the latest upstream response.
+	errorSampleIDKey = "API_ERROR_SAMPLE_ID"
 )
 
 // upstreamRequestLog captures the outbound upstream request details for logging.
@@ -51,11 +55,32 @@ type upstreamAttempt struct {
 
 // recordAPIRequest stores the upstream request metadata in Gin context for request logging.
 func recordAPIRequest(ctx context.Context, cfg *config.Config, info upstreamRequestLog) {
-	if cfg == nil || !cfg.RequestLog {
-		return
+	if apiKey := apiKeyFromContext(ctx); logging.ConsumePayloadDump(apiKey) {
+		logging.PayloadDumpEntry(logWithRequestID(ctx)).Debugf("payload dump for %s: %s %s\n%s", util.HideAPIKey(apiKey), info.Method, info.URL, info.Body)
+	}
+	if apiKey := apiKeyFromContext(ctx); payloadLogRequests(apiKey) {
+		logging.WritePayload(logging.PayloadRecord{
+			RequestID: logging.GetRequestID(ctx),
+			Direction: "request",
+			Method:    info.Method,
+			URL:       info.URL,
+		}, apiKey, info.Headers, info.Body)
 	}
 	ginCtx := ginContextFrom(ctx)
-	if ginCtx == nil {
+	if ginCtx != nil {
+		ginCtx.Set(errorSampleRequestKey, logging.ErrorSampleRequest{
+			RequestID: logging.GetRequestID(ctx),
+			APIKey:    apiKeyFromContext(ctx),
+			Provider:  info.Provider,
+			AuthID:    info.AuthID,
+			Method:    info.Method,
+			URL:       info.URL,
+			Headers:   info.Headers,
+			Body:      info.Body,
+		})
+		ginCtx.Set(errorSampleIDKey, uint64(0))
+	}
+	if cfg == nil || !cfg.RequestLog || ginCtx == nil {
 		return
 	}
 
@@ -98,11 +123,16 @@ func recordAPIRequest(ctx context.Context, cfg *config.Config, info upstreamRequ
 
 // recordAPIResponseMetadata captures upstream response status/header information for the latest attempt.
 func recordAPIResponseMetadata(ctx context.Context, cfg *config.Config, status int, headers http.Header) {
-	if cfg == nil || !cfg.RequestLog {
-		return
+	if apiKey := apiKeyFromContext(ctx); payloadLogResponses(apiKey) {
+		logging.WritePayload(logging.PayloadRecord{
+			RequestID: logging.GetRequestID(ctx),
+			Direction: "response",
+			Status:    status,
+		}, apiKey, headers, nil)
 	}
 	ginCtx := ginContextFrom(ctx)
-	if ginCtx == nil {
+	recordErrorSample(ginCtx, status, headers)
+	if cfg == nil || !cfg.RequestLog || ginCtx == nil {
 		return
 	}
 	attempts, attempt := ensureAttempt(ginCtx)
@@ -149,17 +179,25 @@ func recordAPIResponseError(ctx context.Context, cfg *config.Config, err error)
 
 // appendAPIResponseChunk appends an upstream response chunk to Gin context for request logging.
 func appendAPIResponseChunk(ctx context.Context, cfg *config.Config, chunk []byte) {
-	if cfg == nil || !cfg.RequestLog {
+	if apiKey := apiKeyFromContext(ctx); payloadLogResponses(apiKey) {
+		logging.WritePayload(logging.PayloadRecord{
+			RequestID: logging.GetRequestID(ctx),
+			Direction: "response_body",
+		}, apiKey, nil, chunk)
+	}
+	ginCtx := ginContextFrom(ctx)
+	if ginCtx != nil {
+		if id, ok := ginCtx.Value(errorSampleIDKey).(uint64); ok {
+			logging.AppendErrorSampleBody(id, chunk)
+		}
+	}
+	if cfg == nil || !cfg.RequestLog || ginCtx == nil {
 		return
 	}
 	data := bytes.TrimSpace(chunk)
 	if len(data) == 0 {
 		return
 	}
-	ginCtx := ginContextFrom(ctx)
-	if ginCtx == nil {
-		return
-	}
 	attempts, attempt := ensureAttempt(ginCtx)
 	ensureResponseIntro(attempt)
 
@@ -182,6 +220,22 @@ func appendAPIResponseChunk(ctx context.Context, cfg *config.Config, chunk []byt
 	updateAggregatedResponse(ginCtx, attempts)
 }
 
+// recordErrorSample opens an error sample for a 4xx upstream response, independently of
+// request logging, so rejected translated payloads can be inspected later.
+func recordErrorSample(ginCtx *gin.Context, status int, headers http.Header) {
+	if ginCtx == nil || status < http.StatusBadRequest || status >= http.StatusInternalServerError {
+		return
+	}
+	req, ok := ginCtx.Value(errorSampleRequestKey).(logging.ErrorSampleRequest)
+	if !ok {
+		return
+	}
+	if id, _ := ginCtx.Value(errorSampleIDKey).(uint64); id != 0 {
+		return
+	}
+	ginCtx.Set(errorSampleIDKey, logging.RecordErrorSample(req, status, headers))
+}
+
 func ginContextFrom(ctx context.Context) *gin.Context {
 	ginCtx, _ := ctx.Value("gin").(*gin.Context)
 	return ginCtx
@@ -377,6 +431,18 @@ func extractJSONErrorMessage(body []byte) string {
 	return ""
 }
 
+// payloadLogRequests reports whether upstream requests of apiKey go to the payload log.
+func payloadLogRequests(apiKey string) bool {
+	requests, _ := logging.PayloadLogEnabled(apiKey)
+	return requests
+}
+
+// payloadLogResponses reports whether upstream responses of apiKey go to the payload log.
+func payloadLogResponses(apiKey string) bool {
+	requests, responses := logging.PayloadLogEnabled(apiKey)
+	return requests && responses
+}
+
 // logWithRequestID returns a logrus Entry with request_id field populated from context.
 // If no request ID is found in context, it returns the standard logger.
 func logWithRequestID(ctx context.Context) *log.Entry {
@@ -0,0 +1,285 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// MockExecutor answers requests locally with canned or scripted responses from the
+// mock-backend config. Responses are built in OpenAI chat format and translated back
+// to the client format, so the full translation stack is exercised without credentials.
+type MockExecutor struct {
+	cfg *config.Config
+}
+
+// NewMockExecutor creates an executor for the built-in "mock" provider.
+func NewMockExecutor(cfg *config.Config) *MockExecutor {
+	return &MockExecutor{cfg: cfg}
+}
+
+// Identifier implements cliproxyauth.ProviderExecutor.
+func (e *MockExecutor) Identifier() string { return "mock" }
+
+// HttpRequest is not supported because the mock provider has no upstream.
+func (e *MockExecutor) HttpRequest(context.Context, *cliproxyauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("mock executor: raw HTTP requests not supported")
+}
+
+func (e *MockExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+
+	rule := e.resolveRule(baseModel, translated)
+	if err = mockRuleError(rule); err != nil {
+		return resp, err
+	}
+	body := mockCompletion(baseModel, rule, mockUsage(baseModel, translated, rule))
+	reporter.publish(ctx, parseOpenAIUsage(body))
+
+	var param any
+	out := sdktranslator.TranslateNonStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, body, &param)
+	return cliproxyexecutor.Response{Payload: []byte(out)}, nil
+}
+
+func (e *MockExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (_ *cliproxyexecutor.StreamResult, err error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	reporter := newUsageReporter(ctx, e.Identifier(), baseModel, auth)
+	defer reporter.trackFailure(ctx, &err)
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, true)
+
+	rule := e.resolveRule(baseModel, translated)
+	if err = mockRuleError(rule); err != nil {
+		return nil, err
+	}
+	usageJSON := mockUsage(baseModel, translated, rule)
+	lines := mockStreamLines(baseModel, rule, usageJSON)
+	var delay time.Duration
+	if e.cfg != nil && e.cfg.MockBackend.ChunkDelayMS > 0 {
+		delay = time.Duration(e.cfg.MockBackend.ChunkDelayMS) * time.Millisecond
+	}
+
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		var param any
+		for i, line := range lines {
+			if delay > 0 && i > 0 {
+				select {
+				case <-ctx.Done():
+					reporter.publishCancelled(ctx)
+					return
+				case <-time.After(delay):
+				}
+			}
+			chunks := sdktranslator.TranslateStream(ctx, to, from, req.Model, opts.OriginalRequest, translated, line, &param)
+			for j := range chunks {
+				out <- cliproxyexecutor.StreamChunk{Payload: []byte(chunks[j])}
+			}
+		}
+		reporter.publish(ctx, parseOpenAIUsage([]byte(`{"usage":`+usageJSON+`}`)))
+	}()
+	return &cliproxyexecutor.StreamResult{Chunks: out}, nil
+}
+
+func (e *MockExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	baseModel := thinking.ParseSuffix(req.Model).ModelName
+
+	from := opts.SourceFormat
+	to := sdktranslator.FromString("openai")
+	translated := sdktranslator.TranslateRequest(from, to, baseModel, req.Payload, false)
+
+	enc, err := tokenizerForModel(baseModel)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("mock executor: tokenizer init failed: %w", err)
+	}
+	count, err := countOpenAIChatTokens(enc, translated)
+	if err != nil {
+		return cliproxyexecutor.Response{}, fmt.Errorf("mock executor: token counting failed: %w", err)
+	}
+
+	usageJSON := buildOpenAIUsageJSON(count)
+	translatedUsage := sdktranslator.TranslateTokenCount(ctx, to, from, count, usageJSON)
+	return cliproxyexecutor.Response{Payload: []byte(translatedUsage)}, nil
+}
+
+// Refresh is a no-op for the mock provider.
+func (e *MockExecutor) Refresh(_ context.Context, auth *cliproxyauth.Auth) (*cliproxyauth.Auth, error) {
+	return auth, nil
+}
+
+// resolveRule returns the first configured rule matching the model and the last user
+// message, or an echo of that message when no rule matches.
+func (e *MockExecutor) resolveRule(model string, payload []byte) config.MockRule {
+	text := mockLastUserText(payload)
+	if e.cfg != nil {
+		for _, rule := range e.cfg.MockBackend.Rules {
+			if pattern := strings.TrimSpace(rule.Model); pattern != "" {
+				if ok, _ := path.Match(pattern, model); !ok {
+					continue
+				}
+			}
+			if rule.Contains != "" && !strings.Contains(text, rule.Contains) {
+				continue
+			}
+			return rule
+		}
+	}
+	return config.MockRule{Text: text}
+}
+
+func mockRuleError(rule config.MockRule) error {
+	if rule.Status == 0 || (rule.Status >= 200 && rule.Status < 300) {
+		return nil
+	}
+	msg := rule.Error
+	if msg == "" {
+		msg = fmt.Sprintf(`{"error":{"message":"mock upstream error","code":%d}}`, rule.Status)
+	}
+	return statusErr{code: rule.Status, msg: msg}
+}
+
+func mockLastUserText(payload []byte) string {
+	messages := gjson.GetBytes(payload, "messages").Array()
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Get("role").String() != "user" {
+			continue
+		}
+		content := messages[i].Get("content")
+		if content.Type == gjson.String {
+			return content.String()
+		}
+		var parts []string
+		content.ForEach(func(_, part gjson.Result) bool {
+			if part.Get("type").String() == "text" {
+				parts = append(parts, part.Get("text").String())
+			}
+			return true
+		})
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
+
+// mockUsage returns an OpenAI chat usage object with prompt tokens counted from the
+// request and completion tokens counted from the scripted reply.
+func mockUsage(model string, payload []byte, rule config.MockRule) string {
+	var prompt, completion, reasoning int64
+	if enc, err := tokenizerForModel(model); err == nil {
+		prompt, _ = countOpenAIChatTokens(enc, payload)
+		if n, errCount := enc.Count(rule.Text); errCount == nil {
+			completion = int64(n)
+		}
+		if n, errCount := enc.Count(rule.Thinking); errCount == nil {
+			reasoning = int64(n)
+		}
+		for _, call := range rule.ToolCalls {
+			if n, errCount := enc.Count(call.Name + mockToolArguments(call)); errCount == nil {
+				completion += int64(n)
+			}
+		}
+	}
+	completion += reasoning
+	return fmt.Sprintf(`{"prompt_tokens":%d,"completion_tokens":%d,"total_tokens":%d,"completion_tokens_details":{"reasoning_tokens":%d}}`, prompt, completion, prompt+completion, reasoning)
+}
+
+func mockToolArguments(call config.MockToolCall) string {
+	if args := strings.TrimSpace(call.Arguments); args != "" {
+		return args
+	}
+	return "{}"
+}
+
+func mockFinishReason(rule config.MockRule) string {
+	if len(rule.ToolCalls) > 0 {
+		return "tool_calls"
+	}
+	return "stop"
+}
+
+func mockCompletion(model string, rule config.MockRule, usageJSON string) []byte {
+	out := []byte(`{"id":"chatcmpl-mock","object":"chat.completion","created":0,"model":"","choices":[{"index":0,"message":{"role":"assistant","content":""},"finish_reason":""}]}`)
+	out, _ = sjson.SetBytes(out, "created", time.Now().Unix())
+	out, _ = sjson.SetBytes(out, "model", model)
+	out, _ = sjson.SetBytes(out, "choices.0.message.content", rule.Text)
+	if rule.Thinking != "" {
+		out, _ = sjson.SetBytes(out, "choices.0.message.reasoning_content", rule.Thinking)
+	}
+	for i, call := range rule.ToolCalls {
+		out, _ = sjson.SetRawBytes(out, fmt.Sprintf("choices.0.message.tool_calls.%d", i), mockToolCallJSON(i, call))
+	}
+	out, _ = sjson.SetBytes(out, "choices.0.finish_reason", mockFinishReason(rule))
+	out, _ = sjson.SetRawBytes(out, "usage", []byte(usageJSON))
+	return out
+}
+
+func mockToolCallJSON(index int, call config.MockToolCall) []byte {
+	out := []byte(`{"id":"","type":"function","function":{"name":"","arguments":""}}`)
+	out, _ = sjson.SetBytes(out, "id", fmt.Sprintf("call_mock_%d", index))
+	out, _ = sjson.SetBytes(out, "function.name", call.Name)
+	out, _ = sjson.SetBytes(out, "function.arguments", mockToolArguments(call))
+	return out
+}
+
+// mockStreamLines renders the rule as OpenAI chat completion SSE lines: a role chunk,
+// word-sized reasoning and content deltas, one delta per tool call, the finish reason,
+// a usage chunk and the [DONE] marker.
+func mockStreamLines(model string, rule config.MockRule, usageJSON string) [][]byte {
+	base := []byte(`{"id":"chatcmpl-mock","object":"chat.completion.chunk","created":0,"model":"","choices":[{"index":0,"delta":{},"finish_reason":null}]}`)
+	base, _ = sjson.SetBytes(base, "created", time.Now().Unix())
+	base, _ = sjson.SetBytes(base, "model", model)
+
+	lines := make([][]byte, 0, 8)
+	emit := func(chunk []byte) {
+		lines = append(lines, append([]byte("data: "), chunk...))
+	}
+	delta := func(field string, value any) {
+		chunk, _ := sjson.SetBytes(base, "choices.0.delta."+field, value)
+		emit(chunk)
+	}
+
+	delta("role", "assistant")
+	for _, word := range strings.SplitAfter(rule.Thinking, " ") {
+		if word != "" {
+			delta("reasoning_content", word)
+		}
+	}
+	for _, word := range strings.SplitAfter(rule.Text, " ") {
+		if word != "" {
+			delta("content", word)
+		}
+	}
+	for i, call := range rule.ToolCalls {
+		toolCall, _ := sjson.SetBytes(mockToolCallJSON(i, call), "index", i)
+		chunk, _ := sjson.SetRawBytes(base, "choices.0.delta.tool_calls", append(append([]byte("["), toolCall...), ']'))
+		emit(chunk)
+	}
+	finish, _ := sjson.SetBytes(base, "choices.0.finish_reason", mockFinishReason(rule))
+	emit(finish)
+	usageChunk, _ := sjson.SetRawBytes(base, "choices", []byte("[]"))
+	usageChunk, _ = sjson.SetRawBytes(usageChunk, "usage", []byte(usageJSON))
+	emit(usageChunk)
+	lines = append(lines, []byte("data: [DONE]"))
+	return lines
+}
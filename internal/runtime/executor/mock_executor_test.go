@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func newTestMockExecutor() *MockExecutor {
+	return NewMockExecutor(&config.Config{MockBackend: config.MockBackendConfig{
+		Enabled: true,
+		Rules: []config.MockRule{
+			{Contains: "weather", Thinking: "Need the tool.", ToolCalls: []config.MockToolCall{{Name: "get_weather", Arguments: `{"city":"Paris"}`}}},
+			{Contains: "fail", Status: http.StatusServiceUnavailable},
+			{Model: "mock-*", Text: "Hello from mock."},
+		},
+	}})
+}
+
+func TestMockExecutorNonStreamRules(t *testing.T) {
+	executor := newTestMockExecutor()
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("openai")}
+
+	resp, err := executor.Execute(context.Background(), &cliproxyauth.Auth{}, cliproxyexecutor.Request{
+		Model:   "mock-model",
+		Payload: []byte(`{"model":"mock-model","messages":[{"role":"user","content":"hi"}]}`),
+	}, opts)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "Hello from mock." {
+		t.Fatalf("content = %q, payload = %s", got, resp.Payload)
+	}
+
+	resp, err = executor.Execute(context.Background(), &cliproxyauth.Auth{}, cliproxyexecutor.Request{
+		Model:   "other-model",
+		Payload: []byte(`{"model":"other-model","messages":[{"role":"user","content":"echo me"}]}`),
+	}, opts)
+	if err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	if got := gjson.GetBytes(resp.Payload, "choices.0.message.content").String(); got != "echo me" {
+		t.Fatalf("content = %q, want echo of last user message", got)
+	}
+
+	_, err = executor.Execute(context.Background(), &cliproxyauth.Auth{}, cliproxyexecutor.Request{
+		Model:   "mock-model",
+		Payload: []byte(`{"model":"mock-model","messages":[{"role":"user","content":"please fail"}]}`),
+	}, opts)
+	var se statusErr
+	if !errors.As(err, &se) || se.StatusCode() != http.StatusServiceUnavailable {
+		t.Fatalf("error = %v, want scripted 503", err)
+	}
+}
+
+func TestMockExecutorStreamClaudeToolCall(t *testing.T) {
+	executor := newTestMockExecutor()
+	payload := []byte(`{"model":"mock-model","max_tokens":64,"stream":true,"messages":[{"role":"user","content":"what is the weather?"}]}`)
+	result, err := executor.ExecuteStream(context.Background(), &cliproxyauth.Auth{}, cliproxyexecutor.Request{
+		Model:   "mock-model",
+		Payload: payload,
+	}, cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("claude"), OriginalRequest: payload, Stream: true})
+	if err != nil {
+		t.Fatalf("ExecuteStream error: %v", err)
+	}
+	var sb strings.Builder
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Fatalf("stream error: %v", chunk.Err)
+		}
+		sb.Write(chunk.Payload)
+	}
+	stream := sb.String()
+	for _, want := range []string{`"type":"thinking"`, `"name":"get_weather"`, `"stop_reason":"tool_use"`, "message_stop"} {
+		if !strings.Contains(stream, want) {
+			t.Fatalf("stream missing %s:\n%s", want, stream)
+		}
+	}
+}
@@ -0,0 +1,313 @@
+package executor
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// modelParameterPaths maps the provider-neutral parameters onto a protocol's payload.
+// An empty path means the protocol has no such field.
+type modelParameterPaths struct {
+	temperature string
+	topP        string
+	maxTokens   []string // first existing path wins; the first one is used when none exist
+	stop        string
+}
+
+func modelParameterPathsFor(protocol string) (modelParameterPaths, bool) {
+	switch protocol {
+	case "openai":
+		return modelParameterPaths{temperature: "temperature", topP: "top_p", maxTokens: []string{"max_tokens", "max_completion_tokens"}, stop: "stop"}, true
+	case "openai-response", "codex":
+		return modelParameterPaths{temperature: "temperature", topP: "top_p", maxTokens: []string{"max_output_tokens"}}, true
+	case "claude":
+		return modelParameterPaths{temperature: "temperature", topP: "top_p", maxTokens: []string{"max_tokens"}, stop: "stop_sequences"}, true
+	case "gemini", "gemini-cli", "antigravity":
+		return modelParameterPaths{
+			temperature: "generationConfig.temperature",
+			topP:        "generationConfig.topP",
+			maxTokens:   []string{"generationConfig.maxOutputTokens"},
+			stop:        "generationConfig.stopSequences",
+		}, true
+	default:
+		return modelParameterPaths{}, false
+	}
+}
+
+// applyModelParameters resolves the model-parameters rules for a translated payload.
+// Defaults of all matching rules are applied first, where the first rule to supply a
+// missing value wins. Overrides and clamps then run in rule order so later rules win;
+// within a rule the override replaces the client value and the clamp bounds the
+// result, giving the precedence default < client < override < clamp. root has the same
+// meaning as in applyPayloadConfigWithRoot.
+func applyModelParameters(rules []config.ModelParameterRule, model, protocol, root string, payload []byte, requestedModel string) []byte {
+	if len(rules) == 0 || len(payload) == 0 {
+		return payload
+	}
+	paths, ok := modelParameterPathsFor(protocol)
+	if !ok {
+		return payload
+	}
+	candidates := payloadModelCandidates(model, requestedModel)
+	if len(candidates) == 0 {
+		return payload
+	}
+	out := payload
+	matched := make([]*config.ModelParameterRule, 0, len(rules))
+	for i := range rules {
+		if payloadModelRulesMatch(rules[i].Models, protocol, candidates) {
+			matched = append(matched, &rules[i])
+		}
+	}
+	for _, rule := range matched {
+		out = defaultModelParameters(out, rule.Default, paths, protocol, root)
+	}
+	for _, rule := range matched {
+		out = overrideModelParameters(out, rule.Override, paths, protocol, root)
+		out = clampModelParameters(out, rule.Clamp, paths, protocol, root)
+	}
+	if protocol == "claude" && len(matched) > 0 {
+		out = fitClaudeThinkingBudget(out)
+	}
+	return out
+}
+
+// claudeMinThinkingBudget is the smallest thinking budget Claude accepts.
+const claudeMinThinkingBudget = 1024
+
+// fitClaudeThinkingBudget keeps thinking.budget_tokens below max_tokens, which Claude
+// requires. Like the thinking module it lowers the budget to max_tokens-1; when that
+// would fall under Claude's minimum budget, max_tokens is raised above the budget instead.
+func fitClaudeThinkingBudget(out []byte) []byte {
+	budget := gjson.GetBytes(out, "thinking.budget_tokens").Int()
+	maxTokens := gjson.GetBytes(out, "max_tokens").Int()
+	if budget <= 0 || maxTokens <= 0 || budget < maxTokens {
+		return out
+	}
+	if maxTokens-1 >= claudeMinThinkingBudget {
+		return setPayloadValue(out, "thinking.budget_tokens", maxTokens-1)
+	}
+	return setPayloadValue(out, "max_tokens", budget+1)
+}
+
+// defaultModelParameters fills parameters absent from the payload. Thinking counts as
+// present when the payload carries any thinking setting in the protocol's native form,
+// including one derived from a model-name suffix.
+func defaultModelParameters(out []byte, params config.ModelParameters, paths modelParameterPaths, protocol, root string) []byte {
+	if params.Temperature != nil && paths.temperature != "" {
+		out = setPayloadDefault(out, buildPayloadPath(root, paths.temperature), *params.Temperature)
+	}
+	if params.TopP != nil && paths.topP != "" {
+		out = setPayloadDefault(out, buildPayloadPath(root, paths.topP), *params.TopP)
+	}
+	if params.MaxTokens != nil {
+		out = setPayloadDefault(out, maxTokensPath(out, paths, root), *params.MaxTokens)
+	}
+	if len(params.Stop) > 0 && paths.stop != "" {
+		out = setPayloadDefault(out, buildPayloadPath(root, paths.stop), params.Stop)
+	}
+	if params.ThinkingBudget != nil && !hasThinkingSetting(out, protocol, root) {
+		out = setThinkingBudget(out, *params.ThinkingBudget, protocol, root)
+	}
+	return out
+}
+
+// hasThinkingSetting reports whether the payload already selects a thinking mode.
+func hasThinkingSetting(out []byte, protocol, root string) bool {
+	switch protocol {
+	case "claude":
+		return gjson.GetBytes(out, "thinking").Exists()
+	case "gemini", "gemini-cli", "antigravity":
+		cfg := gjson.GetBytes(out, buildPayloadPath(root, "generationConfig.thinkingConfig"))
+		return cfg.Get("thinkingBudget").Exists() || cfg.Get("thinkingLevel").Exists()
+	case "openai":
+		return gjson.GetBytes(out, "reasoning_effort").Exists()
+	case "openai-response", "codex":
+		return gjson.GetBytes(out, "reasoning.effort").Exists()
+	}
+	return true
+}
+
+func overrideModelParameters(out []byte, params config.ModelParameters, paths modelParameterPaths, protocol, root string) []byte {
+	if params.Temperature != nil && paths.temperature != "" {
+		out = setPayloadValue(out, buildPayloadPath(root, paths.temperature), *params.Temperature)
+	}
+	if params.TopP != nil && paths.topP != "" {
+		out = setPayloadValue(out, buildPayloadPath(root, paths.topP), *params.TopP)
+	}
+	if params.MaxTokens != nil {
+		out = setPayloadValue(out, maxTokensPath(out, paths, root), *params.MaxTokens)
+	}
+	if params.Stop != nil && paths.stop != "" {
+		stopPath := buildPayloadPath(root, paths.stop)
+		if len(params.Stop) == 0 {
+			out = deletePayloadValue(out, stopPath)
+		} else {
+			out = setPayloadValue(out, stopPath, params.Stop)
+		}
+	}
+	if params.ThinkingBudget != nil {
+		out = setThinkingBudget(out, *params.ThinkingBudget, protocol, root)
+	}
+	return out
+}
+
+func clampModelParameters(out []byte, clamp config.ModelParameterClamp, paths modelParameterPaths, protocol, root string) []byte {
+	if paths.temperature != "" {
+		out = clampPayloadNumber(out, buildPayloadPath(root, paths.temperature), clamp.Temperature, false)
+	}
+	if paths.topP != "" {
+		out = clampPayloadNumber(out, buildPayloadPath(root, paths.topP), clamp.TopP, false)
+	}
+	out = clampPayloadNumber(out, maxTokensPath(out, paths, root), clamp.MaxTokens, true)
+	if !clamp.ThinkingBudget.IsZero() {
+		out = clampThinkingBudget(out, clamp.ThinkingBudget, protocol, root)
+	}
+	return out
+}
+
+// maxTokensPath returns the max-tokens path already present in the payload, or the
+// protocol's primary path when none is.
+func maxTokensPath(payload []byte, paths modelParameterPaths, root string) string {
+	if len(paths.maxTokens) == 0 {
+		return ""
+	}
+	for _, p := range paths.maxTokens {
+		full := buildPayloadPath(root, p)
+		if gjson.GetBytes(payload, full).Exists() {
+			return full
+		}
+	}
+	return buildPayloadPath(root, paths.maxTokens[0])
+}
+
+// setThinkingBudget writes a thinking budget in the protocol's native form.
+func setThinkingBudget(out []byte, budget int, protocol, root string) []byte {
+	switch protocol {
+	case "claude":
+		if budget == 0 {
+			return deletePayloadValue(out, "thinking")
+		}
+		if budget < 0 {
+			return setPayloadValue(out, "thinking", map[string]any{"type": "adaptive"})
+		}
+		return setPayloadValue(out, "thinking", map[string]any{"type": "enabled", "budget_tokens": budget})
+	case "gemini", "gemini-cli", "antigravity":
+		levelPath := buildPayloadPath(root, "generationConfig.thinkingConfig.thinkingLevel")
+		if gjson.GetBytes(out, levelPath).Exists() {
+			if level, ok := thinking.ConvertBudgetToLevel(budget); ok {
+				return setPayloadValue(out, levelPath, level)
+			}
+			return out
+		}
+		return setPayloadValue(out, buildPayloadPath(root, "generationConfig.thinkingConfig.thinkingBudget"), budget)
+	case "openai":
+		return setThinkingLevel(out, "reasoning_effort", budget)
+	case "openai-response", "codex":
+		return setThinkingLevel(out, "reasoning.effort", budget)
+	}
+	return out
+}
+
+func setThinkingLevel(out []byte, path string, budget int) []byte {
+	level, ok := thinking.ConvertBudgetToLevel(budget)
+	if !ok {
+		return out
+	}
+	if budget < 0 {
+		// Level-based providers have no dynamic mode; leave the client's choice.
+		return out
+	}
+	return setPayloadValue(out, path, level)
+}
+
+// clampThinkingBudget bounds the thinking budget present in the payload. Level-based
+// payloads are converted to a budget, bounded, and converted back.
+func clampThinkingBudget(out []byte, r config.ParameterRange, protocol, root string) []byte {
+	switch protocol {
+	case "claude":
+		return clampPayloadNumber(out, "thinking.budget_tokens", r, true)
+	case "gemini", "gemini-cli", "antigravity":
+		out = clampPayloadNumber(out, buildPayloadPath(root, "generationConfig.thinkingConfig.thinkingBudget"), r, true)
+		return clampThinkingLevel(out, buildPayloadPath(root, "generationConfig.thinkingConfig.thinkingLevel"), r)
+	case "openai":
+		return clampThinkingLevel(out, "reasoning_effort", r)
+	case "openai-response", "codex":
+		return clampThinkingLevel(out, "reasoning.effort", r)
+	}
+	return out
+}
+
+func clampThinkingLevel(out []byte, path string, r config.ParameterRange) []byte {
+	current := gjson.GetBytes(out, path)
+	if current.Type != gjson.String {
+		return out
+	}
+	budget, ok := thinking.ConvertLevelToBudget(current.String())
+	if !ok || budget < 0 {
+		return out
+	}
+	clamped := int(r.Apply(float64(budget)))
+	if clamped == budget {
+		return out
+	}
+	level, ok := thinking.ConvertBudgetToLevel(clamped)
+	if !ok {
+		return out
+	}
+	return setPayloadValue(out, path, level)
+}
+
+// clampPayloadNumber bounds the numeric value at path, leaving absent values alone.
+// Negative integers are treated as sentinels (e.g. dynamic thinking) and not clamped.
+func clampPayloadNumber(out []byte, path string, r config.ParameterRange, integer bool) []byte {
+	if path == "" || r.IsZero() {
+		return out
+	}
+	current := gjson.GetBytes(out, path)
+	if current.Type != gjson.Number {
+		return out
+	}
+	value := current.Float()
+	if integer && value < 0 {
+		return out
+	}
+	clamped := r.Apply(value)
+	if clamped == value {
+		return out
+	}
+	if integer {
+		return setPayloadValue(out, path, int(clamped))
+	}
+	return setPayloadValue(out, path, clamped)
+}
+
+func setPayloadValue(out []byte, path string, value any) []byte {
+	if strings.TrimSpace(path) == "" {
+		return out
+	}
+	updated, errSet := sjson.SetBytes(out, path, value)
+	if errSet != nil {
+		return out
+	}
+	return updated
+}
+
+func setPayloadDefault(out []byte, path string, value any) []byte {
+	if strings.TrimSpace(path) == "" || gjson.GetBytes(out, path).Exists() {
+		return out
+	}
+	return setPayloadValue(out, path, value)
+}
+
+func deletePayloadValue(out []byte, path string) []byte {
+	updated, errDel := sjson.DeleteBytes(out, path)
+	if errDel != nil {
+		return out
+	}
+	return updated
+}
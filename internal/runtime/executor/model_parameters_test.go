@@ -0,0 +1,116 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+func intPtr(v int) *int           { return &v }
+
+func TestApplyModelParametersOverrideThenClamp(t *testing.T) {
+	rules := []config.ModelParameterRule{{
+		Models:   []config.PayloadModelRule{{Name: "fast-*"}},
+		Override: config.ModelParameters{Temperature: floatPtr(0.2), Stop: []string{"END"}},
+		Clamp:    config.ModelParameterClamp{MaxTokens: config.ParameterRange{Max: floatPtr(1000)}},
+	}}
+	payload := []byte(`{"model":"claude-sonnet-4-5","temperature":1,"max_tokens":8000}`)
+
+	out := applyModelParameters(rules, "claude-sonnet-4-5", "claude", "", payload, "fast-sonnet")
+	if got := gjson.GetBytes(out, "temperature").Float(); got != 0.2 {
+		t.Fatalf("temperature = %v, want 0.2", got)
+	}
+	if got := gjson.GetBytes(out, "max_tokens").Int(); got != 1000 {
+		t.Fatalf("max_tokens = %v, want 1000", got)
+	}
+	if got := gjson.GetBytes(out, "stop_sequences.0").String(); got != "END" {
+		t.Fatalf("stop_sequences = %s", gjson.GetBytes(out, "stop_sequences").Raw)
+	}
+
+	unmatched := applyModelParameters(rules, "claude-sonnet-4-5", "claude", "", payload, "claude-sonnet-4-5")
+	if string(unmatched) != string(payload) {
+		t.Fatalf("unmatched payload changed: %s", unmatched)
+	}
+}
+
+func TestApplyModelParametersClampBoundsOverride(t *testing.T) {
+	rules := []config.ModelParameterRule{{
+		Models:   []config.PayloadModelRule{{Name: "pinned"}},
+		Override: config.ModelParameters{ThinkingBudget: intPtr(30000)},
+		Clamp:    config.ModelParameterClamp{ThinkingBudget: config.ParameterRange{Max: floatPtr(8192)}},
+	}}
+	payload := []byte(`{"request":{"generationConfig":{"temperature":0.7}}}`)
+
+	out := applyModelParameters(rules, "gemini-2.5-pro", "gemini", "request", payload, "pinned")
+	if got := gjson.GetBytes(out, "request.generationConfig.thinkingConfig.thinkingBudget").Int(); got != 8192 {
+		t.Fatalf("thinkingBudget = %v, want 8192", got)
+	}
+
+	openAI := applyModelParameters(rules, "gpt-5", "openai", "", []byte(`{"model":"gpt-5"}`), "pinned")
+	if got := gjson.GetBytes(openAI, "reasoning_effort").String(); got != "medium" {
+		t.Fatalf("reasoning_effort = %q, want medium", got)
+	}
+}
+
+func TestApplyModelParametersDisablesClaudeThinking(t *testing.T) {
+	rules := []config.ModelParameterRule{{
+		Models:   []config.PayloadModelRule{{Name: "no-think", Protocol: "claude"}},
+		Override: config.ModelParameters{ThinkingBudget: intPtr(0)},
+	}}
+	payload := []byte(`{"max_tokens":4096,"thinking":{"type":"enabled","budget_tokens":2048}}`)
+
+	out := applyModelParameters(rules, "claude-opus-4-1", "claude", "", payload, "no-think")
+	if gjson.GetBytes(out, "thinking").Exists() {
+		t.Fatalf("thinking should be removed: %s", out)
+	}
+}
+
+func TestApplyModelParametersKeepsClaudeBudgetBelowMaxTokens(t *testing.T) {
+	rules := []config.ModelParameterRule{{
+		Models:   []config.PayloadModelRule{{Name: "deep-sonnet", Protocol: "claude"}},
+		Override: config.ModelParameters{ThinkingBudget: intPtr(16384)},
+	}}
+
+	out := applyModelParameters(rules, "claude-sonnet-4-5", "claude", "", []byte(`{"max_tokens":4096}`), "deep-sonnet")
+	if budget, maxTokens := gjson.GetBytes(out, "thinking.budget_tokens").Int(), gjson.GetBytes(out, "max_tokens").Int(); budget != 4095 || maxTokens != 4096 {
+		t.Fatalf("budget = %d, max_tokens = %d; want 4095, 4096", budget, maxTokens)
+	}
+
+	out = applyModelParameters(rules, "claude-sonnet-4-5", "claude", "", []byte(`{"max_tokens":512}`), "deep-sonnet")
+	if budget, maxTokens := gjson.GetBytes(out, "thinking.budget_tokens").Int(), gjson.GetBytes(out, "max_tokens").Int(); budget != 16384 || maxTokens != 16385 {
+		t.Fatalf("budget = %d, max_tokens = %d; want 16384, 16385", budget, maxTokens)
+	}
+}
+
+func TestApplyModelParametersThinkingDefault(t *testing.T) {
+	rules := []config.ModelParameterRule{{
+		Models:  []config.PayloadModelRule{{Name: "*-thinking"}},
+		Default: config.ModelParameters{ThinkingBudget: intPtr(16384), Temperature: floatPtr(0.5)},
+	}}
+
+	out := applyModelParameters(rules, "claude-sonnet-4-5", "claude", "", []byte(`{"max_tokens":32000}`), "claude-sonnet-thinking")
+	if got := gjson.GetBytes(out, "thinking.budget_tokens").Int(); got != 16384 {
+		t.Fatalf("thinking = %s, want budget 16384", gjson.GetBytes(out, "thinking").Raw)
+	}
+	if got := gjson.GetBytes(out, "temperature").Float(); got != 0.5 {
+		t.Fatalf("temperature = %v, want 0.5", got)
+	}
+
+	explicit := []byte(`{"temperature":1,"thinking":{"type":"disabled"}}`)
+	kept := applyModelParameters(rules, "claude-sonnet-4-5", "claude", "", explicit, "claude-sonnet-thinking")
+	if string(kept) != string(explicit) {
+		t.Fatalf("client settings should win over defaults: %s", kept)
+	}
+
+	gemini := applyModelParameters(rules, "gemini-2.5-pro", "gemini", "", []byte(`{"generationConfig":{"thinkingConfig":{"includeThoughts":true}}}`), "gemini-thinking")
+	if got := gjson.GetBytes(gemini, "generationConfig.thinkingConfig.thinkingBudget").Int(); got != 16384 {
+		t.Fatalf("thinkingBudget = %v, want 16384", got)
+	}
+
+	openAI := applyModelParameters(rules, "gpt-5", "openai", "", []byte(`{"reasoning_effort":"low"}`), "gpt-5-thinking")
+	if got := gjson.GetBytes(openAI, "reasoning_effort").String(); got != "low" {
+		t.Fatalf("reasoning_effort = %q, want low", got)
+	}
+}
@@ -0,0 +1,103 @@
+package executor
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+// applyParameterProfileDefaults fills parameters from a client API key's profile that
+// the payload leaves out. It runs before the model-parameters defaults so the key's
+// defaults take priority over the model's.
+func applyParameterProfileDefaults(profile config.ParameterProfile, protocol, root string, payload []byte) []byte {
+	paths, ok := modelParameterPathsFor(protocol)
+	if !ok || len(payload) == 0 {
+		return payload
+	}
+	return defaultModelParameters(payload, profile.Default, paths, protocol, root)
+}
+
+// applyParameterProfile applies the override, clamp and system prompt of a client API
+// key's profile. It runs after model-parameters so the key's limits are the final word.
+func applyParameterProfile(profile config.ParameterProfile, protocol, root string, payload []byte) []byte {
+	paths, ok := modelParameterPathsFor(protocol)
+	if !ok || len(payload) == 0 {
+		return payload
+	}
+	out := overrideModelParameters(payload, profile.Override, paths, protocol, root)
+	out = clampModelParameters(out, profile.Clamp, paths, protocol, root)
+	return appendSystemPrompt(out, strings.TrimSpace(profile.SystemPrompt), protocol, root)
+}
+
+// appendSystemPrompt adds prompt to the payload's system instructions in the protocol's
+// native form, after the instructions already present.
+func appendSystemPrompt(out []byte, prompt, protocol, root string) []byte {
+	if prompt == "" {
+		return out
+	}
+	switch protocol {
+	case "openai":
+		messages := gjson.GetBytes(out, "messages")
+		if !messages.IsArray() {
+			return out
+		}
+		// Insert after the leading system messages so the client's own system prompt stays first.
+		index := 0
+		for _, message := range messages.Array() {
+			role := message.Get("role").String()
+			if role != "system" && role != "developer" {
+				break
+			}
+			index++
+		}
+		items := make([]any, 0, len(messages.Array())+1)
+		for i, message := range messages.Array() {
+			if i == index {
+				items = append(items, map[string]any{"role": "system", "content": prompt})
+			}
+			items = append(items, message.Value())
+		}
+		if index == len(messages.Array()) {
+			items = append(items, map[string]any{"role": "system", "content": prompt})
+		}
+		return setPayloadValue(out, "messages", items)
+	case "openai-response", "codex":
+		message := map[string]any{
+			"type":    "message",
+			"role":    "developer",
+			"content": []any{map[string]any{"type": "input_text", "text": prompt}},
+		}
+		input := gjson.GetBytes(out, "input")
+		switch {
+		case input.IsArray():
+			items := make([]any, 0, len(input.Array())+1)
+			items = append(items, message)
+			for _, item := range input.Array() {
+				items = append(items, item.Value())
+			}
+			return setPayloadValue(out, "input", items)
+		case input.Type == gjson.String:
+			user := map[string]any{"type": "message", "role": "user", "content": input.String()}
+			return setPayloadValue(out, "input", []any{message, user})
+		}
+		return out
+	case "claude":
+		block := map[string]any{"type": "text", "text": prompt}
+		system := gjson.GetBytes(out, "system")
+		switch {
+		case system.IsArray():
+			return setPayloadValue(out, "system.-1", block)
+		case system.Type == gjson.String && system.String() != "":
+			return setPayloadValue(out, "system", []any{map[string]any{"type": "text", "text": system.String()}, block})
+		}
+		return setPayloadValue(out, "system", []any{block})
+	case "gemini", "gemini-cli", "antigravity":
+		partsPath := buildPayloadPath(root, "systemInstruction.parts")
+		if gjson.GetBytes(out, partsPath).IsArray() {
+			return setPayloadValue(out, partsPath+".-1", map[string]any{"text": prompt})
+		}
+		return setPayloadValue(out, partsPath, []any{map[string]any{"text": prompt}})
+	}
+	return out
+}
@@ -0,0 +1,88 @@
+package executor
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyPayloadConfigParameterProfile(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ModelParameters = []config.ModelParameterRule{{
+		Models:  []config.PayloadModelRule{{Name: "claude-*"}},
+		Default: config.ModelParameters{Temperature: floatPtr(1), MaxTokens: intPtr(4000)},
+	}}
+	cfg.APIKeyPolicies = []config.APIKeyPolicy{{
+		APIKey: "batch-key",
+		Parameters: config.ParameterProfile{
+			Default:      config.ModelParameters{Temperature: floatPtr(0.3)},
+			Override:     config.ModelParameters{ThinkingBudget: intPtr(0)},
+			Clamp:        config.ModelParameterClamp{MaxTokens: config.ParameterRange{Max: floatPtr(2000)}},
+			SystemPrompt: "Answer tersely.",
+		},
+	}}
+	payload := []byte(`{"model":"claude-sonnet-4-5","system":"Be helpful.","thinking":{"type":"enabled","budget_tokens":1024},"messages":[]}`)
+
+	out := applyPayloadConfigWithRoot(cfg, "claude-sonnet-4-5", "claude", "", payload, payload, "claude-sonnet-4-5", "batch-key", config.ModelParameters{})
+	if got := gjson.GetBytes(out, "temperature").Float(); got != 0.3 {
+		t.Fatalf("temperature = %v, want the key default 0.3", got)
+	}
+	if got := gjson.GetBytes(out, "max_tokens").Int(); got != 2000 {
+		t.Fatalf("max_tokens = %v, want the key cap 2000", got)
+	}
+	if gjson.GetBytes(out, "thinking").Exists() {
+		t.Fatalf("thinking should be disabled: %s", out)
+	}
+	if got := gjson.GetBytes(out, "system.0.text").String(); got != "Be helpful." {
+		t.Fatalf("client system prompt = %q", got)
+	}
+	if got := gjson.GetBytes(out, "system.1.text").String(); got != "Answer tersely." {
+		t.Fatalf("profile system prompt = %q", got)
+	}
+
+	other := applyPayloadConfigWithRoot(cfg, "claude-sonnet-4-5", "claude", "", payload, payload, "claude-sonnet-4-5", "other-key", config.ModelParameters{})
+	if got := gjson.GetBytes(other, "temperature").Float(); got != 1 {
+		t.Fatalf("temperature without a profile = %v, want the model default 1", got)
+	}
+	if gjson.GetBytes(other, "system").String() != "Be helpful." {
+		t.Fatalf("system prompt changed without a profile: %s", other)
+	}
+}
+
+func TestAppendSystemPromptOpenAI(t *testing.T) {
+	payload := []byte(`{"messages":[{"role":"system","content":"client"},{"role":"user","content":"hi"}]}`)
+	out := appendSystemPrompt(payload, "profile", "openai", "")
+	if got := gjson.GetBytes(out, "messages.1.content").String(); got != "profile" {
+		t.Fatalf("messages = %s", gjson.GetBytes(out, "messages").Raw)
+	}
+	if got := gjson.GetBytes(out, "messages.2.role").String(); got != "user" {
+		t.Fatalf("messages = %s", gjson.GetBytes(out, "messages").Raw)
+	}
+
+	gemini := appendSystemPrompt([]byte(`{"request":{"contents":[]}}`), "profile", "gemini-cli", "request")
+	if got := gjson.GetBytes(gemini, "request.systemInstruction.parts.0.text").String(); got != "profile" {
+		t.Fatalf("gemini payload = %s", gemini)
+	}
+}
+
+func TestApplyPayloadConfigParameterHeaders(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.ModelParameters = []config.ModelParameterRule{{
+		Models: []config.PayloadModelRule{{Name: "claude-*"}},
+		Clamp:  config.ModelParameterClamp{Temperature: config.ParameterRange{Max: floatPtr(0.7)}},
+	}}
+	payload := []byte(`{"model":"claude-sonnet-4-5","temperature":0.2,"max_tokens":8000,"thinking":{"type":"enabled","budget_tokens":1024},"messages":[]}`)
+	headers := config.ModelParameters{Temperature: floatPtr(0.9), MaxTokens: intPtr(500), ThinkingBudget: intPtr(0)}
+
+	out := applyPayloadConfigWithRoot(cfg, "claude-sonnet-4-5", "claude", "", payload, payload, "claude-sonnet-4-5", "", headers)
+	if got := gjson.GetBytes(out, "temperature").Float(); got != 0.7 {
+		t.Fatalf("temperature = %v, want the header value clamped to 0.7", got)
+	}
+	if got := gjson.GetBytes(out, "max_tokens").Int(); got != 500 {
+		t.Fatalf("max_tokens = %v, want the header value 500", got)
+	}
+	if gjson.GetBytes(out, "thinking").Exists() {
+		t.Fatalf("thinking should be disabled by the header: %s", out)
+	}
+}
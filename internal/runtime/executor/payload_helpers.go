@@ -16,11 +16,29 @@ import (
 // and restricts matches to the given protocol when supplied. Defaults are checked
 // against the original payload when provided. requestedModel carries the client-visible
 // model name before alias resolution so payload rules can target aliases precisely.
-func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string, payload, original []byte, requestedModel string) []byte {
+// model-parameters rules run last so their overrides and clamps have the final word,
+// wrapped by the parameter profile of the client API key when it has one. headerParams
+// come from the client's parameter override headers and count as client values.
+func applyPayloadConfigWithRoot(cfg *config.Config, model, protocol, root string, payload, original []byte, requestedModel, clientAPIKey string, headerParams config.ModelParameters) []byte {
 	if cfg == nil || len(payload) == 0 {
 		return payload
 	}
-	rules := cfg.Payload
+	out := applyPayloadRules(cfg.Payload, model, protocol, root, payload, original, requestedModel)
+	if paths, ok := modelParameterPathsFor(protocol); ok {
+		out = overrideModelParameters(out, headerParams, paths, protocol, root)
+	}
+	policy, hasPolicy := cfg.APIKeyPolicy(clientAPIKey)
+	if hasPolicy {
+		out = applyParameterProfileDefaults(policy.Parameters, protocol, root, out)
+	}
+	out = applyModelParameters(cfg.ModelParameters, model, protocol, root, out, requestedModel)
+	if hasPolicy {
+		out = applyParameterProfile(policy.Parameters, protocol, root, out)
+	}
+	return out
+}
+
+func applyPayloadRules(rules config.PayloadConfig, model, protocol, root string, payload, original []byte, requestedModel string) []byte {
 	if len(rules.Default) == 0 && len(rules.DefaultRaw) == 0 && len(rules.Override) == 0 && len(rules.OverrideRaw) == 0 && len(rules.Filter) == 0 {
 		return payload
 	}
@@ -244,6 +262,20 @@ func payloadRawValue(value any) ([]byte, bool) {
 	}
 }
 
+// payloadClientAPIKey returns the proxy API key the client authenticated with, if any.
+func payloadClientAPIKey(opts cliproxyexecutor.Options) string {
+	if v, ok := opts.Metadata[cliproxyexecutor.ClientAPIKeyMetadataKey].(string); ok {
+		return strings.TrimSpace(v)
+	}
+	return ""
+}
+
+// payloadParameterHeaders returns the parameters the client set with override headers.
+func payloadParameterHeaders(opts cliproxyexecutor.Options) config.ModelParameters {
+	params, _ := opts.Metadata[cliproxyexecutor.ParameterHeadersMetadataKey].(config.ModelParameters)
+	return params
+}
+
 func payloadRequestedModel(opts cliproxyexecutor.Options, fallback string) string {
 	fallback = strings.TrimSpace(fallback)
 	if len(opts.Metadata) == 0 {
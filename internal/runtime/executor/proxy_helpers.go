@@ -22,6 +22,7 @@ import (
 // 4. Use HTTP/2 enabled transport as default
 //
 // All transports are configured with HTTP/2 support for optimal streaming performance.
+// When a header template is configured for the auth's backend, the transport applies it.
 //
 // Parameters:
 //   - ctx: The context containing optional RoundTripper
@@ -32,6 +33,13 @@ import (
 // Returns:
 //   - *http.Client: An HTTP client with configured proxy or transport (HTTP/2 enabled)
 func newProxyAwareHTTPClient(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, timeout time.Duration) *http.Client {
+	httpClient := newProxyAwareHTTPClientBase(ctx, cfg, auth, timeout)
+	httpClient.Transport = wrapHeaderTemplateTransport(httpClient.Transport, cfg, auth)
+	return httpClient
+}
+
+// newProxyAwareHTTPClientBase resolves the transport without header template decoration.
+func newProxyAwareHTTPClientBase(ctx context.Context, cfg *config.Config, auth *cliproxyauth.Auth, timeout time.Duration) *http.Client {
 	httpClient := &http.Client{}
 	if timeout > 0 {
 		httpClient.Timeout = timeout
@@ -13,6 +13,7 @@ import (
 	qwenauth "github.com/router-for-me/CLIProxyAPI/v6/internal/auth/qwen"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
@@ -94,7 +95,7 @@ func (e *QwenExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req
 	}
 
 	requestedModel := payloadRequestedModel(opts, req.Model)
-	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel, payloadClientAPIKey(opts), payloadParameterHeaders(opts))
 
 	url := strings.TrimSuffix(baseURL, "/") + "/chat/completions"
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
@@ -136,7 +137,7 @@ func (e *QwenExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, req
 		b, _ := io.ReadAll(httpResp.Body)
 		appendAPIResponseChunk(ctx, e.cfg, b)
 		logWithRequestID(ctx).Debugf("request error, error status: %d, error message: %s", httpResp.StatusCode, summarizeErrorBody(httpResp.Header.Get("Content-Type"), b))
-		err = statusErr{code: httpResp.StatusCode, msg: string(b)}
+		err = newQwenStatusErr(httpResp.StatusCode, b, reporter.source, baseModel)
 		return resp, err
 	}
 	data, err := io.ReadAll(httpResp.Body)
@@ -192,7 +193,7 @@ func (e *QwenExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Aut
 	}
 	body, _ = sjson.SetBytes(body, "stream_options.include_usage", true)
 	requestedModel := payloadRequestedModel(opts, req.Model)
-	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel)
+	body = applyPayloadConfigWithRoot(e.cfg, baseModel, to.String(), "", body, originalTranslated, requestedModel, payloadClientAPIKey(opts), payloadParameterHeaders(opts))
 
 	url := strings.TrimSuffix(baseURL, "/") + "/chat/completions"
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
@@ -232,7 +233,7 @@ func (e *QwenExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Aut
 		if errClose := httpResp.Body.Close(); errClose != nil {
 			log.Errorf("qwen executor: close response body error: %v", errClose)
 		}
-		err = statusErr{code: httpResp.StatusCode, msg: string(b)}
+		err = newQwenStatusErr(httpResp.StatusCode, b, reporter.source, baseModel)
 		return nil, err
 	}
 	out := make(chan cliproxyexecutor.StreamChunk)
@@ -382,3 +383,50 @@ func qwenCreds(a *cliproxyauth.Auth) (token, baseURL string) {
 	}
 	return
 }
+
+// qwenQuotaLocation is the timezone in which the Qwen free-tier daily request quota resets.
+var qwenQuotaLocation = time.FixedZone("CST", 8*60*60)
+
+// newQwenStatusErr builds the upstream error for a failed Qwen request. Free-tier quota
+// exhaustion is reported as 429 with a retry delay until the next daily reset so the auth
+// manager parks the account instead of retrying it, and is recorded in the rate limit store.
+func newQwenStatusErr(statusCode int, body []byte, source, model string) statusErr {
+	err := statusErr{code: statusCode, msg: string(body)}
+	if !isQwenQuotaExceeded(statusCode, body) {
+		return err
+	}
+	now := time.Now()
+	reset := nextQwenQuotaReset(now)
+	retryAfter := reset.Sub(now)
+	err.code = http.StatusTooManyRequests
+	err.retryAfter = &retryAfter
+	log.Infof("ratelimit: [quota] model=%s source=%s qwen free-tier quota exhausted until %s", model, source, reset.Format(time.RFC3339))
+	usage.GetRateLimitStore().Record(usage.RateLimitRecord{
+		Source:      source,
+		Model:       model,
+		Type:        usage.RateLimitTypeQuota,
+		QuotaStatus: "rejected",
+		QuotaReset:  reset,
+	})
+	return err
+}
+
+// isQwenQuotaExceeded reports whether the error body signals an exhausted free-tier quota.
+func isQwenQuotaExceeded(statusCode int, body []byte) bool {
+	if statusCode != http.StatusTooManyRequests && statusCode != http.StatusForbidden {
+		return false
+	}
+	for _, code := range cliproxyexecutor.ProviderErrorCodes(body) {
+		if strings.EqualFold(code, "insufficient_quota") {
+			return true
+		}
+	}
+	message := strings.ToLower(gjson.GetBytes(body, "error.message").String())
+	return strings.Contains(message, "free allocated quota exceeded") || strings.Contains(message, "quota exceeded")
+}
+
+// nextQwenQuotaReset returns the next midnight in the Qwen quota timezone after now.
+func nextQwenQuotaReset(now time.Time) time.Time {
+	local := now.In(qwenQuotaLocation)
+	return time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, qwenQuotaLocation)
+}
@@ -1,7 +1,9 @@
 package executor
 
 import (
+	"net/http"
 	"testing"
+	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 )
@@ -28,3 +30,31 @@ func TestQwenExecutorParseSuffix(t *testing.T) {
 		})
 	}
 }
+
+func TestNewQwenStatusErrQuotaExceeded(t *testing.T) {
+	body := []byte(`{"error":{"code":"insufficient_quota","message":"Free allocated quota exceeded.","type":"insufficient_quota"}}`)
+	err := newQwenStatusErr(http.StatusForbidden, body, "qwen-user", "qwen3-coder-plus")
+	if err.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", err.StatusCode(), http.StatusTooManyRequests)
+	}
+	if err.RetryAfter() == nil || *err.RetryAfter() <= 0 || *err.RetryAfter() > 24*time.Hour {
+		t.Fatalf("unexpected retry after: %v", err.RetryAfter())
+	}
+}
+
+func TestNewQwenStatusErrPassthrough(t *testing.T) {
+	body := []byte(`{"error":{"message":"bad request"}}`)
+	err := newQwenStatusErr(http.StatusBadRequest, body, "qwen-user", "qwen3-coder-plus")
+	if err.StatusCode() != http.StatusBadRequest || err.RetryAfter() != nil {
+		t.Fatalf("unexpected error: code=%d retryAfter=%v", err.StatusCode(), err.RetryAfter())
+	}
+}
+
+func TestNextQwenQuotaReset(t *testing.T) {
+	now := time.Date(2026, 1, 2, 15, 30, 0, 0, time.UTC) // 23:30 in UTC+8
+	got := nextQwenQuotaReset(now)
+	want := time.Date(2026, 1, 2, 16, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Fatalf("reset = %s, want %s", got, want)
+	}
+}
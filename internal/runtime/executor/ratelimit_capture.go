@@ -51,3 +51,26 @@ func captureClaudeRateLimit(headers http.Header, source, model string) {
 	record.Model = model
 	usage.GetRateLimitStore().Record(record)
 }
+
+// captureCodexRateLimit parse x-codex-* rate limit headers từ ChatGPT backend
+// và lưu vào RateLimitStore.
+func captureCodexRateLimit(headers http.Header, source, model string) {
+	if headers == nil || headers.Get("x-codex-primary-used-percent") == "" && headers.Get("x-codex-secondary-used-percent") == "" {
+		return
+	}
+
+	record := usage.ParseCodexRateLimitHeaders(headers)
+	if record.IsEmpty() {
+		log.Debugf("ratelimit: codex headers found but parsed empty for model=%s source=%s", model, source)
+		return
+	}
+
+	log.Infof("ratelimit: [codex] model=%s source=%s primary=%.1f%% (%dm) secondary=%.1f%% (%dm)",
+		model, source,
+		record.PrimaryUsedPercent, record.PrimaryWindowMinutes,
+		record.SecondaryUsedPercent, record.SecondaryWindowMinutes)
+
+	record.Source = source
+	record.Model = model
+	usage.GetRateLimitStore().Record(record)
+}
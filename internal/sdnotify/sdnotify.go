@@ -0,0 +1,62 @@
+// Package sdnotify implements the systemd service notification protocol (sd_notify)
+// and a watchdog that only reports liveness while the process stays responsive.
+// It has no effect when the process is not started by systemd.
+package sdnotify
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Ready tells systemd that startup finished (Type=notify units).
+	Ready = "READY=1"
+	// Stopping tells systemd that a graceful shutdown started.
+	Stopping = "STOPPING=1"
+	// Watchdog resets the systemd watchdog timer.
+	Watchdog = "WATCHDOG=1"
+)
+
+// Notify sends state to the socket in NOTIFY_SOCKET. It returns false without error
+// when the variable is unset, i.e. the process is not supervised by systemd.
+func Notify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+	addr := &net.UnixAddr{Name: socket, Net: "unixgram"}
+	// A leading '@' denotes a Linux abstract socket.
+	if strings.HasPrefix(socket, "@") {
+		addr.Name = "\x00" + socket[1:]
+	}
+	conn, err := net.DialUnix("unixgram", nil, addr)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = conn.Close() }()
+	if _, err = conn.Write([]byte(state)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Status formats a free-form status line shown by systemctl status.
+func Status(text string) string {
+	return "STATUS=" + text
+}
+
+// WatchdogInterval returns the watchdog timeout systemd expects this process to honour
+// (WatchdogSec=), or zero when the watchdog is not enabled for it.
+func WatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(strings.TrimSpace(os.Getenv("WATCHDOG_USEC")), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pid := strings.TrimSpace(os.Getenv("WATCHDOG_PID")); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0
+	}
+	return time.Duration(usec) * time.Microsecond
+}
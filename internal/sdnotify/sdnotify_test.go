@@ -0,0 +1,84 @@
+package sdnotify
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if sent, err := Notify(Ready); sent || err != nil {
+		t.Fatalf("Notify without NOTIFY_SOCKET = %v, %v", sent, err)
+	}
+
+	path := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		t.Skipf("unixgram sockets unavailable: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	if sent, errNotify := Notify(Ready); !sent || errNotify != nil {
+		t.Fatalf("Notify = %v, %v", sent, errNotify)
+	}
+	buf := make([]byte, 64)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil || string(buf[:n]) != Ready {
+		t.Fatalf("received %q, %v", buf[:n], err)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	t.Setenv("WATCHDOG_USEC", "30000000")
+	t.Setenv("WATCHDOG_PID", "")
+	if got := WatchdogInterval(); got != 30*time.Second {
+		t.Fatalf("WatchdogInterval() = %s, want 30s", got)
+	}
+	t.Setenv("WATCHDOG_PID", "1")
+	if got := WatchdogInterval(); got != 0 {
+		t.Fatalf("watchdog for another pid should be ignored, got %s", got)
+	}
+}
+
+func TestRunWatchdogWithholdsPingsWhileStalled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var stuck atomic.Bool
+	release := make(chan struct{})
+	probe := func() {
+		if stuck.Load() {
+			<-release
+		}
+	}
+	var pings, stalls atomic.Int32
+	go RunWatchdog(ctx, 20*time.Millisecond, probe, func() { pings.Add(1) }, func() { stalls.Add(1) })
+
+	time.Sleep(60 * time.Millisecond)
+	if pings.Load() == 0 {
+		t.Fatal("expected pings while responsive")
+	}
+	stuck.Store(true)
+	time.Sleep(40 * time.Millisecond)
+	before := pings.Load()
+	time.Sleep(60 * time.Millisecond)
+	if after := pings.Load(); after != before {
+		t.Fatalf("pings continued while stalled: %d -> %d", before, after)
+	}
+	if stalls.Load() != 1 {
+		t.Fatalf("onStall called %d times, want 1", stalls.Load())
+	}
+
+	stuck.Store(false)
+	close(release)
+	time.Sleep(60 * time.Millisecond)
+	if pings.Load() == before {
+		t.Fatal("pings should resume once the probe returns")
+	}
+}
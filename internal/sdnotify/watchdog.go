@@ -0,0 +1,57 @@
+package sdnotify
+
+import (
+	"context"
+	"time"
+)
+
+// Probe checks that the process can still make progress, e.g. by taking the locks that
+// request handling depends on. A probe that deadlocks simply never returns.
+type Probe func()
+
+// RunWatchdog runs probe every interval/2 until ctx is done and calls ping each time it
+// completes within interval/2. While a probe is stuck no ping is sent, so the supervisor
+// sees the process as hung once interval elapses. onStall, if set, is called once when
+// a probe misses its deadline. Only one probe runs at a time; a stuck probe goroutine is
+// left behind rather than stacking new ones on the same deadlock.
+func RunWatchdog(ctx context.Context, interval time.Duration, probe Probe, ping func(), onStall func()) {
+	if interval <= 0 || probe == nil || ping == nil {
+		return
+	}
+	period := interval / 2
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+
+	var inFlight chan struct{}
+	stalled := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if inFlight == nil {
+			inFlight = make(chan struct{})
+			go func(done chan struct{}) {
+				probe()
+				close(done)
+			}(inFlight)
+		}
+		timer := time.NewTimer(period)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-inFlight:
+			timer.Stop()
+			inFlight = nil
+			stalled = false
+			ping()
+		case <-timer.C:
+			if !stalled && onStall != nil {
+				onStall()
+			}
+			stalled = true
+		}
+	}
+}
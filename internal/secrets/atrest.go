@@ -0,0 +1,307 @@
+package secrets
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultKeyEnv is the environment variable consulted for the credential key when
+// no other source is configured.
+const DefaultKeyEnv = "CLIPROXY_CREDENTIAL_KEY"
+
+// valuePrefix marks sealed scalar values such as API keys in config.yaml.
+const valuePrefix = "enc:"
+
+// ErrKeyUnavailable is returned when sealed data is read but no credential key is configured.
+var ErrKeyUnavailable = errors.New("secrets: credential is encrypted but no credential key is configured")
+
+// Sealer encrypts credential material at rest with a single passphrase.
+// Every payload written by a Sealer shares one salt, and derived keys are cached per
+// salt, so the scrypt cost is paid once per process rather than once per file.
+type Sealer struct {
+	passphrase string
+	salt       []byte
+
+	mu   sync.Mutex
+	keys map[string]cipher.AEAD
+}
+
+// NewSealer derives the write key for passphrase and returns a ready Sealer.
+func NewSealer(passphrase string) (*Sealer, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("secrets: passphrase is required")
+	}
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("secrets: failed to generate salt: %w", err)
+	}
+	s := &Sealer{passphrase: passphrase, salt: salt, keys: make(map[string]cipher.AEAD)}
+	if _, err := s.aead(salt); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Seal encrypts plaintext into the same payload format as Encrypt.
+func (s *Sealer) Seal(plaintext []byte) ([]byte, error) {
+	gcm, err := s.aead(s.salt)
+	if err != nil {
+		return nil, err
+	}
+	return seal(gcm, s.salt, plaintext)
+}
+
+// Open decrypts a payload produced by Seal or Encrypt with the same passphrase.
+func (s *Sealer) Open(data []byte) ([]byte, error) {
+	salt, err := payloadSalt(data)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := s.aead(salt)
+	if err != nil {
+		return nil, err
+	}
+	return open(gcm, data)
+}
+
+func (s *Sealer) aead(salt []byte) (cipher.AEAD, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if gcm, ok := s.keys[string(salt)]; ok {
+		return gcm, nil
+	}
+	gcm, err := newGCM(s.passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	s.keys[string(salt)] = gcm
+	return gcm, nil
+}
+
+// KeySource lists where the credential key may come from. Sources are tried in
+// field order and the first non-empty value wins.
+type KeySource struct {
+	// Passphrase is an inline key; prefer the other sources outside of testing.
+	Passphrase string
+	// Env names an environment variable holding the key (defaults to DefaultKeyEnv).
+	Env string
+	// File is a path whose trimmed content is the key, e.g. a mounted secret.
+	File string
+}
+
+// ResolveKey returns the first key found in src, or an empty string when none is set.
+func ResolveKey(src KeySource) (string, error) {
+	if key := strings.TrimSpace(src.Passphrase); key != "" {
+		return key, nil
+	}
+	env := strings.TrimSpace(src.Env)
+	if env == "" {
+		env = DefaultKeyEnv
+	}
+	if key := strings.TrimSpace(os.Getenv(env)); key != "" {
+		return key, nil
+	}
+	if path := strings.TrimSpace(src.File); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("secrets: failed to read key file: %w", err)
+		}
+		if key := strings.TrimSpace(string(data)); key != "" {
+			return key, nil
+		}
+		return "", fmt.Errorf("secrets: key file %s is empty", path)
+	}
+	return "", nil
+}
+
+type atRestState struct {
+	sealer     *Sealer
+	sealWrites bool
+}
+
+var atRest atomic.Pointer[atRestState]
+
+// SetAtRest installs the process-wide sealer used for credential files and config values.
+// A nil sealer disables decryption; sealWrites controls whether new writes are encrypted.
+func SetAtRest(sealer *Sealer, sealWrites bool) {
+	if sealer == nil {
+		atRest.Store(nil)
+		return
+	}
+	atRest.Store(&atRestState{sealer: sealer, sealWrites: sealWrites})
+}
+
+// ResolveSealer resolves the key from src and returns a sealer for it without
+// installing it. The installed sealer is reused when the key is unchanged so reloads
+// stay cheap. When enable is set a key is mandatory; a nil sealer means no key.
+func ResolveSealer(src KeySource, enable bool) (*Sealer, error) {
+	key, err := ResolveKey(src)
+	if err != nil {
+		return nil, err
+	}
+	if key == "" {
+		if enable {
+			return nil, fmt.Errorf("secrets: credential encryption is enabled but no key is configured (set %s or credential-encryption.key-*)", DefaultKeyEnv)
+		}
+		return nil, nil
+	}
+	if current := atRest.Load(); current != nil && current.sealer.passphrase == key {
+		return current.sealer, nil
+	}
+	return NewSealer(key)
+}
+
+// ConfigureAtRest resolves the key from src and installs the process-wide sealer.
+// When enable is set a key is mandatory and all new credential writes are sealed;
+// otherwise a key, if present, is only used to read previously sealed data.
+func ConfigureAtRest(src KeySource, enable bool) error {
+	sealer, err := ResolveSealer(src, enable)
+	if err != nil {
+		return err
+	}
+	SetAtRest(sealer, enable)
+	return nil
+}
+
+// AtRestSealer returns the installed sealer, or nil when none is configured.
+func AtRestSealer() *Sealer {
+	if state := atRest.Load(); state != nil {
+		return state.sealer
+	}
+	return nil
+}
+
+// SealsWrites reports whether new credential writes are encrypted.
+func SealsWrites() bool {
+	state := atRest.Load()
+	return state != nil && state.sealWrites
+}
+
+// OpenAtRest returns the plaintext of data. Unsealed data is returned unchanged so
+// plaintext credentials keep working until they are migrated.
+func OpenAtRest(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	state := atRest.Load()
+	if state == nil {
+		return nil, ErrKeyUnavailable
+	}
+	return state.sealer.Open(data)
+}
+
+// SealAtRest encrypts data when sealing is enabled and returns it unchanged otherwise.
+func SealAtRest(data []byte) ([]byte, error) {
+	state := atRest.Load()
+	if state == nil || !state.sealWrites || IsEncrypted(data) {
+		return data, nil
+	}
+	return state.sealer.Seal(data)
+}
+
+// ReadFile reads a credential file and decrypts it if needed.
+func ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return OpenAtRest(data)
+}
+
+// WriteFile writes a credential file, encrypting it when sealing is enabled.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	sealed, err := SealAtRest(data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, sealed, perm)
+}
+
+// SealFileInPlace encrypts a plaintext credential file written by code that is not
+// aware of sealing. It is a no-op when sealing is disabled or the file is already sealed.
+func SealFileInPlace(path string) error {
+	if !SealsWrites() {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if IsEncrypted(data) || len(data) == 0 {
+		return nil
+	}
+	sealed, err := SealAtRest(data)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".seal-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	if _, err = tmp.Write(sealed); err == nil {
+		err = tmp.Chmod(0o600)
+	}
+	if errClose := tmp.Close(); err == nil {
+		err = errClose
+	}
+	if err == nil {
+		err = os.Rename(tmpName, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpName)
+	}
+	return err
+}
+
+// IsSealedValue reports whether value is a sealed config scalar.
+func IsSealedValue(value string) bool {
+	return strings.HasPrefix(value, valuePrefix)
+}
+
+// SealValue encrypts a config scalar with the installed sealer.
+func SealValue(value string) (string, error) {
+	sealer := AtRestSealer()
+	if sealer == nil {
+		return "", ErrKeyUnavailable
+	}
+	sealed, err := sealer.Seal([]byte(value))
+	if err != nil {
+		return "", err
+	}
+	return valuePrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// OpenValue decrypts a config scalar produced by SealValue with the installed sealer.
+// Values without the sealed prefix are returned unchanged.
+func OpenValue(value string) (string, error) {
+	return AtRestSealer().OpenValue(value)
+}
+
+// OpenValue decrypts a config scalar produced by SealValue. Values without the
+// sealed prefix are returned unchanged.
+func (s *Sealer) OpenValue(value string) (string, error) {
+	if !IsSealedValue(value) {
+		return value, nil
+	}
+	if s == nil {
+		return "", ErrKeyUnavailable
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, valuePrefix))
+	if err != nil {
+		return "", ErrInvalidPassphrase
+	}
+	plaintext, err := s.Open(raw)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
@@ -0,0 +1,69 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAtRestFileRoundTrip(t *testing.T) {
+	t.Cleanup(func() { SetAtRest(nil, false) })
+	if err := ConfigureAtRest(KeySource{Passphrase: "k"}, true); err != nil {
+		t.Fatalf("ConfigureAtRest: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "auth.json")
+	if err := os.WriteFile(path, []byte(`{"type":"claude"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := ReadFile(path); err != nil || string(got) != `{"type":"claude"}` {
+		t.Fatalf("plaintext read = %q, %v", got, err)
+	}
+	if err := SealFileInPlace(path); err != nil {
+		t.Fatalf("SealFileInPlace: %v", err)
+	}
+	raw, _ := os.ReadFile(path)
+	if !IsEncrypted(raw) {
+		t.Fatal("expected file to be sealed")
+	}
+	got, err := ReadFile(path)
+	if err != nil || string(got) != `{"type":"claude"}` {
+		t.Fatalf("sealed read = %q, %v", got, err)
+	}
+
+	SetAtRest(nil, false)
+	if _, err = ReadFile(path); err != ErrKeyUnavailable {
+		t.Errorf("expected ErrKeyUnavailable without key, got %v", err)
+	}
+}
+
+func TestSealValueRoundTrip(t *testing.T) {
+	t.Cleanup(func() { SetAtRest(nil, false) })
+	if err := ConfigureAtRest(KeySource{Passphrase: "k"}, true); err != nil {
+		t.Fatalf("ConfigureAtRest: %v", err)
+	}
+	sealed, err := SealValue("sk-test")
+	if err != nil {
+		t.Fatalf("SealValue: %v", err)
+	}
+	if !IsSealedValue(sealed) {
+		t.Fatalf("expected sealed prefix, got %q", sealed)
+	}
+	if got, errOpen := OpenValue(sealed); errOpen != nil || got != "sk-test" {
+		t.Errorf("OpenValue = %q, %v", got, errOpen)
+	}
+	if got, _ := OpenValue("sk-plain"); got != "sk-plain" {
+		t.Errorf("plain values should pass through, got %q", got)
+	}
+}
+
+func TestConfigureAtRestRequiresKeyWhenEnabled(t *testing.T) {
+	t.Cleanup(func() { SetAtRest(nil, false) })
+	t.Setenv(DefaultKeyEnv, "")
+	if err := ConfigureAtRest(KeySource{}, true); err == nil {
+		t.Fatal("expected error when encryption is enabled without a key")
+	}
+	if err := ConfigureAtRest(KeySource{}, false); err != nil || AtRestSealer() != nil {
+		t.Fatalf("expected no sealer without key, got %v", err)
+	}
+}
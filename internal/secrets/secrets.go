@@ -0,0 +1,140 @@
+// Package secrets provides authenticated encryption helpers for credential material
+// that is written to disk or moved between hosts.
+// Data is sealed with AES-256-GCM using a key derived from a passphrase via scrypt.
+package secrets
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// magic prefixes every sealed payload so encrypted and plaintext data can be told apart.
+var magic = []byte("CPAENC1\n")
+
+const (
+	saltLen = 16
+	keyLen  = 32
+
+	// scrypt parameters (N=2^15, r=8, p=1) as recommended for interactive use.
+	scryptN = 1 << 15
+	scryptR = 8
+	scryptP = 1
+)
+
+// ErrInvalidPassphrase is returned when a payload cannot be decrypted with the given passphrase.
+var ErrInvalidPassphrase = errors.New("secrets: invalid passphrase or corrupted data")
+
+// ErrNotEncrypted is returned when Decrypt receives data without the sealed payload header.
+var ErrNotEncrypted = errors.New("secrets: data is not encrypted")
+
+// IsEncrypted reports whether data carries the sealed payload header.
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// Encrypt seals plaintext with a key derived from passphrase.
+//
+// Parameters:
+//   - plaintext: The data to encrypt
+//   - passphrase: The secret used to derive the encryption key
+//
+// Returns:
+//   - []byte: The sealed payload (header, salt, nonce, ciphertext)
+//   - error: An error if the passphrase is empty or encryption fails
+func Encrypt(plaintext []byte, passphrase string) ([]byte, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("secrets: passphrase is required")
+	}
+	salt := make([]byte, saltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("secrets: failed to generate salt: %w", err)
+	}
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	return seal(gcm, salt, plaintext)
+}
+
+// seal writes the payload header, salt and a fresh nonce followed by the ciphertext.
+func seal(gcm cipher.AEAD, salt, plaintext []byte) ([]byte, error) {
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("secrets: failed to generate nonce: %w", err)
+	}
+
+	out := make([]byte, 0, len(magic)+len(salt)+len(nonce)+len(plaintext)+gcm.Overhead())
+	out = append(out, magic...)
+	out = append(out, salt...)
+	out = append(out, nonce...)
+	return gcm.Seal(out, nonce, plaintext, magic), nil
+}
+
+// Decrypt opens a payload produced by Encrypt.
+//
+// Parameters:
+//   - data: The sealed payload
+//   - passphrase: The secret used when the payload was encrypted
+//
+// Returns:
+//   - []byte: The decrypted plaintext
+//   - error: ErrNotEncrypted, ErrInvalidPassphrase, or a key derivation error
+func Decrypt(data []byte, passphrase string) ([]byte, error) {
+	salt, err := payloadSalt(data)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	return open(gcm, data)
+}
+
+// payloadSalt returns the key derivation salt of a sealed payload.
+func payloadSalt(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return nil, ErrNotEncrypted
+	}
+	body := data[len(magic):]
+	if len(body) < saltLen {
+		return nil, ErrInvalidPassphrase
+	}
+	return body[:saltLen], nil
+}
+
+// open decrypts a sealed payload whose salt has already been validated.
+func open(gcm cipher.AEAD, data []byte) ([]byte, error) {
+	body := data[len(magic)+saltLen:]
+	if len(body) < gcm.NonceSize() {
+		return nil, ErrInvalidPassphrase
+	}
+	nonce, ciphertext := body[:gcm.NonceSize()], body[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, magic)
+	if err != nil {
+		return nil, ErrInvalidPassphrase
+	}
+	return plaintext, nil
+}
+
+func newGCM(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keyLen)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: failed to derive key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: failed to create cipher: %w", err)
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("secrets: failed to create gcm: %w", err)
+	}
+	return gcm, nil
+}
@@ -0,0 +1,30 @@
+package secrets
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncryptDecryptRoundTrip(t *testing.T) {
+	plaintext := []byte(`{"access_token":"abc"}`)
+	sealed, err := Encrypt(plaintext, "pass")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if !IsEncrypted(sealed) {
+		t.Fatal("expected sealed payload to carry the header")
+	}
+	got, err := Decrypt(sealed, "pass")
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if !bytes.Equal(got, plaintext) {
+		t.Errorf("round trip mismatch: %s", got)
+	}
+	if _, err = Decrypt(sealed, "other"); err != ErrInvalidPassphrase {
+		t.Errorf("expected ErrInvalidPassphrase, got %v", err)
+	}
+	if _, err = Decrypt(plaintext, "pass"); err != ErrNotEncrypted {
+		t.Errorf("expected ErrNotEncrypted, got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	stateSyncStatePrefix     = "state"
+	stateSyncLogPrefix       = "logs"
+	defaultStateSyncInterval = 5 * time.Minute
+	stateSyncTimeout         = 2 * time.Minute
+)
+
+// StateSync mirrors local state files (usage and rate-limit snapshots) and, optionally,
+// the log directory to an S3-compatible bucket. The local files act as the buffer: they
+// are uploaded when changed, and a failed upload is retried on the next interval.
+type StateSync struct {
+	client   *minio.Client
+	bucket   string
+	prefix   string
+	files    []string
+	logDir   string
+	interval time.Duration
+
+	mu       sync.Mutex
+	uploaded map[string]time.Time
+}
+
+// NewStateSync creates a StateSync for cfg. files are the state files restored on start and
+// uploaded on change; logDir, when non-empty and cfg.IncludeLogs is set, is uploaded as well.
+// It returns nil, nil when cfg.Type is empty.
+func NewStateSync(cfg config.PersistenceStorageConfig, files []string, logDir string) (*StateSync, error) {
+	kind := strings.ToLower(strings.TrimSpace(cfg.Type))
+	if kind == "" {
+		return nil, nil
+	}
+	endpoint := strings.TrimSpace(cfg.Endpoint)
+	switch kind {
+	case "s3":
+		if endpoint == "" {
+			endpoint = "s3.amazonaws.com"
+		}
+	case "gcs":
+		if endpoint == "" {
+			endpoint = "storage.googleapis.com"
+		}
+	default:
+		return nil, fmt.Errorf("persistence storage: unsupported type %q", cfg.Type)
+	}
+	endpoint = strings.TrimPrefix(strings.TrimPrefix(endpoint, "https://"), "http://")
+	bucket := strings.TrimSpace(cfg.Bucket)
+	if bucket == "" {
+		return nil, fmt.Errorf("persistence storage: bucket is required")
+	}
+	if strings.TrimSpace(cfg.AccessKey) == "" || strings.TrimSpace(cfg.SecretKey) == "" {
+		return nil, fmt.Errorf("persistence storage: access-key and secret-key are required")
+	}
+
+	options := &minio.Options{
+		Creds:  credentials.NewStaticV4(strings.TrimSpace(cfg.AccessKey), strings.TrimSpace(cfg.SecretKey), ""),
+		Secure: !cfg.DisableSSL,
+		Region: strings.TrimSpace(cfg.Region),
+	}
+	if cfg.PathStyle {
+		options.BucketLookup = minio.BucketLookupPath
+	}
+	client, err := minio.New(endpoint, options)
+	if err != nil {
+		return nil, fmt.Errorf("persistence storage: create client: %w", err)
+	}
+
+	interval := time.Duration(cfg.IntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultStateSyncInterval
+	}
+	stateSync := &StateSync{
+		client:   client,
+		bucket:   bucket,
+		prefix:   strings.Trim(cfg.Prefix, "/"),
+		files:    files,
+		interval: interval,
+		uploaded: make(map[string]time.Time),
+	}
+	if cfg.IncludeLogs {
+		stateSync.logDir = logDir
+	}
+	return stateSync, nil
+}
+
+// Restore downloads state files that do not exist locally. It runs before the snapshots
+// are loaded, so a fresh container resumes from the last uploaded state.
+func (s *StateSync) Restore(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, stateSyncTimeout)
+	defer cancel()
+	for _, file := range s.files {
+		if _, err := os.Stat(file); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			log.Warnf("persistence storage: create directory for %s: %v", file, err)
+			continue
+		}
+		key := s.objectKey(stateSyncStatePrefix, filepath.Base(file))
+		if err := s.client.FGetObject(ctx, s.bucket, key, file, minio.GetObjectOptions{}); err != nil {
+			if !isObjectNotFound(err) {
+				log.Warnf("persistence storage: restore %s: %v", key, err)
+			}
+			continue
+		}
+		log.Infof("persistence storage: restored %s from %s", file, key)
+	}
+}
+
+// Run uploads changed files every interval until ctx is done.
+func (s *StateSync) Run(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Flush(ctx)
+		}
+	}
+}
+
+// Flush uploads every state and log file changed since its last successful upload.
+func (s *StateSync) Flush(ctx context.Context) {
+	if s == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), stateSyncTimeout)
+	defer cancel()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, file := range s.files {
+		s.uploadLocked(ctx, file, s.objectKey(stateSyncStatePrefix, filepath.Base(file)), "application/json")
+	}
+	if s.logDir == "" {
+		return
+	}
+	entries, err := os.ReadDir(s.logDir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Warnf("persistence storage: read log directory: %v", err)
+		}
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".log") {
+			continue
+		}
+		s.uploadLocked(ctx, filepath.Join(s.logDir, entry.Name()), s.objectKey(stateSyncLogPrefix, entry.Name()), "text/plain")
+	}
+}
+
+func (s *StateSync) uploadLocked(ctx context.Context, file, key, contentType string) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return
+	}
+	if last, ok := s.uploaded[file]; ok && !info.ModTime().After(last) {
+		return
+	}
+	if _, err = s.client.FPutObject(ctx, s.bucket, key, file, minio.PutObjectOptions{ContentType: contentType}); err != nil {
+		log.Warnf("persistence storage: upload %s: %v", key, err)
+		return
+	}
+	s.uploaded[file] = info.ModTime()
+}
+
+func (s *StateSync) objectKey(kind, name string) string {
+	if s.prefix == "" {
+		return path.Join(kind, name)
+	}
+	return path.Join(s.prefix, kind, name)
+}
@@ -440,40 +440,35 @@ func extractGeminiConfig(body []byte, provider string) ThinkingConfig {
 // extractOpenAIConfig extracts thinking configuration from OpenAI format request body.
 //
 // OpenAI API format:
-//   - reasoning_effort: "none", "low", "medium", "high" (discrete levels)
+//   - reasoning_effort: "none", "minimal", "low", "medium", "high" (discrete levels)
+//     or a numeric token budget
+//   - reasoning.max_tokens / reasoning.effort / reasoning.enabled (OpenRouter style)
 //
-// OpenAI uses level-based thinking configuration only, no numeric budget support.
-// The "none" value is treated specially to return ModeNone.
+// Numeric budgets return ModeBudget; ValidateConfig maps them to the nearest level
+// supported by level-only models. See ExtractReasoningConfig for the priority order.
 func extractOpenAIConfig(body []byte) ThinkingConfig {
-	// Check reasoning_effort (OpenAI Chat Completions format)
-	if effort := gjson.GetBytes(body, "reasoning_effort"); effort.Exists() {
-		value := effort.String()
-		if value == "none" {
-			return ThinkingConfig{Mode: ModeNone, Budget: 0}
-		}
-		return ThinkingConfig{Mode: ModeLevel, Level: ThinkingLevel(value)}
-	}
-
-	return ThinkingConfig{}
+	// Check reasoning_effort (OpenAI Chat Completions format), including numeric budgets
+	// and the reasoning.max_tokens extension.
+	config, _ := ExtractReasoningConfig(gjson.ParseBytes(body))
+	return config
 }
 
 // extractCodexConfig extracts thinking configuration from Codex format request body.
 //
 // Codex API format (OpenAI Responses API):
-//   - reasoning.effort: "none", "low", "medium", "high"
+//   - reasoning.effort: "none", "minimal", "low", "medium", "high" or a numeric budget
+//   - reasoning.max_tokens: exact token budget, takes priority over reasoning.effort
 //
 // This is similar to OpenAI but uses nested field "reasoning.effort" instead of "reasoning_effort".
 func extractCodexConfig(body []byte) ThinkingConfig {
-	// Check reasoning.effort (Codex / OpenAI Responses API format)
-	if effort := gjson.GetBytes(body, "reasoning.effort"); effort.Exists() {
-		value := effort.String()
-		if value == "none" {
-			return ThinkingConfig{Mode: ModeNone, Budget: 0}
-		}
-		return ThinkingConfig{Mode: ModeLevel, Level: ThinkingLevel(value)}
+	// Check reasoning.effort (Codex / OpenAI Responses API format), including numeric
+	// budgets and reasoning.max_tokens.
+	root := gjson.ParseBytes(body)
+	if maxTokens := root.Get("reasoning.max_tokens"); maxTokens.Exists() && maxTokens.Type == gjson.Number {
+		return budgetConfig(int(maxTokens.Int()))
 	}
-
-	return ThinkingConfig{}
+	config, _ := ParseReasoningEffort(root.Get("reasoning.effort"))
+	return config
 }
 
 // extractIFlowConfig extracts thinking configuration from iFlow format request body.
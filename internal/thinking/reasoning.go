@@ -0,0 +1,114 @@
+// Package thinking provides unified thinking configuration processing.
+//
+// This file parses OpenAI-style reasoning inputs (reasoning_effort, reasoning.effort,
+// reasoning.max_tokens) into a ThinkingConfig so translators share one interpretation.
+package thinking
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// ParseReasoningEffort interprets an OpenAI-style effort value.
+//
+// Accepted values:
+//   - Level names: "none", "auto", "minimal", "low", "medium", "high", "xhigh", "max"
+//   - Numeric budgets as JSON numbers or numeric strings: 0 → ModeNone, -1 → ModeAuto,
+//     positive values → ModeBudget
+//
+// Other strings are returned as ModeLevel so validation can report them.
+// Returns ok=false when the value is absent or empty.
+func ParseReasoningEffort(value gjson.Result) (ThinkingConfig, bool) {
+	if !value.Exists() {
+		return ThinkingConfig{}, false
+	}
+	if value.Type == gjson.Number {
+		return budgetConfig(int(value.Int())), true
+	}
+	effort := strings.ToLower(strings.TrimSpace(value.String()))
+	if effort == "" {
+		return ThinkingConfig{}, false
+	}
+	if budget, err := strconv.Atoi(effort); err == nil {
+		return budgetConfig(budget), true
+	}
+	switch effort {
+	case string(LevelNone):
+		return ThinkingConfig{Mode: ModeNone, Budget: 0}, true
+	case string(LevelAuto):
+		return ThinkingConfig{Mode: ModeAuto, Budget: -1}, true
+	}
+	return ThinkingConfig{Mode: ModeLevel, Level: ThinkingLevel(effort)}, true
+}
+
+// ExtractReasoningConfig reads the thinking request from an OpenAI Chat Completions or
+// Responses body.
+//
+// Fields, in priority order:
+//   - reasoning.max_tokens: exact token budget (OpenRouter style)
+//   - reasoning_effort: Chat Completions effort, level or numeric
+//   - reasoning.effort: Responses API effort, level or numeric
+//   - reasoning.enabled: false disables thinking, true requests dynamic thinking
+func ExtractReasoningConfig(root gjson.Result) (ThinkingConfig, bool) {
+	if maxTokens := root.Get("reasoning.max_tokens"); maxTokens.Exists() && maxTokens.Type == gjson.Number {
+		return budgetConfig(int(maxTokens.Int())), true
+	}
+	if config, ok := ParseReasoningEffort(root.Get("reasoning_effort")); ok {
+		return config, true
+	}
+	if config, ok := ParseReasoningEffort(root.Get("reasoning.effort")); ok {
+		return config, true
+	}
+	if enabled := root.Get("reasoning.enabled"); enabled.IsBool() {
+		if !enabled.Bool() {
+			return ThinkingConfig{Mode: ModeNone, Budget: 0}, true
+		}
+		return ThinkingConfig{Mode: ModeAuto, Budget: -1}, true
+	}
+	return ThinkingConfig{}, false
+}
+
+// ConfigToBudget returns the token budget a config stands for: 0 disables thinking,
+// -1 requests dynamic thinking. Levels use the standard ConvertLevelToBudget mapping.
+func ConfigToBudget(config ThinkingConfig) (int, bool) {
+	switch config.Mode {
+	case ModeNone:
+		return 0, true
+	case ModeAuto:
+		return -1, true
+	case ModeBudget:
+		return config.Budget, true
+	case ModeLevel:
+		return ConvertLevelToBudget(string(config.Level))
+	}
+	return 0, false
+}
+
+// ConfigToLevel returns the effort level a config stands for. Budgets are mapped to
+// the nearest level with ConvertBudgetToLevel.
+func ConfigToLevel(config ThinkingConfig) (string, bool) {
+	switch config.Mode {
+	case ModeNone:
+		return string(LevelNone), true
+	case ModeAuto:
+		return string(LevelAuto), true
+	case ModeBudget:
+		return ConvertBudgetToLevel(config.Budget)
+	case ModeLevel:
+		return string(config.Level), config.Level != ""
+	}
+	return "", false
+}
+
+func budgetConfig(budget int) ThinkingConfig {
+	switch {
+	case budget == 0:
+		return ThinkingConfig{Mode: ModeNone, Budget: 0}
+	case budget < 0:
+		return ThinkingConfig{Mode: ModeAuto, Budget: -1}
+	default:
+		return ThinkingConfig{Mode: ModeBudget, Budget: budget}
+	}
+}
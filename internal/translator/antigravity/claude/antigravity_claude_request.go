@@ -68,8 +68,12 @@ func ConvertClaudeRequestToAntigravity(modelName string, inputRawJSON []byte, _
 	contentsJSON := "[]"
 	hasContents := false
 
+	// signatures resolves the cached signatures of every thinking block of the history
+	// with one cache lookup.
+	signatures := cache.NewSignatureLookup(modelName)
 	messagesResult := gjson.GetBytes(rawJSON, "messages")
 	if messagesResult.IsArray() {
+		signatures.Prefetch(historyThinkingTexts(messagesResult))
 		messageResults := messagesResult.Array()
 		numMessages := len(messageResults)
 		for i := 0; i < numMessages; i++ {
@@ -101,7 +105,7 @@ func ConvertClaudeRequestToAntigravity(modelName string, inputRawJSON []byte, _
 						// Client may send stale or invalid signatures from different sessions
 						signature := ""
 						if thinkingText != "" {
-							if cachedSig := cache.GetCachedSignature(modelName, thinkingText); cachedSig != "" {
+							if cachedSig := signatures.CachedSignature(thinkingText); cachedSig != "" {
 								signature = cachedSig
 								// log.Debugf("Using cached signature for thinking block")
 							}
@@ -114,24 +118,24 @@ func ConvertClaudeRequestToAntigravity(modelName string, inputRawJSON []byte, _
 							if signatureResult.Exists() && signatureResult.String() != "" {
 								arrayClientSignatures := strings.SplitN(signatureResult.String(), "#", 2)
 								if len(arrayClientSignatures) == 2 {
-									if cache.GetModelGroup(modelName) == arrayClientSignatures[0] {
+									if signatures.ModelGroup() == arrayClientSignatures[0] {
 										clientSignature = arrayClientSignatures[1]
 									}
 								}
 							}
-							if cache.HasValidSignature(modelName, clientSignature) {
+							if signatures.HasValidSignature(clientSignature) {
 								signature = clientSignature
 							}
 							// log.Debugf("Using client-provided signature for thinking block")
 						}
 
 						// Store for subsequent tool_use in the same message
-						if cache.HasValidSignature(modelName, signature) {
+						if signatures.HasValidSignature(signature) {
 							currentMessageThinkingSignature = signature
 						}
 
 						// Skip trailing unsigned thinking blocks on last assistant message
-						isUnsigned := !cache.HasValidSignature(modelName, signature)
+						isUnsigned := !signatures.HasValidSignature(signature)
 
 						// If unsigned, skip entirely (don't convert to text)
 						// Claude requires assistant messages to start with thinking blocks when thinking is enabled
@@ -189,7 +193,7 @@ func ConvertClaudeRequestToAntigravity(modelName string, inputRawJSON []byte, _
 							// This is the approach used in opencode-google-antigravity-auth for Gemini
 							// and also works for Claude through Antigravity API
 							const skipSentinel = "skip_thought_signature_validator"
-							if cache.HasValidSignature(modelName, currentMessageThinkingSignature) {
+							if signatures.HasValidSignature(currentMessageThinkingSignature) {
 								partJSON, _ = sjson.Set(partJSON, "thoughtSignature", currentMessageThinkingSignature)
 							} else {
 								// No valid signature - use skip sentinel to bypass validation
@@ -414,3 +418,20 @@ func ConvertClaudeRequestToAntigravity(modelName string, inputRawJSON []byte, _
 
 	return outBytes
 }
+
+// historyThinkingTexts returns the text of every thinking block in messages.
+func historyThinkingTexts(messages gjson.Result) []string {
+	var texts []string
+	messages.ForEach(func(_, message gjson.Result) bool {
+		message.Get("content").ForEach(func(_, content gjson.Result) bool {
+			if content.Get("type").String() == "thinking" {
+				if text := thinking.GetThinkingText(content); text != "" {
+					texts = append(texts, text)
+				}
+			}
+			return true
+		})
+		return true
+	})
+	return texts
+}
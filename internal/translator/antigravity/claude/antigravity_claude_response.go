@@ -15,7 +15,7 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
-	log "github.com/sirupsen/logrus"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
 
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -25,20 +25,16 @@ import (
 // This structure tracks the current state of the response translation process to ensure
 // proper sequencing of SSE events and transitions between different content types.
 type Params struct {
-	HasFirstResponse     bool   // Indicates if the initial message_start event has been sent
-	ResponseType         int    // Current response type: 0=none, 1=content, 2=thinking, 3=function
-	ResponseIndex        int    // Index counter for content blocks in the streaming response
-	HasFinishReason      bool   // Tracks whether a finish reason has been observed
-	FinishReason         string // The finish reason string returned by the provider
-	HasUsageMetadata     bool   // Tracks whether usage metadata has been observed
-	PromptTokenCount     int64  // Cached prompt token count from usage metadata
-	CandidatesTokenCount int64  // Cached candidate token count from usage metadata
-	ThoughtsTokenCount   int64  // Cached thinking token count from usage metadata
-	TotalTokenCount      int64  // Cached total token count from usage metadata
-	CachedTokenCount     int64  // Cached content token count (indicates prompt caching)
-	HasSentFinalEvents   bool   // Indicates if final content/message events have been sent
-	HasToolUse           bool   // Indicates if tool use was observed in the stream
-	HasContent           bool   // Tracks whether any content (text, thinking, or tool use) has been output
+	HasFirstResponse   bool             // Indicates if the initial message_start event has been sent
+	ResponseType       int              // Current response type: 0=none, 1=content, 2=thinking, 3=function
+	ResponseIndex      int              // Index counter for content blocks in the streaming response
+	HasFinishReason    bool             // Tracks whether a finish reason has been observed
+	FinishReason       string           // The finish reason string returned by the provider
+	HasUsageMetadata   bool             // Tracks whether usage metadata has been observed
+	Usage              translator.Usage // Token counts from the latest usage metadata
+	HasSentFinalEvents bool             // Indicates if final content/message events have been sent
+	HasToolUse         bool             // Indicates if tool use was observed in the stream
+	HasContent         bool             // Tracks whether any content (text, thinking, or tool use) has been output
 
 	// Signature caching support
 	CurrentThinkingText strings.Builder // Accumulates thinking text for signature caching
@@ -278,17 +274,7 @@ func ConvertAntigravityResponseToClaude(_ context.Context, _ string, originalReq
 
 	if usageResult := gjson.GetBytes(rawJSON, "response.usageMetadata"); usageResult.Exists() {
 		params.HasUsageMetadata = true
-		params.CachedTokenCount = usageResult.Get("cachedContentTokenCount").Int()
-		params.PromptTokenCount = usageResult.Get("promptTokenCount").Int() - params.CachedTokenCount
-		params.CandidatesTokenCount = usageResult.Get("candidatesTokenCount").Int()
-		params.ThoughtsTokenCount = usageResult.Get("thoughtsTokenCount").Int()
-		params.TotalTokenCount = usageResult.Get("totalTokenCount").Int()
-		if params.CandidatesTokenCount == 0 && params.TotalTokenCount > 0 {
-			params.CandidatesTokenCount = params.TotalTokenCount - params.PromptTokenCount - params.ThoughtsTokenCount
-			if params.CandidatesTokenCount < 0 {
-				params.CandidatesTokenCount = 0
-			}
-		}
+		params.Usage = translator.UsageFromGemini(usageResult)
 	}
 
 	if params.HasUsageMetadata && params.HasFinishReason {
@@ -320,25 +306,10 @@ func appendFinalEvents(params *Params, output *string, force bool) {
 	}
 
 	stopReason := resolveStopReason(params)
-	usageOutputTokens := params.CandidatesTokenCount + params.ThoughtsTokenCount
-	if usageOutputTokens == 0 && params.TotalTokenCount > 0 {
-		usageOutputTokens = params.TotalTokenCount - params.PromptTokenCount
-		if usageOutputTokens < 0 {
-			usageOutputTokens = 0
-		}
-	}
-
 	*output = *output + "event: message_delta\n"
 	*output = *output + "data: "
-	delta := fmt.Sprintf(`{"type":"message_delta","delta":{"stop_reason":"%s","stop_sequence":null},"usage":{"input_tokens":%d,"output_tokens":%d}}`, stopReason, params.PromptTokenCount, usageOutputTokens)
-	// Add cache_read_input_tokens if cached tokens are present (indicates prompt caching is working)
-	if params.CachedTokenCount > 0 {
-		var err error
-		delta, err = sjson.Set(delta, "usage.cache_read_input_tokens", params.CachedTokenCount)
-		if err != nil {
-			log.Warnf("antigravity claude response: failed to set cache_read_input_tokens: %v", err)
-		}
-	}
+	delta := fmt.Sprintf(`{"type":"message_delta","delta":{"stop_reason":"%s","stop_sequence":null},"usage":{}}`, stopReason)
+	delta, _ = sjson.SetRaw(delta, "usage", params.Usage.Claude())
 	*output = *output + delta + "\n\n\n"
 
 	params.HasSentFinalEvents = true
@@ -374,32 +345,12 @@ func ConvertAntigravityResponseToClaudeNonStream(_ context.Context, _ string, or
 	modelName := gjson.GetBytes(requestRawJSON, "model").String()
 
 	root := gjson.ParseBytes(rawJSON)
-	promptTokens := root.Get("response.usageMetadata.promptTokenCount").Int()
-	candidateTokens := root.Get("response.usageMetadata.candidatesTokenCount").Int()
-	thoughtTokens := root.Get("response.usageMetadata.thoughtsTokenCount").Int()
-	totalTokens := root.Get("response.usageMetadata.totalTokenCount").Int()
-	cachedTokens := root.Get("response.usageMetadata.cachedContentTokenCount").Int()
-	outputTokens := candidateTokens + thoughtTokens
-	if outputTokens == 0 && totalTokens > 0 {
-		outputTokens = totalTokens - promptTokens
-		if outputTokens < 0 {
-			outputTokens = 0
-		}
-	}
+	usage := translator.UsageFromGemini(root.Get("response.usageMetadata"))
 
 	responseJSON := `{"id":"","type":"message","role":"assistant","model":"","content":null,"stop_reason":null,"stop_sequence":null,"usage":{"input_tokens":0,"output_tokens":0}}`
 	responseJSON, _ = sjson.Set(responseJSON, "id", root.Get("response.responseId").String())
 	responseJSON, _ = sjson.Set(responseJSON, "model", root.Get("response.modelVersion").String())
-	responseJSON, _ = sjson.Set(responseJSON, "usage.input_tokens", promptTokens)
-	responseJSON, _ = sjson.Set(responseJSON, "usage.output_tokens", outputTokens)
-	// Add cache_read_input_tokens if cached tokens are present (indicates prompt caching is working)
-	if cachedTokens > 0 {
-		var err error
-		responseJSON, err = sjson.Set(responseJSON, "usage.cache_read_input_tokens", cachedTokens)
-		if err != nil {
-			log.Warnf("antigravity claude response: failed to set cache_read_input_tokens: %v", err)
-		}
-	}
+	responseJSON, _ = sjson.SetRaw(responseJSON, "usage", usage.Claude())
 
 	contentArrayInitialized := false
 	ensureContentArray := func() {
@@ -509,7 +460,7 @@ func ConvertAntigravityResponseToClaudeNonStream(_ context.Context, _ string, or
 	}
 	responseJSON, _ = sjson.Set(responseJSON, "stop_reason", stopReason)
 
-	if promptTokens == 0 && outputTokens == 0 {
+	if usage.IsZero() {
 		if usageMeta := root.Get("response.usageMetadata"); !usageMeta.Exists() {
 			responseJSON, _ = sjson.Delete(responseJSON, "usage")
 		}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/misc"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	log "github.com/sirupsen/logrus"
@@ -27,26 +28,28 @@ const geminiCLIFunctionThoughtSignature = "skip_thought_signature_validator"
 // Returns:
 //   - []byte: The transformed request data in Gemini CLI API format
 func ConvertOpenAIRequestToAntigravity(modelName string, inputRawJSON []byte, _ bool) []byte {
-	rawJSON := inputRawJSON
+	rawJSON := util.ApplyMessageNames(inputRawJSON)
 	// Base envelope (no default thinkingConfig)
 	out := []byte(`{"project":"","request":{"contents":[]},"model":"gemini-2.5-pro"}`)
 
 	// Model
 	out, _ = sjson.SetBytes(out, "model", modelName)
 
-	// Apply thinking configuration: convert OpenAI reasoning_effort to Gemini CLI thinkingConfig.
+	// Apply thinking configuration: convert OpenAI reasoning_effort / reasoning.max_tokens to Gemini thinkingConfig.
 	// Inline translation-only mapping; capability checks happen later in ApplyThinking.
-	re := gjson.GetBytes(rawJSON, "reasoning_effort")
-	if re.Exists() {
-		effort := strings.ToLower(strings.TrimSpace(re.String()))
-		if effort != "" {
-			thinkingPath := "request.generationConfig.thinkingConfig"
-			if effort == "auto" {
-				out, _ = sjson.SetBytes(out, thinkingPath+".thinkingBudget", -1)
-				out, _ = sjson.SetBytes(out, thinkingPath+".includeThoughts", true)
-			} else {
-				out, _ = sjson.SetBytes(out, thinkingPath+".thinkingLevel", effort)
-				out, _ = sjson.SetBytes(out, thinkingPath+".includeThoughts", effort != "none")
+	if config, ok := thinking.ExtractReasoningConfig(gjson.ParseBytes(rawJSON)); ok {
+		thinkingPath := "request.generationConfig.thinkingConfig"
+		switch config.Mode {
+		case thinking.ModeAuto:
+			out, _ = sjson.SetBytes(out, thinkingPath+".thinkingBudget", -1)
+			out, _ = sjson.SetBytes(out, thinkingPath+".includeThoughts", true)
+		case thinking.ModeBudget:
+			out, _ = sjson.SetBytes(out, thinkingPath+".thinkingBudget", config.Budget)
+			out, _ = sjson.SetBytes(out, thinkingPath+".includeThoughts", true)
+		default:
+			if level, ok := thinking.ConfigToLevel(config); ok {
+				out, _ = sjson.SetBytes(out, thinkingPath+".thinkingLevel", level)
+				out, _ = sjson.SetBytes(out, thinkingPath+".includeThoughts", level != string(thinking.LevelNone))
 			}
 		}
 	}
@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -37,6 +38,17 @@ type ConvertAnthropicResponseToGeminiParams struct {
 	// Keyed by content_block index from Claude SSE events
 	ToolUseNames map[int]string           // function/tool name per block index
 	ToolUseArgs  map[int]*strings.Builder // accumulates partial_json across deltas
+
+	// Usage accumulates token counts from message_start and message_delta
+	Usage translator.Usage
+}
+
+// geminiUsageMetadata renders accumulated Claude usage as Gemini usageMetadata.
+func geminiUsageMetadata(usage translator.Usage) string {
+	out := usage.Gemini()
+	// Set traffic type (required by Gemini API)
+	out, _ = sjson.Set(out, "trafficType", "PROVISIONED_THROUGHPUT")
+	return out
 }
 
 // ConvertClaudeResponseToGemini converts Claude Code streaming response format to Gemini format.
@@ -96,6 +108,7 @@ func ConvertClaudeResponseToGemini(_ context.Context, modelName string, original
 		if message := root.Get("message"); message.Exists() {
 			(*param).(*ConvertAnthropicResponseToGeminiParams).ResponseID = message.Get("id").String()
 			(*param).(*ConvertAnthropicResponseToGeminiParams).Model = message.Get("model").String()
+			(*param).(*ConvertAnthropicResponseToGeminiParams).Usage.MergeClaude(message.Get("usage"))
 		}
 		return []string{}
 
@@ -175,7 +188,8 @@ func ConvertClaudeResponseToGemini(_ context.Context, modelName string, original
 				functionCall, _ = sjson.Set(functionCall, "functionCall.name", name)
 			}
 			if argsTrim != "" {
-				functionCall, _ = sjson.SetRaw(functionCall, "functionCall.args", argsTrim)
+				args, _ := translator.FinalizeToolArguments(argsTrim)
+				functionCall, _ = sjson.SetRaw(functionCall, "functionCall.args", args)
 			}
 			template, _ = sjson.SetRaw(template, "candidates.0.content.parts.-1", functionCall)
 			template, _ = sjson.Set(template, "candidates.0.finishReason", "STOP")
@@ -211,33 +225,9 @@ func ConvertClaudeResponseToGemini(_ context.Context, modelName string, original
 		}
 
 		if usage := root.Get("usage"); usage.Exists() {
-			// Basic token counts for prompt and completion
-			inputTokens := usage.Get("input_tokens").Int()
-			outputTokens := usage.Get("output_tokens").Int()
-
-			// Set basic usage metadata according to Gemini API specification
-			template, _ = sjson.Set(template, "usageMetadata.promptTokenCount", inputTokens)
-			template, _ = sjson.Set(template, "usageMetadata.candidatesTokenCount", outputTokens)
-			template, _ = sjson.Set(template, "usageMetadata.totalTokenCount", inputTokens+outputTokens)
-
-			// Add cache-related token counts if present (Claude Code API cache fields)
-			if cacheCreationTokens := usage.Get("cache_creation_input_tokens"); cacheCreationTokens.Exists() {
-				template, _ = sjson.Set(template, "usageMetadata.cachedContentTokenCount", cacheCreationTokens.Int())
-			}
-			if cacheReadTokens := usage.Get("cache_read_input_tokens"); cacheReadTokens.Exists() {
-				// Add cache read tokens to cached content count
-				existingCacheTokens := usage.Get("cache_creation_input_tokens").Int()
-				totalCacheTokens := existingCacheTokens + cacheReadTokens.Int()
-				template, _ = sjson.Set(template, "usageMetadata.cachedContentTokenCount", totalCacheTokens)
-			}
-
-			// Add thinking tokens if present (for models with reasoning capabilities)
-			if thinkingTokens := usage.Get("thinking_tokens"); thinkingTokens.Exists() {
-				template, _ = sjson.Set(template, "usageMetadata.thoughtsTokenCount", thinkingTokens.Int())
-			}
-
-			// Set traffic type (required by Gemini API)
-			template, _ = sjson.Set(template, "usageMetadata.trafficType", "PROVISIONED_THROUGHPUT")
+			acc := &(*param).(*ConvertAnthropicResponseToGeminiParams).Usage
+			acc.MergeClaude(usage)
+			template, _ = sjson.SetRaw(template, "usageMetadata", geminiUsageMetadata(*acc))
 		}
 		template, _ = sjson.Set(template, "candidates.0.finishReason", "STOP")
 
@@ -312,7 +302,6 @@ func ConvertClaudeResponseToGeminiNonStream(_ context.Context, modelName string,
 
 	// Process each streaming event and collect parts
 	var allParts []string
-	var finalUsageJSON string
 	var responseID string
 	var createdAt int64
 
@@ -335,6 +324,7 @@ func ConvertClaudeResponseToGeminiNonStream(_ context.Context, modelName string,
 				// Set creation time to current time if not provided
 				createdAt = time.Now().Unix()
 				newParam.CreatedAt = createdAt
+				newParam.Usage.MergeClaude(message.Get("usage"))
 			}
 
 		case "content_block_start":
@@ -407,7 +397,8 @@ func ConvertClaudeResponseToGeminiNonStream(_ context.Context, modelName string,
 					functionCallJSON, _ = sjson.Set(functionCallJSON, "functionCall.name", name)
 				}
 				if argsTrim != "" {
-					functionCallJSON, _ = sjson.SetRaw(functionCallJSON, "functionCall.args", argsTrim)
+					args, _ := translator.FinalizeToolArguments(argsTrim)
+					functionCallJSON, _ = sjson.SetRaw(functionCallJSON, "functionCall.args", args)
 				}
 				allParts = append(allParts, functionCallJSON)
 				// cleanup used state for this index
@@ -420,39 +411,9 @@ func ConvertClaudeResponseToGeminiNonStream(_ context.Context, modelName string,
 			}
 
 		case "message_delta":
-			// Extract final usage information using sjson for token counts and metadata
+			// Merge final usage counts into those reported by message_start
 			if usage := root.Get("usage"); usage.Exists() {
-				usageJSON := `{}`
-
-				// Basic token counts for prompt and completion
-				inputTokens := usage.Get("input_tokens").Int()
-				outputTokens := usage.Get("output_tokens").Int()
-
-				// Set basic usage metadata according to Gemini API specification
-				usageJSON, _ = sjson.Set(usageJSON, "promptTokenCount", inputTokens)
-				usageJSON, _ = sjson.Set(usageJSON, "candidatesTokenCount", outputTokens)
-				usageJSON, _ = sjson.Set(usageJSON, "totalTokenCount", inputTokens+outputTokens)
-
-				// Add cache-related token counts if present (Claude Code API cache fields)
-				if cacheCreationTokens := usage.Get("cache_creation_input_tokens"); cacheCreationTokens.Exists() {
-					usageJSON, _ = sjson.Set(usageJSON, "cachedContentTokenCount", cacheCreationTokens.Int())
-				}
-				if cacheReadTokens := usage.Get("cache_read_input_tokens"); cacheReadTokens.Exists() {
-					// Add cache read tokens to cached content count
-					existingCacheTokens := usage.Get("cache_creation_input_tokens").Int()
-					totalCacheTokens := existingCacheTokens + cacheReadTokens.Int()
-					usageJSON, _ = sjson.Set(usageJSON, "cachedContentTokenCount", totalCacheTokens)
-				}
-
-				// Add thinking tokens if present (for models with reasoning capabilities)
-				if thinkingTokens := usage.Get("thinking_tokens"); thinkingTokens.Exists() {
-					usageJSON, _ = sjson.Set(usageJSON, "thoughtsTokenCount", thinkingTokens.Int())
-				}
-
-				// Set traffic type (required by Gemini API)
-				usageJSON, _ = sjson.Set(usageJSON, "trafficType", "PROVISIONED_THROUGHPUT")
-
-				finalUsageJSON = usageJSON
+				newParam.Usage.MergeClaude(usage)
 			}
 		}
 	}
@@ -478,8 +439,8 @@ func ConvertClaudeResponseToGeminiNonStream(_ context.Context, modelName string,
 	}
 
 	// Set usage metadata
-	if finalUsageJSON != "" {
-		template, _ = sjson.SetRaw(template, "usageMetadata", finalUsageJSON)
+	if !newParam.Usage.IsZero() {
+		template, _ = sjson.SetRaw(template, "usageMetadata", geminiUsageMetadata(newParam.Usage))
 	}
 
 	return template
@@ -0,0 +1,65 @@
+package chat_completions
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/tidwall/gjson"
+)
+
+// conversationBranchKeys returns the hash chain over an OpenAI messages array:
+// keys[i] identifies the branch formed by messages[:i], so keys[len(messages)] is the
+// branch a response to this request continues. Editing an earlier message changes the
+// key of every later turn, which keeps cached thinking from leaking across branches.
+func conversationBranchKeys(messages gjson.Result) []string {
+	keys := []string{""}
+	if !messages.IsArray() {
+		return keys
+	}
+	prev := ""
+	messages.ForEach(func(_, message gjson.Result) bool {
+		prev = cache.ChainBranchKey(prev, branchTurnText(message))
+		keys = append(keys, prev)
+		return true
+	})
+	return keys
+}
+
+// branchTurnText normalises a message to the parts that survive a client round trip:
+// role, visible text without thinking markers, and tool call identifiers.
+func branchTurnText(message gjson.Result) string {
+	var sb strings.Builder
+	sb.WriteString(message.Get("role").String())
+	sb.WriteByte('\n')
+
+	content := message.Get("content")
+	if content.Type == gjson.String {
+		sb.WriteString(stripThinkingMarkers(content.String()))
+	} else if content.IsArray() {
+		content.ForEach(func(_, part gjson.Result) bool {
+			if part.Get("type").String() == "text" {
+				sb.WriteString(stripThinkingMarkers(part.Get("text").String()))
+			}
+			return true
+		})
+	}
+
+	message.Get("tool_calls").ForEach(func(_, toolCall gjson.Result) bool {
+		sb.WriteString("\ntool_call:")
+		sb.WriteString(toolCall.Get("id").String())
+		sb.WriteByte(':')
+		sb.WriteString(toolCall.Get("function.name").String())
+		return true
+	})
+	if id := message.Get("tool_call_id").String(); id != "" {
+		sb.WriteString("\ntool_result:")
+		sb.WriteString(id)
+	}
+	return sb.String()
+}
+
+func stripThinkingMarkers(text string) string {
+	text = thinkTagRegex.ReplaceAllString(text, "")
+	text = thinkIdRegex.ReplaceAllString(text, "")
+	return strings.TrimSpace(text)
+}
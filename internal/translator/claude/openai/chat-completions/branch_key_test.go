@@ -0,0 +1,35 @@
+package chat_completions
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/tidwall/gjson"
+)
+
+func TestCachedThinkingRestoredOnlyOnSameBranch(t *testing.T) {
+	thinkingText := "Branch-specific reasoning."
+	signature := "c2lnbmF0dXJlX2Zvcl9icmFuY2hfYXdhcmVfdGhpbmtpbmdfY2FjaGVfdGVzdA=="
+	thinkingID := cache.GenerateThinkingID(thinkingText)
+
+	original := gjson.Parse(`[{"role":"user","content":"Plan a trip to Rome"}]`)
+	keys := conversationBranchKeys(original)
+	cache.CacheBranchThinking(keys[len(keys)-1], thinkingID, thinkingText, signature)
+	defer cache.ClearThinkingCache("")
+
+	assistant := `{"role":"assistant","content":"<think>\n` + thinkingText + `\n</think>\n` + "```plaintext:thinkId:" + thinkingID + "```" + `\nSure."}`
+	request := func(firstUser string) []byte {
+		return []byte(`{"model":"claude-sonnet-4-5","reasoning_effort":"high","messages":[` +
+			`{"role":"user","content":"` + firstUser + `"},` + assistant + `,{"role":"user","content":"Go on"}]}`)
+	}
+
+	same := gjson.ParseBytes(ConvertOpenAIRequestToClaude("claude-sonnet-4-5", request("Plan a trip to Rome"), true))
+	if got := same.Get("messages.1.content.0.signature").String(); got != signature {
+		t.Fatalf("same branch: signature = %q, want cached signature; messages = %s", got, same.Get("messages").Raw)
+	}
+
+	edited := gjson.ParseBytes(ConvertOpenAIRequestToClaude("claude-sonnet-4-5", request("Plan a trip to Paris"), true))
+	if got := edited.Get("messages.1.content.0.signature").String(); got == signature {
+		t.Fatalf("edited branch restored thinking signed for another branch: %s", edited.Get("messages").Raw)
+	}
+}
@@ -6,11 +6,9 @@
 package chat_completions
 
 import (
-	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"math/big"
 	"regexp"
 	"strings"
 
@@ -20,6 +18,7 @@ import (
 
 	// log "github.com/sirupsen/logrus"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -40,7 +39,7 @@ var (
 )
 
 // Note: deriveSessionID đã bị loại bỏ vì không cần thiết.
-// Cache chỉ cần thinkingID là đủ để lookup.
+// Cache lookup theo thinkingID kèm branch key (hash chain các message trước đó).
 
 // ensureAssistantThinkingBlock kiểm tra và fix assistant message khi thinking enabled
 // Theo Claude API: "When thinking is enabled, a final assistant message must start with
@@ -108,12 +107,13 @@ func ensureAssistantThinkingBlock(requestJSON string) string {
 // Hỗ trợ 2 formats:
 // 1. New format: thinkId marker ```plaintext:thinkId:xxx``` -> lookup cache
 // 2. Legacy format: ```plaintext:Thinking\n...\n``` + ```plaintext:Signature:...```
-func extractThinkingFromContent(text string) []interface{} {
+// branchKey là hash chain của các message đứng trước, dùng để chỉ restore thinking của đúng branch
+func extractThinkingFromContent(text, branchKey string) []interface{} {
 	// Thử tìm thinkId marker trước (new format)
 	idMatch := thinkIdRegex.FindStringSubmatch(text)
 	if len(idMatch) > 1 {
 		thinkingID := idMatch[1]
-		entry := cache.GetCachedThinking(thinkingID)
+		entry := cache.GetBranchThinking(branchKey, thinkingID)
 
 		// Nếu tìm thấy cache với valid signature → restore thinking block
 		if entry != nil && cache.HasValidSignature("claude", entry.Signature) {
@@ -252,6 +252,52 @@ func extractThinkingFromContent(text string) []interface{} {
 	}
 }
 
+// appendSystemBlocks appends OpenAI system content to the Claude system array.
+// Content có thể là string hoặc array of content parts; mỗi text part thành một
+// text block riêng. System dạng string có sẵn được chuyển sang array trước khi append.
+func appendSystemBlocks(requestJSON string, content gjson.Result) string {
+	if !content.Exists() {
+		return requestJSON
+	}
+
+	existing := gjson.Get(requestJSON, "system")
+	if existing.Exists() && !existing.IsArray() {
+		requestJSON, _ = sjson.SetRaw(requestJSON, "system", "[]")
+		if text := existing.String(); text != "" {
+			textPart := `{"type":"text","text":""}`
+			textPart, _ = sjson.Set(textPart, "text", text)
+			requestJSON, _ = sjson.SetRaw(requestJSON, "system.-1", textPart)
+		}
+	} else if !existing.Exists() {
+		requestJSON, _ = sjson.SetRaw(requestJSON, "system", "[]")
+	}
+
+	if content.Type == gjson.String {
+		if text := content.String(); text != "" {
+			textPart := `{"type":"text","text":""}`
+			textPart, _ = sjson.Set(textPart, "text", text)
+			requestJSON, _ = sjson.SetRaw(requestJSON, "system.-1", textPart)
+		}
+	} else if content.IsArray() {
+		content.ForEach(func(_, part gjson.Result) bool {
+			if part.Get("type").String() == "text" && part.Get("text").String() != "" {
+				textPart := `{"type":"text","text":""}`
+				textPart, _ = sjson.Set(textPart, "text", part.Get("text").String())
+				if cc := part.Get("cache_control"); cc.Exists() {
+					textPart, _ = sjson.SetRaw(textPart, "cache_control", cc.Raw)
+				}
+				requestJSON, _ = sjson.SetRaw(requestJSON, "system.-1", textPart)
+			}
+			return true
+		})
+	}
+
+	if len(gjson.Get(requestJSON, "system").Array()) == 0 {
+		requestJSON, _ = sjson.Delete(requestJSON, "system")
+	}
+	return requestJSON
+}
+
 // ConvertOpenAIRequestToClaude parses and transforms an OpenAI Chat Completions API request into Claude Code API format.
 // It extracts the model name, system instruction, message contents, and tool declarations
 // from the raw JSON request and returns them in the format expected by the Claude Code API.
@@ -270,7 +316,7 @@ func extractThinkingFromContent(text string) []interface{} {
 // Returns:
 //   - []byte: The transformed request data in Claude Code API format
 func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream bool) []byte {
-	rawJSON := inputRawJSON
+	rawJSON := util.ApplyMessageNames(inputRawJSON)
 
 	if account == "" {
 		u, _ := uuid.NewRandom()
@@ -297,43 +343,24 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 
 	root := gjson.ParseBytes(rawJSON)
 
-	// Convert OpenAI reasoning_effort to Claude thinking config.
-	if v := root.Get("reasoning_effort"); v.Exists() {
-		effort := strings.ToLower(strings.TrimSpace(v.String()))
-		if effort != "" {
-			budget, ok := thinking.ConvertLevelToBudget(effort)
-			if ok {
-				switch budget {
-				case 0:
-					out, _ = sjson.Set(out, "thinking.type", "disabled")
-				case -1:
+	// Convert OpenAI reasoning_effort (level or numeric budget) and reasoning.max_tokens
+	// to Claude thinking config. Budgets are clamped to the model range in ApplyThinking.
+	if config, ok := thinking.ExtractReasoningConfig(root); ok {
+		if budget, ok := thinking.ConfigToBudget(config); ok {
+			switch budget {
+			case 0:
+				out, _ = sjson.Set(out, "thinking.type", "disabled")
+			case -1:
+				out, _ = sjson.Set(out, "thinking.type", "enabled")
+			default:
+				if budget > 0 {
 					out, _ = sjson.Set(out, "thinking.type", "enabled")
-				default:
-					if budget > 0 {
-						out, _ = sjson.Set(out, "thinking.type", "enabled")
-						out, _ = sjson.Set(out, "thinking.budget_tokens", budget)
-					}
+					out, _ = sjson.Set(out, "thinking.budget_tokens", budget)
 				}
-				// log.Debugf("Applied thinking from reasoning_effort=%s: type=%s, budget=%d", effort, gjson.Get(out, "thinking.type").String(), budget)
-			} else {
-				// log.Warnf("Failed to convert reasoning_effort=%s to budget for model=%s", effort, modelName)
 			}
 		}
 	}
 
-	// Helper for generating tool call IDs in the form: toolu_<alphanum>
-	// This ensures unique identifiers for tool calls in the Claude Code format
-	genToolCallID := func() string {
-		const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-		var b strings.Builder
-		// 24 chars random suffix for uniqueness
-		for i := 0; i < 24; i++ {
-			n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
-			b.WriteByte(letters[n.Int64()])
-		}
-		return "toolu_" + b.String()
-	}
-
 	// Model mapping to specify which Claude Code model to use
 	out, _ = sjson.Set(out, "model", modelName)
 
@@ -375,46 +402,35 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 	// Stream configuration to enable or disable streaming responses
 	out, _ = sjson.Set(out, "stream", stream)
 	if system := root.Get("system"); system.Exists() {
-		out, _ = sjson.SetRaw(out, "system", system.Raw)
+		out = appendSystemBlocks(out, system)
 	}
 
 	// Process messages and transform them to Claude Code format
 	if messages := root.Get("messages"); messages.Exists() && messages.IsArray() {
-		messageIndex := 0
-		systemMessageIndex := -1
+		branchKeys := conversationBranchKeys(messages)
+		// Client tool call IDs Claude would reject are replaced by stable toolu_ IDs that
+		// the response translator maps back, so clients keep keying results by their own IDs.
+		toolSession := toolIDSession(messages)
+		converted := make([]string, 0, len(branchKeys))
+		messageIndex := -1
 		messages.ForEach(func(_, message gjson.Result) bool {
+			messageIndex++
+			branchKey := branchKeys[messageIndex]
 			role := message.Get("role").String()
 			contentResult := message.Get("content")
 
 			switch role {
-			case "system":
-				if systemMessageIndex == -1 {
-					systemMsg := `{"role":"user","content":[]}`
-					out, _ = sjson.SetRaw(out, "messages.-1", systemMsg)
-					systemMessageIndex = messageIndex
-					messageIndex++
-				}
-				if contentResult.Exists() && contentResult.Type == gjson.String && contentResult.String() != "" {
-					textPart := `{"type":"text","text":""}`
-					textPart, _ = sjson.Set(textPart, "text", contentResult.String())
-					out, _ = sjson.SetRaw(out, fmt.Sprintf("messages.%d.content.-1", systemMessageIndex), textPart)
-				} else if contentResult.Exists() && contentResult.IsArray() {
-					contentResult.ForEach(func(_, part gjson.Result) bool {
-						if part.Get("type").String() == "text" {
-							textPart := `{"type":"text","text":""}`
-							textPart, _ = sjson.Set(textPart, "text", part.Get("text").String())
-							out, _ = sjson.SetRaw(out, fmt.Sprintf("messages.%d.content.-1", systemMessageIndex), textPart)
-						}
-						return true
-					})
-				}
+			case "system", "developer":
+				// Mỗi system/developer message được giữ thành text block riêng trong system array
+				// để cache_control có thể đặt ở block ổn định cuối cùng
+				out = appendSystemBlocks(out, contentResult)
 			case "user", "assistant":
 				msg := `{"role":"","content":[]}`
 				msg, _ = sjson.Set(msg, "role", role)
 
 				// Handle content based on its type (string or array)
 				if contentResult.Exists() && contentResult.Type == gjson.String && contentResult.String() != "" {
-					parts := extractThinkingFromContent(contentResult.String())
+					parts := extractThinkingFromContent(contentResult.String(), branchKey)
 					for _, part := range parts {
 						msg, _ = sjson.Set(msg, "content.-1", part)
 					}
@@ -424,7 +440,7 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 
 						switch partType {
 						case "text":
-							parts := extractThinkingFromContent(part.Get("text").String())
+							parts := extractThinkingFromContent(part.Get("text").String(), branchKey)
 							for _, p := range parts {
 								msg, _ = sjson.Set(msg, "content.-1", p)
 							}
@@ -461,7 +477,7 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 						case "tool_use":
 							// Handle tool use messages conversion
 							toolUse := `{"type":"tool_use","id":"","name":"","input":{}}`
-							toolUse, _ = sjson.Set(toolUse, "id", part.Get("id").String())
+							toolUse, _ = sjson.Set(toolUse, "id", cache.ClaudeToolID(toolSession, part.Get("id").String()))
 							toolUse, _ = sjson.Set(toolUse, "name", part.Get("name").String())
 							toolUse, _ = sjson.SetRaw(toolUse, "input", part.Get("input").Raw)
 
@@ -470,7 +486,7 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 						case "tool_result":
 							// Handle tool result messages conversion
 							toolResult := `{"type":"tool_result","tool_use_id":"","content":""}`
-							toolResult, _ = sjson.Set(toolResult, "tool_use_id", part.Get("tool_use_id").String())
+							toolResult, _ = sjson.Set(toolResult, "tool_use_id", cache.ClaudeToolID(toolSession, part.Get("tool_use_id").String()))
 							toolResult, _ = sjson.Set(toolResult, "content", part.Get("content").String())
 							msg, _ = sjson.SetRaw(msg, "content.-1", toolResult)
 						}
@@ -480,12 +496,9 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 
 				// Handle tool calls (for assistant messages)
 				if toolCalls := message.Get("tool_calls"); toolCalls.Exists() && toolCalls.IsArray() && role == "assistant" {
-					toolCalls.ForEach(func(_, toolCall gjson.Result) bool {
+					toolCalls.ForEach(func(callIndex, toolCall gjson.Result) bool {
 						if toolCall.Get("type").String() == "function" {
-							toolCallID := toolCall.Get("id").String()
-							if toolCallID == "" {
-								toolCallID = genToolCallID()
-							}
+							toolCallID := claudeToolID(toolSession, toolCall.Get("id").String(), fmt.Sprintf("%d.%d", messageIndex, callIndex.Int()))
 
 							function := toolCall.Get("function")
 							toolUse := `{"type":"tool_use","id":"","name":"","input":{}}`
@@ -515,22 +528,24 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 					})
 				}
 
-				out, _ = sjson.SetRaw(out, "messages.-1", msg)
-				messageIndex++
+				if role == "assistant" {
+					msg = restoreToolCallLayout(msg, message)
+				}
+				converted = append(converted, msg)
 
 			case "tool":
 				// Handle tool result messages conversion
-				toolCallID := message.Get("tool_call_id").String()
+				toolCallID := cache.ClaudeToolID(toolSession, message.Get("tool_call_id").String())
 				content := message.Get("content").String()
 
 				msg := `{"role":"user","content":[{"type":"tool_result","tool_use_id":"","content":""}]}`
 				msg, _ = sjson.Set(msg, "content.0.tool_use_id", toolCallID)
 				msg, _ = sjson.Set(msg, "content.0.content", content)
-				out, _ = sjson.SetRaw(out, "messages.-1", msg)
-				messageIndex++
+				converted = append(converted, msg)
 			}
 			return true
 		})
+		out = setMessages(out, converted)
 	}
 
 	// Tools mapping: OpenAI tools -> Claude Code tools
@@ -601,6 +616,27 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 		}
 	}
 
+	// Rename tool_call_ids reused across turns; Claude requires unique tool_use IDs.
+	out = rewriteDuplicateToolIDs(out)
+
+	// Merge same-role runs before checking tool pairing, then again in case dropping
+	// orphans or empty messages left two turns of the same role next to each other.
+	out = mergeConsecutiveRoles(out)
+
+	// Drop tool results whose tool call was trimmed from the history.
+	out = dropOrphanToolResults(out)
+
+	// Emulate OpenAI predicted outputs with an assistant prefill when configured.
+	out = applyPredictionPrefill(out, root)
+
+	// Sanitize text and trim the assistant prefill before empty messages are handled,
+	// since trimming can leave a prefill empty.
+	out = scrubRequestText(out)
+
+	// Prune empty messages or fill them with a placeholder block.
+	out = normalizeEmptyContent(out)
+	out = mergeConsecutiveRoles(out)
+
 	// Fix assistant messages when thinking is enabled
 	// Claude API yêu cầu: "When thinking is enabled, a final assistant message must start
 	// with a thinking block (preceeding the lastmost set of tool_use and tool_result blocks)"
@@ -0,0 +1,117 @@
+package chat_completions
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+// TestConvertOpenAIRequestToClaude_MultipleSystemMessages verifies that every
+// OpenAI system message becomes its own Claude system text block and that the
+// cache breakpoint lands on the last one.
+func TestConvertOpenAIRequestToClaude_MultipleSystemMessages(t *testing.T) {
+	input := `{
+		"model": "gpt-4",
+		"messages": [
+			{"role": "system", "content": "You are helpful."},
+			{"role": "system", "content": [{"type": "text", "text": "Answer briefly."}, {"type": "text", "text": ""}]},
+			{"role": "user", "content": "Hi"}
+		]
+	}`
+
+	out := ConvertOpenAIRequestToClaude("claude-sonnet-4", []byte(input), false)
+	result := gjson.ParseBytes(out)
+
+	system := result.Get("system").Array()
+	if len(system) != 2 {
+		t.Fatalf("expected 2 system blocks, got %d: %s", len(system), result.Get("system").Raw)
+	}
+	if got := system[0].Get("text").String(); got != "You are helpful." {
+		t.Errorf("system[0].text = %q", got)
+	}
+	if got := system[1].Get("text").String(); got != "Answer briefly." {
+		t.Errorf("system[1].text = %q", got)
+	}
+	if system[0].Get("cache_control").Exists() {
+		t.Errorf("system[0] should not carry cache_control")
+	}
+	if got := system[1].Get("cache_control.type").String(); got != "ephemeral" {
+		t.Errorf("system[1].cache_control.type = %q, want ephemeral", got)
+	}
+
+	messages := result.Get("messages").Array()
+	if len(messages) != 1 || messages[0].Get("role").String() != "user" {
+		t.Fatalf("expected a single user message, got %s", result.Get("messages").Raw)
+	}
+}
+
+// TestConvertOpenAIRequestToClaude_TopLevelSystemString verifies that a
+// top-level string system prompt is kept ahead of system messages.
+func TestConvertOpenAIRequestToClaude_TopLevelSystemString(t *testing.T) {
+	input := `{
+		"system": "Base prompt.",
+		"messages": [
+			{"role": "system", "content": "Extra rules."},
+			{"role": "user", "content": "Hi"}
+		]
+	}`
+
+	out := ConvertOpenAIRequestToClaude("claude-sonnet-4", []byte(input), false)
+	system := gjson.GetBytes(out, "system").Array()
+	if len(system) != 2 {
+		t.Fatalf("expected 2 system blocks, got %d", len(system))
+	}
+	if system[0].Get("text").String() != "Base prompt." || system[1].Get("text").String() != "Extra rules." {
+		t.Errorf("unexpected system blocks: %s", gjson.GetBytes(out, "system").Raw)
+	}
+}
+
+// TestConvertOpenAIRequestToClaude_DeveloperRole verifies that OpenAI developer
+// messages are mapped into the Claude system array instead of being dropped.
+func TestConvertOpenAIRequestToClaude_DeveloperRole(t *testing.T) {
+	input := `{
+		"messages": [
+			{"role": "developer", "content": "Follow the style guide."},
+			{"role": "user", "content": "Hi"}
+		]
+	}`
+
+	out := ConvertOpenAIRequestToClaude("claude-sonnet-4", []byte(input), false)
+	result := gjson.ParseBytes(out)
+	if got := result.Get("system.0.text").String(); got != "Follow the style guide." {
+		t.Fatalf("system.0.text = %q, want developer content", got)
+	}
+	if got := len(result.Get("messages").Array()); got != 1 {
+		t.Errorf("expected 1 message, got %d", got)
+	}
+}
+
+// TestConvertOpenAIRequestToClaude_NumericReasoning verifies that numeric efforts and
+// reasoning.max_tokens map to exact Claude budgets and that levels keep their mapping.
+func TestConvertOpenAIRequestToClaude_NumericReasoning(t *testing.T) {
+	cases := []struct {
+		name       string
+		reasoning  string
+		wantType   string
+		wantBudget int64
+	}{
+		{name: "numeric effort", reasoning: `"reasoning_effort": 6000`, wantType: "enabled", wantBudget: 6000},
+		{name: "numeric string effort", reasoning: `"reasoning_effort": "3000"`, wantType: "enabled", wantBudget: 3000},
+		{name: "max tokens", reasoning: `"reasoning": {"max_tokens": 12000}`, wantType: "enabled", wantBudget: 12000},
+		{name: "minimal level", reasoning: `"reasoning_effort": "minimal"`, wantType: "enabled", wantBudget: 512},
+		{name: "none level", reasoning: `"reasoning_effort": "none"`, wantType: "disabled"},
+		{name: "zero budget", reasoning: `"reasoning_effort": 0`, wantType: "disabled"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			input := `{"model":"gpt-4",` + tc.reasoning + `,"messages":[{"role":"user","content":"Hi"}]}`
+			result := gjson.ParseBytes(ConvertOpenAIRequestToClaude("claude-sonnet-4", []byte(input), false))
+			if got := result.Get("thinking.type").String(); got != tc.wantType {
+				t.Fatalf("thinking.type = %q, want %q: %s", got, tc.wantType, result.Get("thinking").Raw)
+			}
+			if got := result.Get("thinking.budget_tokens").Int(); got != tc.wantBudget {
+				t.Fatalf("thinking.budget_tokens = %d, want %d", got, tc.wantBudget)
+			}
+		})
+	}
+}
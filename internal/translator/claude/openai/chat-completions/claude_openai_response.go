@@ -9,17 +9,19 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
 
 // Note: deriveSessionIDFromRequest đã bị loại bỏ vì không cần thiết.
-// Cache chỉ cần thinkingID là đủ để lookup.
+// Cache lookup theo thinkingID kèm branch key (hash chain các message trước đó).
 
 var (
 	dataTag = []byte("data:")
@@ -34,6 +36,12 @@ type ConvertAnthropicResponseToOpenAIParams struct {
 	ToolCallsAccumulator map[int]*ToolCallAccumulator
 	// Thinking accumulator for streaming
 	ThinkingAccumulator map[int]*ThinkingAccumulator
+	// Usage accumulates token counts from message_start and message_delta
+	Usage translator.Usage
+	// Citations maps text block citations onto content annotations
+	Citations translator.CitationTracker
+	// layout tracks where text falls relative to tool calls
+	layout toolCallLayout
 }
 
 // ToolCallAccumulator holds the state for accumulating tool call data
@@ -101,6 +109,7 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 		if message := root.Get("message"); message.Exists() {
 			(*param).(*ConvertAnthropicResponseToOpenAIParams).ResponseID = message.Get("id").String()
 			(*param).(*ConvertAnthropicResponseToOpenAIParams).CreatedAt = time.Now().Unix()
+			(*param).(*ConvertAnthropicResponseToOpenAIParams).Usage.MergeClaude(message.Get("usage"))
 
 			template, _ = sjson.Set(template, "id", (*param).(*ConvertAnthropicResponseToOpenAIParams).ResponseID)
 			template, _ = sjson.Set(template, "model", modelName)
@@ -108,6 +117,10 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 
 			// Set initial role to assistant for the response
 			template, _ = sjson.Set(template, "choices.0.delta.role", "assistant")
+			if prefill := predictionPrefill(originalRequestRawJSON, requestRawJSON); prefill != "" {
+				template = setDeltaContent(template, (*param).(*ConvertAnthropicResponseToOpenAIParams), prefill)
+				(*param).(*ConvertAnthropicResponseToOpenAIParams).layout.addText(prefill)
+			}
 
 			// Initialize tool calls accumulator for tracking tool call progress
 			if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator == nil {
@@ -127,7 +140,8 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 
 			if blockType == "tool_use" {
 				// Start of tool call - initialize accumulator to track arguments
-				toolCallID := contentBlock.Get("id").String()
+				session := toolIDSession(gjson.GetBytes(originalRequestRawJSON, "messages"))
+				toolCallID := cache.ClientToolID(session, contentBlock.Get("id").String())
 				toolName := contentBlock.Get("name").String()
 				index := int(root.Get("index").Int())
 
@@ -139,6 +153,7 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 					ID:   toolCallID,
 					Name: toolName,
 				}
+				(*param).(*ConvertAnthropicResponseToOpenAIParams).layout.addToolCall(toolCallID)
 
 				// Don't output anything yet - wait for complete tool call
 				return []string{}
@@ -153,8 +168,12 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 				(*param).(*ConvertAnthropicResponseToOpenAIParams).ThinkingAccumulator[index] = &ThinkingAccumulator{}
 
 				// Stream opening <think> tag
-				template, _ = sjson.Set(template, "choices.0.delta.content", "<think>\n")
+				template = setDeltaContent(template, (*param).(*ConvertAnthropicResponseToOpenAIParams), "<think>\n")
 				return []string{template}
+			} else if blockType == "text" {
+				(*param).(*ConvertAnthropicResponseToOpenAIParams).Citations.StartBlock(int(root.Get("index").Int()), contentBlock)
+			} else if _, known := knownContentBlockTypes[blockType]; !known {
+				return unknownEventChunk(template, "content_block_start."+blockType, root)
 			}
 		}
 		return []string{}
@@ -169,7 +188,8 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 			case "text_delta":
 				// Text content delta - send incremental text updates
 				if text := delta.Get("text"); text.Exists() {
-					template, _ = sjson.Set(template, "choices.0.delta.content", text.String())
+					template = setDeltaContent(template, (*param).(*ConvertAnthropicResponseToOpenAIParams), text.String())
+					(*param).(*ConvertAnthropicResponseToOpenAIParams).layout.addText(text.String())
 					hasContent = true
 				}
 			case "thinking_delta":
@@ -187,7 +207,7 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 						}
 					}
 					// Stream escaped thinking delta để hiển thị
-					template, _ = sjson.Set(template, "choices.0.delta.content", originalThinkingText)
+					template = setDeltaContent(template, (*param).(*ConvertAnthropicResponseToOpenAIParams), originalThinkingText)
 					hasContent = true
 				}
 			case "signature_delta":
@@ -214,6 +234,12 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 				}
 				// Don't output anything yet - wait for complete tool call
 				return []string{}
+			case "citations_delta":
+				// Citations are emitted as annotations once the cited text block ends
+				(*param).(*ConvertAnthropicResponseToOpenAIParams).Citations.AddCitation(int(root.Get("index").Int()), delta.Get("citation"))
+				return []string{}
+			default:
+				return unknownEventChunk(template, "content_block_delta."+deltaType, root)
 			}
 		}
 		if hasContent {
@@ -226,19 +252,19 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 		// End of content block - output complete tool call if it's a tool_use block or thinking if it's a thinking block
 		index := int(root.Get("index").Int())
 
+		// Cited text blocks end with their annotations
+		if spans := (*param).(*ConvertAnthropicResponseToOpenAIParams).Citations.EndBlock(index); len(spans) > 0 {
+			for _, span := range spans {
+				template, _ = sjson.SetRaw(template, "choices.0.delta.annotations.-1", span.ChatAnnotation())
+			}
+			return []string{template}
+		}
+
 		// Check for tool call accumulator
 		if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator != nil {
 			if accumulator, exists := (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator[index]; exists {
 				// Build complete tool call with accumulated arguments
-				arguments := accumulator.Arguments.String()
-				if arguments == "" {
-					arguments = "{}"
-				}
-				template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.index", index)
-				template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.id", accumulator.ID)
-				template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.type", "function")
-				template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.function.name", accumulator.Name)
-				template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.function.arguments", arguments)
+				template = setToolCallDelta(template, index, accumulator)
 
 				// Clean up the accumulator for this index
 				delete((*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator, index)
@@ -259,13 +285,14 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 
 				// Cache thinking với signature
 				if thinkingText != "" {
-					cache.CacheThinking(thinkingID, thinkingText, signatureText)
+					branchKeys := conversationBranchKeys(gjson.GetBytes(originalRequestRawJSON, "messages"))
+					cache.CacheBranchThinking(branchKeys[len(branchKeys)-1], thinkingID, thinkingText, signatureText)
 					// log.Debugf("Cached thinking block (thinkingID=%s, textLen=%d)", thinkingID, len(thinkingText))
 				}
 
 				// Stream closing </think> tag + hidden thinkId marker
 				closingContent := "\n</think>\n```plaintext:thinkId:" + thinkingID + "```\n"
-				template, _ = sjson.Set(template, "choices.0.delta.content", closingContent)
+				template = setDeltaContent(template, (*param).(*ConvertAnthropicResponseToOpenAIParams), closingContent)
 
 				// Clean up the accumulator for this index
 				delete((*param).(*ConvertAnthropicResponseToOpenAIParams).ThinkingAccumulator, index)
@@ -277,9 +304,25 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 		return []string{}
 
 	case "message_delta":
+		// With fine-grained tool streaming a tool_use block may still be open when the
+		// message ends (e.g. at max_tokens); emit those tool calls before finishing.
+		var results []string
+		if accumulators := (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator; len(accumulators) > 0 {
+			indexes := make([]int, 0, len(accumulators))
+			for index := range accumulators {
+				indexes = append(indexes, index)
+			}
+			sort.Ints(indexes)
+			for _, index := range indexes {
+				results = append(results, setToolCallDelta(template, index, accumulators[index]))
+				delete(accumulators, index)
+			}
+		}
+
 		// Handle message-level changes including stop reason and usage
 		if delta := root.Get("delta"); delta.Exists() {
 			if stopReason := delta.Get("stop_reason"); stopReason.Exists() {
+				(*param).(*ConvertAnthropicResponseToOpenAIParams).layout.record()
 				(*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason = mapAnthropicStopReasonToOpenAI(stopReason.String())
 				template, _ = sjson.Set(template, "choices.0.finish_reason", (*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason)
 			}
@@ -287,17 +330,12 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 
 		// Handle usage information for token counts
 		if usage := root.Get("usage"); usage.Exists() {
-			inputTokens := usage.Get("input_tokens").Int()
-			outputTokens := usage.Get("output_tokens").Int()
-			cacheReadInputTokens := usage.Get("cache_read_input_tokens").Int()
-			cacheCreationInputTokens := usage.Get("cache_creation_input_tokens").Int()
-			template, _ = sjson.Set(template, "usage.input_tokens", inputTokens)
-			template, _ = sjson.Set(template, "usage.output_tokens", outputTokens)
-			template, _ = sjson.Set(template, "usage.cache_read_input_tokens", cacheReadInputTokens)
-			template, _ = sjson.Set(template, "usage.cache_creation_input_tokens", cacheCreationInputTokens)
-			log.Infof("Request Claude %s. input_tokens: %d, output_tokens: %d, cache_creation_input_tokens: %d, cache_read_input_tokens: %d, totalTokens: %d.", modelName, inputTokens, outputTokens, cacheCreationInputTokens, cacheReadInputTokens, inputTokens+outputTokens+cacheCreationInputTokens+cacheReadInputTokens)
+			acc := &(*param).(*ConvertAnthropicResponseToOpenAIParams).Usage
+			acc.MergeClaude(usage)
+			template, _ = sjson.SetRaw(template, "usage", acc.OpenAIChat())
+			log.Infof("Request Claude %s. input_tokens: %d, output_tokens: %d, cache_creation_input_tokens: %d, cache_read_input_tokens: %d, totalTokens: %d.", modelName, acc.InputTokens, acc.OutputTokens, acc.CacheCreationTokens, acc.CachedTokens, acc.Total())
 		}
-		return []string{template}
+		return append(results, template)
 
 	case "message_stop":
 		// Final message event - no additional output needed
@@ -318,9 +356,58 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 		return []string{}
 
 	default:
-		// Unknown event type - ignore
+		// Unknown event type - skipped or surfaced depending on the configured mode
+		return unknownEventChunk(template, eventType, root)
+	}
+}
+
+// knownContentBlockTypes are Claude content blocks this translator handles or deliberately
+// ignores; other block types are reported as unknown events.
+var knownContentBlockTypes = map[string]struct{}{
+	"text":              {},
+	"tool_use":          {},
+	"thinking":          {},
+	"redacted_thinking": {},
+}
+
+// unknownEventChunk counts an upstream event the translator cannot map. In passthrough
+// mode the event is surfaced as an extension chunk with empty choices, which OpenAI
+// clients already accept for usage-only chunks; otherwise it is skipped.
+func unknownEventChunk(template, event string, raw gjson.Result) []string {
+	translator.RecordUnknownEvent("claude->openai", event)
+	if translator.UnknownEventMode() != translator.UnknownEventPassthrough {
 		return []string{}
 	}
+	out, _ := sjson.SetRaw(template, "choices", "[]")
+	out, _ = sjson.Set(out, "extension.type", "anthropic."+event)
+	out, _ = sjson.SetRaw(out, "extension.data", raw.Raw)
+	return []string{out}
+}
+
+// setDeltaContent writes text as the chunk's content delta and advances citation offsets.
+func setDeltaContent(template string, params *ConvertAnthropicResponseToOpenAIParams, text string) string {
+	params.Citations.Advance(text)
+	template, _ = sjson.Set(template, "choices.0.delta.content", text)
+	return template
+}
+
+// setToolCallDelta writes a complete tool call into an OpenAI stream chunk.
+func setToolCallDelta(template string, index int, accumulator *ToolCallAccumulator) string {
+	template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.index", index)
+	template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.id", accumulator.ID)
+	template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.type", "function")
+	template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.function.name", accumulator.Name)
+	template, _ = sjson.Set(template, "choices.0.delta.tool_calls.0.function.arguments", toolCallArguments(accumulator.Arguments.String()))
+	return template
+}
+
+// toolCallArguments returns accumulated tool input as OpenAI function arguments. With
+// fine-grained tool streaming Claude sends tool input unvalidated, so a truncated stream
+// may leave it partial; it is repaired when possible and otherwise wrapped as
+// {"INVALID_JSON": "<raw>"} to keep the arguments parseable.
+func toolCallArguments(raw string) string {
+	args, _ := translator.FinalizeToolArguments(raw)
+	return args
 }
 
 // mapAnthropicStopReasonToOpenAI maps Anthropic stop reasons to OpenAI stop reasons
@@ -371,8 +458,12 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 	var model string
 	var createdAt int64
 	var stopReason string
+	var usage translator.Usage
 	var contentParts []string
+	var citations translator.CitationTracker
+	var annotations []string
 	toolCallsAccumulator := make(map[int]*ToolCallAccumulator)
+	var layout toolCallLayout
 
 	for _, chunk := range chunks {
 		root := gjson.ParseBytes(chunk)
@@ -385,6 +476,12 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 				messageID = message.Get("id").String()
 				model = message.Get("model").String()
 				createdAt = time.Now().Unix()
+				usage.MergeClaude(message.Get("usage"))
+				if prefill := predictionPrefill(originalRequestRawJSON, requestRawJSON); prefill != "" {
+					contentParts = append(contentParts, prefill)
+					citations.Advance(prefill)
+					layout.addText(prefill)
+				}
 			}
 
 		case "content_block_start":
@@ -398,10 +495,14 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 				} else if blockType == "tool_use" {
 					// Initialize tool call accumulator for this index
 					index := int(root.Get("index").Int())
+					session := toolIDSession(gjson.GetBytes(originalRequestRawJSON, "messages"))
 					toolCallsAccumulator[index] = &ToolCallAccumulator{
-						ID:   contentBlock.Get("id").String(),
+						ID:   cache.ClientToolID(session, contentBlock.Get("id").String()),
 						Name: contentBlock.Get("name").String(),
 					}
+					layout.addToolCall(toolCallsAccumulator[index].ID)
+				} else if blockType == "text" {
+					citations.StartBlock(int(root.Get("index").Int()), contentBlock)
 				}
 			}
 
@@ -416,7 +517,11 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 					// Accumulate text content
 					if text := delta.Get("text"); text.Exists() {
 						contentParts = append(contentParts, text.String())
+						citations.Advance(text.String())
+						layout.addText(text.String())
 					}
+				case "citations_delta":
+					citations.AddCitation(int(root.Get("index").Int()), delta.Get("citation"))
 				case "thinking_delta":
 					// Accumulate reasoning/thinking content
 					// if thinking := delta.Get("thinking"); thinking.Exists() {
@@ -447,6 +552,9 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 		case "content_block_stop":
 			// Finalize tool call arguments or thinking block for this index when content block ends
 			index := int(root.Get("index").Int())
+			for _, span := range citations.EndBlock(index) {
+				annotations = append(annotations, span.ChatAnnotation())
+			}
 			if accumulator, exists := toolCallsAccumulator[index]; exists {
 				if accumulator.Arguments.Len() == 0 {
 					accumulator.Arguments.WriteString("{}")
@@ -460,27 +568,25 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 					stopReason = sr.String()
 				}
 			}
-			if usage := root.Get("usage"); usage.Exists() {
-				inputTokens := usage.Get("input_tokens").Int()
-				outputTokens := usage.Get("output_tokens").Int()
-				cacheReadInputTokens := usage.Get("cache_read_input_tokens").Int()
-				cacheCreationInputTokens := usage.Get("cache_creation_input_tokens").Int()
-				out, _ = sjson.Set(out, "usage.prompt_tokens", inputTokens+cacheCreationInputTokens)
-				out, _ = sjson.Set(out, "usage.completion_tokens", outputTokens)
-				out, _ = sjson.Set(out, "usage.total_tokens", inputTokens+outputTokens)
-				out, _ = sjson.Set(out, "usage.prompt_tokens_details.cached_tokens", cacheReadInputTokens)
-			}
+			usage.MergeClaude(root.Get("usage"))
 		}
 	}
+	out, _ = sjson.SetRaw(out, "usage", usage.OpenAIChat())
 
 	// Set basic response fields including message ID, creation time, and model
 	out, _ = sjson.Set(out, "id", messageID)
 	out, _ = sjson.Set(out, "created", createdAt)
 	out, _ = sjson.Set(out, "model", model)
 
-	// Set accumulated content
+	// Set accumulated content; a tools-only turn has null content, as from OpenAI
 	if len(contentParts) > 0 {
 		out, _ = sjson.Set(out, "choices.0.message.content", strings.Join(contentParts, ""))
+	} else if len(toolCallsAccumulator) > 0 {
+		out, _ = sjson.SetRaw(out, "choices.0.message.content", "null")
+	}
+	layout.record()
+	for _, annotation := range annotations {
+		out, _ = sjson.SetRaw(out, "choices.0.message.annotations.-1", annotation)
 	}
 
 	// Set tool calls if any were accumulated during processing
@@ -499,7 +605,7 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 				continue
 			}
 
-			arguments := accumulator.Arguments.String()
+			arguments := toolCallArguments(accumulator.Arguments.String())
 
 			idPath := fmt.Sprintf("choices.0.message.tool_calls.%d.id", toolCallsCount)
 			typePath := fmt.Sprintf("choices.0.message.tool_calls.%d.type", toolCallsCount)
@@ -0,0 +1,192 @@
+package chat_completions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertClaudeResponseToOpenAINonStream_CacheUsage(t *testing.T) {
+	raw := []byte(`data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-5","usage":{"input_tokens":10,"cache_read_input_tokens":300,"cache_creation_input_tokens":50,"output_tokens":1}}}
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"hi"}}
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":20}}
+data: {"type":"message_stop"}`)
+
+	out := ConvertClaudeResponseToOpenAINonStream(context.Background(), "", nil, nil, raw, nil)
+	usage := gjson.Get(out, "usage")
+	if got := usage.Get("prompt_tokens").Int(); got != 360 {
+		t.Fatalf("prompt_tokens = %d, want 360", got)
+	}
+	if got := usage.Get("completion_tokens").Int(); got != 20 {
+		t.Fatalf("completion_tokens = %d, want 20", got)
+	}
+	if got := usage.Get("total_tokens").Int(); got != 380 {
+		t.Fatalf("total_tokens = %d, want 380", got)
+	}
+	if got := usage.Get("prompt_tokens_details.cached_tokens").Int(); got != 300 {
+		t.Fatalf("cached_tokens = %d, want 300", got)
+	}
+	if got := usage.Get("prompt_tokens_details.cache_creation_tokens").Int(); got != 50 {
+		t.Fatalf("cache_creation_tokens = %d, want 50", got)
+	}
+}
+
+func TestConvertClaudeResponseToOpenAI_StreamCacheUsage(t *testing.T) {
+	var param any
+	ctx := context.Background()
+	ConvertClaudeResponseToOpenAI(ctx, "claude-sonnet-4-5", nil, nil,
+		[]byte(`data: {"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":10,"cache_read_input_tokens":300,"output_tokens":1}}}`), &param)
+	chunks := ConvertClaudeResponseToOpenAI(ctx, "claude-sonnet-4-5", nil, nil,
+		[]byte(`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":20}}`), &param)
+	if len(chunks) != 1 {
+		t.Fatalf("expected one chunk, got %d", len(chunks))
+	}
+	usage := gjson.Get(chunks[0], "usage")
+	if got := usage.Get("prompt_tokens").Int(); got != 310 {
+		t.Fatalf("prompt_tokens = %d, want 310", got)
+	}
+	if got := usage.Get("prompt_tokens_details.cached_tokens").Int(); got != 300 {
+		t.Fatalf("cached_tokens = %d, want 300", got)
+	}
+	if usage.Get("prompt_tokens_details.cache_creation_tokens").Exists() {
+		t.Fatalf("cache_creation_tokens should be omitted when zero: %s", usage.Raw)
+	}
+}
+
+func TestConvertClaudeResponseToOpenAI_UnknownEvents(t *testing.T) {
+	defer translator.SetUnknownEventMode(translator.UnknownEventSkip)
+	countOf := func(event string) int64 {
+		for _, entry := range translator.UnknownEvents() {
+			if entry.Translator == "claude->openai" && entry.Event == event {
+				return entry.Count
+			}
+		}
+		return 0
+	}
+
+	var param any
+	ctx := context.Background()
+	ConvertClaudeResponseToOpenAI(ctx, "claude-sonnet-4-5", nil, nil,
+		[]byte(`data: {"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":1}}}`), &param)
+
+	before := countOf("future_event")
+	translator.SetUnknownEventMode(translator.UnknownEventSkip)
+	if chunks := ConvertClaudeResponseToOpenAI(ctx, "claude-sonnet-4-5", nil, nil, []byte(`data: {"type":"future_event","value":1}`), &param); len(chunks) != 0 {
+		t.Fatalf("skip mode emitted %v", chunks)
+	}
+	if got := countOf("future_event"); got != before+1 {
+		t.Fatalf("unknown event count = %d, want %d", got, before+1)
+	}
+
+	translator.SetUnknownEventMode(translator.UnknownEventPassthrough)
+	chunks := ConvertClaudeResponseToOpenAI(ctx, "claude-sonnet-4-5", nil, nil, []byte(`data: {"type":"future_event","value":1}`), &param)
+	if len(chunks) != 1 {
+		t.Fatalf("passthrough mode emitted %d chunks, want 1", len(chunks))
+	}
+	chunk := gjson.Parse(chunks[0])
+	if chunk.Get("id").String() != "msg_1" || len(chunk.Get("choices").Array()) != 0 ||
+		chunk.Get("extension.type").String() != "anthropic.future_event" || chunk.Get("extension.data.value").Int() != 1 {
+		t.Fatalf("unexpected extension chunk: %s", chunks[0])
+	}
+
+	chunks = ConvertClaudeResponseToOpenAI(ctx, "claude-sonnet-4-5", nil, nil,
+		[]byte(`data: {"type":"content_block_delta","index":0,"delta":{"type":"future_delta","value":"x"}}`), &param)
+	if len(chunks) != 1 || gjson.Get(chunks[0], "extension.type").String() != "anthropic.content_block_delta.future_delta" {
+		t.Fatalf("unexpected chunks for unknown delta: %v", chunks)
+	}
+	if chunks = ConvertClaudeResponseToOpenAI(ctx, "claude-sonnet-4-5", nil, nil,
+		[]byte(`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"hi"}}`), &param); len(chunks) != 1 || gjson.Get(chunks[0], "choices.0.delta.content").String() != "hi" {
+		t.Fatalf("stream broke after unknown events: %v", chunks)
+	}
+}
+
+func TestConvertClaudeResponseToOpenAI_FineGrainedToolStreaming(t *testing.T) {
+	var param any
+	ctx := context.Background()
+	var chunks []string
+	for _, line := range []string{
+		`data: {"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":5}}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_a","name":"search","input":{}}}`,
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_b","name":"write","input":{}}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"q\":"}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"{\"path\":\"a.txt\","}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"\"go\"}"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"input_json_delta","partial_json":"\"body\":\"trunc"}}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"max_tokens"},"usage":{"output_tokens":64}}`,
+	} {
+		chunks = append(chunks, ConvertClaudeResponseToOpenAI(ctx, "claude-sonnet-4-5", nil, nil, []byte(line), &param)...)
+	}
+
+	var calls []gjson.Result
+	for _, chunk := range chunks {
+		if call := gjson.Get(chunk, "choices.0.delta.tool_calls.0"); call.Exists() {
+			calls = append(calls, call)
+		}
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected two tool calls, got %d: %v", len(calls), chunks)
+	}
+	if calls[0].Get("id").String() != "toolu_a" || calls[0].Get("function.arguments").String() != `{"q":"go"}` {
+		t.Fatalf("unexpected first tool call: %s", calls[0].Raw)
+	}
+	args := calls[1].Get("function.arguments").String()
+	if calls[1].Get("id").String() != "toolu_b" || args != `{"path":"a.txt","body":"trunc"}` {
+		t.Fatalf("unexpected truncated tool call: %s", calls[1].Raw)
+	}
+	if last := chunks[len(chunks)-1]; gjson.Get(last, "choices.0.finish_reason").String() != "length" {
+		t.Fatalf("last chunk should finish with length: %s", last)
+	}
+}
+
+func TestConvertClaudeResponseToOpenAI_Citations(t *testing.T) {
+	lines := []string{
+		`data: {"type":"message_start","message":{"id":"msg_c","usage":{"input_tokens":5}}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Per the docs, "}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"text","text":"","citations":[]}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"citations_delta","citation":{"type":"char_location","cited_text":"Grass is green.","document_index":0,"document_title":"Facts","start_char_index":0,"end_char_index":15}}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"citations_delta","citation":{"type":"web_search_result_location","cited_text":"Sky is blue","url":"https://example.com/sky","title":"Sky"}}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"grass is green"}}`,
+		`data: {"type":"content_block_stop","index":1}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":9}}`,
+	}
+
+	var param any
+	var annotations []gjson.Result
+	for _, line := range lines {
+		for _, chunk := range ConvertClaudeResponseToOpenAI(context.Background(), "claude-sonnet-4-5", nil, nil, []byte(line), &param) {
+			annotations = append(annotations, gjson.Get(chunk, "choices.0.delta.annotations").Array()...)
+		}
+	}
+	if len(annotations) != 2 {
+		t.Fatalf("expected 2 streamed annotations, got %d", len(annotations))
+	}
+	file := annotations[0]
+	if file.Get("type").String() != "file_citation" || file.Get("file_citation.file_id").String() != "document_0" ||
+		file.Get("file_citation.start_index").Int() != 14 || file.Get("file_citation.end_index").Int() != 28 ||
+		file.Get("file_citation.location.end_char_index").Int() != 15 {
+		t.Fatalf("unexpected file citation: %s", file.Raw)
+	}
+	if url := annotations[1]; url.Get("url_citation.url").String() != "https://example.com/sky" || url.Get("url_citation.start_index").Int() != 14 {
+		t.Fatalf("unexpected url citation: %s", url.Raw)
+	}
+
+	var raw strings.Builder
+	for _, line := range lines {
+		raw.WriteString(line)
+		raw.WriteString("\n")
+	}
+	out := ConvertClaudeResponseToOpenAINonStream(context.Background(), "", nil, nil, []byte(raw.String()), nil)
+	if got := gjson.Get(out, "choices.0.message.annotations.#").Int(); got != 2 {
+		t.Fatalf("expected 2 message annotations, got %d: %s", got, out)
+	}
+	if got := gjson.Get(out, "choices.0.message.annotations.0.file_citation.end_index").Int(); got != 28 {
+		t.Fatalf("non-stream end_index = %d, want 28", got)
+	}
+}
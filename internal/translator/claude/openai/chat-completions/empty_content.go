@@ -0,0 +1,64 @@
+package chat_completions
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// normalizeEmptyContent removes whitespace-only text blocks and then handles messages
+// left without content according to translator.EmptyMessageMode: they are pruned, or
+// kept with a placeholder text block. Clients that store tool-only or aborted turns
+// produce such messages, which Claude rejects with "text content blocks must be non-empty".
+func normalizeEmptyContent(requestJSON string) string {
+	messages := gjson.Get(requestJSON, "messages")
+	if !messages.IsArray() {
+		return requestJSON
+	}
+
+	placeholder := translator.EmptyMessageMode() == translator.EmptyMessagePlaceholder
+	placeholderBlock, _ := sjson.Set(`{"type":"text","text":""}`, "text", translator.EmptyMessagePlaceholderText)
+
+	kept := make([]string, 0, len(messages.Array()))
+	changed := false
+	for _, message := range messages.Array() {
+		content := message.Get("content")
+		var blocks []string
+		if content.IsArray() {
+			content.ForEach(func(_, block gjson.Result) bool {
+				if block.Get("type").String() == "text" && strings.TrimSpace(block.Get("text").String()) == "" {
+					return true
+				}
+				blocks = append(blocks, block.Raw)
+				return true
+			})
+			if len(blocks) == len(content.Array()) && len(blocks) > 0 {
+				kept = append(kept, message.Raw)
+				continue
+			}
+		} else if content.Type == gjson.String && strings.TrimSpace(content.String()) != "" {
+			kept = append(kept, message.Raw)
+			continue
+		}
+
+		changed = true
+		if len(blocks) == 0 {
+			if !placeholder {
+				continue
+			}
+			blocks = append(blocks, placeholderBlock)
+		}
+		updated, _ := sjson.SetRaw(message.Raw, "content", "[]")
+		for _, block := range blocks {
+			updated, _ = sjson.SetRaw(updated, "content.-1", block)
+		}
+		kept = append(kept, updated)
+	}
+	if !changed {
+		return requestJSON
+	}
+
+	return setMessages(requestJSON, kept)
+}
@@ -0,0 +1,48 @@
+package chat_completions
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+	"github.com/tidwall/gjson"
+)
+
+const emptyAssistantHistory = `{
+	"model": "gpt-4",
+	"messages": [
+		{"role": "user", "content": "First"},
+		{"role": "assistant", "content": ""},
+		{"role": "user", "content": [{"type": "text", "text": "  "}, {"type": "text", "text": "Second"}]}
+	]
+}`
+
+func TestConvertOpenAIRequestToClaude_PrunesEmptyMessages(t *testing.T) {
+	translator.SetEmptyMessageMode(translator.EmptyMessagePrune)
+
+	out := ConvertOpenAIRequestToClaude("claude-sonnet-4", []byte(emptyAssistantHistory), false)
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 1 {
+		t.Fatalf("expected 1 merged user message, got %d: %s", len(messages), gjson.GetBytes(out, "messages").Raw)
+	}
+	content := messages[0].Get("content").Array()
+	if len(content) != 2 || content[0].Get("text").String() != "First" || content[1].Get("text").String() != "Second" {
+		t.Errorf("unexpected content: %s", messages[0].Get("content").Raw)
+	}
+}
+
+func TestConvertOpenAIRequestToClaude_InsertsEmptyMessagePlaceholder(t *testing.T) {
+	translator.SetEmptyMessageMode(translator.EmptyMessagePlaceholder)
+	defer translator.SetEmptyMessageMode(translator.EmptyMessagePrune)
+
+	out := ConvertOpenAIRequestToClaude("claude-sonnet-4", []byte(emptyAssistantHistory), false)
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d: %s", len(messages), gjson.GetBytes(out, "messages").Raw)
+	}
+	if got := messages[1].Get("content.0.text").String(); got != translator.EmptyMessagePlaceholderText {
+		t.Errorf("placeholder text = %q", got)
+	}
+	if got := messages[2].Get("content.#").Int(); got != 1 {
+		t.Errorf("whitespace-only block not removed: %s", messages[2].Get("content").Raw)
+	}
+}
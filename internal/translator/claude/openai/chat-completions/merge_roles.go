@@ -0,0 +1,77 @@
+package chat_completions
+
+import (
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// mergeConsecutiveRoles folds runs of messages with the same role into a single
+// multi-block message, since Claude requires user and assistant turns to alternate.
+// OpenAI histories produce such runs routinely: parallel tool results arrive as
+// separate "tool" messages and some clients split one turn into several messages.
+// Within a merged user turn, tool_result blocks are moved ahead of other blocks because
+// Claude expects them first.
+func mergeConsecutiveRoles(requestJSON string) string {
+	messages := gjson.Get(requestJSON, "messages")
+	if !messages.IsArray() {
+		return requestJSON
+	}
+
+	type turn struct {
+		role   string
+		raw    string
+		blocks []gjson.Result
+		merged bool
+	}
+	var turns []*turn
+	for _, message := range messages.Array() {
+		role := message.Get("role").String()
+		content := message.Get("content")
+		if n := len(turns); n > 0 && turns[n-1].role == role && content.IsArray() && turns[n-1].blocks != nil {
+			turns[n-1].blocks = append(turns[n-1].blocks, content.Array()...)
+			turns[n-1].merged = true
+			continue
+		}
+		t := &turn{role: role, raw: message.Raw}
+		if content.IsArray() {
+			t.blocks = append([]gjson.Result{}, content.Array()...)
+		}
+		turns = append(turns, t)
+	}
+	if len(turns) == len(messages.Array()) {
+		return requestJSON
+	}
+
+	merged := make([]string, 0, len(turns))
+	for _, t := range turns {
+		raw := t.raw
+		if t.merged {
+			raw, _ = sjson.SetRaw(raw, "content", "[]")
+			ordered := t.blocks
+			if t.role == "user" {
+				ordered = toolResultsFirst(t.blocks)
+			}
+			for _, block := range ordered {
+				raw, _ = sjson.SetRaw(raw, "content.-1", block.Raw)
+			}
+		}
+		merged = append(merged, raw)
+	}
+	return setMessages(requestJSON, merged)
+}
+
+// toolResultsFirst returns blocks with tool_result entries stably moved to the front.
+func toolResultsFirst(blocks []gjson.Result) []gjson.Result {
+	ordered := make([]gjson.Result, 0, len(blocks))
+	for _, block := range blocks {
+		if block.Get("type").String() == "tool_result" {
+			ordered = append(ordered, block)
+		}
+	}
+	for _, block := range blocks {
+		if block.Get("type").String() != "tool_result" {
+			ordered = append(ordered, block)
+		}
+	}
+	return ordered
+}
@@ -0,0 +1,60 @@
+package chat_completions
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIRequestToClaude_MergesParallelToolResults(t *testing.T) {
+	input := `{
+		"model": "gpt-4",
+		"messages": [
+			{"role": "user", "content": "Look both up"},
+			{"role": "assistant", "tool_calls": [
+				{"id": "call_a", "type": "function", "function": {"name": "lookup", "arguments": "{}"}},
+				{"id": "call_b", "type": "function", "function": {"name": "lookup", "arguments": "{}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_a", "content": "A"},
+			{"role": "tool", "tool_call_id": "call_b", "content": "B"},
+			{"role": "user", "content": "Summarize"}
+		]
+	}`
+
+	out := ConvertOpenAIRequestToClaude("claude-sonnet-4", []byte(input), false)
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d: %s", len(messages), gjson.GetBytes(out, "messages").Raw)
+	}
+	content := messages[2].Get("content").Array()
+	if len(content) != 3 {
+		t.Fatalf("expected 3 blocks in merged user turn, got %d", len(content))
+	}
+	if content[0].Get("tool_use_id").String() != "call_a" || content[1].Get("tool_use_id").String() != "call_b" {
+		t.Errorf("tool results not kept in order: %s", messages[2].Get("content").Raw)
+	}
+	if content[2].Get("text").String() != "Summarize" {
+		t.Errorf("text block = %s, want Summarize", content[2].Raw)
+	}
+}
+
+func TestMergeConsecutiveRoles_MovesToolResultsFirst(t *testing.T) {
+	input := `{"messages":[
+		{"role":"user","content":[{"type":"text","text":"note"}]},
+		{"role":"user","content":[{"type":"tool_result","tool_use_id":"a"}]},
+		{"role":"assistant","content":[{"type":"text","text":"one"}]},
+		{"role":"assistant","content":[{"type":"text","text":"two"}]}
+	]}`
+
+	out := mergeConsecutiveRoles(input)
+	messages := gjson.Get(out, "messages").Array()
+	if len(messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d: %s", len(messages), out)
+	}
+	if got := messages[0].Get("content.0.type").String(); got != "tool_result" {
+		t.Errorf("first user block type = %q, want tool_result", got)
+	}
+	if got := messages[1].Get("content.#").Int(); got != 2 {
+		t.Errorf("assistant blocks = %d, want 2", got)
+	}
+}
@@ -0,0 +1,40 @@
+package chat_completions
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// setMessages replaces the messages array with the given raw messages in one write.
+// Appending with sjson one message at a time copies the whole request per message,
+// which is quadratic for long histories. The new array is spliced into the request in
+// a pooled buffer, so the only allocation is the resulting request.
+func setMessages(requestJSON string, messages []string) string {
+	current := gjson.Get(requestJSON, "messages")
+	buf := translator.AcquireBuffer()
+	defer translator.ReleaseBuffer(buf)
+
+	size := len(requestJSON) + len(messages) + 2
+	for _, raw := range messages {
+		size += len(raw)
+	}
+	buf.Grow(size)
+	if current.Index > 0 {
+		buf.WriteString(requestJSON[:current.Index])
+	}
+	buf.WriteByte('[')
+	for i, raw := range messages {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(raw)
+	}
+	buf.WriteByte(']')
+	if current.Index > 0 {
+		buf.WriteString(requestJSON[current.Index+len(current.Raw):])
+		return buf.String()
+	}
+	requestJSON, _ = sjson.SetRaw(requestJSON, "messages", buf.String())
+	return requestJSON
+}
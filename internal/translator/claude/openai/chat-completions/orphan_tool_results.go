@@ -0,0 +1,72 @@
+package chat_completions
+
+import (
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// dropOrphanToolResults removes tool_result blocks whose tool_use is not in the
+// immediately preceding assistant message. This happens when clients trim the start
+// of the context and cut a tool call away from its result; Claude answers such
+// histories with a bare "invalid request". Messages left without content are removed.
+func dropOrphanToolResults(requestJSON string) string {
+	messages := gjson.Get(requestJSON, "messages")
+	if !messages.IsArray() {
+		return requestJSON
+	}
+
+	items := messages.Array()
+	kept := make([]string, 0, len(items))
+	changed := false
+	for i, message := range items {
+		content := message.Get("content")
+		if !content.IsArray() {
+			kept = append(kept, message.Raw)
+			continue
+		}
+
+		available := make(map[string]struct{})
+		if i > 0 && items[i-1].Get("role").String() == "assistant" {
+			items[i-1].Get("content").ForEach(func(_, block gjson.Result) bool {
+				if block.Get("type").String() == "tool_use" {
+					available[block.Get("id").String()] = struct{}{}
+				}
+				return true
+			})
+		}
+
+		blocks := make([]string, 0, len(content.Array()))
+		dropped := 0
+		content.ForEach(func(_, block gjson.Result) bool {
+			if block.Get("type").String() == "tool_result" {
+				id := block.Get("tool_use_id").String()
+				if _, ok := available[id]; !ok {
+					log.Warnf("claude translator: dropping tool_result %q without matching tool_use in message %d", id, i)
+					dropped++
+					return true
+				}
+			}
+			blocks = append(blocks, block.Raw)
+			return true
+		})
+		if dropped == 0 {
+			kept = append(kept, message.Raw)
+			continue
+		}
+		changed = true
+		if len(blocks) == 0 {
+			continue
+		}
+		updated, _ := sjson.SetRaw(message.Raw, "content", "[]")
+		for _, block := range blocks {
+			updated, _ = sjson.SetRaw(updated, "content.-1", block)
+		}
+		kept = append(kept, updated)
+	}
+	if !changed {
+		return requestJSON
+	}
+
+	return setMessages(requestJSON, kept)
+}
@@ -0,0 +1,47 @@
+package chat_completions
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIRequestToClaude_DropsOrphanToolResults(t *testing.T) {
+	input := `{
+		"model": "gpt-4",
+		"messages": [
+			{"role": "tool", "tool_call_id": "call_trimmed", "content": "stale"},
+			{"role": "user", "content": "Continue"},
+			{"role": "assistant", "tool_calls": [{"id": "call_2", "type": "function", "function": {"name": "check", "arguments": "{}"}}]},
+			{"role": "tool", "tool_call_id": "call_2", "content": "ok"}
+		]
+	}`
+
+	out := ConvertOpenAIRequestToClaude("claude-sonnet-4", []byte(input), false)
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 3 {
+		t.Fatalf("expected 3 messages, got %d: %s", len(messages), gjson.GetBytes(out, "messages").Raw)
+	}
+	if got := messages[0].Get("content.0.text").String(); got != "Continue" {
+		t.Errorf("first message text = %q, want Continue", got)
+	}
+	if got := messages[2].Get("content.0.tool_use_id").String(); got != "call_2" {
+		t.Errorf("kept tool_result id = %q, want call_2", got)
+	}
+}
+
+func TestDropOrphanToolResults_KeepsSiblingBlocks(t *testing.T) {
+	input := `{"messages":[
+		{"role":"assistant","content":[{"type":"tool_use","id":"a","name":"x","input":{}}]},
+		{"role":"user","content":[{"type":"tool_result","tool_use_id":"a"},{"type":"tool_result","tool_use_id":"b"},{"type":"text","text":"next"}]}
+	]}`
+
+	out := dropOrphanToolResults(input)
+	content := gjson.Get(out, "messages.1.content").Array()
+	if len(content) != 2 {
+		t.Fatalf("expected 2 blocks, got %d: %s", len(content), out)
+	}
+	if content[0].Get("tool_use_id").String() != "a" || content[1].Get("text").String() != "next" {
+		t.Errorf("unexpected content: %s", gjson.Get(out, "messages.1.content").Raw)
+	}
+}
@@ -0,0 +1,55 @@
+package chat_completions
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// applyPredictionPrefill emulates the OpenAI prediction parameter when
+// translator.PredictionMode is prefill: the predicted content becomes a final assistant
+// prefill that Claude continues from. It is skipped when thinking is enabled, since a
+// prefill without a thinking block would disable it, and when the conversation already
+// ends with an assistant turn.
+func applyPredictionPrefill(requestJSON string, root gjson.Result) string {
+	if translator.PredictionMode() != translator.PredictionPrefill {
+		return requestJSON
+	}
+	text := translator.PredictionText(root)
+	if strings.TrimSpace(text) == "" || gjson.Get(requestJSON, "thinking.type").String() == "enabled" {
+		return requestJSON
+	}
+	if gjson.Get(requestJSON, "messages.@reverse.0.role").String() != "user" {
+		return requestJSON
+	}
+	msg := `{"role":"assistant","content":[{"type":"text","text":""}]}`
+	msg, _ = sjson.Set(msg, "content.0.text", text)
+	requestJSON, _ = sjson.SetRaw(requestJSON, "messages.-1", msg)
+	return requestJSON
+}
+
+// predictionPrefill returns the prefill added by applyPredictionPrefill, as it was sent
+// to Claude, so responses can start with it. Claude only returns the continuation.
+func predictionPrefill(originalRequestRawJSON, requestRawJSON []byte) string {
+	if translator.PredictionMode() != translator.PredictionPrefill {
+		return ""
+	}
+	original := gjson.ParseBytes(originalRequestRawJSON)
+	if translator.PredictionText(original) == "" || original.Get("messages.@reverse.0.role").String() == "assistant" {
+		return ""
+	}
+	last := gjson.GetBytes(requestRawJSON, "messages.@reverse.0")
+	if last.Get("role").String() != "assistant" {
+		return ""
+	}
+	var sb strings.Builder
+	last.Get("content").ForEach(func(_, block gjson.Result) bool {
+		if block.Get("type").String() == "text" {
+			sb.WriteString(block.Get("text").String())
+		}
+		return true
+	})
+	return sb.String()
+}
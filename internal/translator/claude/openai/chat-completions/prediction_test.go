@@ -0,0 +1,47 @@
+package chat_completions
+
+import (
+	"context"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+	"github.com/tidwall/gjson"
+)
+
+const predictionRequest = `{
+	"model": "gpt-4o",
+	"messages": [{"role": "user", "content": "Rename x to count"}],
+	"prediction": {"type": "content", "content": [{"type": "text", "text": "func add(count int) {"}]}
+}`
+
+func TestConvertOpenAIRequestToClaude_Prediction(t *testing.T) {
+	translator.SetPredictionMode(translator.PredictionStrip)
+	out := ConvertOpenAIRequestToClaude("claude-sonnet-4", []byte(predictionRequest), true)
+	if gjson.GetBytes(out, "prediction").Exists() || gjson.GetBytes(out, "messages.#").Int() != 1 {
+		t.Fatalf("strip mode should drop prediction: %s", out)
+	}
+
+	translator.SetPredictionMode(translator.PredictionPrefill)
+	defer translator.SetPredictionMode(translator.PredictionStrip)
+	out = ConvertOpenAIRequestToClaude("claude-sonnet-4", []byte(predictionRequest), true)
+	last := gjson.GetBytes(out, "messages.@reverse.0")
+	if last.Get("role").String() != "assistant" || last.Get("content.0.text").String() != "func add(count int) {" {
+		t.Fatalf("expected assistant prefill, got %s", gjson.GetBytes(out, "messages").Raw)
+	}
+
+	var param any
+	chunks := ConvertClaudeResponseToOpenAI(context.Background(), "claude-sonnet-4", []byte(predictionRequest), out,
+		[]byte(`data: {"type":"message_start","message":{"id":"msg_1","usage":{"input_tokens":3}}}`), &param)
+	if len(chunks) != 1 || gjson.Get(chunks[0], "choices.0.delta.content").String() != "func add(count int) {" {
+		t.Fatalf("stream should start with the prefill: %v", chunks)
+	}
+
+	stream := "data: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\"}}\n" +
+		"data: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n" +
+		"data: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"\\n}\"}}\n" +
+		"data: {\"type\":\"content_block_stop\",\"index\":0}\n"
+	result := ConvertClaudeResponseToOpenAINonStream(context.Background(), "claude-sonnet-4", []byte(predictionRequest), out, []byte(stream), nil)
+	if got := gjson.Get(result, "choices.0.message.content").String(); got != "func add(count int) {\n}" {
+		t.Errorf("unexpected content %q", got)
+	}
+}
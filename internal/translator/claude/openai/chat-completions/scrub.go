@@ -0,0 +1,107 @@
+package chat_completions
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// scrubText replaces invalid UTF-8 with U+FFFD and removes control characters other
+// than tab, newline and carriage return. Lone UTF-16 surrogates escaped in the client
+// JSON are already decoded to U+FFFD by gjson, so rewriting the value is enough to
+// fix them.
+func scrubText(s string) string {
+	s = strings.ToValidUTF8(s, "\uFFFD")
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// needsScrub reports whether a JSON string value must be rewritten by scrubText.
+func needsScrub(value gjson.Result) bool {
+	if !utf8.ValidString(value.Raw) || strings.Contains(strings.ToLower(value.Raw), `\ud`) {
+		return true
+	}
+	return scrubText(value.String()) != value.String()
+}
+
+// scrubRequestText sanitizes every string in system and messages (see scrubText) and
+// trims trailing whitespace from a final assistant prefill, both of which Anthropic
+// rejects with opaque errors.
+func scrubRequestText(requestJSON string) string {
+	var paths []string
+	var collect func(path string, value gjson.Result)
+	collect = func(path string, value gjson.Result) {
+		switch {
+		case value.Type == gjson.String:
+			if needsScrub(value) {
+				paths = append(paths, path)
+			}
+		case value.IsArray():
+			for i, item := range value.Array() {
+				collect(path+"."+strconv.Itoa(i), item)
+			}
+		case value.IsObject():
+			value.ForEach(func(key, item gjson.Result) bool {
+				collect(path+"."+escapePathKey(key.String()), item)
+				return true
+			})
+		}
+	}
+	collect("system", gjson.Get(requestJSON, "system"))
+	collect("messages", gjson.Get(requestJSON, "messages"))
+	for _, path := range paths {
+		requestJSON, _ = sjson.Set(requestJSON, path, scrubText(gjson.Get(requestJSON, path).String()))
+	}
+
+	return trimAssistantPrefill(requestJSON)
+}
+
+// trimAssistantPrefill trims trailing whitespace from the last text block of a final
+// assistant message. A block left empty is handled by normalizeEmptyContent.
+func trimAssistantPrefill(requestJSON string) string {
+	last := gjson.Get(requestJSON, "messages.@reverse.0")
+	if last.Get("role").String() != "assistant" {
+		return requestJSON
+	}
+	index := strconv.FormatInt(gjson.Get(requestJSON, "messages.#").Int()-1, 10)
+	content := last.Get("content")
+	if content.Type == gjson.String {
+		if trimmed := strings.TrimRightFunc(content.String(), unicode.IsSpace); trimmed != content.String() {
+			requestJSON, _ = sjson.Set(requestJSON, "messages."+index+".content", trimmed)
+		}
+		return requestJSON
+	}
+	blocks := content.Array()
+	for i := len(blocks) - 1; i >= 0; i-- {
+		if blocks[i].Get("type").String() != "text" {
+			continue
+		}
+		text := blocks[i].Get("text").String()
+		if trimmed := strings.TrimRightFunc(text, unicode.IsSpace); trimmed != text {
+			requestJSON, _ = sjson.Set(requestJSON, "messages."+index+".content."+strconv.Itoa(i)+".text", trimmed)
+		}
+		break
+	}
+	return requestJSON
+}
+
+// escapePathKey escapes gjson/sjson path syntax in an object key.
+func escapePathKey(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		switch r {
+		case '.', '*', '?', '|', '#', '@', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
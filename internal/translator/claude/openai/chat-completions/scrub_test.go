@@ -0,0 +1,38 @@
+package chat_completions
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIRequestToClaude_ScrubsTextAndTrimsPrefill(t *testing.T) {
+	input := `{
+		"model": "gpt-4",
+		"messages": [
+			{"role": "system", "content": "Be\u0007 brief"},
+			{"role": "user", "content": "broken \ud83d pair, fine 😀"},
+			{"role": "assistant", "content": "Sure:  \n"}
+		]
+	}`
+
+	out := ConvertOpenAIRequestToClaude("claude-sonnet-4", []byte(input), false)
+	if got := gjson.GetBytes(out, "system.0.text").String(); got != "Be brief" {
+		t.Errorf("system text = %q, want control character removed", got)
+	}
+	if got := gjson.GetBytes(out, "messages.0.content.0.text").String(); got != "broken � pair, fine 😀" {
+		t.Errorf("user text = %q", got)
+	}
+	if got := gjson.GetBytes(out, "messages.1.content.0.text").String(); got != "Sure:" {
+		t.Errorf("prefill = %q, want trailing whitespace trimmed", got)
+	}
+}
+
+func TestScrubRequestText_EscapesToolInputKeys(t *testing.T) {
+	input := `{"messages":[{"role":"assistant","content":[{"type":"tool_use","id":"a","name":"x","input":{"file.name":"a\u0001b"}}]},{"role":"user","content":"ok"}]}`
+
+	out := scrubRequestText(input)
+	if got := gjson.Get(out, `messages.0.content.0.input.file\.name`).String(); got != "ab" {
+		t.Errorf("tool input = %q, want ab: %s", got, out)
+	}
+}
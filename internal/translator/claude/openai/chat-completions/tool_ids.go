@@ -0,0 +1,92 @@
+package chat_completions
+
+import (
+	"fmt"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// rewriteDuplicateToolIDs renames tool_use IDs that repeat across the history.
+// Clients that replay transcripts sometimes reuse tool_call_ids between turns, which
+// Claude rejects. Each colliding tool_use gets a deterministic suffixed ID, and every
+// tool_result that follows it is remapped to the new ID until the original ID is
+// reused again, so each result stays paired with the call it answers. Suffixes are
+// deterministic so replays of the same history keep hitting the prompt cache.
+func rewriteDuplicateToolIDs(requestJSON string) string {
+	messages := gjson.Get(requestJSON, "messages")
+	if !messages.IsArray() {
+		return requestJSON
+	}
+
+	seen := make(map[string]struct{})
+	current := make(map[string]string)
+	for i, message := range messages.Array() {
+		content := message.Get("content")
+		if !content.IsArray() {
+			continue
+		}
+		for j, block := range content.Array() {
+			switch block.Get("type").String() {
+			case "tool_use":
+				id := block.Get("id").String()
+				if id == "" {
+					continue
+				}
+				if _, dup := seen[id]; !dup {
+					seen[id] = struct{}{}
+					current[id] = id
+					continue
+				}
+				renamed := uniqueToolID(id, seen)
+				seen[renamed] = struct{}{}
+				current[id] = renamed
+				requestJSON, _ = sjson.Set(requestJSON, fmt.Sprintf("messages.%d.content.%d.id", i, j), renamed)
+			case "tool_result":
+				id := block.Get("tool_use_id").String()
+				if renamed, ok := current[id]; ok && renamed != id {
+					requestJSON, _ = sjson.Set(requestJSON, fmt.Sprintf("messages.%d.content.%d.tool_use_id", i, j), renamed)
+				}
+			}
+		}
+	}
+	return requestJSON
+}
+
+// uniqueToolID returns the first "<id>_<n>" not present in seen.
+func uniqueToolID(id string, seen map[string]struct{}) string {
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s_%d", id, n)
+		if _, taken := seen[candidate]; !taken {
+			return candidate
+		}
+	}
+}
+
+// toolIDSession scopes the tool call ID mapping to a conversation: the branch key of the
+// history up to and including the first user message, which stays the same as the
+// conversation grows.
+func toolIDSession(messages gjson.Result) string {
+	keys := conversationBranchKeys(messages)
+	index := len(keys) - 1
+	i := 0
+	messages.ForEach(func(_, message gjson.Result) bool {
+		i++
+		if message.Get("role").String() == "user" {
+			index = i
+			return false
+		}
+		return true
+	})
+	return keys[index]
+}
+
+// claudeToolID maps a client tool call ID to the tool_use ID sent to Claude. A missing ID
+// gets a stable one derived from the call's position in the history.
+func claudeToolID(session, clientID, position string) string {
+	if clientID == "" {
+		return cache.GenerateToolID(session, position)
+	}
+	return cache.ClaudeToolID(session, clientID)
+}
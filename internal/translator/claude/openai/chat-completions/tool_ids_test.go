@@ -0,0 +1,112 @@
+package chat_completions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIRequestToClaude_RewritesDuplicateToolCallIDs(t *testing.T) {
+	input := `{
+		"model": "gpt-4",
+		"messages": [
+			{"role": "user", "content": "Check twice"},
+			{"role": "assistant", "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "check", "arguments": "{}"}}]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "first"},
+			{"role": "assistant", "tool_calls": [{"id": "call_1", "type": "function", "function": {"name": "check", "arguments": "{}"}}]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "second"}
+		]
+	}`
+
+	out := ConvertOpenAIRequestToClaude("claude-sonnet-4", []byte(input), false)
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 5 {
+		t.Fatalf("expected 5 messages, got %d: %s", len(messages), out)
+	}
+	if got := messages[1].Get("content.0.id").String(); got != "call_1" {
+		t.Errorf("first tool_use id = %q, want call_1", got)
+	}
+	if got := messages[2].Get("content.0.tool_use_id").String(); got != "call_1" {
+		t.Errorf("first tool_result id = %q, want call_1", got)
+	}
+	if got := messages[3].Get("content.0.id").String(); got != "call_1_2" {
+		t.Errorf("second tool_use id = %q, want call_1_2", got)
+	}
+	if got := messages[4].Get("content.0.tool_use_id").String(); got != "call_1_2" {
+		t.Errorf("second tool_result id = %q, want call_1_2", got)
+	}
+}
+
+func TestRewriteDuplicateToolIDs_AvoidsExistingSuffix(t *testing.T) {
+	input := `{"messages":[
+		{"role":"assistant","content":[{"type":"tool_use","id":"a","name":"x","input":{}},{"type":"tool_use","id":"a_2","name":"x","input":{}}]},
+		{"role":"user","content":[{"type":"tool_result","tool_use_id":"a"},{"type":"tool_result","tool_use_id":"a_2"}]},
+		{"role":"assistant","content":[{"type":"tool_use","id":"a","name":"x","input":{}}]},
+		{"role":"user","content":[{"type":"tool_result","tool_use_id":"a"}]}
+	]}`
+
+	out := rewriteDuplicateToolIDs(input)
+	if got := gjson.Get(out, "messages.2.content.0.id").String(); got != "a_3" {
+		t.Errorf("renamed id = %q, want a_3", got)
+	}
+	if got := gjson.Get(out, "messages.3.content.0.tool_use_id").String(); got != "a_3" {
+		t.Errorf("remapped result id = %q, want a_3", got)
+	}
+	if got := gjson.Get(out, "messages.1.content.1.tool_use_id").String(); got != "a_2" {
+		t.Errorf("untouched result id = %q, want a_2", got)
+	}
+}
+
+func TestConvertOpenAIRequestToClaude_MapsInvalidToolCallIDs(t *testing.T) {
+	turn1 := `{"model":"gpt-4","messages":[
+		{"role":"user","content":"Look it up"},
+		{"role":"assistant","tool_calls":[{"id":"call:lookup.1","type":"function","function":{"name":"lookup","arguments":"{}"}},{"type":"function","function":{"name":"lookup","arguments":"{}"}}]},
+		{"role":"tool","tool_call_id":"call:lookup.1","content":"found"}
+	]}`
+	out := ConvertOpenAIRequestToClaude("claude-sonnet-4", []byte(turn1), false)
+	mapped := gjson.GetBytes(out, "messages.1.content.0.id").String()
+	if !strings.HasPrefix(mapped, "toolu_") {
+		t.Fatalf("tool_use id = %q, want a toolu_ id", mapped)
+	}
+	if got := gjson.GetBytes(out, "messages.2.content.0.tool_use_id").String(); got != mapped {
+		t.Errorf("tool_result id = %q, want %q", got, mapped)
+	}
+	generated := gjson.GetBytes(out, "messages.1.content.1.id").String()
+	if !strings.HasPrefix(generated, "toolu_") {
+		t.Fatalf("missing id replaced by %q, want a toolu_ id", generated)
+	}
+
+	// The next turn replays the history; both IDs must stay the same.
+	turn2 := strings.TrimSuffix(strings.TrimSpace(turn1), "]}") + `,{"role":"user","content":"Thanks"}]}`
+	out2 := ConvertOpenAIRequestToClaude("claude-sonnet-4", []byte(turn2), false)
+	if got := gjson.GetBytes(out2, "messages.1.content.0.id").String(); got != mapped {
+		t.Errorf("replayed tool_use id = %q, want %q", got, mapped)
+	}
+	if got := gjson.GetBytes(out2, "messages.1.content.1.id").String(); got != generated {
+		t.Errorf("replayed generated id = %q, want %q", got, generated)
+	}
+
+	// A tool_use carrying the mapped ID reaches the client with its own call ID.
+	stream := "data: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"model\":\"claude-sonnet-4\"}}\n" +
+		"data: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"tool_use\",\"id\":\"" + mapped + "\",\"name\":\"lookup\"}}\n" +
+		"data: {\"type\":\"content_block_stop\",\"index\":0}\n" +
+		"data: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"tool_use\"}}\n"
+	resp := ConvertClaudeResponseToOpenAINonStream(context.Background(), "claude-sonnet-4", []byte(turn2), out2, []byte(stream), nil)
+	if got := gjson.Get(resp, "choices.0.message.tool_calls.0.id").String(); got != "call:lookup.1" {
+		t.Errorf("response tool call id = %q, want call:lookup.1", got)
+	}
+}
+
+func TestConvertOpenAIRequestToClaude_KeepsValidToolCallIDs(t *testing.T) {
+	input := `{"model":"gpt-4","messages":[
+		{"role":"user","content":"Look it up"},
+		{"role":"assistant","tool_calls":[{"id":"call_abc-1","type":"function","function":{"name":"lookup","arguments":"{}"}}]},
+		{"role":"tool","tool_call_id":"call_abc-1","content":"found"}
+	]}`
+	out := ConvertOpenAIRequestToClaude("claude-sonnet-4", []byte(input), false)
+	if got := gjson.GetBytes(out, "messages.1.content.0.id").String(); got != "call_abc-1" {
+		t.Errorf("tool_use id = %q, want call_abc-1", got)
+	}
+}
@@ -0,0 +1,92 @@
+package chat_completions
+
+import (
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// toolCallLayout follows the text a Claude response emits before, between and after its
+// tool calls. OpenAI messages hold text and tool calls separately, so when the text does
+// not all come first the layout is cached under the first tool call ID and the replayed
+// turn is rebuilt in the original order.
+type toolCallLayout struct {
+	toolIDs  []string
+	segments []*strings.Builder
+}
+
+// addText appends text to the current segment.
+func (l *toolCallLayout) addText(text string) {
+	if len(l.segments) == 0 {
+		l.segments = append(l.segments, &strings.Builder{})
+	}
+	l.segments[len(l.segments)-1].WriteString(text)
+}
+
+// addToolCall starts a new segment after the tool call with the given client-facing ID.
+func (l *toolCallLayout) addToolCall(id string) {
+	if len(l.segments) == 0 {
+		l.segments = append(l.segments, &strings.Builder{})
+	}
+	l.toolIDs = append(l.toolIDs, id)
+	l.segments = append(l.segments, &strings.Builder{})
+}
+
+// record caches the layout when the response had tool calls.
+func (l *toolCallLayout) record() {
+	if len(l.toolIDs) == 0 {
+		return
+	}
+	segments := make([]string, len(l.segments))
+	for i, segment := range l.segments {
+		segments[i] = segment.String()
+	}
+	cache.CacheToolCallLayout(l.toolIDs[0], segments)
+}
+
+// restoreToolCallLayout reorders the text and tool_use blocks of a converted assistant
+// message to the layout cached for its first tool call. Other blocks, such as thinking,
+// keep their place ahead of the text. The message is returned unchanged when no layout
+// was cached or the replayed text or tool calls no longer match it.
+func restoreToolCallLayout(msg string, message gjson.Result) string {
+	segments := cache.GetToolCallLayout(message.Get("tool_calls.0.id").String())
+	if segments == nil {
+		return msg
+	}
+	var leading, toolUses []string
+	var text strings.Builder
+	gjson.Get(msg, "content").ForEach(func(_, block gjson.Result) bool {
+		switch block.Get("type").String() {
+		case "text":
+			text.WriteString(block.Get("text").String())
+		case "tool_use":
+			toolUses = append(toolUses, block.Raw)
+		default:
+			leading = append(leading, block.Raw)
+		}
+		return true
+	})
+	if len(toolUses) != len(segments)-1 || normalizeLayoutText(text.String()) != normalizeLayoutText(strings.Join(segments, "")) {
+		return msg
+	}
+
+	blocks := leading
+	for i, segment := range segments {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			block, _ := sjson.Set(`{"type":"text","text":""}`, "text", segment)
+			blocks = append(blocks, block)
+		}
+		if i < len(toolUses) {
+			blocks = append(blocks, toolUses[i])
+		}
+	}
+	out, _ := sjson.SetRaw(msg, "content", "["+strings.Join(blocks, ",")+"]")
+	return out
+}
+
+// normalizeLayoutText collapses whitespace, which the request translator trims.
+func normalizeLayoutText(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
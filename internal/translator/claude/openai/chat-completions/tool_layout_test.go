@@ -0,0 +1,97 @@
+package chat_completions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func claudeSSE(events ...string) []byte {
+	var sb strings.Builder
+	for _, event := range events {
+		sb.WriteString("data: ")
+		sb.WriteString(event)
+		sb.WriteByte('\n')
+	}
+	return []byte(sb.String())
+}
+
+func TestToolCallLayout_RestoresTextAfterToolCall(t *testing.T) {
+	request := `{"model":"gpt-4","messages":[{"role":"user","content":"Check the weather"}]}`
+	stream := claudeSSE(
+		`{"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4"}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Let me check."}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_layout_1","name":"weather"}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"content_block_start","index":2,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":2,"delta":{"type":"text_delta","text":"Waiting for the result."}}`,
+		`{"type":"content_block_stop","index":2}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"}}`,
+	)
+	resp := ConvertClaudeResponseToOpenAINonStream(context.Background(), "claude-sonnet-4", []byte(request), nil, stream, nil)
+	if got := gjson.Get(resp, "choices.0.message.content").String(); got != "Let me check.Waiting for the result." {
+		t.Fatalf("content = %q", got)
+	}
+
+	replay := `{"model":"gpt-4","messages":[
+		{"role":"user","content":"Check the weather"},
+		{"role":"assistant","content":"Let me check.Waiting for the result.","tool_calls":[{"id":"toolu_layout_1","type":"function","function":{"name":"weather","arguments":"{}"}}]},
+		{"role":"tool","tool_call_id":"toolu_layout_1","content":"sunny"}
+	]}`
+	out := ConvertOpenAIRequestToClaude("claude-sonnet-4", []byte(replay), false)
+	blocks := gjson.GetBytes(out, "messages.1.content").Array()
+	if len(blocks) != 3 {
+		t.Fatalf("expected 3 blocks, got %d: %s", len(blocks), out)
+	}
+	want := []string{"text:Let me check.", "tool_use:toolu_layout_1", "text:Waiting for the result."}
+	for i, block := range blocks {
+		got := block.Get("type").String() + ":" + block.Get("text").String() + block.Get("id").String()
+		if got != want[i] {
+			t.Errorf("block %d = %q, want %q", i, got, want[i])
+		}
+	}
+}
+
+func TestToolCallLayout_EditedTextKeepsDefaultOrder(t *testing.T) {
+	request := `{"model":"gpt-4","messages":[{"role":"user","content":"Search"}]}`
+	stream := claudeSSE(
+		`{"type":"message_start","message":{"id":"msg_2","model":"claude-sonnet-4"}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_layout_2","name":"search"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Searching."}}`,
+		`{"type":"content_block_stop","index":1}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"}}`,
+	)
+	ConvertClaudeResponseToOpenAINonStream(context.Background(), "claude-sonnet-4", []byte(request), nil, stream, nil)
+
+	replay := `{"model":"gpt-4","messages":[
+		{"role":"user","content":"Search"},
+		{"role":"assistant","content":"Edited by the client.","tool_calls":[{"id":"toolu_layout_2","type":"function","function":{"name":"search","arguments":"{}"}}]}
+	]}`
+	out := ConvertOpenAIRequestToClaude("claude-sonnet-4", []byte(replay), false)
+	if got := gjson.GetBytes(out, "messages.1.content.0.type").String(); got != "text" {
+		t.Errorf("first block = %q, want text", got)
+	}
+	if got := gjson.GetBytes(out, "messages.1.content.1.type").String(); got != "tool_use" {
+		t.Errorf("second block = %q, want tool_use", got)
+	}
+}
+
+func TestConvertClaudeResponseToOpenAINonStream_ToolsOnlyHasNullContent(t *testing.T) {
+	stream := claudeSSE(
+		`{"type":"message_start","message":{"id":"msg_3","model":"claude-sonnet-4"}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_layout_3","name":"search"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"message_delta","delta":{"stop_reason":"tool_use"}}`,
+	)
+	resp := ConvertClaudeResponseToOpenAINonStream(context.Background(), "claude-sonnet-4", nil, nil, stream, nil)
+	content := gjson.Get(resp, "choices.0.message.content")
+	if !content.Exists() || content.Type != gjson.Null {
+		t.Errorf("content = %s, want null", content.Raw)
+	}
+}
@@ -1,14 +1,13 @@
 package responses
 
 import (
-	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"math/big"
 	"strings"
 
 	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/tidwall/gjson"
@@ -59,37 +58,27 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 
 	root := gjson.ParseBytes(rawJSON)
 
-	// Convert OpenAI Responses reasoning.effort to Claude thinking config.
-	if v := root.Get("reasoning.effort"); v.Exists() {
-		effort := strings.ToLower(strings.TrimSpace(v.String()))
-		if effort != "" {
-			budget, ok := thinking.ConvertLevelToBudget(effort)
-			if ok {
-				switch budget {
-				case 0:
-					out, _ = sjson.Set(out, "thinking.type", "disabled")
-				case -1:
+	// Convert OpenAI Responses reasoning.effort (level or numeric budget) and
+	// reasoning.max_tokens to Claude thinking config.
+	if config, ok := thinking.ExtractReasoningConfig(root); ok {
+		if budget, ok := thinking.ConfigToBudget(config); ok {
+			switch budget {
+			case 0:
+				out, _ = sjson.Set(out, "thinking.type", "disabled")
+			case -1:
+				out, _ = sjson.Set(out, "thinking.type", "enabled")
+			default:
+				if budget > 0 {
 					out, _ = sjson.Set(out, "thinking.type", "enabled")
-				default:
-					if budget > 0 {
-						out, _ = sjson.Set(out, "thinking.type", "enabled")
-						out, _ = sjson.Set(out, "thinking.budget_tokens", budget)
-					}
+					out, _ = sjson.Set(out, "thinking.budget_tokens", budget)
 				}
 			}
 		}
 	}
 
-	// Helper for generating tool call IDs when missing
-	genToolCallID := func() string {
-		const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-		var b strings.Builder
-		for i := 0; i < 24; i++ {
-			n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
-			b.WriteByte(letters[n.Int64()])
-		}
-		return "toolu_" + b.String()
-	}
+	// Client call IDs Claude would reject are replaced by stable toolu_ IDs that the
+	// response translator maps back, so clients keep keying results by their own IDs.
+	toolSession := toolIDSession(root)
 
 	// Model
 	out, _ = sjson.Set(out, "model", modelName)
@@ -117,7 +106,7 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 	if instructionsText == "" {
 		if input := root.Get("input"); input.Exists() && input.IsArray() {
 			input.ForEach(func(_, item gjson.Result) bool {
-				if strings.EqualFold(item.Get("role").String(), "system") {
+				if isSystemRole(item.Get("role").String()) {
 					var builder strings.Builder
 					if parts := item.Get("content"); parts.Exists() && parts.IsArray() {
 						parts.ForEach(func(_, part gjson.Result) bool {
@@ -147,8 +136,8 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 
 	// input array processing
 	if input := root.Get("input"); input.Exists() && input.IsArray() {
-		input.ForEach(func(_, item gjson.Result) bool {
-			if extractedFromSystem && strings.EqualFold(item.Get("role").String(), "system") {
+		input.ForEach(func(itemIndex, item gjson.Result) bool {
+			if extractedFromSystem && isSystemRole(item.Get("role").String()) {
 				return true
 			}
 			typ := item.Get("type").String()
@@ -227,6 +216,8 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 					switch r {
 					case "user", "assistant", "system":
 						role = r
+					case "developer":
+						role = "system"
 					default:
 						role = "user"
 					}
@@ -257,7 +248,9 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 				// Map to assistant tool_use
 				callID := item.Get("call_id").String()
 				if callID == "" {
-					callID = genToolCallID()
+					callID = cache.GenerateToolID(toolSession, itemIndex.String())
+				} else {
+					callID = cache.ClaudeToolID(toolSession, callID)
 				}
 				name := item.Get("name").String()
 				argsStr := item.Get("arguments").String()
@@ -278,7 +271,7 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 
 			case "function_call_output":
 				// Map to user tool_result
-				callID := item.Get("call_id").String()
+				callID := cache.ClaudeToolID(toolSession, item.Get("call_id").String())
 				outputStr := item.Get("output").String()
 				toolResult := `{"type":"tool_result","tool_use_id":"","content":""}`
 				toolResult, _ = sjson.Set(toolResult, "tool_use_id", callID)
@@ -344,3 +337,21 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 
 	return []byte(out)
 }
+
+// toolIDSession scopes the tool call ID mapping to a conversation: a hash of the
+// instructions and the input up to and including the first user message, which stay the
+// same as the conversation grows.
+func toolIDSession(root gjson.Result) string {
+	key := cache.ChainBranchKey("", root.Get("instructions").String())
+	root.Get("input").ForEach(func(_, item gjson.Result) bool {
+		key = cache.ChainBranchKey(key, item.Raw)
+		return !strings.EqualFold(item.Get("role").String(), "user")
+	})
+	return key
+}
+
+// isSystemRole reports whether an input item role carries system-level instructions.
+// OpenAI "developer" messages are treated the same as "system" messages.
+func isSystemRole(role string) bool {
+	return strings.EqualFold(role, "system") || strings.EqualFold(role, "developer")
+}
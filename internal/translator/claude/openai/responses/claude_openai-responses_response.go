@@ -8,6 +8,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -27,6 +29,9 @@ type claudeToResponsesState struct {
 	FuncCallIDs map[int]string // index -> call id
 	// message text aggregation
 	TextBuf strings.Builder
+	// citations of text blocks, rendered as output_text annotations
+	Citations   translator.CitationTracker
+	Annotations []string
 	// reasoning state
 	ReasoningActive    bool
 	ReasoningItemID    string
@@ -34,9 +39,8 @@ type claudeToResponsesState struct {
 	ReasoningPartAdded bool
 	ReasoningIndex     int
 	// usage aggregation
-	InputTokens  int64
-	OutputTokens int64
-	UsageSeen    bool
+	Usage     translator.Usage
+	UsageSeen bool
 }
 
 var dataTag = []byte("data:")
@@ -80,6 +84,8 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			st.CreatedAt = time.Now().Unix()
 			// Reset per-message aggregation state
 			st.TextBuf.Reset()
+			st.Citations = translator.CitationTracker{}
+			st.Annotations = nil
 			st.ReasoningBuf.Reset()
 			st.ReasoningActive = false
 			st.InTextBlock = false
@@ -92,19 +98,8 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			st.FuncArgsBuf = make(map[int]*strings.Builder)
 			st.FuncNames = make(map[int]string)
 			st.FuncCallIDs = make(map[int]string)
-			st.InputTokens = 0
-			st.OutputTokens = 0
-			st.UsageSeen = false
-			if usage := msg.Get("usage"); usage.Exists() {
-				if v := usage.Get("input_tokens"); v.Exists() {
-					st.InputTokens = v.Int()
-					st.UsageSeen = true
-				}
-				if v := usage.Get("output_tokens"); v.Exists() {
-					st.OutputTokens = v.Int()
-					st.UsageSeen = true
-				}
-			}
+			st.Usage = translator.UsageFromClaude(msg.Get("usage"))
+			st.UsageSeen = msg.Get("usage.input_tokens").Exists() || msg.Get("usage.output_tokens").Exists()
 			// response.created
 			created := `{"type":"response.created","sequence_number":0,"response":{"id":"","object":"response","created_at":0,"status":"in_progress","background":false,"error":null,"output":[]}}`
 			created, _ = sjson.Set(created, "sequence_number", nextSeq())
@@ -128,6 +123,7 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 		if typ == "text" {
 			// open message item + content part
 			st.InTextBlock = true
+			st.Citations.StartBlock(idx, cb)
 			st.CurrentMsgID = fmt.Sprintf("msg_%s_0", st.ResponseID)
 			item := `{"type":"response.output_item.added","sequence_number":0,"output_index":0,"item":{"id":"","type":"message","status":"in_progress","content":[],"role":"assistant"}}`
 			item, _ = sjson.Set(item, "sequence_number", nextSeq())
@@ -140,7 +136,7 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			out = append(out, emitEvent("response.content_part.added", part))
 		} else if typ == "tool_use" {
 			st.InFuncBlock = true
-			st.CurrentFCID = cb.Get("id").String()
+			st.CurrentFCID = cache.ClientToolID(toolIDSession(gjson.ParseBytes(pickRequestJSON(originalRequestRawJSON, requestRawJSON))), cb.Get("id").String())
 			name := cb.Get("name").String()
 			item := `{"type":"response.output_item.added","sequence_number":0,"output_index":0,"item":{"id":"","type":"function_call","status":"in_progress","arguments":"","call_id":"","name":""}}`
 			item, _ = sjson.Set(item, "sequence_number", nextSeq())
@@ -189,7 +185,10 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 				out = append(out, emitEvent("response.output_text.delta", msg))
 				// aggregate text for response.output
 				st.TextBuf.WriteString(t.String())
+				st.Citations.Advance(t.String())
 			}
+		} else if dt == "citations_delta" {
+			st.Citations.AddCitation(int(root.Get("index").Int()), d.Get("citation"))
 		} else if dt == "input_json_delta" {
 			idx := int(root.Get("index").Int())
 			if pj := d.Get("partial_json"); pj.Exists() {
@@ -220,6 +219,16 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 	case "content_block_stop":
 		idx := int(root.Get("index").Int())
 		if st.InTextBlock {
+			for _, span := range st.Citations.EndBlock(idx) {
+				annotation := span.ResponsesAnnotation()
+				added := `{"type":"response.output_text.annotation.added","sequence_number":0,"item_id":"","output_index":0,"content_index":0,"annotation_index":0,"annotation":{}}`
+				added, _ = sjson.Set(added, "sequence_number", nextSeq())
+				added, _ = sjson.Set(added, "item_id", st.CurrentMsgID)
+				added, _ = sjson.Set(added, "annotation_index", len(st.Annotations))
+				added, _ = sjson.SetRaw(added, "annotation", annotation)
+				out = append(out, emitEvent("response.output_text.annotation.added", added))
+				st.Annotations = append(st.Annotations, annotation)
+			}
 			done := `{"type":"response.output_text.done","sequence_number":0,"item_id":"","output_index":0,"content_index":0,"text":"","logprobs":[]}`
 			done, _ = sjson.Set(done, "sequence_number", nextSeq())
 			done, _ = sjson.Set(done, "item_id", st.CurrentMsgID)
@@ -231,15 +240,17 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			final := `{"type":"response.output_item.done","sequence_number":0,"output_index":0,"item":{"id":"","type":"message","status":"completed","content":[{"type":"output_text","text":""}],"role":"assistant"}}`
 			final, _ = sjson.Set(final, "sequence_number", nextSeq())
 			final, _ = sjson.Set(final, "item.id", st.CurrentMsgID)
+			for _, annotation := range st.Annotations {
+				final, _ = sjson.SetRaw(final, "item.content.0.annotations.-1", annotation)
+			}
 			out = append(out, emitEvent("response.output_item.done", final))
 			st.InTextBlock = false
 		} else if st.InFuncBlock {
-			args := "{}"
+			raw := ""
 			if buf := st.FuncArgsBuf[idx]; buf != nil {
-				if buf.Len() > 0 {
-					args = buf.String()
-				}
+				raw = buf.String()
 			}
+			args, argsOK := translator.FinalizeToolArguments(raw)
 			fcDone := `{"type":"response.function_call_arguments.done","sequence_number":0,"item_id":"","output_index":0,"arguments":""}`
 			fcDone, _ = sjson.Set(fcDone, "sequence_number", nextSeq())
 			fcDone, _ = sjson.Set(fcDone, "item_id", fmt.Sprintf("fc_%s", st.CurrentFCID))
@@ -251,6 +262,9 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			itemDone, _ = sjson.Set(itemDone, "output_index", idx)
 			itemDone, _ = sjson.Set(itemDone, "item.id", fmt.Sprintf("fc_%s", st.CurrentFCID))
 			itemDone, _ = sjson.Set(itemDone, "item.arguments", args)
+			if !argsOK {
+				itemDone, _ = sjson.Set(itemDone, "item.status", "incomplete")
+			}
 			itemDone, _ = sjson.Set(itemDone, "item.call_id", st.CurrentFCID)
 			itemDone, _ = sjson.Set(itemDone, "item.name", st.FuncNames[idx])
 			out = append(out, emitEvent("response.output_item.done", itemDone))
@@ -274,12 +288,8 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 		}
 	case "message_delta":
 		if usage := root.Get("usage"); usage.Exists() {
-			if v := usage.Get("output_tokens"); v.Exists() {
-				st.OutputTokens = v.Int()
-				st.UsageSeen = true
-			}
-			if v := usage.Get("input_tokens"); v.Exists() {
-				st.InputTokens = v.Int()
+			st.Usage.MergeClaude(usage)
+			if usage.Get("input_tokens").Exists() || usage.Get("output_tokens").Exists() {
 				st.UsageSeen = true
 			}
 		}
@@ -370,6 +380,9 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			item := `{"id":"","type":"message","status":"completed","content":[{"type":"output_text","annotations":[],"logprobs":[],"text":""}],"role":"assistant"}`
 			item, _ = sjson.Set(item, "id", st.CurrentMsgID)
 			item, _ = sjson.Set(item, "content.0.text", st.TextBuf.String())
+			for _, annotation := range st.Annotations {
+				item, _ = sjson.SetRaw(item, "content.0.annotations.-1", annotation)
+			}
 			outputsWrapper, _ = sjson.SetRaw(outputsWrapper, "arr.-1", item)
 		}
 		// function_call items (in ascending index order for determinism)
@@ -388,10 +401,11 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 				}
 			}
 			for _, idx := range idxs {
-				args := ""
+				raw := ""
 				if b := st.FuncArgsBuf[idx]; b != nil {
-					args = b.String()
+					raw = b.String()
 				}
+				args, argsOK := translator.FinalizeToolArguments(raw)
 				callID := st.FuncCallIDs[idx]
 				name := st.FuncNames[idx]
 				if callID == "" && st.CurrentFCID != "" {
@@ -402,6 +416,9 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 				item, _ = sjson.Set(item, "arguments", args)
 				item, _ = sjson.Set(item, "call_id", callID)
 				item, _ = sjson.Set(item, "name", name)
+				if !argsOK {
+					item, _ = sjson.Set(item, "status", "incomplete")
+				}
 				outputsWrapper, _ = sjson.SetRaw(outputsWrapper, "arr.-1", item)
 			}
 		}
@@ -409,26 +426,15 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			completed, _ = sjson.SetRaw(completed, "response.output", gjson.Get(outputsWrapper, "arr").Raw)
 		}
 
-		reasoningTokens := int64(0)
-		if st.ReasoningBuf.Len() > 0 {
-			reasoningTokens = int64(st.ReasoningBuf.Len() / 4)
-		}
-		usagePresent := st.UsageSeen || reasoningTokens > 0
-		if usagePresent {
-			completed, _ = sjson.Set(completed, "response.usage.input_tokens", st.InputTokens)
-			completed, _ = sjson.Set(completed, "response.usage.input_tokens_details.cached_tokens", 0)
-			completed, _ = sjson.Set(completed, "response.usage.output_tokens", st.OutputTokens)
-			if reasoningTokens > 0 {
-				completed, _ = sjson.Set(completed, "response.usage.output_tokens_details.reasoning_tokens", reasoningTokens)
-			}
-			total := st.InputTokens + st.OutputTokens
-			if total > 0 || st.UsageSeen {
-				completed, _ = sjson.Set(completed, "response.usage.total_tokens", total)
-			}
+		// Claude does not report reasoning tokens; estimate them from the thinking text.
+		usage := st.Usage
+		usage.ReasoningTokens = int64(st.ReasoningBuf.Len() / 4)
+		if st.UsageSeen || usage.ReasoningTokens > 0 {
+			completed, _ = sjson.SetRaw(completed, "response.usage", usage.OpenAIResponses())
 		}
 
 		// Log thông tin token usage cho request Claude (Responses API)
-		log.Infof("Request Claude %s. prompt_tokens: %d, completion_tokens: %d, totalTokens: %d, reasoningTokens: %d.", modelName, st.InputTokens, st.OutputTokens, st.InputTokens+st.OutputTokens, reasoningTokens)
+		log.Infof("Request Claude %s. prompt_tokens: %d, completion_tokens: %d, totalTokens: %d, reasoningTokens: %d.", modelName, usage.InputTokens, usage.OutputTokens, usage.Total(), usage.ReasoningTokens)
 
 		out = append(out, emitEvent("response.completed", completed))
 	}
@@ -469,11 +475,12 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 		currentMsgID    string
 		currentFCID     string
 		textBuf         strings.Builder
+		citations       translator.CitationTracker
+		annotations     []string
 		reasoningBuf    strings.Builder
 		reasoningActive bool
 		reasoningItemID string
-		inputTokens     int64
-		outputTokens    int64
+		usage           translator.Usage
 	)
 
 	// Per-index tool call aggregation
@@ -494,9 +501,7 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			if msg := root.Get("message"); msg.Exists() {
 				responseID = msg.Get("id").String()
 				createdAt = time.Now().Unix()
-				if usage := msg.Get("usage"); usage.Exists() {
-					inputTokens = usage.Get("input_tokens").Int()
-				}
+				usage.MergeClaude(msg.Get("usage"))
 			}
 
 		case "content_block_start":
@@ -509,8 +514,9 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			switch typ {
 			case "text":
 				currentMsgID = "msg_" + responseID + "_0"
+				citations.StartBlock(idx, cb)
 			case "tool_use":
-				currentFCID = cb.Get("id").String()
+				currentFCID = cache.ClientToolID(toolIDSession(gjson.ParseBytes(pickRequestJSON(originalRequestRawJSON, requestRawJSON))), cb.Get("id").String())
 				name := cb.Get("name").String()
 				if toolCalls[idx] == nil {
 					toolCalls[idx] = &toolState{id: currentFCID, name: name}
@@ -533,7 +539,10 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			case "text_delta":
 				if t := d.Get("text"); t.Exists() {
 					textBuf.WriteString(t.String())
+					citations.Advance(t.String())
 				}
+			case "citations_delta":
+				citations.AddCitation(int(root.Get("index").Int()), d.Get("citation"))
 			case "input_json_delta":
 				if pj := d.Get("partial_json"); pj.Exists() {
 					idx := int(root.Get("index").Int())
@@ -551,13 +560,12 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			}
 
 		case "content_block_stop":
-			// Nothing special to finalize for non-stream aggregation
-			_ = root
+			for _, span := range citations.EndBlock(int(root.Get("index").Int())) {
+				annotations = append(annotations, span.ResponsesAnnotation())
+			}
 
 		case "message_delta":
-			if usage := root.Get("usage"); usage.Exists() {
-				outputTokens = usage.Get("output_tokens").Int()
-			}
+			usage.MergeClaude(root.Get("usage"))
 		}
 	}
 
@@ -643,6 +651,9 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 		item := `{"id":"","type":"message","status":"completed","content":[{"type":"output_text","annotations":[],"logprobs":[],"text":""}],"role":"assistant"}`
 		item, _ = sjson.Set(item, "id", currentMsgID)
 		item, _ = sjson.Set(item, "content.0.text", textBuf.String())
+		for _, annotation := range annotations {
+			item, _ = sjson.SetRaw(item, "content.0.annotations.-1", annotation)
+		}
 		outputsWrapper, _ = sjson.SetRaw(outputsWrapper, "arr.-1", item)
 	}
 	if len(toolCalls) > 0 {
@@ -660,15 +671,15 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 		}
 		for _, i := range idxs {
 			st := toolCalls[i]
-			args := st.args.String()
-			if args == "" {
-				args = "{}"
-			}
+			args, argsOK := translator.FinalizeToolArguments(st.args.String())
 			item := `{"id":"","type":"function_call","status":"completed","arguments":"","call_id":"","name":""}`
 			item, _ = sjson.Set(item, "id", fmt.Sprintf("fc_%s", st.id))
 			item, _ = sjson.Set(item, "arguments", args)
 			item, _ = sjson.Set(item, "call_id", st.id)
 			item, _ = sjson.Set(item, "name", st.name)
+			if !argsOK {
+				item, _ = sjson.Set(item, "status", "incomplete")
+			}
 			outputsWrapper, _ = sjson.SetRaw(outputsWrapper, "arr.-1", item)
 		}
 	}
@@ -676,19 +687,9 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 		out, _ = sjson.SetRaw(out, "output", gjson.Get(outputsWrapper, "arr").Raw)
 	}
 
-	// Usage
-	total := inputTokens + outputTokens
-	out, _ = sjson.Set(out, "usage.input_tokens", inputTokens)
-	out, _ = sjson.Set(out, "usage.output_tokens", outputTokens)
-	out, _ = sjson.Set(out, "usage.total_tokens", total)
-	reasoningTokens := int64(0)
-	if reasoningBuf.Len() > 0 {
-		// Rough estimate similar to chat completions
-		reasoningTokens = int64(len(reasoningBuf.String()) / 4)
-		if reasoningTokens > 0 {
-			out, _ = sjson.Set(out, "usage.output_tokens_details.reasoning_tokens", reasoningTokens)
-		}
-	}
+	// Usage; reasoning tokens are a rough estimate similar to chat completions
+	usage.ReasoningTokens = int64(reasoningBuf.Len() / 4)
+	out, _ = sjson.SetRaw(out, "usage", usage.OpenAIResponses())
 
 	// Log thông tin token usage cho request Claude (Responses API - NonStream)
 	modelName := ""
@@ -697,7 +698,7 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			modelName = v.String()
 		}
 	}
-	log.Infof("Request Claude %s. prompt_tokens: %d, completion_tokens: %d, totalTokens: %d, reasoningTokens: %d.", modelName, inputTokens, outputTokens, total, reasoningTokens)
+	log.Infof("Request Claude %s. prompt_tokens: %d, completion_tokens: %d, totalTokens: %d, reasoningTokens: %d.", modelName, usage.InputTokens, usage.OutputTokens, usage.Total(), usage.ReasoningTokens)
 
 	return out
 }
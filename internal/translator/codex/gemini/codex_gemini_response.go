@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -121,10 +122,7 @@ func ConvertCodexResponseToGemini(_ context.Context, modelName string, originalR
 		part, _ = sjson.Set(part, "text", rootResult.Get("delta").String())
 		template, _ = sjson.SetRaw(template, "candidates.0.content.parts.-1", part)
 	} else if typeStr == "response.completed" { // Handle response completion with usage metadata
-		template, _ = sjson.Set(template, "usageMetadata.promptTokenCount", rootResult.Get("response.usage.input_tokens").Int())
-		template, _ = sjson.Set(template, "usageMetadata.candidatesTokenCount", rootResult.Get("response.usage.output_tokens").Int())
-		totalTokens := rootResult.Get("response.usage.input_tokens").Int() + rootResult.Get("response.usage.output_tokens").Int()
-		template, _ = sjson.Set(template, "usageMetadata.totalTokenCount", totalTokens)
+		template, _ = sjson.SetRaw(template, "usageMetadata", geminiUsageMetadata(rootResult.Get("response.usage")))
 	} else {
 		return []string{}
 	}
@@ -179,13 +177,7 @@ func ConvertCodexResponseToGeminiNonStream(_ context.Context, modelName string,
 
 		// Set usage metadata
 		if usage := responseData.Get("usage"); usage.Exists() {
-			inputTokens := usage.Get("input_tokens").Int()
-			outputTokens := usage.Get("output_tokens").Int()
-			totalTokens := inputTokens + outputTokens
-
-			template, _ = sjson.Set(template, "usageMetadata.promptTokenCount", inputTokens)
-			template, _ = sjson.Set(template, "usageMetadata.candidatesTokenCount", outputTokens)
-			template, _ = sjson.Set(template, "usageMetadata.totalTokenCount", totalTokens)
+			template, _ = sjson.SetRaw(template, "usageMetadata", geminiUsageMetadata(usage))
 		}
 
 		// Process output content to build parts array
@@ -307,6 +299,13 @@ func buildReverseMapFromGeminiOriginal(original []byte) map[string]string {
 	return rev
 }
 
+// geminiUsageMetadata renders a Responses API usage object as Gemini usageMetadata.
+func geminiUsageMetadata(usage gjson.Result) string {
+	out := translator.UsageFromOpenAI(usage).Gemini()
+	out, _ = sjson.Set(out, "trafficType", "PROVISIONED_THROUGHPUT")
+	return out
+}
+
 func GeminiTokenCount(ctx context.Context, count int64) string {
 	return fmt.Sprintf(`{"totalTokens":%d,"promptTokensDetails":[{"modality":"TEXT","tokenCount":%d}]}`, count, count)
 }
@@ -10,6 +10,8 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -27,7 +29,7 @@ import (
 // Returns:
 //   - []byte: The transformed request data in OpenAI Responses API format
 func ConvertOpenAIRequestToCodex(modelName string, inputRawJSON []byte, stream bool) []byte {
-	rawJSON := inputRawJSON
+	rawJSON := util.ApplyMessageNames(inputRawJSON)
 	// Start with empty JSON object
 	out := `{"instructions":""}`
 
@@ -53,12 +55,14 @@ func ConvertOpenAIRequestToCodex(modelName string, inputRawJSON []byte, stream b
 	// 	out, _ = sjson.Set(out, "max_output_tokens", v.Value())
 	// }
 
-	// Map reasoning effort
-	if v := gjson.GetBytes(rawJSON, "reasoning_effort"); v.Exists() {
-		out, _ = sjson.Set(out, "reasoning.effort", v.Value())
-	} else {
-		out, _ = sjson.Set(out, "reasoning.effort", "medium")
+	// Map reasoning effort; numeric budgets and reasoning.max_tokens become the nearest level
+	effort := "medium"
+	if config, ok := thinking.ExtractReasoningConfig(gjson.ParseBytes(rawJSON)); ok {
+		if level, ok := thinking.ConfigToLevel(config); ok {
+			effort = level
+		}
 	}
+	out, _ = sjson.Set(out, "reasoning.effort", effort)
 	out, _ = sjson.Set(out, "parallel_tool_calls", true)
 	out, _ = sjson.Set(out, "reasoning.summary", "auto")
 	out, _ = sjson.Set(out, "include", []string{"reasoning.encrypted_content"})
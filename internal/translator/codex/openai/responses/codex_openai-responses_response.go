@@ -22,6 +22,7 @@ func ConvertCodexResponseToOpenAIResponses(ctx context.Context, modelName string
 					instructions := gjson.GetBytes(originalRequestRawJSON, "instructions").String()
 					rawJSON, _ = sjson.SetBytes(rawJSON, "response.instructions", instructions)
 				}
+				rawJSON = restoreClientResponseFields(rawJSON, "response.", originalRequestRawJSON)
 			}
 		}
 		out := fmt.Sprintf("data: %s", string(rawJSON))
@@ -44,5 +45,20 @@ func ConvertCodexResponseToOpenAIResponsesNonStream(_ context.Context, modelName
 		instructions := gjson.GetBytes(originalRequestRawJSON, "instructions").String()
 		template, _ = sjson.Set(template, "instructions", instructions)
 	}
-	return template
+	return string(restoreClientResponseFields([]byte(template), "", originalRequestRawJSON))
+}
+
+// clientResponseFields are echoed from the client request because the Codex request
+// forces store=false and the proxy, not Codex, resolves previous_response_id.
+var clientResponseFields = []string{"store", "metadata", "previous_response_id"}
+
+// restoreClientResponseFields sets clientResponseFields under prefix to the values the
+// client sent.
+func restoreClientResponseFields(rawJSON []byte, prefix string, originalRequestRawJSON []byte) []byte {
+	for _, field := range clientResponseFields {
+		if v := gjson.GetBytes(originalRequestRawJSON, field); v.Exists() {
+			rawJSON, _ = sjson.SetRawBytes(rawJSON, prefix+field, []byte(v.Raw))
+		}
+	}
+	return rawJSON
 }
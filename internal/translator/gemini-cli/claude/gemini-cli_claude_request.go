@@ -175,11 +175,16 @@ func ConvertClaudeRequestToCLI(modelName string, inputRawJSON []byte, _ bool) []
 	if t := gjson.GetBytes(rawJSON, "thinking"); t.Exists() && t.IsObject() {
 		switch t.Get("type").String() {
 		case "enabled":
+			// Without budget_tokens the client wants thinking at the model's discretion.
+			budget := -1
 			if b := t.Get("budget_tokens"); b.Exists() && b.Type == gjson.Number {
-				budget := int(b.Int())
-				out, _ = sjson.Set(out, "request.generationConfig.thinkingConfig.thinkingBudget", budget)
-				out, _ = sjson.Set(out, "request.generationConfig.thinkingConfig.includeThoughts", true)
+				budget = int(b.Int())
 			}
+			out, _ = sjson.Set(out, "request.generationConfig.thinkingConfig.thinkingBudget", budget)
+			out, _ = sjson.Set(out, "request.generationConfig.thinkingConfig.includeThoughts", true)
+		case "disabled":
+			out, _ = sjson.Set(out, "request.generationConfig.thinkingConfig.thinkingBudget", 0)
+			out, _ = sjson.Set(out, "request.generationConfig.thinkingConfig.includeThoughts", false)
 		case "adaptive":
 			// Keep adaptive as a high level sentinel; ApplyThinking resolves it
 			// to model-specific max capability.
@@ -14,6 +14,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -138,6 +139,13 @@ func ConvertGeminiCLIResponseToClaude(_ context.Context, _ string, originalReque
 						(*param).(*Params).ResponseType = 2 // Set state to thinking
 						(*param).(*Params).HasContent = true
 					}
+					// Forward the thought signature so the block has the same shape as a native
+					// Claude thinking block.
+					if signature := geminiThoughtSignature(partResult); signature != "" {
+						output = output + "event: content_block_delta\n"
+						data, _ := sjson.Set(fmt.Sprintf(`{"type":"content_block_delta","index":%d,"delta":{"type":"signature_delta","signature":""}}`, (*param).(*Params).ResponseIndex), "delta.signature", signature)
+						output = output + fmt.Sprintf("data: %s\n\n\n", data)
+					}
 				} else {
 					// Process regular text content (user-visible output)
 					// Continue existing text block if already in content state
@@ -249,9 +257,7 @@ func ConvertGeminiCLIResponseToClaude(_ context.Context, _ string, originalReque
 				}
 
 				// Include thinking tokens in output token count if present
-				thoughtsTokenCount := usageResult.Get("thoughtsTokenCount").Int()
-				template, _ = sjson.Set(template, "usage.output_tokens", candidatesTokenCountResult.Int()+thoughtsTokenCount)
-				template, _ = sjson.Set(template, "usage.input_tokens", usageResult.Get("promptTokenCount").Int())
+				template, _ = sjson.SetRaw(template, "usage", translator.UsageFromGemini(usageResult).Claude())
 
 				output = output + template + "\n\n\n"
 			}
@@ -281,14 +287,13 @@ func ConvertGeminiCLIResponseToClaudeNonStream(_ context.Context, _ string, orig
 	out, _ = sjson.Set(out, "id", root.Get("response.responseId").String())
 	out, _ = sjson.Set(out, "model", root.Get("response.modelVersion").String())
 
-	inputTokens := root.Get("response.usageMetadata.promptTokenCount").Int()
-	outputTokens := root.Get("response.usageMetadata.candidatesTokenCount").Int() + root.Get("response.usageMetadata.thoughtsTokenCount").Int()
-	out, _ = sjson.Set(out, "usage.input_tokens", inputTokens)
-	out, _ = sjson.Set(out, "usage.output_tokens", outputTokens)
+	usage := translator.UsageFromGemini(root.Get("response.usageMetadata"))
+	out, _ = sjson.SetRaw(out, "usage", usage.Claude())
 
 	parts := root.Get("response.candidates.0.content.parts")
 	textBuilder := strings.Builder{}
 	thinkingBuilder := strings.Builder{}
+	thinkingSignature := ""
 	toolIDCounter := 0
 	hasToolCall := false
 
@@ -303,17 +308,26 @@ func ConvertGeminiCLIResponseToClaudeNonStream(_ context.Context, _ string, orig
 	}
 
 	flushThinking := func() {
-		if thinkingBuilder.Len() == 0 {
+		if thinkingBuilder.Len() == 0 && thinkingSignature == "" {
 			return
 		}
 		block := `{"type":"thinking","thinking":""}`
 		block, _ = sjson.Set(block, "thinking", thinkingBuilder.String())
+		if thinkingSignature != "" {
+			block, _ = sjson.Set(block, "signature", thinkingSignature)
+			thinkingSignature = ""
+		}
 		out, _ = sjson.SetRaw(out, "content.-1", block)
 		thinkingBuilder.Reset()
 	}
 
 	if parts.IsArray() {
 		for _, part := range parts.Array() {
+			if part.Get("thought").Bool() {
+				if signature := geminiThoughtSignature(part); signature != "" {
+					thinkingSignature = signature
+				}
+			}
 			if text := part.Get("text"); text.Exists() && text.String() != "" {
 				if part.Get("thought").Bool() {
 					flushText()
@@ -366,7 +380,7 @@ func ConvertGeminiCLIResponseToClaudeNonStream(_ context.Context, _ string, orig
 	}
 	out, _ = sjson.Set(out, "stop_reason", stopReason)
 
-	if inputTokens == int64(0) && outputTokens == int64(0) && !root.Get("response.usageMetadata").Exists() {
+	if usage.IsZero() && !root.Get("response.usageMetadata").Exists() {
 		out, _ = sjson.Delete(out, "usage")
 	}
 
@@ -376,3 +390,16 @@ func ConvertGeminiCLIResponseToClaudeNonStream(_ context.Context, _ string, orig
 func ClaudeTokenCount(ctx context.Context, count int64) string {
 	return fmt.Sprintf(`{"input_tokens":%d}`, count)
 }
+
+// geminiThoughtSignature returns the signature Gemini attached to a thought part, ignoring
+// the validator-bypass sentinel the request translators inject.
+func geminiThoughtSignature(part gjson.Result) string {
+	signature := part.Get("thoughtSignature")
+	if !signature.Exists() {
+		signature = part.Get("thought_signature")
+	}
+	if value := signature.String(); value != "" && value != "skip_thought_signature_validator" {
+		return value
+	}
+	return ""
+}
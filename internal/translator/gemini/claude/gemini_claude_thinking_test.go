@@ -0,0 +1,58 @@
+package claude
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertClaudeRequestToGemini_ThinkingConfig(t *testing.T) {
+	cases := []struct {
+		name        string
+		thinking    string
+		wantBudget  int64
+		wantInclude bool
+	}{
+		{name: "budget", thinking: `{"type":"enabled","budget_tokens":4096}`, wantBudget: 4096, wantInclude: true},
+		{name: "enabled without budget", thinking: `{"type":"enabled"}`, wantBudget: -1, wantInclude: true},
+		{name: "disabled", thinking: `{"type":"disabled"}`, wantBudget: 0, wantInclude: false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			input := `{"model":"claude","thinking":` + tc.thinking + `,"messages":[{"role":"user","content":"hi"}]}`
+			cfg := gjson.GetBytes(ConvertClaudeRequestToGemini("gemini-2.5-flash", []byte(input), false), "generationConfig.thinkingConfig")
+			if got := cfg.Get("thinkingBudget"); !got.Exists() || got.Int() != tc.wantBudget {
+				t.Fatalf("thinkingBudget = %s, want %d", got.Raw, tc.wantBudget)
+			}
+			if got := cfg.Get("includeThoughts").Bool(); got != tc.wantInclude {
+				t.Fatalf("includeThoughts = %v, want %v", got, tc.wantInclude)
+			}
+		})
+	}
+}
+
+func TestConvertGeminiResponseToClaude_ThoughtSignature(t *testing.T) {
+	chunk := []byte(`{"candidates":[{"content":{"parts":[{"text":"pondering","thought":true,"thoughtSignature":"sig-1"}]}}]}`)
+	var param any
+	events := strings.Join(ConvertGeminiResponseToClaude(context.Background(), "", nil, nil, chunk, &param), "")
+	if !strings.Contains(events, `"thinking_delta","thinking":"pondering"`) {
+		t.Fatalf("missing thinking delta: %s", events)
+	}
+	if !strings.Contains(events, `"signature_delta","signature":"sig-1"`) {
+		t.Fatalf("missing signature delta: %s", events)
+	}
+
+	full := []byte(`{"candidates":[{"content":{"parts":[{"text":"pondering","thought":true},{"text":"","thought":true,"thoughtSignature":"sig-2"},{"text":"answer"}]},"finishReason":"STOP"}]}`)
+	out := gjson.Parse(ConvertGeminiResponseToClaudeNonStream(context.Background(), "", nil, nil, full, nil))
+	if got := out.Get("content.0.type").String(); got != "thinking" {
+		t.Fatalf("content.0.type = %q, want thinking", got)
+	}
+	if got := out.Get("content.0.signature").String(); got != "sig-2" {
+		t.Fatalf("content.0.signature = %q, want sig-2", got)
+	}
+	if got := out.Get("content.1.text").String(); got != "answer" {
+		t.Fatalf("content.1.text = %q, want answer", got)
+	}
+}
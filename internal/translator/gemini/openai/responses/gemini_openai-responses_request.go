@@ -3,6 +3,7 @@ package responses
 import (
 	"strings"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/gemini/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -116,7 +117,7 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 
 			switch itemType {
 			case "message":
-				if strings.EqualFold(itemRole, "system") {
+				if strings.EqualFold(itemRole, "system") || strings.EqualFold(itemRole, "developer") {
 					if contentArray := item.Get("content"); contentArray.Exists() {
 						systemInstr := ""
 						if systemInstructionResult := gjson.Get(out, "system_instruction"); systemInstructionResult.Exists() {
@@ -419,19 +420,21 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 		out, _ = sjson.Set(out, "generationConfig.stopSequences", sequences)
 	}
 
-	// Apply thinking configuration: convert OpenAI Responses API reasoning.effort to Gemini thinkingConfig.
+	// Apply thinking configuration: convert OpenAI Responses API reasoning.effort / reasoning.max_tokens to Gemini thinkingConfig.
 	// Inline translation-only mapping; capability checks happen later in ApplyThinking.
-	re := root.Get("reasoning.effort")
-	if re.Exists() {
-		effort := strings.ToLower(strings.TrimSpace(re.String()))
-		if effort != "" {
-			thinkingPath := "generationConfig.thinkingConfig"
-			if effort == "auto" {
-				out, _ = sjson.Set(out, thinkingPath+".thinkingBudget", -1)
-				out, _ = sjson.Set(out, thinkingPath+".includeThoughts", true)
-			} else {
-				out, _ = sjson.Set(out, thinkingPath+".thinkingLevel", effort)
-				out, _ = sjson.Set(out, thinkingPath+".includeThoughts", effort != "none")
+	if config, ok := thinking.ExtractReasoningConfig(root); ok {
+		thinkingPath := "generationConfig.thinkingConfig"
+		switch config.Mode {
+		case thinking.ModeAuto:
+			out, _ = sjson.Set(out, thinkingPath+".thinkingBudget", -1)
+			out, _ = sjson.Set(out, thinkingPath+".includeThoughts", true)
+		case thinking.ModeBudget:
+			out, _ = sjson.Set(out, thinkingPath+".thinkingBudget", config.Budget)
+			out, _ = sjson.Set(out, thinkingPath+".includeThoughts", true)
+		default:
+			if level, ok := thinking.ConfigToLevel(config); ok {
+				out, _ = sjson.Set(out, thinkingPath+".thinkingLevel", level)
+				out, _ = sjson.Set(out, thinkingPath+".includeThoughts", level != string(thinking.LevelNone))
 			}
 		}
 	}
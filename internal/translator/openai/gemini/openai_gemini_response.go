@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -83,12 +84,7 @@ func ConvertOpenAIResponseToGemini(_ context.Context, _ string, originalRequestR
 					template, _ = sjson.Set(template, "model", model.String())
 				}
 
-				template, _ = sjson.Set(template, "usageMetadata.promptTokenCount", usage.Get("prompt_tokens").Int())
-				template, _ = sjson.Set(template, "usageMetadata.candidatesTokenCount", usage.Get("completion_tokens").Int())
-				template, _ = sjson.Set(template, "usageMetadata.totalTokenCount", usage.Get("total_tokens").Int())
-				if reasoningTokens := reasoningTokensFromUsage(usage); reasoningTokens > 0 {
-					template, _ = sjson.Set(template, "usageMetadata.thoughtsTokenCount", reasoningTokens)
-				}
+				template, _ = sjson.SetRaw(template, "usageMetadata", translator.UsageFromOpenAI(usage).Gemini())
 				return []string{template}
 			}
 			return []string{}
@@ -230,12 +226,7 @@ func ConvertOpenAIResponseToGemini(_ context.Context, _ string, originalRequestR
 
 			// Handle usage information
 			if usage := root.Get("usage"); usage.Exists() {
-				template, _ = sjson.Set(template, "usageMetadata.promptTokenCount", usage.Get("prompt_tokens").Int())
-				template, _ = sjson.Set(template, "usageMetadata.candidatesTokenCount", usage.Get("completion_tokens").Int())
-				template, _ = sjson.Set(template, "usageMetadata.totalTokenCount", usage.Get("total_tokens").Int())
-				if reasoningTokens := reasoningTokensFromUsage(usage); reasoningTokens > 0 {
-					template, _ = sjson.Set(template, "usageMetadata.thoughtsTokenCount", reasoningTokens)
-				}
+				template, _ = sjson.SetRaw(template, "usageMetadata", translator.UsageFromOpenAI(usage).Gemini())
 				results = append(results, template)
 				return true
 			}
@@ -609,12 +600,7 @@ func ConvertOpenAIResponseToGeminiNonStream(_ context.Context, _ string, origina
 
 	// Handle usage information
 	if usage := root.Get("usage"); usage.Exists() {
-		out, _ = sjson.Set(out, "usageMetadata.promptTokenCount", usage.Get("prompt_tokens").Int())
-		out, _ = sjson.Set(out, "usageMetadata.candidatesTokenCount", usage.Get("completion_tokens").Int())
-		out, _ = sjson.Set(out, "usageMetadata.totalTokenCount", usage.Get("total_tokens").Int())
-		if reasoningTokens := reasoningTokensFromUsage(usage); reasoningTokens > 0 {
-			out, _ = sjson.Set(out, "usageMetadata.thoughtsTokenCount", reasoningTokens)
-		}
+		out, _ = sjson.SetRaw(out, "usageMetadata", translator.UsageFromOpenAI(usage).Gemini())
 	}
 
 	return out
@@ -624,18 +610,6 @@ func GeminiTokenCount(ctx context.Context, count int64) string {
 	return fmt.Sprintf(`{"totalTokens":%d,"promptTokensDetails":[{"modality":"TEXT","tokenCount":%d}]}`, count, count)
 }
 
-func reasoningTokensFromUsage(usage gjson.Result) int64 {
-	if usage.Exists() {
-		if v := usage.Get("completion_tokens_details.reasoning_tokens"); v.Exists() {
-			return v.Int()
-		}
-		if v := usage.Get("output_tokens_details.reasoning_tokens"); v.Exists() {
-			return v.Int()
-		}
-	}
-	return 0
-}
-
 func extractReasoningTexts(node gjson.Result) []string {
 	var texts []string
 	if !node.Exists() {
@@ -1,8 +1,7 @@
 package responses
 
 import (
-	"strings"
-
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -198,9 +197,9 @@ func ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName string, inpu
 		}
 	}
 
-	if reasoningEffort := root.Get("reasoning.effort"); reasoningEffort.Exists() {
-		effort := strings.ToLower(strings.TrimSpace(reasoningEffort.String()))
-		if effort != "" {
+	// Numeric efforts and reasoning.max_tokens are mapped to the nearest effort level.
+	if config, ok := thinking.ExtractReasoningConfig(root); ok {
+		if effort, ok := thinking.ConfigToLevel(config); ok {
 			out, _ = sjson.Set(out, "reasoning_effort", effort)
 		}
 	}
@@ -0,0 +1,26 @@
+package translator
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBufferSize keeps buffers grown by unusually large requests out of the pool,
+// so one huge history does not pin its memory for the life of the process.
+const maxPooledBufferSize = 8 << 20
+
+var bufferPool = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// AcquireBuffer returns an empty buffer from the translation buffer pool.
+func AcquireBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+// ReleaseBuffer returns buf to the pool. The caller must not use buf afterwards.
+func ReleaseBuffer(buf *bytes.Buffer) {
+	if buf == nil || buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	bufferPool.Put(buf)
+}
@@ -0,0 +1,146 @@
+package translator
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// CitationTracker maps citations attached to Anthropic text blocks onto OpenAI annotations.
+// Offsets are character positions in the text the client receives, so every piece of text
+// sent to the client must be passed to Advance.
+type CitationTracker struct {
+	offset int
+	blocks map[int]*citedBlock
+}
+
+type citedBlock struct {
+	start     int
+	citations []string
+}
+
+// CitationSpan is one citation covering content[Start:End].
+type CitationSpan struct {
+	Citation gjson.Result
+	Start    int
+	End      int
+}
+
+// Advance records text sent to the client.
+func (t *CitationTracker) Advance(text string) {
+	t.offset += utf8.RuneCountInString(text)
+}
+
+// StartBlock marks the beginning of text block index, keeping citations the block already carries.
+func (t *CitationTracker) StartBlock(index int, block gjson.Result) {
+	if t.blocks == nil {
+		t.blocks = make(map[int]*citedBlock)
+	}
+	cb := &citedBlock{start: t.offset}
+	block.Get("citations").ForEach(func(_, citation gjson.Result) bool {
+		cb.citations = append(cb.citations, citation.Raw)
+		return true
+	})
+	t.blocks[index] = cb
+}
+
+// AddCitation attaches a streamed citations_delta citation to text block index.
+func (t *CitationTracker) AddCitation(index int, citation gjson.Result) {
+	if t.blocks == nil {
+		t.blocks = make(map[int]*citedBlock)
+	}
+	cb, ok := t.blocks[index]
+	if !ok {
+		cb = &citedBlock{start: t.offset}
+		t.blocks[index] = cb
+	}
+	cb.citations = append(cb.citations, citation.Raw)
+}
+
+// EndBlock closes text block index and returns its citations spanning the block's text.
+func (t *CitationTracker) EndBlock(index int) []CitationSpan {
+	cb, ok := t.blocks[index]
+	if !ok {
+		return nil
+	}
+	delete(t.blocks, index)
+	spans := make([]CitationSpan, 0, len(cb.citations))
+	for _, raw := range cb.citations {
+		spans = append(spans, CitationSpan{Citation: gjson.Parse(raw), Start: cb.start, End: t.offset})
+	}
+	return spans
+}
+
+// citationURL returns the source URL of web search and search result citations.
+func (s CitationSpan) citationURL() string {
+	if url := s.Citation.Get("url").String(); url != "" {
+		return url
+	}
+	if source := s.Citation.Get("source").String(); strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return source
+	}
+	return ""
+}
+
+// citationLocation keeps the citation type and its start_/end_ location fields.
+func (s CitationSpan) citationLocation() string {
+	location := `{}`
+	s.Citation.ForEach(func(key, value gjson.Result) bool {
+		name := key.String()
+		if name == "type" || strings.HasPrefix(name, "start_") || strings.HasPrefix(name, "end_") {
+			location, _ = sjson.SetRaw(location, name, value.Raw)
+		}
+		return true
+	})
+	return location
+}
+
+// ChatAnnotation renders the span as an OpenAI Chat Completions message annotation:
+// url_citation for web sources, file_citation for document citations.
+func (s CitationSpan) ChatAnnotation() string {
+	if url := s.citationURL(); url != "" {
+		out := `{"type":"url_citation","url_citation":{"url":"","title":"","start_index":0,"end_index":0}}`
+		out, _ = sjson.Set(out, "url_citation.url", url)
+		out, _ = sjson.Set(out, "url_citation.title", s.Citation.Get("title").String())
+		out, _ = sjson.Set(out, "url_citation.start_index", s.Start)
+		out, _ = sjson.Set(out, "url_citation.end_index", s.End)
+		return out
+	}
+	out := `{"type":"file_citation","file_citation":{"file_id":"","title":"","cited_text":"","start_index":0,"end_index":0}}`
+	out, _ = sjson.Set(out, "file_citation.file_id", s.fileID())
+	out, _ = sjson.Set(out, "file_citation.title", s.Citation.Get("document_title").String())
+	out, _ = sjson.Set(out, "file_citation.cited_text", s.Citation.Get("cited_text").String())
+	out, _ = sjson.Set(out, "file_citation.start_index", s.Start)
+	out, _ = sjson.Set(out, "file_citation.end_index", s.End)
+	out, _ = sjson.SetRaw(out, "file_citation.location", s.citationLocation())
+	return out
+}
+
+// ResponsesAnnotation renders the span as an OpenAI Responses output_text annotation.
+func (s CitationSpan) ResponsesAnnotation() string {
+	if url := s.citationURL(); url != "" {
+		out := `{"type":"url_citation","url":"","title":"","start_index":0,"end_index":0}`
+		out, _ = sjson.Set(out, "url", url)
+		out, _ = sjson.Set(out, "title", s.Citation.Get("title").String())
+		out, _ = sjson.Set(out, "start_index", s.Start)
+		out, _ = sjson.Set(out, "end_index", s.End)
+		return out
+	}
+	out := `{"type":"file_citation","file_id":"","filename":"","index":0,"cited_text":"","start_index":0,"end_index":0}`
+	out, _ = sjson.Set(out, "file_id", s.fileID())
+	out, _ = sjson.Set(out, "filename", s.Citation.Get("document_title").String())
+	out, _ = sjson.Set(out, "index", s.End)
+	out, _ = sjson.Set(out, "cited_text", s.Citation.Get("cited_text").String())
+	out, _ = sjson.Set(out, "start_index", s.Start)
+	out, _ = sjson.Set(out, "end_index", s.End)
+	out, _ = sjson.SetRaw(out, "location", s.citationLocation())
+	return out
+}
+
+// fileID names the cited document by its position in the request, as Anthropic does.
+func (s CitationSpan) fileID() string {
+	return fmt.Sprintf("document_%d", s.Citation.Get("document_index").Int())
+}
@@ -0,0 +1,37 @@
+package translator
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// Empty message handling modes for request translators.
+const (
+	// EmptyMessagePrune removes messages that carry no content.
+	EmptyMessagePrune = "prune"
+	// EmptyMessagePlaceholder keeps them with a minimal placeholder text block.
+	EmptyMessagePlaceholder = "placeholder"
+)
+
+// EmptyMessagePlaceholderText is the text inserted by EmptyMessagePlaceholder.
+const EmptyMessagePlaceholderText = "(no content)"
+
+var emptyMessageMode atomic.Value
+
+// SetEmptyMessageMode selects how request translators treat history messages with no
+// content. Anything other than EmptyMessagePlaceholder means EmptyMessagePrune.
+func SetEmptyMessageMode(mode string) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode != EmptyMessagePlaceholder {
+		mode = EmptyMessagePrune
+	}
+	emptyMessageMode.Store(mode)
+}
+
+// EmptyMessageMode returns the current empty message mode.
+func EmptyMessageMode() string {
+	if mode, ok := emptyMessageMode.Load().(string); ok {
+		return mode
+	}
+	return EmptyMessagePrune
+}
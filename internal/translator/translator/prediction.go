@@ -0,0 +1,58 @@
+package translator
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/tidwall/gjson"
+)
+
+// Prediction handling modes for the OpenAI "prediction" (predicted outputs) parameter.
+const (
+	// PredictionStrip drops the parameter; no backend other than OpenAI understands it.
+	PredictionStrip = "strip"
+	// PredictionPrefill emulates it by prefilling the assistant turn with the predicted
+	// content on backends that support prefill, and echoing that content to the client.
+	PredictionPrefill = "prefill"
+)
+
+var predictionMode atomic.Value
+
+// SetPredictionMode selects how request translators treat the OpenAI prediction parameter.
+// Anything other than PredictionPrefill means PredictionStrip.
+func SetPredictionMode(mode string) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode != PredictionPrefill {
+		mode = PredictionStrip
+	}
+	predictionMode.Store(mode)
+}
+
+// PredictionMode returns the current prediction mode.
+func PredictionMode() string {
+	if mode, ok := predictionMode.Load().(string); ok {
+		return mode
+	}
+	return PredictionStrip
+}
+
+// PredictionText returns the predicted content of an OpenAI request, joining text parts
+// when the content is an array. It is empty when the request carries no usable prediction.
+func PredictionText(root gjson.Result) string {
+	prediction := root.Get("prediction")
+	if !prediction.Exists() || prediction.Get("type").String() != "content" {
+		return ""
+	}
+	content := prediction.Get("content")
+	if content.Type == gjson.String {
+		return content.String()
+	}
+	var sb strings.Builder
+	content.ForEach(func(_, part gjson.Result) bool {
+		if part.Get("type").String() == "text" {
+			sb.WriteString(part.Get("text").String())
+		}
+		return true
+	})
+	return sb.String()
+}
@@ -0,0 +1,133 @@
+package translator
+
+import (
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// FinalizeToolArguments validates tool call arguments accumulated from streamed deltas.
+// Empty input becomes "{}", valid objects pass through, and input cut short mid-stream is
+// repaired by closing open strings, arrays and objects, dropping a trailing partial member
+// when needed. Input that cannot be repaired is wrapped as {"INVALID_JSON": "<raw>"} and
+// reported with ok=false, so clients never receive unparseable arguments.
+func FinalizeToolArguments(raw string) (args string, ok bool) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return "{}", true
+	}
+	if gjson.Valid(trimmed) {
+		return trimmed, true
+	}
+	if repaired, okRepair := repairJSONObject(trimmed); okRepair {
+		log.Debugf("translator: repaired truncated tool arguments (%d bytes)", len(raw))
+		return repaired, true
+	}
+	log.Warnf("translator: tool arguments are not valid JSON and could not be repaired (%d bytes)", len(raw))
+	wrapped, _ := sjson.Set(`{}`, "INVALID_JSON", raw)
+	return wrapped, false
+}
+
+// repairJSONObject completes a truncated JSON object. It first closes the input as is,
+// then retries from each earlier member boundary, newest first.
+func repairJSONObject(raw string) (string, bool) {
+	if !strings.HasPrefix(raw, "{") {
+		return "", false
+	}
+	if candidate := closeJSON(raw); gjson.Valid(candidate) {
+		return candidate, true
+	}
+	cuts := jsonMemberBoundaries(raw)
+	for i := len(cuts) - 1; i >= 0; i-- {
+		if candidate := closeJSON(raw[:cuts[i]]); gjson.Valid(candidate) {
+			return candidate, true
+		}
+	}
+	return "", false
+}
+
+// jsonMemberBoundaries returns offsets outside strings where a member or element may be
+// dropped: just before each comma and just after each opening bracket.
+func jsonMemberBoundaries(raw string) []int {
+	var cuts []int
+	inString, escaped := false, false
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case ',':
+			cuts = append(cuts, i)
+		case '{', '[':
+			cuts = append(cuts, i+1)
+		}
+	}
+	return cuts
+}
+
+// closeJSON terminates an open string, drops a dangling comma, fills a dangling colon with
+// null, and appends the closers for every unclosed object and array.
+func closeJSON(prefix string) string {
+	var stack []byte
+	inString, escaped := false, false
+	for i := 0; i < len(prefix); i++ {
+		c := prefix[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			stack = append(stack, '}')
+		case '[':
+			stack = append(stack, ']')
+		case '}', ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+
+	var sb strings.Builder
+	out := prefix
+	if inString {
+		if escaped {
+			out = out[:len(out)-1]
+		}
+		sb.WriteString(out)
+		sb.WriteByte('"')
+	} else {
+		out = strings.TrimRight(out, " \t\r\n")
+		out = strings.TrimSuffix(out, ",")
+		sb.WriteString(out)
+		if strings.HasSuffix(out, ":") {
+			sb.WriteString("null")
+		}
+	}
+	for i := len(stack) - 1; i >= 0; i-- {
+		sb.WriteByte(stack[i])
+	}
+	return sb.String()
+}
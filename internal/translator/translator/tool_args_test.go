@@ -0,0 +1,28 @@
+package translator
+
+import "testing"
+
+func TestFinalizeToolArguments(t *testing.T) {
+	cases := []struct {
+		raw  string
+		want string
+		ok   bool
+	}{
+		{raw: "", want: `{}`, ok: true},
+		{raw: `{"q":"go"}`, want: `{"q":"go"}`, ok: true},
+		{raw: `{"path":"a.txt","body":"trunc`, want: `{"path":"a.txt","body":"trunc"}`, ok: true},
+		{raw: `{"items":[1,2,{"x":"a\"b`, want: `{"items":[1,2,{"x":"a\"b"}]}`, ok: true},
+		{raw: `{"a":1,`, want: `{"a":1}`, ok: true},
+		{raw: `{"a":1,"b":`, want: `{"a":1,"b":null}`, ok: true},
+		{raw: `{"a":1,"partial_ke`, want: `{"a":1}`, ok: true},
+		{raw: `{"flag":tru`, want: `{}`, ok: true},
+		{raw: `{"s":"end\`, want: `{"s":"end"}`, ok: true},
+		{raw: `not json`, want: `{"INVALID_JSON":"not json"}`, ok: false},
+	}
+	for _, tc := range cases {
+		got, ok := FinalizeToolArguments(tc.raw)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("FinalizeToolArguments(%q) = %q, %v; want %q, %v", tc.raw, got, ok, tc.want, tc.ok)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package translator
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Unknown upstream stream event handling modes.
+const (
+	// UnknownEventSkip drops events the translator does not understand.
+	UnknownEventSkip = "skip"
+	// UnknownEventPassthrough surfaces them to the client as typed extension chunks.
+	UnknownEventPassthrough = "passthrough"
+)
+
+// maxUnknownEventSeries bounds the number of distinct (translator, event) pairs counted,
+// so a misbehaving upstream cannot grow the metric without limit.
+const maxUnknownEventSeries = 256
+
+var unknownEventMode atomic.Value
+
+var unknownEvents = struct {
+	sync.Mutex
+	counts map[unknownEventKey]int64
+}{counts: make(map[unknownEventKey]int64)}
+
+type unknownEventKey struct {
+	translator string
+	event      string
+}
+
+// UnknownEventCount is the number of unknown events a translator has seen.
+type UnknownEventCount struct {
+	Translator string `json:"translator"`
+	Event      string `json:"event"`
+	Count      int64  `json:"count"`
+}
+
+// SetUnknownEventMode selects how stream translators treat unknown upstream events.
+// Anything other than UnknownEventPassthrough means UnknownEventSkip.
+func SetUnknownEventMode(mode string) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode != UnknownEventPassthrough {
+		mode = UnknownEventSkip
+	}
+	unknownEventMode.Store(mode)
+}
+
+// UnknownEventMode returns the current unknown event mode.
+func UnknownEventMode() string {
+	if mode, ok := unknownEventMode.Load().(string); ok {
+		return mode
+	}
+	return UnknownEventSkip
+}
+
+// RecordUnknownEvent counts an upstream event that translatorName could not map. The first
+// occurrence of each event type is logged, since it usually signals an upstream protocol change.
+func RecordUnknownEvent(translatorName, event string) {
+	if event == "" {
+		event = "<empty>"
+	}
+	key := unknownEventKey{translator: translatorName, event: event}
+	unknownEvents.Lock()
+	defer unknownEvents.Unlock()
+	count, seen := unknownEvents.counts[key]
+	if !seen {
+		if len(unknownEvents.counts) >= maxUnknownEventSeries {
+			key.event = "<other>"
+			count = unknownEvents.counts[key]
+		} else {
+			log.Warnf("translator %s: unknown upstream stream event %q", translatorName, event)
+		}
+	}
+	unknownEvents.counts[key] = count + 1
+}
+
+// UnknownEvents returns the unknown event counts sorted by translator and event.
+func UnknownEvents() []UnknownEventCount {
+	unknownEvents.Lock()
+	out := make([]UnknownEventCount, 0, len(unknownEvents.counts))
+	for key, count := range unknownEvents.counts {
+		out = append(out, UnknownEventCount{Translator: key.translator, Event: key.event, Count: count})
+	}
+	unknownEvents.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Translator != out[j].Translator {
+			return out[i].Translator < out[j].Translator
+		}
+		return out[i].Event < out[j].Event
+	})
+	return out
+}
+
+// WriteUnknownEventsPrometheus writes the unknown event counts in the Prometheus text
+// exposition format as cliproxy_translator_unknown_events_total.
+func WriteUnknownEventsPrometheus(w io.Writer) error {
+	if _, err := fmt.Fprintf(w, "# HELP cliproxy_translator_unknown_events_total Upstream stream events a translator did not recognise.\n# TYPE cliproxy_translator_unknown_events_total counter\n"); err != nil {
+		return err
+	}
+	for _, entry := range UnknownEvents() {
+		if _, err := fmt.Fprintf(w, "cliproxy_translator_unknown_events_total{translator=%q,event=%q} %d\n", entry.Translator, entry.Event, entry.Count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
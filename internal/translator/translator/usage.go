@@ -0,0 +1,187 @@
+package translator
+
+import (
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Usage is the provider-neutral token accounting shared by the response translators.
+// Counts follow the OpenAI convention: InputTokens includes cached and cache-written
+// prompt tokens, and OutputTokens includes reasoning tokens.
+type Usage struct {
+	InputTokens         int64
+	OutputTokens        int64
+	ReasoningTokens     int64
+	CachedTokens        int64
+	CacheCreationTokens int64
+	TotalTokens         int64
+}
+
+// UsageFromClaude reads an Anthropic usage object. Anthropic reports input_tokens
+// without cache reads and writes, so those are added back into InputTokens.
+func UsageFromClaude(node gjson.Result) Usage {
+	var u Usage
+	u.MergeClaude(node)
+	return u
+}
+
+// UsageFromOpenAI reads an OpenAI usage object in either the Chat Completions
+// (prompt_tokens/completion_tokens) or the Responses (input_tokens/output_tokens) shape.
+func UsageFromOpenAI(node gjson.Result) Usage {
+	u := Usage{
+		InputTokens:         firstInt(node, "prompt_tokens", "input_tokens"),
+		OutputTokens:        firstInt(node, "completion_tokens", "output_tokens"),
+		ReasoningTokens:     firstInt(node, "completion_tokens_details.reasoning_tokens", "output_tokens_details.reasoning_tokens"),
+		CachedTokens:        firstInt(node, "prompt_tokens_details.cached_tokens", "input_tokens_details.cached_tokens"),
+		CacheCreationTokens: firstInt(node, "prompt_tokens_details.cache_creation_tokens", "input_tokens_details.cache_creation_tokens"),
+		TotalTokens:         node.Get("total_tokens").Int(),
+	}
+	return u
+}
+
+// UsageFromGemini reads a Gemini usageMetadata object. Gemini counts thoughts apart
+// from candidates, so they are added into OutputTokens.
+func UsageFromGemini(node gjson.Result) Usage {
+	u := Usage{
+		InputTokens:     node.Get("promptTokenCount").Int(),
+		ReasoningTokens: node.Get("thoughtsTokenCount").Int(),
+		CachedTokens:    node.Get("cachedContentTokenCount").Int(),
+		TotalTokens:     node.Get("totalTokenCount").Int(),
+	}
+	u.OutputTokens = node.Get("candidatesTokenCount").Int() + u.ReasoningTokens
+	if u.OutputTokens == 0 && u.TotalTokens > u.InputTokens {
+		// Some upstreams omit candidatesTokenCount; derive it from the total.
+		u.OutputTokens = u.TotalTokens - u.InputTokens
+	}
+	return u
+}
+
+// Merge records the non-zero counts of other. Streams report usage in pieces (Claude
+// sends input tokens in message_start and output tokens in message_delta), so later
+// chunks only overwrite what they actually carry.
+func (u *Usage) Merge(other Usage) {
+	if other.InputTokens > 0 {
+		u.InputTokens = other.InputTokens
+	}
+	if other.OutputTokens > 0 {
+		u.OutputTokens = other.OutputTokens
+	}
+	if other.ReasoningTokens > 0 {
+		u.ReasoningTokens = other.ReasoningTokens
+	}
+	if other.CachedTokens > 0 {
+		u.CachedTokens = other.CachedTokens
+	}
+	if other.CacheCreationTokens > 0 {
+		u.CacheCreationTokens = other.CacheCreationTokens
+	}
+	if other.TotalTokens > 0 {
+		u.TotalTokens = other.TotalTokens
+	}
+}
+
+// MergeClaude records the non-zero counts of an Anthropic usage object. Fields are merged
+// before normalisation so a message_delta carrying only input_tokens keeps the cache
+// counts from message_start.
+func (u *Usage) MergeClaude(node gjson.Result) {
+	input := u.InputTokens - u.CachedTokens - u.CacheCreationTokens
+	if v := node.Get("input_tokens").Int(); v > 0 {
+		input = v
+	}
+	if v := node.Get("cache_read_input_tokens").Int(); v > 0 {
+		u.CachedTokens = v
+	}
+	if v := node.Get("cache_creation_input_tokens").Int(); v > 0 {
+		u.CacheCreationTokens = v
+	}
+	if v := node.Get("output_tokens").Int(); v > 0 {
+		u.OutputTokens = v
+	}
+	u.InputTokens = input + u.CachedTokens + u.CacheCreationTokens
+}
+
+// IsZero reports whether no counts were recorded.
+func (u Usage) IsZero() bool {
+	return u == Usage{}
+}
+
+// Total returns the reported total, or input plus output when the provider sent none.
+func (u Usage) Total() int64 {
+	if u.TotalTokens > 0 {
+		return u.TotalTokens
+	}
+	return u.InputTokens + u.OutputTokens
+}
+
+// OpenAIChat renders the usage as an OpenAI Chat Completions usage object. Cache writes
+// use the cache_creation_tokens extension field.
+func (u Usage) OpenAIChat() string {
+	out := `{"prompt_tokens":0,"completion_tokens":0,"total_tokens":0,"prompt_tokens_details":{"cached_tokens":0},"completion_tokens_details":{"reasoning_tokens":0}}`
+	out, _ = sjson.Set(out, "prompt_tokens", u.InputTokens)
+	out, _ = sjson.Set(out, "completion_tokens", u.OutputTokens)
+	out, _ = sjson.Set(out, "total_tokens", u.Total())
+	out, _ = sjson.Set(out, "prompt_tokens_details.cached_tokens", u.CachedTokens)
+	if u.CacheCreationTokens > 0 {
+		out, _ = sjson.Set(out, "prompt_tokens_details.cache_creation_tokens", u.CacheCreationTokens)
+	}
+	out, _ = sjson.Set(out, "completion_tokens_details.reasoning_tokens", u.ReasoningTokens)
+	return out
+}
+
+// OpenAIResponses renders the usage as an OpenAI Responses API usage object.
+func (u Usage) OpenAIResponses() string {
+	out := `{"input_tokens":0,"input_tokens_details":{"cached_tokens":0},"output_tokens":0,"output_tokens_details":{"reasoning_tokens":0},"total_tokens":0}`
+	out, _ = sjson.Set(out, "input_tokens", u.InputTokens)
+	out, _ = sjson.Set(out, "input_tokens_details.cached_tokens", u.CachedTokens)
+	if u.CacheCreationTokens > 0 {
+		out, _ = sjson.Set(out, "input_tokens_details.cache_creation_tokens", u.CacheCreationTokens)
+	}
+	out, _ = sjson.Set(out, "output_tokens", u.OutputTokens)
+	out, _ = sjson.Set(out, "output_tokens_details.reasoning_tokens", u.ReasoningTokens)
+	out, _ = sjson.Set(out, "total_tokens", u.Total())
+	return out
+}
+
+// Claude renders the usage as an Anthropic usage object, where input_tokens excludes
+// cache reads and writes.
+func (u Usage) Claude() string {
+	out := `{"input_tokens":0,"output_tokens":0,"cache_creation_input_tokens":0,"cache_read_input_tokens":0}`
+	input := u.InputTokens - u.CachedTokens - u.CacheCreationTokens
+	if input < 0 {
+		input = 0
+	}
+	out, _ = sjson.Set(out, "input_tokens", input)
+	out, _ = sjson.Set(out, "output_tokens", u.OutputTokens)
+	out, _ = sjson.Set(out, "cache_creation_input_tokens", u.CacheCreationTokens)
+	out, _ = sjson.Set(out, "cache_read_input_tokens", u.CachedTokens)
+	return out
+}
+
+// Gemini renders the usage as a Gemini usageMetadata object, where candidatesTokenCount
+// excludes thoughts.
+func (u Usage) Gemini() string {
+	out := `{"promptTokenCount":0,"candidatesTokenCount":0,"totalTokenCount":0}`
+	candidates := u.OutputTokens - u.ReasoningTokens
+	if candidates < 0 {
+		candidates = 0
+	}
+	out, _ = sjson.Set(out, "promptTokenCount", u.InputTokens)
+	out, _ = sjson.Set(out, "candidatesTokenCount", candidates)
+	out, _ = sjson.Set(out, "totalTokenCount", u.Total())
+	if u.ReasoningTokens > 0 {
+		out, _ = sjson.Set(out, "thoughtsTokenCount", u.ReasoningTokens)
+	}
+	if u.CachedTokens > 0 {
+		out, _ = sjson.Set(out, "cachedContentTokenCount", u.CachedTokens)
+	}
+	return out
+}
+
+func firstInt(node gjson.Result, paths ...string) int64 {
+	for _, path := range paths {
+		if v := node.Get(path); v.Exists() {
+			return v.Int()
+		}
+	}
+	return 0
+}
@@ -0,0 +1,71 @@
+package translator
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestUsageNormalization(t *testing.T) {
+	cases := []struct {
+		name  string
+		usage Usage
+	}{
+		{
+			name:  "claude",
+			usage: UsageFromClaude(gjson.Parse(`{"input_tokens":10,"cache_read_input_tokens":80,"cache_creation_input_tokens":10,"output_tokens":20}`)),
+		},
+		{
+			name:  "openai chat",
+			usage: UsageFromOpenAI(gjson.Parse(`{"prompt_tokens":100,"completion_tokens":20,"total_tokens":120,"prompt_tokens_details":{"cached_tokens":80,"cache_creation_tokens":10}}`)),
+		},
+		{
+			name:  "openai responses",
+			usage: UsageFromOpenAI(gjson.Parse(`{"input_tokens":100,"input_tokens_details":{"cached_tokens":80,"cache_creation_tokens":10},"output_tokens":20,"total_tokens":120}`)),
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			chat := gjson.Parse(tc.usage.OpenAIChat())
+			if chat.Get("prompt_tokens").Int() != 100 || chat.Get("completion_tokens").Int() != 20 || chat.Get("total_tokens").Int() != 120 {
+				t.Fatalf("chat usage = %s", chat.Raw)
+			}
+			if chat.Get("prompt_tokens_details.cached_tokens").Int() != 80 || !chat.Get("completion_tokens_details.reasoning_tokens").Exists() {
+				t.Fatalf("chat usage details = %s", chat.Raw)
+			}
+			claude := gjson.Parse(tc.usage.Claude())
+			if claude.Get("input_tokens").Int() != 10 || claude.Get("cache_read_input_tokens").Int() != 80 || claude.Get("cache_creation_input_tokens").Int() != 10 {
+				t.Fatalf("claude usage = %s", claude.Raw)
+			}
+		})
+	}
+}
+
+func TestUsageFromGeminiThoughts(t *testing.T) {
+	u := UsageFromGemini(gjson.Parse(`{"promptTokenCount":50,"candidatesTokenCount":10,"thoughtsTokenCount":30,"cachedContentTokenCount":20,"totalTokenCount":90}`))
+
+	responses := gjson.Parse(u.OpenAIResponses())
+	if got := responses.Get("output_tokens").Int(); got != 40 {
+		t.Fatalf("output_tokens = %d, want 40", got)
+	}
+	if got := responses.Get("output_tokens_details.reasoning_tokens").Int(); got != 30 {
+		t.Fatalf("reasoning_tokens = %d, want 30", got)
+	}
+	if got := responses.Get("input_tokens").Int(); got != 50 {
+		t.Fatalf("input_tokens = %d, want 50", got)
+	}
+
+	gemini := gjson.Parse(u.Gemini())
+	if gemini.Get("candidatesTokenCount").Int() != 10 || gemini.Get("thoughtsTokenCount").Int() != 30 || gemini.Get("totalTokenCount").Int() != 90 {
+		t.Fatalf("gemini usage = %s", gemini.Raw)
+	}
+}
+
+func TestUsageMergeClaudeKeepsCacheCounts(t *testing.T) {
+	var u Usage
+	u.MergeClaude(gjson.Parse(`{"input_tokens":10,"cache_read_input_tokens":80,"output_tokens":1}`))
+	u.MergeClaude(gjson.Parse(`{"output_tokens":25}`))
+	if u.InputTokens != 90 || u.CachedTokens != 80 || u.OutputTokens != 25 {
+		t.Fatalf("usage = %+v", u)
+	}
+}
@@ -0,0 +1,178 @@
+package usage
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultAutoSaveMaxRecords là số record mới tối đa trước khi ép save.
+	DefaultAutoSaveMaxRecords = 100
+	// DefaultAutoSaveInterval là khoảng thời gian tối đa giữa hai lần save khi có thay đổi.
+	DefaultAutoSaveInterval = time.Minute
+	// autoSaveCoalesceDelay gom các trigger liên tiếp thành một lần ghi file.
+	autoSaveCoalesceDelay = 2 * time.Second
+)
+
+// AutoSavePolicy điều khiển khi nào statistics được ghi xuống đĩa:
+// save khi có MaxRecords record mới HOẶC sau Interval, tùy cái nào tới trước.
+type AutoSavePolicy struct {
+	MaxRecords int
+	Interval   time.Duration
+}
+
+func (p AutoSavePolicy) normalized() AutoSavePolicy {
+	if p.MaxRecords <= 0 {
+		p.MaxRecords = DefaultAutoSaveMaxRecords
+	}
+	if p.Interval <= 0 {
+		p.Interval = DefaultAutoSaveInterval
+	}
+	return p
+}
+
+// PersistenceStatus mô tả trạng thái persist của một store, dùng cho management API.
+type PersistenceStatus struct {
+	Name        string     `json:"name"`
+	Path        string     `json:"path"`
+	Running     bool       `json:"running"`
+	Healthy     bool       `json:"healthy"`
+	Pending     int64      `json:"pending"`
+	Saves       int64      `json:"saves"`
+	LastSave    *time.Time `json:"last_save,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// persister theo dõi số thay đổi chưa được ghi và thực hiện save có coalescing.
+type persister struct {
+	name    string
+	path    func() string
+	save    func() error
+	pending atomic.Int64
+	limit   atomic.Int64
+	running atomic.Int32 // số goroutine auto-save đang chạy
+	trigger chan struct{}
+
+	mu          sync.Mutex
+	saveMu      sync.Mutex
+	saves       int64
+	lastSave    time.Time
+	lastError   string
+	lastErrorAt time.Time
+}
+
+func newPersister(name string, path func() string, save func() error) *persister {
+	p := &persister{name: name, path: path, save: save, trigger: make(chan struct{}, 1)}
+	p.limit.Store(DefaultAutoSaveMaxRecords)
+	return p
+}
+
+var (
+	statsPersister     = newPersister("usage-statistics", GetStatsFilePath, func() error { return defaultRequestStatistics.Save() })
+	rateLimitPersister = newPersister("ratelimit", GetRateLimitFilePath, func() error { return defaultRateLimitStore.Save() })
+)
+
+// markDirty ghi nhận một thay đổi; khi đủ ngưỡng record thì báo goroutine save.
+func (p *persister) markDirty() {
+	n := p.pending.Add(1)
+	if limit := p.limit.Load(); limit > 0 && n >= limit {
+		select {
+		case p.trigger <- struct{}{}:
+		default:
+			// Đã có trigger đang chờ, gom chung
+		}
+	}
+}
+
+// flush ghi store xuống đĩa và cập nhật trạng thái.
+func (p *persister) flush() error {
+	p.saveMu.Lock()
+	defer p.saveMu.Unlock()
+
+	n := p.pending.Swap(0)
+	err := p.save()
+	now := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err != nil {
+		// Giữ lại số thay đổi để lần sau thử lại
+		p.pending.Add(n)
+		p.lastError = err.Error()
+		p.lastErrorAt = now
+		return err
+	}
+	p.saves++
+	p.lastSave = now
+	return nil
+}
+
+func (p *persister) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if p.pending.Load() > 0 {
+				_ = p.flush()
+			}
+		case <-p.trigger:
+			// Chờ thêm một chút để gom các record đến liền nhau
+			timer := time.NewTimer(autoSaveCoalesceDelay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			select {
+			case <-p.trigger:
+			default:
+			}
+			if p.pending.Load() > 0 {
+				_ = p.flush()
+				ticker.Reset(interval)
+			}
+		}
+	}
+}
+
+func (p *persister) status() PersistenceStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := PersistenceStatus{
+		Name:      p.name,
+		Path:      p.path(),
+		Running:   p.running.Load() > 0,
+		Pending:   p.pending.Load(),
+		Saves:     p.saves,
+		LastError: p.lastError,
+	}
+	if !p.lastSave.IsZero() {
+		t := p.lastSave
+		st.LastSave = &t
+	}
+	if !p.lastErrorAt.IsZero() {
+		t := p.lastErrorAt
+		st.LastErrorAt = &t
+	}
+	// Lỗi gần nhất xảy ra sau lần save thành công cuối => volume có vấn đề
+	st.Healthy = p.lastErrorAt.IsZero() || p.lastSave.After(p.lastErrorAt)
+	return st
+}
+
+// persisters trả về persister của các store dùng chung.
+func persisters() []*persister {
+	return []*persister{statsPersister, rateLimitPersister}
+}
+
+// PersistenceStatuses trả về trạng thái persist của các store.
+func PersistenceStatuses() []PersistenceStatus {
+	return []PersistenceStatus{statsPersister.status(), rateLimitPersister.status()}
+}
@@ -0,0 +1,144 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestPersisterSavesAfterMaxRecords(t *testing.T) {
+	var saves atomic.Int32
+	p := newPersister("test", func() string { return "test.json" }, func() error {
+		saves.Add(1)
+		return nil
+	})
+	p.limit.Store(3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.run(ctx, time.Hour)
+
+	for i := 0; i < 5; i++ {
+		p.markDirty()
+	}
+
+	deadline := time.Now().Add(autoSaveCoalesceDelay + 2*time.Second)
+	for saves.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if got := saves.Load(); got != 1 {
+		t.Fatalf("saves = %d, want 1 coalesced save", got)
+	}
+	st := p.status()
+	if st.Pending != 0 || st.LastSave == nil || !st.Healthy {
+		t.Fatalf("unexpected status after save: %+v", st)
+	}
+}
+
+func TestPersisterFlushErrorKeepsPending(t *testing.T) {
+	fail := true
+	p := newPersister("test", func() string { return "" }, func() error {
+		if fail {
+			return errors.New("read-only file system")
+		}
+		return nil
+	})
+	p.markDirty()
+	p.markDirty()
+
+	if err := p.flush(); err == nil {
+		t.Fatal("expected flush error")
+	}
+	st := p.status()
+	if st.Pending != 2 || st.Healthy || st.LastError == "" {
+		t.Fatalf("unexpected status after failed save: %+v", st)
+	}
+
+	fail = false
+	if err := p.flush(); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	st = p.status()
+	if st.Pending != 0 || !st.Healthy || st.Saves != 1 {
+		t.Fatalf("unexpected status after recovery: %+v", st)
+	}
+}
+
+func TestPersisterSerializesSaves(t *testing.T) {
+	var active, overlaps atomic.Int32
+	p := newPersister("test", func() string { return "test.json" }, func() error {
+		if active.Add(1) > 1 {
+			overlaps.Add(1)
+		}
+		time.Sleep(time.Millisecond)
+		active.Add(-1)
+		return nil
+	})
+	p.limit.Store(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go p.run(ctx, 5*time.Millisecond)
+
+	done := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for j := 0; j < 20; j++ {
+				p.markDirty()
+				_ = p.flush()
+			}
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		<-done
+	}
+	if n := overlaps.Load(); n != 0 {
+		t.Fatalf("%d saves overlapped another save", n)
+	}
+}
+
+func TestLifecyclesCoexist(t *testing.T) {
+	first, second, conflicting := NewLifecycle(), NewLifecycle(), NewLifecycle()
+	if err := first.Start(context.Background(), AutoSavePolicy{}); err != nil {
+		t.Fatalf("start first: %v", err)
+	}
+	if err := second.Start(context.Background(), AutoSavePolicy{Interval: DefaultAutoSaveInterval}); err != nil {
+		t.Fatalf("start second with the same policy: %v", err)
+	}
+	if err := conflicting.Start(context.Background(), AutoSavePolicy{MaxRecords: 5}); err == nil {
+		t.Fatal("a lifecycle with another policy started alongside the running ones")
+	}
+	if got := statsPersister.running.Load(); got != 1 {
+		t.Fatalf("%d stats writers running, want 1", got)
+	}
+	if got := statsPersister.limit.Load(); got != DefaultAutoSaveMaxRecords {
+		t.Fatalf("record limit = %d, want %d", got, DefaultAutoSaveMaxRecords)
+	}
+
+	saves := statsPersister.status().Saves
+	first.Stop()
+	if st := statsPersister.status(); !st.Running || st.Saves != saves+1 {
+		t.Fatalf("after stopping one lifecycle: %+v, want running with a final save", st)
+	}
+	second.Stop()
+	if st := statsPersister.status(); st.Running {
+		t.Fatalf("after stopping both lifecycles: %+v, want stopped", st)
+	}
+
+	// A lifecycle whose context ends gives up its claim too.
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := first.Start(ctx, AutoSavePolicy{}); err != nil {
+		t.Fatalf("restart first: %v", err)
+	}
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for statsPersister.status().Running {
+		if time.Now().After(deadline) {
+			t.Fatal("background work still running after the owner's context ended")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,183 @@
+package usage
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+const (
+	// errorRateAlpha is the weight of the newest attempt in the error-rate EWMA.
+	errorRateAlpha = 0.2
+	// defaultErrorRateHalfLife is how long an idle credential takes to halve its error rate.
+	defaultErrorRateHalfLife = 5 * time.Minute
+)
+
+// ErrorRateSummary reports request outcomes and the decayed error rate of one credential.
+type ErrorRateSummary struct {
+	AuthID    string           `json:"auth_id"`
+	Source    string           `json:"source"`
+	Provider  string           `json:"provider"`
+	Requests  int64            `json:"requests"`
+	Failures  int64            `json:"failures"`
+	Classes   map[string]int64 `json:"classes,omitempty"`
+	ErrorRate float64          `json:"error_rate"`
+	LastError *time.Time       `json:"last_error,omitempty"`
+}
+
+type errorSeries struct {
+	source    string
+	provider  string
+	requests  int64
+	failures  int64
+	classes   map[string]int64
+	rate      float64
+	updated   time.Time
+	lastError time.Time
+}
+
+// ErrorRateStore tracks upstream error classes per credential together with an
+// exponentially weighted error rate. The rate also decays towards zero while a
+// credential receives no traffic, so a deprioritised credential recovers over time.
+type ErrorRateStore struct {
+	mu       sync.RWMutex
+	halfLife time.Duration
+	series   map[string]*errorSeries
+	now      func() time.Time
+}
+
+var defaultErrorRateStore = NewErrorRateStore()
+
+// GetErrorRateStore returns the shared error-rate store.
+func GetErrorRateStore() *ErrorRateStore { return defaultErrorRateStore }
+
+// NewErrorRateStore creates an empty error-rate store.
+func NewErrorRateStore() *ErrorRateStore {
+	return &ErrorRateStore{
+		halfLife: defaultErrorRateHalfLife,
+		series:   make(map[string]*errorSeries),
+		now:      time.Now,
+	}
+}
+
+// SetHalfLife sets the idle decay half-life. Non-positive values restore the default.
+func (s *ErrorRateStore) SetHalfLife(halfLife time.Duration) {
+	if s == nil {
+		return
+	}
+	if halfLife <= 0 {
+		halfLife = defaultErrorRateHalfLife
+	}
+	s.mu.Lock()
+	s.halfLife = halfLife
+	s.mu.Unlock()
+}
+
+// Record adds the outcome of one upstream attempt. Invalid-request failures are
+// counted by class but do not affect the error rate, since they are the client's fault.
+func (s *ErrorRateStore) Record(record coreusage.LatencyRecord) {
+	if s == nil || record.AuthID == "" {
+		return
+	}
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	series, ok := s.series[record.AuthID]
+	if !ok {
+		series = &errorSeries{classes: make(map[string]int64), updated: now}
+		s.series[record.AuthID] = series
+	}
+	series.source = record.Source
+	series.provider = record.Provider
+	series.requests++
+	sample := 0.0
+	if record.Failed {
+		class := record.ErrorClass
+		if class == "" {
+			class = coreusage.ErrorClassOther
+		}
+		series.failures++
+		series.classes[class]++
+		series.lastError = now
+		if class == coreusage.ErrorClassInvalidRequest {
+			return
+		}
+		sample = 1
+	}
+	decayed := s.decayedLocked(series, now)
+	series.rate = decayed + errorRateAlpha*(sample-decayed)
+	series.updated = now
+}
+
+// ErrorRate returns the decayed error rate (0..1) of a credential, or 0 when unknown.
+func (s *ErrorRateStore) ErrorRate(authID string) float64 {
+	if s == nil {
+		return 0
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	series, ok := s.series[authID]
+	if !ok {
+		return 0
+	}
+	return s.decayedLocked(series, s.now())
+}
+
+func (s *ErrorRateStore) decayedLocked(series *errorSeries, now time.Time) float64 {
+	elapsed := now.Sub(series.updated)
+	if elapsed <= 0 || series.rate == 0 {
+		return series.rate
+	}
+	return series.rate * math.Exp2(-float64(elapsed)/float64(s.halfLife))
+}
+
+// Summaries returns every tracked credential sorted by descending error rate, then ID.
+func (s *ErrorRateStore) Summaries() []ErrorRateSummary {
+	if s == nil {
+		return nil
+	}
+	now := s.now()
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]ErrorRateSummary, 0, len(s.series))
+	for authID, series := range s.series {
+		classes := make(map[string]int64, len(series.classes))
+		for class, count := range series.classes {
+			classes[class] = count
+		}
+		summary := ErrorRateSummary{
+			AuthID:    authID,
+			Source:    series.source,
+			Provider:  series.provider,
+			Requests:  series.requests,
+			Failures:  series.failures,
+			Classes:   classes,
+			ErrorRate: math.Round(s.decayedLocked(series, now)*1000) / 1000,
+		}
+		if !series.lastError.IsZero() {
+			lastError := series.lastError
+			summary.LastError = &lastError
+		}
+		out = append(out, summary)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].ErrorRate != out[j].ErrorRate {
+			return out[i].ErrorRate > out[j].ErrorRate
+		}
+		return out[i].AuthID < out[j].AuthID
+	})
+	return out
+}
+
+// Reset discards all recorded outcomes.
+func (s *ErrorRateStore) Reset() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.series = make(map[string]*errorSeries)
+	s.mu.Unlock()
+}
@@ -0,0 +1,44 @@
+package usage
+
+import (
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestErrorRateStoreEWMAAndDecay(t *testing.T) {
+	store := NewErrorRateStore()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	for i := 0; i < 10; i++ {
+		store.Record(coreusage.LatencyRecord{AuthID: "bad", Source: "a@example.com", Failed: true, StatusCode: 500, ErrorClass: coreusage.ErrorClassServer})
+		store.Record(coreusage.LatencyRecord{AuthID: "good", Source: "b@example.com"})
+	}
+	store.Record(coreusage.LatencyRecord{AuthID: "good", Failed: true, ErrorClass: coreusage.ErrorClassInvalidRequest})
+
+	if rate := store.ErrorRate("bad"); rate < 0.85 {
+		t.Fatalf("bad error rate = %v, want >= 0.85", rate)
+	}
+	if rate := store.ErrorRate("good"); rate != 0 {
+		t.Fatalf("good error rate = %v, want 0 (invalid requests are not counted)", rate)
+	}
+
+	summaries := store.Summaries()
+	if len(summaries) != 2 || summaries[0].AuthID != "bad" {
+		t.Fatalf("unexpected summaries: %+v", summaries)
+	}
+	if summaries[0].Classes[coreusage.ErrorClassServer] != 10 || summaries[0].Failures != 10 {
+		t.Fatalf("unexpected classes: %+v", summaries[0])
+	}
+	if summaries[1].Classes[coreusage.ErrorClassInvalidRequest] != 1 {
+		t.Fatalf("invalid request not counted by class: %+v", summaries[1])
+	}
+
+	before := store.ErrorRate("bad")
+	now = now.Add(defaultErrorRateHalfLife)
+	if after := store.ErrorRate("bad"); after < before/2-0.001 || after > before/2+0.001 {
+		t.Fatalf("decayed rate = %v, want half of %v", after, before)
+	}
+}
@@ -0,0 +1,403 @@
+package usage
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultExportTable         = "cliproxy_usage_events"
+	defaultExportBatchSize     = 500
+	defaultExportFlushInterval = 10 * time.Second
+	// exportBufferBatches bounds the buffer to this many batches while the sink is down.
+	exportBufferBatches = 20
+	exportTimeout       = 30 * time.Second
+)
+
+// Usage event kinds. A "usage" event carries the token counts of a request; an "attempt"
+// event carries the timing and status of one upstream attempt.
+const (
+	UsageEventKindUsage   = "usage"
+	UsageEventKindAttempt = "attempt"
+)
+
+var exportTablePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// UsageEvent is one exported row.
+type UsageEvent struct {
+	Time            time.Time `json:"time"`
+	Kind            string    `json:"kind"`
+	Provider        string    `json:"provider"`
+	Model           string    `json:"model"`
+	Source          string    `json:"source"`
+	AuthID          string    `json:"auth_id"`
+	InputTokens     int64     `json:"input_tokens"`
+	OutputTokens    int64     `json:"output_tokens"`
+	ReasoningTokens int64     `json:"reasoning_tokens"`
+	CachedTokens    int64     `json:"cached_tokens"`
+	TotalTokens     int64     `json:"total_tokens"`
+	Stream          bool      `json:"stream"`
+	Failed          bool      `json:"failed"`
+	StatusCode      int       `json:"status_code"`
+	ErrorClass      string    `json:"error_class"`
+	FirstByteMs     int64     `json:"first_byte_ms"`
+	TotalMs         int64     `json:"total_ms"`
+}
+
+// usageEventSink writes a batch of events.
+type usageEventSink interface {
+	insert(ctx context.Context, events []UsageEvent) error
+	close()
+}
+
+// UsageExporter buffers usage and latency records and writes them to the configured sink
+// in batches. It is registered as a usage plugin at startup and stays idle until
+// ConfigureUsageExporter enables it.
+type UsageExporter struct {
+	mu        sync.Mutex
+	current   config.UsageExportConfig
+	sink      usageEventSink
+	buffer    []UsageEvent
+	batchSize int
+	interval  time.Duration
+	dropped   int64
+	cancel    context.CancelFunc
+	wake      chan struct{}
+}
+
+var defaultUsageExporter = &UsageExporter{}
+
+func init() {
+	coreusage.RegisterPlugin(defaultUsageExporter)
+}
+
+// ConfigureUsageExporter applies cfg.UsageExport, flushing and replacing any previous sink.
+func ConfigureUsageExporter(cfg *config.Config) error {
+	var exportCfg config.UsageExportConfig
+	if cfg != nil {
+		exportCfg = cfg.UsageExport
+	}
+	return defaultUsageExporter.configure(exportCfg)
+}
+
+// StopUsageExporter flushes buffered events and disables the exporter.
+func StopUsageExporter() {
+	defaultUsageExporter.stop()
+}
+
+func (e *UsageExporter) configure(cfg config.UsageExportConfig) error {
+	e.mu.Lock()
+	unchanged := e.sink != nil && reflect.DeepEqual(e.current, cfg)
+	e.mu.Unlock()
+	if unchanged {
+		return nil
+	}
+	e.stop()
+	kind := strings.ToLower(strings.TrimSpace(cfg.Type))
+	if kind == "" {
+		return nil
+	}
+	table := strings.TrimSpace(cfg.Table)
+	if table == "" {
+		table = defaultExportTable
+	}
+	if !exportTablePattern.MatchString(table) {
+		return fmt.Errorf("usage export: invalid table name %q", table)
+	}
+	var sink usageEventSink
+	var err error
+	switch kind {
+	case "clickhouse":
+		sink, err = newClickHouseSink(cfg, table)
+	case "timescale", "timescaledb", "postgres":
+		sink, err = newTimescaleSink(cfg, table)
+	default:
+		return fmt.Errorf("usage export: unsupported type %q", cfg.Type)
+	}
+	if err != nil {
+		return err
+	}
+
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultExportBatchSize
+	}
+	interval := time.Duration(cfg.FlushIntervalSeconds) * time.Second
+	if interval <= 0 {
+		interval = defaultExportFlushInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	e.mu.Lock()
+	e.current = cfg
+	e.sink = sink
+	e.batchSize = batchSize
+	e.interval = interval
+	e.cancel = cancel
+	e.wake = make(chan struct{}, 1)
+	wake := e.wake
+	e.mu.Unlock()
+	go e.run(ctx, sink, wake)
+	return nil
+}
+
+// stop flushes the buffered events to the current sink and closes it.
+func (e *UsageExporter) stop() {
+	e.mu.Lock()
+	sink, cancel := e.sink, e.cancel
+	e.sink, e.cancel = nil, nil
+	e.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	if sink != nil {
+		e.flush(context.Background(), sink)
+		sink.close()
+	}
+}
+
+// HandleUsage implements coreusage.Plugin.
+func (e *UsageExporter) HandleUsage(_ context.Context, record coreusage.Record) {
+	timestamp := record.RequestedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	e.enqueue(UsageEvent{
+		Time:            timestamp.UTC(),
+		Kind:            UsageEventKindUsage,
+		Provider:        record.Provider,
+		Model:           record.Model,
+		Source:          record.Source,
+		AuthID:          record.AuthID,
+		InputTokens:     record.Detail.InputTokens,
+		OutputTokens:    record.Detail.OutputTokens,
+		ReasoningTokens: record.Detail.ReasoningTokens,
+		CachedTokens:    record.Detail.CachedTokens,
+		TotalTokens:     record.Detail.TotalTokens,
+		Failed:          record.Failed,
+	})
+}
+
+// HandleLatency implements coreusage.LatencyPlugin.
+func (e *UsageExporter) HandleLatency(_ context.Context, record coreusage.LatencyRecord) {
+	timestamp := record.RequestedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	e.enqueue(UsageEvent{
+		Time:        timestamp.UTC(),
+		Kind:        UsageEventKindAttempt,
+		Provider:    record.Provider,
+		Model:       record.Model,
+		Source:      record.Source,
+		AuthID:      record.AuthID,
+		Stream:      record.Stream,
+		Failed:      record.Failed,
+		StatusCode:  record.StatusCode,
+		ErrorClass:  record.ErrorClass,
+		FirstByteMs: record.FirstByte.Milliseconds(),
+		TotalMs:     record.Total.Milliseconds(),
+	})
+}
+
+func (e *UsageExporter) enqueue(event UsageEvent) {
+	e.mu.Lock()
+	if e.sink == nil {
+		e.mu.Unlock()
+		return
+	}
+	if len(e.buffer) >= e.batchSize*exportBufferBatches {
+		if e.dropped == 0 {
+			log.Warn("usage export: buffer full, dropping events until the sink recovers")
+		}
+		e.dropped++
+		e.mu.Unlock()
+		return
+	}
+	e.buffer = append(e.buffer, event)
+	full := len(e.buffer) >= e.batchSize
+	wake := e.wake
+	e.mu.Unlock()
+	if full {
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (e *UsageExporter) run(ctx context.Context, sink usageEventSink, wake <-chan struct{}) {
+	e.mu.Lock()
+	ticker := time.NewTicker(e.interval)
+	e.mu.Unlock()
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+		e.flush(ctx, sink)
+	}
+}
+
+// flush writes buffered events in batches. A failed batch is put back at the front of
+// the buffer and retried on the next flush.
+func (e *UsageExporter) flush(ctx context.Context, sink usageEventSink) {
+	for {
+		e.mu.Lock()
+		if len(e.buffer) == 0 {
+			e.mu.Unlock()
+			return
+		}
+		n := min(len(e.buffer), max(e.batchSize, 1))
+		batch := append([]UsageEvent(nil), e.buffer[:n]...)
+		e.buffer = e.buffer[n:]
+		e.mu.Unlock()
+
+		insertCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), exportTimeout)
+		err := sink.insert(insertCtx, batch)
+		cancel()
+		if err != nil {
+			log.Warnf("usage export: insert of %d events failed: %v", len(batch), err)
+			e.mu.Lock()
+			e.buffer = append(batch, e.buffer...)
+			e.mu.Unlock()
+			return
+		}
+		e.mu.Lock()
+		if e.dropped > 0 {
+			log.Warnf("usage export: sink recovered, %d events were dropped", e.dropped)
+			e.dropped = 0
+		}
+		e.mu.Unlock()
+	}
+}
+
+// clickHouseSink inserts through the ClickHouse HTTP interface using JSONEachRow.
+type clickHouseSink struct {
+	endpoint string
+	username string
+	password string
+	client   *http.Client
+}
+
+func newClickHouseSink(cfg config.UsageExportConfig, table string) (*clickHouseSink, error) {
+	endpoint := strings.TrimSpace(cfg.URL)
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" {
+		return nil, fmt.Errorf("usage export: invalid clickhouse url %q", cfg.URL)
+	}
+	query := parsed.Query()
+	query.Set("query", fmt.Sprintf("INSERT INTO %s FORMAT JSONEachRow", table))
+	query.Set("date_time_input_format", "best_effort")
+	parsed.RawQuery = query.Encode()
+	return &clickHouseSink{
+		endpoint: parsed.String(),
+		username: cfg.Username,
+		password: cfg.Password,
+		client:   &http.Client{Timeout: exportTimeout},
+	}, nil
+}
+
+func (s *clickHouseSink) insert(ctx context.Context, events []UsageEvent) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, &body)
+	if err != nil {
+		return err
+	}
+	if s.username != "" {
+		req.Header.Set("X-ClickHouse-User", s.username)
+		req.Header.Set("X-ClickHouse-Key", s.password)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("clickhouse returned %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+func (s *clickHouseSink) close() {}
+
+// timescaleSink inserts with multi-row INSERT statements over the pgx driver.
+type timescaleSink struct {
+	db    *sql.DB
+	table string
+}
+
+// timescaleColumns lists the columns written, in UsageEvent field order.
+const timescaleColumns = "time, kind, provider, model, source, auth_id, input_tokens, output_tokens, reasoning_tokens, cached_tokens, total_tokens, stream, failed, status_code, error_class, first_byte_ms, total_ms"
+
+func newTimescaleSink(cfg config.UsageExportConfig, table string) (*timescaleSink, error) {
+	dsn := strings.TrimSpace(cfg.URL)
+	if dsn == "" {
+		return nil, fmt.Errorf("usage export: timescale url is required")
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("usage export: open timescale: %w", err)
+	}
+	return &timescaleSink{db: db, table: table}, nil
+}
+
+func (s *timescaleSink) insert(ctx context.Context, events []UsageEvent) error {
+	const columns = 17
+	// PostgreSQL accepts at most 65535 bind parameters per statement.
+	const maxRows = 65535 / columns
+	for len(events) > maxRows {
+		if err := s.insert(ctx, events[:maxRows]); err != nil {
+			return err
+		}
+		events = events[maxRows:]
+	}
+	var query strings.Builder
+	fmt.Fprintf(&query, "INSERT INTO %s (%s) VALUES ", s.table, timescaleColumns)
+	args := make([]any, 0, len(events)*columns)
+	for i, event := range events {
+		if i > 0 {
+			query.WriteString(", ")
+		}
+		query.WriteByte('(')
+		for c := 0; c < columns; c++ {
+			if c > 0 {
+				query.WriteString(", ")
+			}
+			fmt.Fprintf(&query, "$%d", i*columns+c+1)
+		}
+		query.WriteByte(')')
+		args = append(args, event.Time, event.Kind, event.Provider, event.Model, event.Source, event.AuthID,
+			event.InputTokens, event.OutputTokens, event.ReasoningTokens, event.CachedTokens, event.TotalTokens,
+			event.Stream, event.Failed, event.StatusCode, event.ErrorClass, event.FirstByteMs, event.TotalMs)
+	}
+	_, err := s.db.ExecContext(ctx, query.String(), args...)
+	return err
+}
+
+func (s *timescaleSink) close() { _ = s.db.Close() }
@@ -0,0 +1,97 @@
+package usage
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestUsageExporterBatchesToClickHouse(t *testing.T) {
+	var mu sync.Mutex
+	var rows []UsageEvent
+	var query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		query = r.URL.Query().Get("query")
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var row UsageEvent
+			if err := json.Unmarshal(scanner.Bytes(), &row); err != nil {
+				t.Errorf("decode row: %v", err)
+			}
+			rows = append(rows, row)
+		}
+	}))
+	defer server.Close()
+
+	exporter := &UsageExporter{}
+	if err := exporter.configure(config.UsageExportConfig{Type: "clickhouse", URL: server.URL, BatchSize: 2, FlushIntervalSeconds: 60}); err != nil {
+		t.Fatalf("configure: %v", err)
+	}
+	exporter.HandleUsage(context.Background(), coreusage.Record{Provider: "claude", Model: "m", Detail: coreusage.Detail{InputTokens: 3, OutputTokens: 4}})
+	exporter.HandleLatency(context.Background(), coreusage.LatencyRecord{Provider: "claude", Model: "m", Total: 1500 * time.Millisecond})
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		n := len(rows)
+		mu.Unlock()
+		if n == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	exporter.stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(rows) != 2 {
+		t.Fatalf("rows = %d, want 2", len(rows))
+	}
+	if query != "INSERT INTO cliproxy_usage_events FORMAT JSONEachRow" {
+		t.Errorf("query = %q", query)
+	}
+	if rows[0].Kind != UsageEventKindUsage || rows[0].InputTokens != 3 {
+		t.Errorf("usage row = %+v", rows[0])
+	}
+	if rows[1].Kind != UsageEventKindAttempt || rows[1].TotalMs != 1500 {
+		t.Errorf("attempt row = %+v", rows[1])
+	}
+}
+
+func TestUsageExporterKeepsFailedBatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	exporter := &UsageExporter{}
+	if err := exporter.configure(config.UsageExportConfig{Type: "clickhouse", URL: server.URL, BatchSize: 10, FlushIntervalSeconds: 60}); err != nil {
+		t.Fatalf("configure: %v", err)
+	}
+	defer exporter.stop()
+	exporter.HandleUsage(context.Background(), coreusage.Record{Model: "m"})
+	exporter.flush(context.Background(), exporter.sink)
+
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+	if len(exporter.buffer) != 1 {
+		t.Fatalf("buffer = %d, want the failed event kept", len(exporter.buffer))
+	}
+}
+
+func TestUsageExporterRejectsInvalidTable(t *testing.T) {
+	exporter := &UsageExporter{}
+	if err := exporter.configure(config.UsageExportConfig{Type: "clickhouse", URL: "http://localhost:8123", Table: "x; DROP TABLE y"}); err == nil {
+		t.Fatal("expected invalid table error")
+	}
+}
@@ -0,0 +1,104 @@
+package usage
+
+import "time"
+
+// ExhaustionForecast chứa thời điểm dự kiến 1 source chạm 100% utilization.
+// Nil nghĩa là chưa đủ dữ liệu, utilization không tăng, hoặc window reset trước khi chạm limit.
+type ExhaustionForecast struct {
+	Window5h *time.Time `json:"5h"`
+	Window7d *time.Time `json:"7d"`
+}
+
+// minForecastSamples là số record tối thiểu để fit đường thẳng.
+const minForecastSamples = 2
+
+// ForecastExhaustion fit linear regression utilization theo thời gian cho từng source
+// (chỉ record unified trong window hiện tại) và ngoại suy thời điểm chạm 100%.
+func (s *RateLimitStore) ForecastExhaustion(now time.Time) map[string]ExhaustionForecast {
+	forecasts := make(map[string]ExhaustionForecast)
+	if s == nil {
+		return forecasts
+	}
+
+	bySource := make(map[string][]RateLimitRecord)
+	s.viewSources(func(source string, src *sourceRateLimits) {
+		if source == "" {
+			source = "unknown"
+		}
+		for _, r := range src.records {
+			if r.Type == "unified" && !r.Timestamp.After(now) {
+				bySource[source] = append(bySource[source], r)
+			}
+		}
+	})
+
+	for source, records := range bySource {
+		forecasts[source] = ExhaustionForecast{
+			Window5h: forecastWindow(records, now, 5*time.Hour,
+				func(r RateLimitRecord) float64 { return r.Utilization5h },
+				func(r RateLimitRecord) time.Time { return r.Reset5h }),
+			Window7d: forecastWindow(records, now, 7*24*time.Hour,
+				func(r RateLimitRecord) float64 { return r.Utilization7d },
+				func(r RateLimitRecord) time.Time { return r.Reset7d }),
+		}
+	}
+	return forecasts
+}
+
+// forecastWindow dự báo cho 1 window. Chỉ dùng record cùng kỳ reset với record mới nhất,
+// vì utilization về 0 sau mỗi lần reset.
+func forecastWindow(records []RateLimitRecord, now time.Time, window time.Duration, utilization func(RateLimitRecord) float64, reset func(RateLimitRecord) time.Time) *time.Time {
+	latest := records[0]
+	for _, r := range records[1:] {
+		if r.Timestamp.After(latest.Timestamp) {
+			latest = r
+		}
+	}
+	latestReset := reset(latest)
+	if utilization(latest) >= 1 {
+		t := latest.Timestamp
+		return &t
+	}
+
+	cutoff := now.Add(-window)
+	var n, sumX, sumY, sumXY, sumXX float64
+	for _, r := range records {
+		if r.Timestamp.Before(cutoff) {
+			continue
+		}
+		if !latestReset.IsZero() && !sameReset(reset(r), latestReset) {
+			continue
+		}
+		x := r.Timestamp.Sub(latest.Timestamp).Seconds()
+		y := utilization(r)
+		n++
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	if n < minForecastSamples {
+		return nil
+	}
+	denom := n*sumXX - sumX*sumX
+	if denom == 0 {
+		return nil
+	}
+	slope := (n*sumXY - sumX*sumY) / denom // utilization mỗi giây
+	if slope <= 0 {
+		return nil
+	}
+
+	seconds := (1 - utilization(latest)) / slope
+	at := latest.Timestamp.Add(time.Duration(seconds * float64(time.Second)))
+	if !latestReset.IsZero() && !at.Before(latestReset) {
+		return nil
+	}
+	return &at
+}
+
+// sameReset so sánh thời điểm reset, chấp nhận lệch nhỏ giữa các response.
+func sameReset(a, b time.Time) bool {
+	d := a.Sub(b)
+	return d > -time.Minute && d < time.Minute
+}
@@ -0,0 +1,53 @@
+package usage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestForecastExhaustion(t *testing.T) {
+	store := NewRateLimitStore()
+	now := time.Now()
+	reset := now.Add(4 * time.Hour)
+	for i, util := range []float64{0.2, 0.3, 0.4} {
+		store.Record(RateLimitRecord{
+			Timestamp:     now.Add(time.Duration(i-2) * 10 * time.Minute),
+			Source:        "a@example.com",
+			Type:          "unified",
+			Status5h:      "allowed",
+			Utilization5h: util,
+			Reset5h:       reset,
+		})
+	}
+	// Utilization giảm: không dự báo.
+	store.Record(RateLimitRecord{Timestamp: now.Add(-time.Minute), Source: "b@example.com", Type: "unified", Status5h: "allowed", Utilization5h: 0.5, Reset5h: reset})
+	store.Record(RateLimitRecord{Timestamp: now, Source: "b@example.com", Type: "unified", Status5h: "allowed", Utilization5h: 0.4, Reset5h: reset})
+
+	forecasts := store.ForecastExhaustion(now)
+	a := forecasts["a@example.com"]
+	if a.Window5h == nil {
+		t.Fatalf("expected 5h forecast for a")
+	}
+	// +10% mỗi 10 phút từ 40% => chạm 100% sau 60 phút.
+	if d := a.Window5h.Sub(now.Add(time.Hour)); d < -time.Second || d > time.Second {
+		t.Fatalf("5h exhaustion = %s, want about %s", a.Window5h, now.Add(time.Hour))
+	}
+	if a.Window7d != nil {
+		t.Fatalf("7d forecast without 7d data: %s", a.Window7d)
+	}
+	if b := forecasts["b@example.com"]; b.Window5h != nil {
+		t.Fatalf("decreasing utilization should not forecast, got %s", b.Window5h)
+	}
+}
+
+func TestForecastExhaustionAfterReset(t *testing.T) {
+	store := NewRateLimitStore()
+	now := time.Now()
+	reset := now.Add(10 * time.Minute)
+	store.Record(RateLimitRecord{Timestamp: now.Add(-10 * time.Minute), Source: "a", Type: "unified", Status5h: "allowed", Utilization5h: 0.1, Reset5h: reset})
+	store.Record(RateLimitRecord{Timestamp: now, Source: "a", Type: "unified", Status5h: "allowed", Utilization5h: 0.2, Reset5h: reset})
+
+	if got := store.ForecastExhaustion(now)["a"].Window5h; got != nil {
+		t.Fatalf("window resets before exhaustion, got %s", got)
+	}
+}
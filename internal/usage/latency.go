@@ -0,0 +1,264 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+// latencyBucketsMs are the upper bounds of the latency histogram buckets in milliseconds.
+// The final implicit bucket is +Inf.
+var latencyBucketsMs = []float64{50, 100, 250, 500, 1000, 2500, 5000, 10000, 20000, 30000, 60000, 120000, 300000}
+
+// Histogram is a latency histogram with fixed, non-cumulative buckets.
+type Histogram struct {
+	// Counts holds one count per bucket in latencyBucketsMs plus the +Inf bucket.
+	Counts []int64
+	Count  int64
+	SumMs  float64
+}
+
+func newHistogram() *Histogram {
+	return &Histogram{Counts: make([]int64, len(latencyBucketsMs)+1)}
+}
+
+func (h *Histogram) observe(d time.Duration) {
+	ms := float64(d) / float64(time.Millisecond)
+	idx := sort.SearchFloat64s(latencyBucketsMs, ms)
+	h.Counts[idx]++
+	h.Count++
+	h.SumMs += ms
+}
+
+// Quantile estimates the q-quantile (0..1) in milliseconds by linear interpolation
+// within the bucket that contains it. Values in the +Inf bucket report the last bound.
+func (h *Histogram) Quantile(q float64) float64 {
+	if h == nil || h.Count == 0 {
+		return 0
+	}
+	rank := q * float64(h.Count)
+	var cumulative int64
+	for i, c := range h.Counts {
+		if c == 0 {
+			continue
+		}
+		if float64(cumulative+c) >= rank {
+			if i >= len(latencyBucketsMs) {
+				return latencyBucketsMs[len(latencyBucketsMs)-1]
+			}
+			lower := 0.0
+			if i > 0 {
+				lower = latencyBucketsMs[i-1]
+			}
+			upper := latencyBucketsMs[i]
+			fraction := (rank - float64(cumulative)) / float64(c)
+			return lower + (upper-lower)*math.Max(0, math.Min(1, fraction))
+		}
+		cumulative += c
+	}
+	return latencyBucketsMs[len(latencyBucketsMs)-1]
+}
+
+// LatencyPercentiles summarises one histogram.
+type LatencyPercentiles struct {
+	Count int64   `json:"count"`
+	AvgMs float64 `json:"avg_ms"`
+	P50Ms float64 `json:"p50_ms"`
+	P95Ms float64 `json:"p95_ms"`
+	P99Ms float64 `json:"p99_ms"`
+}
+
+func (h *Histogram) percentiles() LatencyPercentiles {
+	if h == nil || h.Count == 0 {
+		return LatencyPercentiles{}
+	}
+	return LatencyPercentiles{
+		Count: h.Count,
+		AvgMs: math.Round(h.SumMs/float64(h.Count)*10) / 10,
+		P50Ms: math.Round(h.Quantile(0.50)*10) / 10,
+		P95Ms: math.Round(h.Quantile(0.95)*10) / 10,
+		P99Ms: math.Round(h.Quantile(0.99)*10) / 10,
+	}
+}
+
+// LatencySummary reports time-to-first-byte and total latency for one model and source.
+type LatencySummary struct {
+	Model     string             `json:"model"`
+	Source    string             `json:"source"`
+	Provider  string             `json:"provider"`
+	Failed    int64              `json:"failed"`
+	FirstByte LatencyPercentiles `json:"ttfb"`
+	Total     LatencyPercentiles `json:"total"`
+}
+
+type latencyKey struct {
+	model  string
+	source string
+}
+
+type latencySeries struct {
+	provider  string
+	failed    int64
+	firstByte *Histogram
+	total     *Histogram
+}
+
+// LatencyStore keeps latency histograms per model and source in memory.
+type LatencyStore struct {
+	mu     sync.RWMutex
+	series map[latencyKey]*latencySeries
+}
+
+var defaultLatencyStore = NewLatencyStore()
+
+// GetLatencyStore returns the shared latency store.
+func GetLatencyStore() *LatencyStore { return defaultLatencyStore }
+
+// NewLatencyStore creates an empty latency store.
+func NewLatencyStore() *LatencyStore {
+	return &LatencyStore{series: make(map[latencyKey]*latencySeries)}
+}
+
+// Record adds one latency observation.
+func (s *LatencyStore) Record(record coreusage.LatencyRecord) {
+	if s == nil || record.Total <= 0 {
+		return
+	}
+	key := latencyKey{model: record.Model, source: record.Source}
+	if key.model == "" {
+		key.model = "unknown"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	series, ok := s.series[key]
+	if !ok {
+		series = &latencySeries{firstByte: newHistogram(), total: newHistogram()}
+		s.series[key] = series
+	}
+	series.provider = record.Provider
+	if record.Failed {
+		series.failed++
+	}
+	series.firstByte.observe(record.FirstByte)
+	series.total.observe(record.Total)
+}
+
+// Summaries returns percentiles for every series matching the optional model and source
+// filters, sorted by model then source.
+func (s *LatencyStore) Summaries(model, source string) []LatencySummary {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]LatencySummary, 0, len(s.series))
+	for key, series := range s.series {
+		if model != "" && !strings.EqualFold(model, key.model) {
+			continue
+		}
+		if source != "" && source != key.source {
+			continue
+		}
+		out = append(out, LatencySummary{
+			Model:     key.model,
+			Source:    key.source,
+			Provider:  series.provider,
+			Failed:    series.failed,
+			FirstByte: series.firstByte.percentiles(),
+			Total:     series.total.percentiles(),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Model != out[j].Model {
+			return out[i].Model < out[j].Model
+		}
+		return out[i].Source < out[j].Source
+	})
+	return out
+}
+
+// Reset discards all recorded latencies.
+func (s *LatencyStore) Reset() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.series = make(map[latencyKey]*latencySeries)
+	s.mu.Unlock()
+}
+
+// WritePrometheus writes the histograms in the Prometheus text exposition format as
+// cliproxy_request_ttfb_seconds and cliproxy_request_duration_seconds.
+func (s *LatencyStore) WritePrometheus(w io.Writer) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]latencyKey, 0, len(s.series))
+	for key := range s.series {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].model != keys[j].model {
+			return keys[i].model < keys[j].model
+		}
+		return keys[i].source < keys[j].source
+	})
+	metrics := []struct {
+		name, help string
+		pick       func(*latencySeries) *Histogram
+	}{
+		{"cliproxy_request_ttfb_seconds", "Time until the first upstream response byte.", func(ls *latencySeries) *Histogram { return ls.firstByte }},
+		{"cliproxy_request_duration_seconds", "Total upstream request duration.", func(ls *latencySeries) *Histogram { return ls.total }},
+	}
+	for _, metric := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s histogram\n", metric.name, metric.help, metric.name); err != nil {
+			return err
+		}
+		for _, key := range keys {
+			series := s.series[key]
+			h := metric.pick(series)
+			labels := fmt.Sprintf(`model=%q,source=%q,provider=%q`, key.model, key.source, series.provider)
+			var cumulative int64
+			for i, bound := range latencyBucketsMs {
+				cumulative += h.Counts[i]
+				if _, err := fmt.Fprintf(w, "%s_bucket{%s,le=\"%g\"} %d\n", metric.name, labels, bound/1000, cumulative); err != nil {
+					return err
+				}
+			}
+			if _, err := fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n%s_sum{%s} %g\n%s_count{%s} %d\n",
+				metric.name, labels, h.Count, metric.name, labels, h.SumMs/1000, metric.name, labels, h.Count); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := fmt.Fprintf(w, "# HELP cliproxy_request_failures_total Failed upstream requests.\n# TYPE cliproxy_request_failures_total counter\n"); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		series := s.series[key]
+		if _, err := fmt.Fprintf(w, "cliproxy_request_failures_total{model=%q,source=%q,provider=%q} %d\n", key.model, key.source, series.provider, series.failed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandleLatency implements coreusage.LatencyPlugin.
+func (p *LoggerPlugin) HandleLatency(_ context.Context, record coreusage.LatencyRecord) {
+	// Error rates drive credential selection, so they are tracked even when
+	// usage statistics are disabled.
+	defaultErrorRateStore.Record(record)
+	if !statisticsEnabled.Load() {
+		return
+	}
+	defaultLatencyStore.Record(record)
+}
@@ -0,0 +1,63 @@
+package usage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestLatencyStorePercentiles(t *testing.T) {
+	store := NewLatencyStore()
+	for i := 0; i < 90; i++ {
+		store.Record(coreusage.LatencyRecord{Model: "m", Source: "a", FirstByte: 80 * time.Millisecond, Total: 400 * time.Millisecond})
+	}
+	for i := 0; i < 10; i++ {
+		store.Record(coreusage.LatencyRecord{Model: "m", Source: "a", Failed: true, FirstByte: 4 * time.Second, Total: 8 * time.Second})
+	}
+	store.Record(coreusage.LatencyRecord{Model: "m", Source: "b", FirstByte: time.Second, Total: time.Second})
+
+	summaries := store.Summaries("m", "a")
+	if len(summaries) != 1 {
+		t.Fatalf("expected one summary, got %d", len(summaries))
+	}
+	s := summaries[0]
+	if s.Total.Count != 100 || s.Failed != 10 {
+		t.Fatalf("count = %d failed = %d", s.Total.Count, s.Failed)
+	}
+	if s.Total.P50Ms < 250 || s.Total.P50Ms > 500 {
+		t.Fatalf("total p50 = %v, want within the 250-500ms bucket", s.Total.P50Ms)
+	}
+	if s.Total.P99Ms < 5000 || s.Total.P99Ms > 10000 {
+		t.Fatalf("total p99 = %v, want within the 5-10s bucket", s.Total.P99Ms)
+	}
+	if s.FirstByte.P50Ms > 100 {
+		t.Fatalf("ttfb p50 = %v, want <= 100ms", s.FirstByte.P50Ms)
+	}
+	if got := len(store.Summaries("", "")); got != 2 {
+		t.Fatalf("unfiltered summaries = %d, want 2", got)
+	}
+}
+
+func TestLatencyStoreWritePrometheus(t *testing.T) {
+	store := NewLatencyStore()
+	store.Record(coreusage.LatencyRecord{Provider: "claude", Model: "claude-sonnet-4-5", Source: "a@example.com", FirstByte: 200 * time.Millisecond, Total: 2 * time.Second})
+
+	var buf bytes.Buffer
+	if err := store.WritePrometheus(&buf); err != nil {
+		t.Fatalf("WritePrometheus: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE cliproxy_request_ttfb_seconds histogram",
+		`cliproxy_request_ttfb_seconds_bucket{model="claude-sonnet-4-5",source="a@example.com",provider="claude",le="0.25"} 1`,
+		`cliproxy_request_duration_seconds_bucket{model="claude-sonnet-4-5",source="a@example.com",provider="claude",le="1"} 0`,
+		`cliproxy_request_duration_seconds_count{model="claude-sonnet-4-5",source="a@example.com",provider="claude"} 1`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("missing %q in:\n%s", want, out)
+		}
+	}
+}
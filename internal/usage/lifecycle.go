@@ -0,0 +1,157 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Lifecycle is one owner's claim on the background work of the shared usage stores: the
+// auto-save writers and the retention compaction loop. The stores are process-wide, so
+// the work runs once however many lifecycles are started; it begins with the first
+// running lifecycle and ends with the last. Owners such as embedded or test services
+// each start their own lifecycle and stop it on shutdown.
+type Lifecycle struct {
+	mu   sync.Mutex
+	stop chan struct{} // closed when this lifecycle stops; nil while stopped
+}
+
+// NewLifecycle creates a stopped lifecycle.
+func NewLifecycle() *Lifecycle {
+	return &Lifecycle{}
+}
+
+// Start claims the background work until ctx ends or Stop is called. The first running
+// lifecycle sets the auto-save policy; starting another with a different policy fails
+// because the stores have a single writer. Starting a running lifecycle changes the
+// policy if no other lifecycle is running.
+func (l *Lifecycle) Start(ctx context.Context, policy AutoSavePolicy) error {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := background.acquire(policy.normalized(), l.stop != nil); err != nil {
+		return err
+	}
+	if l.stop != nil {
+		close(l.stop)
+	}
+	stop := make(chan struct{})
+	l.stop = stop
+	go func() {
+		select {
+		case <-ctx.Done():
+			l.release(stop)
+		case <-stop:
+		}
+	}()
+	return nil
+}
+
+// Stop gives up this lifecycle's claim and saves the stores. The background work ends
+// when no other lifecycle is running.
+func (l *Lifecycle) Stop() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	stop := l.stop
+	l.mu.Unlock()
+	if stop != nil {
+		l.release(stop)
+	}
+	for _, p := range persisters() {
+		if err := p.flush(); err != nil {
+			log.Warnf("failed to save %s: %v", p.name, err)
+		}
+	}
+}
+
+// release drops the claim started with stop, unless it was already replaced or dropped.
+func (l *Lifecycle) release(stop chan struct{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.stop != stop {
+		return
+	}
+	close(stop)
+	l.stop = nil
+	background.release()
+}
+
+// background is the auto-save and compaction work of the shared stores, shared by all
+// running lifecycles.
+var background backgroundWork
+
+type backgroundWork struct {
+	mu     sync.Mutex
+	refs   int
+	policy AutoSavePolicy
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// acquire adds a claim on the work with policy, starting it for the first claim.
+// replacing means the caller already holds a claim and wants policy applied to it.
+func (b *backgroundWork) acquire(policy AutoSavePolicy, replacing bool) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.refs == 0:
+		b.startLocked(policy)
+	case policy == b.policy:
+	case replacing && b.refs == 1:
+		b.stopLocked()
+		b.startLocked(policy)
+	default:
+		return fmt.Errorf("usage auto-save already runs with max-records %d and interval %s", b.policy.MaxRecords, b.policy.Interval)
+	}
+	if !replacing {
+		b.refs++
+	}
+	return nil
+}
+
+// release drops a claim, stopping the work with the last one.
+func (b *backgroundWork) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.refs--; b.refs == 0 {
+		b.stopLocked()
+	}
+}
+
+func (b *backgroundWork) startLocked(policy AutoSavePolicy) {
+	b.policy = policy
+	var ctx context.Context
+	ctx, b.cancel = context.WithCancel(context.Background())
+	for _, p := range persisters() {
+		p.limit.Store(int64(policy.MaxRecords))
+		p.running.Add(1)
+		b.wg.Add(1)
+		go func(p *persister) {
+			defer b.wg.Done()
+			defer p.running.Add(-1)
+			p.run(ctx, policy.Interval)
+		}(p)
+	}
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		runCompaction(ctx)
+	}()
+}
+
+// stopLocked cancels the running goroutines and waits for them. Must be called with
+// b.mu held.
+func (b *backgroundWork) stopLocked() {
+	if b.cancel == nil {
+		return
+	}
+	b.cancel()
+	b.cancel = nil
+	b.wg.Wait()
+}
@@ -26,12 +26,8 @@ var statisticsEnabled atomic.Bool
 // Nếu trống, statistics sẽ không được persist.
 var statsFilePath atomic.Value
 
-// autoSaveCancel dùng để cancel auto-save goroutine
-var autoSaveCancel context.CancelFunc
-var autoSaveMu sync.Mutex
-
 // SetStatsFilePath đặt đường dẫn file lưu statistics.
-// Gọi hàm này trước khi gọi Load() hoặc StartAutoSave().
+// Gọi hàm này trước khi gọi Load() hoặc Lifecycle.Start().
 func SetStatsFilePath(path string) {
 	statsFilePath.Store(path)
 }
@@ -121,6 +117,14 @@ type RequestDetail struct {
 	AuthIndex string     `json:"auth_index"`
 	Tokens    TokenStats `json:"tokens"`
 	Failed    bool       `json:"failed"`
+	// Cancelled marks a request the client abandoned before the response completed.
+	Cancelled bool `json:"cancelled,omitempty"`
+	// SpillOver marks a request sent to a metered API key because the subscription
+	// accounts were near their limits.
+	SpillOver bool `json:"spill_over,omitempty"`
+	// DowngradedFrom is the model the client asked for when a fallback model answered
+	// because that model was out of quota.
+	DowngradedFrom string `json:"downgraded_from,omitempty"`
 }
 
 // TokenStats captures the token usage breakdown for a request.
@@ -130,6 +134,8 @@ type TokenStats struct {
 	ReasoningTokens int64 `json:"reasoning_tokens"`
 	CachedTokens    int64 `json:"cached_tokens"`
 	TotalTokens     int64 `json:"total_tokens"`
+	// CacheCreationTokens counts prompt tokens written to the provider cache.
+	CacheCreationTokens int64 `json:"cache_creation_tokens,omitempty"`
 }
 
 // StatisticsSnapshot represents an immutable view of the aggregated metrics.
@@ -196,7 +202,7 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		statsKey = resolveAPIIdentifier(ctx, record)
 	}
 	failed := record.Failed
-	if !failed {
+	if !failed && !record.Cancelled {
 		failed = !resolveSuccess(ctx)
 	}
 	success := !failed
@@ -210,7 +216,6 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 	s.mu.Lock()
 
 	s.totalRequests++
-	currentRequests := s.totalRequests
 	
 	if success {
 		s.successCount++
@@ -225,11 +230,14 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 		s.apis[statsKey] = stats
 	}
 	s.updateAPIStats(stats, modelName, RequestDetail{
-		Timestamp: timestamp,
-		Source:    record.Source,
-		AuthIndex: record.AuthIndex,
-		Tokens:    detail,
-		Failed:    failed,
+		Timestamp:      timestamp,
+		Source:         record.Source,
+		AuthIndex:      record.AuthIndex,
+		Tokens:         detail,
+		Failed:         failed,
+		Cancelled:      record.Cancelled,
+		SpillOver:      record.SpillOver,
+		DowngradedFrom: record.DowngradedFrom,
 	})
 
 	s.requestsByDay[dayKey]++
@@ -239,16 +247,8 @@ func (s *RequestStatistics) Record(ctx context.Context, record coreusage.Record)
 
 	s.mu.Unlock()
 
-	// log.Debugf("Record(): request #%d recorded, model=%s, tokens=%d", currentRequests, modelName, totalTokens)
-
-	// Auto-save sau mỗi 10 requests để đảm bảo không mất dữ liệu
-	if currentRequests%10 == 0 {
-		go func() {
-			// log.Infof("triggering auto-save after %d requests", currentRequests)
-			if err := s.Save(); err != nil {
-				// log.Errorf("auto-save after %d requests failed: %v", currentRequests, err)
-			}
-		}()
+	if s == defaultRequestStatistics {
+		statsPersister.markDirty()
 	}
 }
 
@@ -389,6 +389,9 @@ func (s *RequestStatistics) MergeSnapshot(snapshot StatisticsSnapshot) MergeResu
 		}
 	}
 
+	if result.Added > 0 && s == defaultRequestStatistics {
+		statsPersister.markDirty()
+	}
 	return result
 }
 
@@ -480,11 +483,12 @@ const httpStatusBadRequest = 400
 
 func normaliseDetail(detail coreusage.Detail) TokenStats {
 	tokens := TokenStats{
-		InputTokens:     detail.InputTokens,
-		OutputTokens:    detail.OutputTokens,
-		ReasoningTokens: detail.ReasoningTokens,
-		CachedTokens:    detail.CachedTokens,
-		TotalTokens:     detail.TotalTokens,
+		InputTokens:         detail.InputTokens,
+		OutputTokens:        detail.OutputTokens,
+		ReasoningTokens:     detail.ReasoningTokens,
+		CachedTokens:        detail.CachedTokens,
+		TotalTokens:         detail.TotalTokens,
+		CacheCreationTokens: detail.CacheCreationTokens,
 	}
 	if tokens.TotalTokens == 0 {
 		tokens.TotalTokens = detail.InputTokens + detail.OutputTokens + detail.ReasoningTokens
@@ -658,61 +662,3 @@ func (s *RequestStatistics) Load() error {
 	// log.Infof("statistics loaded from %s: %d total requests", filePath, s.totalRequests)
 	return nil
 }
-
-// StartAutoSave bắt đầu auto-save statistics định kỳ.
-// Gọi StopAutoSave() để dừng auto-save.
-func StartAutoSave(ctx context.Context, interval time.Duration) {
-	autoSaveMu.Lock()
-	defer autoSaveMu.Unlock()
-
-	// filePath := GetStatsFilePath()
-	// log.Infof("auto-save starting: interval=%v, file=%s", interval, filePath)
-
-	// Dừng auto-save cũ nếu đang chạy
-	if autoSaveCancel != nil {
-		autoSaveCancel()
-	}
-
-	ctx, autoSaveCancel = context.WithCancel(ctx)
-	go func() {
-		ticker := time.NewTicker(interval)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				// log.Debug("auto-save stopped")
-				return
-			case <-ticker.C:
-				stats := defaultRequestStatistics
-				stats.mu.RLock()
-				// totalReqs := stats.totalRequests
-				stats.mu.RUnlock()
-				// log.Infof("auto-save tick: saving %d requests to %s", totalReqs, GetStatsFilePath())
-				if err := defaultRequestStatistics.Save(); err != nil {
-					// log.Errorf("auto-save statistics failed: %v", err)
-				} else {
-					// log.Infof("auto-save completed successfully")
-				}
-			}
-		}
-	}()
-	// log.Infof("auto-save goroutine started with interval %v", interval)
-}
-
-// StopAutoSave dừng auto-save và save lần cuối.
-func StopAutoSave() {
-	autoSaveMu.Lock()
-	if autoSaveCancel != nil {
-		autoSaveCancel()
-		autoSaveCancel = nil
-	}
-	autoSaveMu.Unlock()
-
-	// Save lần cuối khi shutdown
-	if err := defaultRequestStatistics.Save(); err != nil {
-		// log.Errorf("final statistics save failed: %v", err)
-	} else {
-		// log.Info("statistics saved on shutdown")
-	}
-}
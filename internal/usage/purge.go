@@ -0,0 +1,52 @@
+package usage
+
+import "time"
+
+// Purge removes the per-request details recorded for a downstream API key, or for every
+// key when apiKey is empty, that were requested before the given time, or at any time
+// when before is zero. The per-key and per-model totals shrink by the removed requests,
+// and a key purged without a time bound is dropped entirely. The global totals and the
+// per-day and per-hour aggregates are anonymous and kept. It returns how many request
+// details were removed.
+func (s *RequestStatistics) Purge(apiKey string, before time.Time) int {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	removed := 0
+	for name, stats := range s.apis {
+		if stats == nil || (apiKey != "" && name != apiKey) {
+			continue
+		}
+		for modelName, modelStatsValue := range stats.Models {
+			if modelStatsValue == nil {
+				continue
+			}
+			kept := modelStatsValue.Details[:0]
+			for _, detail := range modelStatsValue.Details {
+				if before.IsZero() || detail.Timestamp.Before(before) {
+					removed++
+					modelStatsValue.TotalRequests--
+					modelStatsValue.TotalTokens -= detail.Tokens.TotalTokens
+					stats.TotalRequests--
+					stats.TotalTokens -= detail.Tokens.TotalTokens
+					continue
+				}
+				kept = append(kept, detail)
+			}
+			modelStatsValue.Details = kept
+			if len(kept) == 0 && (before.IsZero() || modelStatsValue.TotalRequests <= 0) {
+				delete(stats.Models, modelName)
+			}
+		}
+		if len(stats.Models) == 0 {
+			delete(s.apis, name)
+		}
+	}
+	s.mu.Unlock()
+
+	if removed > 0 && s == defaultRequestStatistics {
+		statsPersister.markDirty()
+	}
+	return removed
+}
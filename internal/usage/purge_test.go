@@ -0,0 +1,41 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestRequestStatisticsPurge(t *testing.T) {
+	now := time.Now()
+	stats := NewRequestStatistics()
+	for _, age := range []time.Duration{48 * time.Hour, time.Hour} {
+		stats.Record(context.Background(), coreusage.Record{APIKey: "alice", Model: "m", RequestedAt: now.Add(-age), Detail: coreusage.Detail{TotalTokens: 10}})
+		stats.Record(context.Background(), coreusage.Record{APIKey: "bob", Model: "m", RequestedAt: now.Add(-age), Detail: coreusage.Detail{TotalTokens: 10}})
+	}
+
+	if removed := stats.Purge("alice", now.Add(-24*time.Hour)); removed != 1 {
+		t.Fatalf("removed = %d, want 1", removed)
+	}
+	snapshot := stats.Snapshot()
+	alice := snapshot.APIs["alice"]
+	if len(alice.Models["m"].Details) != 1 || alice.TotalRequests != 1 || alice.TotalTokens != 10 {
+		t.Fatalf("alice = %+v, want one remaining request", alice)
+	}
+	if len(snapshot.APIs["bob"].Models["m"].Details) != 2 {
+		t.Fatal("bob's details were purged")
+	}
+
+	if removed := stats.Purge("alice", time.Time{}); removed != 1 {
+		t.Fatalf("removed = %d, want 1", removed)
+	}
+	snapshot = stats.Snapshot()
+	if _, ok := snapshot.APIs["alice"]; ok {
+		t.Fatal("alice still present after a full purge")
+	}
+	if snapshot.TotalRequests != 4 {
+		t.Fatalf("total requests = %d, want 4", snapshot.TotalRequests)
+	}
+}
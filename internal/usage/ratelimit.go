@@ -1,15 +1,14 @@
 package usage
 
 import (
-	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
-	"sync"
 	"sync/atomic"
 	"time"
 )
@@ -17,10 +16,6 @@ import (
 // rateLimitFilePath chứa đường dẫn file lưu rate limit statistics.
 var rateLimitFilePath atomic.Value
 
-// rlAutoSaveCancel dùng để cancel auto-save goroutine cho rate limit
-var rlAutoSaveCancel context.CancelFunc
-var rlAutoSaveMu sync.Mutex
-
 // SetRateLimitFilePath đặt đường dẫn file lưu rate limit statistics.
 func SetRateLimitFilePath(path string) {
 	rateLimitFilePath.Store(path)
@@ -34,15 +29,23 @@ func GetRateLimitFilePath() string {
 	return ""
 }
 
+// RateLimitTypeQuota đánh dấu record quota của Gemini CLI (code assist) theo project.
+const RateLimitTypeQuota = "quota"
+
+// RateLimitTypeCodex đánh dấu record từ x-codex-* headers của ChatGPT backend (Codex).
+const RateLimitTypeCodex = "codex"
+
 // RateLimitRecord lưu 1 snapshot rate limit từ Claude API response headers.
-// Hỗ trợ 2 format:
+// Hỗ trợ 4 format:
 //   - Unified (OAuth/subscription): Anthropic-Ratelimit-Unified-5h-*, Anthropic-Ratelimit-Unified-7d-*
 //   - Standard (API key): anthropic-ratelimit-requests-*, anthropic-ratelimit-tokens-*
+//   - Quota (Gemini CLI): bucket từ retrieveUserQuota hoặc lỗi 429 của cloudcode endpoint
+//   - Codex (ChatGPT OAuth): x-codex-primary-*, x-codex-secondary-*
 type RateLimitRecord struct {
 	Timestamp time.Time `json:"timestamp"`
 	Source    string    `json:"source"` // auth email/key identifier
 	Model     string    `json:"model"`
-	Type      string    `json:"type"` // "unified" hoặc "standard"
+	Type      string    `json:"type"` // "unified", "standard", "quota" hoặc "codex"
 
 	// === Unified fields (OAuth/subscription) ===
 	// 5-hour window
@@ -77,6 +80,23 @@ type RateLimitRecord struct {
 	OutputTokensLimit     int64     `json:"output_tokens_limit,omitempty"`
 	OutputTokensRemaining int64     `json:"output_tokens_remaining,omitempty"`
 	OutputTokensReset     time.Time `json:"output_tokens_reset,omitempty"`
+
+	// === Quota fields (Gemini CLI code assist) ===
+	Project           string    `json:"project,omitempty"`            // Google Cloud project ID
+	RemainingFraction float64   `json:"remaining_fraction,omitempty"` // phần quota còn lại (0.0 - 1.0)
+	QuotaStatus       string    `json:"quota_status,omitempty"`       // "allowed" / "rejected"
+	QuotaReset        time.Time `json:"quota_reset,omitempty"`
+
+	// === Codex fields (ChatGPT OAuth) ===
+	// Primary window (thường là 5h)
+	PrimaryUsedPercent   float64   `json:"primary_used_percent,omitempty"` // % đã dùng (0 - 100)
+	PrimaryWindowMinutes int64     `json:"primary_window_minutes,omitempty"`
+	PrimaryReset         time.Time `json:"primary_reset,omitempty"`
+
+	// Secondary window (thường là 7 ngày)
+	SecondaryUsedPercent   float64   `json:"secondary_used_percent,omitempty"`
+	SecondaryWindowMinutes int64     `json:"secondary_window_minutes,omitempty"`
+	SecondaryReset         time.Time `json:"secondary_reset,omitempty"`
 }
 
 // IsEmpty kiểm tra xem record có chứa dữ liệu rate limit hợp lệ không.
@@ -84,6 +104,12 @@ func (r RateLimitRecord) IsEmpty() bool {
 	if r.Type == "unified" {
 		return r.Status5h == "" && r.Status7d == "" && r.UnifiedStatus == ""
 	}
+	if r.Type == RateLimitTypeQuota {
+		return r.QuotaStatus == ""
+	}
+	if r.Type == RateLimitTypeCodex {
+		return r.PrimaryWindowMinutes == 0 && r.SecondaryWindowMinutes == 0 && r.PrimaryReset.IsZero() && r.SecondaryReset.IsZero()
+	}
 	return r.RequestsLimit == 0 && r.TokensLimit == 0 && r.InputTokensLimit == 0 && r.OutputTokensLimit == 0
 }
 
@@ -107,18 +133,31 @@ type SourceUsage struct {
 	LatestLimit *RateLimitRecord `json:"latest_limit,omitempty"`
 }
 
+// OrganizationUsage gộp usage của các OAuth account cùng 1 Anthropic organization.
+// Anthropic áp limit theo organization, nên utilization ở đây là giá trị cao nhất
+// trong các record mới nhất của từng account thuộc org.
+type OrganizationUsage struct {
+	Requests      int64            `json:"requests"`
+	Sources       []string         `json:"sources"`
+	Utilization5h float64          `json:"utilization_5h"`
+	Utilization7d float64          `json:"utilization_7d"`
+	Rejected      bool             `json:"rejected,omitempty"` // có account trong org đang bị rejected
+	LatestLimit   *RateLimitRecord `json:"latest_limit,omitempty"`
+}
+
 // WindowSummary chứa aggregated usage cho 1 time window.
 type WindowSummary struct {
-	TotalRequests int64                  `json:"total_requests"`
-	Unified       *UnifiedSummary        `json:"unified,omitempty"`      // Unified rate limit data (OAuth)
-	LatestLimit   *RateLimitRecord       `json:"latest_limit,omitempty"` // Standard rate limit (API key)
-	BySource      map[string]SourceUsage `json:"by_source,omitempty"`
+	TotalRequests  int64                        `json:"total_requests"`
+	Unified        *UnifiedSummary              `json:"unified,omitempty"`      // Unified rate limit data (OAuth)
+	LatestLimit    *RateLimitRecord             `json:"latest_limit,omitempty"` // Standard rate limit (API key)
+	BySource       map[string]SourceUsage       `json:"by_source,omitempty"`
+	ByOrganization map[string]OrganizationUsage `json:"by_organization,omitempty"`
 }
 
 // RateLimitStore lưu trữ in-memory các rate limit records với JSON persistence.
+// Records are sharded by source; see ratelimit_shard.go.
 type RateLimitStore struct {
-	mu      sync.RWMutex
-	records []RateLimitRecord
+	shards [rateLimitShardCount]rateLimitShard
 }
 
 var defaultRateLimitStore = NewRateLimitStore()
@@ -131,8 +170,9 @@ func NewRateLimitStore() *RateLimitStore {
 	return &RateLimitStore{}
 }
 
-// maxRecordAge giới hạn records được giữ trong memory (7 ngày).
-const maxRecordAge = 7 * 24 * time.Hour
+// defaultRateLimitMaxAge giới hạn records được giữ trong memory (7 ngày) khi
+// usage-retention.rate-limits.max-age-hours không được đặt.
+const defaultRateLimitMaxAge = 7 * 24 * time.Hour
 
 // Record thêm 1 rate limit record vào store.
 func (s *RateLimitStore) Record(r RateLimitRecord) {
@@ -143,34 +183,100 @@ func (s *RateLimitStore) Record(r RateLimitRecord) {
 		r.Timestamp = time.Now()
 	}
 
-	s.mu.Lock()
-	s.records = append(s.records, r)
-	// Cleanup records cũ hơn 7 ngày mỗi 100 records
-	if len(s.records)%100 == 0 {
-		s.cleanupLocked()
+	sh := s.shard(r.Source)
+	sh.mu.Lock()
+	src := sh.sourceLocked(r.Source)
+	src.insert(r)
+	// Cleanup records cũ hơn thời gian retention mỗi 100 records của source
+	if len(src.records)%100 == 0 {
+		src.dropThrough(time.Now().Add(-rateLimitMaxAge()))
+	}
+	sh.mu.Unlock()
+
+	if s == defaultRateLimitStore {
+		rateLimitPersister.markDirty()
 	}
-	count := len(s.records)
-	s.mu.Unlock()
+}
 
-	// Auto-save sau mỗi 10 records
-	if count%10 == 0 {
-		go func() {
-			_ = s.Save()
-		}()
+// Utilization5h trả về utilization 5h hiện tại (0.0 - 1.0) của source theo record unified
+// mới nhất. Trả về false nếu chưa có dữ liệu hoặc window đã reset sau record đó.
+func (s *RateLimitStore) Utilization5h(source string) (float64, bool) {
+	if s == nil || source == "" {
+		return 0, false
+	}
+	r, ok := s.latestOf(source)
+	if !ok || r.Type != "unified" {
+		return 0, false
+	}
+	if !r.Reset5h.IsZero() && !time.Now().Before(r.Reset5h) {
+		return 0, false
+	}
+	return r.Utilization5h, true
+}
+
+// UnifiedUtilization trả về utilization cao nhất (0.0 - 1.0) giữa window 5h và 7d của
+// source theo record unified mới nhất, bỏ qua window đã reset. Trả về false nếu chưa có dữ liệu.
+func (s *RateLimitStore) UnifiedUtilization(source string) (float64, bool) {
+	if s == nil || source == "" {
+		return 0, false
+	}
+	r, ok := s.latestOf(source)
+	if !ok || r.Type != "unified" {
+		return 0, false
+	}
+	now := time.Now()
+	utilization, known := 0.0, false
+	if r.Reset5h.IsZero() || now.Before(r.Reset5h) {
+		utilization, known = r.Utilization5h, true
+	}
+	if (r.Reset7d.IsZero() || now.Before(r.Reset7d)) && (!known || r.Utilization7d > utilization) {
+		utilization, known = r.Utilization7d, true
+	}
+	return utilization, known
+}
+
+// LatestBySource trả về record mới nhất của từng source trong thời gian retention, sắp xếp theo source.
+func (s *RateLimitStore) LatestBySource() []RateLimitRecord {
+	if s == nil {
+		return nil
 	}
+	cutoff := time.Now().Add(-rateLimitMaxAge())
+	out := []RateLimitRecord{}
+	s.viewSources(func(source string, src *sourceRateLimits) {
+		if r, ok := src.latest(); ok && source != "" && r.Timestamp.After(cutoff) {
+			out = append(out, r)
+		}
+	})
+	sort.Slice(out, func(i, j int) bool { return out[i].Source < out[j].Source })
+	return out
 }
 
-// cleanupLocked xóa records cũ hơn maxRecordAge. Phải gọi trong lock.
-func (s *RateLimitStore) cleanupLocked() {
-	cutoff := time.Now().Add(-maxRecordAge)
-	n := 0
-	for _, r := range s.records {
-		if r.Timestamp.After(cutoff) {
-			s.records[n] = r
-			n++
+// BlockedUntil trả về thời điểm source dùng lại được nếu record cho thấy đã hết limit:
+// thời điểm reset muộn nhất trong các window đã hết. Zero nghĩa là không bị chặn.
+func (r RateLimitRecord) BlockedUntil(now time.Time) time.Time {
+	var until time.Time
+	block := func(exhausted bool, reset time.Time) {
+		if exhausted && reset.After(now) && reset.After(until) {
+			until = reset
 		}
 	}
-	s.records = s.records[:n]
+	switch r.Type {
+	case "unified":
+		block(r.Status5h == "rejected", r.Reset5h)
+		block(r.Status7d == "rejected", r.Reset7d)
+		block(r.UnifiedStatus == "rejected", r.UnifiedReset)
+	case RateLimitTypeQuota:
+		block(r.QuotaStatus == "rejected", r.QuotaReset)
+	case RateLimitTypeCodex:
+		block(r.PrimaryUsedPercent >= 100, r.PrimaryReset)
+		block(r.SecondaryUsedPercent >= 100, r.SecondaryReset)
+	default:
+		block(r.RequestsLimit > 0 && r.RequestsRemaining <= 0, r.RequestsReset)
+		block(r.TokensLimit > 0 && r.TokensRemaining <= 0, r.TokensReset)
+		block(r.InputTokensLimit > 0 && r.InputTokensRemaining <= 0, r.InputTokensReset)
+		block(r.OutputTokensLimit > 0 && r.OutputTokensRemaining <= 0, r.OutputTokensReset)
+	}
+	return until
 }
 
 // Latest trả về record mới nhất (nil nếu chưa có).
@@ -178,13 +284,23 @@ func (s *RateLimitStore) Latest() *RateLimitRecord {
 	if s == nil {
 		return nil
 	}
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	if len(s.records) == 0 {
-		return nil
+	var latest *RateLimitRecord
+	var latestSource string
+	s.viewSources(func(source string, src *sourceRateLimits) {
+		if r, ok := src.latest(); ok && newerRecord(r, source, latest, latestSource) {
+			latest, latestSource = &r, source
+		}
+	})
+	return latest
+}
+
+// newerRecord reports whether r of source is newer than current of currentSource. Ties
+// go to the source sorting first, so results don't depend on map order.
+func newerRecord(r RateLimitRecord, source string, current *RateLimitRecord, currentSource string) bool {
+	if current == nil || r.Timestamp.After(current.Timestamp) {
+		return true
 	}
-	r := s.records[len(s.records)-1]
-	return &r
+	return r.Timestamp.Equal(current.Timestamp) && source < currentSource
 }
 
 // QueryByWindow trả về aggregated summary cho records trong time window.
@@ -198,39 +314,48 @@ func (s *RateLimitStore) QueryByWindow(d time.Duration) WindowSummary {
 
 	cutoff := time.Now().Add(-d)
 
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
-	var latestTime time.Time
 	var latestRecord *RateLimitRecord
-
-	for i := range s.records {
-		r := &s.records[i]
-		if r.Timestamp.Before(cutoff) {
-			continue
+	var latestSource string
+	orgs := make(map[string]int64)
+
+	s.viewSources(func(source string, src *sourceRateLimits) {
+		clear(orgs)
+		requests := src.window(cutoff, orgs)
+		if requests == 0 {
+			return
 		}
-		summary.TotalRequests++
+		// Records are ordered, so the newest one is inside the window.
+		r := src.records[len(src.records)-1]
+		summary.TotalRequests += requests
 
 		// Track latest record overall
-		if r.Timestamp.After(latestTime) {
-			latestTime = r.Timestamp
-			rCopy := *r
-			latestRecord = &rCopy
+		if newerRecord(r, source, latestRecord, latestSource) {
+			rCopy := r
+			latestRecord, latestSource = &rCopy, source
 		}
 
 		// Track per-source
-		source := r.Source
 		if source == "" {
 			source = "unknown"
 		}
 		su := summary.BySource[source]
-		su.Requests++
+		su.Requests += requests
 		if su.LatestLimit == nil || r.Timestamp.After(su.LatestLimit.Timestamp) {
-			rCopy := *r
+			rCopy := r
 			su.LatestLimit = &rCopy
 		}
 		summary.BySource[source] = su
-	}
+
+		for id, n := range orgs {
+			if summary.ByOrganization == nil {
+				summary.ByOrganization = make(map[string]OrganizationUsage)
+			}
+			org := summary.ByOrganization[id]
+			org.Requests += n
+			summary.ByOrganization[id] = org
+		}
+	})
+	summary.rollupOrganizations()
 
 	if latestRecord != nil {
 		if latestRecord.Type == "unified" {
@@ -257,6 +382,85 @@ func (s *RateLimitStore) QueryByWindow(d time.Duration) WindowSummary {
 	return summary
 }
 
+// rollupOrganizations điền utilization và danh sách source cho từng organization
+// từ record mới nhất của mỗi source.
+func (summary *WindowSummary) rollupOrganizations() {
+	if len(summary.ByOrganization) == 0 {
+		return
+	}
+	for source, su := range summary.BySource {
+		latest := su.LatestLimit
+		if latest == nil || latest.OrganizationID == "" {
+			continue
+		}
+		org, ok := summary.ByOrganization[latest.OrganizationID]
+		if !ok {
+			continue
+		}
+		org.Sources = append(org.Sources, source)
+		if latest.Utilization5h > org.Utilization5h {
+			org.Utilization5h = latest.Utilization5h
+		}
+		if latest.Utilization7d > org.Utilization7d {
+			org.Utilization7d = latest.Utilization7d
+		}
+		if latest.UnifiedStatus == "rejected" || latest.Status5h == "rejected" || latest.Status7d == "rejected" {
+			org.Rejected = true
+		}
+		if org.LatestLimit == nil || latest.Timestamp.After(org.LatestLimit.Timestamp) {
+			org.LatestLimit = latest
+		}
+		summary.ByOrganization[latest.OrganizationID] = org
+	}
+	for id, org := range summary.ByOrganization {
+		sort.Strings(org.Sources)
+		summary.ByOrganization[id] = org
+	}
+}
+
+// Records trả về bản sao các records trong thời gian retention, dùng cho state handoff giữa instance.
+func (s *RateLimitStore) Records() []RateLimitRecord {
+	if s == nil {
+		return nil
+	}
+	cutoff := time.Now().Add(-rateLimitMaxAge())
+	out := []RateLimitRecord{}
+	s.viewSources(func(_ string, src *sourceRateLimits) {
+		i := sort.Search(len(src.records), func(i int) bool { return src.records[i].Timestamp.After(cutoff) })
+		out = append(out, src.records[i:]...)
+	})
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp.Before(out[j].Timestamp) })
+	return out
+}
+
+// MergeRecords gộp records export từ instance khác vào store. Record rỗng, quá cũ hoặc
+// trùng (source, type, timestamp) bị bỏ qua. Trả về số record đã thêm.
+func (s *RateLimitStore) MergeRecords(records []RateLimitRecord) int {
+	if s == nil || len(records) == 0 {
+		return 0
+	}
+	cutoff := time.Now().Add(-rateLimitMaxAge())
+
+	added := 0
+	for _, r := range records {
+		if r.IsEmpty() || !r.Timestamp.After(cutoff) {
+			continue
+		}
+		sh := s.shard(r.Source)
+		sh.mu.Lock()
+		if src := sh.sourceLocked(r.Source); src.find(r) < 0 {
+			src.insert(r)
+			added++
+		}
+		sh.mu.Unlock()
+	}
+
+	if added > 0 && s == defaultRateLimitStore {
+		rateLimitPersister.markDirty()
+	}
+	return added
+}
+
 // rateLimitSnapshot dùng cho JSON persistence.
 type rateLimitSnapshot struct {
 	Records []RateLimitRecord `json:"records"`
@@ -272,18 +476,8 @@ func (s *RateLimitStore) Save() error {
 		return nil
 	}
 
-	s.mu.RLock()
-	// Chỉ lưu records trong 7 ngày gần nhất
-	cutoff := time.Now().Add(-maxRecordAge)
-	var filtered []RateLimitRecord
-	for _, r := range s.records {
-		if r.Timestamp.After(cutoff) {
-			filtered = append(filtered, r)
-		}
-	}
-	s.mu.RUnlock()
-
-	snapshot := rateLimitSnapshot{Records: filtered}
+	// Chỉ lưu records trong thời gian retention
+	snapshot := rateLimitSnapshot{Records: s.Records()}
 	data, err := json.MarshalIndent(snapshot, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal ratelimit statistics: %w", err)
@@ -342,50 +536,24 @@ func (s *RateLimitStore) Load() error {
 		return fmt.Errorf("failed to unmarshal ratelimit statistics: %w", err)
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
-	s.records = snapshot.Records
-	s.cleanupLocked()
-
-	return nil
-}
-
-// StartRateLimitAutoSave bắt đầu auto-save rate limit statistics định kỳ.
-func StartRateLimitAutoSave(ctx context.Context, interval time.Duration) {
-	rlAutoSaveMu.Lock()
-	defer rlAutoSaveMu.Unlock()
-
-	if rlAutoSaveCancel != nil {
-		rlAutoSaveCancel()
+	cutoff := time.Now().Add(-rateLimitMaxAge())
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.Lock()
+		sh.sources = nil
+		sh.mu.Unlock()
 	}
-
-	ctx, rlAutoSaveCancel = context.WithCancel(ctx)
-	go func() {
-		ticker := time.NewTicker(interval)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				_ = defaultRateLimitStore.Save()
-			}
+	for _, r := range snapshot.Records {
+		if !r.Timestamp.After(cutoff) {
+			continue
 		}
-	}()
-}
-
-// StopRateLimitAutoSave dừng auto-save và save lần cuối.
-func StopRateLimitAutoSave() {
-	rlAutoSaveMu.Lock()
-	if rlAutoSaveCancel != nil {
-		rlAutoSaveCancel()
-		rlAutoSaveCancel = nil
+		sh := s.shard(r.Source)
+		sh.mu.Lock()
+		sh.sourceLocked(r.Source).insert(r)
+		sh.mu.Unlock()
 	}
-	rlAutoSaveMu.Unlock()
 
-	_ = defaultRateLimitStore.Save()
+	return nil
 }
 
 // ParseRateLimitHeaders parse rate limit headers từ HTTP response của Claude API.
@@ -410,6 +578,36 @@ func ParseRateLimitHeaders(headers http.Header) RateLimitRecord {
 	return r
 }
 
+// ParseCodexRateLimitHeaders parse x-codex-primary-* / x-codex-secondary-* headers
+// từ ChatGPT backend (Codex). Reset có thể ở dạng reset-after-seconds hoặc reset-at (Unix).
+func ParseCodexRateLimitHeaders(headers http.Header) RateLimitRecord {
+	now := time.Now()
+	r := RateLimitRecord{
+		Timestamp: now,
+		Type:      RateLimitTypeCodex,
+	}
+	r.PrimaryUsedPercent = parseFloatHeader(headers.Get("x-codex-primary-used-percent"))
+	r.PrimaryWindowMinutes = parseIntHeader(headers, "x-codex-primary-window-minutes")
+	r.PrimaryReset = parseCodexReset(headers, "x-codex-primary", now)
+	r.SecondaryUsedPercent = parseFloatHeader(headers.Get("x-codex-secondary-used-percent"))
+	r.SecondaryWindowMinutes = parseIntHeader(headers, "x-codex-secondary-window-minutes")
+	r.SecondaryReset = parseCodexReset(headers, "x-codex-secondary", now)
+	return r
+}
+
+// parseCodexReset đọc thời điểm reset của 1 window Codex.
+func parseCodexReset(headers http.Header, prefix string, now time.Time) time.Time {
+	if v := headers.Get(prefix + "-reset-at"); v != "" {
+		return parseUnixTimestamp(v)
+	}
+	if v := headers.Get(prefix + "-reset-after-seconds"); v != "" {
+		if secs := parseFloatHeader(v); secs > 0 {
+			return now.Add(time.Duration(secs * float64(time.Second)))
+		}
+	}
+	return time.Time{}
+}
+
 // parseUnifiedHeaders parse Anthropic-Ratelimit-Unified-* headers.
 // Trả về true nếu tìm thấy ít nhất 1 unified header.
 func parseUnifiedHeaders(headers http.Header, r *RateLimitRecord) bool {
@@ -0,0 +1,104 @@
+package usage
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// maxRecordClockSkew is how far in the future an injected record's timestamp may lie.
+const maxRecordClockSkew = 5 * time.Minute
+
+// ValidateRateLimitRecord checks a record supplied from outside the proxy, e.g. injected
+// through the management API, against the RateLimitRecord schema: a known type and a
+// source, a timestamp within the retention window, the fields its type requires, and
+// fractions, percentages and counters within their ranges.
+func ValidateRateLimitRecord(r RateLimitRecord, now time.Time) error {
+	if strings.TrimSpace(r.Source) == "" {
+		return fmt.Errorf("source is required")
+	}
+	switch r.Type {
+	case "unified", "standard", RateLimitTypeQuota, RateLimitTypeCodex:
+	default:
+		return fmt.Errorf("type must be one of unified, standard, %s or %s", RateLimitTypeQuota, RateLimitTypeCodex)
+	}
+	if r.Timestamp.IsZero() {
+		return fmt.Errorf("timestamp is required")
+	}
+	if r.Timestamp.After(now.Add(maxRecordClockSkew)) {
+		return fmt.Errorf("timestamp %s is in the future", r.Timestamp.Format(time.RFC3339))
+	}
+	if maxAge := rateLimitMaxAge(); !r.Timestamp.After(now.Add(-maxAge)) {
+		return fmt.Errorf("timestamp %s is older than the %s retention window", r.Timestamp.Format(time.RFC3339), maxAge)
+	}
+	if r.IsEmpty() {
+		return fmt.Errorf("record has no %s rate-limit fields", r.Type)
+	}
+
+	ranges := []struct {
+		name     string
+		value    float64
+		min, max float64
+	}{
+		{"utilization_5h", r.Utilization5h, 0, 1},
+		{"utilization_7d", r.Utilization7d, 0, 1},
+		{"fallback_percentage", r.FallbackPercentage, 0, 1},
+		{"remaining_fraction", r.RemainingFraction, 0, 1},
+		{"primary_used_percent", r.PrimaryUsedPercent, 0, 100},
+		{"secondary_used_percent", r.SecondaryUsedPercent, 0, 100},
+	}
+	for _, f := range ranges {
+		if f.value < f.min || f.value > f.max {
+			return fmt.Errorf("%s must be between %v and %v, got %v", f.name, f.min, f.max, f.value)
+		}
+	}
+	counters := []struct {
+		name             string
+		limit, remaining int64
+	}{
+		{"requests", r.RequestsLimit, r.RequestsRemaining},
+		{"tokens", r.TokensLimit, r.TokensRemaining},
+		{"input_tokens", r.InputTokensLimit, r.InputTokensRemaining},
+		{"output_tokens", r.OutputTokensLimit, r.OutputTokensRemaining},
+	}
+	for _, c := range counters {
+		if c.limit < 0 || c.remaining < 0 {
+			return fmt.Errorf("%s_limit and %s_remaining must not be negative", c.name, c.name)
+		}
+		if c.limit > 0 && c.remaining > c.limit {
+			return fmt.Errorf("%s_remaining %d exceeds %s_limit %d", c.name, c.remaining, c.name, c.limit)
+		}
+	}
+	if r.PrimaryWindowMinutes < 0 || r.SecondaryWindowMinutes < 0 {
+		return fmt.Errorf("window minutes must not be negative")
+	}
+	return nil
+}
+
+// InjectRecords stores records supplied by an operator. Unlike MergeRecords, a record
+// with the same source, type and timestamp as a stored one replaces it, so snapshots can
+// be corrected as well as seeded. Records must have been validated with
+// ValidateRateLimitRecord. It returns how many records were added and replaced.
+func (s *RateLimitStore) InjectRecords(records []RateLimitRecord) (added, replaced int) {
+	if s == nil || len(records) == 0 {
+		return 0, 0
+	}
+	for _, r := range records {
+		sh := s.shard(r.Source)
+		sh.mu.Lock()
+		src := sh.sourceLocked(r.Source)
+		if i := src.find(r); i >= 0 {
+			src.replace(i, r)
+			replaced++
+		} else {
+			src.insert(r)
+			added++
+		}
+		sh.mu.Unlock()
+	}
+
+	if s == defaultRateLimitStore {
+		rateLimitPersister.markDirty()
+	}
+	return added, replaced
+}
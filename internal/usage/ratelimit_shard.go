@@ -0,0 +1,206 @@
+package usage
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// rateLimitShardCount is the number of lock shards of a RateLimitStore. Sources are
+// spread over the shards by hash, so records of different credentials rarely contend
+// and management queries only hold one shard at a time.
+const rateLimitShardCount = 16
+
+// rateLimitShard holds the records of the sources hashed to it.
+type rateLimitShard struct {
+	mu      sync.RWMutex
+	sources map[string]*sourceRateLimits
+}
+
+// sourceRateLimits holds the records of one source in timestamp order together with
+// per-minute counters, so window queries need not scan the records.
+type sourceRateLimits struct {
+	records []RateLimitRecord
+	buckets []rateLimitBucket // ordered by minute
+}
+
+// rateLimitBucket counts the records of one source within one minute.
+type rateLimitBucket struct {
+	minute   int64
+	requests int64
+	orgs     map[string]int64 // requests per organization ID
+}
+
+// shard returns the shard of source (FNV-1a).
+func (s *RateLimitStore) shard(source string) *rateLimitShard {
+	h := uint32(2166136261)
+	for i := 0; i < len(source); i++ {
+		h ^= uint32(source[i])
+		h *= 16777619
+	}
+	return &s.shards[h%rateLimitShardCount]
+}
+
+// sourceLocked returns the records of source, creating them. Must be called with the
+// shard's write lock held.
+func (sh *rateLimitShard) sourceLocked(source string) *sourceRateLimits {
+	if sh.sources == nil {
+		sh.sources = make(map[string]*sourceRateLimits)
+	}
+	src := sh.sources[source]
+	if src == nil {
+		src = &sourceRateLimits{}
+		sh.sources[source] = src
+	}
+	return src
+}
+
+// viewSources calls fn for the records of every source, holding each shard's read lock
+// in turn.
+func (s *RateLimitStore) viewSources(fn func(source string, src *sourceRateLimits)) {
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.RLock()
+		for source, src := range sh.sources {
+			fn(source, src)
+		}
+		sh.mu.RUnlock()
+	}
+}
+
+// updateSources calls fn for the records of every source, holding each shard's write
+// lock in turn. Sources left without records are dropped.
+func (s *RateLimitStore) updateSources(fn func(source string, src *sourceRateLimits)) {
+	for i := range s.shards {
+		sh := &s.shards[i]
+		sh.mu.Lock()
+		for source, src := range sh.sources {
+			fn(source, src)
+			if len(src.records) == 0 {
+				delete(sh.sources, source)
+			}
+		}
+		sh.mu.Unlock()
+	}
+}
+
+// latestOf returns the newest record of source.
+func (s *RateLimitStore) latestOf(source string) (RateLimitRecord, bool) {
+	sh := s.shard(source)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	return sh.sources[source].latest()
+}
+
+// recordMinute returns the Unix minute a timestamp falls in.
+func recordMinute(t time.Time) int64 {
+	return t.Unix() / 60
+}
+
+// latest returns the newest record; among records with the same timestamp, the one
+// stored last.
+func (src *sourceRateLimits) latest() (RateLimitRecord, bool) {
+	if src == nil || len(src.records) == 0 {
+		return RateLimitRecord{}, false
+	}
+	return src.records[len(src.records)-1], true
+}
+
+// insert adds r after the records with the same or an earlier timestamp.
+func (src *sourceRateLimits) insert(r RateLimitRecord) {
+	i := sort.Search(len(src.records), func(i int) bool { return src.records[i].Timestamp.After(r.Timestamp) })
+	src.records = append(src.records, RateLimitRecord{})
+	copy(src.records[i+1:], src.records[i:])
+	src.records[i] = r
+	src.count(r, 1)
+}
+
+// find returns the index of the record with r's type and timestamp, or -1.
+func (src *sourceRateLimits) find(r RateLimitRecord) int {
+	i := sort.Search(len(src.records), func(i int) bool { return !src.records[i].Timestamp.Before(r.Timestamp) })
+	for ; i < len(src.records) && src.records[i].Timestamp.Equal(r.Timestamp); i++ {
+		if src.records[i].Type == r.Type {
+			return i
+		}
+	}
+	return -1
+}
+
+// replace overwrites the record at index i with r, which has the same timestamp.
+func (src *sourceRateLimits) replace(i int, r RateLimitRecord) {
+	src.count(src.records[i], -1)
+	src.records[i] = r
+	src.count(r, 1)
+}
+
+// dropThrough removes the records not newer than cutoff and returns how many were
+// removed.
+func (src *sourceRateLimits) dropThrough(cutoff time.Time) int {
+	n := sort.Search(len(src.records), func(i int) bool { return src.records[i].Timestamp.After(cutoff) })
+	if n == 0 {
+		return 0
+	}
+	// Buckets of earlier minutes only hold dropped records; the cutoff minute may keep some.
+	cutoffMinute := recordMinute(cutoff)
+	first := sort.Search(len(src.buckets), func(j int) bool { return src.buckets[j].minute >= cutoffMinute })
+	src.buckets = src.buckets[first:]
+	for _, r := range src.records[:n] {
+		if recordMinute(r.Timestamp) >= cutoffMinute {
+			src.count(r, -1)
+		}
+	}
+	src.records = append(src.records[:0:0], src.records[n:]...)
+	return n
+}
+
+// count adds delta to the counters of r's minute.
+func (src *sourceRateLimits) count(r RateLimitRecord, delta int64) {
+	minute := recordMinute(r.Timestamp)
+	j := sort.Search(len(src.buckets), func(j int) bool { return src.buckets[j].minute >= minute })
+	if j == len(src.buckets) || src.buckets[j].minute != minute {
+		if delta < 0 {
+			return
+		}
+		src.buckets = append(src.buckets, rateLimitBucket{})
+		copy(src.buckets[j+1:], src.buckets[j:])
+		src.buckets[j] = rateLimitBucket{minute: minute}
+	}
+	b := &src.buckets[j]
+	b.requests += delta
+	if r.OrganizationID != "" {
+		if b.orgs == nil {
+			b.orgs = make(map[string]int64)
+		}
+		if b.orgs[r.OrganizationID] += delta; b.orgs[r.OrganizationID] <= 0 {
+			delete(b.orgs, r.OrganizationID)
+		}
+	}
+	if b.requests <= 0 {
+		src.buckets = append(src.buckets[:j], src.buckets[j+1:]...)
+	}
+}
+
+// window counts the records at or after cutoff, adding their organizations to orgs.
+// Whole minutes come from the counters; only the records of the cutoff minute are read.
+func (src *sourceRateLimits) window(cutoff time.Time, orgs map[string]int64) int64 {
+	cutoffMinute := recordMinute(cutoff)
+	var requests int64
+	first := sort.Search(len(src.records), func(i int) bool { return !src.records[i].Timestamp.Before(cutoff) })
+	for _, r := range src.records[first:] {
+		if recordMinute(r.Timestamp) != cutoffMinute {
+			break
+		}
+		requests++
+		if r.OrganizationID != "" {
+			orgs[r.OrganizationID]++
+		}
+	}
+	for j := sort.Search(len(src.buckets), func(j int) bool { return src.buckets[j].minute > cutoffMinute }); j < len(src.buckets); j++ {
+		b := &src.buckets[j]
+		requests += b.requests
+		for org, n := range b.orgs {
+			orgs[org] += n
+		}
+	}
+	return requests
+}
@@ -0,0 +1,180 @@
+package usage
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseCodexRateLimitHeaders(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("x-codex-primary-used-percent", "42.5")
+	headers.Set("x-codex-primary-window-minutes", "300")
+	headers.Set("x-codex-primary-reset-after-seconds", "120")
+	headers.Set("x-codex-secondary-used-percent", "10")
+	headers.Set("x-codex-secondary-window-minutes", "10080")
+	headers.Set("x-codex-secondary-reset-at", "1767225600")
+
+	r := ParseCodexRateLimitHeaders(headers)
+	if r.Type != RateLimitTypeCodex || r.IsEmpty() {
+		t.Fatalf("unexpected record: %+v", r)
+	}
+	if r.PrimaryUsedPercent != 42.5 || r.PrimaryWindowMinutes != 300 {
+		t.Fatalf("primary = %.1f%% (%dm), want 42.5%% (300m)", r.PrimaryUsedPercent, r.PrimaryWindowMinutes)
+	}
+	if d := time.Until(r.PrimaryReset); d <= 0 || d > 2*time.Minute {
+		t.Fatalf("primary reset in %s, want about 2m", d)
+	}
+	if !r.SecondaryReset.Equal(time.Unix(1767225600, 0)) {
+		t.Fatalf("secondary reset = %s", r.SecondaryReset)
+	}
+}
+
+func TestParseCodexRateLimitHeadersEmpty(t *testing.T) {
+	if r := ParseCodexRateLimitHeaders(http.Header{}); !r.IsEmpty() {
+		t.Fatalf("expected empty record, got %+v", r)
+	}
+}
+
+func TestQueryByWindowRollsUpOrganizations(t *testing.T) {
+	store := NewRateLimitStore()
+	now := time.Now()
+	store.Record(RateLimitRecord{Timestamp: now.Add(-2 * time.Minute), Source: "a@example.com", Type: "unified", OrganizationID: "org-1", Status5h: "allowed", Utilization5h: 0.9})
+	store.Record(RateLimitRecord{Timestamp: now.Add(-time.Minute), Source: "a@example.com", Type: "unified", OrganizationID: "org-1", Status5h: "allowed", Utilization5h: 0.3, Utilization7d: 0.2})
+	store.Record(RateLimitRecord{Timestamp: now, Source: "b@example.com", Type: "unified", OrganizationID: "org-1", Status5h: "allowed", Status7d: "rejected", Utilization5h: 0.6, Utilization7d: 1})
+	store.Record(RateLimitRecord{Timestamp: now, Source: "c@example.com", Type: "unified", OrganizationID: "org-2", Status5h: "allowed", Utilization5h: 0.1})
+
+	summary := store.QueryByWindow(time.Hour)
+	org := summary.ByOrganization["org-1"]
+	if org.Requests != 3 || len(org.Sources) != 2 || org.Sources[0] != "a@example.com" || org.Sources[1] != "b@example.com" {
+		t.Fatalf("org-1 = %+v", org)
+	}
+	if org.Utilization5h != 0.6 || org.Utilization7d != 1 || !org.Rejected {
+		t.Fatalf("org-1 utilization = %.1f/%.1f rejected=%v, want 0.6/1.0 rejected", org.Utilization5h, org.Utilization7d, org.Rejected)
+	}
+	if org.LatestLimit == nil || org.LatestLimit.Source != "b@example.com" {
+		t.Fatalf("org-1 latest = %+v", org.LatestLimit)
+	}
+	if other := summary.ByOrganization["org-2"]; other.Requests != 1 || other.Rejected {
+		t.Fatalf("org-2 = %+v", other)
+	}
+}
+
+func TestMergeRecordsSkipsDuplicatesAndStale(t *testing.T) {
+	now := time.Now()
+	recent := RateLimitRecord{Timestamp: now.Add(-time.Hour), Source: "a@example.com", Type: "unified", Status5h: "allowed", Utilization5h: 0.4}
+	src := NewRateLimitStore()
+	src.Record(recent)
+	src.Record(RateLimitRecord{Timestamp: now.Add(-8 * 24 * time.Hour), Source: "old", Type: "unified", Status5h: "allowed"})
+
+	dst := NewRateLimitStore()
+	if added := dst.MergeRecords(src.Records()); added != 1 {
+		t.Fatalf("added = %d, want 1", added)
+	}
+	if added := dst.MergeRecords([]RateLimitRecord{recent}); added != 0 {
+		t.Fatalf("duplicate merge added %d", added)
+	}
+	if u, ok := dst.Utilization5h("a@example.com"); !ok || u != 0.4 {
+		t.Fatalf("Utilization5h = %v, %v; want 0.4 after merge", u, ok)
+	}
+}
+
+func TestValidateRateLimitRecord(t *testing.T) {
+	now := time.Now()
+	valid := RateLimitRecord{Timestamp: now.Add(-time.Minute), Source: "a@example.com", Type: "unified", Status5h: "allowed", Utilization5h: 0.4}
+	if err := ValidateRateLimitRecord(valid, now); err != nil {
+		t.Fatalf("valid record rejected: %v", err)
+	}
+
+	cases := map[string]func(*RateLimitRecord){
+		"missing source":   func(r *RateLimitRecord) { r.Source = "" },
+		"unknown type":     func(r *RateLimitRecord) { r.Type = "weekly" },
+		"future timestamp": func(r *RateLimitRecord) { r.Timestamp = now.Add(time.Hour) },
+		"stale timestamp":  func(r *RateLimitRecord) { r.Timestamp = now.Add(-8 * 24 * time.Hour) },
+		"empty fields":     func(r *RateLimitRecord) { r.Status5h = "" },
+		"utilization > 1":  func(r *RateLimitRecord) { r.Utilization5h = 40 },
+		"remaining > limit": func(r *RateLimitRecord) {
+			r.Type, r.RequestsLimit, r.RequestsRemaining = "standard", 10, 11
+		},
+	}
+	for name, mutate := range cases {
+		record := valid
+		mutate(&record)
+		if err := ValidateRateLimitRecord(record, now); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestInjectRecordsReplacesSameSnapshot(t *testing.T) {
+	ts := time.Now().Add(-time.Hour)
+	store := NewRateLimitStore()
+	store.Record(RateLimitRecord{Timestamp: ts, Source: "a@example.com", Type: "unified", Status5h: "allowed", Utilization5h: 0.9})
+
+	added, replaced := store.InjectRecords([]RateLimitRecord{
+		{Timestamp: ts, Source: "a@example.com", Type: "unified", Status5h: "allowed", Utilization5h: 0.2},
+		{Timestamp: ts, Source: "b@example.com", Type: "unified", Status5h: "allowed", Utilization5h: 0.5},
+	})
+	if added != 1 || replaced != 1 {
+		t.Fatalf("added, replaced = %d, %d; want 1, 1", added, replaced)
+	}
+	if records := store.Records(); len(records) != 2 {
+		t.Fatalf("records = %d, want 2", len(records))
+	}
+	if u, ok := store.Utilization5h("a@example.com"); !ok || u != 0.2 {
+		t.Fatalf("Utilization5h = %v, %v; want corrected 0.2", u, ok)
+	}
+}
+
+func TestQueryByWindowMatchesRecords(t *testing.T) {
+	store := NewRateLimitStore()
+	now := time.Now()
+	var records []RateLimitRecord
+	for i := 0; i < 300; i++ {
+		r := RateLimitRecord{
+			Timestamp:      now.Add(-time.Duration(i*7) * time.Second),
+			Source:         []string{"a@example.com", "b@example.com", "c@example.com"}[i%3],
+			Type:           "unified",
+			OrganizationID: []string{"org-1", "org-2"}[i%2],
+			Status5h:       "allowed",
+		}
+		records = append(records, r)
+		store.Record(r)
+	}
+
+	for _, window := range []time.Duration{90 * time.Second, 5*time.Minute + 13*time.Second, time.Hour} {
+		cutoff := now.Add(-window)
+		wantSources := make(map[string]int64)
+		wantOrgs := make(map[string]int64)
+		var want int64
+		for _, r := range records {
+			if !r.Timestamp.Before(cutoff) {
+				want++
+				wantSources[r.Source]++
+				wantOrgs[r.OrganizationID]++
+			}
+		}
+
+		summary := store.QueryByWindow(window)
+		if summary.TotalRequests != want {
+			t.Fatalf("window %s: total = %d, want %d", window, summary.TotalRequests, want)
+		}
+		for source, n := range wantSources {
+			if got := summary.BySource[source].Requests; got != n {
+				t.Fatalf("window %s: %s = %d, want %d", window, source, got, n)
+			}
+		}
+		for org, n := range wantOrgs {
+			if got := summary.ByOrganization[org].Requests; got != n {
+				t.Fatalf("window %s: %s = %d, want %d", window, org, got, n)
+			}
+		}
+	}
+
+	if removed := store.Compact(RetentionPolicy{MaxAge: 10 * time.Minute}, now); removed == 0 {
+		t.Fatal("expected compaction to remove records")
+	}
+	if summary := store.QueryByWindow(time.Hour); summary.TotalRequests != int64(len(store.Records())) {
+		t.Fatalf("after compaction total = %d, records = %d", summary.TotalRequests, len(store.Records()))
+	}
+}
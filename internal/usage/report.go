@@ -0,0 +1,184 @@
+package usage
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AccountUsage summarises request volume, token consumption, estimated cost and the
+// latest rate-limit utilisation for a single upstream account (source).
+type AccountUsage struct {
+	Source        string     `json:"source"`
+	Requests      int64      `json:"requests"`
+	Failed        int64      `json:"failed"`
+	Tokens        TokenStats `json:"tokens"`
+	EstimatedCost float64    `json:"estimated_cost_usd"`
+
+	Utilization5h float64 `json:"utilization_5h"`
+	Status5h      string  `json:"status_5h,omitempty"`
+	Utilization7d float64 `json:"utilization_7d"`
+	Status7d      string  `json:"status_7d,omitempty"`
+	LastSeen      string  `json:"last_seen,omitempty"`
+}
+
+// modelPrice lưu giá (USD / 1M tokens) cho một model family.
+type modelPrice struct {
+	match  string
+	input  float64
+	output float64
+}
+
+// modelPrices là bảng giá list price dùng để ước tính chi phí.
+// Thứ tự quan trọng: pattern cụ thể hơn phải đứng trước.
+var modelPrices = []modelPrice{
+	{match: "opus-4-5", input: 5, output: 25},
+	{match: "opus-4-6", input: 5, output: 25},
+	{match: "opus", input: 15, output: 75},
+	{match: "sonnet", input: 3, output: 15},
+	{match: "haiku-4", input: 1, output: 5},
+	{match: "haiku-3-5", input: 0.8, output: 4},
+	{match: "haiku", input: 0.25, output: 1.25},
+}
+
+// cacheReadPriceRatio: cache read được tính bằng 10% giá input.
+const cacheReadPriceRatio = 0.1
+
+// cacheWritePriceRatio: cache write (5 phút) được tính bằng 125% giá input.
+const cacheWritePriceRatio = 1.25
+
+// EstimateCost ước tính chi phí USD của một request theo list price của model.
+// Trả về 0 cho model không có trong bảng giá.
+func EstimateCost(model string, tokens TokenStats) float64 {
+	lower := strings.ToLower(model)
+	for _, price := range modelPrices {
+		if !strings.Contains(lower, price.match) {
+			continue
+		}
+		cost := float64(tokens.InputTokens)*price.input +
+			float64(tokens.CachedTokens)*price.input*cacheReadPriceRatio +
+			float64(tokens.CacheCreationTokens)*price.input*cacheWritePriceRatio +
+			float64(tokens.OutputTokens)*price.output
+		return cost / 1_000_000
+	}
+	return 0
+}
+
+// BuildAccountReport gộp usage statistics theo source (account) và bổ sung
+// utilisation mới nhất từ rate limit summary. Kết quả được sort theo số request giảm dần.
+func BuildAccountReport(snapshot StatisticsSnapshot, limits WindowSummary) []AccountUsage {
+	accounts := make(map[string]*AccountUsage)
+	get := func(source string) *AccountUsage {
+		if source == "" {
+			source = "unknown"
+		}
+		acc, ok := accounts[source]
+		if !ok {
+			acc = &AccountUsage{Source: source}
+			accounts[source] = acc
+		}
+		return acc
+	}
+
+	lastSeen := make(map[string]time.Time)
+	for _, api := range snapshot.APIs {
+		for modelName, model := range api.Models {
+			for _, detail := range model.Details {
+				acc := get(detail.Source)
+				acc.Requests++
+				if detail.Failed {
+					acc.Failed++
+				}
+				acc.Tokens.InputTokens += detail.Tokens.InputTokens
+				acc.Tokens.OutputTokens += detail.Tokens.OutputTokens
+				acc.Tokens.ReasoningTokens += detail.Tokens.ReasoningTokens
+				acc.Tokens.CachedTokens += detail.Tokens.CachedTokens
+				acc.Tokens.CacheCreationTokens += detail.Tokens.CacheCreationTokens
+				acc.Tokens.TotalTokens += detail.Tokens.TotalTokens
+				acc.EstimatedCost += EstimateCost(modelName, detail.Tokens)
+				if detail.Timestamp.After(lastSeen[acc.Source]) {
+					lastSeen[acc.Source] = detail.Timestamp
+				}
+			}
+		}
+	}
+
+	for source, su := range limits.BySource {
+		acc := get(source)
+		if su.LatestLimit == nil {
+			continue
+		}
+		acc.Utilization5h = su.LatestLimit.Utilization5h
+		acc.Status5h = su.LatestLimit.Status5h
+		acc.Utilization7d = su.LatestLimit.Utilization7d
+		acc.Status7d = su.LatestLimit.Status7d
+		if su.LatestLimit.Timestamp.After(lastSeen[acc.Source]) {
+			lastSeen[acc.Source] = su.LatestLimit.Timestamp
+		}
+	}
+
+	report := make([]AccountUsage, 0, len(accounts))
+	for source, acc := range accounts {
+		if ts := lastSeen[source]; !ts.IsZero() {
+			acc.LastSeen = ts.UTC().Format(time.RFC3339)
+		}
+		report = append(report, *acc)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Requests != report[j].Requests {
+			return report[i].Requests > report[j].Requests
+		}
+		return report[i].Source < report[j].Source
+	})
+	return report
+}
+
+// SpillOverUsage tổng hợp các request bị chuyển sang API key (tính phí theo token) vì
+// các account subscription đã gần hết quota.
+type SpillOverUsage struct {
+	Requests      int64      `json:"requests"`
+	Tokens        TokenStats `json:"tokens"`
+	EstimatedCost float64    `json:"estimated_cost_usd"`
+}
+
+// BuildSpillOverReport cộng dồn volume và chi phí ước tính của các request spill-over.
+func BuildSpillOverReport(snapshot StatisticsSnapshot) SpillOverUsage {
+	var spill SpillOverUsage
+	for _, api := range snapshot.APIs {
+		for modelName, model := range api.Models {
+			for _, detail := range model.Details {
+				if !detail.SpillOver {
+					continue
+				}
+				spill.Requests++
+				spill.Tokens.InputTokens += detail.Tokens.InputTokens
+				spill.Tokens.OutputTokens += detail.Tokens.OutputTokens
+				spill.Tokens.ReasoningTokens += detail.Tokens.ReasoningTokens
+				spill.Tokens.CachedTokens += detail.Tokens.CachedTokens
+				spill.Tokens.CacheCreationTokens += detail.Tokens.CacheCreationTokens
+				spill.Tokens.TotalTokens += detail.Tokens.TotalTokens
+				spill.EstimatedCost += EstimateCost(modelName, detail.Tokens)
+			}
+		}
+	}
+	return spill
+}
+
+// ParseWindow parse time window dạng Go duration ("5h", "90m") hoặc số ngày ("7d").
+func ParseWindow(raw string) (time.Duration, error) {
+	raw = strings.TrimSpace(raw)
+	if strings.HasSuffix(raw, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(raw, "d"))
+		if err != nil || days <= 0 {
+			return 0, fmt.Errorf("invalid window %q", raw)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("invalid window %q", raw)
+	}
+	return d, nil
+}
@@ -0,0 +1,83 @@
+package usage
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestEstimateCost(t *testing.T) {
+	tokens := TokenStats{InputTokens: 1_000_000, OutputTokens: 1_000_000, CachedTokens: 1_000_000}
+	if got := EstimateCost("claude-sonnet-4-5-20250929", tokens); math.Abs(got-18.3) > 1e-9 {
+		t.Errorf("sonnet cost = %v, want 18.3", got)
+	}
+	if got := EstimateCost("claude-opus-4-5", TokenStats{OutputTokens: 1_000_000}); got != 25 {
+		t.Errorf("opus-4-5 cost = %v, want 25", got)
+	}
+	if got := EstimateCost("gpt-5", tokens); got != 0 {
+		t.Errorf("unknown model cost = %v, want 0", got)
+	}
+}
+
+func TestBuildAccountReport(t *testing.T) {
+	now := time.Now()
+	snapshot := StatisticsSnapshot{
+		APIs: map[string]APISnapshot{
+			"key-1": {Models: map[string]ModelSnapshot{
+				"claude-sonnet-4": {Details: []RequestDetail{
+					{Timestamp: now, Source: "a@example.com", Tokens: TokenStats{InputTokens: 10, OutputTokens: 5, TotalTokens: 15}},
+					{Timestamp: now, Source: "a@example.com", Failed: true},
+					{Timestamp: now, Source: "b@example.com", Tokens: TokenStats{InputTokens: 1, TotalTokens: 1}},
+				}},
+			}},
+		},
+	}
+	limits := WindowSummary{BySource: map[string]SourceUsage{
+		"a@example.com": {Requests: 1, LatestLimit: &RateLimitRecord{Timestamp: now, Utilization5h: 0.42, Status5h: "allowed"}},
+		"c@example.com": {Requests: 1, LatestLimit: &RateLimitRecord{Timestamp: now, Utilization7d: 0.9}},
+	}}
+
+	report := BuildAccountReport(snapshot, limits)
+	if len(report) != 3 {
+		t.Fatalf("expected 3 accounts, got %d", len(report))
+	}
+	first := report[0]
+	if first.Source != "a@example.com" || first.Requests != 2 || first.Failed != 1 {
+		t.Errorf("unexpected first account: %+v", first)
+	}
+	if first.Tokens.TotalTokens != 15 || first.Utilization5h != 0.42 || first.Status5h != "allowed" {
+		t.Errorf("unexpected first account usage: %+v", first)
+	}
+	if report[2].Source != "c@example.com" || report[2].Utilization7d != 0.9 {
+		t.Errorf("expected rate-limit-only account last, got %+v", report[2])
+	}
+}
+
+func TestBuildSpillOverReport(t *testing.T) {
+	snapshot := StatisticsSnapshot{
+		APIs: map[string]APISnapshot{
+			"key-1": {Models: map[string]ModelSnapshot{
+				"claude-sonnet-4": {Details: []RequestDetail{
+					{Source: "sk-ant-1", SpillOver: true, Tokens: TokenStats{InputTokens: 1_000_000, TotalTokens: 1_000_000}},
+					{Source: "a@example.com", Tokens: TokenStats{InputTokens: 5, TotalTokens: 5}},
+				}},
+			}},
+		},
+	}
+	spill := BuildSpillOverReport(snapshot)
+	if spill.Requests != 1 || spill.Tokens.TotalTokens != 1_000_000 || spill.EstimatedCost != 3 {
+		t.Fatalf("spill-over = %+v, want 1 request, 1M tokens, $3", spill)
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	if d, err := ParseWindow("7d"); err != nil || d != 7*24*time.Hour {
+		t.Errorf("ParseWindow(7d) = %v, %v", d, err)
+	}
+	if d, err := ParseWindow("5h"); err != nil || d != 5*time.Hour {
+		t.Errorf("ParseWindow(5h) = %v, %v", d, err)
+	}
+	if _, err := ParseWindow("abc"); err == nil {
+		t.Errorf("expected error for invalid window")
+	}
+}
@@ -0,0 +1,218 @@
+package usage
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultCompactionInterval is how often expired usage history is removed by default.
+const DefaultCompactionInterval = time.Hour
+
+// RetentionPolicy bounds the history kept by one usage store.
+type RetentionPolicy struct {
+	// MaxAge drops entries older than this. Zero keeps the store's default.
+	MaxAge time.Duration
+	// MaxRecords keeps at most this many entries, dropping the oldest first. Zero means no cap.
+	MaxRecords int
+}
+
+func retentionPolicyFromConfig(cfg config.StoreRetentionConfig) RetentionPolicy {
+	policy := RetentionPolicy{MaxRecords: cfg.MaxRecords}
+	if cfg.MaxAgeHours > 0 {
+		policy.MaxAge = time.Duration(cfg.MaxAgeHours) * time.Hour
+	}
+	if policy.MaxRecords < 0 {
+		policy.MaxRecords = 0
+	}
+	return policy
+}
+
+var (
+	retentionMu        sync.RWMutex
+	rateLimitRetention RetentionPolicy
+	requestRetention   RetentionPolicy
+	compactionInterval atomic.Int64
+	compactionWake     = make(chan struct{}, 1)
+)
+
+// rateLimitMaxAge returns how long rate-limit records are kept.
+func rateLimitMaxAge() time.Duration {
+	retentionMu.RLock()
+	defer retentionMu.RUnlock()
+	if rateLimitRetention.MaxAge > 0 {
+		return rateLimitRetention.MaxAge
+	}
+	return defaultRateLimitMaxAge
+}
+
+// ConfigureRetention applies cfg.UsageRetention to the shared usage stores. The
+// compaction loop of a running Lifecycle compacts them right away and then every
+// compaction interval.
+func ConfigureRetention(cfg *config.Config) {
+	var retention config.UsageRetentionConfig
+	if cfg != nil {
+		retention = cfg.UsageRetention
+	}
+	retentionMu.Lock()
+	rateLimitRetention = retentionPolicyFromConfig(retention.RateLimits)
+	requestRetention = retentionPolicyFromConfig(retention.Requests)
+	retentionMu.Unlock()
+
+	interval := DefaultCompactionInterval
+	if retention.CompactionIntervalSeconds > 0 {
+		interval = time.Duration(retention.CompactionIntervalSeconds) * time.Second
+	}
+	compactionInterval.Store(int64(interval))
+
+	select {
+	case compactionWake <- struct{}{}:
+	default:
+	}
+}
+
+// runCompaction compacts the shared stores whenever the interval elapses or the
+// retention configuration changes, until ctx ends.
+func runCompaction(ctx context.Context) {
+	for {
+		interval := time.Duration(compactionInterval.Load())
+		if interval <= 0 {
+			interval = DefaultCompactionInterval
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		case <-compactionWake:
+			timer.Stop()
+		}
+		CompactUsageStores(time.Now())
+	}
+}
+
+// CompactUsageStores applies the configured retention to the shared rate-limit store
+// and usage statistics, and returns how many entries were removed.
+func CompactUsageStores(now time.Time) int {
+	retentionMu.RLock()
+	rateLimits, requests := rateLimitRetention, requestRetention
+	retentionMu.RUnlock()
+	if rateLimits.MaxAge <= 0 {
+		rateLimits.MaxAge = defaultRateLimitMaxAge
+	}
+
+	removedLimits := defaultRateLimitStore.Compact(rateLimits, now)
+	removedDetails := defaultRequestStatistics.Compact(requests, now)
+	if removedLimits > 0 || removedDetails > 0 {
+		log.Debugf("usage retention: removed %d rate-limit records and %d request details", removedLimits, removedDetails)
+	}
+	return removedLimits + removedDetails
+}
+
+// Compact drops records older than policy.MaxAge and, beyond policy.MaxRecords, the
+// oldest records across all sources. It returns how many records were removed.
+func (s *RateLimitStore) Compact(policy RetentionPolicy, now time.Time) int {
+	if s == nil {
+		return 0
+	}
+	var cutoff time.Time
+	if policy.MaxAge > 0 {
+		cutoff = now.Add(-policy.MaxAge)
+	}
+	if policy.MaxRecords > 0 {
+		var timestamps []time.Time
+		s.viewSources(func(_ string, src *sourceRateLimits) {
+			for _, r := range src.records {
+				if r.Timestamp.After(cutoff) {
+					timestamps = append(timestamps, r.Timestamp)
+				}
+			}
+		})
+		if len(timestamps) > policy.MaxRecords {
+			sort.Slice(timestamps, func(i, j int) bool { return timestamps[i].Before(timestamps[j]) })
+			// Keep records newer than the newest one to drop; ties at that instant go too.
+			cutoff = timestamps[len(timestamps)-policy.MaxRecords-1]
+		}
+	}
+	removed := 0
+	if !cutoff.IsZero() {
+		s.updateSources(func(_ string, src *sourceRateLimits) {
+			removed += src.dropThrough(cutoff)
+		})
+	}
+
+	if removed > 0 && s == defaultRateLimitStore {
+		rateLimitPersister.markDirty()
+	}
+	return removed
+}
+
+// Compact drops per-request details older than policy.MaxAge and, beyond
+// policy.MaxRecords, the oldest details across all API keys and models. Request and
+// token totals and the per-day and per-hour aggregates are kept. It returns how many
+// details were removed.
+func (s *RequestStatistics) Compact(policy RetentionPolicy, now time.Time) int {
+	if s == nil || (policy.MaxAge <= 0 && policy.MaxRecords <= 0) {
+		return 0
+	}
+	s.mu.Lock()
+	var cutoff time.Time
+	if policy.MaxAge > 0 {
+		cutoff = now.Add(-policy.MaxAge)
+	}
+	if policy.MaxRecords > 0 {
+		var timestamps []time.Time
+		s.forEachModelLocked(func(stats *modelStats) {
+			for _, detail := range stats.Details {
+				if detail.Timestamp.After(cutoff) {
+					timestamps = append(timestamps, detail.Timestamp)
+				}
+			}
+		})
+		if len(timestamps) > policy.MaxRecords {
+			sort.Slice(timestamps, func(i, j int) bool { return timestamps[i].Before(timestamps[j]) })
+			// Keep details newer than the newest one to drop; ties at that instant go too.
+			cutoff = timestamps[len(timestamps)-policy.MaxRecords-1]
+		}
+	}
+	removed := 0
+	if !cutoff.IsZero() {
+		s.forEachModelLocked(func(stats *modelStats) {
+			kept := stats.Details[:0]
+			for _, detail := range stats.Details {
+				if detail.Timestamp.After(cutoff) {
+					kept = append(kept, detail)
+				}
+			}
+			removed += len(stats.Details) - len(kept)
+			stats.Details = kept
+		})
+	}
+	s.mu.Unlock()
+
+	if removed > 0 && s == defaultRequestStatistics {
+		statsPersister.markDirty()
+	}
+	return removed
+}
+
+// forEachModelLocked calls fn for the statistics of every API key and model. Must be
+// called with the lock held.
+func (s *RequestStatistics) forEachModelLocked(fn func(*modelStats)) {
+	for _, stats := range s.apis {
+		if stats == nil {
+			continue
+		}
+		for _, modelStatsValue := range stats.Models {
+			if modelStatsValue != nil {
+				fn(modelStatsValue)
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestRateLimitStoreCompact(t *testing.T) {
+	now := time.Now()
+	store := NewRateLimitStore()
+	for i, age := range []time.Duration{30 * time.Hour, 3 * time.Hour, 2 * time.Hour, time.Hour} {
+		store.Record(RateLimitRecord{Timestamp: now.Add(-age), Source: "a@example.com", Type: "unified", Status5h: "allowed", Utilization5h: float64(i) / 10})
+	}
+
+	if removed := store.Compact(RetentionPolicy{MaxAge: 24 * time.Hour, MaxRecords: 2}, now); removed != 2 {
+		t.Fatalf("removed = %d, want 2", removed)
+	}
+	records := store.Records()
+	if len(records) != 2 || !records[0].Timestamp.Equal(now.Add(-2*time.Hour)) {
+		t.Fatalf("records = %+v, want the two newest", records)
+	}
+	if u, ok := store.Utilization5h("a@example.com"); !ok || u != 0.3 {
+		t.Fatalf("Utilization5h = %v, %v; want 0.3", u, ok)
+	}
+}
+
+func TestRequestStatisticsCompactKeepsTotals(t *testing.T) {
+	now := time.Now()
+	stats := NewRequestStatistics()
+	for _, age := range []time.Duration{48 * time.Hour, 3 * time.Hour, 2 * time.Hour, time.Hour} {
+		stats.Record(context.Background(), coreusage.Record{APIKey: "key", Model: "m", RequestedAt: now.Add(-age), Detail: coreusage.Detail{TotalTokens: 10}})
+	}
+
+	if removed := stats.Compact(RetentionPolicy{}, now); removed != 0 {
+		t.Fatalf("default policy removed %d details", removed)
+	}
+	if removed := stats.Compact(RetentionPolicy{MaxAge: 24 * time.Hour, MaxRecords: 2}, now); removed != 2 {
+		t.Fatalf("removed = %d, want 2", removed)
+	}
+	snapshot := stats.Snapshot()
+	details := snapshot.APIs["key"].Models["m"].Details
+	if len(details) != 2 || !details[0].Timestamp.Equal(now.Add(-2*time.Hour)) {
+		t.Fatalf("details = %+v, want the two newest", details)
+	}
+	if snapshot.TotalRequests != 4 || snapshot.TotalTokens != 40 {
+		t.Fatalf("totals = %d requests, %d tokens; want 4, 40", snapshot.TotalRequests, snapshot.TotalTokens)
+	}
+}
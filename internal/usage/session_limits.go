@@ -0,0 +1,151 @@
+package usage
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+const (
+	// sessionTurnWindow is the sliding window of the per-session turn limit.
+	sessionTurnWindow = time.Hour
+	// sessionIdleTTL is how long an idle session is remembered. It outlasts the 5h
+	// account window so a paused loop cannot reset its token budget by waiting.
+	sessionIdleTTL = 6 * time.Hour
+	// sessionPruneInterval bounds how often idle sessions are swept.
+	sessionPruneInterval = time.Minute
+)
+
+// Session limit names reported in a SessionLimitExceeded.
+const (
+	SessionLimitTurns  = "max-turns-per-hour"
+	SessionLimitTokens = "max-tokens-per-session"
+)
+
+// SessionLimitExceeded describes why a conversation was refused.
+type SessionLimitExceeded struct {
+	// Limit is SessionLimitTurns or SessionLimitTokens.
+	Limit string
+	// Used is the turns in the last hour or the tokens spent so far.
+	Used int64
+	// Max is the configured limit.
+	Max int64
+	// RetryAfter is when the turn limit frees a slot; zero for the token limit.
+	RetryAfter time.Duration
+}
+
+type sessionUsage struct {
+	turns    []time.Time
+	tokens   int64
+	lastSeen time.Time
+}
+
+// SessionLimitStore counts turns and tokens per client conversation. It receives token
+// usage as a usage plugin, attributed through coreusage.WithSession.
+type SessionLimitStore struct {
+	mu        sync.Mutex
+	sessions  map[string]*sessionUsage
+	lastPrune time.Time
+	now       func() time.Time
+}
+
+var defaultSessionLimitStore = NewSessionLimitStore()
+
+func init() {
+	coreusage.RegisterPlugin(defaultSessionLimitStore)
+}
+
+// GetSessionLimitStore returns the shared session limit store.
+func GetSessionLimitStore() *SessionLimitStore { return defaultSessionLimitStore }
+
+// NewSessionLimitStore creates an empty session limit store.
+func NewSessionLimitStore() *SessionLimitStore {
+	return &SessionLimitStore{sessions: make(map[string]*sessionUsage), now: time.Now}
+}
+
+// Admit checks session against the limits and, when it is within them, counts a new
+// turn. A zero limit is not enforced.
+func (s *SessionLimitStore) Admit(session string, maxTurnsPerHour int, maxTokens int64) (SessionLimitExceeded, bool) {
+	if s == nil || session == "" || (maxTurnsPerHour <= 0 && maxTokens <= 0) {
+		return SessionLimitExceeded{}, true
+	}
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(now)
+
+	entry := s.sessions[session]
+	if entry == nil {
+		entry = &sessionUsage{}
+		s.sessions[session] = entry
+	}
+	entry.lastSeen = now
+	cutoff := now.Add(-sessionTurnWindow)
+	kept := entry.turns[:0]
+	for _, turn := range entry.turns {
+		if turn.After(cutoff) {
+			kept = append(kept, turn)
+		}
+	}
+	entry.turns = kept
+
+	if maxTokens > 0 && entry.tokens >= maxTokens {
+		return SessionLimitExceeded{Limit: SessionLimitTokens, Used: entry.tokens, Max: maxTokens}, false
+	}
+	if maxTurnsPerHour > 0 && len(entry.turns) >= maxTurnsPerHour {
+		retry := entry.turns[len(entry.turns)-maxTurnsPerHour].Add(sessionTurnWindow).Sub(now)
+		return SessionLimitExceeded{Limit: SessionLimitTurns, Used: int64(len(entry.turns)), Max: int64(maxTurnsPerHour), RetryAfter: retry}, false
+	}
+	entry.turns = append(entry.turns, now)
+	return SessionLimitExceeded{}, true
+}
+
+// HandleUsage implements coreusage.Plugin, adding the tokens of records that carry a
+// session to that session.
+func (s *SessionLimitStore) HandleUsage(_ context.Context, record coreusage.Record) {
+	if s == nil || record.Session == "" {
+		return
+	}
+	tokens := record.Detail.TotalTokens
+	if tokens == 0 {
+		tokens = record.Detail.InputTokens + record.Detail.OutputTokens + record.Detail.ReasoningTokens
+	}
+	if tokens <= 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry := s.sessions[record.Session]
+	if entry == nil {
+		entry = &sessionUsage{lastSeen: s.now()}
+		s.sessions[record.Session] = entry
+	}
+	entry.tokens += tokens
+}
+
+// Tokens returns the tokens counted for session.
+func (s *SessionLimitStore) Tokens(session string) int64 {
+	if s == nil {
+		return 0
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entry := s.sessions[session]; entry != nil {
+		return entry.tokens
+	}
+	return 0
+}
+
+func (s *SessionLimitStore) pruneLocked(now time.Time) {
+	if now.Sub(s.lastPrune) < sessionPruneInterval {
+		return
+	}
+	s.lastPrune = now
+	for key, entry := range s.sessions {
+		if now.Sub(entry.lastSeen) > sessionIdleTTL {
+			delete(s.sessions, key)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+)
+
+func TestSessionLimitStoreAdmit(t *testing.T) {
+	store := NewSessionLimitStore()
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		if _, ok := store.Admit("s", 3, 0); !ok {
+			t.Fatalf("turn %d should be admitted", i+1)
+		}
+		now = now.Add(10 * time.Minute)
+	}
+	exceeded, ok := store.Admit("s", 3, 0)
+	if ok || exceeded.Limit != SessionLimitTurns || exceeded.Used != 3 || exceeded.RetryAfter != 30*time.Minute {
+		t.Fatalf("fourth turn: %+v, %v", exceeded, ok)
+	}
+	if _, ok = store.Admit("other", 3, 0); !ok {
+		t.Fatal("other sessions should not be limited")
+	}
+	now = now.Add(30 * time.Minute)
+	if _, ok = store.Admit("s", 3, 0); !ok {
+		t.Fatal("turn should be admitted once the oldest leaves the window")
+	}
+
+	store.HandleUsage(context.Background(), coreusage.Record{Session: "s", Detail: coreusage.Detail{InputTokens: 600, OutputTokens: 500}})
+	store.HandleUsage(context.Background(), coreusage.Record{Detail: coreusage.Detail{TotalTokens: 5000}})
+	if got := store.Tokens("s"); got != 1100 {
+		t.Fatalf("tokens = %d, want 1100", got)
+	}
+	exceeded, ok = store.Admit("s", 0, 1000)
+	if ok || exceeded.Limit != SessionLimitTokens || exceeded.Used != 1100 || exceeded.RetryAfter != 0 {
+		t.Fatalf("token limit: %+v, %v", exceeded, ok)
+	}
+}
+
+func TestSessionLimitStorePrunesIdleSessions(t *testing.T) {
+	store := NewSessionLimitStore()
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	store.Admit("idle", 1, 0)
+	store.HandleUsage(context.Background(), coreusage.Record{Session: "idle", Detail: coreusage.Detail{TotalTokens: 10}})
+	now = now.Add(sessionIdleTTL + time.Minute)
+	store.Admit("active", 1, 0)
+	if got := store.Tokens("idle"); got != 0 {
+		t.Fatalf("idle session should be forgotten, tokens = %d", got)
+	}
+}
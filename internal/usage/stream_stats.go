@@ -0,0 +1,267 @@
+package usage
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// StreamStatsStore tracks the output rate of active streams and, per model, the output
+// rate of finished streams and how often streams stalled.
+type StreamStatsStore struct {
+	mu     sync.Mutex
+	nextID uint64
+	active map[uint64]*StreamTracker
+	models map[string]*streamModelStats
+	now    func() time.Time
+}
+
+type streamModelStats struct {
+	streams           int64
+	stalls            int64
+	outputTokens      int64
+	generationSeconds float64
+}
+
+// StreamTracker follows one client stream. Its methods are safe to call on a nil tracker.
+type StreamTracker struct {
+	store     *StreamStatsStore
+	id        uint64
+	model     string
+	apiKey    string
+	startedAt time.Time
+	firstAt   time.Time
+	lastAt    time.Time
+	chunks    int64
+	tokens    int64
+	stalls    int64
+}
+
+// ActiveStream is a snapshot of a stream in progress.
+type ActiveStream struct {
+	ID              uint64    `json:"id"`
+	Model           string    `json:"model"`
+	APIKey          string    `json:"api_key,omitempty"`
+	StartedAt       time.Time `json:"started_at"`
+	ElapsedSeconds  float64   `json:"elapsed_seconds"`
+	IdleSeconds     float64   `json:"idle_seconds"`
+	Chunks          int64     `json:"chunks"`
+	OutputTokens    int64     `json:"estimated_output_tokens"`
+	TokensPerSecond float64   `json:"tokens_per_second"`
+	Stalls          int64     `json:"stalls,omitempty"`
+}
+
+// StreamModelSummary summarises the finished streams of one model.
+type StreamModelSummary struct {
+	Model           string  `json:"model"`
+	Streams         int64   `json:"streams"`
+	Stalls          int64   `json:"stalls"`
+	OutputTokens    int64   `json:"estimated_output_tokens"`
+	TokensPerSecond float64 `json:"tokens_per_second"`
+}
+
+var defaultStreamStatsStore = NewStreamStatsStore()
+
+// GetStreamStatsStore returns the shared stream statistics store.
+func GetStreamStatsStore() *StreamStatsStore { return defaultStreamStatsStore }
+
+// NewStreamStatsStore creates an empty stream statistics store.
+func NewStreamStatsStore() *StreamStatsStore {
+	return &StreamStatsStore{
+		active: make(map[uint64]*StreamTracker),
+		models: make(map[string]*streamModelStats),
+		now:    time.Now,
+	}
+}
+
+// Start registers a new active stream. apiKey should already be masked.
+func (s *StreamStatsStore) Start(model, apiKey string) *StreamTracker {
+	if s == nil {
+		return nil
+	}
+	if model == "" {
+		model = "unknown"
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	tracker := &StreamTracker{store: s, id: s.nextID, model: model, apiKey: apiKey, startedAt: s.now()}
+	s.active[tracker.id] = tracker
+	return tracker
+}
+
+// Observe records a chunk received from upstream carrying an estimated number of output tokens.
+func (t *StreamTracker) Observe(tokens int64) {
+	if t == nil {
+		return
+	}
+	s := t.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if t.firstAt.IsZero() {
+		t.firstAt = now
+	}
+	t.lastAt = now
+	t.chunks++
+	t.tokens += tokens
+}
+
+// Stalled records that the stream went silent for longer than the stall timeout.
+func (t *StreamTracker) Stalled() {
+	if t == nil {
+		return
+	}
+	s := t.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t.stalls++
+	s.modelLocked(t.model).stalls++
+}
+
+// Finish removes the stream from the active set and adds its output rate to the model summary.
+func (t *StreamTracker) Finish() {
+	if t == nil {
+		return
+	}
+	s := t.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.active[t.id]; !ok {
+		return
+	}
+	delete(s.active, t.id)
+	stats := s.modelLocked(t.model)
+	stats.streams++
+	stats.outputTokens += t.tokens
+	stats.generationSeconds += t.generationTime().Seconds()
+}
+
+// generationTime is the time from the first to the latest upstream chunk, which excludes
+// the time to first byte from the output rate.
+func (t *StreamTracker) generationTime() time.Duration {
+	if t.firstAt.IsZero() {
+		return 0
+	}
+	return t.lastAt.Sub(t.firstAt)
+}
+
+func (s *StreamStatsStore) modelLocked(model string) *streamModelStats {
+	stats, ok := s.models[model]
+	if !ok {
+		stats = &streamModelStats{}
+		s.models[model] = stats
+	}
+	return stats
+}
+
+func tokensPerSecond(tokens int64, seconds float64) float64 {
+	if seconds <= 0 {
+		return 0
+	}
+	return float64(tokens) / seconds
+}
+
+// Active returns the streams in progress, oldest first.
+func (s *StreamStatsStore) Active() []ActiveStream {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	out := make([]ActiveStream, 0, len(s.active))
+	for _, t := range s.active {
+		last := t.lastAt
+		if last.IsZero() {
+			last = t.startedAt
+		}
+		out = append(out, ActiveStream{
+			ID:              t.id,
+			Model:           t.model,
+			APIKey:          t.apiKey,
+			StartedAt:       t.startedAt,
+			ElapsedSeconds:  now.Sub(t.startedAt).Seconds(),
+			IdleSeconds:     now.Sub(last).Seconds(),
+			Chunks:          t.chunks,
+			OutputTokens:    t.tokens,
+			TokensPerSecond: tokensPerSecond(t.tokens, t.generationTime().Seconds()),
+			Stalls:          t.stalls,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Summaries returns the per-model summaries sorted by model.
+func (s *StreamStatsStore) Summaries() []StreamModelSummary {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]StreamModelSummary, 0, len(s.models))
+	for model, stats := range s.models {
+		out = append(out, StreamModelSummary{
+			Model:           model,
+			Streams:         stats.streams,
+			Stalls:          stats.stalls,
+			OutputTokens:    stats.outputTokens,
+			TokensPerSecond: tokensPerSecond(stats.outputTokens, stats.generationSeconds),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out
+}
+
+// Reset discards the per-model summaries. Active streams are kept.
+func (s *StreamStatsStore) Reset() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.models = make(map[string]*streamModelStats)
+	s.mu.Unlock()
+}
+
+// WritePrometheus writes cliproxy_active_streams and, per model, the stall and output
+// counters. The output rate is rate(cliproxy_stream_output_tokens_total) divided by
+// rate(cliproxy_stream_generation_seconds_total).
+func (s *StreamStatsStore) WritePrometheus(w io.Writer) error {
+	if s == nil {
+		return nil
+	}
+	summaries := s.Summaries()
+	s.mu.Lock()
+	active := len(s.active)
+	generation := make(map[string]float64, len(s.models))
+	for model, stats := range s.models {
+		generation[model] = stats.generationSeconds
+	}
+	s.mu.Unlock()
+
+	if _, err := fmt.Fprintf(w, "# HELP cliproxy_active_streams Client streams in progress.\n# TYPE cliproxy_active_streams gauge\ncliproxy_active_streams %d\n", active); err != nil {
+		return err
+	}
+	metrics := []struct {
+		name, help, kind string
+		value            func(StreamModelSummary) string
+	}{
+		{"cliproxy_stream_stalls_total", "Streams that received no upstream data within the stall timeout.", "counter", func(m StreamModelSummary) string { return fmt.Sprint(m.Stalls) }},
+		{"cliproxy_stream_output_tokens_total", "Estimated output tokens of finished streams.", "counter", func(m StreamModelSummary) string { return fmt.Sprint(m.OutputTokens) }},
+		{"cliproxy_stream_generation_seconds_total", "Time finished streams spent generating output, from first to last chunk.", "counter", func(m StreamModelSummary) string { return fmt.Sprintf("%g", generation[m.Model]) }},
+	}
+	for _, metric := range metrics {
+		if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", metric.name, metric.help, metric.name, metric.kind); err != nil {
+			return err
+		}
+		for _, summary := range summaries {
+			if _, err := fmt.Fprintf(w, "%s{model=%q} %s\n", metric.name, summary.Model, metric.value(summary)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package usage
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamStatsStore(t *testing.T) {
+	store := NewStreamStatsStore()
+	now := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+
+	tracker := store.Start("m", "sk-...abcd")
+	now = now.Add(2 * time.Second)
+	tracker.Observe(10)
+	now = now.Add(4 * time.Second)
+	tracker.Observe(190)
+	tracker.Stalled()
+
+	active := store.Active()
+	if len(active) != 1 || active[0].OutputTokens != 200 || active[0].TokensPerSecond != 50 || active[0].Stalls != 1 {
+		t.Fatalf("active = %+v", active)
+	}
+
+	tracker.Finish()
+	tracker.Finish()
+	if len(store.Active()) != 0 {
+		t.Fatal("finished stream should not be active")
+	}
+	summaries := store.Summaries()
+	if len(summaries) != 1 || summaries[0].Streams != 1 || summaries[0].Stalls != 1 || summaries[0].TokensPerSecond != 50 {
+		t.Fatalf("summaries = %+v", summaries)
+	}
+
+	var buf bytes.Buffer
+	if err := store.WritePrometheus(&buf); err != nil {
+		t.Fatalf("WritePrometheus: %v", err)
+	}
+	for _, want := range []string{"cliproxy_active_streams 0", `cliproxy_stream_stalls_total{model="m"} 1`, `cliproxy_stream_output_tokens_total{model="m"} 200`, `cliproxy_stream_generation_seconds_total{model="m"} 4`} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("metrics missing %q:\n%s", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package util
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// routeRegexCache holds compiled model-routing expressions keyed by their source pattern.
+var routeRegexCache sync.Map // map[string]*regexp.Regexp (nil value for invalid patterns)
+
+// ResolveModelRoute returns the model a request for modelName should be routed to when
+// modelName itself has no providers. Rules are tried in order, then the default route when
+// the unknown-model policy is "default"; a candidate is only accepted when its base model
+// (without thinking suffix) is served.
+// The second return value reports whether a route was found.
+func ResolveModelRoute(routing config.ModelRoutingConfig, modelName string) (string, bool) {
+	modelName = strings.TrimSpace(modelName)
+	if modelName == "" {
+		return "", false
+	}
+	for _, rule := range routing.Rules {
+		target := strings.TrimSpace(rule.Target)
+		if target == "" || !modelRouteMatches(rule, modelName) {
+			continue
+		}
+		if routeTargetServed(target) {
+			log.Debugf("model routing: %s -> %s (rule %q)", modelName, target, rule.Match)
+			return target, true
+		}
+		log.Debugf("model routing: %s matched %q but target %s has no providers", modelName, rule.Match, target)
+	}
+	if routing.UnknownModelPolicy() != config.UnknownModelDefault {
+		return "", false
+	}
+	if target := strings.TrimSpace(routing.Default); routeTargetServed(target) {
+		log.Debugf("model routing: %s -> %s (default)", modelName, target)
+		return target, true
+	}
+	return "", false
+}
+
+func modelRouteMatches(rule config.ModelRouteRule, modelName string) bool {
+	pattern := strings.TrimSpace(rule.Match)
+	if pattern == "" {
+		return false
+	}
+	if !rule.Regex {
+		return matchWildcardFold(pattern, modelName)
+	}
+	cached, ok := routeRegexCache.Load(pattern)
+	if !ok {
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			log.Warnf("model routing: invalid regex %q: %v", pattern, err)
+			re = nil
+		}
+		cached, _ = routeRegexCache.LoadOrStore(pattern, re)
+	}
+	re, _ := cached.(*regexp.Regexp)
+	return re != nil && re.MatchString(modelName)
+}
+
+// routeTargetServed reports whether the target, minus any "(suffix)", has providers.
+func routeTargetServed(target string) bool {
+	base := target
+	if idx := strings.LastIndex(base, "("); idx > 0 && strings.HasSuffix(base, ")") {
+		base = base[:idx]
+	}
+	return len(GetProviderName(strings.TrimSpace(base))) > 0
+}
+
+// MatchModelPattern reports whether model matches pattern case-insensitively, where '*'
+// matches zero or more characters.
+func MatchModelPattern(pattern, model string) bool {
+	return matchWildcardFold(strings.TrimSpace(pattern), strings.TrimSpace(model))
+}
+
+// matchWildcardFold matches value against pattern case-insensitively, where '*'
+// matches zero or more characters.
+func matchWildcardFold(pattern, value string) bool {
+	pattern = strings.ToLower(pattern)
+	value = strings.ToLower(value)
+	if !strings.Contains(pattern, "*") {
+		return pattern == value
+	}
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(value, part)
+		if idx < 0 {
+			return false
+		}
+		value = value[idx+len(part):]
+	}
+	return strings.HasSuffix(value, last)
+}
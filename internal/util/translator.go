@@ -6,6 +6,7 @@ package util
 import (
 	"bytes"
 	"fmt"
+	"strings"
 
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -219,3 +220,70 @@ func FixJSON(input string) string {
 
 	return out.String()
 }
+
+// ApplyMessageNames folds OpenAI chat message "name" fields into message content.
+// Upstreams without a per-message speaker field would otherwise drop the name, so
+// the name is encoded as a "[name]: " prefix on the message's first text content and
+// the "name" field is removed. The prefix is not added twice, which keeps the
+// encoding stable when a client replays history that already carries it.
+//
+// Parameters:
+//   - rawJSON: The OpenAI Chat Completions request body
+//
+// Returns:
+//   - []byte: The request body with names folded into content
+func ApplyMessageNames(rawJSON []byte) []byte {
+	messages := gjson.GetBytes(rawJSON, "messages")
+	if !messages.IsArray() {
+		return rawJSON
+	}
+
+	out := rawJSON
+	for i, message := range messages.Array() {
+		name := message.Get("name")
+		if !name.Exists() {
+			continue
+		}
+		role := message.Get("role").String()
+		if role != "user" && role != "assistant" {
+			continue
+		}
+		prefix := fmt.Sprintf("[%s]: ", name.String())
+		content := message.Get("content")
+		switch {
+		case name.String() == "":
+		case content.Type == gjson.String:
+			text := content.String()
+			if !strings.HasPrefix(text, prefix) {
+				out, _ = sjson.SetBytes(out, fmt.Sprintf("messages.%d.content", i), prefix+text)
+			}
+		case content.IsArray():
+			textIdx := -1
+			content.ForEach(func(key, part gjson.Result) bool {
+				if part.Get("type").String() == "text" {
+					textIdx = int(key.Int())
+					return false
+				}
+				return true
+			})
+			if textIdx >= 0 {
+				text := content.Get(fmt.Sprintf("%d.text", textIdx)).String()
+				if !strings.HasPrefix(text, prefix) {
+					out, _ = sjson.SetBytes(out, fmt.Sprintf("messages.%d.content.%d.text", i, textIdx), prefix+text)
+				}
+			} else {
+				// No text part (e.g. image-only content): prepend a dedicated speaker part.
+				part := `{"type":"text","text":""}`
+				part, _ = sjson.Set(part, "text", strings.TrimSuffix(prefix, " "))
+				parts := []string{part}
+				content.ForEach(func(_, existing gjson.Result) bool {
+					parts = append(parts, existing.Raw)
+					return true
+				})
+				out, _ = sjson.SetRawBytes(out, fmt.Sprintf("messages.%d.content", i), []byte("["+strings.Join(parts, ",")+"]"))
+			}
+		}
+		out, _ = sjson.DeleteBytes(out, fmt.Sprintf("messages.%d.name", i))
+	}
+	return out
+}
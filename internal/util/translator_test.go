@@ -0,0 +1,40 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyMessageNames(t *testing.T) {
+	input := []byte(`{"messages":[
+		{"role":"system","name":"ignored","content":"sys"},
+		{"role":"user","name":"alice","content":"hello"},
+		{"role":"assistant","name":"planner","content":[{"type":"text","text":"plan"}]},
+		{"role":"user","name":"bob","content":[{"type":"image_url","image_url":{"url":"data:image/png;base64,AA"}}]},
+		{"role":"user","name":"alice","content":"[alice]: replayed"}
+	]}`)
+
+	out := gjson.ParseBytes(ApplyMessageNames(input))
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"messages.0.content", "sys"},
+		{"messages.0.name", "ignored"},
+		{"messages.1.content", "[alice]: hello"},
+		{"messages.2.content.0.text", "[planner]: plan"},
+		{"messages.3.content.0.text", "[bob]:"},
+		{"messages.3.content.1.type", "image_url"},
+		{"messages.4.content", "[alice]: replayed"},
+	}
+	for _, tt := range tests {
+		if got := out.Get(tt.path).String(); got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+	if out.Get("messages.1.name").Exists() {
+		t.Errorf("expected name to be removed from user message")
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/secrets"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher/diff"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
@@ -71,33 +72,7 @@ func (w *Watcher) reloadClients(rescanAuth bool, affectedOAuthProviders []string
 	}
 
 	if rescanAuth {
-		w.clientsMutex.Lock()
-
-		w.lastAuthHashes = make(map[string]string)
-		w.lastAuthContents = make(map[string]*coreauth.Auth)
-		if resolvedAuthDir, errResolveAuthDir := util.ResolveAuthDir(cfg.AuthDir); errResolveAuthDir != nil {
-			log.Errorf("failed to resolve auth directory for hash cache: %v", errResolveAuthDir)
-		} else if resolvedAuthDir != "" {
-			_ = filepath.Walk(resolvedAuthDir, func(path string, info fs.FileInfo, err error) error {
-				if err != nil {
-					return nil
-				}
-				if !info.IsDir() && strings.HasSuffix(strings.ToLower(info.Name()), ".json") {
-					if data, errReadFile := os.ReadFile(path); errReadFile == nil && len(data) > 0 {
-						sum := sha256.Sum256(data)
-						normalizedPath := w.normalizeAuthPath(path)
-						w.lastAuthHashes[normalizedPath] = hex.EncodeToString(sum[:])
-						// Parse and cache auth content for future diff comparisons
-						var auth coreauth.Auth
-						if errParse := json.Unmarshal(data, &auth); errParse == nil {
-							w.lastAuthContents[normalizedPath] = &auth
-						}
-					}
-				}
-				return nil
-			})
-		}
-		w.clientsMutex.Unlock()
+		w.rebuildAuthHashCache(cfg)
 	}
 
 	totalNewClients := authFileCount + geminiAPIKeyCount + vertexCompatAPIKeyCount + claudeAPIKeyCount + codexAPIKeyCount + openAICompatCount
@@ -120,8 +95,75 @@ func (w *Watcher) reloadClients(rescanAuth bool, affectedOAuthProviders []string
 	)
 }
 
+// rebuildAuthHashCache re-reads the auth directory into the hash and content caches used
+// to detect changes to individual auth files.
+func (w *Watcher) rebuildAuthHashCache(cfg *config.Config) {
+	w.clientsMutex.Lock()
+	w.lastAuthHashes = make(map[string]string)
+	w.lastAuthContents = make(map[string]*coreauth.Auth)
+	if resolvedAuthDir, errResolveAuthDir := util.ResolveAuthDir(cfg.AuthDir); errResolveAuthDir != nil {
+		log.Errorf("failed to resolve auth directory for hash cache: %v", errResolveAuthDir)
+	} else if resolvedAuthDir != "" {
+		_ = filepath.Walk(resolvedAuthDir, func(path string, info fs.FileInfo, err error) error {
+			if err != nil {
+				return nil
+			}
+			if !info.IsDir() && strings.HasSuffix(strings.ToLower(info.Name()), ".json") {
+				if data, errReadFile := secrets.ReadFile(path); errReadFile == nil && len(data) > 0 {
+					sum := sha256.Sum256(data)
+					normalizedPath := w.normalizeAuthPath(path)
+					w.lastAuthHashes[normalizedPath] = hex.EncodeToString(sum[:])
+					// Parse and cache auth content for future diff comparisons
+					var auth coreauth.Auth
+					if errParse := json.Unmarshal(data, &auth); errParse == nil {
+						w.lastAuthContents[normalizedPath] = &auth
+					}
+				}
+			}
+			return nil
+		})
+	}
+	w.clientsMutex.Unlock()
+}
+
+// AuthRescanResult summarises the credential changes applied by RescanAuths.
+type AuthRescanResult struct {
+	Added   int `json:"added"`
+	Updated int `json:"updated"`
+	Removed int `json:"removed"`
+}
+
+// RescanAuths re-reads the auth directory and applies added, changed and removed token
+// files to the credential pool without reloading the configuration. It complements the
+// file watcher for filesystems that do not deliver change events, such as network mounts.
+func (w *Watcher) RescanAuths() AuthRescanResult {
+	var result AuthRescanResult
+	w.clientsMutex.RLock()
+	cfg := w.config
+	w.clientsMutex.RUnlock()
+	if cfg == nil {
+		log.Error("config is nil, cannot rescan auth directory")
+		return result
+	}
+	w.rebuildAuthHashCache(cfg)
+	for _, update := range w.refreshAuthState(false) {
+		switch update.Action {
+		case AuthUpdateActionAdd:
+			result.Added++
+		case AuthUpdateActionModify:
+			result.Updated++
+		case AuthUpdateActionDelete:
+			result.Removed++
+		}
+	}
+	log.Infof("auth directory rescan complete - %d added, %d updated, %d removed", result.Added, result.Updated, result.Removed)
+	return result
+}
+
 func (w *Watcher) addOrUpdateClient(path string) {
-	data, errRead := os.ReadFile(path)
+	// Hashes are computed over the decrypted content so re-sealing an unchanged
+	// file does not trigger a reload.
+	data, errRead := secrets.ReadFile(path)
 	if errRead != nil {
 		log.Errorf("failed to read auth file %s: %v", filepath.Base(path), errRead)
 		return
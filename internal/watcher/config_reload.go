@@ -6,10 +6,13 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher/diff"
 	"gopkg.in/yaml.v3"
@@ -50,8 +53,7 @@ func (w *Watcher) reloadConfigIfChanged() {
 		log.Debugf("ignoring empty config file write event")
 		return
 	}
-	sum := sha256.Sum256(data)
-	newHash := hex.EncodeToString(sum[:])
+	newHash := w.configSourcesHash(data)
 
 	w.clientsMutex.RLock()
 	currentHash := w.lastConfigHash
@@ -65,8 +67,7 @@ func (w *Watcher) reloadConfigIfChanged() {
 	if w.reloadConfig() {
 		finalHash := newHash
 		if updatedData, errRead := os.ReadFile(w.configPath); errRead == nil && len(updatedData) > 0 {
-			sumUpdated := sha256.Sum256(updatedData)
-			finalHash = hex.EncodeToString(sumUpdated[:])
+			finalHash = w.configSourcesHash(updatedData)
 		} else if errRead != nil {
 			log.WithError(errRead).Debug("failed to compute updated config hash after reload")
 		}
@@ -86,6 +87,7 @@ func (w *Watcher) reloadConfig() bool {
 		log.Errorf("failed to reload config: %v", errLoadConfig)
 		return false
 	}
+	newConfig.ApplyCredentialEncryption()
 
 	if w.mirroredAuthDir != "" {
 		newConfig.AuthDir = w.mirroredAuthDir
@@ -102,6 +104,7 @@ func (w *Watcher) reloadConfig() bool {
 	_ = yaml.Unmarshal(w.oldConfigYaml, &oldConfig)
 	w.oldConfigYaml, _ = yaml.Marshal(newConfig)
 	w.config = newConfig
+	w.watchConfigIncludesLocked(newConfig)
 	w.clientsMutex.Unlock()
 
 	var affectedOAuthProviders []string
@@ -109,7 +112,7 @@ func (w *Watcher) reloadConfig() bool {
 		_, affectedOAuthProviders = diff.DiffOAuthExcludedModelChanges(oldConfig.OAuthExcludedModels, newConfig.OAuthExcludedModels)
 	}
 
-	util.SetLogLevel(newConfig)
+	logging.SetLogLevel(newConfig)
 	if oldConfig != nil && oldConfig.Debug != newConfig.Debug {
 		log.Debugf("log level updated - debug mode changed from %t to %t", oldConfig.Debug, newConfig.Debug)
 	}
@@ -133,3 +136,61 @@ func (w *Watcher) reloadConfig() bool {
 	w.reloadClients(authDirChanged, affectedOAuthProviders, forceAuthRefresh)
 	return true
 }
+
+// configSourcesHash hashes the main config data together with the files it includes,
+// so edits to an included file are not mistaken for an unchanged config.
+func (w *Watcher) configSourcesHash(data []byte) string {
+	h := sha256.New()
+	h.Write(data)
+	w.clientsMutex.RLock()
+	cfg := w.config
+	w.clientsMutex.RUnlock()
+	if cfg != nil && len(cfg.Include) > 0 {
+		files, _, errResolve := config.ResolveIncludes(w.configPath, cfg.Include)
+		if errResolve != nil {
+			log.WithError(errResolve).Debug("failed to resolve config includes for hash check")
+		}
+		for _, file := range files {
+			h.Write([]byte(file))
+			if content, errRead := os.ReadFile(file); errRead == nil {
+				h.Write(content)
+			}
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// watchConfigIncludesLocked watches the files and directories cfg includes. The caller
+// holds clientsMutex.
+func (w *Watcher) watchConfigIncludesLocked(cfg *config.Config) {
+	paths := cfg.IncludeWatchPaths()
+	w.includePaths = w.includePaths[:0]
+	for _, path := range paths {
+		if w.watcher != nil {
+			if errAdd := w.watcher.Add(path); errAdd != nil {
+				log.Errorf("failed to watch config include %s: %v", path, errAdd)
+				continue
+			}
+		}
+		w.includePaths = append(w.includePaths, w.normalizeAuthPath(path))
+	}
+}
+
+// isConfigIncludeEvent reports whether normalizedName is an included config file or a
+// YAML file inside an included directory.
+func (w *Watcher) isConfigIncludeEvent(normalizedName string) bool {
+	w.clientsMutex.RLock()
+	defer w.clientsMutex.RUnlock()
+	if len(w.includePaths) == 0 {
+		return false
+	}
+	ext := strings.ToLower(filepath.Ext(normalizedName))
+	isYAML := ext == ".yaml" || ext == ".yml"
+	dir := filepath.Dir(normalizedName)
+	for _, path := range w.includePaths {
+		if normalizedName == path || (isYAML && dir == path) {
+			return true
+		}
+	}
+	return false
+}
@@ -51,12 +51,48 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.ErrorLogsMaxFiles != newCfg.ErrorLogsMaxFiles {
 		changes = append(changes, fmt.Sprintf("error-logs-max-files: %d -> %d", oldCfg.ErrorLogsMaxFiles, newCfg.ErrorLogsMaxFiles))
 	}
+	if oldCfg.UsageRetention.RateLimits.MaxAgeHours != newCfg.UsageRetention.RateLimits.MaxAgeHours {
+		changes = append(changes, fmt.Sprintf("usage-retention.rate-limits.max-age-hours: %d -> %d", oldCfg.UsageRetention.RateLimits.MaxAgeHours, newCfg.UsageRetention.RateLimits.MaxAgeHours))
+	}
+	if oldCfg.UsageRetention.RateLimits.MaxRecords != newCfg.UsageRetention.RateLimits.MaxRecords {
+		changes = append(changes, fmt.Sprintf("usage-retention.rate-limits.max-records: %d -> %d", oldCfg.UsageRetention.RateLimits.MaxRecords, newCfg.UsageRetention.RateLimits.MaxRecords))
+	}
+	if oldCfg.UsageRetention.Requests.MaxAgeHours != newCfg.UsageRetention.Requests.MaxAgeHours {
+		changes = append(changes, fmt.Sprintf("usage-retention.requests.max-age-hours: %d -> %d", oldCfg.UsageRetention.Requests.MaxAgeHours, newCfg.UsageRetention.Requests.MaxAgeHours))
+	}
+	if oldCfg.UsageRetention.Requests.MaxRecords != newCfg.UsageRetention.Requests.MaxRecords {
+		changes = append(changes, fmt.Sprintf("usage-retention.requests.max-records: %d -> %d", oldCfg.UsageRetention.Requests.MaxRecords, newCfg.UsageRetention.Requests.MaxRecords))
+	}
+	if oldCfg.UsageRetention.CompactionIntervalSeconds != newCfg.UsageRetention.CompactionIntervalSeconds {
+		changes = append(changes, fmt.Sprintf("usage-retention.compaction-interval-seconds: %d -> %d", oldCfg.UsageRetention.CompactionIntervalSeconds, newCfg.UsageRetention.CompactionIntervalSeconds))
+	}
+	if oldCfg.UnknownStreamEvents != newCfg.UnknownStreamEvents {
+		changes = append(changes, fmt.Sprintf("unknown-stream-events: %s -> %s", oldCfg.UnknownStreamEvents, newCfg.UnknownStreamEvents))
+	}
+	if oldCfg.PayloadLog.Enabled != newCfg.PayloadLog.Enabled {
+		changes = append(changes, fmt.Sprintf("payload-log.enabled: %t -> %t", oldCfg.PayloadLog.Enabled, newCfg.PayloadLog.Enabled))
+	}
+	if oldCfg.EmptyMessages != newCfg.EmptyMessages {
+		changes = append(changes, fmt.Sprintf("empty-messages: %s -> %s", oldCfg.EmptyMessages, newCfg.EmptyMessages))
+	}
+	if oldCfg.Prediction != newCfg.Prediction {
+		changes = append(changes, fmt.Sprintf("prediction: %s -> %s", oldCfg.Prediction, newCfg.Prediction))
+	}
+	if oldCfg.DisableModelPinning != newCfg.DisableModelPinning {
+		changes = append(changes, fmt.Sprintf("disable-model-pinning: %t -> %t", oldCfg.DisableModelPinning, newCfg.DisableModelPinning))
+	}
 	if oldCfg.RequestRetry != newCfg.RequestRetry {
 		changes = append(changes, fmt.Sprintf("request-retry: %d -> %d", oldCfg.RequestRetry, newCfg.RequestRetry))
 	}
 	if oldCfg.MaxRetryInterval != newCfg.MaxRetryInterval {
 		changes = append(changes, fmt.Sprintf("max-retry-interval: %d -> %d", oldCfg.MaxRetryInterval, newCfg.MaxRetryInterval))
 	}
+	if oldCfg.Hedging.DelayMs != newCfg.Hedging.DelayMs {
+		changes = append(changes, fmt.Sprintf("hedging.delay-ms: %d -> %d", oldCfg.Hedging.DelayMs, newCfg.Hedging.DelayMs))
+	}
+	if oldCfg.Hedging.BudgetPercent != newCfg.Hedging.BudgetPercent {
+		changes = append(changes, fmt.Sprintf("hedging.budget-percent: %v -> %v", oldCfg.Hedging.BudgetPercent, newCfg.Hedging.BudgetPercent))
+	}
 	if oldCfg.ProxyURL != newCfg.ProxyURL {
 		changes = append(changes, fmt.Sprintf("proxy-url: %s -> %s", formatProxyURL(oldCfg.ProxyURL), formatProxyURL(newCfg.ProxyURL)))
 	}
@@ -66,9 +102,60 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.ForceModelPrefix != newCfg.ForceModelPrefix {
 		changes = append(changes, fmt.Sprintf("force-model-prefix: %t -> %t", oldCfg.ForceModelPrefix, newCfg.ForceModelPrefix))
 	}
+	if oldCfg.PassthroughHeaders != newCfg.PassthroughHeaders {
+		changes = append(changes, fmt.Sprintf("passthrough-headers: %t -> %t", oldCfg.PassthroughHeaders, newCfg.PassthroughHeaders))
+	}
+	if !reflect.DeepEqual(trimStrings(oldCfg.PassthroughHeaderAllowlist), trimStrings(newCfg.PassthroughHeaderAllowlist)) {
+		changes = append(changes, fmt.Sprintf("passthrough-header-allowlist: updated (%d -> %d entries)", len(oldCfg.PassthroughHeaderAllowlist), len(newCfg.PassthroughHeaderAllowlist)))
+	}
+	if oldCfg.ParameterHeaders != newCfg.ParameterHeaders {
+		changes = append(changes, fmt.Sprintf("parameter-headers: %t -> %t", oldCfg.ParameterHeaders, newCfg.ParameterHeaders))
+	}
 	if oldCfg.NonStreamKeepAliveInterval != newCfg.NonStreamKeepAliveInterval {
 		changes = append(changes, fmt.Sprintf("nonstream-keepalive-interval: %d -> %d", oldCfg.NonStreamKeepAliveInterval, newCfg.NonStreamKeepAliveInterval))
 	}
+	if oldCfg.Streaming.StallTimeoutSeconds != newCfg.Streaming.StallTimeoutSeconds {
+		changes = append(changes, fmt.Sprintf("streaming.stall-timeout-seconds: %d -> %d", oldCfg.Streaming.StallTimeoutSeconds, newCfg.Streaming.StallTimeoutSeconds))
+	}
+	if oldCfg.Streaming.StallRetries != newCfg.Streaming.StallRetries {
+		changes = append(changes, fmt.Sprintf("streaming.stall-retries: %d -> %d", oldCfg.Streaming.StallRetries, newCfg.Streaming.StallRetries))
+	}
+	if oldCfg.ResponseStoreTTLSeconds != newCfg.ResponseStoreTTLSeconds {
+		changes = append(changes, fmt.Sprintf("response-store-ttl-seconds: %d -> %d", oldCfg.ResponseStoreTTLSeconds, newCfg.ResponseStoreTTLSeconds))
+	}
+	if oldCfg.AsyncThresholdSeconds != newCfg.AsyncThresholdSeconds {
+		changes = append(changes, fmt.Sprintf("async-threshold-seconds: %d -> %d", oldCfg.AsyncThresholdSeconds, newCfg.AsyncThresholdSeconds))
+	}
+	if oldCfg.MaxRequestBodyMB != newCfg.MaxRequestBodyMB {
+		changes = append(changes, fmt.Sprintf("max-request-body-mb: %d -> %d", oldCfg.MaxRequestBodyMB, newCfg.MaxRequestBodyMB))
+	}
+	if oldCfg.RequestLimits.MaxContentBlocks != newCfg.RequestLimits.MaxContentBlocks {
+		changes = append(changes, fmt.Sprintf("request-limits.max-content-blocks: %d -> %d", oldCfg.RequestLimits.MaxContentBlocks, newCfg.RequestLimits.MaxContentBlocks))
+	}
+	if oldCfg.RequestLimits.MaxImages != newCfg.RequestLimits.MaxImages {
+		changes = append(changes, fmt.Sprintf("request-limits.max-images: %d -> %d", oldCfg.RequestLimits.MaxImages, newCfg.RequestLimits.MaxImages))
+	}
+	if oldCfg.SessionLimits.MaxTurnsPerHour != newCfg.SessionLimits.MaxTurnsPerHour {
+		changes = append(changes, fmt.Sprintf("session-limits.max-turns-per-hour: %d -> %d", oldCfg.SessionLimits.MaxTurnsPerHour, newCfg.SessionLimits.MaxTurnsPerHour))
+	}
+	if oldCfg.SessionLimits.MaxTokensPerSession != newCfg.SessionLimits.MaxTokensPerSession {
+		changes = append(changes, fmt.Sprintf("session-limits.max-tokens-per-session: %d -> %d", oldCfg.SessionLimits.MaxTokensPerSession, newCfg.SessionLimits.MaxTokensPerSession))
+	}
+	if oldCfg.LoopDetection.Threshold != newCfg.LoopDetection.Threshold {
+		changes = append(changes, fmt.Sprintf("loop-detection.threshold: %d -> %d", oldCfg.LoopDetection.Threshold, newCfg.LoopDetection.Threshold))
+	}
+	if oldCfg.LoopDetection.Action != newCfg.LoopDetection.Action {
+		changes = append(changes, fmt.Sprintf("loop-detection.action: %s -> %s", oldCfg.LoopDetection.Action, newCfg.LoopDetection.Action))
+	}
+	if !reflect.DeepEqual(oldCfg.LoopDetection.Overrides, newCfg.LoopDetection.Overrides) {
+		changes = append(changes, fmt.Sprintf("loop-detection.overrides: updated (%d -> %d keys)", len(oldCfg.LoopDetection.Overrides), len(newCfg.LoopDetection.Overrides)))
+	}
+	if !reflect.DeepEqual(oldCfg.ModelFallbacks.Models, newCfg.ModelFallbacks.Models) {
+		changes = append(changes, fmt.Sprintf("model-fallbacks.models: updated (%d -> %d models)", len(oldCfg.ModelFallbacks.Models), len(newCfg.ModelFallbacks.Models)))
+	}
+	if oldCfg.ModelFallbacks.WebhookURL != newCfg.ModelFallbacks.WebhookURL {
+		changes = append(changes, "model-fallbacks.webhook-url: updated")
+	}
 
 	// Quota-exceeded behavior
 	if oldCfg.QuotaExceeded.SwitchProject != newCfg.QuotaExceeded.SwitchProject {
@@ -81,8 +168,47 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.Routing.Strategy != newCfg.Routing.Strategy {
 		changes = append(changes, fmt.Sprintf("routing.strategy: %s -> %s", oldCfg.Routing.Strategy, newCfg.Routing.Strategy))
 	}
+	if oldCfg.Routing.PromptCacheAffinity != newCfg.Routing.PromptCacheAffinity || oldCfg.Routing.PromptCacheAffinityTTLSeconds != newCfg.Routing.PromptCacheAffinityTTLSeconds {
+		changes = append(changes, fmt.Sprintf("routing.prompt-cache-affinity: %t -> %t", oldCfg.Routing.PromptCacheAffinity, newCfg.Routing.PromptCacheAffinity))
+	}
+	if oldCfg.Routing.SoftLimit5hPercent != newCfg.Routing.SoftLimit5hPercent {
+		changes = append(changes, fmt.Sprintf("routing.soft-limit-5h-percent: %v -> %v", oldCfg.Routing.SoftLimit5hPercent, newCfg.Routing.SoftLimit5hPercent))
+	}
+	if !reflect.DeepEqual(trimStrings(oldCfg.Routing.InteractiveAPIKeys), trimStrings(newCfg.Routing.InteractiveAPIKeys)) {
+		changes = append(changes, fmt.Sprintf("routing.interactive-api-keys: updated (%d -> %d entries)", len(oldCfg.Routing.InteractiveAPIKeys), len(newCfg.Routing.InteractiveAPIKeys)))
+	}
+	if oldCfg.Routing.SpillOverPercent != newCfg.Routing.SpillOverPercent {
+		changes = append(changes, fmt.Sprintf("routing.spill-over-percent: %v -> %v", oldCfg.Routing.SpillOverPercent, newCfg.Routing.SpillOverPercent))
+	}
+	if !reflect.DeepEqual(oldCfg.Routing.Schedules, newCfg.Routing.Schedules) {
+		changes = append(changes, fmt.Sprintf("routing.schedules: updated (%d -> %d schedules)", len(oldCfg.Routing.Schedules), len(newCfg.Routing.Schedules)))
+	}
+	if !reflect.DeepEqual(oldCfg.Routing.Scripts, newCfg.Routing.Scripts) {
+		changes = append(changes, fmt.Sprintf("routing.scripts: updated (%d -> %d scripts)", len(oldCfg.Routing.Scripts), len(newCfg.Routing.Scripts)))
+	}
 
 	// API keys (redacted) and counts
+	if !reflect.DeepEqual(trimStrings(oldCfg.Include), trimStrings(newCfg.Include)) {
+		changes = append(changes, fmt.Sprintf("include: updated (%d -> %d entries)", len(oldCfg.Include), len(newCfg.Include)))
+	}
+	if !reflect.DeepEqual(oldCfg.Chaos, newCfg.Chaos) {
+		changes = append(changes, fmt.Sprintf("chaos: updated (enabled %t -> %t)", oldCfg.Chaos.Enabled, newCfg.Chaos.Enabled))
+	}
+	if !reflect.DeepEqual(oldCfg.MockBackend, newCfg.MockBackend) {
+		changes = append(changes, fmt.Sprintf("mock-backend: updated (enabled %t -> %t, %d -> %d rules)", oldCfg.MockBackend.Enabled, newCfg.MockBackend.Enabled, len(oldCfg.MockBackend.Rules), len(newCfg.MockBackend.Rules)))
+	}
+	if oldCfg.ThinkingCache != newCfg.ThinkingCache {
+		changes = append(changes, fmt.Sprintf("thinking-cache: max-entry-kb %d -> %d, max-total-mb %d -> %d, compress %t -> %t", oldCfg.ThinkingCache.MaxEntryKB, newCfg.ThinkingCache.MaxEntryKB, oldCfg.ThinkingCache.MaxTotalMB, newCfg.ThinkingCache.MaxTotalMB, oldCfg.ThinkingCache.Compress, newCfg.ThinkingCache.Compress))
+	}
+	if oldCfg.CacheSnapshotFile != newCfg.CacheSnapshotFile {
+		changes = append(changes, fmt.Sprintf("cache-snapshot-file: %s -> %s", oldCfg.CacheSnapshotFile, newCfg.CacheSnapshotFile))
+	}
+	if !reflect.DeepEqual(oldCfg.ModelParameters, newCfg.ModelParameters) {
+		changes = append(changes, fmt.Sprintf("model-parameters: updated (%d -> %d rules)", len(oldCfg.ModelParameters), len(newCfg.ModelParameters)))
+	}
+	if !reflect.DeepEqual(oldCfg.APIKeyPolicies, newCfg.APIKeyPolicies) {
+		changes = append(changes, fmt.Sprintf("api-key-policies: updated (%d -> %d keys)", len(oldCfg.APIKeyPolicies), len(newCfg.APIKeyPolicies)))
+	}
 	if len(oldCfg.APIKeys) != len(newCfg.APIKeys) {
 		changes = append(changes, fmt.Sprintf("api-keys count: %d -> %d", len(oldCfg.APIKeys), len(newCfg.APIKeys)))
 	} else if !reflect.DeepEqual(trimStrings(oldCfg.APIKeys), trimStrings(newCfg.APIKeys)) {
@@ -144,6 +270,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if !equalStringMap(o.Headers, n.Headers) {
 				changes = append(changes, fmt.Sprintf("claude[%d].headers: updated", i))
 			}
+			if strings.TrimSpace(o.APIVersion) != strings.TrimSpace(n.APIVersion) {
+				changes = append(changes, fmt.Sprintf("claude[%d].api-version: %s -> %s", i, strings.TrimSpace(o.APIVersion), strings.TrimSpace(n.APIVersion)))
+			}
 			oldModels := SummarizeClaudeModels(o.Models)
 			newModels := SummarizeClaudeModels(n.Models)
 			if oldModels.hash != newModels.hash {
@@ -268,6 +397,15 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			changes = append(changes, "remote-management.secret-key: updated")
 		}
 	}
+	if len(oldCfg.RemoteManagement.Users) != len(newCfg.RemoteManagement.Users) {
+		changes = append(changes, fmt.Sprintf("remote-management.users count: %d -> %d", len(oldCfg.RemoteManagement.Users), len(newCfg.RemoteManagement.Users)))
+	}
+	if oldCfg.RemoteManagement.SessionTTL != newCfg.RemoteManagement.SessionTTL {
+		changes = append(changes, fmt.Sprintf("remote-management.session-ttl: %s -> %s", oldCfg.RemoteManagement.SessionTTL, newCfg.RemoteManagement.SessionTTL))
+	}
+	if len(oldCfg.RemoteManagement.ReadOnlyKeys) != len(newCfg.RemoteManagement.ReadOnlyKeys) {
+		changes = append(changes, fmt.Sprintf("remote-management.read-only-keys count: %d -> %d", len(oldCfg.RemoteManagement.ReadOnlyKeys), len(newCfg.RemoteManagement.ReadOnlyKeys)))
+	}
 
 	// OpenAI compatibility providers (summarized)
 	if compat := DiffOpenAICompatibility(oldCfg.OpenAICompatibility, newCfg.OpenAICompatibility); len(compat) > 0 {
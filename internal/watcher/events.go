@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/secrets"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -27,11 +28,15 @@ func matchProvider(provider string, targets []string) (string, bool) {
 }
 
 func (w *Watcher) start(ctx context.Context) error {
-	if errAddConfig := w.watcher.Add(w.configPath); errAddConfig != nil {
-		log.Errorf("failed to watch config file %s: %v", w.configPath, errAddConfig)
-		return errAddConfig
+	if w.configPath != "" {
+		if errAddConfig := w.watcher.Add(w.configPath); errAddConfig != nil {
+			log.Errorf("failed to watch config file %s: %v", w.configPath, errAddConfig)
+			return errAddConfig
+		}
+		log.Debugf("watching config file: %s", w.configPath)
+	} else {
+		log.Debug("no config file path set, config hot reload disabled")
 	}
-	log.Debugf("watching config file: %s", w.configPath)
 
 	if errAddAuthDir := w.watcher.Add(w.authDir); errAddAuthDir != nil {
 		log.Errorf("failed to watch auth directory %s: %v", w.authDir, errAddAuthDir)
@@ -72,6 +77,12 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 	normalizedAuthDir := w.normalizeAuthPath(w.authDir)
 	isConfigEvent := normalizedName == normalizedConfigPath && event.Op&configOps != 0
 	authOps := fsnotify.Create | fsnotify.Write | fsnotify.Remove | fsnotify.Rename
+	if !isConfigEvent && event.Op&authOps != 0 && w.isConfigIncludeEvent(normalizedName) {
+		isConfigEvent = true
+	}
+	if w.configPath == "" {
+		isConfigEvent = false
+	}
 	isAuthJSON := strings.HasPrefix(normalizedName, normalizedAuthDir) && strings.HasSuffix(normalizedName, ".json") && event.Op&authOps != 0
 	if !isConfigEvent && !isAuthJSON {
 		// Ignore unrelated files (e.g., cookie snapshots *.cookie) and other noise.
@@ -125,7 +136,7 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 }
 
 func (w *Watcher) authFileUnchanged(path string) (bool, error) {
-	data, errRead := os.ReadFile(path)
+	data, errRead := secrets.ReadFile(path)
 	if errRead != nil {
 		return false, errRead
 	}
@@ -35,6 +35,8 @@ func (s *ConfigSynthesizer) Synthesize(ctx *SynthesisContext) ([]*coreauth.Auth,
 	out = append(out, s.synthesizeOpenAICompat(ctx)...)
 	// Vertex-compat
 	out = append(out, s.synthesizeVertexCompat(ctx)...)
+	// Mock backend
+	out = append(out, s.synthesizeMockBackend(ctx)...)
 
 	return out, nil
 }
@@ -116,6 +118,9 @@ func (s *ConfigSynthesizer) synthesizeClaudeKeys(ctx *SynthesisContext) []*corea
 		if hash := diff.ComputeClaudeModelsHash(ck.Models); hash != "" {
 			attrs["models_hash"] = hash
 		}
+		if version := strings.TrimSpace(ck.APIVersion); version != "" {
+			attrs["api_version"] = version
+		}
 		addConfigHeadersToAttrs(ck.Headers, attrs)
 		proxyURL := strings.TrimSpace(ck.ProxyURL)
 		a := &coreauth.Auth{
@@ -320,3 +325,23 @@ func (s *ConfigSynthesizer) synthesizeVertexCompat(ctx *SynthesisContext) []*cor
 	}
 	return out
 }
+
+// synthesizeMockBackend creates the Auth entry for the built-in mock provider.
+func (s *ConfigSynthesizer) synthesizeMockBackend(ctx *SynthesisContext) []*coreauth.Auth {
+	cfg := ctx.Config
+	if !cfg.MockBackend.Enabled {
+		return nil
+	}
+	id, _ := ctx.IDGenerator.Next("mock:backend", "mock")
+	return []*coreauth.Auth{{
+		ID:       id,
+		Provider: "mock",
+		Label:    "mock-backend",
+		Status:   coreauth.StatusActive,
+		Attributes: map[string]string{
+			"source": "config:mock-backend",
+		},
+		CreatedAt: ctx.Now,
+		UpdatedAt: ctx.Now,
+	}}
+}
@@ -41,6 +41,7 @@ type Watcher struct {
 	lastAuthContents  map[string]*coreauth.Auth
 	lastRemoveTimes   map[string]time.Time
 	lastConfigHash    string
+	includePaths      []string
 	authQueue         chan<- AuthUpdate
 	currentAuths      map[string]*coreauth.Auth
 	runtimeAuths      map[string]*coreauth.Auth
@@ -125,6 +126,7 @@ func (w *Watcher) SetConfig(cfg *config.Config) {
 	defer w.clientsMutex.Unlock()
 	w.config = cfg
 	w.oldConfigYaml, _ = yaml.Marshal(cfg)
+	w.watchConfigIncludesLocked(cfg)
 }
 
 // SetAuthUpdateQueue sets the queue used to emit auth updates.
@@ -1488,3 +1488,39 @@ func TestScheduleProcessEventsStopsOnContextDone(t *testing.T) {
 func hexString(data []byte) string {
 	return strings.ToLower(fmt.Sprintf("%x", data))
 }
+
+func TestRescanAuthsAppliesNewAndRemovedFiles(t *testing.T) {
+	authDir := t.TempDir()
+	queue := make(chan AuthUpdate, 8)
+	w := &Watcher{authDir: authDir, lastAuthHashes: make(map[string]string)}
+	w.SetConfig(&config.Config{AuthDir: authDir})
+	w.SetAuthUpdateQueue(queue)
+	defer w.stopDispatch()
+
+	if got := w.RescanAuths(); got != (AuthRescanResult{}) {
+		t.Fatalf("empty rescan = %+v", got)
+	}
+
+	authFile := filepath.Join(authDir, "claude-user.json")
+	if err := os.WriteFile(authFile, []byte(`{"type":"claude","email":"user@example.com"}`), 0o644); err != nil {
+		t.Fatalf("failed to write auth file: %v", err)
+	}
+	if got := w.RescanAuths(); got.Added != 1 || got.Removed != 0 {
+		t.Fatalf("rescan after add = %+v", got)
+	}
+	select {
+	case u := <-queue:
+		if u.Action != AuthUpdateActionAdd {
+			t.Fatalf("unexpected auth update: %+v", u)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for auth update")
+	}
+
+	if err := os.Remove(authFile); err != nil {
+		t.Fatalf("failed to remove auth file: %v", err)
+	}
+	if got := w.RescanAuths(); got.Removed != 1 || got.Added != 0 {
+		t.Fatalf("rescan after remove = %+v", got)
+	}
+}
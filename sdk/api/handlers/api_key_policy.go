@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// clientAPIKey returns the client API key that authenticated the request, if any.
+func clientAPIKey(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil {
+		return strings.TrimSpace(ginCtx.GetString("apiKey"))
+	}
+	return ""
+}
+
+// checkModelAllowed enforces api-key-policies allowed-models. A model is allowed when
+// either the name the client sent or the model it resolved to matches an entry, so a
+// policy may list aliases as well as upstream names. Disallowed models get the same 404
+// as unknown models, which clients report as model_not_found.
+func checkModelAllowed(cfg *config.SDKConfig, ctx context.Context, requestedModel, resolvedModel string) *interfaces.ErrorMessage {
+	policy, ok := cfg.APIKeyPolicy(clientAPIKey(ctx))
+	if !ok || len(policy.AllowedModels) == 0 {
+		return nil
+	}
+	candidates := []string{requestedModel, thinking.ParseSuffix(requestedModel).ModelName, thinking.ParseSuffix(resolvedModel).ModelName}
+	for _, pattern := range policy.AllowedModels {
+		for _, candidate := range candidates {
+			if candidate != "" && util.MatchModelPattern(pattern, candidate) {
+				return nil
+			}
+		}
+	}
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusNotFound,
+		Error:      fmt.Errorf("The model `%s` does not exist or you do not have access to it.", requestedModel),
+	}
+}
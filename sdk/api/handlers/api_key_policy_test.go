@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestCheckModelAllowed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &sdkconfig.SDKConfig{APIKeyPolicies: []sdkconfig.APIKeyPolicy{
+		{APIKey: "cheap", AllowedModels: []string{"claude-haiku-*", "fast"}},
+	}}
+	ctxFor := func(apiKey string) context.Context {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+		c.Set("apiKey", apiKey)
+		return context.WithValue(context.Background(), "gin", c)
+	}
+
+	if errMsg := checkModelAllowed(cfg, ctxFor("cheap"), "claude-haiku-4-5(8192)", "claude-haiku-4-5(8192)"); errMsg != nil {
+		t.Fatalf("wildcard match rejected: %v", errMsg.Error)
+	}
+	if errMsg := checkModelAllowed(cfg, ctxFor("cheap"), "fast", "claude-sonnet-4-5"); errMsg != nil {
+		t.Fatalf("allowed alias rejected: %v", errMsg.Error)
+	}
+	errMsg := checkModelAllowed(cfg, ctxFor("cheap"), "claude-opus-4-1", "claude-opus-4-1")
+	if errMsg == nil || errMsg.StatusCode != http.StatusNotFound {
+		t.Fatalf("opus should be rejected with 404, got %+v", errMsg)
+	}
+	if errMsg = checkModelAllowed(cfg, ctxFor("other"), "claude-opus-4-1", "claude-opus-4-1"); errMsg != nil {
+		t.Fatalf("keys without a policy must not be restricted: %v", errMsg.Error)
+	}
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 )
@@ -64,15 +65,9 @@ func (h *ClaudeCodeAPIHandler) Models() []map[string]any {
 //   - c: The Gin context for the request.
 func (h *ClaudeCodeAPIHandler) ClaudeMessages(c *gin.Context) {
 	// Extract raw JSON data from the incoming request
-	rawJSON, err := c.GetRawData()
-	// If data retrieval fails, return a 400 Bad Request error.
-	if err != nil {
-		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
-			Error: handlers.ErrorDetail{
-				Message: fmt.Sprintf("Invalid request: %v", err),
-				Type:    "invalid_request_error",
-			},
-		})
+	rawJSON, ok := h.ReadRequestBody(c)
+	// ReadRequestBody has already answered 400 or 413 when the body cannot be read.
+	if !ok {
 		return
 	}
 
@@ -93,15 +88,9 @@ func (h *ClaudeCodeAPIHandler) ClaudeMessages(c *gin.Context) {
 //   - c: The Gin context for the request.
 func (h *ClaudeCodeAPIHandler) ClaudeCountTokens(c *gin.Context) {
 	// Extract raw JSON data from the incoming request
-	rawJSON, err := c.GetRawData()
-	// If data retrieval fails, return a 400 Bad Request error.
-	if err != nil {
-		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
-			Error: handlers.ErrorDetail{
-				Message: fmt.Sprintf("Invalid request: %v", err),
-				Type:    "invalid_request_error",
-			},
-		})
+	rawJSON, ok := h.ReadRequestBody(c)
+	// ReadRequestBody has already answered 400 or 413 when the body cannot be read.
+	if !ok {
 		return
 	}
 
@@ -340,15 +329,39 @@ type claudeErrorResponse struct {
 }
 
 func (h *ClaudeCodeAPIHandler) toClaudeError(msg *interfaces.ErrorMessage) claudeErrorResponse {
+	message := msg.Error.Error()
 	return claudeErrorResponse{
 		Type: "error",
 		Error: claudeErrorDetail{
-			Type:    "api_error",
-			Message: msg.Error.Error(),
+			Type:    claudeErrorType(cliproxyexecutor.ClassifyResponse("", msg.StatusCode, []byte(message)).Kind),
+			Message: message,
 		},
 	}
 }
 
+// claudeErrorType maps an upstream error kind onto the Anthropic error type clients
+// such as Claude Code use to decide whether to retry.
+func claudeErrorType(kind cliproxyexecutor.ErrorKind) string {
+	switch kind {
+	case cliproxyexecutor.ErrorKindInvalidRequest:
+		return "invalid_request_error"
+	case cliproxyexecutor.ErrorKindAuth:
+		return "authentication_error"
+	case cliproxyexecutor.ErrorKindPermission:
+		return "permission_error"
+	case cliproxyexecutor.ErrorKindBilling:
+		return "billing_error"
+	case cliproxyexecutor.ErrorKindNotFound:
+		return "not_found_error"
+	case cliproxyexecutor.ErrorKindRateLimit:
+		return "rate_limit_error"
+	case cliproxyexecutor.ErrorKindOverloaded:
+		return "overloaded_error"
+	default:
+		return "api_error"
+	}
+}
+
 // logClaudeTokenUsage ghi log thông tin token usage từ response Claude
 // Response format: {"usage": {"input_tokens": N, "output_tokens": N, "cache_creation_input_tokens": N, "cache_read_input_tokens": N}}
 func logClaudeTokenUsage(modelName string, resp []byte) {
@@ -151,7 +151,10 @@ func (h *GeminiAPIHandler) GeminiHandler(c *gin.Context) {
 	}
 
 	method := action[1]
-	rawJSON, _ := c.GetRawData()
+	rawJSON, ok := h.ReadRequestBody(c)
+	if !ok {
+		return
+	}
 
 	switch method {
 	case "generateContent":
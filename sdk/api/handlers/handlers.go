@@ -5,9 +5,12 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -16,13 +19,15 @@ import (
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/replay"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
 	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
-	"golang.org/x/net/context"
+	log "github.com/sirupsen/logrus"
 )
 
 // ErrorResponse represents a standard error response format for the API.
@@ -50,6 +55,7 @@ const idempotencyKeyMetadataKey = "idempotency_key"
 const (
 	defaultStreamingKeepAliveSeconds = 0
 	defaultStreamingBootstrapRetries = 0
+	defaultResponseStoreTTLSeconds   = 3600
 )
 
 type pinnedAuthContextKey struct{}
@@ -154,6 +160,30 @@ func StreamingKeepAliveInterval(cfg *config.SDKConfig) time.Duration {
 	return time.Duration(seconds) * time.Second
 }
 
+// ResponseStoreTTL returns how long completed responses are kept for previous_response_id
+// chaining and retrieval by ID. Zero means completed responses are not kept.
+func ResponseStoreTTL(cfg *config.SDKConfig) time.Duration {
+	seconds := defaultResponseStoreTTLSeconds
+	if cfg != nil && cfg.ResponseStoreTTLSeconds != 0 {
+		seconds = cfg.ResponseStoreTTLSeconds
+	}
+	if seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// AsyncOperationThreshold returns how long a non-streaming request that asked for an
+// asynchronous response may run before it is answered with 202 and an operation ID.
+// Returning 0 disables asynchronous operations (default when unset). They also need the
+// response store, whose TTL bounds how long results can be polled.
+func AsyncOperationThreshold(cfg *config.SDKConfig) time.Duration {
+	if cfg == nil || cfg.AsyncThresholdSeconds <= 0 || ResponseStoreTTL(cfg) <= 0 {
+		return 0
+	}
+	return time.Duration(cfg.AsyncThresholdSeconds) * time.Second
+}
+
 // NonStreamingKeepAliveInterval returns the keep-alive interval for non-streaming responses.
 // Returning 0 disables keep-alives (default when unset).
 func NonStreamingKeepAliveInterval(cfg *config.SDKConfig) time.Duration {
@@ -179,19 +209,34 @@ func StreamingBootstrapRetries(cfg *config.SDKConfig) int {
 	return retries
 }
 
-// PassthroughHeadersEnabled returns whether upstream response headers should be forwarded to clients.
-// Default is false.
+// PassthroughHeadersEnabled returns whether upstream response headers should be forwarded to clients,
+// either all of them or those on the allowlist. Default is false.
 func PassthroughHeadersEnabled(cfg *config.SDKConfig) bool {
-	return cfg != nil && cfg.PassthroughHeaders
+	return cfg != nil && (cfg.PassthroughHeaders || len(cfg.PassthroughHeaderAllowlist) > 0)
+}
+
+// PassthroughUpstreamHeaders returns the upstream response headers to forward to clients:
+// all filtered headers when passthrough is on, otherwise only allowlisted ones.
+func PassthroughUpstreamHeaders(cfg *config.SDKConfig, src http.Header) http.Header {
+	if !PassthroughHeadersEnabled(cfg) {
+		return nil
+	}
+	filtered := FilterUpstreamHeaders(src)
+	if cfg.PassthroughHeaders {
+		return filtered
+	}
+	return AllowlistUpstreamHeaders(filtered, cfg.PassthroughHeaderAllowlist)
 }
 
 func requestExecutionMetadata(ctx context.Context) map[string]any {
 	// Idempotency-Key is an optional client-supplied header used to correlate retries.
 	// It is forwarded as execution metadata; when absent we generate a UUID.
 	key := ""
+	clientAPIKey := ""
 	if ctx != nil {
 		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
 			key = strings.TrimSpace(ginCtx.GetHeader("Idempotency-Key"))
+			clientAPIKey = strings.TrimSpace(ginCtx.GetString("apiKey"))
 		}
 	}
 	if key == "" {
@@ -199,6 +244,9 @@ func requestExecutionMetadata(ctx context.Context) map[string]any {
 	}
 
 	meta := map[string]any{idempotencyKeyMetadataKey: key}
+	if clientAPIKey != "" {
+		meta[coreexecutor.ClientAPIKeyMetadataKey] = clientAPIKey
+	}
 	if pinnedAuthID := pinnedAuthIDFromContext(ctx); pinnedAuthID != "" {
 		meta[coreexecutor.PinnedAuthMetadataKey] = pinnedAuthID
 	}
@@ -338,19 +386,34 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 			parentCtx = logging.WithRequestID(parentCtx, requestID)
 		}
 	}
-	newCtx, cancel := context.WithCancel(parentCtx)
+	newCtx, cancelCause := context.WithCancelCause(parentCtx)
 	if requestCtx != nil && requestCtx != parentCtx {
+		done := newCtx.Done()
 		go func() {
 			select {
 			case <-requestCtx.Done():
-				cancel()
-			case <-newCtx.Done():
+				cancelCause(coreexecutor.ErrClientCancelled)
+			case <-done:
 			}
 		}()
 	}
 	newCtx = context.WithValue(newCtx, "gin", c)
 	newCtx = context.WithValue(newCtx, "handler", handler)
 	return newCtx, func(params ...interface{}) {
+		// Cancelling after the client went away, or on its explicit request, records
+		// ErrClientCancelled as the cause so executors account the attempt as
+		// client-cancelled rather than failed.
+		clientCancelled := requestCtx != nil && requestCtx.Err() != nil
+		if len(params) == 1 {
+			if err, ok := params[0].(error); ok && errors.Is(err, coreexecutor.ErrClientCancelled) {
+				clientCancelled = true
+			}
+		}
+		cancel := func() { cancelCause(nil) }
+		if clientCancelled {
+			log.Infof("request %s cancelled by client", logging.GetRequestID(newCtx))
+			cancel = func() { cancelCause(coreexecutor.ErrClientCancelled) }
+		}
 		if h.Cfg.RequestLog && len(params) == 1 {
 			if existing, exists := c.Get("API_RESPONSE"); exists {
 				if existingBytes, ok := existing.([]byte); ok && len(bytes.TrimSpace(existingBytes)) > 0 {
@@ -468,12 +531,36 @@ func appendAPIResponse(c *gin.Context, data []byte) {
 // ExecuteWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
-	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	routedModel, routingScript := applyRoutingScript(ctx, handlerType, modelName, false)
+	providers, normalizedModel, passthrough, errMsg := h.getRequestDetails(routedModel)
+	if errMsg == nil {
+		errMsg = checkModelAllowed(h.Cfg, ctx, modelName, normalizedModel)
+	}
+	if errMsg == nil {
+		errMsg = checkRequestLimits(h.Cfg, rawJSON)
+	}
+	if errMsg == nil {
+		rawJSON, errMsg = applyLoopDetection(h.Cfg, ctx, rawJSON)
+	}
+	if errMsg == nil {
+		ctx, errMsg = checkSessionLimits(h.Cfg, ctx, rawJSON)
+	}
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
+	if passthrough {
+		reqMeta[coreexecutor.PassthroughModelMetadataKey] = true
+	}
+	if routingScript != "" {
+		reqMeta[coreexecutor.RoutingScriptMetadataKey] = routingScript
+	}
+	if h.Cfg != nil && h.Cfg.ParameterHeaders {
+		if params, ok := parameterHeaderOverrides(ctx); ok {
+			reqMeta[coreexecutor.ParameterHeadersMetadataKey] = params
+		}
+	}
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -489,7 +576,19 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		SourceFormat:    sdktranslator.FromString(handlerType),
 	}
 	opts.Metadata = reqMeta
+	ctx, capture := replay.Begin(ctx, h.Cfg, handlerType, normalizedModel, rawJSON)
 	resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
+	var downgrade *modelDowngrade
+	if err != nil {
+		downgrade, err = h.tryModelFallbacks(ctx, normalizedModel, err, req, opts, func(attemptCtx context.Context, attemptProviders []string, attemptReq coreexecutor.Request, attemptOpts coreexecutor.Options) error {
+			var errAttempt error
+			resp, errAttempt = h.AuthManager.Execute(attemptCtx, attemptProviders, attemptReq, attemptOpts)
+			return errAttempt
+		})
+	}
+	if capture != nil {
+		go capture.Finish(err == nil)
+	}
 	if err != nil {
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
@@ -505,21 +604,36 @@ func (h *BaseAPIHandler) ExecuteWithAuthManager(ctx context.Context, handlerType
 		}
 		return nil, nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
-	if !PassthroughHeadersEnabled(h.Cfg) {
-		return resp.Payload, nil, nil
-	}
-	return resp.Payload, FilterUpstreamHeaders(resp.Headers), nil
+	return resp.Payload, withModelDowngradeHeader(PassthroughUpstreamHeaders(h.Cfg, resp.Headers), downgrade), nil
 }
 
 // ExecuteCountWithAuthManager executes a non-streaming request via the core auth manager.
 // This path is the only supported execution route.
 func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) ([]byte, http.Header, *interfaces.ErrorMessage) {
-	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	routedModel, routingScript := applyRoutingScript(ctx, handlerType, modelName, false)
+	providers, normalizedModel, passthrough, errMsg := h.getRequestDetails(routedModel)
+	if errMsg == nil {
+		errMsg = checkModelAllowed(h.Cfg, ctx, modelName, normalizedModel)
+	}
+	if errMsg == nil {
+		errMsg = checkRequestLimits(h.Cfg, rawJSON)
+	}
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
+	if passthrough {
+		reqMeta[coreexecutor.PassthroughModelMetadataKey] = true
+	}
+	if routingScript != "" {
+		reqMeta[coreexecutor.RoutingScriptMetadataKey] = routingScript
+	}
+	if h.Cfg != nil && h.Cfg.ParameterHeaders {
+		if params, ok := parameterHeaderOverrides(ctx); ok {
+			reqMeta[coreexecutor.ParameterHeadersMetadataKey] = params
+		}
+	}
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -551,17 +665,27 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 		}
 		return nil, nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
 	}
-	if !PassthroughHeadersEnabled(h.Cfg) {
-		return resp.Payload, nil, nil
-	}
-	return resp.Payload, FilterUpstreamHeaders(resp.Headers), nil
+	return resp.Payload, PassthroughUpstreamHeaders(h.Cfg, resp.Headers), nil
 }
 
 // ExecuteStreamWithAuthManager executes a streaming request via the core auth manager.
 // This path is the only supported execution route.
 // The returned http.Header carries upstream response headers captured before streaming begins.
 func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
-	providers, normalizedModel, errMsg := h.getRequestDetails(modelName)
+	routedModel, routingScript := applyRoutingScript(ctx, handlerType, modelName, true)
+	providers, normalizedModel, passthrough, errMsg := h.getRequestDetails(routedModel)
+	if errMsg == nil {
+		errMsg = checkModelAllowed(h.Cfg, ctx, modelName, normalizedModel)
+	}
+	if errMsg == nil {
+		errMsg = checkRequestLimits(h.Cfg, rawJSON)
+	}
+	if errMsg == nil {
+		rawJSON, errMsg = applyLoopDetection(h.Cfg, ctx, rawJSON)
+	}
+	if errMsg == nil {
+		ctx, errMsg = checkSessionLimits(h.Cfg, ctx, rawJSON)
+	}
 	if errMsg != nil {
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		errChan <- errMsg
@@ -570,6 +694,17 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	}
 	reqMeta := requestExecutionMetadata(ctx)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = normalizedModel
+	if passthrough {
+		reqMeta[coreexecutor.PassthroughModelMetadataKey] = true
+	}
+	if routingScript != "" {
+		reqMeta[coreexecutor.RoutingScriptMetadataKey] = routingScript
+	}
+	if h.Cfg != nil && h.Cfg.ParameterHeaders {
+		if params, ok := parameterHeaderOverrides(ctx); ok {
+			reqMeta[coreexecutor.ParameterHeadersMetadataKey] = params
+		}
+	}
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -585,8 +720,28 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 		SourceFormat:    sdktranslator.FromString(handlerType),
 	}
 	opts.Metadata = reqMeta
-	streamResult, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+	ctx, capture := replay.Begin(ctx, h.Cfg, handlerType, normalizedModel, rawJSON)
+	stallTimeout := StreamingStallTimeout(h.Cfg)
+	attemptCtx, cancelAttempt := streamAttemptContext(ctx, stallTimeout)
+	streamResult, err := h.AuthManager.ExecuteStream(attemptCtx, providers, req, opts)
+	var downgrade *modelDowngrade
+	if err != nil {
+		// A downgraded stream keeps its fallback model, and its usage marker, for bootstrap
+		// and stall retries.
+		downgrade, err = h.tryModelFallbacks(ctx, normalizedModel, err, req, opts, func(fallbackCtx context.Context, fallbackProviders []string, fallbackReq coreexecutor.Request, fallbackOpts coreexecutor.Options) error {
+			cancelAttempt(nil)
+			attemptCtx, cancelAttempt = streamAttemptContext(fallbackCtx, stallTimeout)
+			result, errAttempt := h.AuthManager.ExecuteStream(attemptCtx, fallbackProviders, fallbackReq, fallbackOpts)
+			if errAttempt != nil {
+				return errAttempt
+			}
+			ctx, providers, req, opts, streamResult = fallbackCtx, fallbackProviders, fallbackReq, fallbackOpts, result
+			normalizedModel = fallbackReq.Model
+			return nil
+		})
+	}
 	if err != nil {
+		cancelAttempt(nil)
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		status := http.StatusInternalServerError
 		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
@@ -609,20 +764,60 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 	// Keep a mutable map so bootstrap retries can replace it before first payload is sent.
 	var upstreamHeaders http.Header
 	if passthroughHeadersEnabled {
-		upstreamHeaders = cloneHeader(FilterUpstreamHeaders(streamResult.Headers))
+		upstreamHeaders = cloneHeader(PassthroughUpstreamHeaders(h.Cfg, streamResult.Headers))
 		if upstreamHeaders == nil {
 			upstreamHeaders = make(http.Header)
 		}
 	}
+	upstreamHeaders = withModelDowngradeHeader(upstreamHeaders, downgrade)
 	chunks := streamResult.Chunks
 	dataChan := make(chan []byte)
 	errChan := make(chan *interfaces.ErrorMessage, 1)
 	go func() {
 		defer close(dataChan)
 		defer close(errChan)
+		completed := false
+		defer func() { capture.Finish(completed) }()
+		defer func() { cancelAttempt(nil) }()
 		sentPayload := false
 		bootstrapRetries := 0
 		maxBootstrapRetries := StreamingBootstrapRetries(h.Cfg)
+		stallRetries := 0
+		maxStallRetries := StreamingStallRetries(h.Cfg)
+		apiKey := util.HideAPIKey(clientAPIKey(ctx))
+		tracker := usage.GetStreamStatsStore().Start(thinking.ParseSuffix(normalizedModel).ModelName, apiKey)
+		defer tracker.Finish()
+
+		var done <-chan struct{}
+		if ctx != nil {
+			done = ctx.Done()
+		}
+		var stallTimer *time.Timer
+		var stallC <-chan time.Time
+		if stallTimeout > 0 {
+			stallTimer = time.NewTimer(stallTimeout)
+			defer stallTimer.Stop()
+			stallC = stallTimer.C
+		}
+
+		// restart abandons the current upstream attempt and starts a new one.
+		restart := func() error {
+			cancelAttempt(nil)
+			attemptCtx, cancelAttempt = streamAttemptContext(ctx, stallTimeout)
+			retryResult, retryErr := h.AuthManager.ExecuteStream(attemptCtx, providers, req, opts)
+			if retryErr != nil {
+				return retryErr
+			}
+			if passthroughHeadersEnabled {
+				replaceHeader(upstreamHeaders, PassthroughUpstreamHeaders(h.Cfg, retryResult.Headers))
+				withModelDowngradeHeader(upstreamHeaders, downgrade)
+			}
+			chunks = retryResult.Chunks
+			if stallTimer != nil {
+				stallTimer.Reset(stallTimeout)
+			}
+			return nil
+		}
 
 		sendErr := func(msg *interfaces.ErrorMessage) bool {
 			if ctx == nil {
@@ -669,16 +864,40 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 			for {
 				var chunk coreexecutor.StreamChunk
 				var ok bool
-				if ctx != nil {
-					select {
-					case <-ctx.Done():
-						return
-					case chunk, ok = <-chunks:
+				select {
+				case <-done:
+					return
+				case chunk, ok = <-chunks:
+				case <-stallC:
+					tracker.Stalled()
+					retry := !sentPayload && stallRetries < maxStallRetries
+					log.WithFields(log.Fields{
+						"model":        normalizedModel,
+						"api_key":      apiKey,
+						"idle_seconds": stallTimeout.Seconds(),
+						"sent_payload": sentPayload,
+						"retry":        retry,
+					}).Warn("stream stalled: no upstream data within the stall timeout")
+					if retry {
+						stallRetries++
+						errRestart := restart()
+						if errRestart == nil {
+							continue outer
+						}
+						log.Warnf("stream stall retry failed: %v", errRestart)
 					}
-				} else {
-					chunk, ok = <-chunks
+					cancelAttempt(errStreamStalled)
+					_ = sendErr(&interfaces.ErrorMessage{
+						StatusCode: http.StatusGatewayTimeout,
+						Error:      fmt.Errorf("upstream stream stalled: no data received for %s", stallTimeout),
+					})
+					return
+				}
+				if stallTimer != nil {
+					stallTimer.Reset(stallTimeout)
 				}
 				if !ok {
+					completed = true
 					return
 				}
 				if chunk.Err != nil {
@@ -688,12 +907,8 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					if !sentPayload {
 						if bootstrapRetries < maxBootstrapRetries && bootstrapEligible(streamErr) {
 							bootstrapRetries++
-							retryResult, retryErr := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
+							retryErr := restart()
 							if retryErr == nil {
-								if passthroughHeadersEnabled {
-									replaceHeader(upstreamHeaders, FilterUpstreamHeaders(retryResult.Headers))
-								}
-								chunks = retryResult.Chunks
 								continue outer
 							}
 							streamErr = retryErr
@@ -716,6 +931,7 @@ func (h *BaseAPIHandler) ExecuteStreamWithAuthManager(ctx context.Context, handl
 					return
 				}
 				if len(chunk.Payload) > 0 {
+					tracker.Observe(estimateStreamTokens(chunk.Payload))
 					sentPayload = true
 					if okSendData := sendData(cloneBytes(chunk.Payload)); !okSendData {
 						return
@@ -739,7 +955,10 @@ func statusFromError(err error) int {
 	return 0
 }
 
-func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string, normalizedModel string, err *interfaces.ErrorMessage) {
+// getRequestDetails resolves the providers and model name for a request. passthrough
+// reports that the unknown-model policy forwards the model verbatim, in which case the
+// registry model check is skipped during auth selection.
+func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string, normalizedModel string, passthrough bool, err *interfaces.ErrorMessage) {
 	resolvedModelName := modelName
 	initialSuffix := thinking.ParseSuffix(modelName)
 	if initialSuffix.ModelName == "auto" {
@@ -766,13 +985,34 @@ func (h *BaseAPIHandler) getRequestDetails(modelName string) (providers []string
 		providers = util.GetProviderName(resolvedModelName)
 	}
 
+	// Unknown models fall through the configured routing rules and catch-all default.
+	// A suffix on the route target takes priority over the client's suffix.
+	if len(providers) == 0 && h.Cfg != nil {
+		if target, ok := util.ResolveModelRoute(h.Cfg.ModelRouting, baseModel); ok {
+			routed := thinking.ParseSuffix(target)
+			resolvedModelName = target
+			if !routed.HasSuffix && parsed.HasSuffix && parsed.RawSuffix != "" {
+				resolvedModelName = fmt.Sprintf("%s(%s)", target, parsed.RawSuffix)
+			}
+			providers = util.GetProviderName(strings.TrimSpace(routed.ModelName))
+		}
+	}
+
+	if len(providers) == 0 && h.Cfg != nil && h.Cfg.ModelRouting.UnknownModelPolicy() == config.UnknownModelPassthrough {
+		provider := strings.ToLower(strings.TrimSpace(h.Cfg.ModelRouting.PassthroughProvider))
+		return []string{provider}, resolvedModelName, true, nil
+	}
+
 	if len(providers) == 0 {
-		return nil, "", &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: fmt.Errorf("unknown provider for model %s", modelName)}
+		return nil, "", false, &interfaces.ErrorMessage{
+			StatusCode: http.StatusNotFound,
+			Error:      fmt.Errorf("The model `%s` does not exist or you do not have access to it.", modelName),
+		}
 	}
 
 	// The thinking suffix is preserved in the model name itself, so no
 	// metadata-based configuration passing is needed.
-	return providers, resolvedModelName, nil
+	return providers, resolvedModelName, false, nil
 }
 
 func cloneBytes(src []byte) []byte {
@@ -811,7 +1051,11 @@ func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.Erro
 		status = msg.StatusCode
 	}
 	if msg != nil && msg.Addon != nil && PassthroughHeadersEnabled(h.Cfg) {
-		for key, values := range msg.Addon {
+		addon := msg.Addon
+		if !h.Cfg.PassthroughHeaders {
+			addon = AllowlistUpstreamHeaders(addon, h.Cfg.PassthroughHeaderAllowlist)
+		}
+		for key, values := range addon {
 			if len(values) == 0 {
 				continue
 			}
@@ -830,6 +1074,15 @@ func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.Erro
 	}
 
 	body := BuildErrorResponseBody(status, errText)
+	if status == http.StatusTooManyRequests {
+		// Upstream Retry-After is only visible here when header passthrough copied it above.
+		retryAfter := c.Writer.Header().Get("Retry-After")
+		var retrySeconds int
+		body, retrySeconds = withRateLimitHints(usage.GetRateLimitStore(), body, retryAfter, time.Now())
+		if retrySeconds > 0 && retryAfter == "" {
+			c.Writer.Header().Set("Retry-After", strconv.Itoa(retrySeconds))
+		}
+	}
 	// Append first to preserve upstream response logs, then drop duplicate payloads if already recorded.
 	var previous []byte
 	if existing, exists := c.Get("API_RESPONSE"); exists {
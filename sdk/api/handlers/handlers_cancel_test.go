@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestGetContextWithCancelMarksClientCancellation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+	newContext := func() (context.Context, APIHandlerCancelFunc, context.CancelFunc) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		requestCtx, disconnect := context.WithCancel(context.Background())
+		c.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil).WithContext(requestCtx)
+		ctx, cancel := h.GetContextWithCancel(nil, c, context.Background())
+		return ctx, cancel, disconnect
+	}
+
+	ctx, cancel, disconnect := newContext()
+	cancel()
+	disconnect()
+	if coreexecutor.ClientCancelled(ctx) {
+		t.Fatal("completed request reported as client-cancelled")
+	}
+
+	ctx, cancel, disconnect = newContext()
+	disconnect()
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("request context was not cancelled after the client disconnected")
+	}
+	cancel(coreexecutor.ErrClientCancelled)
+	if !coreexecutor.ClientCancelled(ctx) {
+		t.Fatalf("context cause = %v, want ErrClientCancelled", context.Cause(ctx))
+	}
+}
@@ -1,7 +1,9 @@
 package handlers
 
 import (
+	"net/http"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -100,7 +102,7 @@ func TestGetRequestDetails_PreservesSuffix(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			providers, model, errMsg := handler.getRequestDetails(tt.inputModel)
+			providers, model, _, errMsg := handler.getRequestDetails(tt.inputModel)
 			if (errMsg != nil) != tt.wantErr {
 				t.Fatalf("getRequestDetails() error = %v, wantErr %v", errMsg, tt.wantErr)
 			}
@@ -116,3 +118,94 @@ func TestGetRequestDetails_PreservesSuffix(t *testing.T) {
 		})
 	}
 }
+
+func TestGetRequestDetails_ModelRouting(t *testing.T) {
+	modelRegistry := registry.GetGlobalRegistry()
+	now := time.Now().Unix()
+	modelRegistry.RegisterClient("test-request-routing-claude", "claude", []*registry.ModelInfo{
+		{ID: "claude-sonnet-4-5", Created: now},
+	})
+	modelRegistry.RegisterClient("test-request-routing-gemini", "gemini", []*registry.ModelInfo{
+		{ID: "gemini-2.5-flash", Created: now},
+	})
+	t.Cleanup(func() {
+		modelRegistry.UnregisterClient("test-request-routing-claude")
+		modelRegistry.UnregisterClient("test-request-routing-gemini")
+	})
+
+	cfg := &sdkconfig.SDKConfig{ModelRouting: sdkconfig.ModelRoutingConfig{
+		Rules: []sdkconfig.ModelRouteRule{
+			{Match: "gpt-4*", Target: "claude-sonnet-4-5"},
+			{Match: "^o[0-9]+(-mini)?$", Regex: true, Target: "gemini-2.5-flash(low)"},
+			{Match: "unserved-*", Target: "missing-model"},
+		},
+		Default: "gemini-2.5-flash",
+	}}
+	handler := NewBaseAPIHandlers(cfg, coreauth.NewManager(nil, nil, nil))
+
+	tests := []struct {
+		name          string
+		inputModel    string
+		wantProviders []string
+		wantModel     string
+	}{
+		{name: "wildcard keeps client suffix", inputModel: "GPT-4o(high)", wantProviders: []string{"claude"}, wantModel: "claude-sonnet-4-5(high)"},
+		{name: "regex target suffix wins", inputModel: "o3-mini(high)", wantProviders: []string{"gemini"}, wantModel: "gemini-2.5-flash(low)"},
+		{name: "unserved target falls to default", inputModel: "unserved-x", wantProviders: []string{"gemini"}, wantModel: "gemini-2.5-flash"},
+		{name: "catch-all default", inputModel: "brand-new-model", wantProviders: []string{"gemini"}, wantModel: "gemini-2.5-flash"},
+		{name: "served model not rerouted", inputModel: "claude-sonnet-4-5", wantProviders: []string{"claude"}, wantModel: "claude-sonnet-4-5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providers, model, _, errMsg := handler.getRequestDetails(tt.inputModel)
+			if errMsg != nil {
+				t.Fatalf("getRequestDetails() error = %v", errMsg.Error)
+			}
+			if !reflect.DeepEqual(providers, tt.wantProviders) {
+				t.Fatalf("providers = %v, want %v", providers, tt.wantProviders)
+			}
+			if model != tt.wantModel {
+				t.Fatalf("model = %v, want %v", model, tt.wantModel)
+			}
+		})
+	}
+}
+
+func TestGetRequestDetails_UnknownModelPolicy(t *testing.T) {
+	modelRegistry := registry.GetGlobalRegistry()
+	modelRegistry.RegisterClient("test-request-policy-gemini", "gemini", []*registry.ModelInfo{
+		{ID: "gemini-2.5-flash", Created: time.Now().Unix()},
+	})
+	t.Cleanup(func() { modelRegistry.UnregisterClient("test-request-policy-gemini") })
+
+	reject := NewBaseAPIHandlers(&sdkconfig.SDKConfig{ModelRouting: sdkconfig.ModelRoutingConfig{
+		UnknownModel: sdkconfig.UnknownModelReject,
+		Default:      "gemini-2.5-flash",
+	}}, coreauth.NewManager(nil, nil, nil))
+	_, _, _, errMsg := reject.getRequestDetails("brand-new-model")
+	if errMsg == nil || errMsg.StatusCode != http.StatusNotFound {
+		t.Fatalf("reject policy error = %+v, want 404", errMsg)
+	}
+	if body := string(BuildErrorResponseBody(errMsg.StatusCode, errMsg.Error.Error())); !strings.Contains(body, `"code":"model_not_found"`) {
+		t.Fatalf("reject body = %s, want model_not_found", body)
+	}
+
+	passthrough := NewBaseAPIHandlers(&sdkconfig.SDKConfig{ModelRouting: sdkconfig.ModelRoutingConfig{
+		UnknownModel:        sdkconfig.UnknownModelPassthrough,
+		PassthroughProvider: "My-Compat",
+		Default:             "gemini-2.5-flash",
+	}}, coreauth.NewManager(nil, nil, nil))
+	providers, model, isPassthrough, errMsg := passthrough.getRequestDetails("brand-new-model(high)")
+	if errMsg != nil {
+		t.Fatalf("passthrough policy error = %v", errMsg.Error)
+	}
+	if !isPassthrough || !reflect.DeepEqual(providers, []string{"my-compat"}) || model != "brand-new-model(high)" {
+		t.Fatalf("passthrough = %v providers = %v model = %s", isPassthrough, providers, model)
+	}
+
+	// Served models are unaffected by the policy.
+	providers, _, isPassthrough, errMsg = passthrough.getRequestDetails("gemini-2.5-flash")
+	if errMsg != nil || isPassthrough || !reflect.DeepEqual(providers, []string{"gemini"}) {
+		t.Fatalf("served model: providers = %v passthrough = %v err = %v", providers, isPassthrough, errMsg)
+	}
+}
@@ -48,6 +48,45 @@ func FilterUpstreamHeaders(src http.Header) http.Header {
 	return dst
 }
 
+// AllowlistUpstreamHeaders returns the headers of src whose names match allowlist.
+// Entries are case-insensitive header names; a trailing '*' matches any header with
+// that prefix. Returns nil when nothing matches.
+func AllowlistUpstreamHeaders(src http.Header, allowlist []string) http.Header {
+	if len(src) == 0 || len(allowlist) == 0 {
+		return nil
+	}
+	dst := make(http.Header)
+	for key, values := range src {
+		if headerAllowed(key, allowlist) {
+			dst[key] = values
+		}
+	}
+	if len(dst) == 0 {
+		return nil
+	}
+	return dst
+}
+
+func headerAllowed(name string, allowlist []string) bool {
+	name = strings.ToLower(name)
+	for _, entry := range allowlist {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if prefix, ok := strings.CutSuffix(entry, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+			continue
+		}
+		if name == entry {
+			return true
+		}
+	}
+	return false
+}
+
 func connectionScopedHeaders(src http.Header) map[string]struct{} {
 	scoped := make(map[string]struct{})
 	for _, rawValue := range src.Values("Connection") {
@@ -3,6 +3,8 @@ package handlers
 import (
 	"net/http"
 	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 )
 
 func TestFilterUpstreamHeaders_RemovesConnectionScopedHeaders(t *testing.T) {
@@ -53,3 +55,32 @@ func TestFilterUpstreamHeaders_ReturnsNilWhenAllHeadersBlocked(t *testing.T) {
 		t.Fatalf("expected nil when all headers are filtered, got %#v", filtered)
 	}
 }
+
+func TestPassthroughUpstreamHeaders_Allowlist(t *testing.T) {
+	src := http.Header{}
+	src.Set("Request-Id", "req_1")
+	src.Set("Anthropic-Version", "2023-06-01")
+	src.Set("Anthropic-Ratelimit-Requests-Remaining", "9")
+	src.Set("X-Internal", "secret")
+	src.Set("Transfer-Encoding", "chunked")
+
+	cfg := &config.SDKConfig{PassthroughHeaderAllowlist: []string{"request-id", "Anthropic-*", "transfer-encoding"}}
+	got := PassthroughUpstreamHeaders(cfg, src)
+	for _, key := range []string{"Request-Id", "Anthropic-Version", "Anthropic-Ratelimit-Requests-Remaining"} {
+		if got.Get(key) == "" {
+			t.Fatalf("expected %s to be forwarded, got %#v", key, got)
+		}
+	}
+	for _, key := range []string{"X-Internal", "Transfer-Encoding"} {
+		if got.Get(key) != "" {
+			t.Fatalf("expected %s to be dropped, got %#v", key, got)
+		}
+	}
+
+	if got := PassthroughUpstreamHeaders(&config.SDKConfig{}, src); got != nil {
+		t.Fatalf("expected no headers when passthrough is disabled, got %#v", got)
+	}
+	if got := PassthroughUpstreamHeaders(&config.SDKConfig{PassthroughHeaders: true}, src); got.Get("X-Internal") == "" {
+		t.Fatalf("expected full passthrough to ignore the allowlist, got %#v", got)
+	}
+}
@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// toolLoop describes the trailing run of identical tool calls in a request history.
+type toolLoop struct {
+	tool    string
+	repeats int
+	// history is the path of the history array and last the index of its final item,
+	// which holds the tool results answering the run.
+	history string
+	last    int
+}
+
+// applyLoopDetection enforces loop-detection for a request. When the history ends with
+// the results of at least threshold identical tool calls in a row, it either appends a
+// warning to the latest tool result or rejects the request, depending on the action
+// configured for the client API key.
+func applyLoopDetection(cfg *config.SDKConfig, ctx context.Context, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	if cfg == nil || len(rawJSON) == 0 {
+		return rawJSON, nil
+	}
+	apiKey := clientAPIKey(ctx)
+	threshold, action := cfg.LoopDetection.Policy(apiKey)
+	if threshold <= 0 {
+		return rawJSON, nil
+	}
+	loop, ok := detectToolLoop(gjson.ParseBytes(rawJSON))
+	if !ok || loop.repeats < threshold {
+		return rawJSON, nil
+	}
+	log.Warnf("loop detection: tool %s called %d times in a row with identical arguments (key %s, action %s)", loop.tool, loop.repeats, util.HideAPIKey(apiKey), action)
+
+	if action == config.LoopDetectionTerminate {
+		message := fmt.Sprintf("The agent called the tool `%s` %d times in a row with identical arguments, reaching the loop-detection threshold of %d. The request was stopped to avoid spending tokens on a stuck agent.", loop.tool, loop.repeats, threshold)
+		body, _ := json.Marshal(map[string]any{"error": map[string]any{
+			"message": message,
+			"type":    "invalid_request_error",
+			"code":    "tool_loop_detected",
+			"param":   nil,
+		}})
+		return rawJSON, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("%s", body)}
+	}
+	warning := fmt.Sprintf("[Proxy warning] You have called the tool `%s` %d times in a row with identical arguments and received the same kind of result. Repeating the call will not change the outcome. Change your approach: use different arguments, try another tool, or explain to the user why you are stuck.", loop.tool, loop.repeats)
+	return injectLoopWarning(rawJSON, loop, warning), nil
+}
+
+// detectToolLoop finds the trailing run of identical tool calls in an OpenAI Chat,
+// Claude, OpenAI Responses or Gemini history. It reports a loop only when the history
+// ends with tool results, i.e. the agent is in the middle of a tool loop.
+func detectToolLoop(root gjson.Result) (toolLoop, bool) {
+	for _, path := range []string{"messages", "contents", "input", "request.contents"} {
+		history := root.Get(path)
+		if !history.IsArray() {
+			continue
+		}
+		items := history.Array()
+		if len(items) == 0 || !isToolResultItem(items[len(items)-1]) {
+			return toolLoop{}, false
+		}
+		var previous, tool string
+		repeats := 0
+		for _, item := range items {
+			forEachToolCall(item, func(name, signature string) {
+				if signature == previous {
+					repeats++
+					return
+				}
+				previous, tool, repeats = signature, name, 1
+			})
+		}
+		if repeats == 0 {
+			return toolLoop{}, false
+		}
+		return toolLoop{tool: tool, repeats: repeats, history: path, last: len(items) - 1}, true
+	}
+	return toolLoop{}, false
+}
+
+// forEachToolCall calls fn with the name and a name+arguments signature of every tool
+// call in a history item.
+func forEachToolCall(item gjson.Result, fn func(name, signature string)) {
+	emit := func(name string, args gjson.Result) {
+		raw := args.Raw
+		if args.Type == gjson.String {
+			raw = args.String()
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, []byte(raw)); err == nil {
+			raw = compact.String()
+		}
+		fn(name, name+"\x00"+raw)
+	}
+	// OpenAI Chat Completions: assistant tool_calls.
+	item.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+		emit(call.Get("function.name").String(), call.Get("function.arguments"))
+		return true
+	})
+	// OpenAI Responses: function_call items.
+	if item.Get("type").String() == "function_call" {
+		emit(item.Get("name").String(), item.Get("arguments"))
+	}
+	// Claude: tool_use content blocks.
+	item.Get("content").ForEach(func(_, block gjson.Result) bool {
+		if block.Get("type").String() == "tool_use" {
+			emit(block.Get("name").String(), block.Get("input"))
+		}
+		return true
+	})
+	// Gemini: functionCall parts.
+	item.Get("parts").ForEach(func(_, part gjson.Result) bool {
+		if call := part.Get("functionCall"); call.Exists() {
+			emit(call.Get("name").String(), call.Get("args"))
+		}
+		return true
+	})
+}
+
+// isToolResultItem reports whether a history item carries tool results.
+func isToolResultItem(item gjson.Result) bool {
+	if item.Get("role").String() == "tool" || item.Get("type").String() == "function_call_output" {
+		return true
+	}
+	found := false
+	item.Get("content").ForEach(func(_, block gjson.Result) bool {
+		found = block.Get("type").String() == "tool_result"
+		return !found
+	})
+	if found {
+		return true
+	}
+	item.Get("parts").ForEach(func(_, part gjson.Result) bool {
+		found = part.Get("functionResponse").Exists()
+		return !found
+	})
+	return found
+}
+
+// injectLoopWarning appends warning to the tool result in the last history item, in the
+// item's own format.
+func injectLoopWarning(rawJSON []byte, loop toolLoop, warning string) []byte {
+	itemPath := loop.history + "." + strconv.Itoa(loop.last)
+	item := gjson.GetBytes(rawJSON, itemPath)
+	var out []byte
+	var err error
+	switch {
+	case item.Get("role").String() == "tool":
+		out, err = appendToolResultText(rawJSON, itemPath+".content", item.Get("content"), warning, "text")
+	case item.Get("type").String() == "function_call_output":
+		out, err = appendToolResultText(rawJSON, itemPath+".output", item.Get("output"), warning, "input_text")
+	case item.Get("parts").IsArray():
+		out, err = sjson.SetBytes(rawJSON, itemPath+".parts.-1", map[string]string{"text": warning})
+	default:
+		last := -1
+		item.Get("content").ForEach(func(key, block gjson.Result) bool {
+			if block.Get("type").String() == "tool_result" {
+				last = int(key.Int())
+			}
+			return true
+		})
+		if last < 0 {
+			return rawJSON
+		}
+		contentPath := itemPath + ".content." + strconv.Itoa(last) + ".content"
+		out, err = appendToolResultText(rawJSON, contentPath, gjson.GetBytes(rawJSON, contentPath), warning, "text")
+	}
+	if err != nil {
+		log.Debugf("loop detection: inject warning: %v", err)
+		return rawJSON
+	}
+	return out
+}
+
+// appendToolResultText appends text to a tool result that is either a string or an array
+// of content parts, using partType for a new part.
+func appendToolResultText(rawJSON []byte, path string, value gjson.Result, text, partType string) ([]byte, error) {
+	if value.IsArray() {
+		return sjson.SetBytes(rawJSON, path+".-1", map[string]string{"type": partType, "text": text})
+	}
+	if existing := value.String(); existing != "" {
+		text = existing + "\n\n" + text
+	}
+	return sjson.SetBytes(rawJSON, path, text)
+}
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyLoopDetectionWarn(t *testing.T) {
+	cfg := &config.SDKConfig{}
+	cfg.LoopDetection.Threshold = 3
+
+	call := `{"role":"assistant","tool_calls":[{"id":"c","type":"function","function":{"name":"read_file","arguments":"{\"path\": \"a.go\"}"}}]}`
+	result := `{"role":"tool","tool_call_id":"c","content":"not found"}`
+	openAI := `{"messages":[{"role":"user","content":"fix it"},` + strings.Repeat(call+","+result+",", 2) + call + "," + result + `]}`
+
+	out, errMsg := applyLoopDetection(cfg, context.Background(), []byte(openAI))
+	if errMsg != nil {
+		t.Fatalf("warn action should not reject: %v", errMsg.Error)
+	}
+	content := gjson.GetBytes(out, "messages.6.content").String()
+	if !strings.HasPrefix(content, "not found\n\n[Proxy warning]") || !strings.Contains(content, "`read_file` 3 times") {
+		t.Fatalf("unexpected tool result %q", content)
+	}
+
+	twice := `{"messages":[` + call + "," + result + "," + call + "," + result + `]}`
+	if out, _ = applyLoopDetection(cfg, context.Background(), []byte(twice)); string(out) != twice {
+		t.Fatal("two repeats should be below the threshold")
+	}
+
+	gemini := `{"contents":[{"role":"model","parts":[{"functionCall":{"name":"ls","args":{"dir":"."}}}]},{"role":"user","parts":[{"functionResponse":{"name":"ls","response":{}}}]},{"role":"model","parts":[{"functionCall":{"name":"ls","args":{"dir":"."}}}]},{"role":"user","parts":[{"functionResponse":{"name":"ls","response":{}}}]},{"role":"model","parts":[{"functionCall":{"name":"ls","args":{"dir":"."}}}]},{"role":"user","parts":[{"functionResponse":{"name":"ls","response":{}}}]}]}`
+	out, _ = applyLoopDetection(cfg, context.Background(), []byte(gemini))
+	if !strings.HasPrefix(gjson.GetBytes(out, "contents.5.parts.1.text").String(), "[Proxy warning]") {
+		t.Fatalf("expected a warning part, got %s", out)
+	}
+}
+
+func TestApplyLoopDetectionTerminatePerKey(t *testing.T) {
+	cfg := &config.SDKConfig{}
+	cfg.LoopDetection.Threshold = 10
+	cfg.LoopDetection.Overrides = []config.LoopDetectionOverride{{APIKey: "sk-agent", Threshold: 2, Action: "terminate"}}
+
+	use := `{"role":"assistant","content":[{"type":"tool_use","id":"t","name":"bash","input":{"command":"make"}}]}`
+	result := `{"role":"user","content":[{"type":"tool_result","tool_use_id":"t","content":"error"}]}`
+	changed := `{"role":"assistant","content":[{"type":"tool_use","id":"t","name":"bash","input":{"command":"make test"}}]}`
+	claude := []byte(`{"messages":[` + use + "," + result + "," + use + "," + result + `]}`)
+
+	if _, errMsg := applyLoopDetection(cfg, context.Background(), claude); errMsg != nil {
+		t.Fatal("other keys should use the global threshold")
+	}
+
+	gin.SetMode(gin.TestMode)
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Set("apiKey", "sk-agent")
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+	_, errMsg := applyLoopDetection(cfg, ctx, claude)
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest || gjson.Get(errMsg.Error.Error(), "error.code").String() != "tool_loop_detected" {
+		t.Fatalf("expected a tool_loop_detected error, got %+v", errMsg)
+	}
+
+	varied := []byte(`{"messages":[` + use + "," + result + "," + changed + "," + result + `]}`)
+	if _, errMsg = applyLoopDetection(cfg, ctx, varied); errMsg != nil {
+		t.Fatal("calls with different arguments are not a loop")
+	}
+}
@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// ModelDowngradeHeader reports that a fallback model answered the request, e.g.
+// "requested=claude-opus-4-1; served=claude-sonnet-4-5; reason=quota_exhausted".
+const ModelDowngradeHeader = "X-CLIProxy-Model-Downgrade"
+
+// modelDowngradeReason is the only reason a request is downgraded today.
+const modelDowngradeReason = "quota_exhausted"
+
+// modelDowngradeWebhookTimeout bounds a single webhook delivery.
+const modelDowngradeWebhookTimeout = 10 * time.Second
+
+var modelDowngradeClient = &http.Client{Timeout: modelDowngradeWebhookTimeout}
+
+// modelDowngrade describes a request answered by a fallback model.
+type modelDowngrade struct {
+	Requested string
+	Served    string
+}
+
+// modelDowngradeEvent is the JSON body posted to model-fallbacks.webhook-url.
+type modelDowngradeEvent struct {
+	Type           string    `json:"type"`
+	RequestedModel string    `json:"requested_model"`
+	ServedModel    string    `json:"served_model"`
+	Reason         string    `json:"reason"`
+	APIKey         string    `json:"api_key,omitempty"`
+	Timestamp      time.Time `json:"timestamp"`
+}
+
+// modelFallbacks returns the fallback models configured for a requested model. Models are
+// matched case-insensitively, first by full name and then without a thinking suffix.
+func modelFallbacks(cfg *config.SDKConfig, model string) []string {
+	if cfg == nil || len(cfg.ModelFallbacks.Models) == 0 {
+		return nil
+	}
+	candidates := []string{strings.TrimSpace(model)}
+	if base := thinking.ParseSuffix(model).ModelName; base != candidates[0] {
+		candidates = append(candidates, base)
+	}
+	for _, candidate := range candidates {
+		for name, fallbacks := range cfg.ModelFallbacks.Models {
+			if strings.EqualFold(strings.TrimSpace(name), candidate) {
+				return fallbacks
+			}
+		}
+	}
+	return nil
+}
+
+// isQuotaExhausted reports whether an execution error means no credential of the model
+// had quota left, which the auth manager reports as 429.
+func isQuotaExhausted(err error) bool {
+	if err == nil {
+		return false
+	}
+	se, ok := err.(interface{ StatusCode() int })
+	return ok && se != nil && se.StatusCode() == http.StatusTooManyRequests
+}
+
+// tryModelFallbacks retries a request that failed with err on the fallback models of
+// requested, in order, while the failures are quota exhaustion. run executes one attempt;
+// its context marks the usage as a downgrade. Fallbacks the client's API key may not use
+// are skipped. When every fallback fails, the original error is returned.
+func (h *BaseAPIHandler) tryModelFallbacks(ctx context.Context, requested string, err error, req coreexecutor.Request, opts coreexecutor.Options, run func(context.Context, []string, coreexecutor.Request, coreexecutor.Options) error) (*modelDowngrade, error) {
+	if !isQuotaExhausted(err) {
+		return nil, err
+	}
+	for _, fallback := range modelFallbacks(h.Cfg, requested) {
+		providers, model, _, errMsg := h.getRequestDetails(fallback)
+		if errMsg == nil {
+			errMsg = checkModelAllowed(h.Cfg, ctx, fallback, model)
+		}
+		if errMsg != nil {
+			log.Debugf("model fallback %s for %s skipped: %v", fallback, requested, errMsg.Error)
+			continue
+		}
+		if strings.EqualFold(model, requested) {
+			continue
+		}
+		attemptReq := req
+		attemptReq.Model = model
+		attemptOpts := opts
+		attemptOpts.Metadata = make(map[string]any, len(opts.Metadata))
+		for k, v := range opts.Metadata {
+			attemptOpts.Metadata[k] = v
+		}
+		attemptOpts.Metadata[coreexecutor.RequestedModelMetadataKey] = model
+		delete(attemptOpts.Metadata, coreexecutor.PassthroughModelMetadataKey)
+		errRun := run(coreusage.WithDowngrade(ctx, requested), providers, attemptReq, attemptOpts)
+		if errRun == nil {
+			downgrade := &modelDowngrade{Requested: requested, Served: model}
+			reportModelDowngrade(h.Cfg, ctx, downgrade)
+			return downgrade, nil
+		}
+		if !isQuotaExhausted(errRun) {
+			return nil, errRun
+		}
+	}
+	return nil, err
+}
+
+// withModelDowngradeHeader adds the downgrade header to the response headers.
+func withModelDowngradeHeader(headers http.Header, downgrade *modelDowngrade) http.Header {
+	if downgrade == nil {
+		return headers
+	}
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	headers.Set(ModelDowngradeHeader, fmt.Sprintf("requested=%s; served=%s; reason=%s", downgrade.Requested, downgrade.Served, modelDowngradeReason))
+	return headers
+}
+
+// reportModelDowngrade logs a downgrade and posts it to the configured webhook.
+func reportModelDowngrade(cfg *config.SDKConfig, ctx context.Context, downgrade *modelDowngrade) {
+	event := modelDowngradeEvent{
+		Type:           "model_downgrade",
+		RequestedModel: downgrade.Requested,
+		ServedModel:    downgrade.Served,
+		Reason:         modelDowngradeReason,
+		APIKey:         util.HideAPIKey(clientAPIKey(ctx)),
+		Timestamp:      time.Now().UTC(),
+	}
+	log.Infof("model downgrade: %s answered by %s (%s)", event.RequestedModel, event.ServedModel, event.Reason)
+	if cfg == nil {
+		return
+	}
+	webhookURL := strings.TrimSpace(cfg.ModelFallbacks.WebhookURL)
+	if webhookURL == "" {
+		return
+	}
+	go func() {
+		if errPost := postModelDowngradeEvent(context.Background(), webhookURL, event); errPost != nil {
+			log.Warnf("model downgrade webhook: %v", errPost)
+		}
+	}()
+}
+
+func postModelDowngradeEvent(ctx context.Context, webhookURL string, event modelDowngradeEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := modelDowngradeClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook returned %d", resp.StatusCode)
+	}
+	return nil
+}
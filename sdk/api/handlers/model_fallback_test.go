@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// quotaExecutor answers every model except exhausted with a payload naming the model.
+type quotaExecutor struct {
+	exhausted string
+}
+
+func (e *quotaExecutor) Identifier() string { return "codex" }
+
+func (e *quotaExecutor) Execute(_ context.Context, _ *coreauth.Auth, req coreexecutor.Request, _ coreexecutor.Options) (coreexecutor.Response, error) {
+	if req.Model == e.exhausted {
+		return coreexecutor.Response{}, &coreauth.Error{Code: "quota", Message: "quota exhausted", HTTPStatus: http.StatusTooManyRequests}
+	}
+	return coreexecutor.Response{Payload: []byte(req.Model)}, nil
+}
+
+func (e *quotaExecutor) ExecuteStream(ctx context.Context, auth *coreauth.Auth, req coreexecutor.Request, opts coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	resp, err := e.Execute(ctx, auth, req, opts)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan coreexecutor.StreamChunk, 1)
+	ch <- coreexecutor.StreamChunk{Payload: resp.Payload}
+	close(ch)
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *quotaExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *quotaExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *quotaExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func newQuotaFallbackHandler(t *testing.T, fallbacks sdkconfig.ModelFallbackConfig) *BaseAPIHandler {
+	t.Helper()
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&quotaExecutor{exhausted: "big-model"})
+	auth := &coreauth.Auth{ID: "fallback-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "big-model"}, {ID: "small-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+	return NewBaseAPIHandlers(&sdkconfig.SDKConfig{ModelFallbacks: fallbacks}, manager)
+}
+
+func TestExecuteWithAuthManager_DowngradesOnQuotaExhaustion(t *testing.T) {
+	events := make(chan modelDowngradeEvent, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event modelDowngradeEvent
+		_ = json.NewDecoder(r.Body).Decode(&event)
+		events <- event
+	}))
+	defer server.Close()
+
+	handler := newQuotaFallbackHandler(t, sdkconfig.ModelFallbackConfig{
+		Models:     map[string][]string{"big-model": {"missing-model", "small-model"}},
+		WebhookURL: server.URL,
+	})
+	payload, headers, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "big-model", []byte(`{"model":"big-model"}`), "")
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if string(payload) != "small-model" {
+		t.Fatalf("payload = %q, want small-model", payload)
+	}
+	if got, want := headers.Get(ModelDowngradeHeader), "requested=big-model; served=small-model; reason=quota_exhausted"; got != want {
+		t.Fatalf("downgrade header = %q, want %q", got, want)
+	}
+
+	select {
+	case event := <-events:
+		if event.Type != "model_downgrade" || event.RequestedModel != "big-model" || event.ServedModel != "small-model" {
+			t.Fatalf("unexpected webhook event: %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook event not delivered")
+	}
+}
+
+func TestExecuteWithAuthManager_NoFallbackKeepsQuotaError(t *testing.T) {
+	handler := newQuotaFallbackHandler(t, sdkconfig.ModelFallbackConfig{})
+	_, _, errMsg := handler.ExecuteWithAuthManager(context.Background(), "openai", "big-model", []byte(`{"model":"big-model"}`), "")
+	if errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected 429, got %+v", errMsg)
+	}
+}
+
+func TestExecuteStreamWithAuthManager_DowngradesOnQuotaExhaustion(t *testing.T) {
+	handler := newQuotaFallbackHandler(t, sdkconfig.ModelFallbackConfig{
+		Models: map[string][]string{"BIG-MODEL": {"small-model"}},
+	})
+	dataChan, headers, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "big-model", []byte(`{"model":"big-model"}`), "")
+	var got []byte
+	for chunk := range dataChan {
+		got = append(got, chunk...)
+	}
+	for errMsg := range errChan {
+		if errMsg != nil {
+			t.Fatalf("unexpected error: %v", errMsg.Error)
+		}
+	}
+	if string(got) != "small-model" {
+		t.Fatalf("payload = %q, want small-model", got)
+	}
+	if headers.Get(ModelDowngradeHeader) == "" {
+		t.Fatal("expected downgrade header on stream")
+	}
+}
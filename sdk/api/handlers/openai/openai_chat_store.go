@@ -0,0 +1,201 @@
+package openai
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// storedChatCompletions keeps completed chat completions so clients that lost the
+// connection can fetch them with GET /v1/chat/completions/{id}.
+var storedChatCompletions = newResponseStore()
+
+// chatCompletionStoreRequested reports whether a chat completion request asked to be
+// stored. Unlike the Responses API, OpenAI only stores chat completions with store=true.
+func chatCompletionStoreRequested(requestJSON []byte) bool {
+	return gjson.GetBytes(requestJSON, "store").Bool()
+}
+
+// recordChatCompletion stores a completed chat.completion object for ttl if the client
+// sent store=true.
+func recordChatCompletion(apiKey string, ttl time.Duration, requestJSON []byte, completion []byte) {
+	if ttl <= 0 || !chatCompletionStoreRequested(requestJSON) {
+		return
+	}
+	root := gjson.ParseBytes(completion)
+	id := root.Get("id").String()
+	if id == "" || root.Get("object").String() != "chat.completion" {
+		return
+	}
+	storedChatCompletions.put(&storedResponse{
+		id:        id,
+		apiKey:    apiKey,
+		body:      string(completion),
+		expiresAt: time.Now().Add(ttl),
+	})
+}
+
+// chatCompletionRecorder rebuilds a chat.completion object from the chunks of a stream
+// and stores it once the stream is done.
+type chatCompletionRecorder struct {
+	apiKey      string
+	ttl         time.Duration
+	requestJSON []byte
+
+	id      string
+	model   string
+	created int64
+	usage   string
+	choices map[int]*recordedChoice
+}
+
+// newChatCompletionRecorder returns a recorder for a streamed chat completion, or nil
+// when the completion will not be stored.
+func newChatCompletionRecorder(apiKey string, ttl time.Duration, requestJSON []byte) *chatCompletionRecorder {
+	if ttl <= 0 || !chatCompletionStoreRequested(requestJSON) {
+		return nil
+	}
+	return &chatCompletionRecorder{apiKey: apiKey, ttl: ttl, requestJSON: requestJSON}
+}
+
+type recordedChoice struct {
+	content      strings.Builder
+	reasoning    strings.Builder
+	toolCalls    map[int]*recordedToolCall
+	annotations  []string
+	finishReason string
+}
+
+type recordedToolCall struct {
+	id        string
+	name      string
+	arguments strings.Builder
+}
+
+// observe accumulates one chat.completion.chunk.
+func (r *chatCompletionRecorder) observe(chunk []byte) {
+	if r == nil || r.ttl <= 0 {
+		return
+	}
+	root := gjson.ParseBytes(chunk)
+	if root.Get("object").String() != "chat.completion.chunk" {
+		return
+	}
+	if r.id == "" {
+		r.id = root.Get("id").String()
+		r.model = root.Get("model").String()
+		r.created = root.Get("created").Int()
+	}
+	if usage := root.Get("usage"); usage.IsObject() {
+		r.usage = usage.Raw
+	}
+	root.Get("choices").ForEach(func(_, choice gjson.Result) bool {
+		r.observeChoice(choice)
+		return true
+	})
+}
+
+func (r *chatCompletionRecorder) observeChoice(choice gjson.Result) {
+	if r.choices == nil {
+		r.choices = make(map[int]*recordedChoice)
+	}
+	index := int(choice.Get("index").Int())
+	rc, ok := r.choices[index]
+	if !ok {
+		rc = &recordedChoice{toolCalls: make(map[int]*recordedToolCall)}
+		r.choices[index] = rc
+	}
+	delta := choice.Get("delta")
+	rc.content.WriteString(delta.Get("content").String())
+	rc.reasoning.WriteString(delta.Get("reasoning_content").String())
+	delta.Get("tool_calls").ForEach(func(_, call gjson.Result) bool {
+		callIndex := int(call.Get("index").Int())
+		tc, ok := rc.toolCalls[callIndex]
+		if !ok {
+			tc = &recordedToolCall{}
+			rc.toolCalls[callIndex] = tc
+		}
+		if id := call.Get("id").String(); id != "" {
+			tc.id = id
+		}
+		if name := call.Get("function.name").String(); name != "" {
+			tc.name = name
+		}
+		tc.arguments.WriteString(call.Get("function.arguments").String())
+		return true
+	})
+	delta.Get("annotations").ForEach(func(_, annotation gjson.Result) bool {
+		rc.annotations = append(rc.annotations, annotation.Raw)
+		return true
+	})
+	if reason := choice.Get("finish_reason").String(); reason != "" {
+		rc.finishReason = reason
+	}
+}
+
+// finish stores the rebuilt completion; it is called when the stream ends normally.
+func (r *chatCompletionRecorder) finish() {
+	if r == nil || r.ttl <= 0 || r.id == "" {
+		return
+	}
+	out := `{"id":"","object":"chat.completion","created":0,"model":"","choices":[]}`
+	out, _ = sjson.Set(out, "id", r.id)
+	out, _ = sjson.Set(out, "created", r.created)
+	out, _ = sjson.Set(out, "model", r.model)
+
+	indexes := make([]int, 0, len(r.choices))
+	for index := range r.choices {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	for _, index := range indexes {
+		out, _ = sjson.SetRaw(out, "choices.-1", r.choices[index].completionChoice(index))
+	}
+	if r.usage != "" {
+		out, _ = sjson.SetRaw(out, "usage", r.usage)
+	}
+	recordChatCompletion(r.apiKey, r.ttl, r.requestJSON, []byte(out))
+}
+
+// completionChoice renders the accumulated deltas as a chat.completion choice.
+func (rc *recordedChoice) completionChoice(index int) string {
+	choice := `{"index":0,"message":{"role":"assistant","content":null},"finish_reason":null}`
+	choice, _ = sjson.Set(choice, "index", index)
+	if rc.content.Len() > 0 {
+		choice, _ = sjson.Set(choice, "message.content", rc.content.String())
+	}
+	if rc.reasoning.Len() > 0 {
+		choice, _ = sjson.Set(choice, "message.reasoning_content", rc.reasoning.String())
+	}
+	callIndexes := make([]int, 0, len(rc.toolCalls))
+	for callIndex := range rc.toolCalls {
+		callIndexes = append(callIndexes, callIndex)
+	}
+	sort.Ints(callIndexes)
+	for _, callIndex := range callIndexes {
+		tc := rc.toolCalls[callIndex]
+		call := `{"id":"","type":"function","function":{"name":"","arguments":""}}`
+		call, _ = sjson.Set(call, "id", tc.id)
+		call, _ = sjson.Set(call, "function.name", tc.name)
+		call, _ = sjson.Set(call, "function.arguments", tc.arguments.String())
+		choice, _ = sjson.SetRaw(choice, "message.tool_calls.-1", call)
+	}
+	for _, annotation := range rc.annotations {
+		choice, _ = sjson.SetRaw(choice, "message.annotations.-1", annotation)
+	}
+	if rc.finishReason != "" {
+		choice, _ = sjson.Set(choice, "finish_reason", rc.finishReason)
+	}
+	return choice
+}
+
+// GetChatCompletion returns a completed chat completion stored for the caller's API key.
+//
+// GET /v1/chat/completions/{completion_id}
+func (h *OpenAIAPIHandler) GetChatCompletion(c *gin.Context) {
+	writeStoredResponse(c, storedChatCompletions, c.Param("completion_id"), "Chat completion")
+}
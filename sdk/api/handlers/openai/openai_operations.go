@@ -0,0 +1,197 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxOperationWait caps the long-poll wait of GET /v1/operations/{id}.
+const maxOperationWait = 60 * time.Second
+
+// storedOperations keeps the state of asynchronous operations: in progress until the
+// request finishes, then its result or error.
+var storedOperations = newResponseStore()
+
+// pendingOperations indexes operations still running so pollers can wait for them.
+var pendingOperations = struct {
+	sync.Mutex
+	entries map[string]*asyncOperation
+}{entries: make(map[string]*asyncOperation)}
+
+// asyncOperation is a non-streaming request running detached from the client connection.
+type asyncOperation struct {
+	id        string
+	apiKey    string
+	createdAt time.Time
+	ttl       time.Duration
+	done      chan struct{}
+
+	mu       sync.Mutex
+	finished bool
+	resp     []byte
+	headers  http.Header
+	errMsg   *interfaces.ErrorMessage
+}
+
+// asyncOperationRequested reports whether the request may be answered asynchronously:
+// the feature is enabled and the client sent "Prefer: respond-async".
+func asyncOperationRequested(h *handlers.BaseAPIHandler, c *gin.Context) bool {
+	if h == nil || handlers.AsyncOperationThreshold(h.Cfg) <= 0 {
+		return false
+	}
+	for _, prefer := range c.Request.Header.Values("Prefer") {
+		for _, token := range strings.Split(prefer, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "respond-async") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// handleAsyncOperation runs execute detached from the client connection. A result that
+// arrives within async-threshold-seconds is written as usual; otherwise the client gets
+// 202 with an operation it polls through GET /v1/operations/{id}. record persists a
+// successful response in the response store.
+func handleAsyncOperation(h *handlers.BaseAPIHandler, handler interfaces.APIHandler, c *gin.Context, execute func(ctx context.Context) ([]byte, http.Header, *interfaces.ErrorMessage), record func(resp []byte)) {
+	op := &asyncOperation{
+		id:        "op_" + strings.ReplaceAll(uuid.NewString(), "-", ""),
+		apiKey:    c.GetString("apiKey"),
+		createdAt: time.Now(),
+		ttl:       handlers.ResponseStoreTTL(h.Cfg),
+		done:      make(chan struct{}),
+	}
+	// The gin context is recycled once the handler returns, so the detached request works
+	// on a copy whose request context outlives the client connection.
+	detached := c.Copy()
+	detached.Request = detached.Request.WithContext(context.WithoutCancel(c.Request.Context()))
+	ctx, cancel := h.GetContextWithCancel(handler, detached, context.Background())
+
+	pendingOperations.Lock()
+	pendingOperations.entries[op.id] = op
+	pendingOperations.Unlock()
+	op.store()
+
+	go func() {
+		resp, headers, errMsg := execute(ctx)
+		if errMsg == nil {
+			record(resp)
+			cancel(resp)
+		} else {
+			cancel(errMsg.Error)
+		}
+		op.finish(resp, headers, errMsg)
+	}()
+
+	timer := time.NewTimer(handlers.AsyncOperationThreshold(h.Cfg))
+	defer timer.Stop()
+	select {
+	case <-op.done:
+		if op.errMsg != nil {
+			h.WriteErrorResponse(c, op.errMsg)
+			return
+		}
+		handlers.WriteUpstreamHeaders(c.Writer.Header(), op.headers)
+		_, _ = c.Writer.Write(op.resp)
+	case <-timer.C:
+		c.Header("Location", "/v1/operations/"+op.id)
+		c.Header("Retry-After", "5")
+		c.Data(http.StatusAccepted, "application/json", []byte(op.body()))
+	case <-c.Request.Context().Done():
+		// Nobody learned the operation ID, so nobody can collect the result.
+		cancel(coreexecutor.ErrClientCancelled)
+	}
+}
+
+// finish records the outcome of the operation and wakes up pollers.
+func (op *asyncOperation) finish(resp []byte, headers http.Header, errMsg *interfaces.ErrorMessage) {
+	op.mu.Lock()
+	op.finished = true
+	op.resp, op.headers, op.errMsg = resp, headers, errMsg
+	op.mu.Unlock()
+	op.store()
+	pendingOperations.Lock()
+	delete(pendingOperations.entries, op.id)
+	pendingOperations.Unlock()
+	close(op.done)
+}
+
+func (op *asyncOperation) store() {
+	storedOperations.put(&storedResponse{
+		id:        op.id,
+		apiKey:    op.apiKey,
+		body:      op.body(),
+		expiresAt: time.Now().Add(op.ttl),
+	})
+}
+
+// body renders the operation object.
+func (op *asyncOperation) body() string {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	out := `{"id":"","object":"operation","status":"in_progress","created_at":0}`
+	out, _ = sjson.Set(out, "id", op.id)
+	out, _ = sjson.Set(out, "created_at", op.createdAt.Unix())
+	if !op.finished {
+		return out
+	}
+	out, _ = sjson.Set(out, "completed_at", time.Now().Unix())
+	if op.errMsg != nil {
+		status := op.errMsg.StatusCode
+		if status <= 0 {
+			status = http.StatusInternalServerError
+		}
+		errText := http.StatusText(status)
+		if op.errMsg.Error != nil {
+			errText = op.errMsg.Error.Error()
+		}
+		out, _ = sjson.Set(out, "status", "failed")
+		out, _ = sjson.Set(out, "status_code", status)
+		errBody := gjson.GetBytes(handlers.BuildErrorResponseBody(status, errText), "error")
+		if errBody.Exists() {
+			out, _ = sjson.SetRaw(out, "error", errBody.Raw)
+		}
+		return out
+	}
+	out, _ = sjson.Set(out, "status", "completed")
+	if gjson.ValidBytes(op.resp) {
+		out, _ = sjson.SetRaw(out, "result", string(op.resp))
+	}
+	return out
+}
+
+// GetOperation returns an asynchronous operation started with the caller's API key. With
+// ?wait=N the request waits up to N seconds (at most 60) for a running operation to finish.
+//
+// GET /v1/operations/{operation_id}
+func (h *OpenAIAPIHandler) GetOperation(c *gin.Context) {
+	id := c.Param("operation_id")
+	if wait, err := strconv.Atoi(c.Query("wait")); err == nil && wait > 0 {
+		pendingOperations.Lock()
+		op := pendingOperations.entries[id]
+		pendingOperations.Unlock()
+		if op != nil && op.apiKey == c.GetString("apiKey") {
+			timeout := min(time.Duration(wait)*time.Second, maxOperationWait)
+			timer := time.NewTimer(timeout)
+			select {
+			case <-op.done:
+			case <-timer.C:
+			case <-c.Request.Context().Done():
+			}
+			timer.Stop()
+		}
+	}
+	writeStoredResponse(c, storedOperations, id, "Operation")
+}
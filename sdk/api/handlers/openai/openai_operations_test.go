@@ -0,0 +1,76 @@
+package openai
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestAsyncOperationLifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{AsyncThresholdSeconds: 1}, nil))
+
+	release := make(chan struct{})
+	recorded := make(chan []byte, 1)
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("apiKey", c.GetHeader("X-Test-Key")) })
+	router.POST("/v1/chat/completions", func(c *gin.Context) {
+		if !asyncOperationRequested(h.BaseAPIHandler, c) {
+			c.Status(http.StatusTeapot)
+			return
+		}
+		handleAsyncOperation(h.BaseAPIHandler, h, c, func(ctx context.Context) ([]byte, http.Header, *interfaces.ErrorMessage) {
+			if clientAPIKey := ctx.Value("gin").(*gin.Context).GetString("apiKey"); clientAPIKey != "owner-key" {
+				t.Errorf("detached request lost the API key, got %q", clientAPIKey)
+			}
+			<-release
+			return []byte(`{"id":"chatcmpl-1","object":"chat.completion"}`), nil, nil
+		}, func(resp []byte) { recorded <- resp })
+	})
+	router.GET("/v1/operations/:operation_id", h.GetOperation)
+	do := func(method, path, key string, prefer bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("X-Test-Key", key)
+		if prefer {
+			req.Header.Set("Prefer", "wait=10, respond-async")
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodPost, "/v1/chat/completions", "owner-key", false); w.Code != http.StatusTeapot {
+		t.Fatalf("request without Prefer: respond-async should run synchronously, got %d", w.Code)
+	}
+	w := do(http.MethodPost, "/v1/chat/completions", "owner-key", true)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202", w.Code)
+	}
+	id := gjson.Get(w.Body.String(), "id").String()
+	if id == "" || w.Header().Get("Location") != "/v1/operations/"+id {
+		t.Fatalf("unexpected 202 response %s (Location %q)", w.Body.String(), w.Header().Get("Location"))
+	}
+
+	if w = do(http.MethodGet, "/v1/operations/"+id, "other-key", false); w.Code != http.StatusNotFound {
+		t.Fatalf("poll with another key = %d, want 404", w.Code)
+	}
+	if w = do(http.MethodGet, "/v1/operations/"+id, "owner-key", false); gjson.Get(w.Body.String(), "status").String() != "in_progress" {
+		t.Fatalf("poll before completion = %s", w.Body.String())
+	}
+
+	close(release)
+	w = do(http.MethodGet, "/v1/operations/"+id+"?wait=5", "owner-key", false)
+	if gjson.Get(w.Body.String(), "status").String() != "completed" || gjson.Get(w.Body.String(), "result.id").String() != "chatcmpl-1" {
+		t.Fatalf("poll after completion = %s", w.Body.String())
+	}
+	if resp := <-recorded; gjson.GetBytes(resp, "id").String() != "chatcmpl-1" {
+		t.Fatalf("recorded %s", resp)
+	}
+}
@@ -0,0 +1,109 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// inflightResponses indexes streaming Responses API requests by response ID so they can
+// be aborted through POST /v1/responses/{response_id}/cancel.
+var inflightResponses = struct {
+	sync.Mutex
+	entries map[string]*inflightResponse
+}{entries: make(map[string]*inflightResponse)}
+
+type inflightResponse struct {
+	apiKey string
+	abort  context.CancelCauseFunc
+}
+
+// responseTracker registers a streaming response once its ID is known from the
+// response.created event.
+type responseTracker struct {
+	apiKey string
+	abort  context.CancelCauseFunc
+	id     string
+}
+
+// newResponseTracker returns a parent context for the request that CancelResponse can
+// abort, and the tracker that registers it.
+func newResponseTracker(c *gin.Context) (context.Context, *responseTracker) {
+	ctx, abort := context.WithCancelCause(context.Background())
+	return ctx, &responseTracker{apiKey: c.GetString("apiKey"), abort: abort}
+}
+
+// observe looks for the response ID in a streamed chunk.
+func (t *responseTracker) observe(chunk []byte) {
+	if t == nil || t.id != "" || !bytes.Contains(chunk, []byte("response.created")) {
+		return
+	}
+	for _, line := range bytes.Split(chunk, []byte("\n")) {
+		payload, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+		if !ok {
+			continue
+		}
+		root := gjson.ParseBytes(bytes.TrimSpace(payload))
+		if root.Get("type").String() != "response.created" {
+			continue
+		}
+		if id := root.Get("response.id").String(); id != "" {
+			t.id = id
+			inflightResponses.Lock()
+			inflightResponses.entries[id] = &inflightResponse{apiKey: t.apiKey, abort: t.abort}
+			inflightResponses.Unlock()
+		}
+		return
+	}
+}
+
+// release unregisters the response and releases its context.
+func (t *responseTracker) release() {
+	if t == nil {
+		return
+	}
+	if t.id != "" {
+		inflightResponses.Lock()
+		delete(inflightResponses.entries, t.id)
+		inflightResponses.Unlock()
+	}
+	t.abort(nil)
+}
+
+// CancelResponse aborts an in-flight streaming response started with the same API key.
+// The upstream request is cancelled and the request is accounted as client-cancelled.
+// Non-streaming responses cannot be cancelled because their ID is only known once
+// they are complete.
+//
+// POST /v1/responses/{response_id}/cancel
+func (h *OpenAIResponsesAPIHandler) CancelResponse(c *gin.Context) {
+	id := c.Param("response_id")
+	inflightResponses.Lock()
+	entry := inflightResponses.entries[id]
+	if entry != nil && entry.apiKey == c.GetString("apiKey") {
+		delete(inflightResponses.entries, id)
+	} else {
+		entry = nil
+	}
+	inflightResponses.Unlock()
+	if entry == nil {
+		c.JSON(http.StatusNotFound, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: "No in-progress response found with id '" + id + "'.",
+				Type:    "invalid_request_error",
+				Code:    "response_not_found",
+			},
+		})
+		return
+	}
+	entry.abort(coreexecutor.ErrClientCancelled)
+	log.Infof("response %s cancelled by client", id)
+	c.JSON(http.StatusOK, gin.H{"id": id, "object": "response", "status": "cancelled"})
+}
@@ -0,0 +1,54 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestCancelResponseAbortsInflightStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewOpenAIResponsesAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil))
+
+	streamCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	streamCtx.Set("apiKey", "owner-key")
+	parent, tracker := newResponseTracker(streamCtx)
+	defer tracker.release()
+	tracker.observe([]byte("event: response.created\ndata: {\"type\":\"response.created\",\"response\":{\"id\":\"resp_123\",\"status\":\"in_progress\"}}"))
+
+	router := gin.New()
+	router.POST("/v1/responses/:response_id/cancel", func(c *gin.Context) {
+		c.Set("apiKey", c.GetHeader("X-Test-Key"))
+		h.CancelResponse(c)
+	})
+	cancel := func(id, key string) int {
+		req := httptest.NewRequest(http.MethodPost, "/v1/responses/"+id+"/cancel", nil)
+		req.Header.Set("X-Test-Key", key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := cancel("resp_123", "other-key"); code != http.StatusNotFound {
+		t.Fatalf("cancel with another key = %d, want 404", code)
+	}
+	if parent.Err() != nil {
+		t.Fatal("response was aborted by another key")
+	}
+	if code := cancel("resp_123", "owner-key"); code != http.StatusOK {
+		t.Fatalf("cancel = %d, want 200", code)
+	}
+	if !errors.Is(context.Cause(parent), coreexecutor.ErrClientCancelled) || !coreexecutor.ClientCancelled(parent) {
+		t.Fatalf("context cause = %v, want ErrClientCancelled", context.Cause(parent))
+	}
+	if code := cancel("resp_123", "owner-key"); code != http.StatusNotFound {
+		t.Fatalf("second cancel = %d, want 404", code)
+	}
+}
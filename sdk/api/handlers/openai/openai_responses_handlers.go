@@ -70,15 +70,16 @@ func (h *OpenAIResponsesAPIHandler) OpenAIResponsesModels(c *gin.Context) {
 // Parameters:
 //   - c: The Gin context containing the HTTP request and response
 func (h *OpenAIResponsesAPIHandler) Responses(c *gin.Context) {
-	rawJSON, err := c.GetRawData()
-	// If data retrieval fails, return a 400 Bad Request error.
-	if err != nil {
-		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
-			Error: handlers.ErrorDetail{
-				Message: fmt.Sprintf("Invalid request: %v", err),
-				Type:    "invalid_request_error",
-			},
-		})
+	rawJSON, ok := h.ReadRequestBody(c)
+	// ReadRequestBody has already answered 400 or 413 when the body cannot be read.
+	if !ok {
+		return
+	}
+
+	// Continue a stored conversation when the request references previous_response_id.
+	rawJSON, errMsg := expandPreviousResponse(c.GetString("apiKey"), rawJSON)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
 		return
 	}
 
@@ -93,14 +94,8 @@ func (h *OpenAIResponsesAPIHandler) Responses(c *gin.Context) {
 }
 
 func (h *OpenAIResponsesAPIHandler) Compact(c *gin.Context) {
-	rawJSON, err := c.GetRawData()
-	if err != nil {
-		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
-			Error: handlers.ErrorDetail{
-				Message: fmt.Sprintf("Invalid request: %v", err),
-				Type:    "invalid_request_error",
-			},
-		})
+	rawJSON, ok := h.ReadRequestBody(c)
+	if !ok {
 		return
 	}
 
@@ -147,6 +142,15 @@ func (h *OpenAIResponsesAPIHandler) handleNonStreamingResponse(c *gin.Context, r
 	c.Header("Content-Type", "application/json")
 
 	modelName := gjson.GetBytes(rawJSON, "model").String()
+	if asyncOperationRequested(h.BaseAPIHandler, c) {
+		apiKey := c.GetString("apiKey")
+		handleAsyncOperation(h.BaseAPIHandler, h, c, func(ctx context.Context) ([]byte, http.Header, *interfaces.ErrorMessage) {
+			return h.ExecuteWithAuthManager(ctx, h.HandlerType(), modelName, rawJSON, "")
+		}, func(resp []byte) {
+			recordResponse(apiKey, handlers.ResponseStoreTTL(h.Cfg), rawJSON, gjson.ParseBytes(resp))
+		})
+		return
+	}
 	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
 	stopKeepAlive := h.StartNonStreamingKeepAlive(c, cliCtx)
 
@@ -157,6 +161,7 @@ func (h *OpenAIResponsesAPIHandler) handleNonStreamingResponse(c *gin.Context, r
 		cliCancel(errMsg.Error)
 		return
 	}
+	recordResponse(c.GetString("apiKey"), handlers.ResponseStoreTTL(h.Cfg), rawJSON, gjson.ParseBytes(resp))
 	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
 	_, _ = c.Writer.Write(resp)
 	cliCancel()
@@ -184,7 +189,10 @@ func (h *OpenAIResponsesAPIHandler) handleStreamingResponse(c *gin.Context, rawJ
 
 	// New core execution path
 	modelName := gjson.GetBytes(rawJSON, "model").String()
-	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	parentCtx, tracker := newResponseTracker(c)
+	defer tracker.release()
+	recorder := &responseRecorder{apiKey: c.GetString("apiKey"), ttl: handlers.ResponseStoreTTL(h.Cfg), requestJSON: rawJSON}
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, parentCtx)
 	dataChan, upstreamHeaders, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, "")
 
 	setSSEHeaders := func() {
@@ -236,17 +244,21 @@ func (h *OpenAIResponsesAPIHandler) handleStreamingResponse(c *gin.Context, rawJ
 			_, _ = c.Writer.Write(chunk)
 			_, _ = c.Writer.Write([]byte("\n"))
 			flusher.Flush()
+			tracker.observe(chunk)
+			recorder.observe(chunk)
 
 			// Continue
-			h.forwardResponsesStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan)
+			h.forwardResponsesStream(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan, tracker, recorder)
 			return
 		}
 	}
 }
 
-func (h *OpenAIResponsesAPIHandler) forwardResponsesStream(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+func (h *OpenAIResponsesAPIHandler) forwardResponsesStream(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage, tracker *responseTracker, recorder *responseRecorder) {
 	h.ForwardStream(c, flusher, cancel, data, errs, handlers.StreamForwardOptions{
 		WriteChunk: func(chunk []byte) {
+			tracker.observe(chunk)
+			recorder.observe(chunk)
 			if bytes.HasPrefix(chunk, []byte("event:")) {
 				_, _ = c.Writer.Write([]byte("\n"))
 			}
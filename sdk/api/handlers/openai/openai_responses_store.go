@@ -0,0 +1,224 @@
+package openai
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// responseStoreLimit bounds how many completed responses each store keeps.
+const responseStoreLimit = 1024
+
+// responseStore keeps completed generations by ID for the API key that created them,
+// evicting the oldest entries beyond responseStoreLimit.
+type responseStore struct {
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List // front is most recently stored
+}
+
+type storedResponse struct {
+	id         string
+	apiKey     string
+	body       string // the completed response object
+	transcript string // Responses API only: input items followed by output items
+	storedAt   time.Time
+	expiresAt  time.Time
+}
+
+func newResponseStore() *responseStore {
+	return &responseStore{entries: make(map[string]*list.Element), order: list.New()}
+}
+
+func (s *responseStore) put(entry *storedResponse) {
+	if entry.storedAt.IsZero() {
+		entry.storedAt = time.Now()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[entry.id]; ok {
+		s.order.Remove(elem)
+	}
+	s.entries[entry.id] = s.order.PushFront(entry)
+	for s.order.Len() > responseStoreLimit {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*storedResponse).id)
+	}
+}
+
+// purge removes the entries of apiKey, or of every key when apiKey is empty, stored
+// before the given time, or at any time when before is zero.
+func (s *responseStore) purge(apiKey string, before time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := 0
+	for elem := s.order.Front(); elem != nil; {
+		next := elem.Next()
+		entry := elem.Value.(*storedResponse)
+		if (apiKey == "" || entry.apiKey == apiKey) && (before.IsZero() || entry.storedAt.Before(before)) {
+			s.order.Remove(elem)
+			delete(s.entries, entry.id)
+			removed++
+		}
+		elem = next
+	}
+	return removed
+}
+
+// get returns the entry stored under id for apiKey, dropping it once expired.
+func (s *responseStore) get(apiKey, id string) (*storedResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[id]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*storedResponse)
+	if time.Now().After(entry.expiresAt) {
+		s.order.Remove(elem)
+		delete(s.entries, id)
+		return nil, false
+	}
+	if entry.apiKey != apiKey {
+		return nil, false
+	}
+	return entry, true
+}
+
+// storedResponses keeps completed Responses API requests so a later request can continue
+// them with previous_response_id and clients can fetch them with GET /v1/responses/{id}.
+// Upstreams do not keep this state for the proxy (Codex requires store=false and the other
+// backends are stateless), so the stored transcript is replayed as input instead.
+var storedResponses = newResponseStore()
+
+func init() {
+	cache.RegisterUserDataPurger("responses", storedResponses.purge)
+	cache.RegisterUserDataPurger("chat_completions", storedChatCompletions.purge)
+	cache.RegisterUserDataPurger("operations", storedOperations.purge)
+}
+
+// expandPreviousResponse replaces the input of a request that references
+// previous_response_id with the stored transcript followed by the new input.
+// previous_response_id stays in the request so it is echoed in the response;
+// executors never forward it upstream.
+func expandPreviousResponse(apiKey string, rawJSON []byte) ([]byte, *interfaces.ErrorMessage) {
+	prev := strings.TrimSpace(gjson.GetBytes(rawJSON, "previous_response_id").String())
+	if prev == "" {
+		return rawJSON, nil
+	}
+	entry, ok := storedResponses.get(apiKey, prev)
+	if !ok {
+		return nil, &interfaces.ErrorMessage{
+			StatusCode: http.StatusNotFound,
+			Error:      fmt.Errorf("Previous response with id '%s' not found.", prev),
+		}
+	}
+	merged, err := mergeJSONArrayRaw(entry.transcript, responseInputItems(rawJSON))
+	if err != nil {
+		return nil, &interfaces.ErrorMessage{
+			StatusCode: http.StatusBadRequest,
+			Error:      fmt.Errorf("invalid request input: %w", err),
+		}
+	}
+	out, err := sjson.SetRawBytes(rawJSON, "input", []byte(merged))
+	if err != nil {
+		return nil, &interfaces.ErrorMessage{
+			StatusCode: http.StatusBadRequest,
+			Error:      fmt.Errorf("failed to merge previous response input: %w", err),
+		}
+	}
+	return out, nil
+}
+
+// responseInputItems returns the request input as an item array; a string input is a
+// single user message.
+func responseInputItems(rawJSON []byte) string {
+	input := gjson.GetBytes(rawJSON, "input")
+	if input.Type == gjson.String {
+		item, _ := sjson.Set(`{"type":"message","role":"user","content":""}`, "content", input.String())
+		return "[" + item + "]"
+	}
+	return normalizeJSONArrayRaw([]byte(input.Raw))
+}
+
+// recordResponse stores a completed response for ttl unless the client opted out with
+// store=false. requestJSON is the request after expandPreviousResponse and response is
+// the completed response object.
+func recordResponse(apiKey string, ttl time.Duration, requestJSON []byte, response gjson.Result) {
+	if ttl <= 0 {
+		return
+	}
+	if store := gjson.GetBytes(requestJSON, "store"); store.Exists() && !store.Bool() {
+		return
+	}
+	id := response.Get("id").String()
+	if id == "" || response.Get("status").String() == "failed" {
+		return
+	}
+	transcript, err := mergeJSONArrayRaw(responseInputItems(requestJSON), normalizeJSONArrayRaw([]byte(response.Get("output").Raw)))
+	if err != nil {
+		return
+	}
+	storedResponses.put(&storedResponse{
+		id:         id,
+		apiKey:     apiKey,
+		body:       response.Raw,
+		transcript: transcript,
+		expiresAt:  time.Now().Add(ttl),
+	})
+}
+
+// responseRecorder stores a streamed response once its response.completed event arrives.
+type responseRecorder struct {
+	apiKey      string
+	ttl         time.Duration
+	requestJSON []byte
+}
+
+// observe looks for the response.completed event in a streamed chunk.
+func (r *responseRecorder) observe(chunk []byte) {
+	if r == nil || r.ttl <= 0 || !bytes.Contains(chunk, []byte("response.completed")) {
+		return
+	}
+	for _, payload := range websocketJSONPayloadsFromChunk(chunk) {
+		root := gjson.ParseBytes(payload)
+		if root.Get("type").String() == "response.completed" {
+			recordResponse(r.apiKey, r.ttl, r.requestJSON, root.Get("response"))
+			return
+		}
+	}
+}
+
+// GetResponse returns a completed response stored for the caller's API key.
+//
+// GET /v1/responses/{response_id}
+func (h *OpenAIResponsesAPIHandler) GetResponse(c *gin.Context) {
+	writeStoredResponse(c, storedResponses, c.Param("response_id"), "Response")
+}
+
+// writeStoredResponse writes the stored body of id, or an OpenAI style 404 error.
+func writeStoredResponse(c *gin.Context, store *responseStore, id, kind string) {
+	entry, ok := store.get(c.GetString("apiKey"), id)
+	if !ok {
+		c.JSON(http.StatusNotFound, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("%s with id '%s' not found.", kind, id),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	c.Data(http.StatusOK, "application/json", []byte(entry.body))
+}
@@ -0,0 +1,96 @@
+package openai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestPreviousResponseIDChaining(t *testing.T) {
+	first := []byte(`{"model":"claude-sonnet-4","input":"Hi, I'm Ana","metadata":{"trace":"1"}}`)
+	recorder := &responseRecorder{apiKey: "owner-key", ttl: time.Minute, requestJSON: first}
+	recorder.observe([]byte("event: response.completed\ndata: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp_chain_1\",\"status\":\"completed\",\"output\":[{\"type\":\"message\",\"role\":\"assistant\",\"content\":[{\"type\":\"output_text\",\"text\":\"Hello Ana\"}]}]}}"))
+
+	next := []byte(`{"model":"claude-sonnet-4","previous_response_id":"resp_chain_1","input":[{"role":"user","content":"What is my name?"}]}`)
+	expanded, errMsg := expandPreviousResponse("owner-key", next)
+	if errMsg != nil {
+		t.Fatalf("expandPreviousResponse: %v", errMsg.Error)
+	}
+	input := gjson.GetBytes(expanded, "input").Array()
+	if len(input) != 3 || input[0].Get("content").String() != "Hi, I'm Ana" ||
+		input[1].Get("content.0.text").String() != "Hello Ana" || input[2].Get("content").String() != "What is my name?" {
+		t.Fatalf("unexpected merged input: %s", gjson.GetBytes(expanded, "input").Raw)
+	}
+	if gjson.GetBytes(expanded, "previous_response_id").String() != "resp_chain_1" {
+		t.Error("previous_response_id should be kept for the response echo")
+	}
+
+	if _, errMsg = expandPreviousResponse("other-key", next); errMsg == nil || errMsg.StatusCode != http.StatusNotFound {
+		t.Fatalf("another key must not resolve the response, got %+v", errMsg)
+	}
+
+	unstored := []byte(`{"model":"claude-sonnet-4","input":"secret","store":false}`)
+	recordResponse("owner-key", time.Minute, unstored, gjson.Parse(`{"id":"resp_chain_2","status":"completed","output":[]}`))
+	if _, ok := storedResponses.get("owner-key", "resp_chain_2"); ok {
+		t.Error("store=false responses must not be kept")
+	}
+}
+
+func TestGetStoredChatCompletionFromStream(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+
+	if newChatCompletionRecorder("owner-key", time.Minute, []byte(`{"model":"m","stream":true}`)) != nil {
+		t.Error("chat completions without store=true must not be recorded")
+	}
+	recordChatCompletion("owner-key", time.Minute, []byte(`{"model":"m"}`), []byte(`{"id":"chatcmpl-unstored","object":"chat.completion","choices":[]}`))
+	if _, ok := storedChatCompletions.get("owner-key", "chatcmpl-unstored"); ok {
+		t.Error("chat completions without store=true must not be kept")
+	}
+
+	recorder := newChatCompletionRecorder("owner-key", time.Minute, []byte(`{"model":"m","stream":true,"store":true}`))
+	for _, chunk := range []string{
+		`{"id":"chatcmpl-stored","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+		`{"id":"chatcmpl-stored","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"content":"lo","tool_calls":[{"index":0,"id":"call_1","type":"function","function":{"name":"f","arguments":"{\"a\":"}}]}}]}`,
+		`{"id":"chatcmpl-stored","object":"chat.completion.chunk","created":1,"model":"m","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"1}"}}]},"finish_reason":"tool_calls"}],"usage":{"total_tokens":5}}`,
+	} {
+		recorder.observe([]byte(chunk))
+	}
+	recorder.finish()
+	recordResponse("owner-key", time.Minute, []byte(`{"input":"hi"}`), gjson.Parse(`{"id":"resp_get_1","object":"response","status":"completed","output":[]}`))
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) { c.Set("apiKey", c.GetHeader("X-Test-Key")) })
+	router.GET("/v1/chat/completions/:completion_id", NewOpenAIAPIHandler(base).GetChatCompletion)
+	router.GET("/v1/responses/:response_id", NewOpenAIResponsesAPIHandler(base).GetResponse)
+	get := func(path, key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Test-Key", key)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get("/v1/chat/completions/chatcmpl-stored", "owner-key")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET chat completion = %d: %s", w.Code, w.Body.String())
+	}
+	body := gjson.Parse(w.Body.String())
+	if body.Get("object").String() != "chat.completion" || body.Get("choices.0.message.content").String() != "Hello" ||
+		body.Get("choices.0.message.tool_calls.0.function.arguments").String() != `{"a":1}` ||
+		body.Get("choices.0.finish_reason").String() != "tool_calls" || body.Get("usage.total_tokens").Int() != 5 {
+		t.Fatalf("unexpected stored completion: %s", w.Body.String())
+	}
+	if w = get("/v1/chat/completions/chatcmpl-stored", "other-key"); w.Code != http.StatusNotFound {
+		t.Fatalf("GET with another key = %d, want 404", w.Code)
+	}
+	if w = get("/v1/responses/resp_get_1", "owner-key"); w.Code != http.StatusOK || gjson.Get(w.Body.String(), "id").String() != "resp_get_1" {
+		t.Fatalf("GET response = %d: %s", w.Code, w.Body.String())
+	}
+}
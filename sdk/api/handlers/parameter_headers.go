@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Parameter override headers read when parameter-headers is enabled.
+const (
+	temperatureHeader = "X-Temperature"
+	topPHeader        = "X-Top-P"
+	maxTokensHeader   = "X-Max-Tokens"
+	thinkingHeader    = "X-Thinking"
+)
+
+// parameterHeaderOverrides parses the client's parameter override headers. Invalid
+// values are ignored. ok is false when no header set a parameter.
+func parameterHeaderOverrides(ctx context.Context) (params config.ModelParameters, ok bool) {
+	if ctx == nil {
+		return params, false
+	}
+	ginCtx, _ := ctx.Value("gin").(*gin.Context)
+	if ginCtx == nil || ginCtx.Request == nil {
+		return params, false
+	}
+	header := ginCtx.Request.Header
+	if value := strings.TrimSpace(header.Get(temperatureHeader)); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil && f >= 0 {
+			params.Temperature, ok = &f, true
+		} else {
+			log.Debugf("ignoring invalid %s header %q", temperatureHeader, value)
+		}
+	}
+	if value := strings.TrimSpace(header.Get(topPHeader)); value != "" {
+		if f, err := strconv.ParseFloat(value, 64); err == nil && f >= 0 && f <= 1 {
+			params.TopP, ok = &f, true
+		} else {
+			log.Debugf("ignoring invalid %s header %q", topPHeader, value)
+		}
+	}
+	if value := strings.TrimSpace(header.Get(maxTokensHeader)); value != "" {
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			params.MaxTokens, ok = &n, true
+		} else {
+			log.Debugf("ignoring invalid %s header %q", maxTokensHeader, value)
+		}
+	}
+	if value := strings.TrimSpace(header.Get(thinkingHeader)); value != "" {
+		if budget, valid := parseThinkingHeader(value); valid {
+			params.ThinkingBudget, ok = &budget, true
+		} else {
+			log.Debugf("ignoring invalid %s header %q", thinkingHeader, value)
+		}
+	}
+	return params, ok
+}
+
+// parseThinkingHeader accepts a token budget, a thinking level ("low", "high", ...),
+// "auto", or "off" and returns the budget it stands for.
+func parseThinkingHeader(value string) (int, bool) {
+	value = strings.ToLower(value)
+	switch value {
+	case "off", "false", "disabled":
+		return 0, true
+	case "on", "true", "enabled":
+		return -1, true
+	}
+	if budget, err := strconv.Atoi(value); err == nil {
+		return budget, budget >= -1
+	}
+	return thinking.ConvertLevelToBudget(value)
+}
@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParameterHeaderOverrides(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+	ginCtx.Request.Header.Set("X-Temperature", "0.4")
+	ginCtx.Request.Header.Set("X-Top-P", "2")
+	ginCtx.Request.Header.Set("X-Max-Tokens", "1024")
+	ginCtx.Request.Header.Set("X-Thinking", "off")
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	params, ok := parameterHeaderOverrides(ctx)
+	if !ok {
+		t.Fatal("expected parameters from headers")
+	}
+	if params.Temperature == nil || *params.Temperature != 0.4 {
+		t.Errorf("temperature = %v", params.Temperature)
+	}
+	if params.TopP != nil {
+		t.Errorf("out-of-range top-p should be ignored, got %v", *params.TopP)
+	}
+	if params.MaxTokens == nil || *params.MaxTokens != 1024 {
+		t.Errorf("max tokens = %v", params.MaxTokens)
+	}
+	if params.ThinkingBudget == nil || *params.ThinkingBudget != 0 {
+		t.Errorf("thinking budget = %v", params.ThinkingBudget)
+	}
+
+	for value, want := range map[string]int{"high": 24576, "auto": -1, "2048": 2048} {
+		if got, valid := parseThinkingHeader(value); !valid || got != want {
+			t.Errorf("parseThinkingHeader(%q) = %d, %v; want %d", value, got, valid, want)
+		}
+	}
+	if _, valid := parseThinkingHeader("lots"); valid {
+		t.Error("expected an unknown level to be rejected")
+	}
+}
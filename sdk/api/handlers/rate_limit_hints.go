@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// maxRateLimitHintAccounts bounds the per-account snapshot attached to a 429 body.
+const maxRateLimitHintAccounts = 50
+
+// rateLimitAccountHint is one account in the utilization snapshot of a 429 body.
+// Accounts are identified by a masked label so credentials are not disclosed to clients.
+type rateLimitAccountHint struct {
+	Account       string   `json:"account"`
+	Type          string   `json:"type"`
+	Status        string   `json:"status"`
+	Utilization5h *float64 `json:"utilization_5h,omitempty"`
+	Utilization7d *float64 `json:"utilization_7d,omitempty"`
+	BlockedUntil  string   `json:"blocked_until,omitempty"`
+}
+
+// withRateLimitHints adds earliest_reset, retry_after_seconds and a per-account
+// utilization snapshot from store to the error object of a 429 body, so automated clients can back
+// off until capacity returns instead of retrying blindly. retryAfter is the Retry-After
+// value already chosen for the response, if any. It returns the body and the suggested
+// retry delay in seconds (0 when unknown).
+func withRateLimitHints(store *usage.RateLimitStore, body []byte, retryAfter string, now time.Time) ([]byte, int) {
+	if !gjson.GetBytes(body, "error").IsObject() {
+		return body, 0
+	}
+	if gjson.GetBytes(body, "error.code").String() == "session_limit_exceeded" {
+		// Session limits are local to the conversation; account capacity does not apply.
+		return body, int(gjson.GetBytes(body, "error.retry_after_seconds").Int())
+	}
+
+	var earliest time.Time
+	accounts := make([]rateLimitAccountHint, 0)
+	for _, record := range store.LatestBySource() {
+		hint := rateLimitAccountHint{Account: util.HideAPIKey(record.Source), Type: record.Type, Status: "allowed"}
+		switch record.Type {
+		case "unified":
+			hint.Utilization5h = floatPtr(record.Utilization5h)
+			hint.Utilization7d = floatPtr(record.Utilization7d)
+		case usage.RateLimitTypeCodex:
+			// Codex primary and secondary windows are the 5h and weekly limits.
+			hint.Utilization5h = floatPtr(record.PrimaryUsedPercent / 100)
+			hint.Utilization7d = floatPtr(record.SecondaryUsedPercent / 100)
+		}
+		if until := record.BlockedUntil(now); !until.IsZero() {
+			hint.Status = "rejected"
+			hint.BlockedUntil = until.UTC().Format(time.RFC3339)
+			if earliest.IsZero() || until.Before(earliest) {
+				earliest = until
+			}
+		}
+		if len(accounts) < maxRateLimitHintAccounts {
+			accounts = append(accounts, hint)
+		}
+	}
+
+	retrySeconds := 0
+	if seconds, err := strconv.Atoi(strings.TrimSpace(retryAfter)); err == nil && seconds > 0 {
+		retrySeconds = seconds
+		if earliest.IsZero() {
+			earliest = now.Add(time.Duration(seconds) * time.Second)
+		}
+	} else if !earliest.IsZero() {
+		retrySeconds = int(math.Ceil(earliest.Sub(now).Seconds()))
+	}
+
+	if !earliest.IsZero() {
+		body, _ = sjson.SetBytes(body, "error.earliest_reset", earliest.UTC().Format(time.RFC3339))
+	}
+	if retrySeconds > 0 {
+		body, _ = sjson.SetBytes(body, "error.retry_after_seconds", retrySeconds)
+	}
+	if len(accounts) > 0 {
+		body, _ = sjson.SetBytes(body, "error.accounts", accounts)
+	}
+	return body, retrySeconds
+}
+
+func floatPtr(v float64) *float64 {
+	return &v
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/tidwall/gjson"
+)
+
+func TestWithRateLimitHints(t *testing.T) {
+	store := usage.NewRateLimitStore()
+	now := time.Now()
+	reset := now.Add(90 * time.Second).Truncate(time.Second)
+	store.Record(usage.RateLimitRecord{
+		Timestamp:     now,
+		Source:        "hints-blocked@example.com",
+		Type:          "unified",
+		Status5h:      "rejected",
+		Utilization5h: 1,
+		Reset5h:       reset,
+		Status7d:      "allowed",
+		Utilization7d: 0.4,
+		Reset7d:       now.Add(48 * time.Hour),
+	})
+
+	body, retry := withRateLimitHints(store, BuildErrorResponseBody(429, "rate limited"), "", now)
+	if retry < 89 || retry > 90 {
+		t.Fatalf("retry = %d, want about 90", retry)
+	}
+	root := gjson.ParseBytes(body)
+	if got := root.Get("error.earliest_reset").String(); got != reset.UTC().Format(time.RFC3339) {
+		t.Fatalf("earliest_reset = %q, want %q", got, reset.UTC().Format(time.RFC3339))
+	}
+	if root.Get("error.code").String() != "rate_limit_exceeded" || root.Get("error.retry_after_seconds").Int() != int64(retry) {
+		t.Fatalf("unexpected body: %s", body)
+	}
+	var found bool
+	root.Get("error.accounts").ForEach(func(_, account gjson.Result) bool {
+		if account.Get("status").String() == "rejected" && account.Get("utilization_5h").Float() == 1 {
+			found = true
+			if account.Get("account").String() == "hints-blocked@example.com" {
+				t.Fatalf("account label must be masked: %s", account.Raw)
+			}
+		}
+		return true
+	})
+	if !found {
+		t.Fatalf("blocked account missing from snapshot: %s", body)
+	}
+
+	// An upstream Retry-After wins over the computed reset.
+	if _, retry = withRateLimitHints(store, BuildErrorResponseBody(429, "rate limited"), "5", now); retry != 5 {
+		t.Fatalf("retry with Retry-After = %d, want 5", retry)
+	}
+	// Bodies without an error object are left alone.
+	if body, _ = withRateLimitHints(store, []byte(`"busy"`), "", now); string(body) != `"busy"` {
+		t.Fatalf("non-object body changed: %s", body)
+	}
+}
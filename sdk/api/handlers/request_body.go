@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+const (
+	// defaultMaxRequestBodyMB is the body limit used when max-request-body-mb is unset. It
+	// is above the request size limits of the upstream APIs, so it only refuses bodies
+	// that would fail upstream anyway.
+	defaultMaxRequestBodyMB = 64
+	// maxUnlimitedBodyPresize caps how much buffer a declared Content-Length reserves when
+	// the body limit is disabled, so a client cannot make the proxy allocate memory it
+	// never sends.
+	maxUnlimitedBodyPresize = 4 << 20
+)
+
+// errRequestBodyTooLarge reports a body above the configured max-request-body-mb.
+var errRequestBodyTooLarge = errors.New("request body too large")
+
+// MaxRequestBodyBytes returns the request body limit, or 0 when it is disabled.
+func MaxRequestBodyBytes(cfg *config.SDKConfig) int64 {
+	mb := defaultMaxRequestBodyMB
+	if cfg != nil && cfg.MaxRequestBodyMB != 0 {
+		mb = cfg.MaxRequestBodyMB
+	}
+	if mb < 0 {
+		return 0
+	}
+	return int64(mb) << 20
+}
+
+// ReadRequestBody reads the request body, enforcing max-request-body-mb. Bodies that
+// declare a larger Content-Length are refused without being read, and accepted bodies
+// are read into a buffer sized up front so large histories are not copied while it grows.
+// Without a limit the up-front size is capped and the buffer grows as data arrives.
+// On failure it writes a 413 or 400 error response and reports false.
+func (h *BaseAPIHandler) ReadRequestBody(c *gin.Context) ([]byte, bool) {
+	limit := MaxRequestBodyBytes(h.Cfg)
+	body, err := readRequestBody(c.Request, limit)
+	if err == nil {
+		return body, true
+	}
+	if errors.Is(err, errRequestBodyTooLarge) {
+		c.JSON(http.StatusRequestEntityTooLarge, ErrorResponse{
+			Error: ErrorDetail{
+				Message: fmt.Sprintf("Request body exceeds the %d MB limit.", limit>>20),
+				Type:    "invalid_request_error",
+				Code:    "request_too_large",
+			},
+		})
+		return nil, false
+	}
+	c.JSON(http.StatusBadRequest, ErrorResponse{
+		Error: ErrorDetail{
+			Message: fmt.Sprintf("Invalid request: %v", err),
+			Type:    "invalid_request_error",
+		},
+	})
+	return nil, false
+}
+
+func readRequestBody(req *http.Request, limit int64) ([]byte, error) {
+	if req == nil || req.Body == nil {
+		return nil, nil
+	}
+	if limit > 0 && req.ContentLength > limit {
+		return nil, errRequestBodyTooLarge
+	}
+	var buf bytes.Buffer
+	if presize := req.ContentLength; presize > 0 {
+		if limit <= 0 {
+			presize = min(presize, maxUnlimitedBodyPresize)
+		}
+		buf.Grow(int(presize) + bytes.MinRead)
+	}
+	var reader io.Reader = req.Body
+	if limit > 0 {
+		reader = io.LimitReader(req.Body, limit+1)
+	}
+	if _, err := buf.ReadFrom(reader); err != nil {
+		return nil, err
+	}
+	if limit > 0 && int64(buf.Len()) > limit {
+		return nil, errRequestBodyTooLarge
+	}
+	return buf.Bytes(), nil
+}
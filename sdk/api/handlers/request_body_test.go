@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestReadRequestBodyEnforcesLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{MaxRequestBodyMB: 1}, nil)
+	read := func(body string, contentLength int64) (int, string, bool) {
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+		c.Request.ContentLength = contentLength
+		got, ok := h.ReadRequestBody(c)
+		return w.Code, string(got), ok
+	}
+
+	if _, got, ok := read(`{"model":"m"}`, 13); !ok || got != `{"model":"m"}` {
+		t.Fatalf("small body: ok=%v body=%q", ok, got)
+	}
+	large := strings.Repeat("x", 1<<20+1)
+	if code, _, ok := read(large, int64(len(large))); ok || code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("declared oversize body: ok=%v code=%d", ok, code)
+	}
+	if code, _, ok := read(large, -1); ok || code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("chunked oversize body: ok=%v code=%d", ok, code)
+	}
+}
+
+func TestReadRequestBodyIgnoresDeclaredLengthWithoutLimit(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/v1/messages", strings.NewReader(`{"model":"m"}`))
+	req.ContentLength = 1 << 40
+	body, err := readRequestBody(req, 0)
+	if err != nil || string(body) != `{"model":"m"}` {
+		t.Fatalf("body = %q, err = %v", body, err)
+	}
+}
+
+func TestMaxRequestBodyBytesDefault(t *testing.T) {
+	for _, tc := range []struct {
+		cfg  *sdkconfig.SDKConfig
+		want int64
+	}{
+		{nil, defaultMaxRequestBodyMB << 20},
+		{&sdkconfig.SDKConfig{}, defaultMaxRequestBodyMB << 20},
+		{&sdkconfig.SDKConfig{MaxRequestBodyMB: 8}, 8 << 20},
+		{&sdkconfig.SDKConfig{MaxRequestBodyMB: -1}, 0},
+	} {
+		if got := MaxRequestBodyBytes(tc.cfg); got != tc.want {
+			t.Errorf("MaxRequestBodyBytes(%+v) = %d, want %d", tc.cfg, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	defaultMaxContentBlocks = 20000
+	defaultMaxImages        = 500
+)
+
+// requestLimit resolves a request-limits value: zero means def, negative means unlimited.
+func requestLimit(value, def int) int {
+	switch {
+	case value == 0:
+		return def
+	case value < 0:
+		return 0
+	default:
+		return value
+	}
+}
+
+// requestContentCounts holds what checkRequestLimits counts in a request history.
+type requestContentCounts struct {
+	blocks int
+	images int
+}
+
+// checkRequestLimits rejects requests whose history exceeds request-limits. It scans the
+// client payload once, before any translator runs, so an oversized history cannot be
+// expanded into an even larger upstream payload.
+func checkRequestLimits(cfg *config.SDKConfig, rawJSON []byte) *interfaces.ErrorMessage {
+	var limits config.RequestLimitsConfig
+	if cfg != nil {
+		limits = cfg.RequestLimits
+	}
+	maxBlocks := requestLimit(limits.MaxContentBlocks, defaultMaxContentBlocks)
+	maxImages := requestLimit(limits.MaxImages, defaultMaxImages)
+	if (maxBlocks == 0 && maxImages == 0) || len(rawJSON) == 0 {
+		return nil
+	}
+
+	var counts requestContentCounts
+	root := gjson.ParseBytes(rawJSON)
+	for _, key := range []string{"messages", "contents", "input", "request.contents"} {
+		if history := root.Get(key); history.IsArray() {
+			countContent(history, &counts)
+		}
+	}
+
+	if maxBlocks > 0 && counts.blocks > maxBlocks {
+		return &interfaces.ErrorMessage{
+			StatusCode: http.StatusBadRequest,
+			Error:      fmt.Errorf("request has %d content blocks, more than the %d allowed by request-limits.max-content-blocks", counts.blocks, maxBlocks),
+		}
+	}
+	if maxImages > 0 && counts.images > maxImages {
+		return &interfaces.ErrorMessage{
+			StatusCode: http.StatusBadRequest,
+			Error:      fmt.Errorf("request has %d images, more than the %d allowed by request-limits.max-images", counts.images, maxImages),
+		}
+	}
+	return nil
+}
+
+// countContent walks a message history, counting every element of content and parts
+// arrays (including nested tool results) and every image in any client format.
+func countContent(value gjson.Result, counts *requestContentCounts) {
+	switch {
+	case value.IsArray():
+		value.ForEach(func(_, item gjson.Result) bool {
+			countContent(item, counts)
+			return true
+		})
+	case value.IsObject():
+		if isImagePart(value) {
+			counts.images++
+		}
+		value.ForEach(func(key, item gjson.Result) bool {
+			if !item.IsArray() && !item.IsObject() {
+				return true
+			}
+			if name := key.String(); name == "content" || name == "parts" {
+				if item.IsArray() {
+					counts.blocks += len(item.Array())
+				}
+			}
+			countContent(item, counts)
+			return true
+		})
+	}
+}
+
+// isImagePart reports whether a content part carries an image: OpenAI image_url and
+// input_image parts, Claude image blocks, or Gemini inline or file data with an image type.
+func isImagePart(part gjson.Result) bool {
+	switch part.Get("type").String() {
+	case "image", "image_url", "input_image":
+		return true
+	}
+	for _, key := range []string{"inlineData", "inline_data", "fileData", "file_data"} {
+		if data := part.Get(key); data.Exists() {
+			mime := data.Get("mimeType").String()
+			if mime == "" {
+				mime = data.Get("mime_type").String()
+			}
+			return strings.HasPrefix(mime, "image/")
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestCheckRequestLimits(t *testing.T) {
+	cfg := &sdkconfig.SDKConfig{RequestLimits: sdkconfig.RequestLimitsConfig{MaxContentBlocks: 4, MaxImages: 1}}
+
+	within := `{"messages":[{"role":"user","content":[{"type":"text","text":"a"},{"type":"image_url","image_url":{"url":"data:image/png;base64,AA"}}]}]}`
+	if errMsg := checkRequestLimits(cfg, []byte(within)); errMsg != nil {
+		t.Fatalf("unexpected rejection: %v", errMsg.Error)
+	}
+
+	nested := `{"messages":[{"role":"user","content":[{"type":"tool_result","tool_use_id":"t","content":[{"type":"text","text":"1"},{"type":"text","text":"2"},{"type":"text","text":"3"},{"type":"text","text":"4"}]}]}]}`
+	errMsg := checkRequestLimits(cfg, []byte(nested))
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest || !strings.Contains(errMsg.Error.Error(), "max-content-blocks") {
+		t.Fatalf("nested blocks should exceed the limit, got %+v", errMsg)
+	}
+
+	images := `{"contents":[{"role":"user","parts":[{"inlineData":{"mimeType":"image/png","data":"AA"}},{"inlineData":{"mimeType":"image/jpeg","data":"AA"}}]}]}`
+	if errMsg = checkRequestLimits(cfg, []byte(images)); errMsg == nil || !strings.Contains(errMsg.Error.Error(), "2 images") {
+		t.Fatalf("gemini images should exceed the limit, got %+v", errMsg)
+	}
+
+	unlimited := &sdkconfig.SDKConfig{RequestLimits: sdkconfig.RequestLimitsConfig{MaxContentBlocks: -1, MaxImages: -1}}
+	if errMsg = checkRequestLimits(unlimited, []byte(nested)); errMsg != nil {
+		t.Fatalf("negative limits should disable the check: %v", errMsg.Error)
+	}
+}
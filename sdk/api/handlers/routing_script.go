@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+// applyRoutingScript evaluates the configured routing scripts for a request. It returns
+// the model to route, rewritten when the matching script sets one, and the name of the
+// matching script, which is empty when none matched. A suffix on the script's model
+// takes priority over the client's suffix.
+func applyRoutingScript(ctx context.Context, handlerType, modelName string, stream bool) (string, string) {
+	name, target, ok := coreauth.MatchRoutingScript(coreauth.RoutingRequest{
+		Model:  modelName,
+		APIKey: clientAPIKey(ctx),
+		Format: handlerType,
+		Stream: stream,
+	}, time.Now())
+	if !ok {
+		return modelName, ""
+	}
+	if target == "" {
+		return modelName, name
+	}
+	requested := thinking.ParseSuffix(modelName)
+	if !thinking.ParseSuffix(target).HasSuffix && requested.HasSuffix && requested.RawSuffix != "" {
+		target = fmt.Sprintf("%s(%s)", target, requested.RawSuffix)
+	}
+	return target, name
+}
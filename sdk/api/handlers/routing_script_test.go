@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+)
+
+func TestApplyRoutingScriptKeepsClientSuffix(t *testing.T) {
+	script, err := coreauth.NewRoutingScript("mini", `format == "openai" && hasPrefix(model, "gpt-5")`, "gpt-5-mini", "", "")
+	if err != nil {
+		t.Fatalf("NewRoutingScript: %v", err)
+	}
+	coreauth.SetRoutingScripts([]coreauth.RoutingScript{script}, nil)
+	t.Cleanup(func() { coreauth.SetRoutingScripts(nil, nil) })
+
+	model, name := applyRoutingScript(context.Background(), "openai", "gpt-5(high)", false)
+	if model != "gpt-5-mini(high)" || name != "mini" {
+		t.Errorf("got %q %q, want gpt-5-mini(high) mini", model, name)
+	}
+	model, name = applyRoutingScript(context.Background(), "claude", "gpt-5", false)
+	if model != "gpt-5" || name != "" {
+		t.Errorf("got %q %q, want the request unchanged", model, name)
+	}
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// sessionHeaders carry an explicit conversation ID, e.g. the Session_id sent by Codex CLI.
+var sessionHeaders = []string{"X-Session-Id", "Session_id"}
+
+// checkSessionLimits enforces session-limits for the conversation a request belongs to.
+// Admitted requests get a context attributing their token usage to the conversation.
+func checkSessionLimits(cfg *config.SDKConfig, ctx context.Context, rawJSON []byte) (context.Context, *interfaces.ErrorMessage) {
+	if cfg == nil || (cfg.SessionLimits.MaxTurnsPerHour <= 0 && cfg.SessionLimits.MaxTokensPerSession <= 0) {
+		return ctx, nil
+	}
+	session := deriveSessionID(ctx, rawJSON)
+	if session == "" {
+		return ctx, nil
+	}
+	limits := cfg.SessionLimits
+	exceeded, ok := usage.GetSessionLimitStore().Admit(session, limits.MaxTurnsPerHour, limits.MaxTokensPerSession)
+	if !ok {
+		return ctx, &interfaces.ErrorMessage{StatusCode: http.StatusTooManyRequests, Error: sessionLimitError(exceeded)}
+	}
+	return coreusage.WithSession(ctx, session), nil
+}
+
+// sessionLimitError builds the OpenAI-style error body returned for a refused conversation.
+func sessionLimitError(exceeded usage.SessionLimitExceeded) error {
+	var message string
+	switch exceeded.Limit {
+	case usage.SessionLimitTokens:
+		message = fmt.Sprintf("This conversation has used %d tokens, reaching the limit of %d tokens per conversation (session-limits.max-tokens-per-session). Start a new conversation to continue.", exceeded.Used, exceeded.Max)
+	default:
+		message = fmt.Sprintf("This conversation has made %d requests in the last hour, reaching the limit of %d (session-limits.max-turns-per-hour). This usually means an agent is looping; retry later or start a new conversation.", exceeded.Used, exceeded.Max)
+	}
+	detail := map[string]any{
+		"message": message,
+		"type":    "rate_limit_error",
+		"code":    "session_limit_exceeded",
+		"param":   exceeded.Limit,
+	}
+	if exceeded.RetryAfter > 0 {
+		detail["retry_after_seconds"] = int(math.Ceil(exceeded.RetryAfter.Seconds()))
+	}
+	body, _ := json.Marshal(map[string]any{"error": detail})
+	return fmt.Errorf("%s", body)
+}
+
+// deriveSessionID identifies the conversation of a request: an explicit session header,
+// the session in Claude metadata.user_id, or prompt_cache_key, and otherwise a hash of the
+// system prompt and first user turn, which stay the same as the conversation grows. The
+// client API key is part of the ID so clients never share a session.
+func deriveSessionID(ctx context.Context, rawJSON []byte) string {
+	id := ""
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+		for _, name := range sessionHeaders {
+			if id = strings.TrimSpace(ginCtx.GetHeader(name)); id != "" {
+				break
+			}
+		}
+	}
+	if id == "" && len(rawJSON) > 0 {
+		root := gjson.ParseBytes(rawJSON)
+		if userID := root.Get("metadata.user_id").String(); userID != "" {
+			if _, session, found := strings.Cut(userID, "_session_"); found && session != "" {
+				id = session
+			}
+		}
+		if id == "" {
+			id = strings.TrimSpace(root.Get("prompt_cache_key").String())
+		}
+		if id == "" {
+			id = conversationPrefixHash(root)
+		}
+	}
+	if id == "" {
+		return ""
+	}
+	return clientAPIKey(ctx) + "|" + id
+}
+
+// conversationPrefixHash hashes the system prompt and the history up to the first user
+// message. It returns "" when the request has no history.
+func conversationPrefixHash(root gjson.Result) string {
+	h := sha256.New()
+	wrote := false
+	for _, path := range []string{"system", "systemInstruction", "system_instruction", "instructions"} {
+		if value := root.Get(path); value.Exists() {
+			h.Write([]byte(value.Raw))
+			h.Write([]byte{0})
+		}
+	}
+	for _, path := range []string{"messages", "contents", "input"} {
+		history := root.Get(path)
+		if !history.IsArray() {
+			continue
+		}
+		for _, item := range history.Array() {
+			h.Write([]byte(item.Raw))
+			h.Write([]byte{0})
+			wrote = true
+			if strings.EqualFold(item.Get("role").String(), "user") {
+				break
+			}
+		}
+		break
+	}
+	if !wrote {
+		return ""
+	}
+	return hex.EncodeToString(h.Sum(nil)[:16])
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestDeriveSessionID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest("POST", "/v1/messages", nil)
+	ginCtx.Set("apiKey", "sk-a")
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	claude := []byte(`{"metadata":{"user_id":"user_abc_account__session_1234"},"messages":[{"role":"user","content":"hi"}]}`)
+	if got := deriveSessionID(ctx, claude); got != "sk-a|1234" {
+		t.Errorf("claude metadata: got %q", got)
+	}
+
+	first := []byte(`{"messages":[{"role":"system","content":"be brief"},{"role":"user","content":"fix the bug"}]}`)
+	later := []byte(`{"messages":[{"role":"system","content":"be brief"},{"role":"user","content":"fix the bug"},{"role":"assistant","content":"done"},{"role":"user","content":"thanks"}]}`)
+	other := []byte(`{"messages":[{"role":"system","content":"be brief"},{"role":"user","content":"write docs"}]}`)
+	if a, b := deriveSessionID(ctx, first), deriveSessionID(ctx, later); a == "" || a != b {
+		t.Errorf("growing conversation should keep its session: %q vs %q", a, b)
+	}
+	if deriveSessionID(ctx, first) == deriveSessionID(ctx, other) {
+		t.Error("different conversations should not share a session")
+	}
+	if got := deriveSessionID(ctx, []byte(`{"model":"gpt-5"}`)); got != "" {
+		t.Errorf("request without history: got %q", got)
+	}
+
+	ginCtx.Request.Header.Set("Session_id", "codex-42")
+	if got := deriveSessionID(ctx, first); got != "sk-a|codex-42" {
+		t.Errorf("session header: got %q", got)
+	}
+}
+
+func TestCheckSessionLimits(t *testing.T) {
+	cfg := &config.SDKConfig{}
+	cfg.SessionLimits.MaxTurnsPerHour = 2
+	body := []byte(`{"prompt_cache_key":"check-session-limits-test","messages":[{"role":"user","content":"hi"}]}`)
+
+	for i := 0; i < 2; i++ {
+		ctx, errMsg := checkSessionLimits(cfg, context.Background(), body)
+		if errMsg != nil {
+			t.Fatalf("turn %d rejected: %v", i+1, errMsg.Error)
+		}
+		if coreusage.Session(ctx) == "" {
+			t.Fatal("admitted request should carry its session")
+		}
+	}
+	_, errMsg := checkSessionLimits(cfg, context.Background(), body)
+	if errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("third turn: %+v", errMsg)
+	}
+	payload := errMsg.Error.Error()
+	if gjson.Get(payload, "error.code").String() != "session_limit_exceeded" || gjson.Get(payload, "error.retry_after_seconds").Int() <= 0 {
+		t.Fatalf("unexpected error body %s", payload)
+	}
+
+	if _, errMsg = checkSessionLimits(&config.SDKConfig{}, context.Background(), body); errMsg != nil {
+		t.Fatal("disabled limits should admit every request")
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// streamFlusher decides when ForwardStream flushes buffered output, trading latency for
+// fewer syscalls and proxy frames on chatty streams.
+type streamFlusher struct {
+	flusher  http.Flusher
+	strategy string
+	interval time.Duration
+	writer   *lastByteWriter
+
+	pending   bool
+	lastFlush time.Time
+	timer     *time.Timer
+	timerC    <-chan time.Time
+}
+
+// newStreamFlusher builds the flusher for the client's API key. For the line strategy it
+// wraps c.Writer to observe line endings; the returned restore func undoes that.
+func newStreamFlusher(c *gin.Context, flusher http.Flusher, cfg *config.SDKConfig) (*streamFlusher, func()) {
+	var streaming config.StreamingConfig
+	if cfg != nil {
+		streaming = cfg.Streaming
+	}
+	strategy, interval := streaming.FlushPolicy(c.GetString("apiKey"))
+	f := &streamFlusher{flusher: flusher, strategy: strategy, interval: interval}
+	if strategy != config.StreamFlushLine {
+		return f, func() {}
+	}
+	original := c.Writer
+	f.writer = &lastByteWriter{ResponseWriter: original, last: '\n'}
+	c.Writer = f.writer
+	return f, func() { c.Writer = original }
+}
+
+// wrote is called after a chunk was written and flushes according to the strategy.
+func (f *streamFlusher) wrote() {
+	switch f.strategy {
+	case config.StreamFlushLine:
+		if f.writer.last == '\n' {
+			f.flush()
+		} else {
+			f.pending = true
+		}
+	case config.StreamFlushCoalesce:
+		f.pending = true
+		elapsed := time.Since(f.lastFlush)
+		if elapsed >= f.interval {
+			f.flush()
+			return
+		}
+		if f.timerC == nil {
+			f.timer = time.NewTimer(f.interval - elapsed)
+			f.timerC = f.timer.C
+		}
+	default:
+		f.flush()
+	}
+}
+
+// expired handles the coalescing timer firing.
+func (f *streamFlusher) expired() {
+	f.timerC = nil
+	if f.pending {
+		f.flush()
+	}
+}
+
+// flush writes out everything buffered so far.
+func (f *streamFlusher) flush() {
+	f.flusher.Flush()
+	f.pending = false
+	f.lastFlush = time.Now()
+	f.stop()
+}
+
+func (f *streamFlusher) stop() {
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+	f.timerC = nil
+}
+
+// lastByteWriter records the last byte written so the line strategy knows whether the
+// output ends on a complete line.
+type lastByteWriter struct {
+	gin.ResponseWriter
+	last byte
+}
+
+func (w *lastByteWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	if n > 0 {
+		w.last = p[n-1]
+	}
+	return n, err
+}
+
+func (w *lastByteWriter) WriteString(s string) (int, error) {
+	n, err := w.ResponseWriter.WriteString(s)
+	if n > 0 {
+		w.last = s[n-1]
+	}
+	return n, err
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+type countingFlusher struct{ flushes int }
+
+func (f *countingFlusher) Flush() { f.flushes++ }
+
+func TestForwardStreamFlushStrategies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := &config.SDKConfig{Streaming: config.StreamingConfig{
+		FlushStrategy:   config.StreamFlushCoalesce,
+		FlushIntervalMs: 3600 * 1000,
+		FlushOverrides: []config.StreamFlushOverride{
+			{APIKey: "realtime-key", Strategy: config.StreamFlushImmediate},
+			{APIKey: "line-key", Strategy: config.StreamFlushLine},
+		},
+	}}
+	h := &BaseAPIHandler{Cfg: cfg}
+
+	tests := []struct {
+		name        string
+		apiKey      string
+		chunks      []string
+		wantFlushes int
+	}{
+		{name: "coalesce", apiKey: "batch-key", chunks: []string{"a\n", "b\n", "c\n"}, wantFlushes: 2},
+		{name: "immediate override", apiKey: "realtime-key", chunks: []string{"a\n", "b\n", "c\n"}, wantFlushes: 4},
+		{name: "line override", apiKey: "line-key", chunks: []string{"a", "b\n", "c"}, wantFlushes: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			c.Request = httptest.NewRequest("POST", "/v1/chat/completions", nil)
+			c.Set("apiKey", tt.apiKey)
+
+			data := make(chan []byte, len(tt.chunks))
+			for _, chunk := range tt.chunks {
+				data <- []byte(chunk)
+			}
+			close(data)
+			errs := make(chan *interfaces.ErrorMessage)
+			flusher := &countingFlusher{}
+			h.ForwardStream(c, flusher, func(error) {}, data, errs, StreamForwardOptions{
+				WriteChunk: func(chunk []byte) { _, _ = c.Writer.Write(chunk) },
+			})
+
+			if flusher.flushes != tt.wantFlushes {
+				t.Fatalf("flushes = %d, want %d", flusher.flushes, tt.wantFlushes)
+			}
+			if got, want := w.Body.String(), tt.chunks[0]+tt.chunks[1]+tt.chunks[2]; got != want {
+				t.Fatalf("body = %q, want %q", got, want)
+			}
+		})
+	}
+}
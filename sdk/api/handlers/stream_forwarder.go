@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/interfaces"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
 )
 
 type StreamForwardOptions struct {
@@ -61,11 +62,15 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 		keepAliveC = keepAlive.C
 	}
 
+	flush, restoreWriter := newStreamFlusher(c, flusher, h.Cfg)
+	defer restoreWriter()
+	defer flush.stop()
+
 	var terminalErr *interfaces.ErrorMessage
 	for {
 		select {
 		case <-c.Request.Context().Done():
-			cancel(c.Request.Context().Err())
+			cancel(coreexecutor.ErrClientCancelled)
 			return
 		case chunk, ok := <-data:
 			if !ok {
@@ -95,7 +100,7 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 				return
 			}
 			writeChunk(chunk)
-			flusher.Flush()
+			flush.wrote()
 		case errMsg, ok := <-errs:
 			if !ok {
 				continue
@@ -115,7 +120,9 @@ func (h *BaseAPIHandler) ForwardStream(c *gin.Context, flusher http.Flusher, can
 			return
 		case <-keepAliveC:
 			writeKeepAlive()
-			flusher.Flush()
+			flush.flush()
+		case <-flush.timerC:
+			flush.expired()
 		}
 	}
 }
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// errStreamStalled is the cancellation cause of an upstream stream abandoned for stalling.
+var errStreamStalled = errors.New("upstream stream stalled")
+
+// StreamingStallTimeout returns how long a stream may go without upstream data before it
+// is treated as stalled. Returning 0 disables stall detection (default when unset).
+func StreamingStallTimeout(cfg *config.SDKConfig) time.Duration {
+	if cfg == nil || cfg.Streaming.StallTimeoutSeconds <= 0 {
+		return 0
+	}
+	return time.Duration(cfg.Streaming.StallTimeoutSeconds) * time.Second
+}
+
+// StreamingStallRetries returns how many times a stream that stalls before any bytes are
+// sent may be restarted.
+func StreamingStallRetries(cfg *config.SDKConfig) int {
+	if cfg == nil || cfg.Streaming.StallRetries < 0 {
+		return 0
+	}
+	return cfg.Streaming.StallRetries
+}
+
+// streamAttemptContext derives the context of one upstream stream attempt, which can be
+// cancelled on its own when the attempt stalls. Without stall detection it returns ctx.
+func streamAttemptContext(ctx context.Context, stallTimeout time.Duration) (context.Context, context.CancelCauseFunc) {
+	if ctx == nil || stallTimeout <= 0 {
+		return ctx, func(error) {}
+	}
+	return context.WithCancelCause(ctx)
+}
+
+// streamTextPaths locate generated text in the stream events of every client format:
+// OpenAI Chat Completions, OpenAI Responses, Claude and Gemini.
+var streamTextPaths = []string{
+	"choices.0.delta.content",
+	"choices.0.delta.reasoning_content",
+	"choices.0.delta.tool_calls.#.function.arguments",
+	"delta",
+	"delta.text",
+	"delta.thinking",
+	"delta.partial_json",
+	"candidates.0.content.parts.#.text",
+	"response.candidates.0.content.parts.#.text",
+}
+
+// estimateStreamTokens estimates the output tokens in a translated stream chunk at four
+// characters of generated text per token. A chunk may hold several SSE lines.
+func estimateStreamTokens(chunk []byte) int64 {
+	var chars int
+	for _, line := range bytes.Split(chunk, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		line = bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+		if len(line) == 0 || line[0] != '{' {
+			continue
+		}
+		event := gjson.ParseBytes(line)
+		for _, path := range streamTextPaths {
+			value := event.Get(path)
+			if value.IsArray() {
+				value.ForEach(func(_, item gjson.Result) bool {
+					chars += len(item.String())
+					return true
+				})
+				continue
+			}
+			if value.Type == gjson.String {
+				chars += len(value.String())
+			}
+		}
+	}
+	if chars == 0 {
+		return 0
+	}
+	return int64((chars + 3) / 4)
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// stallOnceStreamExecutor sends nothing on its first stream until the attempt is cancelled.
+type stallOnceStreamExecutor struct {
+	mu        sync.Mutex
+	calls     int
+	cancelled bool
+}
+
+func (e *stallOnceStreamExecutor) Identifier() string { return "codex" }
+
+func (e *stallOnceStreamExecutor) Execute(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "Execute not implemented"}
+}
+
+func (e *stallOnceStreamExecutor) ExecuteStream(ctx context.Context, _ *coreauth.Auth, _ coreexecutor.Request, _ coreexecutor.Options) (*coreexecutor.StreamResult, error) {
+	e.mu.Lock()
+	e.calls++
+	call := e.calls
+	e.mu.Unlock()
+
+	ch := make(chan coreexecutor.StreamChunk, 1)
+	if call == 1 {
+		go func() {
+			<-ctx.Done()
+			e.mu.Lock()
+			e.cancelled = true
+			e.mu.Unlock()
+			close(ch)
+		}()
+		return &coreexecutor.StreamResult{Chunks: ch}, nil
+	}
+	ch <- coreexecutor.StreamChunk{Payload: []byte(`data: {"choices":[{"delta":{"content":"hello world!"}}]}`)}
+	close(ch)
+	return &coreexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func (e *stallOnceStreamExecutor) Refresh(_ context.Context, auth *coreauth.Auth) (*coreauth.Auth, error) {
+	return auth, nil
+}
+
+func (e *stallOnceStreamExecutor) CountTokens(context.Context, *coreauth.Auth, coreexecutor.Request, coreexecutor.Options) (coreexecutor.Response, error) {
+	return coreexecutor.Response{}, &coreauth.Error{Code: "not_implemented", Message: "CountTokens not implemented"}
+}
+
+func (e *stallOnceStreamExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, &coreauth.Error{Code: "not_implemented", Message: "HttpRequest not implemented", HTTPStatus: http.StatusNotImplemented}
+}
+
+func TestExecuteStreamWithAuthManager_RetriesStalledStream(t *testing.T) {
+	executor := &stallOnceStreamExecutor{}
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor)
+	auth := &coreauth.Auth{ID: "stall-auth", Provider: "codex", Status: coreauth.StatusActive}
+	if _, err := manager.Register(context.Background(), auth); err != nil {
+		t.Fatalf("manager.Register: %v", err)
+	}
+	registry.GetGlobalRegistry().RegisterClient(auth.ID, auth.Provider, []*registry.ModelInfo{{ID: "stall-model"}})
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(auth.ID) })
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		Streaming: sdkconfig.StreamingConfig{StallTimeoutSeconds: 1, StallRetries: 1},
+	}, manager)
+	dataChan, _, errChan := handler.ExecuteStreamWithAuthManager(context.Background(), "openai", "stall-model", []byte(`{"model":"stall-model"}`), "")
+
+	var got []byte
+	for chunk := range dataChan {
+		got = append(got, chunk...)
+	}
+	for msg := range errChan {
+		if msg != nil {
+			t.Fatalf("unexpected error: %+v", msg)
+		}
+	}
+	if len(got) == 0 {
+		t.Fatal("expected the retried stream's payload")
+	}
+	executor.mu.Lock()
+	defer executor.mu.Unlock()
+	if executor.calls != 2 || !executor.cancelled {
+		t.Fatalf("calls = %d cancelled = %v; want the stalled attempt cancelled and retried", executor.calls, executor.cancelled)
+	}
+}
+
+func TestEstimateStreamTokens(t *testing.T) {
+	cases := map[string]int64{
+		`data: {"choices":[{"delta":{"content":"12345678"}}]}`:                                                 2,
+		"event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"text\":\"1234\"}}\n": 1,
+		`data: {"type":"response.output_text.delta","delta":"123456789"}`:                                      3,
+		`{"candidates":[{"content":{"parts":[{"text":"1234"},{"text":"5678"}]}}]}`:                             2,
+		`data: [DONE]`: 0,
+	}
+	for chunk, want := range cases {
+		if got := estimateStreamTokens([]byte(chunk)); got != want {
+			t.Errorf("estimateStreamTokens(%q) = %d, want %d", chunk, got, want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+// promptCacheAffinityMaxEntries bounds the number of remembered prefixes.
+const promptCacheAffinityMaxEntries = 8192
+
+// promptCacheAffinity routes requests sharing a cacheable prompt prefix to the credential
+// that served the prefix last, since upstream prompt caches are scoped per account.
+type promptCacheAffinity struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]promptCacheEntry
+}
+
+type promptCacheEntry struct {
+	authID  string
+	expires time.Time
+}
+
+var currentPromptCacheAffinity atomic.Pointer[promptCacheAffinity]
+
+// SetPromptCacheAffinity enables sticky routing of requests with the same prompt prefix
+// for ttl after their last use. A non-positive ttl disables it and forgets all prefixes.
+func SetPromptCacheAffinity(ttl time.Duration) {
+	if ttl <= 0 {
+		currentPromptCacheAffinity.Store(nil)
+		return
+	}
+	if current := currentPromptCacheAffinity.Load(); current != nil && current.ttl == ttl {
+		return
+	}
+	currentPromptCacheAffinity.Store(&promptCacheAffinity{ttl: ttl, entries: make(map[string]promptCacheEntry)})
+}
+
+// stickyPromptCacheAuth returns the available credential that last served the request's
+// prompt prefix, and the affinity key to remember the eventual pick under. The key is
+// empty when affinity is disabled or the request has no recognisable prefix.
+func stickyPromptCacheAuth(provider, model string, opts cliproxyexecutor.Options, available []*Auth) (*Auth, string) {
+	affinity := currentPromptCacheAffinity.Load()
+	if affinity == nil || len(available) == 0 {
+		return nil, ""
+	}
+	key := promptCacheKey(provider, model, opts)
+	if key == "" {
+		return nil, ""
+	}
+	now := time.Now()
+	affinity.mu.Lock()
+	entry, ok := affinity.entries[key]
+	affinity.mu.Unlock()
+	if !ok || now.After(entry.expires) {
+		return nil, key
+	}
+	for _, candidate := range available {
+		if candidate.ID == entry.authID {
+			return candidate, key
+		}
+	}
+	return nil, key
+}
+
+// rememberPromptCacheAuth records auth as the credential holding the cache for key.
+func rememberPromptCacheAuth(key string, auth *Auth) {
+	affinity := currentPromptCacheAffinity.Load()
+	if affinity == nil || key == "" || auth == nil {
+		return
+	}
+	now := time.Now()
+	affinity.mu.Lock()
+	defer affinity.mu.Unlock()
+	if _, ok := affinity.entries[key]; !ok && len(affinity.entries) >= promptCacheAffinityMaxEntries {
+		for k, entry := range affinity.entries {
+			if now.After(entry.expires) {
+				delete(affinity.entries, k)
+			}
+		}
+		if len(affinity.entries) >= promptCacheAffinityMaxEntries {
+			affinity.entries = make(map[string]promptCacheEntry)
+		}
+	}
+	affinity.entries[key] = promptCacheEntry{authID: auth.ID, expires: now.Add(affinity.ttl)}
+}
+
+// promptCacheKey hashes the part of the request every turn of a conversation shares:
+// system prompt, tools and the history up to the first user message. Later turns only
+// append to this prefix, so they map to the same key.
+func promptCacheKey(provider, model string, opts cliproxyexecutor.Options) string {
+	payload := opts.OriginalRequest
+	if len(payload) == 0 || !gjson.ValidBytes(payload) {
+		return ""
+	}
+	root := gjson.ParseBytes(payload)
+	h := sha256.New()
+	wrote := false
+	write := func(value gjson.Result) {
+		if !value.Exists() {
+			return
+		}
+		h.Write([]byte(value.Raw))
+		h.Write([]byte{0})
+		wrote = true
+	}
+	for _, path := range []string{"system", "systemInstruction", "system_instruction", "instructions", "tools"} {
+		write(root.Get(path))
+	}
+	for _, path := range []string{"messages", "contents", "input"} {
+		history := root.Get(path)
+		if !history.Exists() {
+			continue
+		}
+		if !history.IsArray() {
+			write(history)
+			break
+		}
+		for _, item := range history.Array() {
+			write(item)
+			if strings.EqualFold(item.Get("role").String(), "user") {
+				break
+			}
+		}
+		break
+	}
+	if !wrote {
+		return ""
+	}
+	return provider + ":" + canonicalModelKey(model) + ":" + hex.EncodeToString(h.Sum(nil))
+}
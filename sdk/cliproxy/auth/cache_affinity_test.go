@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestRoundRobinSelectorPick_PromptCacheAffinity(t *testing.T) {
+	SetPromptCacheAffinity(time.Minute)
+	t.Cleanup(func() { SetPromptCacheAffinity(0) })
+
+	selector := &RoundRobinSelector{}
+	auths := []*Auth{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	turn1 := cliproxyexecutor.Options{OriginalRequest: []byte(`{"system":"be brief","messages":[{"role":"user","content":"hello"}]}`)}
+	turn2 := cliproxyexecutor.Options{OriginalRequest: []byte(`{"system":"be brief","messages":[{"role":"user","content":"hello"},{"role":"assistant","content":"hi"},{"role":"user","content":"more"}]}`)}
+	other := cliproxyexecutor.Options{OriginalRequest: []byte(`{"system":"be verbose","messages":[{"role":"user","content":"hello"}]}`)}
+
+	first, err := selector.Pick(context.Background(), "claude", "claude-sonnet-4-5", turn1, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		got, errPick := selector.Pick(context.Background(), "claude", "claude-sonnet-4-5", turn2, auths)
+		if errPick != nil {
+			t.Fatalf("Pick() error = %v", errPick)
+		}
+		if got.ID != first.ID {
+			t.Fatalf("follow-up turn #%d picked %q, want sticky %q", i, got.ID, first.ID)
+		}
+	}
+
+	got, err := selector.Pick(context.Background(), "claude", "claude-sonnet-4-5", other, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID == first.ID {
+		t.Fatalf("different prefix should rotate to the next credential, got %q again", got.ID)
+	}
+
+	// When the sticky credential is no longer a candidate the prefix moves to the new pick.
+	remaining := make([]*Auth, 0, len(auths)-1)
+	for _, a := range auths {
+		if a.ID != first.ID {
+			remaining = append(remaining, a)
+		}
+	}
+	moved, err := selector.Pick(context.Background(), "claude", "claude-sonnet-4-5", turn2, remaining)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	again, err := selector.Pick(context.Background(), "claude", "claude-sonnet-4-5", turn2, auths)
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if again.ID != moved.ID {
+		t.Fatalf("prefix should stay with %q after failover, got %q", moved.ID, again.ID)
+	}
+}
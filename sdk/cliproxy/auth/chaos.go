@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// chaosEnvEnabled reports whether CLIPROXY_CHAOS switches chaos mode on regardless of config.
+var chaosEnvEnabled = sync.OnceValue(func() bool {
+	enabled, _ := strconv.ParseBool(strings.TrimSpace(os.Getenv("CLIPROXY_CHAOS")))
+	return enabled
+})
+
+// chaosMalformedPayload is an SSE event whose JSON body is cut off mid-object.
+var chaosMalformedPayload = []byte(`data: {"chaos":"malformed`)
+
+// chaosError is a synthetic upstream failure produced by chaos mode.
+type chaosError struct {
+	status     int
+	retryAfter *time.Duration
+}
+
+func (e chaosError) Error() string {
+	return fmt.Sprintf("chaos: injected upstream status %d", e.status)
+}
+
+func (e chaosError) StatusCode() int { return e.status }
+
+func (e chaosError) RetryAfter() *time.Duration { return e.retryAfter }
+
+// chaosExecutor wraps a provider executor and injects synthetic errors, stream
+// truncations and malformed SSE events according to the chaos config.
+type chaosExecutor struct {
+	ProviderExecutor
+	cfg internalconfig.ChaosConfig
+}
+
+// withChaos wraps executor when chaos mode applies to provider.
+func (m *Manager) withChaos(executor ProviderExecutor, provider string) ProviderExecutor {
+	if m == nil || executor == nil {
+		return executor
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !chaosApplies(cfg.Chaos, provider) {
+		return executor
+	}
+	return &chaosExecutor{ProviderExecutor: executor, cfg: cfg.Chaos}
+}
+
+// ChaosEnabled reports whether chaos mode is switched on by config or by CLIPROXY_CHAOS.
+func ChaosEnabled(cfg internalconfig.ChaosConfig) bool {
+	return cfg.Enabled || chaosEnvEnabled()
+}
+
+func chaosApplies(cfg internalconfig.ChaosConfig, provider string) bool {
+	if !ChaosEnabled(cfg) {
+		return false
+	}
+	if cfg.ErrorRate <= 0 && cfg.TruncateRate <= 0 && cfg.MalformedRate <= 0 {
+		return false
+	}
+	if len(cfg.Providers) == 0 {
+		return true
+	}
+	for _, p := range cfg.Providers {
+		if strings.EqualFold(strings.TrimSpace(p), provider) {
+			return true
+		}
+	}
+	return false
+}
+
+func chaosRoll(rate float64) bool {
+	return rate > 0 && rand.Float64() < rate
+}
+
+func (e *chaosExecutor) injectedError() error {
+	if !chaosRoll(e.cfg.ErrorRate) {
+		return nil
+	}
+	status := e.cfg.ErrorStatus
+	if status <= 0 {
+		status = http.StatusTooManyRequests
+	}
+	err := chaosError{status: status}
+	if status == http.StatusTooManyRequests && e.cfg.RetryAfterSeconds > 0 {
+		retryAfter := time.Duration(e.cfg.RetryAfterSeconds) * time.Second
+		err.retryAfter = &retryAfter
+	}
+	log.Debugf("chaos: injecting status %d for %s", status, e.Identifier())
+	return err
+}
+
+// Execute implements ProviderExecutor.
+func (e *chaosExecutor) Execute(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if err := e.injectedError(); err != nil {
+		return cliproxyexecutor.Response{}, err
+	}
+	return e.ProviderExecutor.Execute(ctx, auth, req, opts)
+}
+
+// ExecuteStream implements ProviderExecutor.
+func (e *chaosExecutor) ExecuteStream(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	if err := e.injectedError(); err != nil {
+		return nil, err
+	}
+	result, err := e.ProviderExecutor.ExecuteStream(ctx, auth, req, opts)
+	if err != nil || result == nil {
+		return result, err
+	}
+	truncateAt, malformedAt := -1, -1
+	if chaosRoll(e.cfg.TruncateRate) {
+		truncateAt = 1 + rand.IntN(8)
+	}
+	if chaosRoll(e.cfg.MalformedRate) {
+		malformedAt = rand.IntN(4)
+	}
+	if truncateAt < 0 && malformedAt < 0 {
+		return result, nil
+	}
+	return &cliproxyexecutor.StreamResult{
+		Headers: result.Headers,
+		Chunks:  chaosStream(result.Chunks, truncateAt, malformedAt),
+	}, nil
+}
+
+// chaosStream forwards chunks, replacing chunk malformedAt with invalid JSON and ending
+// the stream with an unexpected EOF after truncateAt chunks. Negative indexes disable
+// the corresponding fault. The upstream channel is drained so its producer can exit.
+func chaosStream(in <-chan cliproxyexecutor.StreamChunk, truncateAt, malformedAt int) <-chan cliproxyexecutor.StreamChunk {
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		index := 0
+		for chunk := range in {
+			if truncateAt >= 0 && index == truncateAt {
+				log.Debugf("chaos: truncating stream after %d chunks", index)
+				out <- cliproxyexecutor.StreamChunk{Err: fmt.Errorf("chaos: stream truncated: %w", io.ErrUnexpectedEOF)}
+				for range in {
+				}
+				return
+			}
+			if index == malformedAt && chunk.Err == nil {
+				log.Debugf("chaos: replacing stream chunk %d with a malformed event", index)
+				chunk.Payload = chaosMalformedPayload
+			}
+			out <- chunk
+			index++
+		}
+	}()
+	return out
+}
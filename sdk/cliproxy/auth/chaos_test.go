@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestWithChaosInjectsStatusError(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	manager.SetConfig(&internalconfig.Config{Chaos: internalconfig.ChaosConfig{
+		Enabled:           true,
+		Providers:         []string{"codex"},
+		ErrorRate:         1,
+		RetryAfterSeconds: 3,
+	}})
+	base := &replaceAwareExecutor{id: "codex"}
+
+	if got := manager.withChaos(base, "claude"); got != ProviderExecutor(base) {
+		t.Fatalf("expected provider outside chaos.providers to be left unwrapped")
+	}
+
+	_, err := manager.withChaos(base, "codex").Execute(context.Background(), &Auth{}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{})
+	se, ok := errors.AsType[cliproxyexecutor.StatusError](err)
+	if !ok || se.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("error = %v, want injected 429", err)
+	}
+	if ra := retryAfterFromError(err); ra == nil || *ra != 3*time.Second {
+		t.Fatalf("retry-after = %v, want 3s", ra)
+	}
+}
+
+func TestWithChaosDisabled(t *testing.T) {
+	if chaosEnvEnabled() {
+		t.Skip("CLIPROXY_CHAOS is set")
+	}
+	manager := NewManager(nil, nil, nil)
+	manager.SetConfig(&internalconfig.Config{Chaos: internalconfig.ChaosConfig{ErrorRate: 1}})
+	base := &replaceAwareExecutor{id: "codex"}
+	if got := manager.withChaos(base, "codex"); got != ProviderExecutor(base) {
+		t.Fatalf("expected executor to be left unwrapped when chaos is disabled")
+	}
+}
+
+func TestChaosStreamFaults(t *testing.T) {
+	feed := func(n int) <-chan cliproxyexecutor.StreamChunk {
+		ch := make(chan cliproxyexecutor.StreamChunk, n)
+		for i := 0; i < n; i++ {
+			ch <- cliproxyexecutor.StreamChunk{Payload: []byte(`data: {"ok":true}`)}
+		}
+		close(ch)
+		return ch
+	}
+
+	var chunks []cliproxyexecutor.StreamChunk
+	for chunk := range chaosStream(feed(5), 3, 1) {
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) != 4 {
+		t.Fatalf("got %d chunks, want 3 payloads and a truncation error", len(chunks))
+	}
+	if string(chunks[1].Payload) != string(chaosMalformedPayload) {
+		t.Fatalf("chunk 1 = %q, want malformed event", chunks[1].Payload)
+	}
+	if !errors.Is(chunks[3].Err, io.ErrUnexpectedEOF) {
+		t.Fatalf("last chunk error = %v, want unexpected EOF", chunks[3].Err)
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/util"
 	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	cliproxyusage "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -151,6 +152,9 @@ type Manager struct {
 	// It is initialized in NewManager; never Load() before first Store().
 	runtimeConfig atomic.Value
 
+	// hedges bounds how many hedged attempts may be sent.
+	hedges hedgeBudget
+
 	// Optional HTTP RoundTripper provider injected by host.
 	rtProvider RoundTripperProvider
 
@@ -610,38 +614,55 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 		publishSelectedAuthMetadata(opts.Metadata, auth.ID)
 
 		tried[auth.ID] = struct{}{}
-		execCtx := ctx
-		if rt := m.roundTripperFor(auth); rt != nil {
-			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
-			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
+		out := m.executeOnAuth(ctx, attemptTarget{auth: auth, executor: executor, provider: provider}, routeModel, req, opts)
+		if out.err == nil {
+			return out.value, nil
 		}
-		execReq := req
-		execReq.Model = rewriteModelForAuth(routeModel, auth)
-		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
-		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
-		resp, errExec := executor.Execute(execCtx, auth, execReq, opts)
-		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
-		if errExec != nil {
-			if errCtx := execCtx.Err(); errCtx != nil {
-				return cliproxyexecutor.Response{}, errCtx
-			}
-			result.Error = &Error{Message: errExec.Error()}
-			if se, ok := errors.AsType[cliproxyexecutor.StatusError](errExec); ok && se != nil {
-				result.Error.HTTPStatus = se.StatusCode()
-			}
-			if ra := retryAfterFromError(errExec); ra != nil {
-				result.RetryAfter = ra
-			}
-			m.MarkResult(execCtx, result)
-			if isRequestInvalidError(errExec) {
-				return cliproxyexecutor.Response{}, errExec
-			}
-			lastErr = errExec
-			continue
+		if !out.failover {
+			return cliproxyexecutor.Response{}, out.err
+		}
+		lastErr = out.err
+	}
+}
+
+// executeOnAuth runs one non-streaming attempt on target and records its result.
+func (m *Manager) executeOnAuth(ctx context.Context, target attemptTarget, routeModel string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) attemptOutcome[cliproxyexecutor.Response] {
+	auth, executor, provider := target.auth, target.executor, target.provider
+	execCtx := ctx
+	if rt := m.roundTripperFor(auth); rt != nil {
+		execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
+		execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
+	}
+	if spillOverFromMetadata(opts.Metadata) {
+		execCtx = cliproxyusage.WithSpillOver(execCtx)
+	}
+	execReq := req
+	execReq.Model = rewriteModelForAuth(routeModel, auth)
+	execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
+	execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
+	execReq.Model = applyModelPin(auth, execReq.Model)
+	started := time.Now()
+	resp, errExec := m.withChaos(withExtensions(executor, provider), provider).Execute(execCtx, auth, execReq, opts)
+	result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
+	if errExec != nil {
+		if errCtx := execCtx.Err(); errCtx != nil {
+			return attemptOutcome[cliproxyexecutor.Response]{err: errCtx}
+		}
+		if isMutatorError(errExec) {
+			return attemptOutcome[cliproxyexecutor.Response]{err: errExec}
+		}
+		publishLatency(execCtx, auth, provider, routeModel, false, errExec, started, 0)
+		var classified *cliproxyexecutor.UpstreamError
+		result.Error, classified = resultErrorFrom(provider, errExec)
+		if ra := retryAfterFromError(errExec); ra != nil {
+			result.RetryAfter = ra
 		}
 		m.MarkResult(execCtx, result)
-		return resp, nil
+		return attemptOutcome[cliproxyexecutor.Response]{err: errExec, failover: classified.Failover}
 	}
+	publishLatency(execCtx, auth, provider, routeModel, false, nil, started, 0)
+	m.MarkResult(execCtx, result)
+	return attemptOutcome[cliproxyexecutor.Response]{value: resp}
 }
 
 func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
@@ -671,25 +692,27 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
 			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
 		}
+		if spillOverFromMetadata(opts.Metadata) {
+			execCtx = cliproxyusage.WithSpillOver(execCtx)
+		}
 		execReq := req
 		execReq.Model = rewriteModelForAuth(routeModel, auth)
 		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
 		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
+		execReq.Model = applyModelPin(auth, execReq.Model)
 		resp, errExec := executor.CountTokens(execCtx, auth, execReq, opts)
 		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: errExec == nil}
 		if errExec != nil {
 			if errCtx := execCtx.Err(); errCtx != nil {
 				return cliproxyexecutor.Response{}, errCtx
 			}
-			result.Error = &Error{Message: errExec.Error()}
-			if se, ok := errors.AsType[cliproxyexecutor.StatusError](errExec); ok && se != nil {
-				result.Error.HTTPStatus = se.StatusCode()
-			}
+			var classified *cliproxyexecutor.UpstreamError
+			result.Error, classified = resultErrorFrom(provider, errExec)
 			if ra := retryAfterFromError(errExec); ra != nil {
 				result.RetryAfter = ra
 			}
 			m.MarkResult(execCtx, result)
-			if isRequestInvalidError(errExec) {
+			if !classified.Failover {
 				return cliproxyexecutor.Response{}, errExec
 			}
 			lastErr = errExec
@@ -722,69 +745,160 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 		publishSelectedAuthMetadata(opts.Metadata, auth.ID)
 
 		tried[auth.ID] = struct{}{}
-		execCtx := ctx
-		if rt := m.roundTripperFor(auth); rt != nil {
-			execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
-			execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
-		}
-		execReq := req
-		execReq.Model = rewriteModelForAuth(routeModel, auth)
-		execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
-		execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
-		streamResult, errStream := executor.ExecuteStream(execCtx, auth, execReq, opts)
-		if errStream != nil {
-			if errCtx := execCtx.Err(); errCtx != nil {
-				return nil, errCtx
-			}
-			rerr := &Error{Message: errStream.Error()}
-			if se, ok := errors.AsType[cliproxyexecutor.StatusError](errStream); ok && se != nil {
-				rerr.HTTPStatus = se.StatusCode()
-			}
-			result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr}
-			result.RetryAfter = retryAfterFromError(errStream)
-			m.MarkResult(execCtx, result)
-			if isRequestInvalidError(errStream) {
-				return nil, errStream
+		target := attemptTarget{auth: auth, executor: executor, provider: provider}
+		out := m.hedgeStream(ctx, providers, routeModel, opts, tried, target, func(attemptCtx context.Context, target attemptTarget, attemptOpts cliproxyexecutor.Options) attemptOutcome[*cliproxyexecutor.StreamResult] {
+			return m.executeStreamOnAuth(attemptCtx, target, routeModel, req, attemptOpts)
+		})
+		if out.err == nil {
+			return out.value, nil
+		}
+		if !out.failover {
+			return nil, out.err
+		}
+		lastErr = out.err
+	}
+}
+
+// executeStreamOnAuth opens one streaming attempt on target. Failures to open the
+// stream are recorded here; the outcome of an opened stream is recorded once it ends.
+func (m *Manager) executeStreamOnAuth(ctx context.Context, target attemptTarget, routeModel string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) attemptOutcome[*cliproxyexecutor.StreamResult] {
+	auth, executor, provider := target.auth, target.executor, target.provider
+	execCtx := ctx
+	if rt := m.roundTripperFor(auth); rt != nil {
+		execCtx = context.WithValue(execCtx, roundTripperContextKey{}, rt)
+		execCtx = context.WithValue(execCtx, "cliproxy.roundtripper", rt)
+	}
+	if spillOverFromMetadata(opts.Metadata) {
+		execCtx = cliproxyusage.WithSpillOver(execCtx)
+	}
+	execReq := req
+	execReq.Model = rewriteModelForAuth(routeModel, auth)
+	execReq.Model = m.applyOAuthModelAlias(auth, execReq.Model)
+	execReq.Model = m.applyAPIKeyModelAlias(auth, execReq.Model)
+	execReq.Model = applyModelPin(auth, execReq.Model)
+	started := time.Now()
+	streamResult, errStream := m.withChaos(withExtensions(executor, provider), provider).ExecuteStream(execCtx, auth, execReq, opts)
+	if errStream != nil {
+		if errCtx := execCtx.Err(); errCtx != nil {
+			return attemptOutcome[*cliproxyexecutor.StreamResult]{err: errCtx}
+		}
+		if isMutatorError(errStream) {
+			return attemptOutcome[*cliproxyexecutor.StreamResult]{err: errStream}
+		}
+		publishLatency(execCtx, auth, provider, routeModel, true, errStream, started, 0)
+		rerr, classified := resultErrorFrom(provider, errStream)
+		result := Result{AuthID: auth.ID, Provider: provider, Model: routeModel, Success: false, Error: rerr}
+		result.RetryAfter = retryAfterFromError(errStream)
+		m.MarkResult(execCtx, result)
+		return attemptOutcome[*cliproxyexecutor.StreamResult]{err: errStream, failover: classified.Failover}
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func(streamCtx context.Context, streamAuth *Auth, streamProvider string, streamChunks <-chan cliproxyexecutor.StreamChunk) {
+		defer close(out)
+		var failed bool
+		var streamErr error
+		var firstByte time.Duration
+		forward := true
+		for chunk := range streamChunks {
+			if firstByte == 0 && len(chunk.Payload) > 0 {
+				firstByte = time.Since(started)
 			}
-			lastErr = errStream
-			continue
-		}
-		out := make(chan cliproxyexecutor.StreamChunk)
-		go func(streamCtx context.Context, streamAuth *Auth, streamProvider string, streamChunks <-chan cliproxyexecutor.StreamChunk) {
-			defer close(out)
-			var failed bool
-			forward := true
-			for chunk := range streamChunks {
-				if chunk.Err != nil && !failed {
-					failed = true
-					rerr := &Error{Message: chunk.Err.Error()}
-					if se, ok := errors.AsType[cliproxyexecutor.StatusError](chunk.Err); ok && se != nil {
-						rerr.HTTPStatus = se.StatusCode()
-					}
+			if chunk.Err != nil && !failed {
+				failed = true
+				streamErr = chunk.Err
+				// A stream cut short by its consumer says nothing about the credential.
+				if !cliproxyexecutor.ClientCancelled(streamCtx) {
+					rerr, _ := resultErrorFrom(streamProvider, chunk.Err)
 					m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: false, Error: rerr})
 				}
-				if !forward {
-					continue
-				}
-				if streamCtx == nil {
-					out <- chunk
-					continue
-				}
-				select {
-				case <-streamCtx.Done():
-					forward = false
-				case out <- chunk:
-				}
 			}
-			if !failed {
-				m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true})
+			if !forward {
+				continue
+			}
+			if streamCtx == nil {
+				out <- chunk
+				continue
+			}
+			select {
+			case <-streamCtx.Done():
+				forward = false
+			case out <- chunk:
 			}
-		}(execCtx, auth.Clone(), provider, streamResult.Chunks)
-		return &cliproxyexecutor.StreamResult{
-			Headers: streamResult.Headers,
-			Chunks:  out,
-		}, nil
+		}
+		publishLatency(streamCtx, streamAuth, streamProvider, routeModel, true, streamErr, started, firstByte)
+		if !failed {
+			m.MarkResult(streamCtx, Result{AuthID: streamAuth.ID, Provider: streamProvider, Model: routeModel, Success: true})
+		}
+	}(execCtx, auth.Clone(), provider, streamResult.Chunks)
+	return attemptOutcome[*cliproxyexecutor.StreamResult]{value: &cliproxyexecutor.StreamResult{
+		Headers: streamResult.Headers,
+		Chunks:  out,
+	}}
+}
+
+// publishLatency reports the timing and outcome of one upstream attempt. A zero
+// firstByte means the first byte was not observed separately and is taken to equal
+// the total. A non-nil errAttempt marks the attempt as failed.
+func publishLatency(ctx context.Context, auth *Auth, provider, model string, stream bool, errAttempt error, started time.Time, firstByte time.Duration) {
+	// Attempts the client abandoned say nothing about the credential or upstream latency.
+	if cliproxyexecutor.ClientCancelled(ctx) {
+		return
+	}
+	total := time.Since(started)
+	if firstByte <= 0 || firstByte > total {
+		firstByte = total
+	}
+	if parsed := thinking.ParseSuffix(model); parsed.ModelName != "" {
+		model = parsed.ModelName
 	}
+	record := cliproxyusage.LatencyRecord{
+		Provider:    provider,
+		Model:       model,
+		RequestedAt: started,
+		Stream:      stream,
+		Failed:      errAttempt != nil,
+		FirstByte:   firstByte,
+		Total:       total,
+	}
+	if errAttempt != nil {
+		record.StatusCode = statusCodeFromError(errAttempt)
+		record.ErrorClass = classifyAttemptError(provider, errAttempt)
+	}
+	if auth != nil {
+		record.AuthID = auth.ID
+		record.Source = auth.UsageSource()
+	}
+	cliproxyusage.PublishLatency(ctx, record)
+}
+
+// classifyAttemptError maps a failed upstream attempt onto a coarse error class used
+// for per-credential error-rate tracking.
+func classifyAttemptError(provider string, err error) string {
+	switch cliproxyexecutor.ClassifyError(provider, err).Kind {
+	case cliproxyexecutor.ErrorKindInvalidRequest:
+		return cliproxyusage.ErrorClassInvalidRequest
+	case cliproxyexecutor.ErrorKindRateLimit:
+		return cliproxyusage.ErrorClassRateLimit
+	case cliproxyexecutor.ErrorKindAuth, cliproxyexecutor.ErrorKindPermission, cliproxyexecutor.ErrorKindBilling:
+		return cliproxyusage.ErrorClassAuth
+	case cliproxyexecutor.ErrorKindTimeout:
+		return cliproxyusage.ErrorClassTimeout
+	case cliproxyexecutor.ErrorKindServer, cliproxyexecutor.ErrorKindOverloaded:
+		return cliproxyusage.ErrorClassServer
+	}
+	return cliproxyusage.ErrorClassOther
+}
+
+// resultErrorFrom classifies an execution error and converts it into the Error
+// recorded against the auth. Code carries the error kind.
+func resultErrorFrom(provider string, err error) (*Error, *cliproxyexecutor.UpstreamError) {
+	classified := cliproxyexecutor.ClassifyError(provider, err)
+	return &Error{
+		Code:       string(classified.Kind),
+		Message:    err.Error(),
+		Retryable:  classified.Retryable,
+		HTTPStatus: classified.Status,
+	}, classified
 }
 
 func ensureRequestedModelMetadata(opts cliproxyexecutor.Options, requestedModel string) cliproxyexecutor.Options {
@@ -826,6 +940,14 @@ func hasRequestedModelMetadata(meta map[string]any) bool {
 	}
 }
 
+func passthroughModelFromMetadata(meta map[string]any) bool {
+	if len(meta) == 0 {
+		return false
+	}
+	v, ok := meta[cliproxyexecutor.PassthroughModelMetadataKey].(bool)
+	return ok && v
+}
+
 func pinnedAuthIDFromMetadata(meta map[string]any) string {
 	if len(meta) == 0 {
 		return ""
@@ -873,6 +995,27 @@ func rewriteModelForAuth(model string, auth *Auth) string {
 	return strings.TrimPrefix(model, needle)
 }
 
+// applyModelPin replaces a "-latest" upstream model with the concrete version it is pinned
+// to for the auth's channel, keeping any thinking suffix.
+func applyModelPin(auth *Auth, model string) string {
+	if auth == nil {
+		return model
+	}
+	channel := modelAliasChannel(auth)
+	if channel == "" {
+		channel = strings.ToLower(strings.TrimSpace(auth.Provider))
+	}
+	parsed := thinking.ParseSuffix(model)
+	pinned := registry.PinnedModel(channel, parsed.ModelName)
+	if pinned == parsed.ModelName {
+		return model
+	}
+	if parsed.HasSuffix {
+		return pinned + "(" + parsed.RawSuffix + ")"
+	}
+	return pinned
+}
+
 func (m *Manager) applyAPIKeyModelAlias(auth *Auth, requestedModel string) string {
 	if m == nil || auth == nil {
 		return requestedModel
@@ -1494,26 +1637,26 @@ func retryAfterFromError(err error) *time.Duration {
 	return new(*retryAfter)
 }
 
+// statusCodeFromResult returns the status that drives cooldown decisions. Billing
+// failures that a provider reports as 400 are treated as 402 so the account is parked.
 func statusCodeFromResult(err *Error) int {
 	if err == nil {
 		return 0
 	}
+	if err.Code == string(cliproxyexecutor.ErrorKindBilling) && err.HTTPStatus == http.StatusBadRequest {
+		return http.StatusPaymentRequired
+	}
 	return err.StatusCode()
 }
 
 // isRequestInvalidError returns true if the error represents a client request
-// error that should not be retried. Specifically, it checks for 400 Bad Request
-// with "invalid_request_error" in the message, indicating the request itself is
-// malformed and switching to a different auth will not help.
+// error that should not be retried, because the request itself is malformed and
+// switching to a different auth will not help.
 func isRequestInvalidError(err error) bool {
 	if err == nil {
 		return false
 	}
-	status := statusCodeFromError(err)
-	if status != http.StatusBadRequest {
-		return false
-	}
-	return strings.Contains(err.Error(), "invalid_request_error")
+	return !cliproxyexecutor.ClassifyError("", err).Failover
 }
 
 func applyAuthFailureState(auth *Auth, resultErr *Error, retryAfter *time.Duration, now time.Time) {
@@ -1663,6 +1806,7 @@ func (m *Manager) CloseExecutionSession(sessionID string) {
 
 func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, error) {
 	pinnedAuthID := pinnedAuthIDFromMetadata(opts.Metadata)
+	passthroughModel := passthroughModelFromMetadata(opts.Metadata)
 
 	m.mu.RLock()
 	executor, okExecutor := m.executors[provider]
@@ -1690,7 +1834,7 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		if _, used := tried[candidate.ID]; used {
 			continue
 		}
-		if modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(candidate.ID, modelKey) {
+		if !passthroughModel && modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(candidate.ID, modelKey) {
 			continue
 		}
 		candidates = append(candidates, candidate)
@@ -1723,6 +1867,7 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 
 func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, string, error) {
 	pinnedAuthID := pinnedAuthIDFromMetadata(opts.Metadata)
+	passthroughModel := passthroughModelFromMetadata(opts.Metadata)
 
 	providerSet := make(map[string]struct{}, len(providers))
 	for _, provider := range providers {
@@ -1767,7 +1912,7 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 		if _, ok := m.executors[providerKey]; !ok {
 			continue
 		}
-		if modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(candidate.ID, modelKey) {
+		if !passthroughModel && modelKey != "" && registryRef != nil && !registryRef.ClientSupportsModel(candidate.ID, modelKey) {
 			continue
 		}
 		candidates = append(candidates, candidate)
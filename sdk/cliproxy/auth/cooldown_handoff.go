@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+)
+
+// AuthCooldown is the transferable cooldown state of one credential, exported by one
+// proxy instance and imported by its replacement during a rolling upgrade.
+type AuthCooldown struct {
+	AuthID         string                 `json:"auth_id"`
+	Unavailable    bool                   `json:"unavailable,omitempty"`
+	NextRetryAfter time.Time              `json:"next_retry_after,omitempty"`
+	Quota          QuotaState             `json:"quota"`
+	ModelStates    map[string]*ModelState `json:"model_states,omitempty"`
+}
+
+// ExportCooldowns returns the cooldowns still pending at now, keeping only the model
+// states that block a retry.
+func (m *Manager) ExportCooldowns(now time.Time) []AuthCooldown {
+	if m == nil {
+		return nil
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var out []AuthCooldown
+	for id, auth := range m.auths {
+		if auth == nil {
+			continue
+		}
+		cooldown := AuthCooldown{AuthID: id}
+		if auth.Unavailable && auth.NextRetryAfter.After(now) {
+			cooldown.Unavailable = true
+			cooldown.NextRetryAfter = auth.NextRetryAfter
+			cooldown.Quota = auth.Quota
+		}
+		for model, state := range auth.ModelStates {
+			if state == nil || !state.Unavailable || !state.NextRetryAfter.After(now) {
+				continue
+			}
+			if cooldown.ModelStates == nil {
+				cooldown.ModelStates = make(map[string]*ModelState)
+			}
+			copied := *state
+			copied.LastError = cloneError(state.LastError)
+			cooldown.ModelStates[model] = &copied
+		}
+		if cooldown.Unavailable || len(cooldown.ModelStates) > 0 {
+			out = append(out, cooldown)
+		}
+	}
+	return out
+}
+
+// ImportCooldowns applies exported cooldowns to the matching credentials. Expired entries,
+// unknown credentials and cooldowns shorter than the current ones are skipped. It returns
+// the number of credentials updated.
+func (m *Manager) ImportCooldowns(ctx context.Context, cooldowns []AuthCooldown, now time.Time) int {
+	if m == nil || len(cooldowns) == 0 {
+		return 0
+	}
+	type quotaModel struct{ authID, model string }
+	var quotaModels []quotaModel
+
+	applied := 0
+	m.mu.Lock()
+	for _, cooldown := range cooldowns {
+		auth, ok := m.auths[cooldown.AuthID]
+		if !ok || auth == nil {
+			continue
+		}
+		changed := false
+		for model, state := range cooldown.ModelStates {
+			if state == nil || !state.Unavailable || !state.NextRetryAfter.After(now) {
+				continue
+			}
+			current := ensureModelState(auth, model)
+			if current.Unavailable && !current.NextRetryAfter.Before(state.NextRetryAfter) {
+				continue
+			}
+			*current = *state
+			current.LastError = cloneError(state.LastError)
+			changed = true
+			if state.Quota.Exceeded {
+				quotaModels = append(quotaModels, quotaModel{cooldown.AuthID, model})
+			}
+		}
+		if len(auth.ModelStates) > 0 && changed {
+			updateAggregatedAvailability(auth, now)
+			auth.Status = StatusError
+		}
+		if cooldown.Unavailable && cooldown.NextRetryAfter.After(now) && cooldown.NextRetryAfter.After(auth.NextRetryAfter) {
+			auth.Unavailable = true
+			auth.NextRetryAfter = cooldown.NextRetryAfter
+			auth.Quota = cooldown.Quota
+			changed = true
+		}
+		if changed {
+			auth.UpdatedAt = now
+			_ = m.persist(ctx, auth)
+			applied++
+		}
+	}
+	m.mu.Unlock()
+
+	for _, qm := range quotaModels {
+		registry.GetGlobalRegistry().SetModelQuotaExceeded(qm.authID, qm.model)
+		registry.GetGlobalRegistry().SuspendClientModel(qm.authID, qm.model, "quota")
+	}
+	return applied
+}
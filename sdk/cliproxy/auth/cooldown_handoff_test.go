@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+)
+
+func TestCooldownHandoffRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient("a") })
+
+	old := NewManager(nil, nil, nil)
+	if _, err := old.Register(ctx, &Auth{ID: "a", Provider: "claude", Status: StatusActive}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	if _, err := old.Register(ctx, &Auth{ID: "b", Provider: "claude", Status: StatusActive}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	old.MarkResult(ctx, Result{AuthID: "a", Provider: "claude", Model: "claude-sonnet-4", Error: &Error{HTTPStatus: 429, Message: "rate limited"}})
+
+	exported := old.ExportCooldowns(now)
+	if len(exported) != 1 || exported[0].AuthID != "a" || exported[0].ModelStates["claude-sonnet-4"] == nil {
+		t.Fatalf("exported = %+v", exported)
+	}
+
+	fresh := NewManager(nil, nil, nil)
+	if _, err := fresh.Register(ctx, &Auth{ID: "a", Provider: "claude", Status: StatusActive}); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	expired := AuthCooldown{AuthID: "a", ModelStates: map[string]*ModelState{
+		"claude-opus-4": {Unavailable: true, NextRetryAfter: now.Add(-time.Minute)},
+	}}
+	unknown := AuthCooldown{AuthID: "missing", Unavailable: true, NextRetryAfter: now.Add(time.Hour)}
+	if applied := fresh.ImportCooldowns(ctx, append(exported, expired, unknown), now); applied != 1 {
+		t.Fatalf("applied = %d, want 1", applied)
+	}
+
+	auth, _ := fresh.GetByID("a")
+	state := auth.ModelStates["claude-sonnet-4"]
+	if state == nil || !state.Unavailable || !state.NextRetryAfter.After(now) || !state.Quota.Exceeded {
+		t.Fatalf("model state not restored: %+v", state)
+	}
+	if _, ok := auth.ModelStates["claude-opus-4"]; ok {
+		t.Fatal("expired cooldown imported")
+	}
+}
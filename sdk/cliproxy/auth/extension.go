@@ -0,0 +1,104 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/extension"
+)
+
+// extensionExecutor wraps a provider executor and runs the request and response
+// mutators registered through the extension package around each upstream attempt.
+type extensionExecutor struct {
+	ProviderExecutor
+	provider string
+}
+
+// mutatorError marks a request rejected by an extension request mutator. It is returned
+// to the client as-is without failing over or penalising the credential.
+type mutatorError struct {
+	err error
+}
+
+func (e *mutatorError) Error() string { return e.err.Error() }
+
+func (e *mutatorError) Unwrap() error { return e.err }
+
+// StatusCode forwards the status of the wrapped error, if any.
+func (e *mutatorError) StatusCode() int {
+	if se, ok := errors.AsType[cliproxyexecutor.StatusError](e.err); ok {
+		return se.StatusCode()
+	}
+	return 0
+}
+
+func isMutatorError(err error) bool {
+	_, ok := errors.AsType[*mutatorError](err)
+	return ok
+}
+
+// withExtensions wraps executor when any extension mutator is registered.
+func withExtensions(executor ProviderExecutor, provider string) ProviderExecutor {
+	if executor == nil || !extension.HasMutators() {
+		return executor
+	}
+	return &extensionExecutor{ProviderExecutor: executor, provider: provider}
+}
+
+func (e *extensionExecutor) requestInfo(auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) extension.RequestInfo {
+	info := extension.RequestInfo{
+		Provider:     e.provider,
+		Model:        req.Model,
+		SourceFormat: opts.SourceFormat.String(),
+		Stream:       opts.Stream,
+	}
+	if auth != nil {
+		info.AuthID = auth.ID
+	}
+	if key, ok := opts.Metadata[cliproxyexecutor.ClientAPIKeyMetadataKey].(string); ok {
+		info.ClientAPIKey = key
+	}
+	return info
+}
+
+// Execute implements ProviderExecutor.
+func (e *extensionExecutor) Execute(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	info := e.requestInfo(auth, req, opts)
+	payload, err := extension.MutateRequest(ctx, info, req.Payload)
+	if err != nil {
+		return cliproxyexecutor.Response{}, &mutatorError{err: err}
+	}
+	req.Payload = payload
+	resp, err := e.ProviderExecutor.Execute(ctx, auth, req, opts)
+	if err != nil {
+		return resp, err
+	}
+	resp.Payload = extension.MutateResponse(ctx, info, resp.Payload)
+	return resp, nil
+}
+
+// ExecuteStream implements ProviderExecutor.
+func (e *extensionExecutor) ExecuteStream(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	info := e.requestInfo(auth, req, opts)
+	payload, err := extension.MutateRequest(ctx, info, req.Payload)
+	if err != nil {
+		return nil, &mutatorError{err: err}
+	}
+	req.Payload = payload
+	result, err := e.ProviderExecutor.ExecuteStream(ctx, auth, req, opts)
+	if err != nil || result == nil {
+		return result, err
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		for chunk := range result.Chunks {
+			if chunk.Err == nil && len(chunk.Payload) > 0 {
+				chunk.Payload = extension.MutateResponse(ctx, info, chunk.Payload)
+			}
+			out <- chunk
+		}
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: result.Headers, Chunks: out}, nil
+}
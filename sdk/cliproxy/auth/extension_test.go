@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/extension"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// payloadEchoExecutor answers with the request payload, as one chunk when streaming.
+type payloadEchoExecutor struct {
+	replaceAwareExecutor
+}
+
+func (e *payloadEchoExecutor) Execute(_ context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{Payload: req.Payload}, nil
+}
+
+func (e *payloadEchoExecutor) ExecuteStream(_ context.Context, _ *Auth, req cliproxyexecutor.Request, _ cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	ch := make(chan cliproxyexecutor.StreamChunk, 1)
+	ch <- cliproxyexecutor.StreamChunk{Payload: req.Payload}
+	close(ch)
+	return &cliproxyexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func TestWithExtensionsMutatesRequestAndResponse(t *testing.T) {
+	extension.Reset()
+	t.Cleanup(extension.Reset)
+
+	base := &payloadEchoExecutor{replaceAwareExecutor{id: "claude"}}
+	if got := withExtensions(base, "claude"); got != ProviderExecutor(base) {
+		t.Fatal("expected executor to be left unwrapped without mutators")
+	}
+
+	var seen extension.RequestInfo
+	extension.RegisterRequestMutator(func(_ context.Context, info extension.RequestInfo, payload []byte) ([]byte, error) {
+		seen = info
+		return append(payload, " req"...), nil
+	})
+	extension.RegisterResponseMutator(func(_ context.Context, _ extension.RequestInfo, payload []byte) []byte {
+		return append(payload, " resp"...)
+	})
+
+	wrapped := withExtensions(base, "claude")
+	opts := cliproxyexecutor.Options{
+		SourceFormat: sdktranslator.FromString("openai"),
+		Metadata:     map[string]any{cliproxyexecutor.ClientAPIKeyMetadataKey: "sk-client"},
+	}
+	resp, err := wrapped.Execute(context.Background(), &Auth{ID: "a1"}, cliproxyexecutor.Request{Model: "m", Payload: []byte("body")}, opts)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+	if string(resp.Payload) != "body req resp" {
+		t.Errorf("payload = %q", resp.Payload)
+	}
+	if seen.Provider != "claude" || seen.AuthID != "a1" || seen.Model != "m" || seen.SourceFormat != "openai" || seen.ClientAPIKey != "sk-client" {
+		t.Errorf("info = %+v", seen)
+	}
+
+	opts.Stream = true
+	result, err := wrapped.ExecuteStream(context.Background(), &Auth{ID: "a1"}, cliproxyexecutor.Request{Model: "m", Payload: []byte("chunk")}, opts)
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+	var chunks []string
+	for chunk := range result.Chunks {
+		chunks = append(chunks, string(chunk.Payload))
+	}
+	if len(chunks) != 1 || chunks[0] != "chunk req resp" {
+		t.Errorf("chunks = %q", chunks)
+	}
+}
+
+func TestWithExtensionsRequestMutatorAborts(t *testing.T) {
+	extension.Reset()
+	t.Cleanup(extension.Reset)
+
+	denied := errors.New("budget exceeded")
+	extension.RegisterRequestMutator(func(context.Context, extension.RequestInfo, []byte) ([]byte, error) {
+		return nil, denied
+	})
+	_, err := withExtensions(&payloadEchoExecutor{replaceAwareExecutor{id: "codex"}}, "codex").
+		Execute(context.Background(), &Auth{}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{})
+	if !errors.Is(err, denied) || !isMutatorError(err) {
+		t.Fatalf("err = %v, want %v", err, denied)
+	}
+}
+
+func TestRequestMutatorRejectionSkipsFailover(t *testing.T) {
+	extension.Reset()
+	t.Cleanup(extension.Reset)
+	extension.RegisterRequestMutator(func(context.Context, extension.RequestInfo, []byte) ([]byte, error) {
+		return nil, errors.New("denied")
+	})
+
+	ctx := context.Background()
+	manager := NewManager(nil, nil, nil)
+	manager.RegisterExecutor(&payloadEchoExecutor{replaceAwareExecutor{id: "claude"}})
+	for _, id := range []string{"ext-a", "ext-b"} {
+		if _, err := manager.Register(ctx, &Auth{ID: id, Provider: "claude", Status: StatusActive}); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	}
+	if _, err := manager.Execute(ctx, []string{"claude"}, cliproxyexecutor.Request{Model: "m"}, cliproxyexecutor.Options{}); err == nil {
+		t.Fatal("expected the mutator error")
+	}
+	for _, auth := range manager.List() {
+		if auth.LastError != nil || auth.Unavailable {
+			t.Errorf("auth %s penalised: %+v", auth.ID, auth.LastError)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"sync/atomic"
+)
+
+// ErrorRateSource reports the recent upstream error rate (0..1) of a credential.
+type ErrorRateSource interface {
+	ErrorRate(authID string) float64
+}
+
+type errorRateScoring struct {
+	source    ErrorRateSource
+	threshold float64
+}
+
+var currentErrorRateScoring atomic.Pointer[errorRateScoring]
+
+// SetErrorRateScoring enables deprioritisation of credentials whose error rate is at or
+// above threshold. A nil source or a threshold outside (0, 1] disables it.
+func SetErrorRateScoring(source ErrorRateSource, threshold float64) {
+	if source == nil || threshold <= 0 || threshold > 1 {
+		currentErrorRateScoring.Store(nil)
+		return
+	}
+	currentErrorRateScoring.Store(&errorRateScoring{source: source, threshold: threshold})
+}
+
+// deprioritizeFailingAuths drops credentials whose error rate reaches the configured
+// threshold, as long as at least one healthy credential remains. When every candidate
+// is degraded the list is returned unchanged so requests keep flowing.
+func deprioritizeFailingAuths(available []*Auth) []*Auth {
+	scoring := currentErrorRateScoring.Load()
+	if scoring == nil || len(available) < 2 {
+		return available
+	}
+	healthy := make([]*Auth, 0, len(available))
+	for _, candidate := range available {
+		if scoring.source.ErrorRate(candidate.ID) < scoring.threshold {
+			healthy = append(healthy, candidate)
+		}
+	}
+	if len(healthy) == 0 {
+		return available
+	}
+	return healthy
+}
@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+const (
+	// defaultHedgeBudgetPercent is the share of streams that may be hedged by default.
+	defaultHedgeBudgetPercent = 10
+	// hedgeBudgetMaxTokens caps how many unused hedges accumulate, bounding bursts.
+	hedgeBudgetMaxTokens = 10
+	// hedgeTokenUnits is one hedge in budget units, hundredths of a percent of a request.
+	hedgeTokenUnits = 10000
+)
+
+// errHedgeLost cancels the slower of two hedged attempts. It wraps ErrClientCancelled
+// so the abandoned attempt is neither charged to its credential nor timed.
+var errHedgeLost = fmt.Errorf("hedged attempt lost: %w", cliproxyexecutor.ErrClientCancelled)
+
+// errEmptyStream fails a hedged stream that ended without producing a chunk.
+var errEmptyStream = errors.New("upstream stream ended without data")
+
+// hedgeBudget is a token bucket of hedged attempts. Every hedgeable stream deposits a
+// fraction of a token and every hedge withdraws a whole one, so hedges stay within the
+// configured share of requests.
+type hedgeBudget struct {
+	mu    sync.Mutex
+	units int64
+}
+
+func (b *hedgeBudget) deposit(percent float64) {
+	b.add(int64(math.Round(percent * 100)))
+}
+
+func (b *hedgeBudget) add(units int64) {
+	b.mu.Lock()
+	b.units = min(b.units+units, hedgeBudgetMaxTokens*hedgeTokenUnits)
+	b.mu.Unlock()
+}
+
+func (b *hedgeBudget) withdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.units < hedgeTokenUnits {
+		return false
+	}
+	b.units -= hedgeTokenUnits
+	return true
+}
+
+func (b *hedgeBudget) refund() {
+	b.add(hedgeTokenUnits)
+}
+
+// hedgeSettings returns how long a stream may go without a first chunk before it is
+// hedged, and the budget percentage. A zero delay means hedging is off.
+func (m *Manager) hedgeSettings() (time.Duration, float64) {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || cfg.Hedging.DelayMs <= 0 {
+		return 0, 0
+	}
+	percent := cfg.Hedging.BudgetPercent
+	if percent <= 0 {
+		percent = defaultHedgeBudgetPercent
+	}
+	return time.Duration(cfg.Hedging.DelayMs) * time.Millisecond, min(percent, 100)
+}
+
+// attemptTarget is the credential and executor one upstream attempt is sent with.
+type attemptTarget struct {
+	auth     *Auth
+	executor ProviderExecutor
+	provider string
+}
+
+// attemptOutcome is the result of one upstream attempt. failover reports whether the
+// request may move on to another credential after err.
+type attemptOutcome[T any] struct {
+	value    T
+	err      error
+	failover bool
+	authID   string
+}
+
+// hedgeStream opens a stream on target. With hedging on, a stream that has not produced
+// its first chunk within the hedging delay is raced against a second stream on another
+// credential; the first to produce a chunk is returned and the other is cancelled. The
+// hedge's credential is added to tried. Non-streaming requests are not hedged: they only
+// respond once the whole generation is done, so every slow generation would be sent twice.
+func (m *Manager) hedgeStream(ctx context.Context, providers []string, routeModel string, opts cliproxyexecutor.Options, tried map[string]struct{}, target attemptTarget, run func(context.Context, attemptTarget, cliproxyexecutor.Options) attemptOutcome[*cliproxyexecutor.StreamResult]) attemptOutcome[*cliproxyexecutor.StreamResult] {
+	delay, percent := m.hedgeSettings()
+	if delay <= 0 {
+		return run(ctx, target, opts)
+	}
+	m.hedges.deposit(percent)
+
+	// Concurrent attempts each get their own metadata; the winner is published below.
+	attempt := func(target attemptTarget, opts cliproxyexecutor.Options) func(context.Context) attemptOutcome[*cliproxyexecutor.StreamResult] {
+		return func(attemptCtx context.Context) attemptOutcome[*cliproxyexecutor.StreamResult] {
+			out := run(attemptCtx, target, opts)
+			if out.err == nil {
+				out.value, out.err = awaitFirstChunk(attemptCtx, out.value)
+				if out.err != nil {
+					_, classified := resultErrorFrom(target.provider, out.err)
+					out.failover = errors.Is(out.err, errEmptyStream) || classified.Failover
+				}
+			}
+			out.authID = target.auth.ID
+			return out
+		}
+	}
+	nextHedge := func() func(context.Context) attemptOutcome[*cliproxyexecutor.StreamResult] {
+		if !m.hedges.withdraw() {
+			return nil
+		}
+		hedgeOpts := cloneOptionsMetadata(opts)
+		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, hedgeOpts, tried)
+		if errPick != nil {
+			m.hedges.refund()
+			return nil
+		}
+		tried[auth.ID] = struct{}{}
+		entry := logEntryWithRequestID(ctx)
+		entry.Debugf("hedging %s after %s without a first chunk from auth %s on auth %s", routeModel, delay, target.auth.ID, auth.ID)
+		return attempt(attemptTarget{auth: auth, executor: executor, provider: provider}, hedgeOpts)
+	}
+
+	out := raceAttempts(ctx, delay, attempt(target, cloneOptionsMetadata(opts)), nextHedge)
+	if out.err == nil && out.authID != target.auth.ID {
+		publishSelectedAuthMetadata(opts.Metadata, out.authID)
+	}
+	return out
+}
+
+// raceAttempts runs primary and, if it has not finished within delay, the hedge returned
+// by nextHedge. It returns the first success, cancelling the other attempt, or the last
+// failure. nextHedge returns nil when no hedge can be sent.
+func raceAttempts[T any](ctx context.Context, delay time.Duration, primary func(context.Context) attemptOutcome[T], nextHedge func() func(context.Context) attemptOutcome[T]) attemptOutcome[T] {
+	type finished struct {
+		index   int
+		outcome attemptOutcome[T]
+	}
+	results := make(chan finished, 2)
+	var cancels []context.CancelCauseFunc
+	start := func(fn func(context.Context) attemptOutcome[T]) {
+		attemptCtx, cancel := context.WithCancelCause(ctx)
+		index := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() { results <- finished{index: index, outcome: fn(attemptCtx)} }()
+	}
+
+	start(primary)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case done := <-results:
+		return done.outcome
+	case <-timer.C:
+	}
+	hedge := nextHedge()
+	if hedge == nil {
+		return (<-results).outcome
+	}
+	start(hedge)
+
+	first := <-results
+	if first.outcome.err == nil {
+		// The winner's context stays live for the body it returned; it ends with ctx.
+		cancels[1-first.index](errHedgeLost)
+		return first.outcome
+	}
+	return (<-results).outcome
+}
+
+// cloneOptionsMetadata gives opts a metadata map of its own.
+func cloneOptionsMetadata(opts cliproxyexecutor.Options) cliproxyexecutor.Options {
+	if opts.Metadata == nil {
+		return opts
+	}
+	meta := make(map[string]any, len(opts.Metadata))
+	for k, v := range opts.Metadata {
+		meta[k] = v
+	}
+	opts.Metadata = meta
+	return opts
+}
+
+// awaitFirstChunk waits for the first chunk of a stream and returns a stream that
+// replays it, so a hedged stream counts as responded only once data arrives. A stream
+// whose first chunk is an error, or that ends without a chunk, fails the attempt so the
+// race goes on with the other one.
+func awaitFirstChunk(ctx context.Context, result *cliproxyexecutor.StreamResult) (*cliproxyexecutor.StreamResult, error) {
+	var first cliproxyexecutor.StreamChunk
+	var ok bool
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case first, ok = <-result.Chunks:
+	}
+	if !ok {
+		return nil, errEmptyStream
+	}
+	if first.Err != nil {
+		go func() {
+			for range result.Chunks {
+			}
+		}()
+		return nil, first.Err
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	go func() {
+		defer close(out)
+		forward := true
+		send := func(chunk cliproxyexecutor.StreamChunk) {
+			if !forward {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				forward = false
+			case out <- chunk:
+			}
+		}
+		send(first)
+		for chunk := range result.Chunks {
+			send(chunk)
+		}
+	}()
+	return &cliproxyexecutor.StreamResult{Headers: result.Headers, Chunks: out}, nil
+}
@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v6/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// stallFirstExecutor stalls its first call until cancelled and answers the others with
+// the ID of the auth they ran on.
+type stallFirstExecutor struct {
+	replaceAwareExecutor
+	calls     atomic.Int32
+	abandoned chan bool
+}
+
+func newStallFirstExecutor() *stallFirstExecutor {
+	return &stallFirstExecutor{replaceAwareExecutor: replaceAwareExecutor{id: "claude"}, abandoned: make(chan bool, 1)}
+}
+
+func (e *stallFirstExecutor) stall(ctx context.Context) bool {
+	if e.calls.Add(1) > 1 {
+		return false
+	}
+	<-ctx.Done()
+	e.abandoned <- cliproxyexecutor.ClientCancelled(ctx)
+	return true
+}
+
+func (e *stallFirstExecutor) Execute(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	if e.stall(ctx) {
+		return cliproxyexecutor.Response{}, ctx.Err()
+	}
+	return cliproxyexecutor.Response{Payload: []byte(auth.ID)}, nil
+}
+
+func (e *stallFirstExecutor) ExecuteStream(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	ch := make(chan cliproxyexecutor.StreamChunk, 1)
+	go func() {
+		defer close(ch)
+		if !e.stall(ctx) {
+			ch <- cliproxyexecutor.StreamChunk{Payload: []byte(auth.ID)}
+		}
+	}()
+	return &cliproxyexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func newHedgingManager(t *testing.T, executor ProviderExecutor) *Manager {
+	t.Helper()
+	manager := NewManager(nil, nil, nil)
+	manager.SetConfig(&internalconfig.Config{Hedging: internalconfig.HedgingConfig{DelayMs: 10}})
+	manager.RegisterExecutor(executor)
+	for _, id := range []string{"hedge-a", "hedge-b"} {
+		registry.GetGlobalRegistry().RegisterClient(id, "claude", []*registry.ModelInfo{{ID: "m"}})
+		t.Cleanup(func() { registry.GetGlobalRegistry().UnregisterClient(id) })
+		if _, err := manager.Register(context.Background(), &Auth{ID: id, Provider: "claude", Status: StatusActive}); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	}
+	return manager
+}
+
+func TestHedgedStreamUsesFirstChunk(t *testing.T) {
+	ctx := context.Background()
+
+	executor := newStallFirstExecutor()
+	manager := newHedgingManager(t, executor)
+	manager.hedges.refund()
+	var selected string
+	opts := cliproxyexecutor.Options{Stream: true, Metadata: map[string]any{
+		cliproxyexecutor.SelectedAuthCallbackMetadataKey: func(id string) { selected = id },
+	}}
+	result, err := manager.ExecuteStream(ctx, []string{"claude"}, cliproxyexecutor.Request{Model: "m"}, opts)
+	if err != nil {
+		t.Fatalf("ExecuteStream: %v", err)
+	}
+	var chunks []string
+	for chunk := range result.Chunks {
+		if chunk.Err != nil {
+			t.Errorf("chunk error: %v", chunk.Err)
+		}
+		chunks = append(chunks, string(chunk.Payload))
+	}
+	if len(chunks) != 1 || chunks[0] != selected {
+		t.Errorf("chunks = %q, want one from the hedge %q", chunks, selected)
+	}
+
+	if !<-executor.abandoned {
+		t.Error("slow stream was not cancelled as abandoned")
+	}
+	for _, auth := range manager.List() {
+		if auth.LastError != nil || auth.Unavailable {
+			t.Errorf("auth %s penalised: %+v", auth.ID, auth.LastError)
+		}
+	}
+}
+
+// failingHedgeExecutor answers its first stream only after the second one has failed,
+// either with an error chunk or by closing without data.
+type failingHedgeExecutor struct {
+	replaceAwareExecutor
+	calls      atomic.Int32
+	errorChunk bool
+	hedgeDone  chan struct{}
+}
+
+func (e *failingHedgeExecutor) ExecuteStream(ctx context.Context, auth *Auth, _ cliproxyexecutor.Request, _ cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	ch := make(chan cliproxyexecutor.StreamChunk, 1)
+	if e.calls.Add(1) > 1 {
+		if e.errorChunk {
+			ch <- cliproxyexecutor.StreamChunk{Err: errors.New("upstream broke")}
+		}
+		close(ch)
+		close(e.hedgeDone)
+		return &cliproxyexecutor.StreamResult{Chunks: ch}, nil
+	}
+	go func() {
+		defer close(ch)
+		select {
+		case <-ctx.Done():
+		case <-e.hedgeDone:
+			ch <- cliproxyexecutor.StreamChunk{Payload: []byte(auth.ID)}
+		}
+	}()
+	return &cliproxyexecutor.StreamResult{Chunks: ch}, nil
+}
+
+func TestFailedHedgeDoesNotWinTheRace(t *testing.T) {
+	for name, errorChunk := range map[string]bool{"error chunk": true, "empty stream": false} {
+		t.Run(name, func(t *testing.T) {
+			executor := &failingHedgeExecutor{replaceAwareExecutor: replaceAwareExecutor{id: "claude"}, errorChunk: errorChunk, hedgeDone: make(chan struct{})}
+			manager := newHedgingManager(t, executor)
+			manager.hedges.refund()
+			var selected string
+			opts := cliproxyexecutor.Options{Stream: true, Metadata: map[string]any{
+				cliproxyexecutor.SelectedAuthCallbackMetadataKey: func(id string) { selected = id },
+			}}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			result, err := manager.ExecuteStream(ctx, []string{"claude"}, cliproxyexecutor.Request{Model: "m"}, opts)
+			if err != nil {
+				t.Fatalf("ExecuteStream: %v", err)
+			}
+			var chunks []string
+			for chunk := range result.Chunks {
+				if chunk.Err != nil {
+					t.Errorf("chunk error: %v", chunk.Err)
+				}
+				chunks = append(chunks, string(chunk.Payload))
+			}
+			if calls := executor.calls.Load(); calls != 2 {
+				t.Fatalf("calls = %d, want a primary and a hedge", calls)
+			}
+			if len(chunks) != 1 || chunks[0] != selected {
+				t.Errorf("chunks = %q, want one from the primary %q", chunks, selected)
+			}
+		})
+	}
+}
+
+func TestNonStreamingRequestsAreNotHedged(t *testing.T) {
+	executor := newStallFirstExecutor()
+	manager := newHedgingManager(t, executor)
+	manager.hedges.refund()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := manager.Execute(ctx, []string{"claude"}, cliproxyexecutor.Request{Model: "m"}, cliproxyexecutor.Options{}); err == nil {
+		t.Fatal("expected the slow request to time out")
+	}
+	if calls := executor.calls.Load(); calls != 1 {
+		t.Fatalf("calls = %d, want 1", calls)
+	}
+}
+
+func TestHedgeBudget(t *testing.T) {
+	var budget hedgeBudget
+	for i := 0; i < 9; i++ {
+		budget.deposit(10)
+	}
+	if budget.withdraw() {
+		t.Fatal("hedge allowed after 9 requests at 10%")
+	}
+	budget.deposit(10)
+	if !budget.withdraw() {
+		t.Fatal("hedge refused after 10 requests at 10%")
+	}
+	if budget.withdraw() {
+		t.Fatal("budget spent twice")
+	}
+
+	for i := 0; i < 100; i++ {
+		budget.refund()
+	}
+	for i := 0; i < hedgeBudgetMaxTokens; i++ {
+		if !budget.withdraw() {
+			t.Fatalf("withdraw %d refused", i)
+		}
+	}
+	if budget.withdraw() {
+		t.Fatal("budget exceeded its cap")
+	}
+}
@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// routingScriptRequestVars are the variables available to a routing script condition.
+var routingScriptRequestVars = map[string]struct{}{
+	"model": {}, "key": {}, "format": {}, "stream": {}, "hour": {}, "minute": {}, "weekday": {},
+}
+
+// routingScriptAuthVars are the variables available to a credential filter: the request
+// variables plus the candidate credential.
+var routingScriptAuthVars = map[string]struct{}{
+	"model": {}, "key": {}, "format": {}, "stream": {}, "hour": {}, "minute": {}, "weekday": {},
+	"provider": {}, "auth": {}, "label": {}, "account": {}, "priority": {}, "utilization": {},
+}
+
+// RoutingScript is an expression-based routing rule: a request condition with an
+// optional model rewrite and an optional per-credential filter.
+type RoutingScript struct {
+	name     string
+	when     *scriptExpr
+	model    string
+	auths    *scriptExpr
+	location *time.Location
+}
+
+// RoutingRequest holds the request fields routing script conditions can inspect.
+type RoutingRequest struct {
+	// Model is the model requested by the client.
+	Model string
+	// APIKey is the proxy API key the client authenticated with.
+	APIKey string
+	// Format is the client request schema, e.g. "openai" or "claude".
+	Format string
+	// Stream reports whether the client asked for a streaming response.
+	Stream bool
+}
+
+// NewRoutingScript compiles a routing script. when is the request condition (empty
+// matches every request), model the optional model rewrite and auths the optional
+// credential filter; at least one of model and auths is required. timezone is an IANA
+// name used for the time variables; empty uses the server's local time.
+func NewRoutingScript(name, when, model, auths, timezone string) (RoutingScript, error) {
+	script := RoutingScript{name: strings.TrimSpace(name), model: strings.TrimSpace(model), location: time.Local}
+	var err error
+	if when = strings.TrimSpace(when); when != "" {
+		if script.when, err = compileScriptExpr(when, routingScriptRequestVars); err != nil {
+			return RoutingScript{}, fmt.Errorf("when: %w", err)
+		}
+	}
+	if auths = strings.TrimSpace(auths); auths != "" {
+		if script.auths, err = compileScriptExpr(auths, routingScriptAuthVars); err != nil {
+			return RoutingScript{}, fmt.Errorf("auths: %w", err)
+		}
+	}
+	if script.model == "" && script.auths == nil {
+		return RoutingScript{}, fmt.Errorf("a model or auths expression is required")
+	}
+	if timezone = strings.TrimSpace(timezone); timezone != "" {
+		if script.location, err = time.LoadLocation(timezone); err != nil {
+			return RoutingScript{}, fmt.Errorf("timezone %q: %w", timezone, err)
+		}
+	}
+	return script, nil
+}
+
+// Name returns the script name.
+func (s RoutingScript) Name() string { return s.name }
+
+func (s RoutingScript) requestEnv(req RoutingRequest, now time.Time) map[string]any {
+	local := now.In(s.location)
+	return map[string]any{
+		"model":   req.Model,
+		"key":     req.APIKey,
+		"format":  req.Format,
+		"stream":  req.Stream,
+		"hour":    float64(local.Hour()),
+		"minute":  float64(local.Minute()),
+		"weekday": strings.ToLower(local.Weekday().String()[:3]),
+	}
+}
+
+type routingScripts struct {
+	scripts []RoutingScript
+	source  UtilizationSource
+}
+
+var currentRoutingScripts atomic.Pointer[routingScripts]
+
+// SetRoutingScripts replaces the routing scripts. source supplies the utilization
+// variable of credential filters and may be nil. An empty list disables scripting.
+func SetRoutingScripts(scripts []RoutingScript, source UtilizationSource) {
+	if len(scripts) == 0 {
+		currentRoutingScripts.Store(nil)
+		return
+	}
+	named := make([]RoutingScript, len(scripts))
+	for i, script := range scripts {
+		if script.name == "" {
+			script.name = fmt.Sprintf("scripts[%d]", i)
+		}
+		named[i] = script
+	}
+	currentRoutingScripts.Store(&routingScripts{scripts: named, source: source})
+}
+
+// MatchRoutingScript returns the name and model rewrite of the first routing script
+// whose condition matches req. model is empty when the script does not rewrite it.
+// Scripts whose condition fails to evaluate are skipped.
+func MatchRoutingScript(req RoutingRequest, now time.Time) (name, model string, ok bool) {
+	current := currentRoutingScripts.Load()
+	if current == nil {
+		return "", "", false
+	}
+	for _, script := range current.scripts {
+		if script.when != nil {
+			matched, err := script.when.evalBool(script.requestEnv(req, now))
+			if err != nil {
+				log.Debugf("routing script %s: %v", script.name, err)
+				continue
+			}
+			if !matched {
+				continue
+			}
+		}
+		return script.name, script.model, true
+	}
+	return "", "", false
+}
+
+// filterScriptedAuths applies the credential filter of the routing script recorded in
+// the request metadata. Credentials whose filter fails to evaluate are dropped.
+func filterScriptedAuths(model string, opts cliproxyexecutor.Options, auths []*Auth, now time.Time) ([]*Auth, error) {
+	current := currentRoutingScripts.Load()
+	if current == nil || len(auths) == 0 {
+		return auths, nil
+	}
+	name, _ := opts.Metadata[cliproxyexecutor.RoutingScriptMetadataKey].(string)
+	if name == "" {
+		return auths, nil
+	}
+	var script *RoutingScript
+	for i := range current.scripts {
+		if current.scripts[i].name == name {
+			script = &current.scripts[i]
+			break
+		}
+	}
+	if script == nil || script.auths == nil {
+		return auths, nil
+	}
+	if requested, ok := opts.Metadata[cliproxyexecutor.RequestedModelMetadataKey].(string); ok && requested != "" {
+		model = requested
+	}
+	env := script.requestEnv(RoutingRequest{
+		Model:  model,
+		APIKey: clientAPIKeyFromMetadata(opts.Metadata),
+		Format: opts.SourceFormat.String(),
+		Stream: opts.Stream,
+	}, now)
+	kept := make([]*Auth, 0, len(auths))
+	for _, candidate := range auths {
+		env["provider"] = candidate.Provider
+		env["auth"] = candidate.ID
+		env["label"] = candidate.Label
+		_, account := candidate.AccountInfo()
+		env["account"] = account
+		env["priority"] = float64(authPriority(candidate))
+		utilization := 0.0
+		if current.source != nil {
+			utilization, _ = current.source.Utilization5h(candidate.UsageSource())
+		}
+		env["utilization"] = utilization
+		allowed, err := script.auths.evalBool(env)
+		if err != nil {
+			log.Debugf("routing script %s: auth %s: %v", script.name, candidate.ID, err)
+			continue
+		}
+		if allowed {
+			kept = append(kept, candidate)
+		}
+	}
+	if len(kept) == 0 {
+		return nil, &Error{Code: "auth_unavailable", Message: fmt.Sprintf("no credential is allowed by routing script %s", script.name)}
+	}
+	return kept, nil
+}
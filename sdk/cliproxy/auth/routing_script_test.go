@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+func TestScriptExprEval(t *testing.T) {
+	env := map[string]any{"model": "claude-sonnet-4-5", "key": "sk-batch", "hour": 10.0, "stream": true}
+	cases := []struct {
+		source string
+		want   bool
+	}{
+		{`key == "sk-batch" && hour >= 9 && hour < 18`, true},
+		{`!stream || hour > 20`, false},
+		{`hasPrefix(model, "claude-") && !contains(model, "opus")`, true},
+		{`match(model, "CLAUDE-*-4-5")`, true},
+		{`oneOf(key, "sk-a", "sk-batch")`, true},
+		{`(hour + 14) % 24 == 0`, true},
+		{`lower("ABC") == "abc"`, true},
+	}
+	for _, tc := range cases {
+		expr, err := compileScriptExpr(tc.source, routingScriptRequestVars)
+		if err != nil {
+			t.Fatalf("compile %q: %v", tc.source, err)
+		}
+		got, err := expr.evalBool(env)
+		if err != nil || got != tc.want {
+			t.Errorf("%q = %v (err %v), want %v", tc.source, got, err, tc.want)
+		}
+	}
+
+	for _, source := range []string{`utilization < 0.5`, `os.Exit(1)`, `model = "x"`, `split(model)`, `hasPrefix(model)`} {
+		if _, err := compileScriptExpr(source, routingScriptRequestVars); err == nil {
+			t.Errorf("compile %q: expected an error", source)
+		}
+	}
+
+	expr, _ := compileScriptExpr(`model > 1`, routingScriptRequestVars)
+	if _, err := expr.evalBool(env); err == nil {
+		t.Error("expected a type error comparing a string with a number")
+	}
+}
+
+func TestMatchRoutingScript(t *testing.T) {
+	batch, err := NewRoutingScript("batch", `key == "sk-batch"`, "gpt-5-mini", "", "UTC")
+	if err != nil {
+		t.Fatalf("NewRoutingScript: %v", err)
+	}
+	offHours, err := NewRoutingScript("", `hour >= 20`, "", `provider == "codex"`, "UTC")
+	if err != nil {
+		t.Fatalf("NewRoutingScript: %v", err)
+	}
+	if _, err = NewRoutingScript("empty", `true`, "", "", ""); err == nil {
+		t.Error("expected an error without model or auths")
+	}
+	SetRoutingScripts([]RoutingScript{batch, offHours}, nil)
+	t.Cleanup(func() { SetRoutingScripts(nil, nil) })
+
+	noon := time.Date(2026, 1, 5, 12, 0, 0, 0, time.UTC)
+	if name, model, ok := MatchRoutingScript(RoutingRequest{Model: "gpt-5", APIKey: "sk-batch"}, noon); !ok || name != "batch" || model != "gpt-5-mini" {
+		t.Errorf("batch key: got %q %q %v", name, model, ok)
+	}
+	if _, _, ok := MatchRoutingScript(RoutingRequest{Model: "gpt-5", APIKey: "sk-user"}, noon); ok {
+		t.Error("expected no script to match at noon")
+	}
+	if name, model, ok := MatchRoutingScript(RoutingRequest{Model: "gpt-5"}, noon.Add(9*time.Hour)); !ok || name != "scripts[1]" || model != "" {
+		t.Errorf("evening: got %q %q %v", name, model, ok)
+	}
+}
+
+func TestFillFirstSelectorPick_RoutingScriptFiltersAuths(t *testing.T) {
+	script, err := NewRoutingScript("spare-busy", `hasPrefix(model, "claude-")`, "", `utilization < 0.8`, "")
+	if err != nil {
+		t.Fatalf("NewRoutingScript: %v", err)
+	}
+	SetRoutingScripts([]RoutingScript{script}, fakeUtilization{"a@example.com": 0.9, "b@example.com": 0.5})
+	t.Cleanup(func() { SetRoutingScripts(nil, nil) })
+
+	selector := &FillFirstSelector{}
+	auths := []*Auth{
+		{ID: "a", Metadata: map[string]any{"email": "a@example.com"}},
+		{ID: "b", Metadata: map[string]any{"email": "b@example.com"}},
+	}
+	scripted := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.RoutingScriptMetadataKey: "spare-busy"}}
+
+	got, err := selector.Pick(context.Background(), "claude", "claude-sonnet-4-5", scripted, auths)
+	if err != nil || got.ID != "b" {
+		t.Fatalf("scripted request picked %v (err %v), want b", got, err)
+	}
+	got, err = selector.Pick(context.Background(), "claude", "claude-sonnet-4-5", cliproxyexecutor.Options{}, auths)
+	if err != nil || got.ID != "a" {
+		t.Fatalf("unscripted request picked %v (err %v), want a", got, err)
+	}
+	if _, err = selector.Pick(context.Background(), "claude", "claude-sonnet-4-5", scripted, auths[:1]); err == nil {
+		t.Fatal("expected an error when the script allows no credential")
+	}
+}
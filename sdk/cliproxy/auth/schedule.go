@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// RoutingSchedule limits the credentials it matches to a daily time window. During the
+// window they are preferred over credentials of the same priority that no schedule
+// matches, which then only take overflow; outside the window they are not selected.
+type RoutingSchedule struct {
+	auths    []string
+	start    int // minutes after midnight
+	end      int
+	days     [7]bool
+	location *time.Location
+}
+
+var weekdayNames = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// NewRoutingSchedule parses a schedule. auths matches credential IDs, file names, labels
+// or account emails, where '*' is a wildcard. hours is "HH:MM-HH:MM" and may wrap past
+// midnight. days lists weekdays ("mon".."sun"); empty means every day. timezone is an
+// IANA name; empty uses the server's local time.
+func NewRoutingSchedule(auths []string, hours string, days []string, timezone string) (RoutingSchedule, error) {
+	schedule := RoutingSchedule{location: time.Local}
+	for _, pattern := range auths {
+		if pattern = strings.ToLower(strings.TrimSpace(pattern)); pattern != "" {
+			schedule.auths = append(schedule.auths, pattern)
+		}
+	}
+	if len(schedule.auths) == 0 {
+		return RoutingSchedule{}, fmt.Errorf("no auths listed")
+	}
+	startText, endText, ok := strings.Cut(strings.TrimSpace(hours), "-")
+	if !ok {
+		return RoutingSchedule{}, fmt.Errorf("hours %q must look like 09:00-18:00", hours)
+	}
+	var err error
+	if schedule.start, err = parseClock(startText); err != nil {
+		return RoutingSchedule{}, err
+	}
+	if schedule.end, err = parseClock(endText); err != nil {
+		return RoutingSchedule{}, err
+	}
+	if schedule.start == schedule.end {
+		return RoutingSchedule{}, fmt.Errorf("hours %q is an empty window", hours)
+	}
+	if len(days) == 0 {
+		for i := range schedule.days {
+			schedule.days[i] = true
+		}
+	}
+	for _, day := range days {
+		name := strings.ToLower(strings.TrimSpace(day))
+		if len(name) > 3 {
+			name = name[:3]
+		}
+		weekday, found := weekdayNames[name]
+		if !found {
+			return RoutingSchedule{}, fmt.Errorf("unknown day %q", day)
+		}
+		schedule.days[weekday] = true
+	}
+	if timezone = strings.TrimSpace(timezone); timezone != "" {
+		if schedule.location, err = time.LoadLocation(timezone); err != nil {
+			return RoutingSchedule{}, fmt.Errorf("timezone %q: %w", timezone, err)
+		}
+	}
+	return schedule, nil
+}
+
+func parseClock(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// active reports whether now falls inside the window. For a window wrapping past
+// midnight the days apply to the day it starts on.
+func (s RoutingSchedule) active(now time.Time) bool {
+	local := now.In(s.location)
+	minute := local.Hour()*60 + local.Minute()
+	if s.start < s.end {
+		return s.days[local.Weekday()] && minute >= s.start && minute < s.end
+	}
+	if minute >= s.start {
+		return s.days[local.Weekday()]
+	}
+	return minute < s.end && s.days[local.AddDate(0, 0, -1).Weekday()]
+}
+
+// matches reports whether the schedule applies to auth.
+func (s RoutingSchedule) matches(auth *Auth) bool {
+	names := []string{auth.ID, auth.Label}
+	if auth.FileName != "" {
+		names = append(names, filepath.Base(auth.FileName))
+	}
+	if _, account := auth.AccountInfo(); account != "" {
+		names = append(names, account)
+	}
+	for _, pattern := range s.auths {
+		for _, name := range names {
+			if name != "" && matchSchedulePattern(pattern, strings.ToLower(strings.TrimSpace(name))) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matchSchedulePattern matches a lower-cased value against a lower-cased pattern where
+// '*' matches zero or more characters.
+func matchSchedulePattern(pattern, value string) bool {
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(value, part)
+		if idx < 0 {
+			return false
+		}
+		value = value[idx+len(part):]
+	}
+	return strings.HasSuffix(value, parts[len(parts)-1])
+}
+
+var currentRoutingSchedules atomic.Pointer[[]RoutingSchedule]
+
+// SetRoutingSchedules replaces the time-of-day routing rules. An empty list disables them.
+func SetRoutingSchedules(schedules []RoutingSchedule) {
+	if len(schedules) == 0 {
+		currentRoutingSchedules.Store(nil)
+		return
+	}
+	cloned := append([]RoutingSchedule(nil), schedules...)
+	currentRoutingSchedules.Store(&cloned)
+}
+
+// scheduleState classifies an auth: unscheduled when no schedule matches it, otherwise
+// whether any matching schedule is active at now.
+func scheduleState(schedules []RoutingSchedule, auth *Auth, now time.Time) (scheduled, active bool) {
+	for _, schedule := range schedules {
+		if !schedule.matches(auth) {
+			continue
+		}
+		scheduled = true
+		if schedule.active(now) {
+			return true, true
+		}
+	}
+	return scheduled, false
+}
+
+// excludeOffScheduleAuths drops credentials whose schedules are all inactive at now. It
+// runs before priority grouping so lower-priority credentials take over off-hours.
+func excludeOffScheduleAuths(auths []*Auth, now time.Time) ([]*Auth, error) {
+	schedules := currentRoutingSchedules.Load()
+	if schedules == nil || len(auths) == 0 {
+		return auths, nil
+	}
+	kept := make([]*Auth, 0, len(auths))
+	for _, candidate := range auths {
+		if scheduled, active := scheduleState(*schedules, candidate, now); scheduled && !active {
+			continue
+		}
+		kept = append(kept, candidate)
+	}
+	if len(kept) == 0 {
+		return nil, &Error{Code: "auth_unavailable", Message: "no credential is scheduled for the current time"}
+	}
+	return kept, nil
+}
+
+// preferScheduledAuths narrows the available credentials to those in an active
+// schedule window, leaving unscheduled credentials as overflow.
+func preferScheduledAuths(available []*Auth, now time.Time) []*Auth {
+	schedules := currentRoutingSchedules.Load()
+	if schedules == nil || len(available) < 2 {
+		return available
+	}
+	preferred := make([]*Auth, 0, len(available))
+	for _, candidate := range available {
+		if _, active := scheduleState(*schedules, candidate, now); active {
+			preferred = append(preferred, candidate)
+		}
+	}
+	if len(preferred) == 0 {
+		return available
+	}
+	return preferred
+}
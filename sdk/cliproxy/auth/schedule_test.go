@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRoutingScheduleActive(t *testing.T) {
+	office, err := NewRoutingSchedule([]string{"max@example.com"}, "09:00-18:00", []string{"mon", "Friday"}, "UTC")
+	if err != nil {
+		t.Fatalf("NewRoutingSchedule: %v", err)
+	}
+	monday := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		at   time.Time
+		want bool
+	}{
+		{monday.Add(9 * time.Hour), true},
+		{monday.Add(17*time.Hour + 59*time.Minute), true},
+		{monday.Add(18 * time.Hour), false},
+		{monday.AddDate(0, 0, 4).Add(10 * time.Hour), true},
+		{monday.AddDate(0, 0, 1).Add(10 * time.Hour), false},
+	}
+	for _, tc := range cases {
+		if got := office.active(tc.at); got != tc.want {
+			t.Fatalf("active(%s) = %v, want %v", tc.at, got, tc.want)
+		}
+	}
+
+	night, err := NewRoutingSchedule([]string{"*"}, "22:00-06:00", []string{"fri"}, "UTC")
+	if err != nil {
+		t.Fatalf("NewRoutingSchedule: %v", err)
+	}
+	friday := monday.AddDate(0, 0, 4)
+	if !night.active(friday.Add(23*time.Hour)) || !night.active(friday.AddDate(0, 0, 1).Add(5*time.Hour)) {
+		t.Fatal("overnight window should cover friday night into saturday morning")
+	}
+	if night.active(friday.Add(5 * time.Hour)) {
+		t.Fatal("overnight window started on thursday should not apply")
+	}
+
+	if _, err = NewRoutingSchedule([]string{"a"}, "9-18", nil, ""); err == nil {
+		t.Fatal("malformed hours should be rejected")
+	}
+}
+
+func TestSelectorRoutingSchedules(t *testing.T) {
+	office, err := NewRoutingSchedule([]string{"max@example.com"}, "09:00-18:00", nil, "UTC")
+	if err != nil {
+		t.Fatalf("NewRoutingSchedule: %v", err)
+	}
+	SetRoutingSchedules([]RoutingSchedule{office})
+	t.Cleanup(func() { SetRoutingSchedules(nil) })
+
+	auths := []*Auth{
+		{ID: "api-key-1"},
+		{ID: "max", Metadata: map[string]any{"email": "max@example.com"}},
+	}
+	day := time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC)
+	night := time.Date(2026, 10, 12, 20, 0, 0, 0, time.UTC)
+
+	kept, err := excludeOffScheduleAuths(auths, day)
+	if err != nil || len(kept) != 2 {
+		t.Fatalf("daytime candidates = %v (err %v), want both", kept, err)
+	}
+	if preferred := preferScheduledAuths(kept, day); len(preferred) != 1 || preferred[0].ID != "max" {
+		t.Fatalf("daytime preference = %v, want max", preferred)
+	}
+
+	kept, err = excludeOffScheduleAuths(auths, night)
+	if err != nil || len(kept) != 1 || kept[0].ID != "api-key-1" {
+		t.Fatalf("night candidates = %v (err %v), want api-key-1 only", kept, err)
+	}
+	if _, err = excludeOffScheduleAuths(auths[1:], night); err == nil {
+		t.Fatal("expected an error when only off-schedule credentials remain")
+	}
+}
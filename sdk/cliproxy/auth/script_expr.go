@@ -0,0 +1,292 @@
+package auth
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// scriptExpr is a compiled routing expression. Expressions use Go syntax restricted to
+// literals, variables, comparison, arithmetic and logical operators and a few string
+// helpers. Values are strings, numbers (float64) and booleans.
+type scriptExpr struct {
+	source string
+	root   ast.Expr
+}
+
+// scriptFuncs maps helper names to their arity; negative means at least -arity arguments.
+var scriptFuncs = map[string]int{
+	"hasPrefix": 2,
+	"hasSuffix": 2,
+	"contains":  2,
+	"lower":     1,
+	"match":     2,
+	"oneOf":     -2,
+}
+
+// compiledScriptExprs caches compiled expressions by source so config reloads reuse them.
+var compiledScriptExprs sync.Map // map[string]*scriptExpr
+
+// compileScriptExpr parses source and checks that it only references known variables
+// and helpers.
+func compileScriptExpr(source string, vars map[string]struct{}) (*scriptExpr, error) {
+	source = strings.TrimSpace(source)
+	if cached, ok := compiledScriptExprs.Load(source); ok {
+		expr := cached.(*scriptExpr)
+		if err := checkScriptExpr(expr.root, vars); err != nil {
+			return nil, err
+		}
+		return expr, nil
+	}
+	root, err := parser.ParseExpr(source)
+	if err != nil {
+		return nil, fmt.Errorf("parse %q: %w", source, err)
+	}
+	if err = checkScriptExpr(root, vars); err != nil {
+		return nil, err
+	}
+	expr := &scriptExpr{source: source, root: root}
+	compiledScriptExprs.Store(source, expr)
+	return expr, nil
+}
+
+func checkScriptExpr(node ast.Expr, vars map[string]struct{}) error {
+	switch n := node.(type) {
+	case *ast.BasicLit:
+		if n.Kind != token.INT && n.Kind != token.FLOAT && n.Kind != token.STRING {
+			return fmt.Errorf("unsupported literal %s", n.Value)
+		}
+		return nil
+	case *ast.Ident:
+		if n.Name == "true" || n.Name == "false" {
+			return nil
+		}
+		if _, ok := vars[n.Name]; !ok {
+			return fmt.Errorf("unknown variable %q", n.Name)
+		}
+		return nil
+	case *ast.ParenExpr:
+		return checkScriptExpr(n.X, vars)
+	case *ast.UnaryExpr:
+		if n.Op != token.NOT && n.Op != token.SUB {
+			return fmt.Errorf("unsupported operator %s", n.Op)
+		}
+		return checkScriptExpr(n.X, vars)
+	case *ast.BinaryExpr:
+		switch n.Op {
+		case token.LAND, token.LOR, token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ,
+			token.ADD, token.SUB, token.MUL, token.QUO, token.REM:
+		default:
+			return fmt.Errorf("unsupported operator %s", n.Op)
+		}
+		if err := checkScriptExpr(n.X, vars); err != nil {
+			return err
+		}
+		return checkScriptExpr(n.Y, vars)
+	case *ast.CallExpr:
+		fn, ok := n.Fun.(*ast.Ident)
+		if !ok {
+			return fmt.Errorf("unsupported call")
+		}
+		arity, known := scriptFuncs[fn.Name]
+		if !known {
+			return fmt.Errorf("unknown function %q", fn.Name)
+		}
+		if (arity >= 0 && len(n.Args) != arity) || (arity < 0 && len(n.Args) < -arity) || n.Ellipsis.IsValid() {
+			return fmt.Errorf("wrong number of arguments to %s", fn.Name)
+		}
+		for _, arg := range n.Args {
+			if err := checkScriptExpr(arg, vars); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported expression %T", node)
+	}
+}
+
+// evalBool evaluates the expression and requires a boolean result.
+func (e *scriptExpr) evalBool(env map[string]any) (bool, error) {
+	value, err := evalScriptNode(e.root, env)
+	if err != nil {
+		return false, err
+	}
+	result, ok := value.(bool)
+	if !ok {
+		return false, fmt.Errorf("%q evaluates to %T, want bool", e.source, value)
+	}
+	return result, nil
+}
+
+func evalScriptNode(node ast.Expr, env map[string]any) (any, error) {
+	switch n := node.(type) {
+	case *ast.BasicLit:
+		if n.Kind == token.STRING {
+			return strconv.Unquote(n.Value)
+		}
+		return strconv.ParseFloat(n.Value, 64)
+	case *ast.Ident:
+		switch n.Name {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		}
+		return env[n.Name], nil
+	case *ast.ParenExpr:
+		return evalScriptNode(n.X, env)
+	case *ast.UnaryExpr:
+		value, err := evalScriptNode(n.X, env)
+		if err != nil {
+			return nil, err
+		}
+		if n.Op == token.NOT {
+			b, ok := value.(bool)
+			if !ok {
+				return nil, fmt.Errorf("operator ! needs a bool, got %T", value)
+			}
+			return !b, nil
+		}
+		f, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("operator - needs a number, got %T", value)
+		}
+		return -f, nil
+	case *ast.BinaryExpr:
+		return evalScriptBinary(n, env)
+	case *ast.CallExpr:
+		args := make([]any, len(n.Args))
+		for i, arg := range n.Args {
+			value, err := evalScriptNode(arg, env)
+			if err != nil {
+				return nil, err
+			}
+			args[i] = value
+		}
+		return callScriptFunc(n.Fun.(*ast.Ident).Name, args)
+	}
+	return nil, fmt.Errorf("unsupported expression %T", node)
+}
+
+func evalScriptBinary(n *ast.BinaryExpr, env map[string]any) (any, error) {
+	left, err := evalScriptNode(n.X, env)
+	if err != nil {
+		return nil, err
+	}
+	if n.Op == token.LAND || n.Op == token.LOR {
+		lb, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s needs bools, got %T", n.Op, left)
+		}
+		if (n.Op == token.LAND && !lb) || (n.Op == token.LOR && lb) {
+			return lb, nil
+		}
+		right, errRight := evalScriptNode(n.Y, env)
+		if errRight != nil {
+			return nil, errRight
+		}
+		rb, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("operator %s needs bools, got %T", n.Op, right)
+		}
+		return rb, nil
+	}
+	right, err := evalScriptNode(n.Y, env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.Op {
+	case token.EQL:
+		return left == right, nil
+	case token.NEQ:
+		return left != right, nil
+	}
+	if ls, ok := left.(string); ok {
+		rs, okRight := right.(string)
+		if !okRight {
+			return nil, fmt.Errorf("operator %s: mismatched types string and %T", n.Op, right)
+		}
+		switch n.Op {
+		case token.ADD:
+			return ls + rs, nil
+		case token.LSS:
+			return ls < rs, nil
+		case token.LEQ:
+			return ls <= rs, nil
+		case token.GTR:
+			return ls > rs, nil
+		case token.GEQ:
+			return ls >= rs, nil
+		}
+		return nil, fmt.Errorf("operator %s is not defined on strings", n.Op)
+	}
+	lf, okLeft := left.(float64)
+	rf, okRight := right.(float64)
+	if !okLeft || !okRight {
+		return nil, fmt.Errorf("operator %s: mismatched types %T and %T", n.Op, left, right)
+	}
+	switch n.Op {
+	case token.ADD:
+		return lf + rf, nil
+	case token.SUB:
+		return lf - rf, nil
+	case token.MUL:
+		return lf * rf, nil
+	case token.QUO:
+		if rf == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return lf / rf, nil
+	case token.REM:
+		if int64(rf) == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return float64(int64(lf) % int64(rf)), nil
+	case token.LSS:
+		return lf < rf, nil
+	case token.LEQ:
+		return lf <= rf, nil
+	case token.GTR:
+		return lf > rf, nil
+	case token.GEQ:
+		return lf >= rf, nil
+	}
+	return nil, fmt.Errorf("unsupported operator %s", n.Op)
+}
+
+func callScriptFunc(name string, args []any) (any, error) {
+	if name == "oneOf" {
+		for _, candidate := range args[1:] {
+			if args[0] == candidate {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+	strs := make([]string, len(args))
+	for i, arg := range args {
+		s, ok := arg.(string)
+		if !ok {
+			return nil, fmt.Errorf("%s needs string arguments, got %T", name, arg)
+		}
+		strs[i] = s
+	}
+	switch name {
+	case "hasPrefix":
+		return strings.HasPrefix(strs[0], strs[1]), nil
+	case "hasSuffix":
+		return strings.HasSuffix(strs[0], strs[1]), nil
+	case "contains":
+		return strings.Contains(strs[0], strs[1]), nil
+	case "lower":
+		return strings.ToLower(strs[0]), nil
+	case "match":
+		return matchSchedulePattern(strings.ToLower(strs[1]), strings.ToLower(strs[0])), nil
+	}
+	return nil, fmt.Errorf("unknown function %q", name)
+}
@@ -244,18 +244,35 @@ func getAvailableAuths(auths []*Auth, provider, model string, now time.Time) ([]
 	if len(available) > 1 {
 		sort.Slice(available, func(i, j int) bool { return available[i].ID < available[j].ID })
 	}
-	return available, nil
+	return deprioritizeFailingAuths(available), nil
 }
 
 // Pick selects the next available auth for the provider in a round-robin manner.
 func (s *RoundRobinSelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
-	_ = opts
 	now := time.Now()
+	auths, err := excludeOffScheduleAuths(auths, now)
+	if err != nil {
+		return nil, err
+	}
+	auths, err = filterScriptedAuths(model, opts, auths, now)
+	if err != nil {
+		return nil, err
+	}
 	available, err := getAvailableAuths(auths, provider, model, now)
 	if err != nil {
 		return nil, err
 	}
+	available = preferScheduledAuths(available, now)
+	available = preferSubscriptionAuths(opts, available)
 	available = preferCodexWebsocketAuths(ctx, provider, available)
+	available, err = reserveInteractiveHeadroom(opts, available)
+	if err != nil {
+		return nil, err
+	}
+	sticky, cacheKey := stickyPromptCacheAuth(provider, model, opts, available)
+	if sticky != nil {
+		return sticky, nil
+	}
 	key := provider + ":" + canonicalModelKey(model)
 	s.mu.Lock()
 	if s.cursors == nil {
@@ -277,18 +294,38 @@ func (s *RoundRobinSelector) Pick(ctx context.Context, provider, model string, o
 	s.cursors[key] = index + 1
 	s.mu.Unlock()
 	// log.Debugf("available: %d, index: %d, key: %d", len(available), index, index%len(available))
-	return available[index%len(available)], nil
+	selected := available[index%len(available)]
+	rememberPromptCacheAuth(cacheKey, selected)
+	return selected, nil
 }
 
 // Pick selects the first available auth for the provider in a deterministic manner.
 func (s *FillFirstSelector) Pick(ctx context.Context, provider, model string, opts cliproxyexecutor.Options, auths []*Auth) (*Auth, error) {
-	_ = opts
 	now := time.Now()
+	auths, err := excludeOffScheduleAuths(auths, now)
+	if err != nil {
+		return nil, err
+	}
+	auths, err = filterScriptedAuths(model, opts, auths, now)
+	if err != nil {
+		return nil, err
+	}
 	available, err := getAvailableAuths(auths, provider, model, now)
 	if err != nil {
 		return nil, err
 	}
+	available = preferScheduledAuths(available, now)
+	available = preferSubscriptionAuths(opts, available)
 	available = preferCodexWebsocketAuths(ctx, provider, available)
+	available, err = reserveInteractiveHeadroom(opts, available)
+	if err != nil {
+		return nil, err
+	}
+	sticky, cacheKey := stickyPromptCacheAuth(provider, model, opts, available)
+	if sticky != nil {
+		return sticky, nil
+	}
+	rememberPromptCacheAuth(cacheKey, available[0])
 	return available[0], nil
 }
 
@@ -402,3 +402,33 @@ func TestRoundRobinSelectorPick_CursorKeyCap(t *testing.T) {
 		t.Fatalf("selector.cursors missing key %q", "gemini:m3")
 	}
 }
+
+type staticErrorRates map[string]float64
+
+func (r staticErrorRates) ErrorRate(authID string) float64 { return r[authID] }
+
+func TestSelectorPick_DeprioritizesFailingAuths(t *testing.T) {
+	SetErrorRateScoring(staticErrorRates{"a": 0.9, "b": 0.1}, 0.5)
+	t.Cleanup(func() { SetErrorRateScoring(nil, 0) })
+
+	auths := []*Auth{{ID: "a"}, {ID: "b"}, {ID: "c"}}
+	selector := &RoundRobinSelector{}
+	for i := 0; i < 4; i++ {
+		got, err := selector.Pick(context.Background(), "gemini", "", cliproxyexecutor.Options{}, auths)
+		if err != nil {
+			t.Fatalf("Pick() error = %v", err)
+		}
+		if got.ID == "a" {
+			t.Fatalf("Pick() #%d selected degraded auth %q", i, got.ID)
+		}
+	}
+
+	SetErrorRateScoring(staticErrorRates{"a": 0.9, "b": 0.8}, 0.5)
+	got, err := (&FillFirstSelector{}).Pick(context.Background(), "gemini", "", cliproxyexecutor.Options{}, auths[:2])
+	if err != nil {
+		t.Fatalf("Pick() error = %v", err)
+	}
+	if got.ID != "a" {
+		t.Fatalf("Pick() auth.ID = %q, want %q when every auth is degraded", got.ID, "a")
+	}
+}
@@ -0,0 +1,77 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// UtilizationSource reports the current 5-hour window utilization (0..1) of a usage
+// source, and whether it is known.
+type UtilizationSource interface {
+	Utilization5h(source string) (float64, bool)
+}
+
+type softLimit struct {
+	source      UtilizationSource
+	threshold   float64
+	interactive map[string]struct{}
+}
+
+var currentSoftLimit atomic.Pointer[softLimit]
+
+// SetSoftLimit stops routing requests from API keys not listed in interactiveKeys to
+// credentials whose 5h utilization exceeds threshold, keeping the remaining headroom
+// for interactive keys. A nil source or a threshold outside (0, 1) disables it.
+func SetSoftLimit(source UtilizationSource, threshold float64, interactiveKeys []string) {
+	if source == nil || threshold <= 0 || threshold >= 1 {
+		currentSoftLimit.Store(nil)
+		return
+	}
+	interactive := make(map[string]struct{}, len(interactiveKeys))
+	for _, key := range interactiveKeys {
+		if key = strings.TrimSpace(key); key != "" {
+			interactive[key] = struct{}{}
+		}
+	}
+	currentSoftLimit.Store(&softLimit{source: source, threshold: threshold, interactive: interactive})
+}
+
+// reserveInteractiveHeadroom drops credentials above the soft limit for requests from
+// non-interactive keys. Unlike error-rate scoring it does not fall back when every
+// candidate is over the limit: the request is refused so the headroom stays reserved.
+func reserveInteractiveHeadroom(opts cliproxyexecutor.Options, available []*Auth) ([]*Auth, error) {
+	limit := currentSoftLimit.Load()
+	if limit == nil || len(available) == 0 {
+		return available, nil
+	}
+	if _, ok := limit.interactive[clientAPIKeyFromMetadata(opts.Metadata)]; ok {
+		return available, nil
+	}
+	within := make([]*Auth, 0, len(available))
+	for _, candidate := range available {
+		if utilization, ok := limit.source.Utilization5h(candidate.UsageSource()); ok && utilization > limit.threshold {
+			continue
+		}
+		within = append(within, candidate)
+	}
+	if len(within) == 0 {
+		return nil, &Error{
+			Code:       "soft_limit_reached",
+			Message:    fmt.Sprintf("all credentials are above the %.0f%% 5h soft limit reserved for interactive keys", limit.threshold*100),
+			HTTPStatus: http.StatusTooManyRequests,
+		}
+	}
+	return within, nil
+}
+
+func clientAPIKeyFromMetadata(meta map[string]any) string {
+	if len(meta) == 0 {
+		return ""
+	}
+	key, _ := meta[cliproxyexecutor.ClientAPIKeyMetadataKey].(string)
+	return strings.TrimSpace(key)
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type fakeUtilization map[string]float64
+
+func (f fakeUtilization) Utilization5h(source string) (float64, bool) {
+	v, ok := f[source]
+	return v, ok
+}
+
+func TestFillFirstSelectorPick_SoftLimit(t *testing.T) {
+	SetSoftLimit(fakeUtilization{"a@example.com": 0.9, "b@example.com": 0.5}, 0.8, []string{"interactive-key"})
+	t.Cleanup(func() { SetSoftLimit(nil, 0, nil) })
+
+	selector := &FillFirstSelector{}
+	auths := []*Auth{
+		{ID: "a", Metadata: map[string]any{"email": "a@example.com"}},
+		{ID: "b", Metadata: map[string]any{"email": "b@example.com"}},
+	}
+	batch := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.ClientAPIKeyMetadataKey: "batch-key"}}
+	interactive := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.ClientAPIKeyMetadataKey: "interactive-key"}}
+
+	got, err := selector.Pick(context.Background(), "claude", "claude-sonnet-4-5", batch, auths)
+	if err != nil || got.ID != "b" {
+		t.Fatalf("batch key picked %v (err %v), want b below the soft limit", got, err)
+	}
+	got, err = selector.Pick(context.Background(), "claude", "claude-sonnet-4-5", interactive, auths)
+	if err != nil || got.ID != "a" {
+		t.Fatalf("interactive key picked %v (err %v), want a", got, err)
+	}
+
+	_, err = selector.Pick(context.Background(), "claude", "claude-sonnet-4-5", batch, auths[:1])
+	var authErr *Error
+	if !errors.As(err, &authErr) || authErr.HTTPStatus != http.StatusTooManyRequests {
+		t.Fatalf("batch key with every account over the soft limit: err = %v, want 429", err)
+	}
+}
@@ -0,0 +1,82 @@
+package auth
+
+import (
+	"sync/atomic"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+// UnifiedUtilizationSource reports the highest current utilization (0..1) across the
+// rate-limit windows of a usage source, and whether it is known.
+type UnifiedUtilizationSource interface {
+	UnifiedUtilization(source string) (float64, bool)
+}
+
+type spendRouting struct {
+	source    UnifiedUtilizationSource
+	threshold float64
+}
+
+var currentSpendRouting atomic.Pointer[spendRouting]
+
+// SetSpendAwareRouting prefers subscription (OAuth) credentials over metered API-key
+// credentials until every subscription candidate's utilization exceeds threshold, and
+// spills over to the API keys after that. A nil source or a threshold outside (0, 1]
+// disables it.
+func SetSpendAwareRouting(source UnifiedUtilizationSource, threshold float64) {
+	if source == nil || threshold <= 0 || threshold > 1 {
+		currentSpendRouting.Store(nil)
+		return
+	}
+	currentSpendRouting.Store(&spendRouting{source: source, threshold: threshold})
+}
+
+// preferSubscriptionAuths narrows mixed OAuth and API-key candidates to the OAuth ones
+// below the threshold, or to the API-key ones when none is. Credentials with unknown
+// utilization count as below the threshold. The decision is recorded in the request
+// metadata so usage records can report the spill-over.
+func preferSubscriptionAuths(opts cliproxyexecutor.Options, available []*Auth) []*Auth {
+	routing := currentSpendRouting.Load()
+	if routing == nil || len(available) < 2 {
+		markSpillOver(opts, false)
+		return available
+	}
+	var subscription, within, metered []*Auth
+	for _, candidate := range available {
+		switch kind, _ := candidate.AccountInfo(); kind {
+		case "oauth":
+			subscription = append(subscription, candidate)
+			if utilization, ok := routing.source.UnifiedUtilization(candidate.UsageSource()); !ok || utilization < routing.threshold {
+				within = append(within, candidate)
+			}
+		case "api_key":
+			metered = append(metered, candidate)
+		}
+	}
+	if len(subscription) == 0 || len(metered) == 0 {
+		markSpillOver(opts, false)
+		return available
+	}
+	if len(within) > 0 {
+		markSpillOver(opts, false)
+		return within
+	}
+	markSpillOver(opts, true)
+	return metered
+}
+
+func markSpillOver(opts cliproxyexecutor.Options, spilled bool) {
+	if opts.Metadata == nil {
+		return
+	}
+	if spilled {
+		opts.Metadata[cliproxyexecutor.SpillOverMetadataKey] = true
+		return
+	}
+	delete(opts.Metadata, cliproxyexecutor.SpillOverMetadataKey)
+}
+
+func spillOverFromMetadata(meta map[string]any) bool {
+	spilled, _ := meta[cliproxyexecutor.SpillOverMetadataKey].(bool)
+	return spilled
+}
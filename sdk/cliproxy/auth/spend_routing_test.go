@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+)
+
+type fakeUnifiedUtilization map[string]float64
+
+func (f fakeUnifiedUtilization) UnifiedUtilization(source string) (float64, bool) {
+	v, ok := f[source]
+	return v, ok
+}
+
+func TestFillFirstSelectorPick_SpendAwareRouting(t *testing.T) {
+	utilization := fakeUnifiedUtilization{"max@example.com": 0.5}
+	SetSpendAwareRouting(utilization, 0.9)
+	t.Cleanup(func() { SetSpendAwareRouting(nil, 0) })
+
+	selector := &FillFirstSelector{}
+	auths := []*Auth{
+		{ID: "a-api-key", Attributes: map[string]string{"api_key": "sk-ant-1"}},
+		{ID: "b-max", Metadata: map[string]any{"email": "max@example.com"}},
+	}
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{}}
+
+	got, err := selector.Pick(context.Background(), "claude", "claude-sonnet-4-5", opts, auths)
+	if err != nil || got.ID != "b-max" {
+		t.Fatalf("picked %v (err %v), want the subscription account below the threshold", got, err)
+	}
+	if spillOverFromMetadata(opts.Metadata) {
+		t.Fatal("subscription pick must not be flagged as spill-over")
+	}
+
+	utilization["max@example.com"] = 0.95
+	got, err = selector.Pick(context.Background(), "claude", "claude-sonnet-4-5", opts, auths)
+	if err != nil || got.ID != "a-api-key" {
+		t.Fatalf("picked %v (err %v), want the API key once the subscription is saturated", got, err)
+	}
+	if !spillOverFromMetadata(opts.Metadata) {
+		t.Fatal("API-key pick after saturation should be flagged as spill-over")
+	}
+
+	got, err = selector.Pick(context.Background(), "claude", "claude-sonnet-4-5", opts, auths[1:])
+	if err != nil || got.ID != "b-max" || spillOverFromMetadata(opts.Metadata) {
+		t.Fatalf("without API keys the saturated subscription should still serve, got %v (err %v)", got, err)
+	}
+}
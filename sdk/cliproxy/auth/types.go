@@ -313,6 +313,50 @@ func parseIntAny(val any) (int, bool) {
 	}
 }
 
+// UsageSource returns the label usage and latency statistics are grouped by: the
+// project for gemini-cli and vertex, otherwise the account, email, or API key.
+func (a *Auth) UsageSource() string {
+	if a == nil {
+		return ""
+	}
+	provider := strings.TrimSpace(a.Provider)
+	if strings.EqualFold(provider, "gemini-cli") {
+		if id := strings.TrimSpace(a.ID); id != "" {
+			return id
+		}
+	}
+	if strings.EqualFold(provider, "vertex") {
+		if a.Metadata != nil {
+			if projectID, ok := a.Metadata["project_id"].(string); ok {
+				if trimmed := strings.TrimSpace(projectID); trimmed != "" {
+					return trimmed
+				}
+			}
+			if project, ok := a.Metadata["project"].(string); ok {
+				if trimmed := strings.TrimSpace(project); trimmed != "" {
+					return trimmed
+				}
+			}
+		}
+	}
+	if _, value := a.AccountInfo(); value != "" {
+		return strings.TrimSpace(value)
+	}
+	if a.Metadata != nil {
+		if email, ok := a.Metadata["email"].(string); ok {
+			if trimmed := strings.TrimSpace(email); trimmed != "" {
+				return trimmed
+			}
+		}
+	}
+	if a.Attributes != nil {
+		if key := strings.TrimSpace(a.Attributes["api_key"]); key != "" {
+			return key
+		}
+	}
+	return ""
+}
+
 func (a *Auth) AccountInfo() (string, string) {
 	if a == nil {
 		return "", ""
@@ -48,6 +48,9 @@ type Builder struct {
 
 	// serverOptions contains additional server configuration options.
 	serverOptions []api.ServerOption
+
+	// credentials are registered with the core manager when the service starts.
+	credentials []*coreauth.Auth
 }
 
 // Hooks allows callers to plug into service lifecycle stages.
@@ -85,6 +88,8 @@ func (b *Builder) WithConfig(cfg *config.Config) *Builder {
 }
 
 // WithConfigPath sets the absolute configuration file path used for reload watching.
+// When left empty the service runs from the in-memory configuration only: config hot
+// reload is disabled and management edits are not persisted.
 //
 // Parameters:
 //   - path: The absolute path to the configuration file
@@ -138,6 +143,17 @@ func (b *Builder) WithCoreAuthManager(mgr *coreauth.Manager) *Builder {
 	return b
 }
 
+// WithCredentials registers credentials with the service in addition to the ones found
+// in the auth directory and configuration. They are applied when the service starts.
+func (b *Builder) WithCredentials(auths ...*coreauth.Auth) *Builder {
+	for _, auth := range auths {
+		if auth != nil {
+			b.credentials = append(b.credentials, auth.Clone())
+		}
+	}
+	return b
+}
+
 // WithServerOptions appends server configuration options used during construction.
 func (b *Builder) WithServerOptions(opts ...api.ServerOption) *Builder {
 	b.serverOptions = append(b.serverOptions, opts...)
@@ -158,10 +174,6 @@ func (b *Builder) Build() (*Service, error) {
 	if b.cfg == nil {
 		return nil, fmt.Errorf("cliproxy: configuration is required")
 	}
-	if b.configPath == "" {
-		return nil, fmt.Errorf("cliproxy: configuration path is required")
-	}
-
 	tokenProvider := b.tokenProvider
 	if tokenProvider == nil {
 		tokenProvider = NewFileTokenClientProvider()
@@ -227,6 +239,7 @@ func (b *Builder) Build() (*Service, error) {
 		accessManager:  accessManager,
 		coreManager:    coreManager,
 		serverOptions:  append([]api.ServerOption(nil), b.serverOptions...),
+		credentials:    b.credentials,
 	}
 	return service, nil
 }
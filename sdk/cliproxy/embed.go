@@ -0,0 +1,77 @@
+package cliproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+// Options configures a proxy embedded in another Go program. Unlike the standalone
+// binary, no configuration file is required: the configuration and credentials are
+// provided in code.
+type Options struct {
+	// Config is the proxy configuration. It is sanitized the same way a loaded
+	// configuration file is.
+	Config *config.Config
+
+	// ConfigPath optionally points at a configuration file to watch for hot reload.
+	// When empty, the in-memory configuration is used as-is for the service lifetime.
+	ConfigPath string
+
+	// Credentials are registered with the proxy on startup, alongside the ones found in
+	// Config.AuthDir and the API keys listed in Config.
+	Credentials []*coreauth.Auth
+
+	// CoreAuthManager optionally replaces the default runtime auth manager.
+	CoreAuthManager *coreauth.Manager
+
+	// ServerOptions customise the HTTP server (middleware, extra routes, loggers).
+	ServerOptions []api.ServerOption
+
+	// Hooks are lifecycle callbacks run around server startup.
+	Hooks Hooks
+}
+
+// New builds an embeddable proxy service from opts. Call Serve to run it.
+func New(opts Options) (*Service, error) {
+	if opts.Config == nil {
+		return nil, fmt.Errorf("cliproxy: configuration is required")
+	}
+	if err := opts.Config.Sanitize(); err != nil {
+		return nil, fmt.Errorf("cliproxy: invalid configuration: %w", err)
+	}
+	opts.Config.ApplyCredentialEncryption()
+	for i, auth := range opts.Credentials {
+		if auth == nil || strings.TrimSpace(auth.ID) == "" || strings.TrimSpace(auth.Provider) == "" {
+			return nil, fmt.Errorf("cliproxy: credentials[%d]: id and provider are required", i)
+		}
+	}
+	builder := NewBuilder().
+		WithConfig(opts.Config).
+		WithConfigPath(opts.ConfigPath).
+		WithCredentials(opts.Credentials...).
+		WithServerOptions(opts.ServerOptions...).
+		WithHooks(opts.Hooks)
+	if opts.CoreAuthManager != nil {
+		builder.WithCoreAuthManager(opts.CoreAuthManager)
+	}
+	return builder.Build()
+}
+
+// Serve runs the service until ctx is cancelled and shuts it down gracefully. Unlike
+// Run it returns nil when the service stopped because ctx was cancelled.
+func (s *Service) Serve(ctx context.Context) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	err := s.Run(ctx)
+	if err != nil && ctx.Err() != nil && errors.Is(err, ctx.Err()) {
+		return nil
+	}
+	return err
+}
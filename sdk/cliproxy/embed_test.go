@@ -0,0 +1,89 @@
+package cliproxy
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+func TestNewRejectsInvalidOptions(t *testing.T) {
+	if _, err := New(Options{}); err == nil {
+		t.Error("expected an error without a configuration")
+	}
+	_, err := New(Options{
+		Config:      &config.Config{},
+		Credentials: []*coreauth.Auth{{ID: "no-provider"}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "credentials[0]") {
+		t.Errorf("err = %v, want a credentials error", err)
+	}
+}
+
+func TestServeWithoutConfigFile(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	_ = listener.Close()
+
+	cfg := &config.Config{Host: "127.0.0.1", Port: port, AuthDir: t.TempDir()}
+	cfg.APIKeys = []string{"sk-embed"}
+	svc, err := New(Options{
+		Config: cfg,
+		Credentials: []*coreauth.Auth{{
+			ID:         "embed-claude",
+			Provider:   "claude",
+			Attributes: map[string]string{"api_key": "sk-ant-test"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	t.Cleanup(func() { GlobalModelRegistry().UnregisterClient("embed-claude") })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- svc.Serve(ctx) }()
+
+	base := "http://127.0.0.1:" + strconv.Itoa(port)
+	var body string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+		req, _ := http.NewRequest(http.MethodGet, base+"/v1/models", nil)
+		req.Header.Set("Authorization", "Bearer sk-embed")
+		resp, errGet := http.DefaultClient.Do(req)
+		if errGet != nil {
+			continue
+		}
+		data, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if resp.StatusCode == http.StatusOK {
+			body = string(data)
+			break
+		}
+	}
+	if !strings.Contains(body, "claude") {
+		t.Errorf("models = %q, want the credential's claude models", body)
+	}
+	if auth, ok := svc.coreManager.GetByID("embed-claude"); !ok || auth.Provider != "claude" {
+		t.Errorf("credential not registered: %+v", auth)
+	}
+
+	cancel()
+	select {
+	case err = <-done:
+		if err != nil {
+			t.Errorf("Serve: %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Serve did not return after cancel")
+	}
+}
@@ -1,6 +1,18 @@
 package executor
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+// ErrClientCancelled is the context cause when the downstream client disconnected or
+// cancelled the response before it completed.
+var ErrClientCancelled = errors.New("client cancelled the request")
+
+// ClientCancelled reports whether ctx was cancelled on behalf of the downstream client.
+func ClientCancelled(ctx context.Context) bool {
+	return ctx != nil && errors.Is(context.Cause(ctx), ErrClientCancelled)
+}
 
 type downstreamWebsocketContextKey struct{}
 
@@ -0,0 +1,222 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/tidwall/gjson"
+)
+
+// ErrorKind is the provider-neutral category of an upstream failure.
+type ErrorKind string
+
+const (
+	// ErrorKindInvalidRequest is a malformed request; no credential can serve it.
+	ErrorKindInvalidRequest ErrorKind = "invalid_request"
+	// ErrorKindAuth is a rejected or expired credential.
+	ErrorKindAuth ErrorKind = "authentication"
+	// ErrorKindPermission is a credential without access to the model or feature.
+	ErrorKindPermission ErrorKind = "permission"
+	// ErrorKindBilling is an account without credit or with an exhausted paid quota.
+	ErrorKindBilling ErrorKind = "billing"
+	// ErrorKindNotFound is a model or endpoint the credential does not serve.
+	ErrorKindNotFound ErrorKind = "not_found"
+	// ErrorKindRateLimit is a rate or usage limit that resets over time.
+	ErrorKindRateLimit ErrorKind = "rate_limit"
+	// ErrorKindOverloaded is a provider that is temporarily out of capacity.
+	ErrorKindOverloaded ErrorKind = "overloaded"
+	// ErrorKindTimeout is a request that timed out upstream or on the way there.
+	ErrorKindTimeout ErrorKind = "timeout"
+	// ErrorKindServer is any other upstream server failure.
+	ErrorKindServer ErrorKind = "server"
+	// ErrorKindOther is a failure that fits no other category.
+	ErrorKindOther ErrorKind = "other"
+)
+
+// UpstreamError is a classified upstream failure. Executors may return it directly;
+// ClassifyError derives one from any other error so routing and response formatting
+// decide on the same facts instead of matching error strings.
+type UpstreamError struct {
+	// Provider is the executor identifier that produced the error, when known.
+	Provider string
+	// Status is the HTTP status reported by the provider, or 0 for transport failures.
+	Status int
+	// Code is the provider's own error code, e.g. "overloaded_error",
+	// "insufficient_quota" or "RESOURCE_EXHAUSTED".
+	Code string
+	// Kind is the provider-neutral category.
+	Kind ErrorKind
+	// Message is the provider's error body or message.
+	Message string
+	// Retryable reports whether the same credential may succeed later.
+	Retryable bool
+	// Failover reports whether another credential may succeed now.
+	Failover bool
+	// Retry is the provider-supplied delay before the credential can be retried.
+	Retry *time.Duration
+	// Header carries upstream response headers worth passing to the client.
+	Header http.Header
+
+	cause error
+}
+
+// Error implements the error interface with the provider's message.
+func (e *UpstreamError) Error() string {
+	if e == nil {
+		return ""
+	}
+	if e.Message != "" {
+		return e.Message
+	}
+	if e.Status > 0 {
+		return http.StatusText(e.Status)
+	}
+	return string(e.Kind)
+}
+
+// StatusCode implements StatusError.
+func (e *UpstreamError) StatusCode() int { return e.Status }
+
+// RetryAfter returns the provider-supplied retry delay, if any.
+func (e *UpstreamError) RetryAfter() *time.Duration { return e.Retry }
+
+// Headers returns upstream response headers attached to the error.
+func (e *UpstreamError) Headers() http.Header { return e.Header }
+
+// Unwrap returns the error the classification was derived from.
+func (e *UpstreamError) Unwrap() error { return e.cause }
+
+// ClassifyError returns the classification of err. An UpstreamError in the chain is
+// returned as is; any other error is classified from its status code, the provider
+// error code in its message and its retry delay. It returns nil for a nil error.
+func ClassifyError(provider string, err error) *UpstreamError {
+	if err == nil {
+		return nil
+	}
+	var upstream *UpstreamError
+	if errors.As(err, &upstream) && upstream != nil {
+		return upstream
+	}
+
+	classified := &UpstreamError{Provider: provider, Message: err.Error(), cause: err}
+	var statusErr StatusError
+	if errors.As(err, &statusErr) && statusErr != nil {
+		classified.Status = statusErr.StatusCode()
+	}
+	var retryAfter interface{ RetryAfter() *time.Duration }
+	if errors.As(err, &retryAfter) && retryAfter != nil {
+		classified.Retry = retryAfter.RetryAfter()
+	}
+	var headers interface{ Headers() http.Header }
+	if errors.As(err, &headers) && headers != nil {
+		classified.Header = headers.Headers()
+	}
+
+	classified.classify(err)
+	return classified
+}
+
+// ClassifyResponse classifies a failed upstream response from its status and body.
+func ClassifyResponse(provider string, status int, body []byte) *UpstreamError {
+	classified := &UpstreamError{Provider: provider, Status: status, Message: string(body)}
+	classified.classify(nil)
+	return classified
+}
+
+func (e *UpstreamError) classify(err error) {
+	codes := ProviderErrorCodes([]byte(e.Message))
+	if len(codes) > 0 {
+		e.Code = codes[0]
+	}
+	e.Kind = classifyKind(e.Status, codes, e.Message, err)
+	e.Retryable, e.Failover = kindRetryPolicy(e.Kind)
+}
+
+// ProviderErrorCodes extracts the error codes from a provider error body in the order
+// error.code, error.status, error.type, covering the OpenAI, Gemini and Claude formats.
+// Gemini bodies wrapped in an array are unwrapped. Numeric codes are skipped.
+func ProviderErrorCodes(body []byte) []string {
+	trimmed := strings.TrimSpace(string(body))
+	if trimmed == "" || !gjson.Valid(trimmed) {
+		return nil
+	}
+	root := gjson.Parse(trimmed)
+	if root.IsArray() {
+		root = root.Get("0")
+	}
+	errNode := root.Get("error")
+	if !errNode.IsObject() {
+		return nil
+	}
+	var codes []string
+	for _, key := range []string{"code", "status", "type"} {
+		if value := errNode.Get(key); value.Type == gjson.String && strings.TrimSpace(value.String()) != "" {
+			codes = append(codes, strings.TrimSpace(value.String()))
+		}
+	}
+	return codes
+}
+
+func classifyKind(status int, codes []string, message string, err error) ErrorKind {
+	has := func(candidates ...string) bool {
+		for _, code := range codes {
+			for _, candidate := range candidates {
+				if strings.EqualFold(code, candidate) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	lower := strings.ToLower(message)
+
+	switch {
+	case status == http.StatusPaymentRequired || has("insufficient_quota", "billing_error", "billing_hard_limit_reached") ||
+		strings.Contains(lower, "credit balance is too low"):
+		return ErrorKindBilling
+	case status == http.StatusTooManyRequests || has("rate_limit_error", "rate_limit_exceeded", "resource_exhausted"):
+		return ErrorKindRateLimit
+	case status == 529 || has("overloaded_error", "unavailable"):
+		return ErrorKindOverloaded
+	case status == http.StatusUnauthorized || has("authentication_error", "invalid_api_key", "unauthenticated"):
+		return ErrorKindAuth
+	case status == http.StatusForbidden || has("permission_error", "permission_denied"):
+		return ErrorKindPermission
+	case status == http.StatusNotFound || has("not_found_error", "not_found", "model_not_found"):
+		return ErrorKindNotFound
+	case status == http.StatusRequestTimeout || status == http.StatusGatewayTimeout || has("deadline_exceeded", "timeout_error"):
+		return ErrorKindTimeout
+	case status >= http.StatusInternalServerError || has("api_error", "server_error", "internal"):
+		return ErrorKindServer
+	case status == http.StatusBadRequest && (has("invalid_request_error") || (len(codes) == 0 && strings.Contains(message, "invalid_request_error"))):
+		return ErrorKindInvalidRequest
+	}
+	if err == nil {
+		return ErrorKindOther
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrorKindTimeout
+	}
+	var netErr interface{ Timeout() bool }
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ErrorKindTimeout
+	}
+	return ErrorKindOther
+}
+
+// kindRetryPolicy returns whether a kind is worth retrying on the same credential
+// later and whether another credential should be tried now. Only invalid requests
+// stop failover, since another account or provider may accept anything else.
+func kindRetryPolicy(kind ErrorKind) (retryable, failover bool) {
+	switch kind {
+	case ErrorKindInvalidRequest:
+		return false, false
+	case ErrorKindRateLimit, ErrorKindOverloaded, ErrorKindTimeout, ErrorKindServer:
+		return true, true
+	default:
+		return false, true
+	}
+}
@@ -0,0 +1,55 @@
+package executor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type testStatusErr struct {
+	code  int
+	msg   string
+	retry *time.Duration
+}
+
+func (e testStatusErr) Error() string              { return e.msg }
+func (e testStatusErr) StatusCode() int            { return e.code }
+func (e testStatusErr) RetryAfter() *time.Duration { return e.retry }
+
+func TestClassifyError(t *testing.T) {
+	retry := 30 * time.Second
+	cases := []struct {
+		name      string
+		err       error
+		kind      ErrorKind
+		code      string
+		retryable bool
+		failover  bool
+	}{
+		{"claude invalid request", testStatusErr{code: 400, msg: `{"type":"error","error":{"type":"invalid_request_error","message":"messages: field required"}}`}, ErrorKindInvalidRequest, "invalid_request_error", false, false},
+		{"claude low credit", testStatusErr{code: 400, msg: `{"type":"error","error":{"type":"invalid_request_error","message":"Your credit balance is too low to access the Anthropic API."}}`}, ErrorKindBilling, "invalid_request_error", false, true},
+		{"claude overloaded", testStatusErr{code: 529, msg: `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`}, ErrorKindOverloaded, "overloaded_error", true, true},
+		{"openai quota", testStatusErr{code: 429, msg: `{"error":{"message":"quota","type":"insufficient_quota","code":"insufficient_quota"}}`}, ErrorKindBilling, "insufficient_quota", false, true},
+		{"gemini exhausted", testStatusErr{code: 429, msg: `[{"error":{"code":429,"message":"quota","status":"RESOURCE_EXHAUSTED"}}]`, retry: &retry}, ErrorKindRateLimit, "RESOURCE_EXHAUSTED", true, true},
+		{"plain 401", testStatusErr{code: 401, msg: "missing access token"}, ErrorKindAuth, "", false, true},
+		{"bad gateway", testStatusErr{code: 502, msg: "<html>bad gateway</html>"}, ErrorKindServer, "", true, true},
+		{"deadline", fmt.Errorf("request failed: %w", context.DeadlineExceeded), ErrorKindTimeout, "", true, true},
+	}
+	for _, tc := range cases {
+		got := ClassifyError("test", tc.err)
+		if got.Kind != tc.kind || got.Code != tc.code || got.Retryable != tc.retryable || got.Failover != tc.failover {
+			t.Errorf("%s: got kind=%s code=%q retryable=%t failover=%t, want %s %q %t %t",
+				tc.name, got.Kind, got.Code, got.Retryable, got.Failover, tc.kind, tc.code, tc.retryable, tc.failover)
+		}
+	}
+
+	limited := ClassifyError("gemini", cases[4].err)
+	if limited.RetryAfter() == nil || *limited.RetryAfter() != retry || limited.StatusCode() != http.StatusTooManyRequests {
+		t.Fatalf("classification lost status or retry delay: %+v", limited)
+	}
+	if again := ClassifyError("other", fmt.Errorf("wrapped: %w", limited)); again != limited {
+		t.Fatal("an UpstreamError in the chain should be returned as is")
+	}
+}
@@ -19,6 +19,20 @@ const (
 	SelectedAuthCallbackMetadataKey = "selected_auth_callback"
 	// ExecutionSessionMetadataKey identifies a long-lived downstream execution session.
 	ExecutionSessionMetadataKey = "execution_session_id"
+	// PassthroughModelMetadataKey marks a model forwarded verbatim to a provider that does
+	// not list it, so auth selection skips the registry model check.
+	PassthroughModelMetadataKey = "passthrough_model"
+	// ClientAPIKeyMetadataKey carries the proxy API key the client authenticated with.
+	ClientAPIKeyMetadataKey = "client_api_key"
+	// SpillOverMetadataKey is set by credential selection when a request was routed to a
+	// metered API-key credential because the subscription credentials were near their limits.
+	SpillOverMetadataKey = "spill_over"
+	// RoutingScriptMetadataKey names the routing script that matched the request, whose
+	// credential filter then applies during auth selection.
+	RoutingScriptMetadataKey = "routing_script"
+	// ParameterHeadersMetadataKey carries the config.ModelParameters parsed from the
+	// client's parameter override headers.
+	ParameterHeadersMetadataKey = "parameter_headers"
 )
 
 // Request encapsulates the translated payload that will be sent to a provider executor.
@@ -0,0 +1,145 @@
+// Package provider lets long-tail upstream providers plug into CLIProxyAPI without
+// touching core routing code. A Registration bundles the login flow, the executor and
+// the translators of one provider; the service consults the registry whenever it binds
+// authenticators, executors or models for an auth whose provider is not built in.
+package provider
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	sdkauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+// Translator describes one request/response transform pair contributed by a provider.
+type Translator struct {
+	From     sdktranslator.Format
+	To       sdktranslator.Format
+	Request  sdktranslator.RequestTransform
+	Response sdktranslator.ResponseTransform
+}
+
+// Registration describes a pluggable provider.
+type Registration struct {
+	// Key is the provider identifier stored in auth records (the "type" field of auth files).
+	Key string
+	// Authenticator builds the login flow used by the login commands and management API. Optional.
+	Authenticator func() sdkauth.Authenticator
+	// Executor builds the executor that serves requests for auths of this provider. Required.
+	Executor func(cfg *config.Config) coreauth.ProviderExecutor
+	// Models lists the models exposed by every auth of this provider. Optional.
+	Models func() []*registry.ModelInfo
+	// Translators are registered with the default translator registry on Register.
+	Translators []Translator
+}
+
+// builtinProviders are handled by core routing and cannot be replaced through the registry.
+var builtinProviders = map[string]struct{}{
+	"gemini":               {},
+	"vertex":               {},
+	"gemini-cli":           {},
+	"aistudio":             {},
+	"antigravity":          {},
+	"claude":               {},
+	"codex":                {},
+	"qwen":                 {},
+	"iflow":                {},
+	"kimi":                 {},
+	"openai-compatibility": {},
+}
+
+var (
+	registryMu    sync.RWMutex
+	registrations = make(map[string]Registration)
+)
+
+// Register adds or replaces a provider registration. It is typically called from init.
+func Register(reg Registration) error {
+	key := normalizeKey(reg.Key)
+	if key == "" {
+		return fmt.Errorf("provider: registration key is empty")
+	}
+	if _, builtin := builtinProviders[key]; builtin {
+		return fmt.Errorf("provider: %s is a built-in provider", key)
+	}
+	if reg.Executor == nil {
+		return fmt.Errorf("provider: %s has no executor factory", key)
+	}
+	reg.Key = key
+
+	for _, tr := range reg.Translators {
+		if tr.From == "" || tr.To == "" {
+			return fmt.Errorf("provider: %s has a translator without formats", key)
+		}
+	}
+	for _, tr := range reg.Translators {
+		sdktranslator.Register(tr.From, tr.To, tr.Request, tr.Response)
+	}
+	if reg.Authenticator != nil {
+		factory := reg.Authenticator
+		coreauth.RegisterRefreshLeadProvider(key, func() *time.Duration {
+			if authenticator := factory(); authenticator != nil {
+				return authenticator.RefreshLead()
+			}
+			return nil
+		})
+	}
+
+	registryMu.Lock()
+	registrations[key] = reg
+	registryMu.Unlock()
+	return nil
+}
+
+// MustRegister is like Register but panics on error.
+func MustRegister(reg Registration) {
+	if err := Register(reg); err != nil {
+		panic(err)
+	}
+}
+
+// Unregister removes a provider registration. Translators stay registered.
+func Unregister(key string) {
+	registryMu.Lock()
+	delete(registrations, normalizeKey(key))
+	registryMu.Unlock()
+}
+
+// Lookup returns the registration for the provider key.
+func Lookup(key string) (Registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	reg, ok := registrations[normalizeKey(key)]
+	return reg, ok
+}
+
+// Registered returns all registrations sorted by key.
+func Registered() []Registration {
+	registryMu.RLock()
+	out := make([]Registration, 0, len(registrations))
+	for _, reg := range registrations {
+		out = append(out, reg)
+	}
+	registryMu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+	return out
+}
+
+// ListModels returns the models of the registration, or nil when none are declared.
+func (r Registration) ListModels() []*registry.ModelInfo {
+	if r.Models == nil {
+		return nil
+	}
+	return r.Models()
+}
+
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.TrimSpace(key))
+}
@@ -0,0 +1,91 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v6/sdk/translator"
+)
+
+type stubExecutor struct{}
+
+func (stubExecutor) Identifier() string { return "stub" }
+func (stubExecutor) Execute(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+func (stubExecutor) ExecuteStream(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	return nil, nil
+}
+func (stubExecutor) Refresh(_ context.Context, a *coreauth.Auth) (*coreauth.Auth, error) {
+	return a, nil
+}
+func (stubExecutor) CountTokens(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+func (stubExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func newStubExecutor(*config.Config) coreauth.ProviderExecutor { return stubExecutor{} }
+
+func TestRegisterValidates(t *testing.T) {
+	cases := []struct {
+		name string
+		reg  Registration
+	}{
+		{"empty key", Registration{Executor: newStubExecutor}},
+		{"builtin", Registration{Key: "Claude", Executor: newStubExecutor}},
+		{"no executor", Registration{Key: "stub"}},
+		{"translator without formats", Registration{Key: "stub", Executor: newStubExecutor, Translators: []Translator{{From: "openai"}}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := Register(tc.reg); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+	if _, ok := Lookup("stub"); ok {
+		t.Fatal("invalid registrations must not be stored")
+	}
+}
+
+func TestRegisterLookupAndTranslators(t *testing.T) {
+	from := sdktranslator.Format("stub-test.client")
+	to := sdktranslator.Format("stub-test.upstream")
+	err := Register(Registration{
+		Key:      " Stub ",
+		Executor: newStubExecutor,
+		Models: func() []*registry.ModelInfo {
+			return []*registry.ModelInfo{{ID: "stub-1"}}
+		},
+		Translators: []Translator{{
+			From:    from,
+			To:      to,
+			Request: func(_ string, raw []byte, _ bool) []byte { return append([]byte("x"), raw...) },
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	t.Cleanup(func() { Unregister("stub") })
+
+	reg, ok := Lookup("STUB")
+	if !ok || reg.Key != "stub" {
+		t.Fatalf("Lookup = %+v, %v", reg, ok)
+	}
+	if models := reg.ListModels(); len(models) != 1 || models[0].ID != "stub-1" {
+		t.Fatalf("models = %+v", models)
+	}
+	if got := string(sdktranslator.TranslateRequest(from, to, "m", []byte("y"), false)); got != "xy" {
+		t.Fatalf("translated = %q, want %q", got, "xy")
+	}
+	if all := Registered(); len(all) != 1 || all[0].Key != "stub" {
+		t.Fatalf("Registered = %+v", all)
+	}
+}
@@ -13,14 +13,19 @@ import (
 	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/api"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/credsource"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/runtime/executor"
-	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/translator/translator"
+	internalusage "github.com/router-for-me/CLIProxyAPI/v6/internal/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/watcher"
 	"github.com/router-for-me/CLIProxyAPI/v6/internal/wsrelay"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v6/sdk/access"
 	sdkAuth "github.com/router-for-me/CLIProxyAPI/v6/sdk/auth"
 	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	sdkprovider "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/provider"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/usage"
 	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
 	log "github.com/sirupsen/logrus"
@@ -89,6 +94,15 @@ type Service struct {
 
 	// wsGateway manages websocket Gemini providers.
 	wsGateway *wsrelay.Manager
+
+	// credSources polls external secret stores for runtime-only credentials.
+	credSources *credsource.Syncer
+
+	// credentials are the programmatic credentials registered on startup.
+	credentials []*coreauth.Auth
+
+	// usageLifecycle runs the auto-save and compaction of the usage stores.
+	usageLifecycle *internalusage.Lifecycle
 }
 
 // RegisterUsagePlugin registers a usage plugin on the global usage manager.
@@ -102,13 +116,19 @@ func (s *Service) RegisterUsagePlugin(plugin usage.Plugin) {
 
 // newDefaultAuthManager creates a default authentication manager with all supported providers.
 func newDefaultAuthManager() *sdkAuth.Manager {
-	return sdkAuth.NewManager(
+	mgr := sdkAuth.NewManager(
 		sdkAuth.GetTokenStore(),
 		sdkAuth.NewGeminiAuthenticator(),
 		sdkAuth.NewCodexAuthenticator(),
 		sdkAuth.NewClaudeAuthenticator(),
 		sdkAuth.NewQwenAuthenticator(),
 	)
+	for _, reg := range sdkprovider.Registered() {
+		if reg.Authenticator != nil {
+			mgr.Register(reg.Authenticator())
+		}
+	}
+	return mgr
 }
 
 func (s *Service) ensureAuthUpdateQueue(ctx context.Context) {
@@ -331,6 +351,40 @@ func (s *Service) applyCoreAuthRemoval(ctx context.Context, id string) {
 	}
 }
 
+// RegisterCredential adds or replaces a credential at runtime and registers its models.
+// Credentials registered this way are kept in memory and, when the auth store persists,
+// written to it.
+func (s *Service) RegisterCredential(ctx context.Context, auth *coreauth.Auth) error {
+	if s == nil || s.coreManager == nil {
+		return fmt.Errorf("cliproxy: service is nil")
+	}
+	if auth == nil || strings.TrimSpace(auth.ID) == "" || strings.TrimSpace(auth.Provider) == "" {
+		return fmt.Errorf("cliproxy: credential id and provider are required")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s.applyCoreAuthAddOrUpdate(ctx, auth)
+	return nil
+}
+
+// RemoveCredential disables the credential with the given id and unregisters its models.
+func (s *Service) RemoveCredential(ctx context.Context, id string) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	s.applyCoreAuthRemoval(ctx, id)
+}
+
+// RescanAuths re-reads the auth directory and applies new, changed and removed token files
+// to the credential pool, so accounts can be added without a restart.
+func (s *Service) RescanAuths() (watcher.AuthRescanResult, error) {
+	if s == nil || s.watcher == nil {
+		return watcher.AuthRescanResult{}, fmt.Errorf("cliproxy: watcher not running")
+	}
+	return s.watcher.RescanAuths(), nil
+}
+
 func (s *Service) applyRetryConfig(cfg *config.Config) {
 	if s == nil || s.coreManager == nil || cfg == nil {
 		return
@@ -339,6 +393,161 @@ func (s *Service) applyRetryConfig(cfg *config.Config) {
 	s.coreManager.SetRetryConfig(cfg.RequestRetry, maxInterval)
 }
 
+// applyErrorRateConfig wires the usage error-rate store, the 5h soft limit, spend-aware
+// routing, prompt cache affinity and time-of-day schedules into credential selection.
+func (s *Service) applyErrorRateConfig(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	store := internalusage.GetErrorRateStore()
+	store.SetHalfLife(time.Duration(cfg.Routing.ErrorRateHalfLifeSeconds) * time.Second)
+	coreauth.SetErrorRateScoring(store, cfg.Routing.ErrorRateThreshold)
+	coreauth.SetSoftLimit(internalusage.GetRateLimitStore(), cfg.Routing.SoftLimit5hPercent/100, cfg.Routing.InteractiveAPIKeys)
+	coreauth.SetSpendAwareRouting(internalusage.GetRateLimitStore(), cfg.Routing.SpillOverPercent/100)
+
+	var affinityTTL time.Duration
+	if cfg.Routing.PromptCacheAffinity {
+		affinityTTL = 300 * time.Second
+		if cfg.Routing.PromptCacheAffinityTTLSeconds > 0 {
+			affinityTTL = time.Duration(cfg.Routing.PromptCacheAffinityTTLSeconds) * time.Second
+		}
+	}
+	coreauth.SetPromptCacheAffinity(affinityTTL)
+
+	schedules := make([]coreauth.RoutingSchedule, 0, len(cfg.Routing.Schedules))
+	for i, entry := range cfg.Routing.Schedules {
+		schedule, err := coreauth.NewRoutingSchedule(entry.Auths, entry.Hours, entry.Days, entry.Timezone)
+		if err != nil {
+			log.Warnf("routing.schedules[%d]: %v, schedule ignored", i, err)
+			continue
+		}
+		schedules = append(schedules, schedule)
+	}
+	coreauth.SetRoutingSchedules(schedules)
+
+	scripts := make([]coreauth.RoutingScript, 0, len(cfg.Routing.Scripts))
+	for i, entry := range cfg.Routing.Scripts {
+		name := entry.Name
+		if strings.TrimSpace(name) == "" {
+			name = fmt.Sprintf("scripts[%d]", i)
+		}
+		script, err := coreauth.NewRoutingScript(name, entry.When, entry.Model, entry.Auths, entry.Timezone)
+		if err != nil {
+			log.Warnf("routing.scripts[%d]: %v, script ignored", i, err)
+			continue
+		}
+		scripts = append(scripts, script)
+	}
+	coreauth.SetRoutingScripts(scripts, internalusage.GetRateLimitStore())
+}
+
+// applyTranslatorConfig applies runtime translator options.
+func (s *Service) applyTranslatorConfig(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	translator.SetUnknownEventMode(cfg.UnknownStreamEvents)
+	translator.SetEmptyMessageMode(cfg.EmptyMessages)
+	translator.SetPredictionMode(cfg.Prediction)
+}
+
+// applyCacheConfig applies the thinking cache memory limits and cache persistence.
+func (s *Service) applyCacheConfig(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	cache.SetThinkingCacheLimits(cache.ThinkingCacheLimits{
+		MaxEntryBytes: cfg.ThinkingCache.MaxEntryKB << 10,
+		MaxTotalBytes: cfg.ThinkingCache.MaxTotalMB << 20,
+		Compress:      cfg.ThinkingCache.Compress,
+	})
+	var persister cache.Persister
+	if path := strings.TrimSpace(cfg.CacheSnapshotFile); path != "" {
+		persister = cache.FilePersister{Path: path}
+	}
+	if err := cache.SetPersister(persister); err != nil {
+		log.Errorf("cache snapshot not restored: %v", err)
+	}
+}
+
+// applyUsageExportConfig starts, reconfigures or stops the usage event exporter.
+func (s *Service) applyUsageExportConfig(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	if err := internalusage.ConfigureUsageExporter(cfg); err != nil {
+		log.Errorf("usage export disabled: %v", err)
+	}
+}
+
+// applyUsageRetentionConfig applies the retention of the usage stores and compacts them.
+func (s *Service) applyUsageRetentionConfig(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	internalusage.ConfigureRetention(cfg)
+}
+
+// startUsageLifecycle starts saving the usage stores per cfg.UsageAutoSave (after N new
+// records or T seconds, whichever comes first) and compacting them, until shutdown.
+func (s *Service) startUsageLifecycle(ctx context.Context) {
+	if s.usageLifecycle == nil {
+		s.usageLifecycle = internalusage.NewLifecycle()
+	}
+	err := s.usageLifecycle.Start(ctx, internalusage.AutoSavePolicy{
+		MaxRecords: s.cfg.UsageAutoSave.MaxRecords,
+		Interval:   time.Duration(s.cfg.UsageAutoSave.IntervalSeconds) * time.Second,
+	})
+	if err != nil {
+		log.Warnf("usage auto-save settings of this service ignored: %v", err)
+	}
+}
+
+// applyModelPinConfig pins the "-latest" model targets of the configuration to concrete
+// versions so responses stay reproducible; drift across reloads is logged by the registry.
+func (s *Service) applyModelPinConfig(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	if cfg.DisableModelPinning {
+		registry.PinModels(nil)
+		return
+	}
+	targets := make(map[string][]string)
+	add := func(channel, name string) {
+		name = thinking.ParseSuffix(strings.TrimSpace(name)).ModelName
+		if registry.IsFloatingModel(name) {
+			targets[channel] = append(targets[channel], name)
+		}
+	}
+	for i := range cfg.ClaudeKey {
+		for _, model := range cfg.ClaudeKey[i].Models {
+			add("claude", model.Name)
+		}
+	}
+	for i := range cfg.GeminiKey {
+		for _, model := range cfg.GeminiKey[i].Models {
+			add("gemini", model.Name)
+		}
+	}
+	for i := range cfg.CodexKey {
+		for _, model := range cfg.CodexKey[i].Models {
+			add("codex", model.Name)
+		}
+	}
+	for i := range cfg.VertexCompatAPIKey {
+		for _, model := range cfg.VertexCompatAPIKey[i].Models {
+			add("vertex", model.Name)
+		}
+	}
+	for channel, aliases := range cfg.OAuthModelAlias {
+		for _, alias := range aliases {
+			add(channel, alias.Name)
+		}
+	}
+	registry.PinModels(targets)
+}
+
 func openAICompatInfoFromAuth(a *coreauth.Auth) (providerKey string, compatName string, ok bool) {
 	if a == nil {
 		return "", "", false
@@ -418,7 +627,13 @@ func (s *Service) ensureExecutorsForAuthWithMode(a *coreauth.Auth, forceReplace
 		s.coreManager.RegisterExecutor(executor.NewIFlowExecutor(s.cfg))
 	case "kimi":
 		s.coreManager.RegisterExecutor(executor.NewKimiExecutor(s.cfg))
+	case "mock":
+		s.coreManager.RegisterExecutor(executor.NewMockExecutor(s.cfg))
 	default:
+		if reg, ok := sdkprovider.Lookup(a.Provider); ok {
+			s.coreManager.RegisterExecutor(reg.Executor(s.cfg))
+			return
+		}
 		providerKey := strings.ToLower(strings.TrimSpace(a.Provider))
 		if providerKey == "" {
 			providerKey = "openai-compatibility"
@@ -477,12 +692,25 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 
 	s.applyRetryConfig(s.cfg)
+	s.applyErrorRateConfig(s.cfg)
+	s.applyTranslatorConfig(s.cfg)
+	s.applyCacheConfig(s.cfg)
+	s.applyModelPinConfig(s.cfg)
+	s.applyUsageExportConfig(s.cfg)
+	s.applyUsageRetentionConfig(s.cfg)
+	s.startUsageLifecycle(ctx)
+	if coreauth.ChaosEnabled(s.cfg.Chaos) {
+		log.Warn("chaos mode enabled: synthetic upstream failures will be injected")
+	}
 
 	if s.coreManager != nil {
 		if errLoad := s.coreManager.Load(ctx); errLoad != nil {
 			log.Warnf("failed to load auth store: %v", errLoad)
 		}
 	}
+	for _, auth := range s.credentials {
+		s.applyCoreAuthAddOrUpdate(ctx, auth)
+	}
 
 	tokenResult, err := s.tokenProvider.Load(ctx, s.cfg)
 	if err != nil && !errors.Is(err, context.Canceled) {
@@ -509,6 +737,10 @@ func (s *Service) Run(ctx context.Context) error {
 		s.authManager = newDefaultAuthManager()
 	}
 
+	if s.server != nil {
+		s.server.SetAuthRescanHandler(s.RescanAuths)
+	}
+
 	s.ensureWebsocketGateway()
 	if s.server != nil && s.wsGateway != nil {
 		s.server.AttachWebsocketRoute(s.wsGateway.Path(), s.wsGateway.Handler())
@@ -593,6 +825,12 @@ func (s *Service) Run(ctx context.Context) error {
 		}
 
 		s.applyRetryConfig(newCfg)
+		s.applyErrorRateConfig(newCfg)
+		s.applyTranslatorConfig(newCfg)
+		s.applyCacheConfig(newCfg)
+		s.applyModelPinConfig(newCfg)
+		s.applyUsageExportConfig(newCfg)
+		s.applyUsageRetentionConfig(newCfg)
 		s.applyPprofConfig(newCfg)
 		if s.server != nil {
 			s.server.UpdateClients(newCfg)
@@ -605,6 +843,9 @@ func (s *Service) Run(ctx context.Context) error {
 			s.coreManager.SetOAuthModelAlias(newCfg.OAuthModelAlias)
 		}
 		s.rebindExecutors()
+		if s.credSources != nil {
+			s.credSources.Apply(newCfg)
+		}
 	}
 
 	watcherWrapper, err = s.watcherFactory(s.configPath, s.cfg.AuthDir, reloadCallback)
@@ -618,6 +859,11 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 	watcherWrapper.SetConfig(s.cfg)
 
+	// The syncer must exist before the watcher starts: config reloads apply to it from
+	// the watcher goroutine.
+	s.credSources = credsource.NewSyncer(s.emitAuthUpdate)
+	s.credSources.Apply(s.cfg)
+
 	watcherCtx, watcherCancel := context.WithCancel(context.Background())
 	s.watcherCancel = watcherCancel
 	if err = watcherWrapper.Start(watcherCtx); err != nil {
@@ -641,6 +887,20 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 }
 
+// CheckResponsive returns once the locks that request handling depends on (the auth
+// manager and the usage stores) can be taken. It never returns while one of them is
+// deadlocked, which lets a watchdog detect a hung process.
+func (s *Service) CheckResponsive() {
+	if s == nil {
+		return
+	}
+	if s.coreManager != nil {
+		_ = s.coreManager.List()
+	}
+	_ = internalusage.GetRateLimitStore().Latest()
+	_ = internalusage.GetErrorRateStore().ErrorRate("")
+}
+
 // Shutdown gracefully stops background workers and the HTTP server.
 // It ensures all resources are properly cleaned up and connections are closed.
 // The shutdown is idempotent and can be called multiple times safely.
@@ -665,6 +925,9 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		if s.watcherCancel != nil {
 			s.watcherCancel()
 		}
+		if s.credSources != nil {
+			s.credSources.Stop()
+		}
 		if s.coreManager != nil {
 			s.coreManager.StopAutoRefresh()
 		}
@@ -694,6 +957,11 @@ func (s *Service) Shutdown(ctx context.Context) error {
 			}
 		}
 
+		internalusage.StopUsageExporter()
+		if err := cache.FlushPersister(); err != nil {
+			log.Errorf("failed to persist cache snapshot: %v", err)
+		}
+
 		// no legacy clients to persist
 
 		if s.server != nil {
@@ -708,6 +976,8 @@ func (s *Service) Shutdown(ctx context.Context) error {
 		}
 
 		usage.StopDefault()
+		// Save the usage stores after the last queued records have been recorded.
+		s.usageLifecycle.Stop()
 	})
 	return shutdownErr
 }
@@ -836,7 +1106,13 @@ func (s *Service) registerModelsForAuth(a *coreauth.Auth) {
 	case "kimi":
 		models = registry.GetKimiModels()
 		models = applyExcludedModels(models, excluded)
+	case "mock":
+		models = buildMockBackendModels(s.cfg)
 	default:
+		if reg, ok := sdkprovider.Lookup(provider); ok && !compatDetected {
+			models = applyExcludedModels(reg.ListModels(), excluded)
+			break
+		}
 		// Handle OpenAI-compatibility providers by name using config
 		if s.cfg != nil {
 			providerKey := provider
@@ -1268,6 +1544,32 @@ func buildCodexConfigModels(entry *config.CodexKey) []*ModelInfo {
 	return buildConfigModels(entry.Models, "openai", "openai")
 }
 
+// buildMockBackendModels lists the models served by the built-in mock provider.
+func buildMockBackendModels(cfg *config.Config) []*ModelInfo {
+	ids := []string{"mock-model"}
+	if cfg != nil && len(cfg.MockBackend.Models) > 0 {
+		ids = cfg.MockBackend.Models
+	}
+	now := time.Now().Unix()
+	out := make([]*ModelInfo, 0, len(ids))
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id == "" {
+			continue
+		}
+		out = append(out, &ModelInfo{
+			ID:          id,
+			Object:      "model",
+			Created:     now,
+			OwnedBy:     "mock",
+			Type:        "mock",
+			DisplayName: id,
+			UserDefined: true,
+		})
+	}
+	return out
+}
+
 func rewriteModelInfoName(name, oldID, newID string) string {
 	trimmed := strings.TrimSpace(name)
 	if trimmed == "" {
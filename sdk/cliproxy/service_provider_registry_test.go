@@ -0,0 +1,70 @@
+package cliproxy
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/executor"
+	sdkprovider "github.com/router-for-me/CLIProxyAPI/v6/sdk/cliproxy/provider"
+	"github.com/router-for-me/CLIProxyAPI/v6/sdk/config"
+)
+
+type registeredProviderExecutor struct{}
+
+func (registeredProviderExecutor) Identifier() string { return "longtail" }
+func (registeredProviderExecutor) Execute(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+func (registeredProviderExecutor) ExecuteStream(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
+	return nil, nil
+}
+func (registeredProviderExecutor) Refresh(_ context.Context, a *coreauth.Auth) (*coreauth.Auth, error) {
+	return a, nil
+}
+func (registeredProviderExecutor) CountTokens(context.Context, *coreauth.Auth, cliproxyexecutor.Request, cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{}, nil
+}
+func (registeredProviderExecutor) HttpRequest(context.Context, *coreauth.Auth, *http.Request) (*http.Response, error) {
+	return nil, nil
+}
+
+func TestRegisteredProviderBindsExecutorAndModels(t *testing.T) {
+	sdkprovider.MustRegister(sdkprovider.Registration{
+		Key:      "longtail",
+		Executor: func(*config.Config) coreauth.ProviderExecutor { return registeredProviderExecutor{} },
+		Models: func() []*ModelInfo {
+			return []*ModelInfo{{ID: "longtail-1", Object: "model"}, {ID: "longtail-2", Object: "model"}}
+		},
+	})
+	t.Cleanup(func() { sdkprovider.Unregister("longtail") })
+
+	service := &Service{
+		cfg:         &config.Config{},
+		coreManager: coreauth.NewManager(nil, nil, nil),
+	}
+	auth := &coreauth.Auth{
+		ID:         "auth-longtail",
+		Provider:   "longtail",
+		Status:     coreauth.StatusActive,
+		Attributes: map[string]string{"excluded_models": "longtail-2"},
+	}
+	registry := GlobalModelRegistry()
+	t.Cleanup(func() { registry.UnregisterClient(auth.ID) })
+
+	service.ensureExecutorsForAuth(auth)
+	exec, ok := service.coreManager.Executor("longtail")
+	if !ok {
+		t.Fatal("expected executor for registered provider")
+	}
+	if _, isRegistered := exec.(registeredProviderExecutor); !isRegistered {
+		t.Fatalf("executor = %T, want registeredProviderExecutor", exec)
+	}
+
+	service.registerModelsForAuth(auth)
+	models := registry.GetAvailableModelsByProvider("longtail")
+	if len(models) != 1 || models[0].ID != "longtail-1" {
+		t.Fatalf("models = %+v, want only longtail-1", models)
+	}
+}
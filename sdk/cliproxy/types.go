@@ -89,6 +89,7 @@ type WatcherWrapper struct {
 	snapshotAuths         func() []*coreauth.Auth
 	setUpdateQueue        func(queue chan<- watcher.AuthUpdate)
 	dispatchRuntimeUpdate func(update watcher.AuthUpdate) bool
+	rescanAuths           func() watcher.AuthRescanResult
 }
 
 // Start proxies to the underlying watcher Start implementation.
@@ -125,6 +126,14 @@ func (w *WatcherWrapper) DispatchRuntimeAuthUpdate(update watcher.AuthUpdate) bo
 	return w.dispatchRuntimeUpdate(update)
 }
 
+// RescanAuths re-reads the auth directory and applies credential changes live.
+func (w *WatcherWrapper) RescanAuths() watcher.AuthRescanResult {
+	if w == nil || w.rescanAuths == nil {
+		return watcher.AuthRescanResult{}
+	}
+	return w.rescanAuths()
+}
+
 // SetClients updates the watcher file-backed clients registry.
 // SetClients and SetAPIKeyClients removed; watcher manages its own caches
 
@@ -0,0 +1,73 @@
+package usage
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LatencyRecord captures the timing of a single upstream attempt.
+type LatencyRecord struct {
+	Provider    string
+	Model       string
+	AuthID      string
+	Source      string
+	RequestedAt time.Time
+	Stream      bool
+	Failed      bool
+	// StatusCode is the upstream HTTP status of a failed attempt, when known.
+	StatusCode int
+	// ErrorClass categorises a failed attempt; see the ErrorClass constants.
+	ErrorClass string
+	// FirstByte is the time until the first response payload was available. For
+	// non-streaming requests it equals Total.
+	FirstByte time.Duration
+	// Total is the time until the upstream response completed.
+	Total time.Duration
+}
+
+// Error classes reported in LatencyRecord.ErrorClass.
+const (
+	ErrorClassRateLimit      = "rate_limit"
+	ErrorClassAuth           = "auth"
+	ErrorClassServer         = "server"
+	ErrorClassTimeout        = "timeout"
+	ErrorClassInvalidRequest = "invalid_request"
+	ErrorClassOther          = "other"
+)
+
+// LatencyPlugin is implemented by plugins that also consume latency records.
+type LatencyPlugin interface {
+	HandleLatency(ctx context.Context, record LatencyRecord)
+}
+
+// PublishLatency enqueues a latency record for delivery to plugins implementing LatencyPlugin.
+func (m *Manager) PublishLatency(ctx context.Context, record LatencyRecord) {
+	if m == nil {
+		return
+	}
+	m.Start(context.Background())
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return
+	}
+	m.queue = append(m.queue, queueItem{ctx: ctx, latency: &record})
+	m.mu.Unlock()
+	m.cond.Signal()
+}
+
+func safeInvokeLatency(plugin LatencyPlugin, ctx context.Context, record LatencyRecord) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("usage: latency plugin panic recovered: %v", r)
+		}
+	}()
+	plugin.HandleLatency(ctx, record)
+}
+
+// PublishLatency publishes a latency record using the default manager.
+func PublishLatency(ctx context.Context, record LatencyRecord) {
+	DefaultManager().PublishLatency(ctx, record)
+}
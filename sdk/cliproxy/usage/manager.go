@@ -18,7 +18,75 @@ type Record struct {
 	Source      string
 	RequestedAt time.Time
 	Failed      bool
-	Detail      Detail
+	// Cancelled marks a request the client abandoned before it completed; Detail then
+	// holds the usage observed up to that point.
+	Cancelled bool
+	// SpillOver marks a request routed to a metered API-key credential because the
+	// subscription credentials were near their limits.
+	SpillOver bool
+	// DowngradedFrom is the model the client requested when the request was answered by a
+	// fallback model because that model's credentials were out of quota.
+	DowngradedFrom string
+	// Session identifies the client conversation the request belongs to, when known.
+	Session string
+	Detail  Detail
+}
+
+type spillOverContextKey struct{}
+
+// WithSpillOver marks usage published under ctx as spill-over from subscription credentials.
+func WithSpillOver(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, spillOverContextKey{}, true)
+}
+
+// SpillOver reports whether ctx was marked by WithSpillOver.
+func SpillOver(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	spilled, _ := ctx.Value(spillOverContextKey{}).(bool)
+	return spilled
+}
+
+type sessionContextKey struct{}
+
+// WithSession attributes usage published under ctx to the client conversation session.
+func WithSession(ctx context.Context, session string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, sessionContextKey{}, session)
+}
+
+// Session returns the conversation session set by WithSession, if any.
+func Session(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	session, _ := ctx.Value(sessionContextKey{}).(string)
+	return session
+}
+
+type downgradeContextKey struct{}
+
+// WithDowngrade marks usage published under ctx as served by a fallback for requestedModel.
+func WithDowngrade(ctx context.Context, requestedModel string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, downgradeContextKey{}, requestedModel)
+}
+
+// DowngradedFrom returns the requested model set by WithDowngrade, if any.
+func DowngradedFrom(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	model, _ := ctx.Value(downgradeContextKey{}).(string)
+	return model
 }
 
 // Detail holds the token usage breakdown.
@@ -28,6 +96,8 @@ type Detail struct {
 	ReasoningTokens int64
 	CachedTokens    int64
 	TotalTokens     int64
+	// CacheCreationTokens counts prompt tokens written to the provider cache (Claude).
+	CacheCreationTokens int64
 }
 
 // Plugin consumes usage records emitted by the proxy runtime.
@@ -36,8 +106,9 @@ type Plugin interface {
 }
 
 type queueItem struct {
-	ctx    context.Context
-	record Record
+	ctx     context.Context
+	record  Record
+	latency *LatencyRecord
 }
 
 // Manager maintains a queue of usage records and delivers them to registered plugins.
@@ -109,6 +180,15 @@ func (m *Manager) Publish(ctx context.Context, record Record) {
 	if m == nil {
 		return
 	}
+	if SpillOver(ctx) {
+		record.SpillOver = true
+	}
+	if record.DowngradedFrom == "" {
+		record.DowngradedFrom = DowngradedFrom(ctx)
+	}
+	if record.Session == "" {
+		record.Session = Session(ctx)
+	}
 	// ensure worker is running even if Start was not called explicitly
 	m.Start(context.Background())
 	m.mu.Lock()
@@ -150,6 +230,12 @@ func (m *Manager) dispatch(item queueItem) {
 		if plugin == nil {
 			continue
 		}
+		if item.latency != nil {
+			if lp, ok := plugin.(LatencyPlugin); ok {
+				safeInvokeLatency(lp, item.ctx, *item.latency)
+			}
+			continue
+		}
 		safeInvoke(plugin, item.ctx, item.record)
 	}
 }
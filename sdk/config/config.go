@@ -11,6 +11,19 @@ type SDKConfig = internalconfig.SDKConfig
 type Config = internalconfig.Config
 
 type StreamingConfig = internalconfig.StreamingConfig
+type StreamFlushOverride = internalconfig.StreamFlushOverride
+type ModelRoutingConfig = internalconfig.ModelRoutingConfig
+type ModelRouteRule = internalconfig.ModelRouteRule
+type ModelFallbackConfig = internalconfig.ModelFallbackConfig
+type RequestSamplingConfig = internalconfig.RequestSamplingConfig
+type RequestLimitsConfig = internalconfig.RequestLimitsConfig
+type SessionLimitsConfig = internalconfig.SessionLimitsConfig
+type LoopDetectionConfig = internalconfig.LoopDetectionConfig
+type LoopDetectionOverride = internalconfig.LoopDetectionOverride
+type RoutingSchedule = internalconfig.RoutingSchedule
+type RoutingScript = internalconfig.RoutingScript
+type APIKeyPolicy = internalconfig.APIKeyPolicy
+type ParameterProfile = internalconfig.ParameterProfile
 type TLSConfig = internalconfig.TLSConfig
 type RemoteManagement = internalconfig.RemoteManagement
 type AmpCode = internalconfig.AmpCode
@@ -19,6 +32,10 @@ type PayloadConfig = internalconfig.PayloadConfig
 type PayloadRule = internalconfig.PayloadRule
 type PayloadFilterRule = internalconfig.PayloadFilterRule
 type PayloadModelRule = internalconfig.PayloadModelRule
+type ModelParameterRule = internalconfig.ModelParameterRule
+type ModelParameters = internalconfig.ModelParameters
+type ModelParameterClamp = internalconfig.ModelParameterClamp
+type ParameterRange = internalconfig.ParameterRange
 
 type GeminiKey = internalconfig.GeminiKey
 type CodexKey = internalconfig.CodexKey
@@ -33,6 +50,17 @@ type TLS = internalconfig.TLSConfig
 
 const (
 	DefaultPanelGitHubRepository = internalconfig.DefaultPanelGitHubRepository
+
+	UnknownModelReject      = internalconfig.UnknownModelReject
+	UnknownModelDefault     = internalconfig.UnknownModelDefault
+	UnknownModelPassthrough = internalconfig.UnknownModelPassthrough
+
+	StreamFlushImmediate = internalconfig.StreamFlushImmediate
+	StreamFlushLine      = internalconfig.StreamFlushLine
+	StreamFlushCoalesce  = internalconfig.StreamFlushCoalesce
+
+	LoopDetectionWarn      = internalconfig.LoopDetectionWarn
+	LoopDetectionTerminate = internalconfig.LoopDetectionTerminate
 )
 
 func LoadConfig(configFile string) (*Config, error) { return internalconfig.LoadConfig(configFile) }
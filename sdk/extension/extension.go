@@ -0,0 +1,132 @@
+// Package extension exposes hook points for projects embedding CLIProxyAPI as a library.
+//
+// Hooks are registered globally, typically from an init function or before the service
+// starts, and let callers add billing, logging or routing logic without forking the
+// internal packages:
+//
+//   - RegisterMiddleware adds Gin middleware to every HTTP route.
+//   - RegisterRequestMutator rewrites the client-format request before each upstream attempt.
+//   - RegisterResponseMutator rewrites the client-format response and every stream chunk.
+package extension
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestInfo describes the upstream attempt a mutator runs for.
+type RequestInfo struct {
+	// Provider is the provider key of the selected credential, e.g. "claude".
+	Provider string
+	// AuthID identifies the selected credential.
+	AuthID string
+	// Model is the model sent to the provider after alias resolution.
+	Model string
+	// SourceFormat is the schema the client spoke, e.g. "openai" or "claude".
+	SourceFormat string
+	// Stream reports whether the client requested a streaming response.
+	Stream bool
+	// ClientAPIKey is the API key the client authenticated with, if any.
+	ClientAPIKey string
+}
+
+// RequestMutator rewrites a client-format request payload before it is translated and
+// sent upstream. Returning an error aborts the request with that error; errors that
+// implement StatusCode() int are reported to the client with that status.
+type RequestMutator func(ctx context.Context, info RequestInfo, payload []byte) ([]byte, error)
+
+// ResponseMutator rewrites a client-format response payload. For streaming requests it
+// is called once per chunk.
+type ResponseMutator func(ctx context.Context, info RequestInfo, payload []byte) []byte
+
+var hooks struct {
+	sync.RWMutex
+	middleware []gin.HandlerFunc
+	requests   []RequestMutator
+	responses  []ResponseMutator
+}
+
+// RegisterMiddleware appends Gin middleware applied to every route of servers built
+// after the call, after the built-in logging and recovery middleware.
+func RegisterMiddleware(mw ...gin.HandlerFunc) {
+	hooks.Lock()
+	defer hooks.Unlock()
+	for _, fn := range mw {
+		if fn != nil {
+			hooks.middleware = append(hooks.middleware, fn)
+		}
+	}
+}
+
+// RegisterRequestMutator appends a request mutator. Mutators run in registration order,
+// each receiving the previous one's output.
+func RegisterRequestMutator(fn RequestMutator) {
+	if fn == nil {
+		return
+	}
+	hooks.Lock()
+	defer hooks.Unlock()
+	hooks.requests = append(hooks.requests, fn)
+}
+
+// RegisterResponseMutator appends a response mutator. Mutators run in registration order,
+// each receiving the previous one's output.
+func RegisterResponseMutator(fn ResponseMutator) {
+	if fn == nil {
+		return
+	}
+	hooks.Lock()
+	defer hooks.Unlock()
+	hooks.responses = append(hooks.responses, fn)
+}
+
+// Middleware returns the registered Gin middleware.
+func Middleware() []gin.HandlerFunc {
+	hooks.RLock()
+	defer hooks.RUnlock()
+	return append([]gin.HandlerFunc(nil), hooks.middleware...)
+}
+
+// HasMutators reports whether any request or response mutator is registered.
+func HasMutators() bool {
+	hooks.RLock()
+	defer hooks.RUnlock()
+	return len(hooks.requests) > 0 || len(hooks.responses) > 0
+}
+
+// MutateRequest runs the registered request mutators over payload.
+func MutateRequest(ctx context.Context, info RequestInfo, payload []byte) ([]byte, error) {
+	hooks.RLock()
+	mutators := hooks.requests
+	hooks.RUnlock()
+	for _, fn := range mutators {
+		out, err := fn(ctx, info, payload)
+		if err != nil {
+			return nil, err
+		}
+		payload = out
+	}
+	return payload, nil
+}
+
+// MutateResponse runs the registered response mutators over payload.
+func MutateResponse(ctx context.Context, info RequestInfo, payload []byte) []byte {
+	hooks.RLock()
+	mutators := hooks.responses
+	hooks.RUnlock()
+	for _, fn := range mutators {
+		payload = fn(ctx, info, payload)
+	}
+	return payload
+}
+
+// Reset removes every registered hook. It is intended for tests.
+func Reset() {
+	hooks.Lock()
+	defer hooks.Unlock()
+	hooks.middleware = nil
+	hooks.requests = nil
+	hooks.responses = nil
+}
@@ -0,0 +1,31 @@
+package translator
+
+import "context"
+
+// ResponseObserver receives the inputs and outputs of every response translation
+// performed with a context that carries it, e.g. to sample traffic for offline replay.
+// clientFormat is the schema the client spoke and upstreamFormat the provider schema.
+type ResponseObserver interface {
+	ObserveResponse(clientFormat, upstreamFormat Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, translated []string, stream bool)
+}
+
+type responseObserverKey struct{}
+
+// WithResponseObserver returns a context whose response translations are reported to observer.
+func WithResponseObserver(ctx context.Context, observer ResponseObserver) context.Context {
+	if ctx == nil || observer == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, responseObserverKey{}, observer)
+}
+
+func observeResponse(ctx context.Context, clientFormat, upstreamFormat Format, model string, originalRequestRawJSON, requestRawJSON, rawJSON []byte, translated []string, stream bool) {
+	if ctx == nil {
+		return
+	}
+	observer, ok := ctx.Value(responseObserverKey{}).(ResponseObserver)
+	if !ok || observer == nil {
+		return
+	}
+	observer.ObserveResponse(clientFormat, upstreamFormat, model, originalRequestRawJSON, requestRawJSON, rawJSON, translated, stream)
+}
@@ -72,7 +72,9 @@ func (r *Registry) TranslateStream(ctx context.Context, from, to Format, model s
 
 	if byTarget, ok := r.responses[to]; ok {
 		if fn, isOk := byTarget[from]; isOk && fn.Stream != nil {
-			return fn.Stream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+			out := fn.Stream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+			observeResponse(ctx, to, from, model, originalRequestRawJSON, requestRawJSON, rawJSON, out, true)
+			return out
 		}
 	}
 	return []string{string(rawJSON)}
@@ -85,7 +87,9 @@ func (r *Registry) TranslateNonStream(ctx context.Context, from, to Format, mode
 
 	if byTarget, ok := r.responses[to]; ok {
 		if fn, isOk := byTarget[from]; isOk && fn.NonStream != nil {
-			return fn.NonStream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+			out := fn.NonStream(ctx, model, originalRequestRawJSON, requestRawJSON, rawJSON, param)
+			observeResponse(ctx, to, from, model, originalRequestRawJSON, requestRawJSON, rawJSON, []string{out}, false)
+			return out
 		}
 	}
 	return string(rawJSON)
@@ -0,0 +1,37 @@
+package test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v6/internal/replay"
+	_ "github.com/router-for-me/CLIProxyAPI/v6/internal/translator"
+)
+
+// TestReplay re-runs recorded request samples through the translators and reports any
+// drift from the recorded output. Samples are read from testdata/replay, or from the
+// directory in CLIPROXY_REPLAY_DIR (e.g. the request-sampling dir of a running proxy):
+//
+//	CLIPROXY_REPLAY_DIR=./samples go test ./test -run Replay
+func TestReplay(t *testing.T) {
+	dir := strings.TrimSpace(os.Getenv("CLIPROXY_REPLAY_DIR"))
+	if dir == "" {
+		dir = "testdata/replay"
+	}
+	samples, err := replay.LoadSamples(dir)
+	if err != nil {
+		t.Fatalf("load samples from %s: %v", dir, err)
+	}
+	if len(samples) == 0 {
+		t.Skipf("no samples in %s", dir)
+	}
+	for _, sample := range samples {
+		t.Run(sample.From+"->"+sample.To+"/"+sample.ID, func(t *testing.T) {
+			if diffs := replay.Replay(context.Background(), sample); len(diffs) > 0 {
+				t.Errorf("sample %s drifted from its recording:\n%s", sample.ID, strings.Join(diffs, "\n"))
+			}
+		})
+	}
+}